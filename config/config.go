@@ -2,7 +2,6 @@ package config
 
 import (
 	"log"
-	"os"
 
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
@@ -10,11 +9,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var PrivateKey string = os.Getenv("PRKEY")
+var PrivateKey string = devEnv("PRKEY", DevPrivateKey)
 
 var IPPort, Net = at.GetAddress()
 
-var PhoneNumber string = os.Getenv("PHONENUMBER")
+var PhoneNumber string = devEnv("PHONENUMBER", DevPhoneNumber)
 
 func SetEnv() {
 	if ErrorMongoconn != nil {
@@ -23,7 +23,7 @@ func isAllowedOrigin(origin string) bool {
 			return true
 		}
 	}
-	return false
+	return isDevOrigin(origin)
 }
 
 // Fungsi untuk mengatur header CORS
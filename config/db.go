@@ -1,12 +1,10 @@
 package config
 
 import (
-	"os"
-
 	"github.com/gocroot/helper/atdb"
 )
 
-var MongoString string = os.Getenv("MONGOSTRING")
+var MongoString string = devEnv("MONGOSTRING", "mongodb://localhost:27017")
 
 var mongoinfo = atdb.DBInfo{
 	DBString: MongoString,
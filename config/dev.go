@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Dev aktif jika DEVMODE diisi. run/main.go lalu memasang server tiruan untuk WA API dan layanan luar lain,
+// mengisi MongoDB lokal dengan fixture dan membuka endpoint /dev untuk melihat pesan WA yang tertahan.
+var Dev bool = os.Getenv("DEVMODE") != ""
+
+// DevFakeAddr adalah alamat server tiruan lokal, semua request keluar dialihkan ke sini pada mode dev
+var DevFakeAddr string = devEnv("DEVFAKEADDR", "127.0.0.1:8081")
+
+// DevStubDir adalah folder stub tambahan, file di sini didahulukan dari stub bawaan helper/devmode/stub
+var DevStubDir string = os.Getenv("DEVSTUBDIR")
+
+// Kunci token dan nomor bot khusus mode dev dan test, jangan dipakai di produksi.
+// Fixture profile memakai DevPublicKey sehingga token dari /dev/token lolos watoken.Decode.
+const (
+	DevPrivateKey  = "22d3c7d590d73c0099deb8a88461a292eb0b50d82ff6c052fcf39da8926fd2557e80fa6d3f7315fd51872aecf90ee42888d797745fc0122b1d091c9558ebe40e"
+	DevPublicKey   = "7e80fa6d3f7315fd51872aecf90ee42888d797745fc0122b1d091c9558ebe40e"
+	DevPhoneNumber = "6281100000000"
+)
+
+// devEnv membaca environment, nilai bawaan hanya dipakai pada mode dev supaya produksi tetap gagal jika env kosong
+func devEnv(key, devDefault string) string {
+	if v := os.Getenv(key); v != "" || !Dev {
+		return v
+	}
+	return devDefault
+}
+
+// isDevOrigin mengizinkan frontend yang dijalankan di localhost selama mode dev
+func isDevOrigin(origin string) bool {
+	return Dev && (strings.HasPrefix(origin, "http://localhost:") || strings.HasPrefix(origin, "http://127.0.0.1:"))
+}
@@ -1,24 +1,33 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/scoring"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func GetAllActivityScore(w http.ResponseWriter, r *http.Request) {
 	authorization, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -31,12 +40,12 @@ func GetAllActivityScore(w http.ResponseWriter, r *http.Request) {
 func GetLastWeekActivityScore(w http.ResponseWriter, r *http.Request) {
 	authorization, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -46,124 +55,237 @@ func GetLastWeekActivityScore(w http.ResponseWriter, r *http.Request) {
 	at.WriteJSON(w, http.StatusOK, score)
 }
 
+// Batas waktu satu sumber data skor, sumber yang lebih lambat ditandai timeout dan skornya tidak dihitung
+const batasWaktuSumberSkor = 10 * time.Second
+
+// sumberSkor adalah satu sumber data ActivityScore. isi menyalin field milik sumber ini ke skor gabungan.
+// ambil menerima ctx yang dibatalkan setelah batasWaktuSumberSkor dan meneruskannya ke query Mongo dan request HTTP.
+type sumberSkor struct {
+	nama  string
+	ambil func(ctx context.Context) (model.ActivityScore, error)
+	isi   func(score *model.ActivityScore, data model.ActivityScore)
+}
+
 // helper function
 func GetAllActivityScoreData(userID string) (model.ActivityScore, error) {
-	var score model.ActivityScore
-
-	datasponsor, _ := GetAllDataSponsorPoin(config.Mongoconn, userID)
-	datatracker, _ := report.GetAllDataTracker(config.Mongoconn, GetHostnameFromProject(userID))
-	datastravapoin, _ := report.GetAllDataStravaPoin(config.Mongoconn, userID)
-	dataWebhook, _ := report.GetAllWebhookPoin(config.Mongoconn, userID)
-	dataPresensi, _ := report.GetAllPresensiPoin(config.Mongoconn, userID)
-	dataPomokitScore, _ := GetPomokitScoreForUser(userID)
-	dataIQ, _ := GetAllDataIQScore(config.Mongoconn, userID)
-	bukpedMemberScore, _ := GetBukpedScoreForUser(userID) // Using Bukupedia function
-	dataGTMetrixScore, _ := GetGTMetrixScoreForUser(userID)
-	dataMicroBitcoin, _ := GetAllDataMicroBitcoinScore(config.Mongoconn, userID)
-	dataRavencoin, _ := GetAllDataRavencoinScore(config.Mongoconn, userID)
-	dataQRIS, _ := GetAllDataQRISScore(config.Mongoconn, userID)
-
-	totalScore := HitungTotalScore(&score)
-
-	score = model.ActivityScore{
-		Sponsordata:     datasponsor.Sponsordata,
-		Sponsor:         datasponsor.Sponsor,
-		Trackerdata:     datatracker.Trackerdata,
-		Tracker:         datatracker.Tracker,
-		StravaKM:        datastravapoin.StravaKM,
-		Strava:          datastravapoin.Strava,
-		IQresult:        dataIQ.IQresult,
-		IQ:              dataIQ.IQ,
-		Pomokitsesi:     dataPomokitScore.Pomokitsesi,
-		Pomokit:         dataPomokitScore.Pomokit,
-		GTMetrixResult:  dataGTMetrixScore.GTMetrixResult,
-		BukuKatalog:     bukpedMemberScore.BukuKatalog,
-		BukPed:          bukpedMemberScore.BukPed,
-		GTMetrix:        dataGTMetrixScore.GTMetrix,
-		WebHookpush:     dataWebhook.WebHookpush,
-		WebHook:         dataWebhook.WebHook,
-		PresensiHari:    dataPresensi.PresensiHari,
-		Presensi:        dataPresensi.Presensi,
-		MBC:             dataMicroBitcoin.MBC,
-		MBCPoints:       dataMicroBitcoin.MBCPoints,
-		BlockChain:      dataMicroBitcoin.BlockChain,
-		RVN:             dataRavencoin.RVN,
-		RavencoinPoints: dataRavencoin.RavencoinPoints,
-		Rupiah:          dataQRIS.Rupiah,
-		QRIS:            dataQRIS.QRIS,
-		QRISPoints:      dataQRIS.QRISPoints,
-		TotalScore:      totalScore,
-	}
+	hostnames := GetHostnameFromProject(userID)
+	score := kumpulkanSkor([]sumberSkor{
+		{"sponsor", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataSponsorPoin(ctx, config.Mongoconn, userID)
+		}, isiSponsor},
+		{"tracker", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetAllDataTracker(ctx, config.Mongoconn, hostnames)
+		}, isiTracker},
+		{"strava", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetAllDataStravaPoin(ctx, config.Mongoconn, userID)
+		}, isiStrava},
+		{"webhook", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetAllWebhookPoin(ctx, config.Mongoconn, userID)
+		}, isiWebhook},
+		{"presensi", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetAllPresensiPoin(ctx, config.Mongoconn, userID)
+		}, isiPresensi},
+		{"pomokit", func(ctx context.Context) (model.ActivityScore, error) { return GetPomokitScoreForUser(ctx, userID) }, isiPomokit},
+		{"iq", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataIQScore(ctx, config.Mongoconn, userID)
+		}, isiIQ},
+		{"bukped", func(ctx context.Context) (model.ActivityScore, error) { return GetBukpedScoreForUser(ctx, userID) }, isiBukped},
+		{"gtmetrix", func(ctx context.Context) (model.ActivityScore, error) { return GetGTMetrixScoreForUser(ctx, userID) }, isiGTMetrix},
+		{"mbc", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataMicroBitcoinScore(ctx, config.Mongoconn, userID)
+		}, isiMicroBitcoin},
+		{"rvn", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataRavencoinScore(ctx, config.Mongoconn, userID)
+		}, isiRavencoin},
+		{"qris", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataQRISScore(ctx, config.Mongoconn, userID)
+		}, isiQRIS},
+	})
+
+	rules, version := scoring.RulesForEnroll(config.Mongoconn, GetEnrollFromProject(userID))
+	score.TotalScore = scoring.Apply(&score, rules, report.JumlahMinggu())
+	score.RuleVersion = version
 
 	return score, nil
 }
 
 func GetLastWeekActivityScoreData(userID string) (model.ActivityScore, error) {
-	var score model.ActivityScore
-	datasponsor, _ := GetAllDataSponsorPoin(config.Mongoconn, userID)
-	datatracker, _ := report.GetLastWeekDataTracker(config.Mongoconn, GetHostnameFromProject(userID))
-	datastravapoin, _ := report.GetLastWeekDataStravaPoin(config.Mongoconn, userID, "proyek1")
-	dataIQ, _ := report.GetLastWeekDataIQScoress(config.Mongoconn, userID, "proyek1")
-	dataPresensi, _ := report.GetLastWeekPresensiPoin(config.Mongoconn, userID)
-	dataWebhook, _ := report.GetLastWeekWebhookPoin(config.Mongoconn, userID)
-	dataPomokitScore, _ := GetLastWeekPomokitScoreForUser(userID)
-	bukpedMemberScore, _ := GetLastWeekBukpedScoreForUser(userID) // Using Bukupedia function
-	dataGTMetrixScore, _ := GetLastWeekGTMetrixScoreForUser(userID)
-	dataMicroBitcoin, _ := GetLastWeekDataMicroBitcoinScore(config.Mongoconn, userID)
-	dataRavencoin, _ := GetLastWeekDataRavencoinScore(config.Mongoconn, userID)
-	dataQRIS, _ := GetLastWeekDataQRISScore(config.Mongoconn, userID)
-
-	totalScore := HitungTotalScore(&score)
-
-	score = model.ActivityScore{
-		Sponsordata:     datasponsor.Sponsordata,
-		Sponsor:         datasponsor.Sponsor,
-		Trackerdata:     datatracker.Trackerdata,
-		Tracker:         datatracker.Tracker,
-		StravaKM:        datastravapoin.StravaKM,
-		Strava:          datastravapoin.Strava,
-		IQresult:        dataIQ.IQresult,
-		IQ:              dataIQ.IQ,
-		Pomokitsesi:     dataPomokitScore.Pomokitsesi,
-		Pomokit:         dataPomokitScore.Pomokit,
-		GTMetrixResult:  dataGTMetrixScore.GTMetrixResult,
-		BukuKatalog:     bukpedMemberScore.BukuKatalog,
-		BukPed:          bukpedMemberScore.BukPed,
-		GTMetrix:        dataGTMetrixScore.GTMetrix,
-		PresensiHari:    dataPresensi.PresensiHari,
-		Presensi:        dataPresensi.Presensi,
-		WebHookpush:     dataWebhook.WebHookpush,
-		WebHook:         dataWebhook.WebHook,
-		MBC:             dataMicroBitcoin.MBC,
-		MBCPoints:       dataMicroBitcoin.MBCPoints,
-		BlockChain:      dataMicroBitcoin.BlockChain,
-		RVN:             dataRavencoin.RVN,
-		RavencoinPoints: dataRavencoin.RavencoinPoints,
-		Rupiah:          dataQRIS.Rupiah,
-		QRIS:            dataQRIS.QRIS,
-		QRISPoints:      dataQRIS.QRISPoints,
-		TotalScore:      totalScore,
-	}
+	hostnames := GetHostnameFromProject(userID)
+	score := kumpulkanSkor([]sumberSkor{
+		{"sponsor", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetAllDataSponsorPoin(ctx, config.Mongoconn, userID)
+		}, isiSponsor},
+		{"tracker", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetLastWeekDataTracker(ctx, config.Mongoconn, hostnames)
+		}, isiTracker},
+		{"strava", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetLastWeekDataStravaPoin(ctx, config.Mongoconn, userID, "proyek1")
+		}, isiStrava},
+		{"iq", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetLastWeekDataIQScoress(ctx, config.Mongoconn, userID, "proyek1")
+		}, isiIQ},
+		{"presensi", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetLastWeekPresensiPoin(ctx, config.Mongoconn, userID)
+		}, isiPresensi},
+		{"webhook", func(ctx context.Context) (model.ActivityScore, error) {
+			return report.GetLastWeekWebhookPoin(ctx, config.Mongoconn, userID)
+		}, isiWebhook},
+		{"pomokit", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekPomokitScoreForUser(ctx, userID)
+		}, isiPomokit},
+		{"bukped", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekBukpedScoreForUser(ctx, userID)
+		}, isiBukped},
+		{"gtmetrix", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekGTMetrixScoreForUser(ctx, userID)
+		}, isiGTMetrix},
+		{"mbc", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekDataMicroBitcoinScore(ctx, config.Mongoconn, userID)
+		}, isiMicroBitcoin},
+		{"rvn", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekDataRavencoinScore(ctx, config.Mongoconn, userID)
+		}, isiRavencoin},
+		{"qris", func(ctx context.Context) (model.ActivityScore, error) {
+			return GetLastWeekDataQRISScore(ctx, config.Mongoconn, userID)
+		}, isiQRIS},
+	})
+
+	rules, version := scoring.RulesForEnroll(config.Mongoconn, GetEnrollFromProject(userID))
+	score.TotalScore = scoring.Apply(&score, rules, 1)
+	score.RuleVersion = version
 
 	return score, nil
 }
 
+// kumpulkanSkor memanggil semua sumber bersamaan, masing-masing dibatasi batasWaktuSumberSkor lewat context
+// sehingga query dan request sumber yang timeout ikut dihentikan, bukan ditinggal berjalan di background.
+// Status setiap sumber dicatat di score.Sources sehingga sumber yang gagal tidak terlihat sebagai skor 0.
+func kumpulkanSkor(sources []sumberSkor) (score model.ActivityScore) {
+	type hasil struct {
+		data   model.ActivityScore
+		status model.ActivityScoreSource
+	}
+	results := make([]chan hasil, len(sources))
+	for i, src := range sources {
+		results[i] = make(chan hasil, 1)
+		go func(src sumberSkor, out chan<- hasil) {
+			mulai := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), batasWaktuSumberSkor)
+			defer cancel()
+			selesai := make(chan hasil, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						selesai <- hasil{status: model.ActivityScoreSource{Status: model.SourceError, Error: fmt.Sprintf("panic: %v", r)}}
+					}
+				}()
+				data, err := src.ambil(ctx)
+				h := hasil{data: data, status: model.ActivityScoreSource{Status: model.SourceOK}}
+				//belum ada data bukan kegagalan sumber
+				if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+					h.status = model.ActivityScoreSource{Status: model.SourceError, Error: err.Error()}
+				}
+				selesai <- h
+			}()
+			var h hasil
+			select {
+			case h = <-selesai:
+			case <-ctx.Done():
+			}
+			//sumber yang berhenti karena ctx habis tetap dicatat timeout, bukan error
+			if ctx.Err() != nil && h.status.Status != model.SourceOK {
+				h.status = model.ActivityScoreSource{Status: model.SourceTimeout, Error: "tidak merespon dalam " + batasWaktuSumberSkor.String()}
+			}
+			h.status.Source = src.nama
+			h.status.DurationMs = time.Since(mulai).Milliseconds()
+			out <- h
+		}(src, results[i])
+	}
+	for i, src := range sources {
+		h := <-results[i]
+		if h.status.Status == model.SourceOK {
+			src.isi(&score, h.data)
+		}
+		score.Sources = append(score.Sources, h.status)
+	}
+	return score
+}
+
+func isiSponsor(score *model.ActivityScore, data model.ActivityScore) {
+	score.Sponsordata = data.Sponsordata
+	score.Sponsor = data.Sponsor
+}
+
+func isiTracker(score *model.ActivityScore, data model.ActivityScore) {
+	score.Trackerdata = data.Trackerdata
+	score.Tracker = data.Tracker
+}
+
+func isiStrava(score *model.ActivityScore, data model.ActivityScore) {
+	score.StravaKM = data.StravaKM
+	score.Strava = data.Strava
+}
+
+func isiWebhook(score *model.ActivityScore, data model.ActivityScore) {
+	score.WebHookpush = data.WebHookpush
+	score.WebHook = data.WebHook
+}
+
+func isiPresensi(score *model.ActivityScore, data model.ActivityScore) {
+	score.PresensiHari = data.PresensiHari
+	score.Presensi = data.Presensi
+}
+
+func isiPomokit(score *model.ActivityScore, data model.ActivityScore) {
+	score.Pomokitsesi = data.Pomokitsesi
+	score.Pomokit = data.Pomokit
+}
+
+func isiIQ(score *model.ActivityScore, data model.ActivityScore) {
+	score.IQresult = data.IQresult
+	score.IQ = data.IQ
+}
+
+func isiBukped(score *model.ActivityScore, data model.ActivityScore) {
+	score.BukuKatalog = data.BukuKatalog
+	score.BukPed = data.BukPed
+}
+
+func isiGTMetrix(score *model.ActivityScore, data model.ActivityScore) {
+	score.GTMetrixResult = data.GTMetrixResult
+	score.GTMetrix = data.GTMetrix
+}
+
+func isiMicroBitcoin(score *model.ActivityScore, data model.ActivityScore) {
+	score.MBC = data.MBC
+	score.MBCPoints = data.MBCPoints
+	score.BlockChain = data.BlockChain
+}
+
+func isiRavencoin(score *model.ActivityScore, data model.ActivityScore) {
+	score.RVN = data.RVN
+	score.RavencoinPoints = data.RavencoinPoints
+}
+
+func isiQRIS(score *model.ActivityScore, data model.ActivityScore) {
+	score.Rupiah = data.Rupiah
+	score.QRIS = data.QRIS
+	score.QRISPoints = data.QRISPoints
+}
+
+// HitungTotalScore menjumlahkan skor komponen dengan aturan bawaan
 func HitungTotalScore(a *model.ActivityScore) int {
-	total := 0
-
-	total += a.Sponsor
-	total += a.Strava
-	total += a.IQ
-	total += a.Pomokit
-	total += a.BlockChain
-	total += a.Rupiah
-	total += a.QRIS
-	total += int(a.Tracker)
-	total += a.BukPed
-	total += a.Jurnal
-	total += a.GTMetrix
-	total += a.WebHook
-	total += a.Presensi
-
-	return total
+	return scoring.Apply(a, scoring.DefaultRules(), 1)
+}
+
+// GetEnrollFromProject mengambil kode MasterEnrool dari proyek pertama user yang punya enroll
+func GetEnrollFromProject(nomorhp string) string {
+	filter := primitive.M{"$or": []primitive.M{{"members.phonenumber": nomorhp}, {"owner.phonenumber": nomorhp}}}
+	projects, _ := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", filter)
+	for _, p := range projects {
+		if p.Enroll != "" {
+			return p.Enroll
+		}
+	}
+	return ""
 }
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/auth"
 	"github.com/gocroot/helper/watoken"
@@ -25,27 +27,21 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 		Token string `json:"token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid request"})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Invalid request", ""))
 		return
 	}
 
 	// Ambil kredensial dari database
 	creds, err := atdb.GetOneDoc[auth.GoogleCredential](config.Mongoconn, "credentials", bson.M{})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Database Connection Problem: Unable to fetch credentials"})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Database Connection Problem: Unable to fetch credentials", ""))
 		return
 	}
 
 	// Verifikasi ID token menggunakan client_id
 	payload, err := auth.VerifyIDToken(request.Token, creds.ClientID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid token: Token verification failed"})
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Invalid token: Token verification failed", ""))
 		return
 	}
 
@@ -66,21 +62,15 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 	err = collection.FindOne(ctx, filter).Decode(&existingUser)
 	if err != nil || existingUser.PhoneNumber == "" {
 		// User does not exist or exists but has no phone number, request QR scan
-		response := map[string]interface{}{
-			"message": "Please scan the QR code to provide your phone number",
-			"user":    userInfo,
-			"token":   "",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status: "Please scan the QR code to provide your phone number",
+			Data:   userInfo,
+		}))
 		return
 	} else if existingUser.PhoneNumber != "" {
 		token, err := watoken.EncodeforHours(existingUser.PhoneNumber, existingUser.Name, config.PrivateKey, 18) // Generating a token for 18 hours
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Token generation failed"})
+			at.WriteError(w, r, apperr.New(apperr.Internal, "Token generation failed", ""))
 			return
 		}
 		response := map[string]interface{}{
@@ -100,9 +90,7 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 	opts := options.Update().SetUpsert(true)
 	_, err = collection.UpdateOne(ctx, filter, update, opts)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save user info: Database update failed"})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Failed to save user info: Database update failed", ""))
 		return
 	}
 
@@ -123,11 +111,11 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Invalid Request"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 	// Validate CAPTCHA
-	captchaResponse, err := http.PostForm("https://challenges.cloudflare.com/turnstile/v0/siteverify", url.Values{
+	captchaResponse, err := atapi.NewClient(0).PostForm("https://challenges.cloudflare.com/turnstile/v0/siteverify", url.Values{
 		"secret":   {"0x4AAAAAAAfj2NjfaHRBhkd2VjcfmRe5gvI"},
 		"response": {request.Captcha},
 	})
@@ -135,7 +123,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to verify captcha"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusServiceUnavailable, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Upstream, respn))
 		return
 	}
 	defer captchaResponse.Body.Close()
@@ -147,14 +135,14 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to decode captcha response"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Upstream, respn))
 		return
 	}
 	if !captchaResult.Success {
 		var respn model.Response
 		respn.Status = "Unauthorized"
 		respn.Response = "Invalid captcha"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -164,7 +152,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Bad Request"
 		respn.Response = "Invalid phone number format"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -175,7 +163,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Unauthorized"
 		respn.Response = "Phone number not registered"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -185,7 +173,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to generate password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Internal, respn))
 		return
 	}
 
@@ -195,7 +183,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to hash password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Internal, respn))
 		return
 	}
 
@@ -216,7 +204,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 			var respn model.Response
 			respn.Status = "Failed to insert new user"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, r, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		responseMessage = "New user created and password generated successfully"
@@ -232,7 +220,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 			var respn model.Response
 			respn.Status = "Failed to update user"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, r, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		responseMessage = "User info updated and password generated successfully"
@@ -246,7 +234,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	at.WriteJSON(respw, http.StatusOK, response)
 
 	// Send the random password via WhatsApp
-	auth.SendWhatsAppPassword(respw, request.PhoneNumber, randomPassword)
+	auth.SendWhatsAppPassword(respw, r, request.PhoneNumber, randomPassword)
 }
 
 var (
@@ -262,7 +250,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Invalid Request"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -272,7 +260,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Too Many Requests"
 		respn.Response = "Please try again later."
-		at.WriteJSON(respw, http.StatusTooManyRequests, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.RateLimited, respn))
 		return
 	}
 
@@ -283,7 +271,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to verify password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -292,7 +280,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Unauthorized"
 		respn.Response = "Password Expired"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -301,7 +289,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to verify password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -312,7 +300,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Unauthorized"
 		respn.Response = "Phone number not registered"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.InvalidCredentials, respn))
 		return
 	}
 
@@ -321,7 +309,7 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to give the token"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Internal, respn))
 		return
 	}
 
@@ -343,7 +331,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Invalid Request"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -353,7 +341,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to generate password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Internal, respn))
 		return
 	}
 
@@ -363,7 +351,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to hash password"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Internal, respn))
 		return
 	}
 
@@ -383,7 +371,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 			var respn model.Response
 			respn.Status = "Failed to insert new user"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, r, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		responseMessage := "New user created and password generated successfully"
@@ -396,13 +384,13 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		at.WriteJSON(respw, http.StatusOK, response)
 
 		// Send the random password via WhatsApp
-		auth.SendWhatsAppPassword(respw, request.PhoneNumber, randomPassword)
+		auth.SendWhatsAppPassword(respw, r, request.PhoneNumber, randomPassword)
 		return
 	} else if stpErr != nil {
 		var respn model.Response
 		respn.Status = "Failed to fetch user info"
 		respn.Response = stpErr.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -417,7 +405,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Failed to update user"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	responseMessage := "User info updated and password generated successfully"
@@ -430,5 +418,5 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	at.WriteJSON(respw, http.StatusOK, response)
 
 	// Send the random password via WhatsApp
-	auth.SendWhatsAppPassword(respw, request.PhoneNumber, randomPassword)
+	auth.SendWhatsAppPassword(respw, r, request.PhoneNumber, randomPassword)
 }
@@ -8,10 +8,14 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/rbac"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
@@ -20,29 +24,8 @@ import (
 
 // Fungsi helper untuk mengecek status bimbingan minggu ini
 func CheckWeeklyBimbinganStatus(phoneNumber string) (hasApproved bool, hasUnapproved bool, err error) {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	now := time.Now().In(loc)
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-
-	// Jika sekarang masih Senin sebelum 17:01, anggap masih minggu sebelumnya
-	if weekday == 1 && (now.Hour() < 17 || (now.Hour() == 17 && now.Minute() < 1)) {
-		now = now.AddDate(0, 0, -1)
-		weekday = int(now.Weekday())
-		if weekday == 0 {
-			weekday = 7
-		}
-	}
-
-	// Dapatkan Senin minggu ini
-	mondayThisWeek := now.AddDate(0, 0, -weekday+1)
-	mondayThisWeek = time.Date(mondayThisWeek.Year(), mondayThisWeek.Month(), mondayThisWeek.Day(), 17, 1, 0, 0, mondayThisWeek.Location())
-
-	// Dapatkan Senin berikutnya jam 17:00
-	mondayNextWeek := mondayThisWeek.AddDate(0, 0, 7)
-	mondayNextWeek = time.Date(mondayNextWeek.Year(), mondayNextWeek.Month(), mondayNextWeek.Day(), 17, 0, 0, 0, mondayNextWeek.Location())
+	// minggu bimbingan mengikuti periode akademik, defaultnya Senin 17:01 sampai Senin depan 17:01
+	mondayThisWeek, mondayNextWeek := periode.Get(config.Mongoconn).MingguIni("")
 
 	// Cek approved
 	filterApproved := primitive.M{
@@ -88,7 +71,7 @@ func GetWeeklyBimbinganStatus(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -96,7 +79,7 @@ func GetWeeklyBimbinganStatus(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengecek status"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -124,7 +107,7 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var bimbingan model.ActivityScore
@@ -132,13 +115,13 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if bimbingan.Asesor.PhoneNumber == "" {
 		respn.Status = "Error : No Telepon Asesor tidak diisi"
 		respn.Response = "Isi lebih lengkap terlebih dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 	//validasi eksistensi user di db
@@ -146,17 +129,17 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	//validasi nomor telepon asesor
 	bimbingan.Asesor.PhoneNumber = ValidasiNoHP(bimbingan.Asesor.PhoneNumber)
-	docasesor, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": bimbingan.Asesor.PhoneNumber, "isdosen": true})
-	if err != nil {
+	docasesor, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": bimbingan.Asesor.PhoneNumber})
+	if err != nil || !rbac.Can(config.Mongoconn, docasesor.PhoneNumber, rbac.ApproveBimbingan, bimbingan.Enroll.Kode) {
 		respn.Status = "Error : Data asesor tidak di temukan"
 		respn.Response = "Nomor Telepon bukan milik Dosen Asesor"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -165,7 +148,7 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 	if err == nil {
 		respn.Status = "Info : Data bimbingan sudah di approve"
 		respn.Response = "Bimbingan sudah disetujui, tidak dapat mengajukan ulang."
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -211,7 +194,7 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -222,11 +205,11 @@ func PostDosenAsesorPerdana(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, bimbingan)
@@ -241,7 +224,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -250,7 +233,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengecek status bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -258,7 +241,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if hasApproved {
 		respn.Status = "Info : Bimbingan minggu ini sudah disetujui"
 		respn.Response = "Anda sudah melakukan bimbingan yang disetujui minggu ini. Silakan tunggu minggu depan untuk bimbingan selanjutnya."
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -266,7 +249,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if hasUnapproved {
 		respn.Status = "Info : Sudah ada pengajuan bimbingan"
 		respn.Response = "Anda sudah mengajukan bimbingan minggu ini yang masih menunggu persetujuan. Silakan tunggu konfirmasi dari asesor."
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -275,13 +258,13 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if bimbingan.Asesor.PhoneNumber == "" {
 		respn.Status = "Error : No Telepon Asesor tidak diisi"
 		respn.Response = "Isi lebih lengkap terlebih dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 	//validasi eksistensi user di db
@@ -289,43 +272,22 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	//validasi nomor telepon asesor
 	bimbingan.Asesor.PhoneNumber = ValidasiNoHP(bimbingan.Asesor.PhoneNumber)
-	docasesor, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": bimbingan.Asesor.PhoneNumber, "isdosen": true})
-	if err != nil {
+	docasesor, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": bimbingan.Asesor.PhoneNumber})
+	if err != nil || !rbac.Can(config.Mongoconn, docasesor.PhoneNumber, rbac.ApproveBimbingan, bimbingan.Enroll.Kode) {
 		respn.Status = "Error : Data asesor tidak di temukan"
 		respn.Response = "Nomor Telepon bukan milik Dosen Asesor"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	now := time.Now().In(loc)
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7 // Ubah Minggu (0) jadi 7 agar Senin = 1
-	}
-
-	// Jika sekarang masih Senin sebelum 17:01, anggap masih minggu sebelumnya
-	if weekday == 1 && (now.Hour() < 17 || (now.Hour() == 17 && now.Minute() < 1)) {
-		now = now.AddDate(0, 0, -1)
-		weekday = int(now.Weekday())
-		if weekday == 0 {
-			weekday = 7
-		}
-	}
-
-	// Dapatkan Senin minggu ini
-	mondayThisWeek := now.AddDate(0, 0, -weekday+1)
-	mondayThisWeek = time.Date(mondayThisWeek.Year(), mondayThisWeek.Month(), mondayThisWeek.Day(), 17, 1, 0, 0, mondayThisWeek.Location()) // Senin 17:01
-
-	// Dapatkan Senin berikutnya jam 17:00
-	mondayNextWeek := mondayThisWeek.AddDate(0, 0, 7)
-	mondayNextWeek = time.Date(mondayNextWeek.Year(), mondayNextWeek.Month(), mondayNextWeek.Day(), 17, 0, 0, 0, mondayNextWeek.Location())
+	// satu approval per minggu periode akademik berjalan
+	mondayThisWeek, mondayNextWeek := periode.Get(config.Mongoconn).MingguIni("")
 
 	filter := primitive.M{
 		"phonenumber": docuser.PhoneNumber,
@@ -344,7 +306,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 			!strings.Contains(existingBimbingan.Komentar, "Bonus Bimbingan dari Event Referral Code") {
 			respn.Status = "Info : Data bimbingan sudah di approve"
 			respn.Response = "Bimbingan sudah disetujui, tidak dapat mengajukan ulang untuk minggu ini."
-			at.WriteJSON(respw, http.StatusBadRequest, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 			return
 		}
 		// Jika komentar mengandung "Bonus Bimbingan dari Event Time Code" atau "Bonus Bimbingan dari Event Referral Code", lanjutkan proses
@@ -390,7 +352,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data bimbingan tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	// Insert data baru
@@ -399,7 +361,7 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -410,11 +372,11 @@ func PostDosenAsesorLanjutan(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, bimbingan)
@@ -429,14 +391,14 @@ func GetDataBimbinganById(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	bimbingan, err := atdb.GetOneDoc[model.ActivityScore](config.Mongoconn, "bimbingan", primitive.M{"_id": objectId})
 	if err != nil {
 		respn.Status = "Error : Data bimbingan tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, bimbingan)
@@ -450,7 +412,7 @@ func GetDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -464,7 +426,7 @@ func GetDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -480,7 +442,7 @@ func ReplaceDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	var bim model.ActivityScore
@@ -488,7 +450,7 @@ func ReplaceDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	filter := primitive.M{"_id": objectId}
@@ -496,7 +458,15 @@ func ReplaceDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data bimbingan tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
+		return
+	}
+	//hanya asesor yang dipilih mahasiswa yang boleh menilai, owner tetap bisa membantu
+	auth, _ := rbac.FromRequest(req)
+	if bimbingan.Asesor.PhoneNumber != auth.Payload.Id && !rbac.Has(auth.Roles, rbac.KelolaSistem, "") {
+		respn.Status = "Error : Akses Ditolak"
+		respn.Response = "Hanya asesor bimbingan ini yang dapat memberi penilaian"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, respn))
 		return
 	}
 
@@ -507,7 +477,7 @@ func ReplaceDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Response = "Error : Gagal replaceonedoc"
 		respn.Info = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, respn))
 		return
 	}
 
@@ -524,11 +494,11 @@ func ReplaceDataBimbingan(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, bimbingan)
@@ -540,3 +510,12 @@ func LaporanBelumBimbingan(w http.ResponseWriter, r *http.Request) {
 		Response: "Berhasil simpan data",
 	})
 }
+
+// EnrollBimbingan adalah scope penilaian bimbingan :id, yaitu kode enroll yang dipilih mahasiswa saat mengajukan
+func EnrollBimbingan(req *http.Request) string {
+	bimbingan, err := atdb.GetOneDoc[model.ActivityScore](config.Mongoconn, "bimbingan", primitive.M{"_id": router.ParamObjectID(req, "id")})
+	if err != nil {
+		return ""
+	}
+	return bimbingan.Enroll.Kode
+}
@@ -2,14 +2,17 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
@@ -26,7 +29,7 @@ func GetDosenPenguji(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -35,7 +38,7 @@ func GetDosenPenguji(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data dosen"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -68,7 +71,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -78,7 +81,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -86,7 +89,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if pengajuan.DosenPengujiPhone == "" || pengajuan.NomorKelompok == "" {
 		respn.Status = "Error : Data pengajuan tidak lengkap"
 		respn.Response = "Isi Dosen Penguji dan Nomor Kelompok terlebih dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -95,7 +98,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -104,7 +107,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if err == nil && len(existingPengajuan) > 0 {
 		respn.Status = "Error : Pengajuan sudah ada"
 		respn.Response = "Anda sudah pernah mengajukan sidang sebelumnya. Tidak dapat mengajukan lagi."
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -117,23 +120,26 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
 	if len(bimbinganList) < 8 {
 		respn.Status = "Error : Syarat bimbingan belum terpenuhi"
 		respn.Response = fmt.Sprintf("Anda memerlukan minimal 8 sesi bimbingan yang sudah disetujui untuk mengajukan sidang. Saat ini: %d approved", len(bimbinganList))
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.PreconditionFailed, respn))
 		return
 	}
 
 	// Get the dosen penguji data
-	dosenPenguji, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": pengajuan.DosenPengujiPhone, "isdosen": true})
+	dosenPenguji, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": pengajuan.DosenPengujiPhone})
+	if err == nil && !rbac.Can(config.Mongoconn, dosenPenguji.PhoneNumber, rbac.ApproveBimbingan, "") {
+		err = errors.New("nomor " + pengajuan.DosenPengujiPhone + " bukan dosen")
+	}
 	if err != nil {
 		respn.Status = "Error : Data dosen penguji tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -146,7 +152,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	} else {
 		respn.Status = "Error : Tidak ada data bimbingan"
 		respn.Response = "Tidak dapat menemukan data dosen pembimbing"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -167,7 +173,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	} else {
 		respn.Status = "Error : Data dosen pembimbing tidak ditemukan"
 		respn.Response = "Tidak dapat menemukan data dosen pembimbing dari riwayat bimbingan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -176,7 +182,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -197,7 +203,7 @@ func PostPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, respWA, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, respWA, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		// Still proceed even if notification fails
 		respn.Status = "Warning: Pengajuan berhasil tetapi notifikasi gagal terkirim"
@@ -228,7 +234,7 @@ func GetPengajuanSidang(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -258,7 +264,7 @@ func CheckSidangEligibility(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -270,7 +276,7 @@ func CheckSidangEligibility(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -281,7 +287,7 @@ func CheckSidangEligibility(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
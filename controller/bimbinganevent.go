@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -48,7 +51,7 @@ func sendDiscordNotification(payload DiscordWebhookPayload) error {
 		return err
 	}
 
-	resp, err := http.Post(DISCORD_WEBHOOK_URL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := atapi.NewClient(0).Post(DISCORD_WEBHOOK_URL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
@@ -60,37 +63,14 @@ func sendDiscordNotification(payload DiscordWebhookPayload) error {
 // GenerateEventCode untuk generate kode referral (khusus owner)
 func GenerateEventCode(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Unauthorized"
-		respn.Response = "Anda tidak memiliki akses untuk generate kode"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
-		return
-	}
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Get user data untuk informasi lengkap
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": payload.Id})
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
 	if err != nil {
 		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -99,7 +79,7 @@ func GenerateEventCode(respw http.ResponseWriter, req *http.Request) {
 	if _, err := rand.Read(bytes); err != nil {
 		respn.Status = "Error : Gagal generate kode"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	code := "GLR" + hex.EncodeToString(bytes)
@@ -107,7 +87,7 @@ func GenerateEventCode(respw http.ResponseWriter, req *http.Request) {
 	// Simpan ke database
 	eventCode := model.EventCode{
 		Code:      code,
-		CreatedBy: payload.Id,
+		CreatedBy: phonenumber,
 		CreatedAt: time.Now(),
 		IsUsed:    false,
 	}
@@ -116,7 +96,7 @@ func GenerateEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan kode"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -181,37 +161,14 @@ func GenerateEventCode(respw http.ResponseWriter, req *http.Request) {
 // GenerateEventCodeTime untuk generate kode dengan waktu kadaluarsa (khusus owner)
 func GenerateEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Unauthorized"
-		respn.Response = "Anda tidak memiliki akses untuk generate kode"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
-		return
-	}
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Get user data untuk informasi lengkap
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": payload.Id})
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
 	if err != nil {
 		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -221,14 +178,14 @@ func GenerateEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
 	if timeReq.DurationSeconds <= 0 {
 		respn.Status = "Error : Durasi harus lebih dari 0 detik"
 		respn.Response = "Masukkan durasi yang valid"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -237,7 +194,7 @@ func GenerateEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if _, err := rand.Read(bytes); err != nil {
 		respn.Status = "Error : Gagal generate kode"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	code := "GLR" + hex.EncodeToString(bytes)
@@ -249,7 +206,7 @@ func GenerateEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	// Simpan ke database
 	eventCodeTime := model.EventCodeTime{
 		Code:        code,
-		CreatedBy:   payload.Id,
+		CreatedBy:   phonenumber,
 		CreatedAt:   now,
 		ExpiresAt:   expiresAt,
 		DurationSec: timeReq.DurationSeconds,
@@ -260,7 +217,7 @@ func GenerateEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan kode"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -356,7 +313,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -366,7 +323,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -375,7 +332,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Kode tidak valid"
 		respn.Response = "Kode referral tidak ditemukan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -383,7 +340,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if eventCode.IsUsed {
 		respn.Status = "Error : Kode sudah digunakan"
 		respn.Response = "Kode referral sudah digunakan oleh pengguna lain"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -392,7 +349,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -464,7 +421,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menambah bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -477,7 +434,7 @@ func ClaimEventCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal update status kode"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -556,7 +513,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -566,7 +523,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -575,7 +532,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Kode tidak valid"
 		respn.Response = "Kode time event tidak ditemukan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -583,7 +540,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if !eventCodeTime.IsActive {
 		respn.Status = "Error : Kode tidak aktif"
 		respn.Response = "Kode sudah tidak aktif"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Expired, respn))
 		return
 	}
 
@@ -596,7 +553,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 
 		respn.Status = "Error : Kode kadaluarsa"
 		respn.Response = "Kode sudah kadaluarsa dan tidak dapat digunakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Expired, respn))
 		return
 	}
 
@@ -608,7 +565,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err == nil {
 		respn.Status = "Error : Sudah pernah claim"
 		respn.Response = "Anda sudah pernah mengclaim kode ini sebelumnya"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -617,7 +574,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -688,7 +645,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menambah bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -705,7 +662,7 @@ func ClaimEventCodeTime(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan record claim"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -34,14 +36,14 @@ func GetCachedToken(phoneNumber string) string {
     return tokenCache[phoneNumber]
 }
 
-func GetBukpedMemberScoreForUser(phoneNumber string, token string) (int, string, []model.BukpedBook, error) {
+func GetBukpedMemberScoreForUser(ctx context.Context, phoneNumber string, token string) (int, string, []model.BukpedBook, error) {
     var bukpedBooks []model.BukpedBook
     
     var conf model.Config
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    confCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
-    err := config.Mongoconn.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
+    err := config.Mongoconn.Collection("config").FindOne(confCtx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
     if err != nil {
         return 0, "", nil, fmt.Errorf("Config Not Found: %v", err)
     }
@@ -50,8 +52,8 @@ func GetBukpedMemberScoreForUser(phoneNumber string, token string) (int, string,
         return 0, "", nil, errors.New("Bukped API URL not configured")
     }
     
-    client := &http.Client{Timeout: 30 * time.Second}
-    req, err := http.NewRequest("GET", conf.DataMemberBukped, nil)
+    client := atapi.NewClient(30 * time.Second)
+    req, err := http.NewRequestWithContext(ctx, "GET", conf.DataMemberBukped, nil)
     if err != nil {
         return 0, "", nil, fmt.Errorf("failed to create request: %v", err)
     }
@@ -139,17 +141,17 @@ func GetBukpedMemberScoreForUser(phoneNumber string, token string) (int, string,
 }
 
 func GetLastWeekBukpedMemberScoreForUser(phoneNumber string, token string) (int, string, []model.BukpedBook, error) {
-	return GetBukpedMemberScoreForUser(phoneNumber, token)
+	return GetBukpedMemberScoreForUser(context.Background(), phoneNumber, token)
 }
 
 func GetBukpedDataUserAPI(w http.ResponseWriter, r *http.Request) {
     payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
     if err != nil {
-        at.WriteJSON(w, http.StatusForbidden, model.Response{
+        at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
             Status:   "Error: Invalid Token",
             Location: "Token Validation",
             Response: err.Error(),
-        })
+        }))
         return
     }
     
@@ -159,13 +161,13 @@ func GetBukpedDataUserAPI(w http.ResponseWriter, r *http.Request) {
         phoneNumber = payload.Id 
     }
     
-    bukpedScore, catalogURL, userBooks, err := GetBukpedMemberScoreForUser(phoneNumber, at.GetLoginFromHeader(r))
+    bukpedScore, catalogURL, userBooks, err := GetBukpedMemberScoreForUser(r.Context(), phoneNumber, at.GetLoginFromHeader(r))
     if err != nil {
-        at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+        at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
             Status:   "Error: Failed to fetch Bukped data",
             Location: "Bukped API",
             Response: err.Error(),
-        })
+        }))
         return
     }
     
@@ -230,12 +232,12 @@ func GetBukpedDataUserAPI(w http.ResponseWriter, r *http.Request) {
     at.WriteJSON(w, http.StatusOK, response)
 }
 
-func GetBukpedScoreForUser(phoneNumber string) (model.ActivityScore, error) {
+func GetBukpedScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
     var score model.ActivityScore
 
     token := GetCachedToken(phoneNumber)
     
-    bukpedScore, _, userBooks, err := GetBukpedMemberScoreForUser(phoneNumber, token)
+    bukpedScore, _, userBooks, err := GetBukpedMemberScoreForUser(ctx, phoneNumber, token)
     if err != nil {
         return score, fmt.Errorf("gagal mendapatkan data Bukped: %v", err)
     }
@@ -277,6 +279,6 @@ func GetBukpedScoreForUser(phoneNumber string) (model.ActivityScore, error) {
     return score, nil
 }
 
-func GetLastWeekBukpedScoreForUser(phoneNumber string) (model.ActivityScore, error) {
-    return GetBukpedScoreForUser(phoneNumber)
+func GetLastWeekBukpedScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
+    return GetBukpedScoreForUser(ctx, phoneNumber)
 }
\ No newline at end of file
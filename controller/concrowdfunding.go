@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"regexp"
@@ -16,7 +17,11 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/crowdfunding"
+	"github.com/gocroot/helper/periode"
 	"github.com/gocroot/helper/report"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
@@ -30,13 +35,6 @@ import (
 const (
 	// Discord webhook URL for logging
 	CrowdfundingDiscordWebhookURL = "https://discord.com/api/webhooks/1370349053321154581/wxAIb8_Lszb1aOBG4kceE1DtCXbCtnE4RclptWqNvbLor1-hAWjaaVrR0NbJmtsulTaI"
-	MicroBitcoinWalletAddress     = "BXheTnryBeec7Ere3zsuRmWjB1LiyCFpec"
-	RavencoinWalletAddress        = "RKJpSmjTq5MPDaBx2ubTx1msVB2uZcKA5j"
-
-	// Expiry times for different payment methods
-	QRISExpirySeconds         = 3600 // 60 minutes
-	MicroBitcoinExpirySeconds = 1500 // 15 minutes
-	RavencoinExpirySeconds    = 1500 // 15 minutes
 )
 
 // Discord embed structure
@@ -111,7 +109,7 @@ func sendCrowdfundingDiscordEmbed(title, description string, color int, fields [
 
 	// Send to Discord webhook asynchronously
 	go func() {
-		client := &http.Client{Timeout: 5 * time.Second}
+		client := atapi.NewClient(5 * time.Second)
 		resp, err := client.Post(CrowdfundingDiscordWebhookURL, "application/json", bytes.NewBuffer(jsonData))
 		if err != nil {
 			log.Printf("Error sending embed to Discord: %v", err)
@@ -164,83 +162,21 @@ func InitializeCrowdfundingTotal() {
 	}
 }
 
-// Initialize Crowdfunding queue
-func InitializeCrowdfundingQueue() {
-	var queue model.CrowdfundingQueue
-	err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
-	if err != nil {
-		// Queue document doesn't exist, create it
-		_, err = config.Mongoconn.Collection("crowdfundingqueue").InsertOne(context.Background(), model.CrowdfundingQueue{
-			IsProcessing:   false,
-			CurrentOrderID: "",
-			ExpiryTime:     time.Time{},
-		})
-
-		if err != nil {
-			log.Printf("Error initializing crowdfunding queue: %v", err)
-			sendCrowdfundingDiscordEmbed(
-				"🔴 Error: Crowdfunding Queue Initialization Failed",
-				"Failed to initialize crowdfunding payment queue.",
-				ColorRed,
-				[]DiscordEmbedField{
-					{Name: "Error", Value: err.Error(), Inline: false},
-				},
-			)
-		} else {
-			log.Println("Initialized crowdfunding queue successfully")
-			sendCrowdfundingDiscordEmbed(
-				"✅ System: Crowdfunding Queue Initialized",
-				"Crowdfunding payment queue initialized successfully.",
-				ColorGreen,
-				nil,
-			)
-		}
-	}
-}
-
 // Helper function to update payment totals
-func updateCrowdfundingTotal(amount float64, paymentMethod model.PaymentMethod) {
+func updateCrowdfundingTotal(amount float64, p crowdfunding.Provider) {
 	opts := options.FindOneAndUpdate().SetUpsert(true)
-
-	// Create update based on payment method
-	var update bson.M
-
-	if paymentMethod == model.QRIS {
-		update = bson.M{
-			"$inc": bson.M{
-				"totalQRISAmount": amount,
-				"qrisCount":       1,
-				"totalAmount":     amount,
-				"totalCount":      1,
-			},
-			"$set": bson.M{
-				"lastUpdated": time.Now(),
-			},
-		}
-	} else if paymentMethod == model.MicroBitcoin {
-		update = bson.M{
-			"$inc": bson.M{
-				"totalBitcoinAmount": amount,
-				"bitcoinCount":       1,
-				"totalAmount":        amount,
-				"totalCount":         1,
-			},
-			"$set": bson.M{
-				"lastUpdated": time.Now(),
-			},
-		}
-	} else if paymentMethod == model.Ravencoin {
-		update = bson.M{
-			"$inc": bson.M{
-				"totalRavencoinAmount": amount,
-				"ravencoinCount":       1,
-				"totalAmount":          amount,
-				"totalCount":           1,
-			},
-			"$set": bson.M{
-				"lastUpdated": time.Now(),
-			},
-		}
+	info := p.Info()
+
+	update := bson.M{
+		"$inc": bson.M{
+			info.TotalAmountKey: amount,
+			info.TotalCountKey:  1,
+			"totalAmount":       amount,
+			"totalCount":        1,
+		},
+		"$set": bson.M{
+			"lastUpdated": time.Now(),
+		},
 	}
 
 	var result model.CrowdfundingTotal
@@ -258,8 +194,8 @@ func updateCrowdfundingTotal(amount float64, paymentMethod model.PaymentMethod)
 			"Failed to update crowdfunding totals in database.",
 			ColorRed,
 			[]DiscordEmbedField{
-				{Name: "Payment Method", Value: string(paymentMethod), Inline: true},
-				{Name: "Amount", Value: formatAmount(amount, paymentMethod), Inline: true},
+				{Name: "Payment Method", Value: string(info.Method), Inline: true},
+				{Name: "Amount", Value: p.FormatAmount(amount), Inline: true},
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
@@ -269,25 +205,13 @@ func updateCrowdfundingTotal(amount float64, paymentMethod model.PaymentMethod)
 			"Successfully updated crowdfunding totals.",
 			ColorGreen,
 			[]DiscordEmbedField{
-				{Name: "Payment Method", Value: string(paymentMethod), Inline: true},
-				{Name: "Amount Added", Value: formatAmount(amount, paymentMethod), Inline: true},
+				{Name: "Payment Method", Value: string(info.Method), Inline: true},
+				{Name: "Amount Added", Value: p.FormatAmount(amount), Inline: true},
 			},
 		)
 	}
 }
 
-// Helper function to format amount based on payment method
-func formatAmount(amount float64, paymentMethod model.PaymentMethod) string {
-	if paymentMethod == model.QRIS {
-		return fmt.Sprintf("Rp %s", strconv.FormatFloat(amount, 'f', 2, 64))
-	} else if paymentMethod == model.MicroBitcoin {
-		return fmt.Sprintf("%f MBC", amount)
-	} else if paymentMethod == model.Ravencoin {
-		return fmt.Sprintf("%f RVN", amount)
-	}
-	return strconv.FormatFloat(amount, 'f', 8, 64)
-}
-
 // Extract user info from token
 func extractUserInfoFromToken(r *http.Request) (phoneNumber, name, npm, wonpaywallet, rvnwallet string, err error) {
 	// Get login token from header - gunakan 'login' bukan 'Authorization'
@@ -329,24 +253,24 @@ func GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	// Decode token menggunakan `at.GetLoginFromHeader(r)`
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
 	// Ambil `phonenumber` dari payload
 	phoneNumber := payload.Id
 	if phoneNumber == "" {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Missing Phonenumber",
 			Info:     "Nomor telepon tidak ditemukan dalam token",
 			Location: "Token Parsing",
 			Response: "Invalid Payload",
-		})
+		}))
 		return
 	}
 
@@ -381,170 +305,85 @@ func GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// CleanupExpiredQueue automatically cleans up any expired payment queue entries
-func CleanupExpiredQueue() {
-	// Get current queue status
-	var queue model.CrowdfundingQueue
-	err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
+// CheckQueueStatus reports the number of active payment slots and the caller's own pending payment
+func CheckQueueStatus(w http.ResponseWriter, r *http.Request) {
+	// Release any expired slots first
+	CleanupExpiredSlots()
 
+	activeSlots, err := config.Mongoconn.Collection(crowdfunding.SlotCollection).CountDocuments(context.Background(), bson.M{"expiryTime": bson.M{"$gt": time.Now()}})
 	if err != nil {
-		// If queue document doesn't exist, no cleanup needed
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error checking payment slots", ""))
 		return
 	}
+	InitializeCrowdfundingTotal()
 
-	// Check if there's an active payment that has expired
-	if queue.IsProcessing && !queue.ExpiryTime.IsZero() && time.Now().After(queue.ExpiryTime) {
-		log.Printf("Found expired payment in queue, order ID: %s, expiry time: %v", queue.CurrentOrderID, queue.ExpiryTime)
-
-		// The payment has expired, reset the queue
-		_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-			context.Background(),
-			bson.M{},
-			bson.M{"$set": bson.M{
-				"isProcessing":   false,
-				"currentOrderId": "",
-				"paymentMethod":  "",
-				"expiryTime":     time.Time{},
-			}},
-		)
-
-		if err != nil {
-			log.Printf("Error resetting expired queue: %v", err)
-			sendCrowdfundingDiscordEmbed(
-				"🔴 Error: Queue Reset Failed",
-				"Failed to reset expired queue during cleanup.",
-				ColorRed,
-				[]DiscordEmbedField{
-					{Name: "Error", Value: err.Error(), Inline: false},
-					{Name: "Order ID", Value: queue.CurrentOrderID, Inline: true},
-					{Name: "Expiry Time", Value: queue.ExpiryTime.Format(time.RFC3339), Inline: true},
-				},
-			)
-		} else {
-			log.Println("Successfully reset expired payment queue")
-			sendCrowdfundingDiscordEmbed(
-				"🕒 Queue: Expired Payment Cleaned Up",
-				"Successfully reset expired payment queue during cleanup.",
-				ColorYellow,
-				[]DiscordEmbedField{
-					{Name: "Order ID", Value: queue.CurrentOrderID, Inline: true},
-					{Name: "Payment Method", Value: string(queue.PaymentMethod), Inline: true},
-					{Name: "Expiry Time", Value: queue.ExpiryTime.Format(time.RFC3339), Inline: true},
-				},
-			)
-
-			// Also update the order status if it exists
-			if queue.CurrentOrderID != "" {
-				_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-					context.Background(),
-					bson.M{"orderId": queue.CurrentOrderID},
-					bson.M{"$set": bson.M{
-						"status":    "failed",
-						"updatedAt": time.Now(),
-					}},
-				)
-
-				if err != nil {
-					log.Printf("Error updating expired order status: %v", err)
-					sendCrowdfundingDiscordEmbed(
-						"🔴 Error: Order Status Update Failed",
-						"Failed to update expired order status during cleanup.",
-						ColorRed,
-						[]DiscordEmbedField{
-							{Name: "Error", Value: err.Error(), Inline: false},
-							{Name: "Order ID", Value: queue.CurrentOrderID, Inline: true},
-						},
-					)
-				} else {
-					log.Printf("Successfully updated order status to 'failed' for expired payment: %s", queue.CurrentOrderID)
-				}
-			}
-		}
-	}
-}
-
-// CheckQueueStatus checks if there's an active payment in the queue
-func CheckQueueStatus(w http.ResponseWriter, r *http.Request) {
-	// Clean up any expired queues first
-	CleanupExpiredQueue()
-
-	var queue model.CrowdfundingQueue
-	err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
+	// Without a token only the global slot usage can be returned
+	phoneNumber, _, _, _, _, err := extractUserInfoFromToken(r)
 	if err != nil {
-		// If no queue document exists, initialize it
-		InitializeCrowdfundingQueue()
-		InitializeCrowdfundingTotal()
-
-		// Return empty queue status
 		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 			Success:      true,
 			IsProcessing: false,
+			ActiveSlots:  int(activeSlots),
 		})
 		return
 	}
 
-	// Additional check for expired payments
-	// This is a double-check in case cleanup didn't work for some reason
-	if queue.IsProcessing && !queue.ExpiryTime.IsZero() && time.Now().After(queue.ExpiryTime) {
-		log.Printf("Detected expired payment in CheckQueueStatus: %s, expired at %v",
-			queue.CurrentOrderID, queue.ExpiryTime)
-
-		// Try once more to reset the queue
-		_, updateErr := config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-			context.Background(),
-			bson.M{},
-			bson.M{"$set": bson.M{
-				"isProcessing":   false,
-				"currentOrderId": "",
-				"paymentMethod":  "",
-				"expiryTime":     time.Time{},
-			}},
-		)
-
-		if updateErr != nil {
-			log.Printf("Error in CheckQueueStatus when trying to reset expired queue: %v", updateErr)
-		}
-
-		// If payment has expired, return non-processing status regardless of DB update
-		// This ensures front-end can proceed even if DB update failed
+	var slot model.CrowdfundingSlot
+	err = config.Mongoconn.Collection(crowdfunding.SlotCollection).FindOne(context.Background(), bson.M{
+		"phoneNumber": phoneNumber,
+		"expiryTime":  bson.M{"$gt": time.Now()},
+	}).Decode(&slot)
+	if err != nil {
 		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 			Success:      true,
 			IsProcessing: false,
-			Message:      "Previous payment session expired",
+			ActiveSlots:  int(activeSlots),
 		})
 		return
 	}
 
-	// Normal response with current queue status
+	var order model.CrowdfundingOrder
+	_ = config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": slot.OrderID}).Decode(&order)
+
+	// The caller has a pending payment of their own
 	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 		Success:       true,
-		IsProcessing:  queue.IsProcessing,
-		ExpiryTime:    queue.ExpiryTime,
-		PaymentMethod: queue.PaymentMethod,
+		IsProcessing:  true,
+		ActiveSlots:   int(activeSlots),
+		OrderID:       slot.OrderID,
+		ExpiryTime:    slot.ExpiryTime,
+		PaymentMethod: slot.PaymentMethod,
+		UniqueCode:    slot.UniqueCode,
+		PayAmount:     formatSlotPayAmount(order),
 	})
 }
 
-// CreateQRISOrder creates a new QRIS payment order
-func CreateQRISOrder(w http.ResponseWriter, r *http.Request) {
-	var request model.CreateQRISOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+// CreateCrowdfundingOrder creates a new payment order for /api/crowdfunding/{provider}/createOrder
+func CreateCrowdfundingOrder(w http.ResponseWriter, r *http.Request) {
+	p, _ := crowdfunding.ProviderAction(r.URL.Path)
+	if p == nil {
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Unknown payment method", ""))
+		return
+	}
+	info := p.Info()
+
+	// Crypto orders are created without a request body
+	var request crowdfunding.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
 		sendCrowdfundingDiscordEmbed(
 			"🔴 Error: Invalid Request",
-			"Failed to process create QRIS order request.",
+			"Failed to process create "+info.Name+" order request.",
 			ColorRed,
 			[]DiscordEmbedField{
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Invalid request body", ""))
 		return
 	}
 
-	// Extract user info from token - updated to handle 6 return values
-	phoneNumber, name, npm, _, _, err := extractUserInfoFromToken(r)
+	// Extract user info from token
+	phoneNumber, name, npm, wonpaywallet, rvnwallet, err := extractUserInfoFromToken(r)
 	if err != nil {
 		sendCrowdfundingDiscordEmbed(
 			"🔴 Error: Authentication Failed",
@@ -554,58 +393,16 @@ func CreateQRISOrder(w http.ResponseWriter, r *http.Request) {
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Authentication failed: " + err.Error(),
-		})
-		return
-	}
-
-	// Validate request
-	if request.Amount <= 0 {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Invalid Order Parameters",
-			"QRIS order creation failed due to invalid amount.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Name", Value: name, Inline: true},
-				{Name: "Phone", Value: phoneNumber, Inline: true},
-				{Name: "Amount", Value: strconv.FormatFloat(request.Amount, 'f', 2, 64), Inline: true},
-			},
-		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Valid amount is required",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Authentication failed: "+err.Error(), ""))
 		return
 	}
+	request.Wonpaywallet = wonpaywallet
+	request.RVNwallet = rvnwallet
 
-	// Check if someone is currently paying
-	var queue model.CrowdfundingQueue
-	err = config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
-	if err != nil {
-		// Initialize queue if it doesn't exist
-		InitializeCrowdfundingQueue()
-	} else if queue.IsProcessing {
-		sendCrowdfundingDiscordEmbed(
-			"⏳ Queue: Payment in Progress",
-			"Another payment is already in progress.",
-			ColorYellow,
-			[]DiscordEmbedField{
-				{Name: "Customer", Value: name, Inline: true},
-				{Name: "Phone", Value: phoneNumber, Inline: true},
-				{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(request.Amount, 'f', 2, 64)), Inline: true},
-				{Name: "Status", Value: "Queued", Inline: true},
-				{Name: "Payment Method", Value: string(queue.PaymentMethod), Inline: true},
-			},
-		)
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "Sedang ada pembayaran berlangsung. Silakan tunggu.",
-			QueueStatus:   true,
-			ExpiryTime:    queue.ExpiryTime,
-			PaymentMethod: queue.PaymentMethod,
-		})
+	// Release expired slots and check if this user already has a pending payment with this method
+	CleanupExpiredSlots()
+	if activeSlot, err := crowdfunding.GetActiveSlot(phoneNumber, info.Method); err == nil {
+		writeActiveSlotResponse(w, activeSlot)
 		return
 	}
 
@@ -613,392 +410,130 @@ func CreateQRISOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := uuid.New().String()
 
 	// Set expiry time
-	expiryTime := time.Now().Add(QRISExpirySeconds * time.Second)
+	expiryTime := time.Now().Add(info.Expiry)
 
-	// Create new order in database
+	// Create new order, the provider fills in its own fields
 	newOrder := model.CrowdfundingOrder{
 		OrderID:       orderID,
 		Name:          name,
 		PhoneNumber:   phoneNumber,
 		NPM:           npm,
-		Amount:        request.Amount,
-		PaymentMethod: model.QRIS,
+		PaymentMethod: info.Method,
 		Timestamp:     time.Now(),
 		ExpiryTime:    expiryTime,
 		Status:        "pending",
 	}
-
-	_, err = config.Mongoconn.Collection("crowdfundingorders").InsertOne(context.Background(), newOrder)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Database Error",
-			"Failed to create QRIS order in database.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error creating order",
-		})
-		return
-	}
-
-	// Update queue status
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   true,
-			"currentOrderId": orderID,
-			"paymentMethod":  model.QRIS,
-			"expiryTime":     expiryTime,
-		}},
-		options.Update().SetUpsert(true),
-	)
-
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Update Failed",
-			"Failed to update payment queue.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating queue",
-		})
-		return
-	}
-
-	// Log successful order creation
-	sendCrowdfundingDiscordEmbed(
-		"🛒 New QRIS Order Created",
-		"A new QRIS payment order has been created.",
-		ColorBlue,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: name, Inline: true},
-			{Name: "Phone", Value: phoneNumber, Inline: true},
-			{Name: "NPM", Value: npm, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(request.Amount, 'f', 2, 64)), Inline: true},
-			{Name: "Expires", Value: expiryTime.Format("15:04:05"), Inline: true},
-			{Name: "Status", Value: "Pending", Inline: true},
-		},
-	)
-
-	// Set up expiry timer
-	go func() {
-		time.Sleep(QRISExpirySeconds * time.Second)
-
-		// Check if this order is still the current one
-		var currentQueue model.CrowdfundingQueue
-		err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&currentQueue)
-		if err != nil {
-			log.Printf("Error checking queue for timeout: %v", err)
-			return
-		}
-
-		if currentQueue.CurrentOrderID == orderID {
-			// Update order status to failed
-			_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-				context.Background(),
-				bson.M{"orderId": orderID},
-				bson.M{"$set": bson.M{
-					"status":    "failed",
-					"updatedAt": time.Now(),
-				}},
-			)
-			if err != nil {
-				log.Printf("Error updating order status: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Status Update Failed",
-					"Failed to update expired order status.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
-
-			// Reset queue
-			_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-				context.Background(),
-				bson.M{},
-				bson.M{"$set": bson.M{
-					"isProcessing":   false,
-					"currentOrderId": "",
-					"paymentMethod":  "",
-					"expiryTime":     time.Time{},
-				}},
-			)
-			if err != nil {
-				log.Printf("Error resetting queue: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Queue Reset Failed",
-					"Failed to reset queue after order expiry.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
-
-			sendCrowdfundingDiscordEmbed(
-				"⏱️ Order Expired",
-				"A payment order has expired.",
-				ColorYellow,
-				[]DiscordEmbedField{
-					{Name: "Order ID", Value: orderID, Inline: true},
-					{Name: "Customer", Value: newOrder.Name, Inline: true},
-					{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(newOrder.Amount, 'f', 2, 64)), Inline: true},
-					{Name: "Status", Value: "Expired", Inline: true},
-				},
-			)
-		}
-	}()
-
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		OrderID:       orderID,
-		ExpiryTime:    expiryTime,
-		QRISImageURL:  "qris.png",
-		PaymentMethod: model.QRIS,
-	})
-}
-
-// CreateMicroBitcoinOrder creates a new MicroBitcoin payment order
-func CreateMicroBitcoinOrder(w http.ResponseWriter, r *http.Request) {
-	// Extract user info from token
-	phoneNumber, name, npm, wonpaywallet, _, err := extractUserInfoFromToken(r)
+	senderWallet, err := p.PrepareOrder(&newOrder, request)
 	if err != nil {
 		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Authentication Failed",
-			"Failed to extract user information from token.",
+			"🔴 Error: Invalid Order Parameters",
+			info.Name+" order creation failed due to invalid parameters.",
 			ColorRed,
 			[]DiscordEmbedField{
+				{Name: "Name", Value: name, Inline: true},
+				{Name: "Phone", Value: phoneNumber, Inline: true},
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Authentication failed: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, err.Error(), ""))
 		return
 	}
 
-	// Check if someone is currently paying
-	var queue model.CrowdfundingQueue
-	err = config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
+	// Reserve a payment slot with a unique code
+	slot, err := crowdfunding.AcquireSlot(p, &newOrder, senderWallet)
 	if err != nil {
-		// Initialize queue if it doesn't exist
-		InitializeCrowdfundingQueue()
-	} else if queue.IsProcessing {
 		sendCrowdfundingDiscordEmbed(
-			"⏳ Queue: MicroBitcoin Payment in Progress",
-			"Another payment is already in progress.",
+			"⏳ Slot: No Free Payment Slot",
+			"Failed to reserve a "+info.Name+" payment slot.",
 			ColorYellow,
 			[]DiscordEmbedField{
 				{Name: "Customer", Value: name, Inline: true},
 				{Name: "Phone", Value: phoneNumber, Inline: true},
-				{Name: "Status", Value: "Queued", Inline: true},
-				{Name: "Payment Method", Value: string(queue.PaymentMethod), Inline: true},
+				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "Sedang ada pembayaran berlangsung. Silakan tunggu.",
-			QueueStatus:   true,
-			ExpiryTime:    queue.ExpiryTime,
-			PaymentMethod: queue.PaymentMethod,
-		})
+		at.WriteError(w, r, apperr.New(apperr.Upstream, err.Error(), ""))
 		return
 	}
 
-	// Create order ID
-	orderID := uuid.New().String()
-
-	// Set expiry time
-	expiryTime := time.Now().Add(MicroBitcoinExpirySeconds * time.Second)
-
-	// Create new order in database
-	newOrder := model.CrowdfundingOrder{
-		OrderID:       orderID,
-		Name:          name,
-		PhoneNumber:   phoneNumber,
-		NPM:           npm,
-		WalletAddress: MicroBitcoinWalletAddress,
-		PaymentMethod: model.MicroBitcoin,
-		Timestamp:     time.Now(),
-		ExpiryTime:    expiryTime,
-		Status:        "pending",
-		Wonpaywallet:  wonpaywallet, // Include user's Wonpay wallet
-	}
-
 	_, err = config.Mongoconn.Collection("crowdfundingorders").InsertOne(context.Background(), newOrder)
 	if err != nil {
 		sendCrowdfundingDiscordEmbed(
 			"🔴 Error: Database Error",
-			"Failed to create MicroBitcoin order in database.",
+			"Failed to create "+info.Name+" order in database.",
 			ColorRed,
 			[]DiscordEmbedField{
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error creating order",
-		})
+		if releaseErr := crowdfunding.ReleaseSlot(orderID); releaseErr != nil {
+			log.Printf("Error releasing slot after order insert failure: %v", releaseErr)
+		}
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error creating order", ""))
 		return
 	}
 
-	// Update queue status
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   true,
-			"currentOrderId": orderID,
-			"paymentMethod":  model.MicroBitcoin,
-			"expiryTime":     expiryTime,
-		}},
-		options.Update().SetUpsert(true),
-	)
-
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Update Failed",
-			"Failed to update MicroBitcoin payment queue.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
+	// Log successful order creation
+	fields := []DiscordEmbedField{
+		{Name: "Order ID", Value: orderID, Inline: true},
+		{Name: "Customer", Value: name, Inline: true},
+		{Name: "Phone", Value: phoneNumber, Inline: true},
+		{Name: "NPM", Value: npm, Inline: true},
+	}
+	if info.WalletAddress != "" {
+		userWallet := senderWallet
+		if userWallet == "" {
+			userWallet = "Not provided"
+		}
+		fields = append(fields,
+			DiscordEmbedField{Name: "User Wallet", Value: userWallet, Inline: true},
+			DiscordEmbedField{Name: "Destination", Value: info.WalletAddress, Inline: true},
 		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating queue",
-		})
-		return
-	}
-
-	// For MicroBitcoin Discord notification:
-	var userWalletField DiscordEmbedField
-	if wonpaywallet != "" {
-		userWalletField = DiscordEmbedField{
-			Name:   "User Wallet",
-			Value:  wonpaywallet,
-			Inline: true,
-		}
-	} else {
-		userWalletField = DiscordEmbedField{
-			Name:   "User Wallet",
-			Value:  "Not provided",
-			Inline: true,
-		}
 	}
-
+	fields = append(fields,
+		DiscordEmbedField{Name: "Amount", Value: formatSlotPayAmount(newOrder), Inline: true},
+		DiscordEmbedField{Name: "Unique Code", Value: strconv.Itoa(slot.UniqueCode), Inline: true},
+		DiscordEmbedField{Name: "Expires", Value: expiryTime.Format("15:04:05"), Inline: true},
+		DiscordEmbedField{Name: "Status", Value: "Pending", Inline: true},
+	)
 	sendCrowdfundingDiscordEmbed(
-		"🛒 New MicroBitcoin Order Created",
-		"A new MicroBitcoin payment order has been created.",
+		"🛒 New "+info.Name+" Order Created",
+		"A new "+info.Name+" payment order has been created.",
 		ColorBlue,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: name, Inline: true},
-			{Name: "Phone", Value: phoneNumber, Inline: true},
-			{Name: "NPM", Value: npm, Inline: true},
-			userWalletField,
-			{Name: "Destination", Value: MicroBitcoinWalletAddress, Inline: true},
-			{Name: "Expires", Value: expiryTime.Format("15:04:05"), Inline: true},
-			{Name: "Status", Value: "Pending", Inline: true},
-		},
+		fields,
 	)
-	// Set up expiry timer
-	go func() {
-		time.Sleep(MicroBitcoinExpirySeconds * time.Second)
-
-		// Check if this order is still the current one
-		var currentQueue model.CrowdfundingQueue
-		err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&currentQueue)
-		if err != nil {
-			log.Printf("Error checking MicroBitcoin queue for timeout: %v", err)
-			return
-		}
-
-		if currentQueue.CurrentOrderID == orderID {
-			// Update order status to failed
-			_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-				context.Background(),
-				bson.M{"orderId": orderID},
-				bson.M{"$set": bson.M{
-					"status":    "failed",
-					"updatedAt": time.Now(),
-				}},
-			)
-			if err != nil {
-				log.Printf("Error updating MicroBitcoin order status: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Status Update Failed",
-					"Failed to update expired MicroBitcoin order status.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
-
-			// Reset queue
-			_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-				context.Background(),
-				bson.M{},
-				bson.M{"$set": bson.M{
-					"isProcessing":   false,
-					"currentOrderId": "",
-					"paymentMethod":  "",
-					"expiryTime":     time.Time{},
-				}},
-			)
-			if err != nil {
-				log.Printf("Error resetting MicroBitcoin queue: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Queue Reset Failed",
-					"Failed to reset MicroBitcoin queue after order expiry.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
 
-			sendCrowdfundingDiscordEmbed(
-				"⏱️ MicroBitcoin Order Expired",
-				"A MicroBitcoin payment order has expired.",
-				ColorYellow,
-				[]DiscordEmbedField{
-					{Name: "Order ID", Value: orderID, Inline: true},
-					{Name: "Customer", Value: newOrder.Name, Inline: true},
-					{Name: "Status", Value: "Expired", Inline: true},
-				},
-			)
-		}
-	}()
+	// Set up expiry timer for this slot only
+	scheduleSlotExpiry(slot)
 
 	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 		Success:       true,
 		OrderID:       orderID,
 		ExpiryTime:    expiryTime,
-		QRImageURL:    "wonpay.png",
-		WalletAddress: MicroBitcoinWalletAddress,
-		PaymentMethod: model.MicroBitcoin,
+		QRISImageURL:  info.QRImageURL,
+		QRImageURL:    info.QRImageURL,
+		WalletAddress: info.WalletAddress,
+		PaymentMethod: info.Method,
+		Amount:        newOrder.Amount,
+		UniqueCode:    slot.UniqueCode,
+		PayAmount:     formatSlotPayAmount(newOrder),
 	})
 }
 
-// CheckPayment checks the status of a payment
+// CheckPayment checks the status of a payment. The generic /api/crowdfunding/checkPayment/:orderId runs step 1,
+// /api/crowdfunding/{provider}/checkPayment|checkStep2|checkStep3/:orderId run the matching step of that provider.
 func CheckPayment(w http.ResponseWriter, r *http.Request) {
 	orderID := at.GetParam(r)
 
+	step := 1
+	routeProvider, action := crowdfunding.ProviderAction(r.URL.Path)
+	switch action {
+	case "checkStep2":
+		step = 2
+	case "checkStep3":
+		step = 3
+	}
+
 	var order model.CrowdfundingOrder
 	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
 	if err != nil {
@@ -1011,564 +546,277 @@ func CheckPayment(w http.ResponseWriter, r *http.Request) {
 				{Name: "Status", Value: "Not Found", Inline: true},
 			},
 		)
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Order not found", ""))
+		return
+	}
+
+	p, ok := crowdfunding.Get(order.PaymentMethod)
+	if !ok || (routeProvider != nil && routeProvider.Info().Method != order.PaymentMethod) {
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "This endpoint is not for "+string(order.PaymentMethod)+" payments", ""))
 		return
 	}
+	info := p.Info()
+
+	// Release this order's slot if it has expired
+	if order.Status == "pending" && time.Now().After(order.ExpiryTime) {
+		CleanupExpiredSlots()
+		order.Status = "failed"
+	}
 
-	// For QRIS payments, just return the status
-	if order.PaymentMethod == model.QRIS {
+	// Finished orders and providers confirmed by notification only report the status
+	if order.Status != "pending" || step > info.Steps {
 		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 			Success:       true,
 			Status:        order.Status,
+			TxID:          order.TxID,
+			Amount:        order.Amount,
 			PaymentMethod: order.PaymentMethod,
+			UniqueCode:    order.UniqueCode,
+			PayAmount:     formatSlotPayAmount(order),
 		})
 		return
 	}
 
-	// For MicroBitcoin payments, if the payment is pending, check mempool
-	if order.PaymentMethod == model.MicroBitcoin && order.Status == "pending" {
-		// Check mempool (Step 1)
-		mempoolStatus, mempoolTxid, _, err := checkMicroBitcoinMempool()
-
-		if err != nil {
-			at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-				Success:       true,
-				Status:        "pending",
-				Message:       "Checking mempool failed: " + err.Error(),
-				Step1Complete: false,
-				Step2Complete: false,
-				Step3Complete: false,
-				PaymentMethod: model.MicroBitcoin,
-			})
-			return
-		}
-
-		if mempoolStatus && mempoolTxid != "" {
-			at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-				Success:       true,
-				Status:        "pending",
-				Message:       "Transaction found in mempool, waiting for confirmation.",
-				Step1Complete: true,
-				Step2Complete: false,
-				Step3Complete: false,
-				TxID:          mempoolTxid,
-				PaymentMethod: model.MicroBitcoin,
-			})
-			return
-		}
-
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "No transaction found yet. Please make the payment or wait if you've already sent it.",
-			Step1Complete: false,
-			Step2Complete: false,
-			Step3Complete: false,
-			PaymentMethod: model.MicroBitcoin,
-		})
+	if step == 1 {
+		checkCrowdfundingFirstStep(w, p, order)
 		return
 	}
 
-	// For Ravencoin payments, if the payment is pending, check address
-	if order.PaymentMethod == model.Ravencoin && order.Status == "pending" {
-		// Check Ravencoin address for unconfirmed transactions (Step 1)
-		addressStatus, txid, amount, err := checkRavencoinAddressAPI()
-
-		if err != nil {
-			at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-				Success:       true,
-				Status:        "pending",
-				Message:       "Checking Ravencoin address failed: " + err.Error(),
-				Step1Complete: false,
-				Step2Complete: false,
-				Step3Complete: false,
-				PaymentMethod: model.Ravencoin,
-			})
-			return
-		}
-
-		if addressStatus && txid != "" {
-			at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-				Success:       true,
-				Status:        "pending",
-				Message:       "Transaction found in unconfirmed transactions, waiting for confirmation.",
-				Step1Complete: true,
-				Step2Complete: false,
-				Step3Complete: false,
-				TxID:          txid,
-				Amount:        amount,
-				PaymentMethod: model.Ravencoin,
-			})
-			return
-		}
-
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "No transaction found yet. Please make the payment or wait if you've already sent it.",
-			Step1Complete: false,
-			Step2Complete: false,
-			Step3Complete: false,
-			PaymentMethod: model.Ravencoin,
-		})
+	txid := r.URL.Query().Get("txid")
+	if txid == "" {
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "Transaction ID is required", ""))
 		return
 	}
 
-	// For already processed payments
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		Status:        order.Status,
-		TxID:          order.TxID,
-		Amount:        order.Amount,
-		PaymentMethod: order.PaymentMethod,
-	})
-}
-
-// Step 1: Check mempool for transactions and extract txid properly
-func checkMicroBitcoinMempool() (bool, string, float64, error) {
-	// API URL for checking mempool
-	url := "https://api.mbc.wiki/mempool/" + MicroBitcoinWalletAddress
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, "", 0, err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var mempoolResp model.MicroBitcoinMempoolResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mempoolResp); err != nil {
-		return false, "", 0, err
-	}
-
-	// Check for errors in API response
-	if mempoolResp.Error != nil {
-		return false, "", 0, errors.New("API error: " + *mempoolResp.Error)
-	}
-
-	// Check if there are transactions in mempool
-	if mempoolResp.Result.TxCount > 0 && len(mempoolResp.Result.Tx) > 0 {
-		// Extract transaction ID from the first transaction in the mempool
-		tx := mempoolResp.Result.Tx[0]
-		// We only return the txid in Step 1, the actual amount will be determined in Step 3
-		return true, tx.TxID, 0, nil
-	}
-
-	return false, "", 0, nil
-}
-
-// Step 2: Check if transaction exists in history
-func checkMicroBitcoinTxHistory() (bool, string, error) {
-	// API URL for checking transaction history
-	url := "https://api.mbc.wiki/history/" + MicroBitcoinWalletAddress
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, "", err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var historyResp model.MicroBitcoinHistoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&historyResp); err != nil {
-		return false, "", err
-	}
-
-	// Check for errors in API response
-	if historyResp.Error != nil {
-		return false, "", errors.New("API error: " + *historyResp.Error)
-	}
-
-	// Check if there are any transactions in history
-	if historyResp.Result.TxCount > 0 && len(historyResp.Result.Tx) > 0 {
-		// Get the most recent transaction (first in the list)
-		if len(historyResp.Result.Tx) >= 1 {
-			return true, historyResp.Result.Tx[0], nil
-		}
+	// Make sure the transaction belongs to this order's slot
+	slot, ok := requireOrderSlotTx(w, r, order, txid)
+	if !ok {
+		return
 	}
 
-	return false, "", nil
-}
-
-// Step 3: Verify transaction details
-func checkMicroBitcoinTxDetails(txid string) (bool, float64, error) {
-	// API URL for getting transaction details
-	url := "https://api.mbc.wiki/transaction/" + txid
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+	result, err := p.Poll(slot, step, txid)
 	if err != nil {
-		return false, 0, err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var txResp model.MicroBitcoinTransactionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
-		return false, 0, err
-	}
-
-	// Check for errors in API response
-	if txResp.Error != nil {
-		return false, 0, errors.New("API error: " + *txResp.Error)
-	}
-
-	// Find the output that matches our wallet address
-	var amount int64 = 0
-	for _, vout := range txResp.Result.Vout {
-		// Check if this output is to our wallet address
-		for _, addr := range vout.ScriptPubKey.Addresses {
-			if addr == MicroBitcoinWalletAddress {
-				amount = vout.Value
-				break
-			}
-		}
-		// Alternative check using the single address field
-		if vout.ScriptPubKey.Address == MicroBitcoinWalletAddress {
-			amount = vout.Value
-			break
-		}
-	}
-
-	// Convert satoshis to MBC
-	amountMBC := float64(amount) / 100000000
-
-	// Transaction is valid if we found our address with some value
-	return amount > 0, amountMBC, nil
-}
-
-// CheckStep2Handler checks transaction history after the 7-minute delay
-func CheckStep2Handler(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
-	txid := r.URL.Query().Get("txid")
-
-	if txid == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", err.Error(), step-1, txid, 0))
 		return
 	}
-
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
-	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
+	if !result.Done {
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", result.Message, step-1, txid, 0))
 		return
 	}
-
-	// Verify this is a MicroBitcoin payment
-	if order.PaymentMethod != model.MicroBitcoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        order.Status,
-			Message:       "This endpoint is only for MicroBitcoin payments",
-			PaymentMethod: order.PaymentMethod,
-		})
+	if step < info.Steps {
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", result.Message, step, txid, 0))
 		return
 	}
 
-	// Step 2: Check history with the txid from step 1
-	historyStatus, historyTxid, err := checkMicroBitcoinTxHistory()
+	// The last step verified the transaction and returned the actual amount,
+	// update the order status to success with that amount
+	updateResult, err := config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
+		context.Background(),
+		bson.M{"orderId": orderID, "status": "pending"},
+		bson.M{"$set": bson.M{
+			"status":    "success",
+			"txid":      txid,
+			"amount":    result.Amount,
+			"updatedAt": time.Now(),
+		}},
+	)
 	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Checking transaction history failed: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: false,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.MicroBitcoin,
-		})
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", "Transaction verified but error updating order status: "+err.Error(), step-1, txid, result.Amount))
 		return
 	}
-
-	if historyStatus && historyTxid != "" {
-		// Transaction found in history, step 2 complete
+	if updateResult.ModifiedCount == 0 {
+		// Order was already completed or expired by another request
 		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction found in history, proceed to final verification.",
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
+			Status:        order.Status,
+			Message:       "Order is no longer pending",
 			TxID:          txid,
-			PaymentMethod: model.MicroBitcoin,
+			PaymentMethod: order.PaymentMethod,
 		})
 		return
 	}
 
-	// Transaction not found in history
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		Status:        "pending",
-		Message:       "Transaction not found in history yet. Please wait.",
-		Step1Complete: true,
-		Step2Complete: false,
-		Step3Complete: false,
-		TxID:          txid,
-		PaymentMethod: model.MicroBitcoin,
-	})
-}
+	finishCrowdfundingPayment(p, orderID, result.Amount)
 
-// CheckStep3Handler finalizes the payment after the step 2 delay
-func CheckStep3Handler(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
-	txid := r.URL.Query().Get("txid")
+	sendCrowdfundingDiscordEmbed(
+		"✅ "+info.Name+" Payment Successful",
+		"A "+info.Name+" payment has been confirmed automatically.",
+		ColorGreen,
+		[]DiscordEmbedField{
+			{Name: "Order ID", Value: orderID, Inline: true},
+			{Name: "Customer", Value: order.Name, Inline: true},
+			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
+			{Name: "NPM", Value: order.NPM, Inline: true},
+			{Name: "Transaction ID", Value: txid, Inline: true},
+			{Name: "Amount", Value: p.FormatAmount(result.Amount), Inline: true},
+		},
+	)
 
-	if txid == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
-		return
-	}
+	at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "success", result.Message, step, txid, result.Amount))
+}
 
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
+// checkCrowdfundingFirstStep looks for a new transaction of the order and binds it to the order's slot
+func checkCrowdfundingFirstStep(w http.ResponseWriter, p crowdfunding.Provider, order model.CrowdfundingOrder) {
+	// Pending payments are matched against the order's own slot
+	slot, err := crowdfunding.GetSlotByOrderID(order.OrderID)
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
-		return
-	}
-
-	// Verify this is a MicroBitcoin payment
-	if order.PaymentMethod != model.MicroBitcoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
+		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
 			Success:       true,
 			Status:        order.Status,
-			Message:       "This endpoint is only for MicroBitcoin payments",
+			Message:       "Payment slot for this order is no longer active",
 			PaymentMethod: order.PaymentMethod,
 		})
 		return
 	}
 
-	// Step 3: Verify transaction details and get the actual amount
-	txDetails, actualAmount, err := checkMicroBitcoinTxDetails(txid)
+	result, err := p.Poll(slot, 1, "")
 	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Error checking transaction details: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.MicroBitcoin,
-		})
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", err.Error(), 0, "", 0))
 		return
 	}
-
-	if !txDetails {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction details verification failed.",
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.MicroBitcoin,
-		})
+	if !result.Done {
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", result.Message, 0, "", 0))
 		return
 	}
-
-	// Now that we have verified the transaction and gotten the actual amount,
-	// update the order status to success with the final amount from Step 3
-	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-		context.Background(),
-		bson.M{"orderId": orderID},
-		bson.M{"$set": bson.M{
-			"status":    "success",
-			"txid":      txid,
-			"amount":    actualAmount,
-			"updatedAt": time.Now(),
-		}},
-	)
-	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction verified but error updating order status: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			Amount:        actualAmount,
-			PaymentMethod: model.MicroBitcoin,
-		})
+	if crowdfunding.ClaimSlotTx(order.OrderID, result.TxID) != nil {
+		at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", "No transaction found yet. Please make the payment or wait if you've already sent it.", 0, "", 0))
 		return
 	}
+	at.WriteJSON(w, http.StatusOK, crowdfundingStepResponse(order, "pending", result.Message, 1, result.TxID, result.Amount))
+}
+
+// crowdfundingStepResponse builds the polling response with the number of completed steps
+func crowdfundingStepResponse(order model.CrowdfundingOrder, status, message string, completed int, txid string, amount float64) model.CrowdfundingPaymentResponse {
+	return model.CrowdfundingPaymentResponse{
+		Success:       true,
+		Status:        status,
+		Message:       message,
+		Step1Complete: completed >= 1,
+		Step2Complete: completed >= 2,
+		Step3Complete: completed >= 3,
+		TxID:          txid,
+		Amount:        amount,
+		PaymentMethod: order.PaymentMethod,
+	}
+}
 
-	// Update payment totals with the actual amount from Step 3
-	updateCrowdfundingTotal(actualAmount, model.MicroBitcoin)
+// finishCrowdfundingPayment updates the totals, releases the slot and recalculates the points of a paid order
+func finishCrowdfundingPayment(p crowdfunding.Provider, orderID string, amount float64) {
+	// Update payment totals
+	updateCrowdfundingTotal(amount, p)
 
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
-	)
-	if err != nil {
-		log.Printf("Error resetting crowdfunding queue: %v", err)
+	// Release the payment slot of this order
+	if err := crowdfunding.ReleaseSlot(orderID); err != nil {
+		log.Printf("Error releasing crowdfunding slot: %v", err)
 	}
 
 	// Calculate payment points after successful payment
 	RecalculatePointsAfterPayment()
-
-	sendCrowdfundingDiscordEmbed(
-		"✅ MicroBitcoin Payment Successful",
-		"A MicroBitcoin payment has been confirmed automatically.",
-		ColorGreen,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: order.Name, Inline: true},
-			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
-			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Transaction ID", Value: txid, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("%f MBC", actualAmount), Inline: true},
-		},
-	)
-
-	// Return success response with the actual amount from Step 3
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		Status:        "success",
-		Message:       "Payment confirmed successfully!",
-		Step1Complete: true,
-		Step2Complete: true,
-		Step3Complete: true,
-		TxID:          txid,
-		Amount:        actualAmount,
-		PaymentMethod: model.MicroBitcoin,
-	})
 }
 
-// ConfirmQRISPayment manually confirms a QRIS payment
-func ConfirmQRISPayment(w http.ResponseWriter, r *http.Request) {
+// ConfirmCrowdfundingPayment manually confirms a payment for /api/crowdfunding/{provider}/confirm/:orderId,
+// the route requires the crowdfunding:konfirmasi permission
+func ConfirmCrowdfundingPayment(w http.ResponseWriter, r *http.Request) {
 	orderID := at.GetParam(r)
 
+	p, _ := crowdfunding.ProviderAction(r.URL.Path)
+	if p == nil {
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Unknown payment method", ""))
+		return
+	}
+	info := p.Info()
+
+	// Crypto confirmations carry txid and amount, QRIS confirmations have no body
+	var request crowdfunding.ConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Invalid request body", ""))
+		return
+	}
+
+	// Find the order
 	var order model.CrowdfundingOrder
 	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
 	if err != nil {
 		sendCrowdfundingDiscordEmbed(
 			"🔴 Error: Manual Confirmation Failed",
-			"Failed to confirm QRIS payment manually.",
+			"Failed to confirm "+info.Name+" payment manually.",
 			ColorRed,
 			[]DiscordEmbedField{
 				{Name: "Order ID", Value: orderID, Inline: true},
 				{Name: "Error", Value: "Order not found", Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Order not found", ""))
 		return
 	}
 
-	// Verify this is a QRIS payment
-	if order.PaymentMethod != model.QRIS {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "This endpoint is only for QRIS payments",
-			PaymentMethod: order.PaymentMethod,
-		})
+	if order.PaymentMethod != info.Method {
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "This endpoint is only for "+info.Name+" payments", ""))
 		return
 	}
 
-	// Update order status
-	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-		context.Background(),
-		bson.M{"orderId": orderID},
-		bson.M{"$set": bson.M{
-			"status":    "success",
-			"updatedAt": time.Now(),
-		}},
-	)
+	txid, amount, err := p.Confirm(order, request)
 	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Status Update Failed",
-			"Failed to update QRIS order status during manual confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Order ID", Value: orderID, Inline: true},
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating order status",
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, err.Error(), ""))
 		return
 	}
 
-	// Update payment totals
-	updateCrowdfundingTotal(order.Amount, model.QRIS)
+	// A transaction can only pay for one order
+	if txid != "" && crowdfunding.IsTxUsedBySuccessfulOrder(txid, orderID) {
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "Transaction already used by another order", ""))
+		return
+	}
 
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
+	// Update order status
+	set := bson.M{
+		"status":    "success",
+		"amount":    amount,
+		"updatedAt": time.Now(),
+	}
+	if txid != "" {
+		set["txid"] = txid
+	}
+	// Only pending orders can be confirmed, so a repeated confirmation or an expired slot is never paid twice
+	res, err := config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
 		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
+		bson.M{"orderId": orderID, "status": "pending"},
+		bson.M{"$set": set},
 	)
 	if err != nil {
 		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Reset Failed",
-			"Failed to reset queue after manual confirmation.",
+			"🔴 Error: Status Update Failed",
+			"Failed to update "+info.Name+" order status during manual confirmation.",
 			ColorRed,
 			[]DiscordEmbedField{
+				{Name: "Order ID", Value: orderID, Inline: true},
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error resetting queue",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error updating order status", ""))
+		return
+	}
+	if res.ModifiedCount == 0 {
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "Order is no longer pending", "Order status is "+order.Status))
 		return
 	}
 
-	// Calculate payment points after successful payment
-	RecalculatePointsAfterPayment()
+	finishCrowdfundingPayment(p, orderID, amount)
 
+	fields := []DiscordEmbedField{
+		{Name: "Order ID", Value: orderID, Inline: true},
+		{Name: "Customer", Value: order.Name, Inline: true},
+		{Name: "Phone", Value: order.PhoneNumber, Inline: true},
+		{Name: "NPM", Value: order.NPM, Inline: true},
+	}
+	if txid != "" {
+		fields = append(fields, DiscordEmbedField{Name: "Transaction ID", Value: txid, Inline: true})
+	}
+	fields = append(fields, DiscordEmbedField{Name: "Amount", Value: p.FormatAmount(amount), Inline: true})
 	sendCrowdfundingDiscordEmbed(
-		"✅ Manual QRIS Payment Confirmation",
-		"A QRIS payment has been confirmed manually.",
+		"✅ Manual "+info.Name+" Payment Confirmation",
+		"A "+info.Name+" payment has been confirmed manually.",
 		ColorGreen,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: order.Name, Inline: true},
-			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
-			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(order.Amount, 'f', 2, 64)), Inline: true},
-			{Name: "Status", Value: "Confirmed", Inline: true},
-		},
+		fields,
 	)
 
 	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
@@ -1577,157 +825,13 @@ func ConfirmQRISPayment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ConfirmMicroBitcoinPayment manually confirms a MicroBitcoin payment
-func ConfirmMicroBitcoinPayment(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
+func ProcessQRISNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	BasicAuth(ProcessQRISNotification)(w, r)
+}
 
-	// Parse request body to get txid and amount
-	var request struct {
-		TxID   string  `json:"txid"`
-		Amount float64 `json:"amount"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
-		return
-	}
-
-	// Validate the request
-	if request.TxID == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
-		return
-	}
-
-	if request.Amount <= 0 {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Amount must be greater than 0",
-		})
-		return
-	}
-
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Manual Confirmation Failed",
-			"Failed to confirm MicroBitcoin payment manually.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Order ID", Value: orderID, Inline: true},
-				{Name: "Error", Value: "Order not found", Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
-		return
-	}
-
-	// Verify this is a MicroBitcoin payment
-	if order.PaymentMethod != model.MicroBitcoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "This endpoint is only for MicroBitcoin payments",
-			PaymentMethod: order.PaymentMethod,
-		})
-		return
-	}
-
-	// Update order status
-	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-		context.Background(),
-		bson.M{"orderId": orderID},
-		bson.M{"$set": bson.M{
-			"status":    "success",
-			"txid":      request.TxID,
-			"amount":    request.Amount,
-			"updatedAt": time.Now(),
-		}},
-	)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Status Update Failed",
-			"Failed to update MicroBitcoin order status during manual confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Order ID", Value: orderID, Inline: true},
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating order status",
-		})
-		return
-	}
-
-	// Update payment totals
-	updateCrowdfundingTotal(request.Amount, model.MicroBitcoin)
-
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{"isProcessing": false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
-	)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Reset Failed",
-			"Failed to reset MicroBitcoin queue after manual confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error resetting queue",
-		})
-		return
-	}
-
-	// Calculate payment points after successful payment
-	RecalculatePointsAfterPayment()
-
-	sendCrowdfundingDiscordEmbed(
-		"✅ Manual MicroBitcoin Payment Confirmation",
-		"A MicroBitcoin payment has been confirmed manually.",
-		ColorGreen,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: order.Name, Inline: true},
-			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
-			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Transaction ID", Value: request.TxID, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("%f MBC", request.Amount), Inline: true},
-		},
-	)
-
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success: true,
-		Message: "Payment confirmed",
-	})
-}
-
-func ProcessQRISNotificationHandler(w http.ResponseWriter, r *http.Request) {
-	BasicAuth(ProcessQRISNotification)(w, r)
-}
-
-// ProcessQRISNotification processes QRIS payment notifications from payment gateway
-func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
-	var request model.NotificationRequest
+// ProcessQRISNotification processes QRIS payment notifications from payment gateway
+func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
+	var request model.NotificationRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		sendCrowdfundingDiscordEmbed(
 			"🔴 Error: Invalid Notification",
@@ -1737,10 +841,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Invalid request body", ""))
 		return
 	}
 
@@ -1766,10 +867,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 				{Name: "Reason", Value: "Not a QRIS payment notification", Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Not a QRIS payment notification",
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "Not a QRIS payment notification", ""))
 		return
 	}
 
@@ -1786,10 +884,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 				{Name: "Notification Text", Value: request.NotificationText, Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Cannot extract payment amount from notification",
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "Cannot extract payment amount from notification", ""))
 		return
 	}
 
@@ -1809,10 +904,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Invalid payment amount",
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "Invalid payment amount", ""))
 		return
 	}
 
@@ -1827,6 +919,11 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 		"paymentMethod": model.QRIS,
 	}
 
+	// The unique amount resolves to exactly one active slot
+	if slot, slotErr := crowdfunding.GetSlotByMatchKey(crowdfunding.SlotMatchKey(model.QRIS, int64(amount))); slotErr == nil {
+		filter["orderId"] = slot.OrderID
+	}
+
 	// Add sort by latest timestamp
 	opts := options.FindOne().SetSort(bson.M{"timestamp": -1})
 
@@ -1838,7 +935,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 			"No pending QRIS order found with the exact amount.",
 			ColorRed,
 			[]DiscordEmbedField{
-				{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(amount, 'f', 2, 64)), Inline: true},
+				{Name: "Amount", Value: crowdfunding.QRIS{}.FormatAmount(amount), Inline: true},
 				{Name: "Status", Value: "Failed to Match", Inline: true},
 				{Name: "Notification", Value: request.NotificationText, Inline: false},
 			},
@@ -1870,7 +967,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 	// Update order status to success
 	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
 		ctx,
-		bson.M{"orderId": order.OrderID},
+		bson.M{"orderId": order.OrderID, "status": "pending"},
 		bson.M{"$set": bson.M{
 			"status":    "success",
 			"updatedAt": time.Now(),
@@ -1886,45 +983,11 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 				{Name: "Error", Value: err.Error(), Inline: false},
 			},
 		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating order status",
-		})
-		return
-	}
-
-	// Update payment totals
-	updateCrowdfundingTotal(amount, model.QRIS)
-
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		ctx,
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
-	)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Reset Failed",
-			"Failed to reset queue after payment confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error resetting queue",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error updating order status", ""))
 		return
 	}
 
-	// Calculate payment points after successful payment
-	RecalculatePointsAfterPayment()
+	finishCrowdfundingPayment(crowdfunding.QRIS{}, order.OrderID, amount)
 
 	// Log successful confirmation
 	log.Printf("QRIS Payment confirmed from notification for amount: Rp%v, Order ID: %s", amount, order.OrderID)
@@ -1937,7 +1000,7 @@ func ProcessQRISNotification(w http.ResponseWriter, r *http.Request) {
 			{Name: "Customer", Value: order.Name, Inline: true},
 			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
 			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("Rp %s", strconv.FormatFloat(amount, 'f', 2, 64)), Inline: true},
+			{Name: "Amount", Value: crowdfunding.QRIS{}.FormatAmount(amount), Inline: true},
 			{Name: "Status", Value: "Confirmed", Inline: true},
 			{Name: "Notification", Value: request.NotificationText, Inline: false},
 		},
@@ -1981,10 +1044,7 @@ func GetUserCrowdfundingHistory(w http.ResponseWriter, r *http.Request) {
 	// Extract user info from token - updated to handle 6 return values
 	phoneNumber, _, _, _, _, err := extractUserInfoFromToken(r)
 	if err != nil {
-		at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Authentication failed: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Authentication failed: "+err.Error(), ""))
 		return
 	}
 
@@ -1994,10 +1054,7 @@ func GetUserCrowdfundingHistory(w http.ResponseWriter, r *http.Request) {
 
 	cursor, err := config.Mongoconn.Collection("crowdfundingorders").Find(context.Background(), filter, opts)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error retrieving payment history",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error retrieving payment history", ""))
 		return
 	}
 	defer cursor.Close(context.Background())
@@ -2005,10 +1062,7 @@ func GetUserCrowdfundingHistory(w http.ResponseWriter, r *http.Request) {
 	// Decode results
 	var orders []model.CrowdfundingOrder
 	if err := cursor.All(context.Background(), &orders); err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error parsing payment history",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Error parsing payment history", ""))
 		return
 	}
 
@@ -2029,10 +1083,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Authentication required",
-			})
+			at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Unauthorized: Authentication required", ""))
 			return
 		}
 
@@ -2040,10 +1091,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		authParts := strings.Split(authHeader, " ")
 		if len(authParts) != 2 || authParts[0] != "Basic" {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Invalid authentication format",
-			})
+			at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Unauthorized: Invalid authentication format", ""))
 			return
 		}
 
@@ -2051,10 +1099,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		payload, err := base64.StdEncoding.DecodeString(authParts[1])
 		if err != nil {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Invalid authentication credentials",
-			})
+			at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Unauthorized: Invalid authentication credentials", ""))
 			return
 		}
 
@@ -2062,10 +1107,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		pair := strings.SplitN(string(payload), ":", 2)
 		if len(pair) != 2 {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Invalid authentication credentials",
-			})
+			at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Unauthorized: Invalid authentication credentials", ""))
 			return
 		}
 
@@ -2075,10 +1117,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		if err != nil {
 			log.Printf("Warning: Could not retrieve auth credentials from database: %v.", err)
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Server error retrieving credentials",
-			})
+			at.WriteError(w, r, apperr.New(apperr.Database, "Unauthorized: Server error retrieving credentials", ""))
 			return
 		}
 
@@ -2086,10 +1125,7 @@ func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
 		if subtle.ConstantTimeCompare([]byte(pair[0]), []byte(dbCreds.Username)) != 1 ||
 			subtle.ConstantTimeCompare([]byte(pair[1]), []byte(dbCreds.Password)) != 1 {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-				Success: false,
-				Message: "Unauthorized: Invalid username or password",
-			})
+			at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "Unauthorized: Invalid username or password", ""))
 			return
 		}
 
@@ -2106,11 +1142,11 @@ func GetCrowdfundingDailyReport(w http.ResponseWriter, r *http.Request) {
 	// Run the daily crowdfunding report
 	err := report.RekapCrowdfundingHarian(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengirim rekap crowdfunding harian",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -2130,11 +1166,11 @@ func GetCrowdfundingWeeklyReport(w http.ResponseWriter, r *http.Request) {
 	// Run the weekly crowdfunding report
 	err := report.RekapCrowdfundingMingguan(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengirim rekap crowdfunding mingguan",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -2154,11 +1190,11 @@ func GetCrowdfundingTotalReport(w http.ResponseWriter, r *http.Request) {
 	// Run the total crowdfunding report
 	err := report.RekapCrowdfundingTotal(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengirim rekap crowdfunding total",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -2175,11 +1211,11 @@ func GetCrowdfundingUserData(w http.ResponseWriter, r *http.Request) {
 	// Get the phone number from URL parameter
 	phoneNumber := r.URL.Query().Get("phonenumber")
 	if phoneNumber == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.InvalidParam, model.Response{
 			Status:   "Error",
 			Info:     "Parameter 'phonenumber' diperlukan",
 			Response: "Missing parameter",
-		})
+		}))
 		return
 	}
 
@@ -2199,11 +1235,11 @@ func GetCrowdfundingUserData(w http.ResponseWriter, r *http.Request) {
 			errMsg += "QRIS error: " + err2.Error()
 		}
 
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengambil data crowdfunding",
 			Response: errMsg,
-		})
+		}))
 		return
 	}
 
@@ -2355,11 +1391,11 @@ func GetCrowdfundingGlobalReport(w http.ResponseWriter, r *http.Request) {
 	// Run the global crowdfunding report
 	err := report.RekapCrowdfundingGlobal(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengirim rekap crowdfunding global",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -2379,11 +1415,11 @@ func GetLogCrowdfundingGlobalReport(w http.ResponseWriter, r *http.Request) {
 	// Generate the global report without sending
 	msg, _, err := report.GenerateRekapCrowdfundingGlobal(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error",
 			Info:     "Gagal membuat log rekap crowdfunding global",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -2395,956 +1431,86 @@ func GetLogCrowdfundingGlobalReport(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// CreateRavencoinOrder creates a new Ravencoin payment order
-func CreateRavencoinOrder(w http.ResponseWriter, r *http.Request) {
-	// Extract user info from token
-	phoneNumber, name, npm, _, rvnwallet, err := extractUserInfoFromToken(r)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Authentication Failed",
-			"Failed to extract user information from token.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusUnauthorized, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Authentication failed: " + err.Error(),
-		})
-		return
-	}
+// RecalculatePointsAfterPayment recalculates all user payment points
+func RecalculatePointsAfterPayment() {
+	// Run the calculation in a goroutine to not block the current request
+	go func() {
+		err := CalculatePaymentPoints()
+		if err != nil {
+			log.Printf("Error recalculating payment points after new payment: %v", err)
+		} else {
+			log.Println("Successfully recalculated payment points after new payment")
+		}
+	}()
+}
+
+// CalculatePaymentPoints recalculates all payment points and updates the database
+func CalculatePaymentPoints() error {
+	log.Println("Starting payment points calculation...")
+
+	ctx := context.Background()
+	db := config.Mongoconn
 
-	// Check if someone is currently paying
-	var queue model.CrowdfundingQueue
-	err = config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&queue)
+	// Get all successful payments
+	filter := bson.M{"status": "success"}
+	cursor, err := db.Collection("crowdfundingorders").Find(ctx, filter)
 	if err != nil {
-		// Initialize queue if it doesn't exist
-		InitializeCrowdfundingQueue()
-	} else if queue.IsProcessing {
-		sendCrowdfundingDiscordEmbed(
-			"⏳ Queue: Ravencoin Payment in Progress",
-			"Another payment is already in progress.",
-			ColorYellow,
-			[]DiscordEmbedField{
-				{Name: "Customer", Value: name, Inline: true},
-				{Name: "Phone", Value: phoneNumber, Inline: true},
-				{Name: "Status", Value: "Queued", Inline: true},
-				{Name: "Payment Method", Value: string(queue.PaymentMethod), Inline: true},
-			},
-		)
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "Sedang ada pembayaran berlangsung. Silakan tunggu.",
-			QueueStatus:   true,
-			ExpiryTime:    queue.ExpiryTime,
-			PaymentMethod: queue.PaymentMethod,
-		})
-		return
+		log.Printf("Error fetching crowdfunding orders: %v", err)
+		return err
 	}
+	defer cursor.Close(ctx)
 
-	// Initialize Ravencoin last transactions before creating the order
-	// This ensures we have a baseline transaction count for comparison
-	initErr := InitializeRavencoinLastTransactions()
-	if initErr != nil {
-		log.Printf("Warning: Error initializing Ravencoin transaction count: %v", initErr)
-		// Continue anyway, as we can still attempt to process the payment
+	type methodTotal struct {
+		Amount float64
+		Count  int
 	}
-
-	// Create order ID
-	orderID := uuid.New().String()
-
-	// Set expiry time
-	expiryTime := time.Now().Add(RavencoinExpirySeconds * time.Second)
-
-	// Create new order in database
-	newOrder := model.CrowdfundingOrder{
-		OrderID:       orderID,
-		Name:          name,
-		PhoneNumber:   phoneNumber,
-		NPM:           npm,
-		WalletAddress: RavencoinWalletAddress,
-		PaymentMethod: model.Ravencoin,
-		Timestamp:     time.Now(),
-		ExpiryTime:    expiryTime,
-		Status:        "pending",
-		RVNwallet:     rvnwallet, // Include user's Ravencoin wallet
+	type userPayments struct {
+		Name        string
+		PhoneNumber string
+		Methods     map[model.PaymentMethod]*methodTotal
+		TotalCount  int
 	}
 
-	_, err = config.Mongoconn.Collection("crowdfundingorders").InsertOne(context.Background(), newOrder)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Database Error",
-			"Failed to create Ravencoin order in database.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error creating order",
-		})
-		return
-	}
+	// Group payments by user and payment method
+	userPaymentsMap := make(map[string]*userPayments)
+	methodTotals := make(map[model.PaymentMethod]*methodTotal)
 
-	// Update queue status
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   true,
-			"currentOrderId": orderID,
-			"paymentMethod":  model.Ravencoin,
-			"expiryTime":     expiryTime,
-		}},
-		options.Update().SetUpsert(true),
-	)
+	// Process each payment
+	for cursor.Next(ctx) {
+		var payment model.CrowdfundingOrder
+		if err := cursor.Decode(&payment); err != nil {
+			log.Printf("Error decoding payment: %v", err)
+			continue
+		}
 
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Update Failed",
-			"Failed to update Ravencoin payment queue.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
+		// Get or create user data
+		userData, exists := userPaymentsMap[payment.PhoneNumber]
+		if !exists {
+			userData = &userPayments{
+				Name:        payment.Name,
+				PhoneNumber: payment.PhoneNumber,
+				Methods:     make(map[model.PaymentMethod]*methodTotal),
+			}
+			userPaymentsMap[payment.PhoneNumber] = userData
+		}
 
-		// Try to clean up the created order since queue update failed
-		_, cleanupErr := config.Mongoconn.Collection("crowdfundingorders").DeleteOne(
-			context.Background(),
-			bson.M{"orderId": orderID},
-		)
-		if cleanupErr != nil {
-			log.Printf("Error cleaning up order after queue update failure: %v", cleanupErr)
+		// Update user and method data for registered payment methods
+		if _, ok := crowdfunding.Get(payment.PaymentMethod); ok {
+			if userData.Methods[payment.PaymentMethod] == nil {
+				userData.Methods[payment.PaymentMethod] = &methodTotal{}
+			}
+			userData.Methods[payment.PaymentMethod].Amount += payment.Amount
+			userData.Methods[payment.PaymentMethod].Count++
+
+			if methodTotals[payment.PaymentMethod] == nil {
+				methodTotals[payment.PaymentMethod] = &methodTotal{}
+			}
+			methodTotals[payment.PaymentMethod].Amount += payment.Amount
+			methodTotals[payment.PaymentMethod].Count++
 		}
 
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating queue",
-		})
-		return
-	}
-
-	// For Ravencoin Discord notification:
-	var userRvnWalletField DiscordEmbedField
-	if rvnwallet != "" {
-		userRvnWalletField = DiscordEmbedField{
-			Name:   "User Wallet",
-			Value:  rvnwallet,
-			Inline: true,
-		}
-	} else {
-		userRvnWalletField = DiscordEmbedField{
-			Name:   "User Wallet",
-			Value:  "Not provided",
-			Inline: true,
-		}
-	}
-
-	sendCrowdfundingDiscordEmbed(
-		"🛒 New Ravencoin Order Created",
-		"A new Ravencoin payment order has been created.",
-		ColorBlue,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: name, Inline: true},
-			{Name: "Phone", Value: phoneNumber, Inline: true},
-			{Name: "NPM", Value: npm, Inline: true},
-			userRvnWalletField,
-			{Name: "Destination", Value: RavencoinWalletAddress, Inline: true},
-			{Name: "Expires", Value: expiryTime.Format("15:04:05"), Inline: true},
-			{Name: "Status", Value: "Pending", Inline: true},
-		},
-	)
-
-	// Set up expiry timer
-	go func() {
-		time.Sleep(RavencoinExpirySeconds * time.Second)
-
-		// Check if this order is still the current one
-		var currentQueue model.CrowdfundingQueue
-		err := config.Mongoconn.Collection("crowdfundingqueue").FindOne(context.Background(), bson.M{}).Decode(&currentQueue)
-		if err != nil {
-			log.Printf("Error checking Ravencoin queue for timeout: %v", err)
-			return
-		}
-
-		if currentQueue.CurrentOrderID == orderID {
-			// Update order status to failed
-			_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-				context.Background(),
-				bson.M{"orderId": orderID},
-				bson.M{"$set": bson.M{
-					"status":    "failed",
-					"updatedAt": time.Now(),
-				}},
-			)
-			if err != nil {
-				log.Printf("Error updating Ravencoin order status: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Status Update Failed",
-					"Failed to update expired Ravencoin order status.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
-
-			// Reset queue
-			_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-				context.Background(),
-				bson.M{},
-				bson.M{"$set": bson.M{
-					"isProcessing":   false,
-					"currentOrderId": "",
-					"paymentMethod":  "",
-					"expiryTime":     time.Time{},
-				}},
-			)
-			if err != nil {
-				log.Printf("Error resetting Ravencoin queue: %v", err)
-				sendCrowdfundingDiscordEmbed(
-					"🔴 Error: Queue Reset Failed",
-					"Failed to reset Ravencoin queue after order expiry.",
-					ColorRed,
-					[]DiscordEmbedField{
-						{Name: "Error", Value: err.Error(), Inline: false},
-					},
-				)
-			}
-
-			sendCrowdfundingDiscordEmbed(
-				"⏱️ Ravencoin Order Expired",
-				"A Ravencoin payment order has expired.",
-				ColorYellow,
-				[]DiscordEmbedField{
-					{Name: "Order ID", Value: orderID, Inline: true},
-					{Name: "Customer", Value: newOrder.Name, Inline: true},
-					{Name: "Status", Value: "Expired", Inline: true},
-				},
-			)
-		}
-	}()
-
-	// Return success response with order details
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		OrderID:       orderID,
-		ExpiryTime:    expiryTime,
-		QRImageURL:    "ravencoin.png",
-		WalletAddress: RavencoinWalletAddress,
-		PaymentMethod: model.Ravencoin,
-	})
-}
-
-// Step 1: Check Ravencoin address for new transactions
-func checkRavencoinAddressAPI() (bool, string, float64, error) {
-	// API URL for checking Ravencoin address
-	url := "https://blockbook.ravencoin.org/api/v2/address/" + RavencoinWalletAddress
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, "", 0, err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var addressResp model.RavencoinAddressResponse
-	if err := json.NewDecoder(resp.Body).Decode(&addressResp); err != nil {
-		return false, "", 0, err
-	}
-
-	// Check current transaction count against last saved transaction count
-	var lastTxData model.RavencoinLastTransactions
-	err = config.Mongoconn.Collection("crowdfundinglastraventxs").FindOne(context.Background(), bson.M{}).Decode(&lastTxData)
-
-	// If no record found, initialize with current txs count
-	if err == mongo.ErrNoDocuments {
-		_, err = config.Mongoconn.Collection("crowdfundinglastraventxs").InsertOne(
-			context.Background(),
-			bson.M{
-				"lastTxCount": addressResp.Txs,
-				"lastUpdated": time.Now(),
-			},
-		)
-		if err != nil {
-			log.Printf("Error initializing Ravencoin last transactions: %v", err)
-		}
-		return false, "", 0, nil
-	} else if err != nil {
-		return false, "", 0, err
-	}
-
-	// No new transactions
-	if addressResp.Txs <= lastTxData.LastTxCount {
-		return false, "", 0, nil
-	}
-
-	// New transaction(s) found! Get the latest txid (first in the list)
-	if len(addressResp.Txids) > 0 {
-		latestTxid := addressResp.Txids[0]
-
-		// We'll get the actual amount in Step 3, for now just indicate we found a transaction
-		return true, latestTxid, 0, nil
-	}
-
-	return false, "", 0, nil
-}
-
-// Step 2: Check if transaction count has changed in the blockchain
-func checkRavencoinTxHistory(txid string) (bool, error) {
-	// API URL for checking Ravencoin address
-	url := "https://blockbook.ravencoin.org/api/v2/address/" + RavencoinWalletAddress
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var addressResp model.RavencoinAddressResponse
-	if err := json.NewDecoder(resp.Body).Decode(&addressResp); err != nil {
-		return false, err
-	}
-
-	// Get last transaction count
-	var lastTxData model.RavencoinLastTransactions
-	err = config.Mongoconn.Collection("crowdfundinglastraventxs").FindOne(context.Background(), bson.M{}).Decode(&lastTxData)
-	if err != nil {
-		return false, err
-	}
-
-	// If transaction count has increased, update the record and return success
-	if addressResp.Txs > lastTxData.LastTxCount {
-		// Update the last transaction count
-		_, err = config.Mongoconn.Collection("crowdfundinglastraventxs").UpdateOne(
-			context.Background(),
-			bson.M{},
-			bson.M{"$set": bson.M{
-				"lastTxCount": addressResp.Txs,
-				"lastUpdated": time.Now(),
-			}},
-		)
-		if err != nil {
-			log.Printf("Error updating Ravencoin last transactions: %v", err)
-		}
-
-		return true, nil
-	}
-
-	// No change in transaction count
-	return false, nil
-}
-
-// Step 3: Verify transaction details and get the actual amount
-func checkRavencoinTxDetails(txid string) (bool, float64, error) {
-	// API URL for getting transaction details
-	url := "https://blockbook.ravencoin.org/api/tx/" + txid
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, 0, err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var txResp model.RavencoinTransactionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
-		return false, 0, err
-	}
-
-	// Find the output that matches our wallet address
-	var amount float64 = 0
-	for _, vout := range txResp.Vout {
-		// Check if this output is to our wallet address
-		for _, addr := range vout.ScriptPubKey.Addresses {
-			if addr == RavencoinWalletAddress {
-				// Convert from string to float
-				outputAmount, err := strconv.ParseFloat(vout.Value, 64)
-				if err != nil {
-					continue
-				}
-				amount = outputAmount
-				break
-			}
-		}
-		if amount > 0 {
-			break
-		}
-	}
-
-	// Transaction is valid if we found our address with some value
-	return amount > 0, amount, nil
-}
-
-// CheckRavencoinStep2Handler checks transaction history without the 7-minute delay
-func CheckRavencoinStep2Handler(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
-	txid := r.URL.Query().Get("txid")
-
-	if txid == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
-		return
-	}
-
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
-	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
-		return
-	}
-
-	// Verify this is a Ravencoin payment
-	if order.PaymentMethod != model.Ravencoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        order.Status,
-			Message:       "This endpoint is only for Ravencoin payments",
-			PaymentMethod: order.PaymentMethod,
-		})
-		return
-	}
-
-	// Step 2: Check if transaction count has changed (no more timer)
-	historyStatus, err := checkRavencoinTxHistory(txid)
-	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Checking transaction history failed: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: false,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.Ravencoin,
-		})
-		return
-	}
-
-	if historyStatus {
-		// Transaction count has changed, step 2 complete - proceed directly to step 3
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction confirmed in blockchain, proceeding to verification.",
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.Ravencoin,
-		})
-		return
-	}
-
-	// Transaction count hasn't changed yet, continue polling
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		Status:        "pending",
-		Message:       "Waiting for transaction confirmation. Please keep checking.",
-		Step1Complete: true,
-		Step2Complete: false,
-		Step3Complete: false,
-		TxID:          txid,
-		PaymentMethod: model.Ravencoin,
-	})
-}
-
-// CheckRavencoinStep3Handler finalizes the payment without waiting
-func CheckRavencoinStep3Handler(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
-	txid := r.URL.Query().Get("txid")
-
-	if txid == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
-		return
-	}
-
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
-	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
-		return
-	}
-
-	// Verify this is a Ravencoin payment
-	if order.PaymentMethod != model.Ravencoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        order.Status,
-			Message:       "This endpoint is only for Ravencoin payments",
-			PaymentMethod: order.PaymentMethod,
-		})
-		return
-	}
-
-	// Step 3: Verify transaction details and get the actual amount (no delay)
-	txDetails, actualAmount, err := checkRavencoinTxDetails(txid)
-	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Error checking transaction details: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.Ravencoin,
-		})
-		return
-	}
-
-	if !txDetails {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction details verification failed.",
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			PaymentMethod: model.Ravencoin,
-		})
-		return
-	}
-
-	// Now that we have verified the transaction and gotten the actual amount,
-	// update the order status to success with the final amount from Step 3
-	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-		context.Background(),
-		bson.M{"orderId": orderID},
-		bson.M{"$set": bson.M{
-			"status":    "success",
-			"txid":      txid,
-			"amount":    actualAmount,
-			"updatedAt": time.Now(),
-		}},
-	)
-	if err != nil {
-		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-			Success:       true,
-			Status:        "pending",
-			Message:       "Transaction verified but error updating order status: " + err.Error(),
-			Step1Complete: true,
-			Step2Complete: true,
-			Step3Complete: false,
-			TxID:          txid,
-			Amount:        actualAmount,
-			PaymentMethod: model.Ravencoin,
-		})
-		return
-	}
-
-	// Update payment totals with the actual amount from Step 3
-	updateCrowdfundingTotal(actualAmount, model.Ravencoin)
-
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
-	)
-	if err != nil {
-		log.Printf("Error resetting crowdfunding queue: %v", err)
-	}
-
-	// Calculate payment points after successful payment
-	RecalculatePointsAfterPayment()
-
-	sendCrowdfundingDiscordEmbed(
-		"✅ Ravencoin Payment Successful",
-		"A Ravencoin payment has been confirmed automatically.",
-		ColorGreen,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: order.Name, Inline: true},
-			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
-			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Transaction ID", Value: txid, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("%f RVN", actualAmount), Inline: true},
-		},
-	)
-
-	// Return success response with the actual amount from Step 3
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success:       true,
-		Status:        "success",
-		Message:       "Payment confirmed successfully!",
-		Step1Complete: true,
-		Step2Complete: true,
-		Step3Complete: true,
-		TxID:          txid,
-		Amount:        actualAmount,
-		PaymentMethod: model.Ravencoin,
-	})
-}
-
-// ConfirmRavencoinPayment manually confirms a Ravencoin payment
-func ConfirmRavencoinPayment(w http.ResponseWriter, r *http.Request) {
-	orderID := at.GetParam(r)
-
-	// Parse request body to get txid and amount
-	var request struct {
-		TxID   string  `json:"txid"`
-		Amount float64 `json:"amount"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
-		return
-	}
-
-	// Validate the request
-	if request.TxID == "" {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Transaction ID is required",
-		})
-		return
-	}
-
-	if request.Amount <= 0 {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Amount must be greater than 0",
-		})
-		return
-	}
-
-	// Find the order
-	var order model.CrowdfundingOrder
-	err := config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&order)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Manual Confirmation Failed",
-			"Failed to confirm Ravencoin payment manually.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Order ID", Value: orderID, Inline: true},
-				{Name: "Error", Value: "Order not found", Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusNotFound, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Order not found",
-		})
-		return
-	}
-
-	// Verify this is a Ravencoin payment
-	if order.PaymentMethod != model.Ravencoin {
-		at.WriteJSON(w, http.StatusBadRequest, model.CrowdfundingPaymentResponse{
-			Success:       false,
-			Message:       "This endpoint is only for Ravencoin payments",
-			PaymentMethod: order.PaymentMethod,
-		})
-		return
-	}
-
-	// Update order status
-	_, err = config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
-		context.Background(),
-		bson.M{"orderId": orderID},
-		bson.M{"$set": bson.M{
-			"status":    "success",
-			"txid":      request.TxID,
-			"amount":    request.Amount,
-			"updatedAt": time.Now(),
-		}},
-	)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Status Update Failed",
-			"Failed to update Ravencoin order status during manual confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Order ID", Value: orderID, Inline: true},
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error updating order status",
-		})
-		return
-	}
-
-	// Update payment totals
-	updateCrowdfundingTotal(request.Amount, model.Ravencoin)
-
-	// Reset queue
-	_, err = config.Mongoconn.Collection("crowdfundingqueue").UpdateOne(
-		context.Background(),
-		bson.M{},
-		bson.M{"$set": bson.M{
-			"isProcessing":   false,
-			"currentOrderId": "",
-			"paymentMethod":  "",
-			"expiryTime":     time.Time{},
-		}},
-	)
-	if err != nil {
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Queue Reset Failed",
-			"Failed to reset Ravencoin queue after manual confirmation.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		at.WriteJSON(w, http.StatusInternalServerError, model.CrowdfundingPaymentResponse{
-			Success: false,
-			Message: "Error resetting queue",
-		})
-		return
-	}
-
-	// Calculate payment points after successful payment
-	RecalculatePointsAfterPayment()
-
-	sendCrowdfundingDiscordEmbed(
-		"✅ Manual Ravencoin Payment Confirmation",
-		"A Ravencoin payment has been confirmed manually.",
-		ColorGreen,
-		[]DiscordEmbedField{
-			{Name: "Order ID", Value: orderID, Inline: true},
-			{Name: "Customer", Value: order.Name, Inline: true},
-			{Name: "Phone", Value: order.PhoneNumber, Inline: true},
-			{Name: "NPM", Value: order.NPM, Inline: true},
-			{Name: "Transaction ID", Value: request.TxID, Inline: true},
-			{Name: "Amount", Value: fmt.Sprintf("%f RVN", request.Amount), Inline: true},
-		},
-	)
-
-	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
-		Success: true,
-		Message: "Payment confirmed",
-	})
-}
-
-// InitializeRavencoinLastTransactions initializes the last Ravencoin transactions collection if it doesn't exist
-func InitializeRavencoinLastTransactions() error {
-	// Always fetch the current transaction count from API for consistency
-	url := "https://blockbook.ravencoin.org/api/v2/address/" + RavencoinWalletAddress
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		log.Printf("Error fetching Ravencoin transaction count: %v", err)
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Ravencoin API Error",
-			"Failed to fetch transaction count from Ravencoin API.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var addressResp model.RavencoinAddressResponse
-	if err := json.NewDecoder(resp.Body).Decode(&addressResp); err != nil {
-		log.Printf("Error parsing Ravencoin address response: %v", err)
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Ravencoin API Response Error",
-			"Failed to parse response from Ravencoin API.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		return err
-	}
-
-	// Check if we have an existing record
-	var existingRecord model.RavencoinLastTransactions
-	findErr := config.Mongoconn.Collection("crowdfundinglastraventxs").FindOne(
-		context.Background(),
-		bson.M{},
-	).Decode(&existingRecord)
-
-	// Log the current transaction count and existing record for debugging
-	if findErr == nil {
-		log.Printf("Current Ravencoin tx count from API: %d, Existing record count: %d",
-			addressResp.Txs, existingRecord.LastTxCount)
-	} else {
-		log.Printf("Current Ravencoin tx count from API: %d, No existing record found",
-			addressResp.Txs)
-	}
-
-	// Update with current transaction count using upsert
-	updateResult, err := config.Mongoconn.Collection("crowdfundinglastraventxs").UpdateOne(
-		context.Background(),
-		bson.M{}, // Empty filter to match any document (there should be only one)
-		bson.M{"$set": bson.M{
-			"lastTxCount": addressResp.Txs,
-			"lastUpdated": time.Now(),
-		}},
-		options.Update().SetUpsert(true), // Create if it doesn't exist
-	)
-
-	if err != nil {
-		log.Printf("Error updating Ravencoin last transactions: %v", err)
-		sendCrowdfundingDiscordEmbed(
-			"🔴 Error: Database Update Failed",
-			"Failed to update Ravencoin transaction count in database.",
-			ColorRed,
-			[]DiscordEmbedField{
-				{Name: "Error", Value: err.Error(), Inline: false},
-			},
-		)
-		return err
-	}
-
-	// Log the update result
-	if updateResult.UpsertedCount > 0 {
-		log.Printf("Created new Ravencoin transaction count record with count: %d", addressResp.Txs)
-		sendCrowdfundingDiscordEmbed(
-			"✅ System: Ravencoin Transactions Initialized",
-			"Successfully initialized the Ravencoin transactions tracking.",
-			ColorGreen,
-			[]DiscordEmbedField{
-				{Name: "Initial Transaction Count", Value: fmt.Sprintf("%d", addressResp.Txs), Inline: false},
-			},
-		)
-	} else if updateResult.ModifiedCount > 0 {
-		log.Printf("Updated existing Ravencoin transaction count to: %d", addressResp.Txs)
-		sendCrowdfundingDiscordEmbed(
-			"✅ System: Ravencoin Transactions Updated",
-			"Successfully updated the Ravencoin transactions count.",
-			ColorGreen,
-			[]DiscordEmbedField{
-				{Name: "Transaction Count", Value: fmt.Sprintf("%d", addressResp.Txs), Inline: false},
-			},
-		)
-	} else {
-		log.Printf("Ravencoin transaction count already up to date: %d", addressResp.Txs)
-	}
-
-	return nil
-}
-
-// RecalculatePointsAfterPayment recalculates all user payment points
-func RecalculatePointsAfterPayment() {
-	// Run the calculation in a goroutine to not block the current request
-	go func() {
-		err := CalculatePaymentPoints()
-		if err != nil {
-			log.Printf("Error recalculating payment points after new payment: %v", err)
-		} else {
-			log.Println("Successfully recalculated payment points after new payment")
-		}
-	}()
-}
-
-// CalculatePaymentPoints recalculates all payment points and updates the database
-func CalculatePaymentPoints() error {
-	log.Println("Starting payment points calculation...")
-
-	ctx := context.Background()
-	db := config.Mongoconn
-
-	// Get all successful payments
-	filter := bson.M{"status": "success"}
-	cursor, err := db.Collection("crowdfundingorders").Find(ctx, filter)
-	if err != nil {
-		log.Printf("Error fetching crowdfunding orders: %v", err)
-		return err
-	}
-	defer cursor.Close(ctx)
-
-	// Group payments by user and payment method
-	userPaymentsMap := make(map[string]*struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalCount      int
-	})
-
-	var allPayments []model.CrowdfundingOrder
-
-	// Process each payment
-	for cursor.Next(ctx) {
-		var payment model.CrowdfundingOrder
-		if err := cursor.Decode(&payment); err != nil {
-			log.Printf("Error decoding payment: %v", err)
-			continue
-		}
-
-		allPayments = append(allPayments, payment)
-
-		// Get or create user data
-		userData, exists := userPaymentsMap[payment.PhoneNumber]
-		if !exists {
-			userData = &struct {
-				Name            string
-				PhoneNumber     string
-				QRISAmount      float64
-				QRISCount       int
-				MBCAmount       float64
-				MBCCount        int
-				RavencoinAmount float64
-				RavencoinCount  int
-				TotalCount      int
-			}{
-				Name:        payment.Name,
-				PhoneNumber: payment.PhoneNumber,
-			}
-			userPaymentsMap[payment.PhoneNumber] = userData
-		}
-
-		// Update user data based on payment method
-		switch payment.PaymentMethod {
-		case model.QRIS:
-			userData.QRISAmount += payment.Amount
-			userData.QRISCount++
-		case model.MicroBitcoin:
-			userData.MBCAmount += payment.Amount
-			userData.MBCCount++
-		case model.Ravencoin:
-			userData.RavencoinAmount += payment.Amount
-			userData.RavencoinCount++
-		}
-
-		// Update total
-		userData.TotalCount++
+		// Update total
+		userData.TotalCount++
 	}
 
 	if cursor.Err() != nil {
@@ -3352,838 +1518,197 @@ func CalculatePaymentPoints() error {
 		return cursor.Err()
 	}
 
-	// Calculate averages for each payment method
-	var qrisTotal, mbcTotal, ravencoinTotal float64
-	var qrisCount, mbcCount, ravencoinCount int
-
-	for _, payment := range allPayments {
-		switch payment.PaymentMethod {
-		case model.QRIS:
-			qrisTotal += payment.Amount
-			qrisCount++
-		case model.MicroBitcoin:
-			mbcTotal += payment.Amount
-			mbcCount++
-		case model.Ravencoin:
-			ravencoinTotal += payment.Amount
-			ravencoinCount++
-		}
-	}
-
-	// Calculate averages
-	qrisAvg := 0.0
-	if qrisCount > 0 {
-		qrisAvg = qrisTotal / float64(qrisCount)
-	}
-
-	mbcAvg := 0.0
-	if mbcCount > 0 {
-		mbcAvg = mbcTotal / float64(mbcCount)
-	}
-
-	ravencoinAvg := 0.0
-	if ravencoinCount > 0 {
-		ravencoinAvg = ravencoinTotal / float64(ravencoinCount)
-	}
-
-	log.Printf("Averages - QRIS: %f, MBC: %f, Ravencoin: %f", qrisAvg, mbcAvg, ravencoinAvg)
-
-	// Calculate points for each user and update the database
-	pointsCollection := db.Collection("crowdfundingpoints")
-
-	// For each user, update or insert their points
-	for phoneNumber, userData := range userPaymentsMap {
-		// Calculate points for each payment method
-		qrisPoints := 0.0
-		if userData.QRISCount > 0 && qrisAvg > 0 {
-			qrisPoints = (userData.QRISAmount / qrisAvg) * 100
-		}
-
-		mbcPoints := 0.0
-		if userData.MBCCount > 0 && mbcAvg > 0 {
-			mbcPoints = (userData.MBCAmount / mbcAvg) * 100
-		}
-
-		ravencoinPoints := 0.0
-		if userData.RavencoinCount > 0 && ravencoinAvg > 0 {
-			ravencoinPoints = (userData.RavencoinAmount / ravencoinAvg) * 100
-		}
-
-		// Calculate total points
-		totalPoints := qrisPoints + mbcPoints + ravencoinPoints
-
-		// Prepare data for update/insert
-		pointsData := bson.M{
-			"phoneNumber":     phoneNumber,
-			"name":            userData.Name,
-			"qrisPoints":      qrisPoints,
-			"qrisAmount":      userData.QRISAmount,
-			"qrisCount":       userData.QRISCount,
-			"mbcPoints":       mbcPoints,
-			"mbcAmount":       userData.MBCAmount,
-			"mbcCount":        userData.MBCCount,
-			"ravencoinPoints": ravencoinPoints,
-			"ravencoinAmount": userData.RavencoinAmount,
-			"ravencoinCount":  userData.RavencoinCount,
-			"totalPoints":     totalPoints,
-			"totalCount":      userData.TotalCount,
-			"lastUpdated":     time.Now(),
-		}
-
-		// Update or insert
-		filter := bson.M{"phoneNumber": phoneNumber}
-		update := bson.M{"$set": pointsData}
-		opts := options.Update().SetUpsert(true)
-
-		_, err := pointsCollection.UpdateOne(ctx, filter, update, opts)
-		if err != nil {
-			log.Printf("Error updating points for user %s: %v", phoneNumber, err)
-			continue
-		}
-	}
-
-	log.Printf("Successfully updated payment points for %d users", len(userPaymentsMap))
-	return nil
-}
-
-// point semuanya
-// GetAllDataMicroBitcoinScore retrieves the MicroBitcoin (MBC) payments and calculates score
-func GetAllDataMicroBitcoinScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Query successful MicroBitcoin payments for this user
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.MicroBitcoin,
-		"status":        "success",
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalMBC float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
-	}
-
-	// Sum up the total MBC amount
-	for _, payment := range payments {
-		totalMBC += float32(payment.Amount)
-	}
-
-	// Calculate blockchain score based on total MBC
-	blockchainScore := calculateBlockchainScore(db, totalMBC, model.MicroBitcoin)
-
-	// Calculate MBC points - we'll use a similar approach as in PaymentPointsData
-	mbcPoints := calculateMBCPoints(db, totalMBC)
-
-	// Set the activity score values
-	activityScore.MBC = totalMBC
-	activityScore.MBCPoints = mbcPoints
-	activityScore.BlockChain = blockchainScore
-	activityScore.PhoneNumber = phoneNumber
-	activityScore.CreatedAt = time.Now()
-
-	return activityScore, nil
-}
-
-// GetLastWeekDataMicroBitcoinScore gets MBC data for the last week only
-func GetLastWeekDataMicroBitcoinScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful MicroBitcoin payments for this user from the last week
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.MicroBitcoin,
-		"status":        "success",
-		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
-		},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalMBC float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
-	}
-
-	// Sum up the total MBC amount
-	for _, payment := range payments {
-		totalMBC += float32(payment.Amount)
-	}
-
-	// Calculate blockchain score based on total MBC
-	blockchainScore := calculateBlockchainScore(db, totalMBC, model.MicroBitcoin)
-
-	// Calculate MBC points
-	mbcPoints := calculateMBCPoints(db, totalMBC)
-
-	// Set the activity score values
-	activityScore.MBC = totalMBC
-	activityScore.MBCPoints = mbcPoints
-	activityScore.BlockChain = blockchainScore
-	activityScore.PhoneNumber = phoneNumber
-	activityScore.CreatedAt = time.Now()
-
-	return activityScore, nil
-}
-
-// GetAllDataRavencoinScore retrieves the Ravencoin (RVN) payments and calculates score
-func GetAllDataRavencoinScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Query successful Ravencoin payments for this user
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.Ravencoin,
-		"status":        "success",
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalRVN float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
-	}
-
-	// Sum up the total RVN amount
-	for _, payment := range payments {
-		totalRVN += float32(payment.Amount)
-	}
-
-	// Calculate Ravencoin points
-	ravencoinPoints := calculateRavencoinPoints(db, totalRVN)
-
-	// Calculate Ravencoin score using the new function
-	ravencoinScore := calculateRavencoinScore(db, totalRVN)
-
-	// Set the activity score values
-	activityScore.RVN = totalRVN
-	activityScore.RavencoinPoints = ravencoinPoints
-	// Add the Ravencoin score to the Blockchain field
-	activityScore.BlockChain = ravencoinScore
-	activityScore.PhoneNumber = phoneNumber
-	activityScore.CreatedAt = time.Now()
-
-	return activityScore, nil
-}
-
-// GetLastWeekDataRavencoinScore gets RVN data for the last week only
-func GetLastWeekDataRavencoinScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful Ravencoin payments for this user from the last week
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.Ravencoin,
-		"status":        "success",
-		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
-		},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return activityScore, err
+	// Calculate averages for each payment method
+	averages := make(map[model.PaymentMethod]float64)
+	var averageLog []string
+	for _, p := range crowdfunding.Providers() {
+		info := p.Info()
+		if total := methodTotals[info.Method]; total != nil && total.Count > 0 {
+			averages[info.Method] = total.Amount / float64(total.Count)
+		}
+		averageLog = append(averageLog, fmt.Sprintf("%s: %f", info.Symbol, averages[info.Method]))
 	}
-	defer cursor.Close(context.Background())
+	log.Printf("Averages - %s", strings.Join(averageLog, ", "))
 
-	// Process payments
-	var totalRVN float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
-	}
+	// Calculate points for each user and update the database
+	pointsCollection := db.Collection("crowdfundingpoints")
 
-	// Sum up the total RVN amount
-	for _, payment := range payments {
-		totalRVN += float32(payment.Amount)
-	}
+	// For each user, update or insert their points
+	for phoneNumber, userData := range userPaymentsMap {
+		pointsData := bson.M{
+			"phoneNumber": phoneNumber,
+			"name":        userData.Name,
+			"totalCount":  userData.TotalCount,
+			"lastUpdated": time.Now(),
+		}
 
-	// Calculate Ravencoin points
-	ravencoinPoints := calculateRavencoinPoints(db, totalRVN)
+		// Points per payment method: (amount / average) * 100
+		totalPoints := 0.0
+		for _, p := range crowdfunding.Providers() {
+			info := p.Info()
+			var amount float64
+			var count int
+			points := 0.0
+			if total := userData.Methods[info.Method]; total != nil {
+				amount, count = total.Amount, total.Count
+				if avg := averages[info.Method]; count > 0 && avg > 0 {
+					points = (amount / avg) * 100
+				}
+			}
+			pointsData[info.PointsKey+"Points"] = points
+			pointsData[info.PointsKey+"Amount"] = amount
+			pointsData[info.PointsKey+"Count"] = count
+			totalPoints += points
+		}
+		pointsData["totalPoints"] = totalPoints
 
-	// Calculate Ravencoin score using the new function
-	ravencoinScore := calculateRavencoinScore(db, totalRVN)
+		// Update or insert
+		filter := bson.M{"phoneNumber": phoneNumber}
+		update := bson.M{"$set": pointsData}
+		opts := options.Update().SetUpsert(true)
 
-	// Set the activity score values
-	activityScore.RVN = totalRVN
-	activityScore.RavencoinPoints = ravencoinPoints
-	// Add the Ravencoin score to the Blockchain field
-	activityScore.BlockChain = ravencoinScore
-	activityScore.PhoneNumber = phoneNumber
-	activityScore.CreatedAt = time.Now()
+		_, err := pointsCollection.UpdateOne(ctx, filter, update, opts)
+		if err != nil {
+			log.Printf("Error updating points for user %s: %v", phoneNumber, err)
+			continue
+		}
+	}
 
-	return activityScore, nil
+	log.Printf("Successfully updated payment points for %d users", len(userPaymentsMap))
+	return nil
 }
 
-// GetAllDataQRISScore retrieves the QRIS payments and calculates score
-func GetAllDataQRISScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Query successful QRIS payments for this user
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.QRIS,
-		"status":        "success",
+// crowdfundingActivityScore sums the successful payments of one method that match the filter
+// and lets the provider convert the total to activity score fields
+func crowdfundingActivityScore(ctx context.Context, db *mongo.Database, paymentMethod model.PaymentMethod, filter bson.M) (resultid []primitive.ObjectID, activityScore model.ActivityScore, err error) {
+	p, ok := crowdfunding.Get(paymentMethod)
+	if !ok {
+		return nil, activityScore, fmt.Errorf("unknown payment method: %s", paymentMethod)
 	}
+	filter["paymentMethod"] = paymentMethod
+	filter["status"] = "success"
 
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
+	cursor, err := db.Collection("crowdfundingorders").Find(ctx, filter)
 	if err != nil {
-		return activityScore, err
+		return nil, activityScore, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
-	// Process payments
-	var totalRupiah int = 0
 	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
+	if err = cursor.All(ctx, &payments); err != nil {
+		return nil, activityScore, err
 	}
 
-	// Sum up the total QRIS amount
+	// Sum up the total amount and collect IDs
+	var total float64
 	for _, payment := range payments {
-		totalRupiah += int(payment.Amount)
+		resultid = append(resultid, payment.ID)
+		total += payment.Amount
 	}
 
-	// Calculate QRIS score based on total amount
-	qrisScore := calculateQRISScore(db, totalRupiah)
-
-	// Calculate QRIS points
-	qrisPoints := calculateQRISPoints(db, totalRupiah)
+	points, score := p.Points(db, total)
+	p.ApplyScore(&activityScore, total, points, score)
 
-	// Set the activity score values
-	activityScore.Rupiah = totalRupiah
-	activityScore.QRISPoints = qrisPoints
-	activityScore.QRIS = qrisScore
-	activityScore.PhoneNumber = phoneNumber
-	activityScore.CreatedAt = time.Now()
-
-	return activityScore, nil
+	return resultid, activityScore, nil
 }
 
-// GetLastWeekDataQRISScore gets QRIS data for the last week only
-func GetLastWeekDataQRISScore(db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
-	var activityScore model.ActivityScore
-
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful QRIS payments for this user from the last week
-	filter := bson.M{
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.QRIS,
-		"status":        "success",
+// lastWeekFilter returns the filter of payments made by the user in the running academic week,
+// kelas selects the class cutoff from the academic period (empty uses the main cutoff)
+func lastWeekFilter(db *mongo.Database, phoneNumber, kelas string) bson.M {
+	weekStart, weekEnd := periode.Get(db).MingguIni(kelas)
+	return bson.M{
+		"phoneNumber": phoneNumber,
 		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
+			"$gte": weekStart,
+			"$lt":  weekEnd,
 		},
 	}
+}
 
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
+// point semuanya
+// GetAllDataMicroBitcoinScore retrieves the MicroBitcoin (MBC) payments and calculates score
+func GetAllDataMicroBitcoinScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.MicroBitcoin, bson.M{"phoneNumber": phoneNumber})
 	if err != nil {
 		return activityScore, err
 	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalRupiah int = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return activityScore, err
-	}
-
-	// Sum up the total QRIS amount
-	for _, payment := range payments {
-		totalRupiah += int(payment.Amount)
-	}
-
-	// Calculate QRIS score based on total amount
-	qrisScore := calculateQRISScore(db, totalRupiah)
-
-	// Calculate QRIS points
-	qrisPoints := calculateQRISPoints(db, totalRupiah)
-
-	// Set the activity score values
-	activityScore.Rupiah = totalRupiah
-	activityScore.QRISPoints = qrisPoints
-	activityScore.QRIS = qrisScore
 	activityScore.PhoneNumber = phoneNumber
 	activityScore.CreatedAt = time.Now()
-
 	return activityScore, nil
 }
 
-// Helper function to calculate MBC points
-func calculateMBCPoints(db *mongo.Database, amount float32) float64 {
-	// Get average MBC amount
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": model.MicroBitcoin,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
-	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		avgAmount = 0.001 // Default average for MBC
-	}
-
-	// Calculate points: (user's amount / average amount) * 100
-	points := (float64(amount) / avgAmount) * 100
-	// Cap points at 100
-	if points > 100 {
-		points = 100
-	}
-
-	return points
-}
-
-// Helper function to calculate Ravencoin points
-func calculateRavencoinPoints(db *mongo.Database, amount float32) float64 {
-	// Get average Ravencoin amount
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": model.Ravencoin,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+// GetLastWeekDataMicroBitcoinScore gets MBC data for the last week only
+func GetLastWeekDataMicroBitcoinScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.MicroBitcoin, lastWeekFilter(db, phoneNumber, ""))
 	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		avgAmount = 1 // Default average for Ravencoin
-	}
-
-	// Calculate points: (user's amount / average amount) * 100
-	points := (float64(amount) / avgAmount) * 100
-
-	if points > 100 {
-		points = 100
+		return activityScore, err
 	}
-
-	return points
+	activityScore.PhoneNumber = phoneNumber
+	activityScore.CreatedAt = time.Now()
+	return activityScore, nil
 }
 
-// Helper function to calculate QRIS points
-func calculateQRISPoints(db *mongo.Database, amount int) float64 {
-	// Get average QRIS payment amount
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": model.QRIS,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+// GetAllDataRavencoinScore retrieves the Ravencoin (RVN) payments and calculates score
+func GetAllDataRavencoinScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.Ravencoin, bson.M{"phoneNumber": phoneNumber})
 	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		avgAmount = 10000 // Default average QRIS amount (IDR)
-	}
-
-	// Calculate points: (user's amount / average amount) * 100
-	points := (float64(amount) / avgAmount) * 100
-
-	// Cap points at 100
-	if points > 100 {
-		points = 100
+		return activityScore, err
 	}
-
-	return points
+	activityScore.PhoneNumber = phoneNumber
+	activityScore.CreatedAt = time.Now()
+	return activityScore, nil
 }
 
-// Helper function to calculate QRIS score based on payment amount
-func calculateQRISScore(db *mongo.Database, amount int) int {
-	// Get average QRIS payment amount
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": model.QRIS,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+// GetLastWeekDataRavencoinScore gets RVN data for the last week only
+func GetLastWeekDataRavencoinScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.Ravencoin, lastWeekFilter(db, phoneNumber, ""))
 	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		avgAmount = 10000 // Default average QRIS amount (IDR)
+		return activityScore, err
 	}
-
-	// Calculate score: (user's amount / average amount) * 100
-	score := int((float64(amount) / avgAmount) * 100)
-
-	// if score > 100 {
-	//     score = 100
-	// }
-
-	return score
+	activityScore.PhoneNumber = phoneNumber
+	activityScore.CreatedAt = time.Now()
+	return activityScore, nil
 }
 
-// Helper function to calculate Ravencoin score based on payment amount
-func calculateRavencoinScore(db *mongo.Database, amount float32) int {
-	// Get average Ravencoin payment amount
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": model.Ravencoin,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+// GetAllDataQRISScore retrieves the QRIS payments and calculates score
+func GetAllDataQRISScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.QRIS, bson.M{"phoneNumber": phoneNumber})
 	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		avgAmount = 1 // Default average for Ravencoin
+		return activityScore, err
 	}
-
-	// Calculate score: (user's amount / average amount) * 100
-	score := int((float64(amount) / avgAmount) * 100)
-
-	// No capping at 100 for score calculation
-	return score
+	activityScore.PhoneNumber = phoneNumber
+	activityScore.CreatedAt = time.Now()
+	return activityScore, nil
 }
 
-// Helper function to calculate blockchain score based on payment amount
-func calculateBlockchainScore(db *mongo.Database, amount float32, paymentMethod model.PaymentMethod) int {
-	// Get average payment amount for this payment method
-	var avgAmount float64
-
-	// Aggregate to find average - using properly keyed fields
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"paymentMethod": paymentMethod,
-			"status":        "success",
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":       nil,
-			"avgAmount": bson.M{"$avg": "$amount"},
-		}}},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+// GetLastWeekDataQRISScore gets QRIS data for the last week only
+func GetLastWeekDataQRISScore(ctx context.Context, db *mongo.Database, phoneNumber string) (model.ActivityScore, error) {
+	_, activityScore, err := crowdfundingActivityScore(ctx, db, model.QRIS, lastWeekFilter(db, phoneNumber, ""))
 	if err != nil {
-		return 0
-	}
-	defer cursor.Close(context.Background())
-
-	// Extract result
-	var result struct {
-		AvgAmount float64 `bson:"avgAmount"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err != nil {
-			return 0
-		}
-		avgAmount = result.AvgAmount
-	}
-
-	// If no average found or it's zero, use a default value
-	if avgAmount <= 0 {
-		if paymentMethod == model.MicroBitcoin {
-			avgAmount = 0.001 // Default average for MBC
-		} else {
-			avgAmount = 1 // Default average for other methods
-		}
+		return activityScore, err
 	}
-
-	// Calculate score: (user's amount / average amount) * 100, max 100
-	score := int((float64(amount) / avgAmount) * 100)
-
-	// // Cap score at 100
-	// if score > 100 {
-	// 	score = 100
-	// }
-
-	return score
+	activityScore.PhoneNumber = phoneNumber
+	activityScore.CreatedAt = time.Now()
+	return activityScore, nil
 }
 
-// GetLastWeekDataMicroBitcoinScore gets MBC data for the last week only
+// GetLastWeekDataMicroBitcoinScoreKelas gets MBC data for the last week only
 func GetLastWeekDataMicroBitcoinScoreKelas(db *mongo.Database, phoneNumber string, usedIDs []primitive.ObjectID) (resultid []primitive.ObjectID, activityScore model.ActivityScore, err error) {
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful MicroBitcoin payments for this user from the last week
-	filter := bson.M{
-		"_id":           bson.M{"$nin": usedIDs},
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.MicroBitcoin,
-		"status":        "success",
-		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
-		},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return nil, activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalMBC float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return nil, activityScore, err
-	}
-
-	// Sum up the total MBC amount
-	for _, payment := range payments {
-		resultid = append(resultid, payment.ID)
-		totalMBC += float32(payment.Amount)
-	}
-
-	// Calculate blockchain score based on total MBC
-	blockchainScore := calculateBlockchainScore(db, totalMBC, model.MicroBitcoin)
-
-	// Calculate MBC points
-	mbcPoints := calculateMBCPoints(db, totalMBC)
-
-	// Set the activity score values
-	activityScore.MBC = totalMBC
-	activityScore.MBCPoints = mbcPoints
-	activityScore.BlockChain = blockchainScore
-
-	return resultid, activityScore, nil
+	filter := lastWeekFilter(db, phoneNumber, "kelasai")
+	filter["_id"] = bson.M{"$nin": usedIDs}
+	return crowdfundingActivityScore(context.Background(), db, model.MicroBitcoin, filter)
 }
 
-// GetLastWeekDataRavencoinScoreKelasAI gets RVN data for the last week only for KelasAI
+// GetLastWeekDataRavencoinScoreKelas gets RVN data for the last week only for KelasAI
 func GetLastWeekDataRavencoinScoreKelas(db *mongo.Database, phoneNumber string, usedIDs []primitive.ObjectID) (resultid []primitive.ObjectID, activityScore model.ActivityScore, err error) {
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful Ravencoin payments for this user from the last week
-	filter := bson.M{
-		"_id":           bson.M{"$nin": usedIDs},
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.Ravencoin,
-		"status":        "success",
-		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
-		},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return nil, activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalRVN float32 = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return nil, activityScore, err
-	}
-
-	// Sum up the total RVN amount and collect IDs
-	for _, payment := range payments {
-		resultid = append(resultid, payment.ID)
-		totalRVN += float32(payment.Amount)
-	}
-
-	// Calculate Ravencoin points
-	ravencoinPoints := calculateRavencoinPoints(db, totalRVN)
-
-	// Calculate Ravencoin score using the new function
-	ravencoinScore := calculateRavencoinScore(db, totalRVN)
-
-	// Set the activity score values
-	activityScore.RVN = totalRVN
-	activityScore.RavencoinPoints = ravencoinPoints
-	// Add the Ravencoin score to the Blockchain field
-	activityScore.BlockChain = ravencoinScore
-
-	return resultid, activityScore, nil
+	filter := lastWeekFilter(db, phoneNumber, "kelasai")
+	filter["_id"] = bson.M{"$nin": usedIDs}
+	return crowdfundingActivityScore(context.Background(), db, model.Ravencoin, filter)
 }
 
-// GetLastWeekDataQRISScore gets QRIS data for the last week only
+// GetLastWeekDataQRISScoreKelas gets QRIS data for the last week only
 func GetLastWeekDataQRISScoreKelas(db *mongo.Database, phoneNumber string, usedIDs []primitive.ObjectID) (resultid []primitive.ObjectID, activityScore model.ActivityScore, err error) {
-	// Calculate the date one week ago
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Query successful QRIS payments for this user from the last week
-	filter := bson.M{
-		"_id":           bson.M{"$nin": usedIDs},
-		"phoneNumber":   phoneNumber,
-		"paymentMethod": model.QRIS,
-		"status":        "success",
-		"timestamp": bson.M{
-			"$gte": oneWeekAgo,
-		},
-	}
-
-	cursor, err := db.Collection("crowdfundingorders").Find(context.Background(), filter)
-	if err != nil {
-		return nil, activityScore, err
-	}
-	defer cursor.Close(context.Background())
-
-	// Process payments
-	var totalRupiah int = 0
-	var payments []model.CrowdfundingOrder
-	if err = cursor.All(context.Background(), &payments); err != nil {
-		return nil, activityScore, err
-	}
-
-	// Sum up the total QRIS amount
-	for _, payment := range payments {
-		resultid = append(resultid, payment.ID)
-		totalRupiah += int(payment.Amount)
-	}
-
-	// Calculate QRIS score based on total amount
-	qrisScore := calculateQRISScore(db, totalRupiah)
-
-	// Calculate QRIS points
-	qrisPoints := calculateQRISPoints(db, totalRupiah)
-
-	// Set the activity score values
-	activityScore.Rupiah = totalRupiah
-	activityScore.QRISPoints = qrisPoints
-	activityScore.QRIS = qrisScore
-
-	return resultid, activityScore, nil
+	filter := lastWeekFilter(db, phoneNumber, "kelasai")
+	filter["_id"] = bson.M{"$nin": usedIDs}
+	return crowdfundingActivityScore(context.Background(), db, model.QRIS, filter)
 }
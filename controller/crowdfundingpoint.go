@@ -7,8 +7,9 @@ import (
 	"strconv"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -82,11 +83,7 @@ func CalculatePaymentPointsHandler(w http.ResponseWriter, r *http.Request) {
 	// Recalculate all payment points
 	err := CalculatePaymentPoints()
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"message": "Gagal menghitung poin pembayaran",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Internal, "Gagal menghitung poin pembayaran", err.Error()))
 		return
 	}
 
@@ -105,10 +102,7 @@ func GetUserPaymentPointsHandler(w http.ResponseWriter, r *http.Request) {
 		var err error
 		phoneNumber, _, _, _, _, err = extractUserInfoFromToken(r)
 		if err != nil || phoneNumber == "" {
-			at.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
-				"success": false,
-				"message": "Nomor telepon diperlukan",
-			})
+			at.WriteError(w, r, apperr.New(apperr.BadRequest, "Nomor telepon diperlukan", ""))
 			return
 		}
 	}
@@ -128,11 +122,7 @@ func GetUserPaymentPointsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		at.WriteJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"message": "Gagal mengambil poin pembayaran",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil poin pembayaran", err.Error()))
 		return
 	}
 
@@ -147,11 +137,7 @@ func GetAllPaymentPointsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get all payment points
 	points, err := GetAllPaymentPoints()
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"message": "Gagal mengambil poin pembayaran",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil poin pembayaran", err.Error()))
 		return
 	}
 
@@ -176,11 +162,7 @@ func GetTopPaymentPointsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get top payment points
 	points, err := GetTopPaymentPoints(limit)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"message": "Gagal mengambil poin pembayaran teratas",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil poin pembayaran teratas", err.Error()))
 		return
 	}
 
@@ -246,11 +228,11 @@ func SendPaymentPointsReportHandler(w http.ResponseWriter, r *http.Request) {
 	// Generate the report
 	msg, err := GeneratePaymentPointsReport()
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error",
 			Info:     "Gagal membuat laporan poin pembayaran",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -266,7 +248,7 @@ func SendPaymentPointsReportHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Send the message
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("gagal mengirim laporan poin ke grup %s: %v, info: %s", groupID, err, resp.Info)
 
@@ -276,11 +258,11 @@ func SendPaymentPointsReportHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if lastErr != nil && sentCount == 0 {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error",
 			Info:     "Gagal mengirim laporan poin pembayaran",
 			Response: lastErr.Error(),
-		})
+		}))
 		return
 	}
 
@@ -297,11 +279,11 @@ func GetPaymentPointsReportHandler(w http.ResponseWriter, r *http.Request) {
 	// Get report content
 	reportContent, err := GeneratePaymentPointsReport()
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error",
 			Info:     "Gagal membuat log laporan poin pembayaran",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/crowdfunding"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// formatSlotPayAmount returns the exact amount the user has to transfer for the slot
+func formatSlotPayAmount(order model.CrowdfundingOrder) string {
+	if p, ok := crowdfunding.Get(order.PaymentMethod); ok {
+		return p.FormatPayAmount(order)
+	}
+	return ""
+}
+
+// expireCrowdfundingSlot marks a still pending order as failed and frees its slot
+func expireCrowdfundingSlot(slot model.CrowdfundingSlot) {
+	res, err := config.Mongoconn.Collection("crowdfundingorders").UpdateOne(
+		context.Background(),
+		bson.M{"orderId": slot.OrderID, "status": "pending"},
+		bson.M{"$set": bson.M{
+			"status":    "failed",
+			"updatedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		log.Printf("Error updating expired order status: %v", err)
+		sendCrowdfundingDiscordEmbed(
+			"🔴 Error: Order Status Update Failed",
+			"Failed to update expired order status.",
+			ColorRed,
+			[]DiscordEmbedField{
+				{Name: "Error", Value: err.Error(), Inline: false},
+				{Name: "Order ID", Value: slot.OrderID, Inline: true},
+			},
+		)
+		return
+	}
+
+	if err = crowdfunding.ReleaseSlot(slot.OrderID); err != nil {
+		log.Printf("Error releasing expired slot: %v", err)
+		return
+	}
+
+	if res.ModifiedCount > 0 {
+		sendCrowdfundingDiscordEmbed(
+			"⏱️ Order Expired",
+			"A payment order has expired and its slot was released.",
+			ColorYellow,
+			[]DiscordEmbedField{
+				{Name: "Order ID", Value: slot.OrderID, Inline: true},
+				{Name: "Payment Method", Value: string(slot.PaymentMethod), Inline: true},
+				{Name: "Unique Code", Value: strconv.Itoa(slot.UniqueCode), Inline: true},
+				{Name: "Expiry Time", Value: slot.ExpiryTime.Format(time.RFC3339), Inline: true},
+			},
+		)
+	}
+}
+
+// scheduleSlotExpiry releases the slot once its expiry time has passed
+func scheduleSlotExpiry(slot model.CrowdfundingSlot) {
+	go func() {
+		time.Sleep(time.Until(slot.ExpiryTime))
+		current, err := crowdfunding.GetSlotByOrderID(slot.OrderID)
+		if err != nil {
+			// Slot already released by a confirmation or cleanup
+			return
+		}
+		expireCrowdfundingSlot(current)
+	}()
+}
+
+// CleanupExpiredSlots releases every slot whose expiry time has passed
+func CleanupExpiredSlots() {
+	cursor, err := config.Mongoconn.Collection(crowdfunding.SlotCollection).Find(context.Background(), bson.M{"expiryTime": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		log.Printf("Error finding expired crowdfunding slots: %v", err)
+		return
+	}
+	var slots []model.CrowdfundingSlot
+	if err = cursor.All(context.Background(), &slots); err != nil {
+		log.Printf("Error decoding expired crowdfunding slots: %v", err)
+		return
+	}
+	for _, slot := range slots {
+		expireCrowdfundingSlot(slot)
+	}
+}
+
+// writeActiveSlotResponse tells the user to finish the payment they already started
+func writeActiveSlotResponse(w http.ResponseWriter, slot model.CrowdfundingSlot) {
+	var order model.CrowdfundingOrder
+	_ = config.Mongoconn.Collection("crowdfundingorders").FindOne(context.Background(), bson.M{"orderId": slot.OrderID}).Decode(&order)
+	at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
+		Success:       false,
+		Message:       "Anda masih memiliki pembayaran yang belum selesai. Silakan selesaikan atau tunggu hingga kedaluwarsa.",
+		QueueStatus:   true,
+		OrderID:       slot.OrderID,
+		ExpiryTime:    slot.ExpiryTime,
+		PaymentMethod: slot.PaymentMethod,
+		WalletAddress: order.WalletAddress,
+		Amount:        order.Amount,
+		UniqueCode:    slot.UniqueCode,
+		PayAmount:     formatSlotPayAmount(order),
+	})
+}
+
+// requireOrderSlotTx makes sure the transaction sent by the client is bound to the order's own slot.
+// It writes the error response and returns false when it is not.
+func requireOrderSlotTx(w http.ResponseWriter, r *http.Request, order model.CrowdfundingOrder, txid string) (slot model.CrowdfundingSlot, ok bool) {
+	slot, err := crowdfunding.GetSlotByOrderID(order.OrderID)
+	if err != nil {
+		at.WriteJSON(w, http.StatusOK, model.CrowdfundingPaymentResponse{
+			Success:       true,
+			Status:        order.Status,
+			Message:       "Payment slot for this order is no longer active",
+			TxID:          txid,
+			PaymentMethod: order.PaymentMethod,
+		})
+		return
+	}
+	if err = crowdfunding.ClaimSlotTx(order.OrderID, txid); err != nil {
+		at.WriteError(w, r, apperr.Wrap(apperr.Conflict, "Transaction does not belong to this order", err))
+		return
+	}
+	return slot, true
+}
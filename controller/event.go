@@ -3,18 +3,25 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/eventjadwal"
+	"github.com/gocroot/helper/ledger"
+	"github.com/gocroot/helper/linkcheck"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/store"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // sendNewEventNotificationToGroup sends notification to specific WhatsApp group when new event is created
@@ -32,52 +39,55 @@ func sendNewEventNotificationToGroup() {
 		Messages: message,
 	}
 
-	// Send message asynchronously to not block the main request
-	go func() {
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
-		if err != nil {
-			fmt.Printf("Failed to send WhatsApp notification to group %s: %v, info: %s\n", targetGroupID, err, resp.Info)
-		} else {
-			fmt.Printf("WhatsApp notification sent successfully to group %s\n", targetGroupID)
-		}
-	}()
-}
-
-// CreateEvent untuk membuat event baru (khusus owner)
-func CreateEvent(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	// Send through the outbox, failed deliveries are queued for retry instead of blocking the request
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
+		fmt.Printf("Failed to send WhatsApp notification to group %s: %v, info: %s\n", targetGroupID, err, resp.Info)
+	} else {
+		fmt.Printf("WhatsApp notification sent successfully to group %s\n", targetGroupID)
 	}
+}
 
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
+// umumkanEvent mengirim notifikasi Discord dan WA grup saat event mulai tayang
+func umumkanEvent(event model.Event, keterangan string) {
+	// Send Discord notification
+	discordPayload := DiscordWebhookPayload{
+		Content: "🎯 **New Event Created!**",
+		Embeds: []DiscordEmbedevent{
+			{
+				Title:       "New Event Created",
+				Description: keterangan,
+				Color:       5763719, // Green color
+				Fields: []DiscordEmbedeventField{
+					{Name: "📋 Event Name", Value: event.Name, Inline: true},
+					{Name: "🎯 Points", Value: fmt.Sprintf("%d", event.Points), Inline: true},
+					{Name: "⏰ Deadline", Value: fmt.Sprintf("%d seconds", event.DeadlineSeconds), Inline: true},
+					{Name: "👤 Created By", Value: event.CreatedBy, Inline: true},
+					{Name: "🆔 Event ID", Value: event.ID.Hex(), Inline: true},
+					{Name: "📝 Description", Value: event.Description, Inline: false},
+				},
+				Timestamp: time.Now().Format(time.RFC3339),
+			},
+		},
 	}
+	go sendDiscordNotification(discordPayload)
 
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat membuat event"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
+	// Send WhatsApp notification to group
+	sendNewEventNotificationToGroup()
+}
+
+// CreateEvent untuk membuat event baru (khusus owner)
+func CreateEvent(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Parse request body
 	var eventReq model.EventCreateRequest
-	err = json.NewDecoder(req.Body).Decode(&eventReq)
+	err := json.NewDecoder(req.Body).Decode(&eventReq)
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -85,21 +95,52 @@ func CreateEvent(respw http.ResponseWriter, req *http.Request) {
 	if eventReq.Name == "" || eventReq.Description == "" || eventReq.Points <= 0 || eventReq.DeadlineSeconds <= 0 {
 		respn.Status = "Error : Data tidak lengkap"
 		respn.Response = "Nama, deskripsi, poin, dan deadline harus diisi dengan benar"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
 	// No maximum deadline validation - owner can set any deadline they want
+	if eventReq.MaxClaimants < 0 || eventReq.Eligibility.MinPoints < 0 {
+		respn.Status = "Error : Data tidak valid"
+		respn.Response = "Jumlah claimant dan minimal poin tidak boleh negatif"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
+		return
+	}
+	now := time.Now()
+	if err = eventjadwal.Periksa(eventReq, now); err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.BadRequest, "Error : Jadwal event tidak valid"))
+		return
+	}
 
-	// Buat event baru
+	// Buat event baru, event dengan start_at di masa depan disimpan nonaktif dan diaktifkan oleh /refresh/event/jadwal
+	terjadwal := eventReq.StartAt.After(now)
 	event := model.Event{
-		Name:            eventReq.Name,
-		Description:     eventReq.Description,
-		Points:          eventReq.Points,
-		DeadlineSeconds: eventReq.DeadlineSeconds,
-		CreatedBy:       payload.Id,
-		CreatedAt:       time.Now(),
-		IsActive:        true,
+		ID:               primitive.NewObjectID(),
+		Name:             eventReq.Name,
+		Description:      eventReq.Description,
+		Points:           eventReq.Points,
+		DeadlineSeconds:  eventReq.DeadlineSeconds,
+		CreatedBy:        phonenumber,
+		CreatedAt:        now,
+		IsActive:         !terjadwal,
+		Category:         strings.TrimSpace(eventReq.Category),
+		MaxClaimants:     eventReq.MaxClaimants,
+		Eligibility:      eventReq.Eligibility,
+		StartAt:          eventReq.StartAt,
+		EndAt:            eventReq.EndAt,
+		Recurrence:       eventReq.Recurrence,
+		AllowOwnHostname: eventReq.AllowOwnHostname,
+	}
+	for _, d := range eventReq.AllowedDomains {
+		if d = linkcheck.BersihkanDomain(d); d != "" {
+			event.AllowedDomains = append(event.AllowedDomains, d)
+		}
+	}
+	if event.Recurrence != nil {
+		event.SeriesID, event.Occurrence = event.ID, 1
+	}
+	if !terjadwal {
+		event.AnnouncedAt = now
 	}
 
 	// Simpan ke database
@@ -107,34 +148,27 @@ func CreateEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan event"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
-	// Send Discord notification
-	discordPayload := DiscordWebhookPayload{
-		Content: "🎯 **New Event Created!**",
-		Embeds: []DiscordEmbedevent{
-			{
-				Title:       "New Event Created",
-				Description: "A new event has been created by owner",
-				Color:       5763719, // Green color
-				Fields: []DiscordEmbedeventField{
-					{Name: "📋 Event Name", Value: event.Name, Inline: true},
-					{Name: "🎯 Points", Value: fmt.Sprintf("%d", event.Points), Inline: true},
-					{Name: "⏰ Deadline", Value: fmt.Sprintf("%d seconds", event.DeadlineSeconds), Inline: true},
-					{Name: "👤 Created By", Value: payload.Id, Inline: true},
-					{Name: "🆔 Event ID", Value: fmt.Sprintf("%v", eventID), Inline: true},
-					{Name: "📝 Description", Value: event.Description, Inline: false},
-				},
-				Timestamp: time.Now().Format(time.RFC3339),
-			},
-		},
+	if terjadwal {
+		respn.Status = "Success"
+		respn.Response = "Event dijadwalkan tayang " + event.StartAt.Format("2006-01-02 15:04:05")
+		respn.Data = map[string]interface{}{
+			"event_id": eventID,
+			"event":    event,
+		}
+		at.WriteJSON(respw, http.StatusOK, respn)
+		return
 	}
-	go sendDiscordNotification(discordPayload)
-
-	// Send WhatsApp notification to group
-	sendNewEventNotificationToGroup()
+	// Event berulang yang langsung tayang langsung disiapkan event berikutnya, jika gagal disusulkan oleh cron jadwal event
+	if event.Recurrence != nil {
+		if _, _, err = eventjadwal.BuatBerikutnya(config.Mongoconn, event, now); err != nil {
+			fmt.Printf("Failed to create next occurrence of event %s: %v\n", eventID.Hex(), err)
+		}
+	}
+	umumkanEvent(event, "A new event has been created by owner")
 
 	respn.Status = "Success"
 	respn.Response = "Event berhasil dibuat"
@@ -152,16 +186,20 @@ func GetAllEvents(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
-	// Get all active events
-	events, err := atdb.GetAllDoc[[]model.Event](config.Mongoconn, "events", primitive.M{"isactive": true})
+	// Get all active events, filter kategori opsional ?category=
+	eventFilter := primitive.M{"isactive": true}
+	if category := req.URL.Query().Get("category"); category != "" {
+		eventFilter["category"] = category
+	}
+	events, err := atdb.GetAllDoc[[]model.Event](config.Mongoconn, "events", eventFilter)
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data event"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -169,7 +207,7 @@ func GetAllEvents(respw http.ResponseWriter, req *http.Request) {
 	// Include all statuses including approved to completely hide claimed events
 	userClaims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
 		"userphone": payload.Id,
-		"status":    primitive.M{"$in": []string{"claimed", "submitted", "approved"}},
+		"status":    primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimSubmitted, model.ClaimRevision, model.ClaimApproved, model.ClaimRejected}},
 	})
 	if err != nil {
 		userClaims = []model.EventClaim{} // If error, assume no claims
@@ -177,7 +215,7 @@ func GetAllEvents(respw http.ResponseWriter, req *http.Request) {
 
 	// Get all active claims from any user to check if event is claimed by others
 	allActiveClaims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
-		"status": primitive.M{"$in": []string{"claimed", "submitted"}},
+		"status": primitive.M{"$in": activeClaimStatuses},
 	})
 	if err != nil {
 		allActiveClaims = []model.EventClaim{} // If error, assume no claims
@@ -189,10 +227,17 @@ func GetAllEvents(respw http.ResponseWriter, req *http.Request) {
 		userClaimedEventIDs[claim.EventID.Hex()] = true
 	}
 
-	// Create map of claimed event IDs by any user (untuk show status)
-	allClaimedEventIDs := make(map[string]bool)
+	// Hitung claim aktif per event (untuk show status dan kuota)
+	activeClaimCount := make(map[string]int)
 	for _, claim := range allActiveClaims {
-		allClaimedEventIDs[claim.EventID.Hex()] = true
+		activeClaimCount[claim.EventID.Hex()]++
+	}
+
+	// Profil user untuk syarat event, jika gagal dimuat event tetap tampil dan syarat dicek lagi saat claim
+	docuser, _ := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	profile, err := loadEventProfile(config.Mongoconn, docuser)
+	if err != nil {
+		fmt.Printf("Failed to load event profile %s: %v\n", payload.Id, err)
 	}
 
 	// Filter events - exclude events yang sudah di-claim user ini
@@ -211,7 +256,13 @@ func GetAllEvents(respw http.ResponseWriter, req *http.Request) {
 			"deadline_seconds":   event.DeadlineSeconds,
 			"created_at":         event.CreatedAt,
 			"is_claimed_by_user": false, // Selalu false karena sudah di-filter
-			"is_claimed_by_any":  allClaimedEventIDs[event.ID.Hex()],
+			"is_claimed_by_any":  activeClaimCount[event.ID.Hex()] > 0,
+			"category":           event.Category,
+			"max_claimants":      eventQuota(event),
+			"active_claims":      activeClaimCount[event.ID.Hex()],
+			"is_full":            activeClaimCount[event.ID.Hex()] >= eventQuota(event),
+			"eligibility":        event.Eligibility,
+			"is_eligible":        profile.alasanTidakEligible(event.Eligibility) == "",
 		}
 		eventsWithStatus = append(eventsWithStatus, eventData)
 	}
@@ -229,7 +280,7 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -239,7 +290,7 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -248,7 +299,7 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -260,38 +311,21 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan atau tidak aktif"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
-		return
-	}
-
-	// Cek apakah ada user lain yang sudah claim event ini dan masih aktif
-	existingActiveClaim, err := atdb.GetOneDoc[model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
-		"eventid": eventObjectID,
-		"status":  primitive.M{"$in": []string{"claimed", "submitted"}},
-	})
-	if err == nil {
-		respn.Status = "Error : Event sudah di-claim oleh user lain"
-		respn.Response = "Event ini sudah di-claim oleh user lain dan sedang dalam proses"
-		respn.Data = map[string]interface{}{
-			"claimed_by": existingActiveClaim.UserPhone,
-			"claimed_at": existingActiveClaim.ClaimedAt,
-			"deadline":   existingActiveClaim.Deadline,
-		}
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Expired, respn))
 		return
 	}
 
-	// Cek apakah user ini sudah pernah claim event ini
+	// Cek apakah user ini sudah pernah claim event ini, claim yang ditolak tidak bisa diulang
 	userExistingClaim, err := atdb.GetOneDoc[model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
 		"eventid":   eventObjectID,
 		"userphone": payload.Id,
-		"status":    primitive.M{"$in": []string{"claimed", "submitted", "approved"}},
+		"status":    primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimSubmitted, model.ClaimRevision, model.ClaimApproved, model.ClaimRejected}},
 	})
 	if err == nil {
 		respn.Status = "Error : Anda sudah claim event ini"
 		respn.Response = "Anda sudah claim event ini sebelumnya"
 		respn.Data = userExistingClaim
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -300,7 +334,49 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
+		return
+	}
+
+	// Cek syarat enroll, kelas dan minimal poin
+	profile, err := loadEventProfile(config.Mongoconn, docuser)
+	if err != nil {
+		respn.Status = "Error : Gagal memeriksa syarat event"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if alasan := profile.alasanTidakEligible(event.Eligibility); alasan != "" {
+		respn.Status = "Error : Anda tidak memenuhi syarat event"
+		respn.Response = alasan
+		respn.Data = event.Eligibility
+		at.WriteError(respw, req, apperr.FromResponse(apperr.PreconditionFailed, respn))
+		return
+	}
+
+	// Ambil kursi kuota secara atomik, claim aktif tidak boleh melebihi MaxClaimants event
+	ensureEventClaimIndexes()
+	dapat, err := pesanKursiClaim(event)
+	if err != nil {
+		respn.Status = "Error : Gagal memeriksa kuota event"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if !dapat {
+		respn.Status = "Error : Event sudah di-claim oleh user lain"
+		respn.Response = fmt.Sprintf("Kuota event ini sudah penuh (%d claimant) dan sedang dalam proses", eventQuota(event))
+		data := map[string]interface{}{"max_claimants": eventQuota(event)}
+		if first, err := atdb.GetOneDoc[model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
+			"eventid": eventObjectID,
+			"status":  primitive.M{"$in": activeClaimStatuses},
+		}); err == nil {
+			data["claimed_by"] = first.UserPhone
+			data["claimed_at"] = first.ClaimedAt
+			data["deadline"] = first.Deadline
+		}
+		respn.Data = data
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -320,14 +396,22 @@ func ClaimEvent(respw http.ResponseWriter, req *http.Request) {
 		Deadline:   deadline,
 		Status:     "claimed",
 		IsApproved: false,
+		Aktif:      true,
 	}
 
-	// Simpan claim ke database
+	// Simpan claim ke database, kursi dikembalikan jika claim gagal disimpan
 	claimID, err := atdb.InsertOneDoc(config.Mongoconn, "eventclaims", eventClaim)
 	if err != nil {
+		lepasKursiClaim(eventObjectID)
+		if mongo.IsDuplicateKeyError(err) {
+			respn.Status = "Error : Anda sudah claim event ini"
+			respn.Response = "Anda masih punya claim aktif untuk event ini"
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
+			return
+		}
 		respn.Status = "Error : Gagal claim event"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -375,7 +459,7 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -385,7 +469,7 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -394,44 +478,33 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
-	// Cek apakah claim exists dan milik user ini
+	// Cek apakah claim exists dan milik user ini, claim yang diminta revisi boleh disubmit ulang
 	claim, err := atdb.GetOneDoc[model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
 		"_id":       claimObjectID,
 		"userphone": payload.Id,
-		"status":    "claimed",
+		"status":    primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}},
 	})
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan atau sudah disubmit"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
 	// Cek apakah masih dalam deadline
 	if time.Now().After(claim.Deadline) {
 		// Update status ke expired
-		_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claimObjectID}, model.EventClaim{
-			ID:          claim.ID,
-			EventID:     claim.EventID,
-			UserPhone:   claim.UserPhone,
-			UserName:    claim.UserName,
-			UserNPM:     claim.UserNPM,
-			ClaimedAt:   claim.ClaimedAt,
-			Deadline:    claim.Deadline,
-			Status:      "expired",
-			TaskLink:    claim.TaskLink,
-			SubmittedAt: claim.SubmittedAt,
-			ApprovedAt:  claim.ApprovedAt,
-			ApprovedBy:  claim.ApprovedBy,
-		})
+		expiredClaim := claim
+		expiredClaim.Status = model.ClaimExpired
+		_, err = gantiClaim(expiredClaim, claim.Status)
 
 		respn.Status = "Error : Deadline sudah terlewat"
 		respn.Response = "Waktu untuk menyelesaikan tugas sudah habis"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Expired, respn))
 		return
 	}
 
@@ -439,67 +512,90 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 	if submitReq.TaskLink == "" {
 		respn.Status = "Error : Link tugas harus diisi"
 		respn.Response = "Silakan masukkan link tugas yang sudah dikerjakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
-	// Update claim dengan task link dan status submitted
-	updatedClaim := model.EventClaim{
-		ID:          claim.ID,
-		EventID:     claim.EventID,
-		UserPhone:   claim.UserPhone,
-		UserName:    claim.UserName,
-		UserNPM:     claim.UserNPM,
-		ClaimedAt:   claim.ClaimedAt,
-		Deadline:    claim.Deadline,
-		Status:      "submitted",
-		TaskLink:    submitReq.TaskLink,
-		SubmittedAt: time.Now(),
-		ApprovedAt:  claim.ApprovedAt,
-		ApprovedBy:  claim.ApprovedBy,
+	// Get event data
+	event, err := atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{"_id": claim.EventID})
+	if err != nil {
+		respn.Status = "Error : Data event tidak ditemukan"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
+		return
 	}
 
-	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claimObjectID}, updatedClaim)
+	// Cek otomatis link tugas sebelum sampai ke reviewer, hasilnya disimpan walau gagal supaya bisa ditelusuri.
+	// Alamat internal dan domain di luar allowlist ditolak, kegagalan lain tetap masuk antrian dengan tanda untuk reviewer
+	check, linkKey, err := linkcheck.Periksa(config.Mongoconn, event, claim, submitReq.TaskLink)
 	if err != nil {
-		respn.Status = "Error : Gagal submit tugas"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Link tugas tidak valid"))
+		return
+	}
+	updatedClaim := claim
+	updatedClaim.LinkCheck = &check
+	if check.Ditolak {
+		_, err = gantiClaim(updatedClaim, claim.Status)
+		if err != nil {
+			fmt.Printf("Error menyimpan hasil cek link claim %s: %v\n", claimObjectID.Hex(), err)
+		}
+		respn.Status = "Error : Link tugas tidak lolos pemeriksaan"
+		respn.Response = strings.Join(check.Alasan, "; ")
+		respn.Data = check
+		at.WriteError(respw, req, apperr.FromResponse(apperr.PreconditionFailed, respn))
 		return
 	}
 
-	// Get user data untuk notifikasi
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	// Update claim dengan task link dan status submitted, komentar review sebelumnya tetap disimpan
+	updatedClaim.Status = model.ClaimSubmitted
+	updatedClaim.TaskLink = submitReq.TaskLink
+	updatedClaim.TaskLinkKey = linkKey
+	updatedClaim.SubmittedAt = time.Now()
+
+	ok, err := gantiClaim(updatedClaim, claim.Status)
 	if err != nil {
-		respn.Status = "Error : Data user tidak ditemukan"
+		respn.Status = "Error : Gagal submit tugas"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if !ok {
+		respn.Status = "Error : Claim sudah berubah"
+		respn.Response = "Status claim sudah diubah proses lain, muat ulang data claim"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
-	// Get event data
-	event, err := atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{"_id": claim.EventID})
+	// Get user data untuk notifikasi
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data event tidak ditemukan"
+		respn.Status = "Error : Data user tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	// Send notification ke owner dengan link approval
 	approvalLink := fmt.Sprintf("https://www.do.my.id/event/#%s", claimObjectID.Hex())
 
+	hasilCek := fmt.Sprintf("HTTP %d, domain %s, belum pernah disubmit user lain", check.StatusCode, check.Domain)
+	if !check.Passed {
+		hasilCek = "⚠️ perlu dicek manual, " + strings.Join(check.Alasan, "; ")
+	}
+
 	// Prepare notification message for WhatsApp
 	message := fmt.Sprintf("🎯 *Event Task Submitted*\n\n"+
 		"📋 Event: %s\n"+
 		"👤 User: %s (%s)\n"+
 		"📱 Phone: %s\n"+
 		"🔗 Task Link: %s\n"+
+		"🔎 Cek Otomatis: %s\n"+
 		"✅ Approval Link: %s\n\n"+
 		"Klik link approval untuk menyetujui tugas ini.",
-		event.Name, docuser.Name, docuser.NPM, docuser.PhoneNumber, submitReq.TaskLink, approvalLink)
+		event.Name, docuser.Name, docuser.NPM, docuser.PhoneNumber, submitReq.TaskLink, hasilCek, approvalLink)
 
 	// Send to owner numbers
-	ownerNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
+	ownerNumbers := rbac.PhoneNumbersWithRole(config.Mongoconn, rbac.RoleOwner)
 	for _, ownerNum := range ownerNumbers {
 		// Send WhatsApp message to owner
 		dt := &whatsauth.TextMessage{
@@ -508,7 +604,7 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 			Messages: message,
 		}
 
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 		if err != nil {
 			// Log error but don't fail the request
 			fmt.Printf("Failed to send WhatsApp to %s: %v, info: %s\n", ownerNum, err, resp.Info)
@@ -533,6 +629,7 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 					{Name: "🎯 Points", Value: fmt.Sprintf("%d", event.Points), Inline: true},
 					{Name: "📅 Submitted At", Value: time.Now().Format("2006-01-02 15:04:05"), Inline: true},
 					{Name: "🔗 Task Link", Value: submitReq.TaskLink, Inline: false},
+					{Name: "🔎 Link Check", Value: hasilCek, Inline: false},
 					{Name: "✅ Approval Link", Value: approvalLink, Inline: false},
 					{Name: "🆔 Claim ID", Value: claimObjectID.Hex(), Inline: false},
 				},
@@ -544,6 +641,9 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 
 	respn.Status = "Success"
 	respn.Response = "Tugas berhasil disubmit dan menunggu approval dari owner"
+	if !check.Passed {
+		respn.Response += ", link ditandai untuk dicek manual: " + strings.Join(check.Alasan, "; ")
+	}
 	respn.Data = map[string]interface{}{
 		"claim":         updatedClaim,
 		"event":         event,
@@ -555,38 +655,15 @@ func SubmitEventTask(respw http.ResponseWriter, req *http.Request) {
 // ApproveEventClaim untuk approve claim event (khusus owner)
 func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat approve event"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Parse request body
 	var approveReq model.EventApproveRequest
-	err = json.NewDecoder(req.Body).Decode(&approveReq)
+	err := json.NewDecoder(req.Body).Decode(&approveReq)
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -595,7 +672,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -607,7 +684,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan atau belum disubmit"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -616,7 +693,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -625,32 +702,28 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	// Update claim status to approved
-	updatedClaim := model.EventClaim{
-		ID:          claim.ID,
-		EventID:     claim.EventID,
-		UserPhone:   claim.UserPhone,
-		UserName:    claim.UserName,
-		UserNPM:     claim.UserNPM,
-		ClaimedAt:   claim.ClaimedAt,
-		Deadline:    claim.Deadline,
-		Status:      "approved",
-		TaskLink:    claim.TaskLink,
-		SubmittedAt: claim.SubmittedAt,
-		ApprovedAt:  time.Now(),
-		ApprovedBy:  payload.Id,
-		IsApproved:  true,
-	}
-
-	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claimObjectID}, updatedClaim)
+	updatedClaim := claim
+	updatedClaim.Status = model.ClaimApproved
+	updatedClaim.ApprovedAt = time.Now()
+	updatedClaim.ApprovedBy = phonenumber
+	updatedClaim.IsApproved = true
+
+	ok, err := gantiClaim(updatedClaim, claim.Status)
 	if err != nil {
 		respn.Status = "Error : Gagal approve claim"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if !ok {
+		respn.Status = "Error : Claim sudah diproses"
+		respn.Response = "Claim ini sudah diproses reviewer lain"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -671,7 +744,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan poin user"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -686,7 +759,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	// Create asesor data
 	asesor := model.Userdomyikado{
 		Name:        "System Event",
-		PhoneNumber: payload.Id,
+		PhoneNumber: phonenumber,
 	}
 
 	// Create bimbingan entry for points
@@ -734,7 +807,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menambah points ke bimbingan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -752,7 +825,7 @@ func ApproveEventClaim(respw http.ResponseWriter, req *http.Request) {
 					{Name: "🎓 NPM", Value: user.NPM, Inline: true},
 					{Name: "📱 Phone", Value: user.PhoneNumber, Inline: true},
 					{Name: "🎯 Points Awarded", Value: fmt.Sprintf("%d", event.Points), Inline: true},
-					{Name: "👨‍💼 Approved By", Value: payload.Id, Inline: true},
+					{Name: "👨‍💼 Approved By", Value: phonenumber, Inline: true},
 					{Name: "📅 Approved At", Value: time.Now().Format("2006-01-02 15:04:05"), Inline: true},
 					{Name: "🔗 Task Link", Value: claim.TaskLink, Inline: false},
 					{Name: "🆔 Claim ID", Value: claimObjectID.Hex(), Inline: false},
@@ -781,14 +854,15 @@ func GetUserEventClaims(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
 	// Get user's claims (exclude approved ones as they should disappear from user view)
+	// Claim yang ditolak tetap tampil supaya user bisa membaca komentar reviewer
 	claims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
 		"userphone": payload.Id,
-		"status":    primitive.M{"$in": []string{"claimed", "submitted"}},
+		"status":    primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimSubmitted, model.ClaimRevision, model.ClaimRejected}},
 	})
 	if err != nil {
 		claims = []model.EventClaim{}
@@ -803,7 +877,7 @@ func GetUserEventClaims(respw http.ResponseWriter, req *http.Request) {
 		}
 
 		// Check if expired
-		isExpired := time.Now().After(claim.Deadline) && claim.Status == "claimed"
+		isExpired := time.Now().After(claim.Deadline) && (claim.Status == model.ClaimClaimed || claim.Status == model.ClaimRevision)
 
 		// Calculate approval deadline (24 jam dari submitted_at)
 		var approvalDeadline time.Time
@@ -821,6 +895,8 @@ func GetUserEventClaims(respw http.ResponseWriter, req *http.Request) {
 			"submitted_at":      claim.SubmittedAt,
 			"approval_deadline": approvalDeadline,
 			"is_expired":        isExpired,
+			"review_comment":    claim.ReviewComment,
+			"revisions":         claim.Revisions,
 		}
 		claimsWithEvents = append(claimsWithEvents, claimData)
 	}
@@ -837,36 +913,23 @@ func CheckExpiredClaims(respw http.ResponseWriter, req *http.Request) {
 
 	// Get all claimed events that are past deadline
 	expiredClaims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
-		"status":   "claimed",
+		"status":   primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}},
 		"deadline": primitive.M{"$lt": time.Now()},
 	})
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data expired claims"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
 	updatedCount := 0
 	for _, claim := range expiredClaims {
 		// Update status to expired
-		updatedClaim := model.EventClaim{
-			ID:          claim.ID,
-			EventID:     claim.EventID,
-			UserPhone:   claim.UserPhone,
-			UserName:    claim.UserName,
-			UserNPM:     claim.UserNPM,
-			ClaimedAt:   claim.ClaimedAt,
-			Deadline:    claim.Deadline,
-			Status:      "expired",
-			TaskLink:    claim.TaskLink,
-			SubmittedAt: claim.SubmittedAt,
-			ApprovedAt:  claim.ApprovedAt,
-			ApprovedBy:  claim.ApprovedBy,
-		}
+		updatedClaim := claim
+		updatedClaim.Status = model.ClaimExpired
 
-		_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claim.ID}, updatedClaim)
-		if err == nil {
+		if ok, err := gantiClaim(updatedClaim, claim.Status); err == nil && ok {
 			updatedCount++
 		}
 	}
@@ -889,7 +952,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 	if claimId == "" {
 		respn.Status = "Error : Claim ID tidak ditemukan"
 		respn.Response = "Claim ID harus disediakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -898,7 +961,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -909,7 +972,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -920,7 +983,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -931,7 +994,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -951,6 +1014,7 @@ func GetEventApprovalData(respw http.ResponseWriter, req *http.Request) {
 		"status":      claim.Status,
 		"isapproved":  claim.IsApproved,
 		"approved":    claim.IsApproved, // untuk kompatibilitas dengan pola kambing
+		"linkcheck":   claim.LinkCheck,  // hasil cek otomatis link, nil untuk claim sebelum pemeriksaan ada
 	}
 
 	at.WriteJSON(respw, http.StatusOK, responseData)
@@ -965,7 +1029,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if claimId == "" {
 		respn.Status = "Error : Claim ID tidak ditemukan"
 		respn.Response = "Claim ID harus disediakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -974,7 +1038,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -987,7 +1051,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -998,7 +1062,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1006,7 +1070,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if claim.IsApproved {
 		respn.Status = "Error : Sudah di-approve"
 		respn.Response = "Claim ini sudah di-approve sebelumnya"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -1014,7 +1078,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if claim.Status != "submitted" {
 		respn.Status = "Error : Status tidak valid"
 		respn.Response = "Claim harus dalam status submitted untuk di-approve"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -1025,7 +1089,7 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1036,22 +1100,29 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	// Update claim dengan approval
+	statusLama := claim.Status
 	claim.IsApproved = approvalData.Approved
 	claim.Status = "approved"
 	claim.ApprovedAt = time.Now()
-	claim.ApprovedBy = "owner" // Bisa disesuaikan dengan token jika perlu
+	claim.ApprovedBy = rbac.PhoneNumber(req)
 
 	// Update claim di database
-	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claimObjectID}, claim)
+	ok, err := gantiClaim(claim, statusLama)
 	if err != nil {
 		respn.Status = "Error : Gagal update claim"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if !ok {
+		respn.Status = "Error : Sudah di-approve"
+		respn.Response = "Claim ini sudah diproses sebelumnya"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -1072,17 +1143,23 @@ func PostEventApproval(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan poin user"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
-	// Update user's total event points (PointEvent)
-	user.PointEvent += event.Points
-	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": claim.UserPhone}, user)
-	if err != nil {
+	// Update user's total event points (PointEvent) lewat ledger, claim yang sama hanya dihitung sekali
+	user, _, err = ledger.Post(config.Mongoconn, primitive.M{"phonenumber": claim.UserPhone}, ledger.Posting{
+		Account:        model.AkunPointEvent,
+		Amount:         float64(event.Points),
+		Source:         "event",
+		ReferenceID:    claim.ID.Hex(),
+		IdempotencyKey: "event:" + claim.ID.Hex(),
+		Keterangan:     event.Name,
+	})
+	if err != nil && err != ledger.ErrDuplicate {
 		respn.Status = "Error : Gagal update poin user"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -1132,7 +1209,7 @@ func GetEventClaimDetails(respw http.ResponseWriter, req *http.Request) {
 	if claimId == "" {
 		respn.Status = "Error : Claim ID tidak ditemukan"
 		respn.Response = "Claim ID harus disediakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1141,7 +1218,7 @@ func GetEventClaimDetails(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1152,7 +1229,7 @@ func GetEventClaimDetails(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1163,7 +1240,7 @@ func GetEventClaimDetails(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1174,7 +1251,7 @@ func GetEventClaimDetails(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -1214,15 +1291,15 @@ func runExpiredApprovalsCheck() int {
 
 	// 1. Check deadline timeout (user tidak submit tepat waktu)
 	expiredDeadlineClaims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
-		"status":   "claimed",
+		"status":   primitive.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}},
 		"deadline": primitive.M{"$lt": now},
 	})
 	if err == nil {
 		for _, claim := range expiredDeadlineClaims {
 			// Update claim status ke expired
+			statusLama := claim.Status
 			claim.Status = "expired"
-			_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claim.ID}, claim)
-			if err != nil {
+			if ok, err := gantiClaim(claim, statusLama); err != nil || !ok {
 				continue
 			}
 
@@ -1248,9 +1325,9 @@ func runExpiredApprovalsCheck() int {
 	if err == nil {
 		for _, claim := range expiredApprovalClaims {
 			// Update claim status ke expired
+			statusLama := claim.Status
 			claim.Status = "expired"
-			_, err = atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claim.ID}, claim)
-			if err != nil {
+			if ok, err := gantiClaim(claim, statusLama); err != nil || !ok {
 				continue
 			}
 
@@ -1276,7 +1353,7 @@ func GetUserEventPoints(respw http.ResponseWriter, req *http.Request) {
 		fmt.Printf("❌ GetUserEventPoints: Token decode error: %v\n", err)
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -1290,7 +1367,7 @@ func GetUserEventPoints(respw http.ResponseWriter, req *http.Request) {
 		fmt.Printf("❌ GetUserEventPoints: User not found for phone: %s, error: %v\n", payload.Id, err)
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -1320,14 +1397,14 @@ func GetUserEventPoints(respw http.ResponseWriter, req *http.Request) {
 	at.WriteJSON(respw, http.StatusOK, respn)
 }
 
-// BuyBimbinganCode untuk membeli code bimbingan dengan pointevent
+// BuyBimbinganCode untuk membeli code bimbingan dengan pointevent, memakai item kodebimbingan di katalog toko
 func BuyBimbinganCode(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
 	if err != nil {
 		respn.Status = "Error : Token Tidak Valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -1340,52 +1417,22 @@ func BuyBimbinganCode(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : User tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
-		return
-	}
-
-	// Check if user has enough points
-	requiredPoints := 15
-	if user.PointEvent < requiredPoints {
-		respn.Status = "Error : Poin tidak cukup"
-		respn.Response = fmt.Sprintf("Anda memiliki %d poin, butuh %d poin untuk membeli code bimbingan", user.PointEvent, requiredPoints)
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
-	// Generate bimbingan code (sama seperti Generate Event Code)
-	generatedCode := generateBimbinganCode()
-
-	// Deduct points from user
-	user.PointEvent -= requiredPoints
-	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, user)
+	item, err := store.ItemBawaan(config.Mongoconn, model.StoreKodeBimbingan)
 	if err != nil {
-		respn.Status = "Error : Gagal update poin user"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal mengambil item toko"))
 		return
 	}
-
-	// Create EventCode entry (sama seperti GenerateEventCode untuk referral)
-	eventCode := model.EventCode{
-		Code:      generatedCode,
-		CreatedBy: payload.Id,
-		CreatedAt: time.Now(),
-		IsUsed:    false,
-	}
-
-	// Save to eventcodes collection
-	eventCodeID, err := atdb.InsertOneDoc(config.Mongoconn, "eventcodes", eventCode)
+	// Poin dipotong atomik lewat ledger, dikembalikan jika code gagal disimpan
+	pembelian, sisa, err := store.Beli(config.Mongoconn, user, item, model.StoreBeliRequest{ItemID: item.ID})
 	if err != nil {
-		// Rollback points if failed to save event code
-		user.PointEvent += requiredPoints
-		atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, user)
-
-		respn.Status = "Error : Gagal menyimpan code bimbingan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal membeli code bimbingan"))
 		return
 	}
+	generatedCode := pembelian.Hasil
 
 	// Send Discord notification
 	discordPayload := DiscordWebhookPayload{
@@ -1400,10 +1447,10 @@ func BuyBimbinganCode(respw http.ResponseWriter, req *http.Request) {
 					{Name: "🎓 NPM", Value: user.NPM, Inline: true},
 					{Name: "📱 Phone", Value: user.PhoneNumber, Inline: true},
 					{Name: "🎫 Generated Code", Value: generatedCode, Inline: true},
-					{Name: "💰 Points Used", Value: fmt.Sprintf("%d", requiredPoints), Inline: true},
-					{Name: "📊 Remaining Points", Value: fmt.Sprintf("%d", user.PointEvent), Inline: true},
-					{Name: "📅 Created At", Value: eventCode.CreatedAt.Format("2006-01-02 15:04:05"), Inline: true},
-					{Name: "🆔 Code ID", Value: fmt.Sprintf("%v", eventCodeID), Inline: false},
+					{Name: "💰 Points Used", Value: fmt.Sprintf("%d", item.Harga), Inline: true},
+					{Name: "📊 Remaining Points", Value: fmt.Sprintf("%d", sisa), Inline: true},
+					{Name: "📅 Created At", Value: pembelian.CreatedAt.Format("2006-01-02 15:04:05"), Inline: true},
+					{Name: "🆔 Purchase ID", Value: pembelian.ID.Hex(), Inline: false},
 				},
 				Timestamp: time.Now().Format(time.RFC3339),
 			},
@@ -1412,64 +1459,30 @@ func BuyBimbinganCode(respw http.ResponseWriter, req *http.Request) {
 	go sendDiscordNotification(discordPayload)
 
 	respn.Status = "Success"
-	respn.Response = fmt.Sprintf("Code bimbingan berhasil dibeli! Poin dikurangi %d, sisa %d poin", requiredPoints, user.PointEvent)
+	respn.Response = fmt.Sprintf("Code bimbingan berhasil dibeli! Poin dikurangi %d, sisa %d poin", item.Harga, sisa)
 	respn.Data = map[string]interface{}{
 		"code":             generatedCode,
-		"eventcode_id":     eventCodeID,
-		"points_used":      requiredPoints,
-		"remaining_points": user.PointEvent,
-		"created_at":       eventCode.CreatedAt,
+		"pembelian_id":     pembelian.ID.Hex(),
+		"points_used":      item.Harga,
+		"remaining_points": sisa,
+		"created_at":       pembelian.CreatedAt,
 		"message":          fmt.Sprintf("Selamat! Anda mendapat code bimbingan: %s (sekali pakai)", generatedCode),
 		"usage_note":       "Code ini dapat digunakan sekali oleh satu user untuk mendapatkan bimbingan",
 	}
 	at.WriteJSON(respw, http.StatusOK, respn)
 }
 
-// generateBimbinganCode untuk generate code bimbingan dengan prefix GLR-
-func generateBimbinganCode() string {
-	// Generate random code dengan prefix GLR-
-	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	code := make([]byte, 6)
-	for i := range code {
-		code[i] = charset[rand.Intn(len(charset))]
-	}
-	return "GLR-" + string(code)
-}
-
 // DeleteEvent untuk menghapus event (khusus owner)
 func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat menghapus event"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Get event ID from URL parameter
 	eventId := at.GetParam(req)
 	if eventId == "" {
 		respn.Status = "Error : Event ID tidak ditemukan"
 		respn.Response = "Event ID harus disediakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1478,7 +1491,7 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1489,7 +1502,7 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1500,7 +1513,7 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menghapus claims terkait"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -1511,7 +1524,7 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menghapus event"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -1526,7 +1539,7 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 				Fields: []DiscordEmbedeventField{
 					{Name: "📋 Event Name", Value: event.Name, Inline: true},
 					{Name: "🎯 Points", Value: fmt.Sprintf("%d", event.Points), Inline: true},
-					{Name: "👤 Deleted By", Value: payload.Id, Inline: true},
+					{Name: "👤 Deleted By", Value: phonenumber, Inline: true},
 					{Name: "🕒 Deleted At", Value: time.Now().Format("2006-01-02 15:04:05"), Inline: true},
 					{Name: "🆔 Event ID", Value: eventId, Inline: true},
 					{Name: "📝 Description", Value: event.Description, Inline: false},
@@ -1549,37 +1562,14 @@ func DeleteEvent(respw http.ResponseWriter, req *http.Request) {
 // DeleteEventClaim untuk menghapus claim event (khusus owner)
 func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat menghapus claim event"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
+	phonenumber := rbac.PhoneNumber(req)
 
 	// Get claim ID from URL parameter
 	claimId := at.GetParam(req)
 	if claimId == "" {
 		respn.Status = "Error : Claim ID tidak ditemukan"
 		respn.Response = "Claim ID harus disediakan"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1588,7 +1578,7 @@ func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim ID tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 
@@ -1599,7 +1589,7 @@ func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Claim tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -1610,20 +1600,24 @@ func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Event tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
-	// Delete the claim
-	_, err = atdb.DeleteOneDoc(config.Mongoconn, "eventclaims", primitive.M{
-		"_id": claimObjectID,
+	// Delete the claim, kursi kuota dikembalikan jika claim masih aktif
+	deleted, err := atdb.DeleteOneDoc(config.Mongoconn, "eventclaims", primitive.M{
+		"_id":    claimObjectID,
+		"status": claim.Status,
 	})
 	if err != nil {
 		respn.Status = "Error : Gagal menghapus claim"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
+	if deleted.DeletedCount == 1 && claimAktif(claim.Status) {
+		lepasKursiClaim(claim.EventID)
+	}
 
 	// Set event back to active if it was claimed
 	if claim.Status == "claimed" || claim.Status == "submitted" {
@@ -1647,7 +1641,7 @@ func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 					{Name: "📋 Event Name", Value: event.Name, Inline: true},
 					{Name: "👤 User Phone", Value: claim.UserPhone, Inline: true},
 					{Name: "📊 Status", Value: claim.Status, Inline: true},
-					{Name: "👨‍💼 Deleted By", Value: payload.Id, Inline: true},
+					{Name: "👨‍💼 Deleted By", Value: phonenumber, Inline: true},
 					{Name: "🕒 Deleted At", Value: time.Now().Format("2006-01-02 15:04:05"), Inline: true},
 					{Name: "🔄 Event Reactivated", Value: fmt.Sprintf("%t", claim.Status == "claimed" || claim.Status == "submitted"), Inline: true},
 					{Name: "🆔 Claim ID", Value: claimId, Inline: false},
@@ -1672,82 +1666,34 @@ func DeleteEventClaim(respw http.ResponseWriter, req *http.Request) {
 // GetAllEventClaims untuk mendapatkan semua claims (khusus owner)
 func GetAllEventClaims(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat melihat semua claims"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
 
 	// Get all claims
 	claims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{})
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data claims"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
 	// Debug log
 	fmt.Printf("Found %d claims in database\n", len(claims))
 
-	// Enrich claims with event data
-	var enrichedClaims []map[string]interface{}
-
-	// If no claims found, return empty array instead of null
-	if len(claims) == 0 {
-		enrichedClaims = []map[string]interface{}{}
-	} else {
-		for _, claim := range claims {
-			// Get event data
-			event, err := atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{
-				"_id": claim.EventID,
-			})
-
-			claimData := map[string]interface{}{
-				"_id":         claim.ID,
-				"eventid":     claim.EventID,
-				"userphone":   claim.UserPhone,
-				"username":    claim.UserName,
-				"usernpm":     claim.UserNPM,
-				"claimedat":   claim.ClaimedAt,
-				"deadline":    claim.Deadline,
-				"status":      claim.Status,
-				"tasklink":    claim.TaskLink,
-				"submittedat": claim.SubmittedAt,
-				"approvedat":  claim.ApprovedAt,
-				"approvedby":  claim.ApprovedBy,
-				"isapproved":  claim.IsApproved,
-			}
-
-			if err == nil {
-				claimData["eventname"] = event.Name
-				claimData["eventpoints"] = event.Points
-			} else {
-				claimData["eventname"] = "Unknown Event"
-				claimData["eventpoints"] = 0
-			}
+	// Enrich claims with event data, array kosong jika belum ada claim
+	enrichedClaims := []model.EventClaimDetail{}
+	for _, claim := range claims {
+		// Get event data
+		event, err := atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{
+			"_id": claim.EventID,
+		})
 
-			enrichedClaims = append(enrichedClaims, claimData)
+		claimData := model.EventClaimDetail{EventClaim: claim, EventName: "Unknown Event"}
+		if err == nil {
+			claimData.EventName = event.Name
+			claimData.EventPoints = event.Points
 		}
+
+		enrichedClaims = append(enrichedClaims, claimData)
 	}
 
 	respn.Status = "Success"
@@ -1759,37 +1705,13 @@ func GetAllEventClaims(respw http.ResponseWriter, req *http.Request) {
 // GetAllEventsForOwner untuk mendapatkan semua events untuk owner management
 func GetAllEventsForOwner(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-
-	// Cek apakah user adalah owner
-	allowedNumbers := []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
-	isOwner := false
-	for _, num := range allowedNumbers {
-		if payload.Id == num {
-			isOwner = true
-			break
-		}
-	}
-
-	if !isOwner {
-		respn.Status = "Error : Akses Ditolak"
-		respn.Response = "Hanya owner yang dapat melihat semua events"
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
 
 	// Get all events (tidak filter berdasarkan isactive untuk management)
 	events, err := atdb.GetAllDoc[[]model.Event](config.Mongoconn, "events", primitive.M{})
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data events"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/eventjadwal"
+	"github.com/gocroot/model"
+)
+
+// RefreshEventJadwal dipasang di cronjob setiap beberapa menit. Event terjadwal yang sudah tiba waktunya diaktifkan
+// dan diumumkan ke Discord dan grup WA, event yang melewati end_at dinonaktifkan, dan seri berulang disiapkan event berikutnya.
+func RefreshEventJadwal(respw http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	aktif, err := eventjadwal.Aktifkan(config.Mongoconn, now)
+	for _, event := range aktif {
+		umumkanEvent(event, "A scheduled event is now open")
+	}
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal mengaktifkan event terjadwal"))
+		return
+	}
+	selesai, err := eventjadwal.Kedaluwarsa(config.Mongoconn, now)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menonaktifkan event"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: strconv.Itoa(len(aktif)) + " event diaktifkan, " + strconv.FormatInt(selesai, 10) + " event berakhir",
+		Data:     aktif,
+	})
+}
@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/waoutbox"
+	"github.com/gocroot/helper/whatsauth"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activeClaimStatuses adalah status claim yang masih memakai kuota event
+var activeClaimStatuses = []string{model.ClaimClaimed, model.ClaimSubmitted, model.ClaimRevision}
+
+// eventQuota adalah jumlah claimant aktif yang boleh mengerjakan event bersamaan
+func eventQuota(event model.Event) int {
+	if event.MaxClaimants <= 0 {
+		return 1
+	}
+	return event.MaxClaimants
+}
+
+func claimAktif(status string) bool {
+	for _, s := range activeClaimStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+var eventClaimIndexOnce sync.Once
+
+// ensureEventClaimIndexes menjaga satu claim aktif per user per event walau request claim datang bersamaan
+func ensureEventClaimIndexes() {
+	eventClaimIndexOnce.Do(func() {
+		_, err := config.Mongoconn.Collection("eventclaims").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "eventid", Value: 1}, {Key: "userphone", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"aktif": true}),
+		})
+		if err != nil {
+			log.Printf("Error creating eventclaims index: %v", err)
+		}
+	})
+}
+
+// pesanKursiClaim menaikkan claimcount event secara atomik selama masih di bawah kuota, false jika kuota penuh.
+// Event lama yang belum punya claimcount diisi dulu dari jumlah claim aktifnya.
+func pesanKursiClaim(event model.Event) (bool, error) {
+	coll := config.Mongoconn.Collection("events")
+	if event.ClaimCount == 0 {
+		n, err := config.Mongoconn.Collection("eventclaims").CountDocuments(context.Background(), bson.M{
+			"eventid": event.ID,
+			"status":  bson.M{"$in": activeClaimStatuses},
+		})
+		if err != nil {
+			return false, err
+		}
+		_, err = coll.UpdateOne(context.Background(), bson.M{"_id": event.ID, "claimcount": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"claimcount": n}})
+		if err != nil {
+			return false, err
+		}
+	}
+	res, err := coll.UpdateOne(context.Background(),
+		bson.M{"_id": event.ID, "isactive": true, "claimcount": bson.M{"$lt": eventQuota(event)}},
+		bson.M{"$inc": bson.M{"claimcount": 1}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// lepasKursiClaim mengembalikan satu kursi kuota event
+func lepasKursiClaim(eventID primitive.ObjectID) {
+	_, err := config.Mongoconn.Collection("events").UpdateOne(context.Background(),
+		bson.M{"_id": eventID, "claimcount": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"claimcount": -1}})
+	if err != nil {
+		log.Printf("Error melepas kuota event %s: %v", eventID.Hex(), err)
+	}
+}
+
+// gantiClaim menyimpan claim hanya jika statusnya masih statusLama, false jika sudah diubah proses lain.
+// Kursi kuota dikembalikan tepat sekali saat claim keluar dari status aktif.
+func gantiClaim(claim model.EventClaim, statusLama string) (bool, error) {
+	claim.Aktif = claimAktif(claim.Status)
+	res, err := atdb.ReplaceOneDoc(config.Mongoconn, "eventclaims", primitive.M{"_id": claim.ID, "status": statusLama}, claim)
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount == 0 {
+		return false, nil
+	}
+	if claimAktif(statusLama) && !claim.Aktif {
+		lepasKursiClaim(claim.EventID)
+	}
+	return true, nil
+}
+
+// eventProfile adalah data user yang dipakai untuk mengecek EventEligibility,
+// diambil sekali per request supaya daftar event tidak memicu query per event
+type eventProfile struct {
+	enroll map[string]bool
+	kelas  map[string]bool
+	points int
+}
+
+func loadEventProfile(db *mongo.Database, user model.Userdomyikado) (p eventProfile, err error) {
+	p = eventProfile{enroll: make(map[string]bool), kelas: make(map[string]bool), points: user.PointEvent}
+	enrolls, err := atdb.GetAllDistinct[string](db, primitive.M{
+		"$or": []primitive.M{{"members.phonenumber": user.PhoneNumber}, {"owner.phonenumber": user.PhoneNumber}},
+	}, "enroll", "project")
+	if err != nil {
+		return
+	}
+	for _, e := range enrolls {
+		p.enroll[e] = true
+	}
+	for _, coll := range []string{"tugaskelasai", "tugaskelasws"} {
+		kelas, err := atdb.GetAllDistinct[string](db, primitive.M{"phonenumber": user.PhoneNumber}, "kelas", coll)
+		if err != nil {
+			return p, err
+		}
+		for _, k := range kelas {
+			p.kelas[k] = true
+		}
+	}
+	return
+}
+
+// alasanTidakEligible mengembalikan alasan user tidak memenuhi syarat, kosong jika memenuhi
+func (p eventProfile) alasanTidakEligible(syarat model.EventEligibility) string {
+	if len(syarat.Enroll) > 0 && !p.punyaSalahSatu(p.enroll, syarat.Enroll) {
+		return "Event khusus peserta enroll " + strings.Join(syarat.Enroll, ", ")
+	}
+	if len(syarat.Kelas) > 0 && !p.punyaSalahSatu(p.kelas, syarat.Kelas) {
+		return "Event khusus kelas " + strings.Join(syarat.Kelas, ", ")
+	}
+	if p.points < syarat.MinPoints {
+		return fmt.Sprintf("Event memerlukan minimal %d poin event, poin Anda %d", syarat.MinPoints, p.points)
+	}
+	return ""
+}
+
+func (p eventProfile) punyaSalahSatu(milik map[string]bool, daftar []string) bool {
+	for _, d := range daftar {
+		if milik[d] {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewEventClaim untuk menolak claim dengan komentar atau meminta revisi (reviewer event).
+// Revisi memberi deadline baru sepanjang DeadlineSeconds event, penolakan bersifat final dan membebaskan kuota.
+func ReviewEventClaim(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	phonenumber := rbac.PhoneNumber(req)
+
+	var reviewReq model.EventReviewRequest
+	err := json.NewDecoder(req.Body).Decode(&reviewReq)
+	if err != nil {
+		respn.Status = "Error : Body tidak valid"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
+		return
+	}
+	if reviewReq.Action != "reject" && reviewReq.Action != "revision" {
+		respn.Status = "Error : Aksi review tidak valid"
+		respn.Response = "Gunakan action reject atau revision"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
+		return
+	}
+	reviewReq.Comment = strings.TrimSpace(reviewReq.Comment)
+	if reviewReq.Comment == "" {
+		respn.Status = "Error : Komentar harus diisi"
+		respn.Response = "Tuliskan alasan penolakan atau bagian yang perlu direvisi"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
+		return
+	}
+
+	claimObjectID, err := primitive.ObjectIDFromHex(reviewReq.ClaimID)
+	if err != nil {
+		respn.Status = "Error : Claim ID tidak valid"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
+		return
+	}
+	claim, err := atdb.GetOneDoc[model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{
+		"_id":    claimObjectID,
+		"status": model.ClaimSubmitted,
+	})
+	if err != nil {
+		respn.Status = "Error : Claim tidak ditemukan atau belum disubmit"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
+		return
+	}
+	event, err := atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{"_id": claim.EventID})
+	if err != nil {
+		respn.Status = "Error : Event tidak ditemukan"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
+		return
+	}
+
+	now := time.Now()
+	claim.ReviewComment = reviewReq.Comment
+	claim.ReviewedBy = phonenumber
+	claim.ReviewedAt = now
+	var message string
+	if reviewReq.Action == "revision" {
+		claim.Status = model.ClaimRevision
+		claim.Revisions++
+		claim.Deadline = now.Add(time.Duration(event.DeadlineSeconds) * time.Second)
+		message = fmt.Sprintf("✏️ *Revisi Tugas Event*\n\n📋 Event: %s\n💬 Catatan: %s\n⏰ Submit ulang sebelum: %s",
+			event.Name, claim.ReviewComment, claim.Deadline.Format("2006-01-02 15:04:05"))
+	} else {
+		claim.Status = model.ClaimRejected
+		message = fmt.Sprintf("❌ *Tugas Event Ditolak*\n\n📋 Event: %s\n💬 Alasan: %s", event.Name, claim.ReviewComment)
+	}
+
+	ok, err := gantiClaim(claim, model.ClaimSubmitted)
+	if err != nil {
+		respn.Status = "Error : Gagal menyimpan review"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	if !ok {
+		respn.Status = "Error : Claim sudah diproses"
+		respn.Response = "Claim ini sudah direview atau diapprove reviewer lain"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
+		return
+	}
+
+	_, resp, err := waoutbox.Send(config.Mongoconn, &whatsauth.TextMessage{To: claim.UserPhone, IsGroup: false, Messages: message})
+	if err != nil {
+		log.Printf("Failed to send WhatsApp review to %s: %v, info: %s", claim.UserPhone, err, resp.Info)
+	}
+
+	respn.Status = "Success"
+	respn.Response = "Review tersimpan, status claim " + claim.Status
+	respn.Data = map[string]interface{}{
+		"claim": claim,
+		"event": event,
+	}
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// GetEventReviewQueue untuk antrian review, claim submitted terlama di urutan pertama.
+// Claim yang linknya tidak lolos cek otomatis ditandai flagged beserta alasannya.
+// Filter opsional ?category=
+func GetEventReviewQueue(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	claims, err := atdb.GetAllDoc[[]model.EventClaim](config.Mongoconn, "eventclaims", primitive.M{"status": model.ClaimSubmitted})
+	if err != nil {
+		respn.Status = "Error : Gagal mengambil antrian review"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	sort.SliceStable(claims, func(i, j int) bool { return claims[i].SubmittedAt.Before(claims[j].SubmittedAt) })
+
+	category := req.URL.Query().Get("category")
+	events := make(map[primitive.ObjectID]model.Event)
+	now := time.Now()
+	queue := []model.EventReviewItem{}
+	for _, claim := range claims {
+		event, ok := events[claim.EventID]
+		if !ok {
+			event, err = atdb.GetOneDoc[model.Event](config.Mongoconn, "events", primitive.M{"_id": claim.EventID})
+			if err != nil {
+				continue
+			}
+			events[claim.EventID] = event
+		}
+		if category != "" && event.Category != category {
+			continue
+		}
+		queue = append(queue, model.EventReviewItem{
+			ClaimID:     claim.ID.Hex(),
+			EventID:     event.ID.Hex(),
+			EventName:   event.Name,
+			Category:    event.Category,
+			Points:      event.Points,
+			Username:    claim.UserName,
+			NPM:         claim.UserNPM,
+			PhoneNumber: claim.UserPhone,
+			TaskLink:    claim.TaskLink,
+			SubmittedAt: claim.SubmittedAt,
+			AgeSeconds:  int(now.Sub(claim.SubmittedAt).Seconds()),
+			Revisions:   claim.Revisions,
+			Flagged:     claim.LinkCheck != nil && !claim.LinkCheck.Passed,
+			LinkCheck:   claim.LinkCheck,
+		})
+	}
+
+	respn.Status = "Success"
+	respn.Response = fmt.Sprintf("%d tugas menunggu review", len(queue))
+	respn.Data = queue
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/report"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
@@ -23,12 +25,12 @@ func GetGTMetrixDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 	// Validasi token
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusForbidden, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(req),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	
@@ -39,45 +41,45 @@ func GetGTMetrixDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 
 	err = config.Mongoconn.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error: Config Not Found",
 			Location: "Database Config",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	
 	// HTTP Client request ke API GTMetrix
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := atapi.NewClient(15 * time.Second)
 	resp, err := client.Get(conf.PomokitUrl) // URL yang sama dengan Pomokit (sesuaikan jika berbeda)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: API Connection Failed",
 			Location: "GTMetrix API",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   fmt.Sprintf("Error: API Returned Status %d", resp.StatusCode),
 			Location: "GTMetrix API",
 			Response: string(body),
-		})
+		}))
 		return
 	}
 	
 	// Proses response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: Failed to Read Response",
 			Location: "Response Reading",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	
@@ -95,11 +97,11 @@ func GetGTMetrixDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 		
 		err = json.Unmarshal(body, &apiResponse)
 		if err != nil {
-			at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 				Status:   "Error: Invalid API Response Format",
 				Location: "Response Decoding",
 				Response: fmt.Sprintf("Error: %v, Raw Response: %s", err, string(body)),
-			})
+			}))
 			return
 		}
 		gtmetrixReports = apiResponse.Data
@@ -126,10 +128,13 @@ func GetGTMetrixDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 	
 	// Kembalikan data kosong jika tidak ada yang cocok
 	if len(matchingReports) == 0 {
-		at.WriteJSON(respw, http.StatusNotFound, model.GTMetrixInfo{
-			PhoneNumber: payload.Id,
-			Name:        payload.Alias,
-		})
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, model.Response{
+			Status: "Error : Laporan GTMetrix tidak ditemukan",
+			Data: model.GTMetrixInfo{
+				PhoneNumber: payload.Id,
+				Name:        payload.Alias,
+			},
+		}))
 		return
 	}
 
@@ -172,7 +177,7 @@ func GetGTMetrixReportYesterday(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan GTMetrix Kemarin"
 		resp.Response = "Parameter 'groupid' tidak boleh kosong"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -182,7 +187,7 @@ func GetGTMetrixReportYesterday(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan GTMetrix Kemarin"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -197,7 +202,7 @@ func GetGTMetrixReportYesterday(respw http.ResponseWriter, req *http.Request) {
 			resp.Status = "Error"
 			resp.Location = "Laporan GTMetrix Total"
 			resp.Response = "Gagal mengirim laporan: " + err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 			return
 		}
 
@@ -253,7 +258,7 @@ func RefreshGTMetrixHarianReport(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan GTMetrix Harian"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 	case <-time.After(2 * time.Second):
 		// Timeout, tetapi proses tetap berjalan di background
 		resp.Status = "Success"
@@ -301,7 +306,7 @@ func RefreshGTMetrixMingguanReport(respw http.ResponseWriter, req *http.Request)
         resp.Status = "Error"
         resp.Location = "Laporan GTMetrix Mingguan"
         resp.Response = err.Error()
-        at.WriteJSON(respw, http.StatusInternalServerError, resp)
+        at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
     case <-time.After(2 * time.Second):
         // Timeout, tetapi proses tetap berjalan di background
         resp.Status = "Success"
@@ -321,7 +326,7 @@ func GetGTMetrixReportLastWeek(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan GTMetrix Seminggu Terakhir"
 		resp.Response = "Parameter 'groupid' tidak boleh kosong"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -331,7 +336,7 @@ func GetGTMetrixReportLastWeek(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan GTMetrix Seminggu Terakhir"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -346,7 +351,7 @@ func GetGTMetrixReportLastWeek(respw http.ResponseWriter, req *http.Request) {
 			resp.Status = "Error"
 			resp.Location = "Laporan GTMetrix Seminggu Terakhir"
 			resp.Response = "Gagal mengirim laporan: " + err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 			return
 		}
 
@@ -373,7 +378,7 @@ func GetGTMetrixReportTotal(respw http.ResponseWriter, req *http.Request) {
         resp.Status = "Error"
         resp.Location = "Laporan GTMetrix Total"
         resp.Response = "Parameter 'groupid' tidak boleh kosong"
-        at.WriteJSON(respw, http.StatusBadRequest, resp)
+        at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
         return
     }
 
@@ -383,7 +388,7 @@ func GetGTMetrixReportTotal(respw http.ResponseWriter, req *http.Request) {
         resp.Status = "Error"
         resp.Location = "Laporan GTMetrix Total"
         resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-        at.WriteJSON(respw, http.StatusInternalServerError, resp)
+        at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
         return
     }
 
@@ -398,7 +403,7 @@ func GetGTMetrixReportTotal(respw http.ResponseWriter, req *http.Request) {
             resp.Status = "Error"
             resp.Location = "Laporan GTMetrix Total"
             resp.Response = "Gagal mengirim laporan: " + err.Error()
-            at.WriteJSON(respw, http.StatusInternalServerError, resp)
+            at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
             return
         }
 
@@ -418,11 +423,11 @@ func GetGTMetrixReportTotal(respw http.ResponseWriter, req *http.Request) {
 // untuk activity_score.go
 
 // Fungsi untuk mendapatkan skor GTMetrix terbaru
-func GetGTMetrixScoreForUser(phoneNumber string) (model.ActivityScore, error) {
+func GetGTMetrixScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
     var score model.ActivityScore
     
     // Ambil semua data GTMetrix (tanpa filter waktu)
-    allGTMetrixData, err := report.GetGTMetrixData(config.Mongoconn, false, false)
+    allGTMetrixData, err := report.GetGTMetrixDataContext(ctx, config.Mongoconn, false, false)
     if err != nil {
         return score, err
     }
@@ -465,11 +470,11 @@ func GetGTMetrixScoreForUser(phoneNumber string) (model.ActivityScore, error) {
 }
 
 // Fungsi untuk mendapatkan skor GTMetrix seminggu terakhir
-func GetLastWeekGTMetrixScoreForUser(phoneNumber string) (model.ActivityScore, error) {
+func GetLastWeekGTMetrixScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
     var score model.ActivityScore
     
     // Ambil data GTMetrix seminggu terakhir
-    lastWeekData, err := report.GetGTMetrixData(config.Mongoconn, false, true)
+    lastWeekData, err := report.GetGTMetrixDataContext(ctx, config.Mongoconn, false, true)
     if err != nil {
         return score, err
     }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
@@ -41,10 +42,7 @@ func GetOneIqQuestion(w http.ResponseWriter, r *http.Request) {
 	err := config.Mongoconn.Collection("iqquestion").FindOne(context.Background(), filter).Decode(&iqQuestion)
 	if err != nil {
 		log.Printf("Error querying IQ question with ID %s: %v", id, err)
-		at.WriteJSON(w, http.StatusNotFound, map[string]string{
-			"message": "Soal IQ tidak ditemukan.",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.Wrap(apperr.NotFound, "Soal IQ tidak ditemukan.", err))
 		return
 	}
 
@@ -63,7 +61,7 @@ func GetIqScoring(w http.ResponseWriter, r *http.Request) {
 	cursor, err := collection.Find(context.Background(), bson.M{})
 	if err != nil {
 		log.Println("Gagal mengambil skor referensi:", err)
-		http.Error(w, "Gagal mengambil data", http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil data", ""))
 		return
 	}
 	defer cursor.Close(context.Background())
@@ -71,7 +69,7 @@ func GetIqScoring(w http.ResponseWriter, r *http.Request) {
 	var results []model.IqScoring
 	if err = cursor.All(context.Background(), &results); err != nil {
 		log.Println("Gagal decode data:", err)
-		http.Error(w, "Gagal memproses data", http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal memproses data", ""))
 		return
 	}
 
@@ -84,21 +82,21 @@ func HandleGetAllDataIQScore(w http.ResponseWriter, r *http.Request) {
 	// Ambil token dari header
 	token := at.GetLoginFromHeader(r)
 	if token == "" {
-		http.Error(w, `{"error": "Token login diperlukan"}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Token login diperlukan", ""))
 		return
 	}
 
 	// Decode token
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, token)
 	if err != nil {
-		http.Error(w, `{"error": "Token tidak valid"}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Token tidak valid", ""))
 		return
 	}
 
 	// Panggil fungsi logika
-	result, err := GetAllDataIQScore(config.Mongoconn, payload.Id)
+	result, err := GetAllDataIQScore(r.Context(), config.Mongoconn, payload.Id)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(`{"error": "%s"}`, err.Error()), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "", err))
 		return
 	}
 
@@ -107,7 +105,7 @@ func HandleGetAllDataIQScore(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
-func GetAllDataIQScore(db *mongo.Database, phonenumber string) (model.ActivityScore, error) {
+func GetAllDataIQScore(ctx context.Context, db *mongo.Database, phonenumber string) (model.ActivityScore, error) {
 	var activityscore model.ActivityScore
 
 	// Ambil data IQ Score berdasarkan nomor telepon dan urutkan berdasarkan created_at (terlama)
@@ -115,14 +113,14 @@ func GetAllDataIQScore(db *mongo.Database, phonenumber string) (model.ActivitySc
 	sort := bson.M{"created_at": 1} // Sort by created_at in ascending order (terlama)
 
 	// Ambil data pertama (terlama)
-	cursor, err := db.Collection("iqscore").Find(context.TODO(), filter, options.Find().SetSort(sort).SetLimit(1))
+	cursor, err := db.Collection("iqscore").Find(ctx, filter, options.Find().SetSort(sort).SetLimit(1))
 	if err != nil {
 		return activityscore, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	// Pastikan data ditemukan
-	if cursor.Next(context.TODO()) {
+	if cursor.Next(ctx) {
 		var iqDoc model.UserWithIqScore
 		if err := cursor.Decode(&iqDoc); err != nil {
 			return activityscore, err
@@ -147,24 +145,24 @@ func GetUserAndIqScore(respw http.ResponseWriter, req *http.Request) {
 	// Decode token menggunakan `at.GetLoginFromHeader(req)`
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusForbidden, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(req),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
 	// Ambil `phonenumber` dari payload
 	phoneNumber := payload.Id
 	if phoneNumber == "" {
-		at.WriteJSON(respw, http.StatusUnauthorized, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Missing Phonenumber",
 			Info:     "Nomor telepon tidak ditemukan dalam token",
 			Location: "Token Parsing",
 			Response: "Invalid Payload",
-		})
+		}))
 		return
 	}
 
@@ -176,12 +174,12 @@ func GetUserAndIqScore(respw http.ResponseWriter, req *http.Request) {
 	var user model.Userdomyikado
 	err = userCollection.FindOne(context.TODO(), bson.M{"phonenumber": phoneNumber}).Decode(&user)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
 			Status:   "Error: User Not Found",
 			Info:     "Tidak ada user dengan nomor telepon ini",
 			Location: "User Lookup",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -328,14 +326,14 @@ func PostAnswer(w http.ResponseWriter, r *http.Request) {
 	// Cek Token Login di Header
 	token := at.GetLoginFromHeader(r)
 	if token == "" {
-		http.Error(w, `{"error": "Akses ditolak! Token login diperlukan."}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Akses ditolak! Token login diperlukan.", ""))
 		return
 	}
 
 	// Decode token untuk mendapatkan user ID dan Alias
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, token)
 	if err != nil {
-		http.Error(w, `{"error": "Token tidak valid atau tidak dapat didecode"}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Token tidak valid atau tidak dapat didecode", ""))
 		return
 	}
 
@@ -355,7 +353,7 @@ func PostAnswer(w http.ResponseWriter, r *http.Request) {
 	var userAnswer model.UserAnswer
 	err = json.NewDecoder(r.Body).Decode(&userAnswer)
 	if err != nil {
-		http.Error(w, `{"error": "Gagal membaca data"}`, http.StatusBadRequest)
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Gagal membaca data", ""))
 		return
 	}
 
@@ -368,14 +366,14 @@ func PostAnswer(w http.ResponseWriter, r *http.Request) {
 	collection := config.Mongoconn.Collection("iqquestion")
 	cursor, err := collection.Find(context.TODO(), bson.M{})
 	if err != nil {
-		http.Error(w, `{"error": "Gagal mengambil data dari database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil data dari database", ""))
 		return
 	}
 	defer cursor.Close(context.TODO())
 
 	var correctAnswers []model.SoalIQ
 	if err = cursor.All(context.TODO(), &correctAnswers); err != nil {
-		http.Error(w, `{"error": "Gagal membaca jawaban dari database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal membaca jawaban dari database", ""))
 		return
 	}
 
@@ -394,14 +392,14 @@ func PostAnswer(w http.ResponseWriter, r *http.Request) {
 	var iqScoring model.IqScoring
 	err = iqScoringCollection.FindOne(context.TODO(), bson.M{"score": fmt.Sprintf("%d", correctCount)}).Decode(&iqScoring)
 	if err != nil {
-		http.Error(w, `{"error": "Gagal mendapatkan data IQ dari database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mendapatkan data IQ dari database", ""))
 		return
 	}
 
 	// Konversi waktu ke zona WIB (UTC+7)
 	loc, err := time.LoadLocation("Asia/Jakarta") // WIB (Western Indonesian Time)
 	if err != nil {
-		http.Error(w, `{"error": "Gagal mengatur zona waktu"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Internal, "Gagal mengatur zona waktu", ""))
 		return
 	}
 
@@ -425,7 +423,7 @@ func PostAnswer(w http.ResponseWriter, r *http.Request) {
 
 	_, err = iqScoreCollection.InsertOne(context.TODO(), newIqScore)
 	if err != nil {
-		http.Error(w, `{"error": "Gagal menyimpan skor ke database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal menyimpan skor ke database", ""))
 		return
 	}
 
@@ -453,11 +451,11 @@ func GetIqScoreDataDaily(w http.ResponseWriter, r *http.Request) {
 	// Jalankan fungsi rekap IQ Score harian
 	err := report.RekapIqScoreHarian(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error",
 			Info:     "Gagal melakukan rekap IQ Score",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -477,11 +475,11 @@ func GetIqScoreDataWeekly(w http.ResponseWriter, r *http.Request) {
 	// Jalankan fungsi rekap IQ Score harian
 	err := report.RekapIqScoreMingguan(db)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error",
 			Info:     "Gagal melakukan rekap IQ Score",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/libur"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetLiburNasional menampilkan kalender libur, filter opsional ?tahun=2025&kampus=kode
+func GetLiburNasional(respw http.ResponseWriter, req *http.Request) {
+	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	filter := bson.M{}
+	if tahun := req.URL.Query().Get("tahun"); tahun != "" {
+		if _, err := strconv.Atoi(tahun); err != nil {
+			at.WriteError(respw, req, apperr.New(apperr.BadRequest, "Error : Tahun tidak valid", tahun))
+			return
+		}
+		filter["tanggal"] = bson.M{"$regex": "^" + tahun + "-"}
+	}
+	if kampus := req.URL.Query().Get("kampus"); kampus != "" {
+		filter["kampus"] = bson.M{"$in": bson.A{"", nil, kampus}}
+	}
+	docs, err := atdb.GetAllDoc[[]model.LiburNasional](config.Mongoconn, libur.Collection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil kalender libur", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, docs)
+}
+
+// PostLiburNasional menambah satu tanggal atau rentang tanggal libur (khusus owner)
+func PostLiburNasional(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	var liburReq model.LiburRequest
+	if err := json.NewDecoder(req.Body).Decode(&liburReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	docs, err := libur.DariRequest(liburReq)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.BadRequest, "Error : Tanggal libur tidak valid", err.Error()))
+		return
+	}
+	simpanLibur(respw, req, docs, phonenumber)
+}
+
+// ImportLiburNasional mengimpor file kalender tahunan (khusus owner).
+// Body berupa isi file JSON format dayoffapi atau file ICS, ?kampus=kode untuk libur tambahan kampus.
+func ImportLiburNasional(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	body, err := io.ReadAll(req.Body)
+	if err != nil || len(body) == 0 {
+		at.WriteError(respw, req, apperr.New(apperr.BadRequest, "Error : File kalender kosong", "kirim isi file JSON atau ICS sebagai body"))
+		return
+	}
+	var docs []model.LiburNasional
+	if bytes.Contains(body[:min(len(body), 64)], []byte("BEGIN:VCALENDAR")) {
+		docs, err = libur.ParseICS(body)
+	} else {
+		docs, err = libur.ParseJSON(body)
+	}
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.BadRequest, "Error : File kalender tidak valid", err.Error()))
+		return
+	}
+	kampus := req.URL.Query().Get("kampus")
+	for i := range docs {
+		docs[i].Kampus = kampus
+	}
+	simpanLibur(respw, req, docs, phonenumber)
+}
+
+// PutLiburNasional mengubah keterangan atau status cuti satu tanggal libur (khusus owner)
+func PutLiburNasional(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	doc, ok := getLiburByParam(respw, req)
+	if !ok {
+		return
+	}
+	var liburReq model.LiburRequest
+	if err := json.NewDecoder(req.Body).Decode(&liburReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	_, err := atdb.UpdateOneDoc(config.Mongoconn, libur.Collection, bson.M{"_id": doc.ID}, bson.M{
+		"keterangan": liburReq.Keterangan,
+		"iscuti":     liburReq.IsCuti,
+		"createdby":  phonenumber,
+	})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengubah tanggal libur", err.Error()))
+		return
+	}
+	libur.Invalidate()
+	doc.Keterangan = liburReq.Keterangan
+	doc.IsCuti = liburReq.IsCuti
+	doc.CreatedBy = phonenumber
+	at.WriteJSON(respw, http.StatusOK, doc)
+}
+
+// DeleteLiburNasional menghapus satu tanggal libur (khusus owner)
+func DeleteLiburNasional(respw http.ResponseWriter, req *http.Request) {
+	doc, ok := getLiburByParam(respw, req)
+	if !ok {
+		return
+	}
+	if _, err := atdb.DeleteOneDoc(config.Mongoconn, libur.Collection, bson.M{"_id": doc.ID}); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal menghapus tanggal libur", err.Error()))
+		return
+	}
+	libur.Invalidate()
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: "Tanggal libur " + doc.Tanggal + " terhapus",
+	})
+}
+
+func simpanLibur(respw http.ResponseWriter, req *http.Request, docs []model.LiburNasional, phonenumber string) {
+	for i := range docs {
+		docs[i].CreatedBy = phonenumber
+	}
+	n, err := libur.Simpan(config.Mongoconn, docs)
+	if err != nil {
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, model.Response{
+			Status:   "Error : Gagal menyimpan tanggal libur",
+			Response: err.Error(),
+			Info:     strconv.Itoa(n) + " tanggal sudah tersimpan",
+		}))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: strconv.Itoa(n) + " tanggal libur tersimpan",
+	})
+}
+
+func getLiburByParam(respw http.ResponseWriter, req *http.Request) (doc model.LiburNasional, ok bool) {
+	id := router.ParamObjectID(req, "id")
+	doc, err := atdb.GetOneDoc[model.LiburNasional](config.Mongoconn, libur.Collection, bson.M{"_id": id})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Tanggal libur tidak ditemukan", err.Error()))
+		return
+	}
+	return doc, true
+}
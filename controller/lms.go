@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/lms"
 	"github.com/gocroot/model"
@@ -14,7 +15,7 @@ func GetCountDocUser(w http.ResponseWriter, r *http.Request) {
 	rkp, err := lms.GetRekapPendaftaranUsers(config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusConflict, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, rkp)
@@ -25,7 +26,7 @@ func RefreshLMSCookie(respw http.ResponseWriter, req *http.Request) {
 	err := lms.RefreshCookie(config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	resp.Info = "ok"
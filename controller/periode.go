@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+)
+
+// GetPeriodeAkademik menampilkan konfigurasi periode akademik beserta minggu yang sedang berjalan
+func GetPeriodeAkademik(respw http.ResponseWriter, req *http.Request) {
+	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	p := periode.Get(config.Mongoconn)
+	at.WriteJSON(respw, http.StatusOK, model.PeriodeAkademikStatus{
+		Periode: p.Config,
+		Minggu:  p.Status(time.Now()),
+	})
+}
+
+// PutPeriodeAkademik mengganti mulai semester, cutoff minggu dan zona waktu yang dipakai semua skor mingguan (khusus owner)
+func PutPeriodeAkademik(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	var cfg model.PeriodeAkademik
+	if err := json.NewDecoder(req.Body).Decode(&cfg); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	cfg.UpdatedBy = phonenumber
+	p, err := periode.Simpan(config.Mongoconn, cfg)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal menyimpan periode akademik", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.PeriodeAkademikStatus{
+		Periode: p.Config,
+		Minggu:  p.Status(time.Now()),
+	})
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/ledger"
+	"github.com/gocroot/model"
+)
+
+// GetPoinLedgerDrift melaporkan selisih user.poin dan pointevent dengan ledger tanpa menulis apa pun (khusus pengelola sistem)
+func GetPoinLedgerDrift(respw http.ResponseWriter, req *http.Request) {
+	rekonsiliasiPoin(respw, req, false)
+}
+
+// PostPoinLedgerReconcile menyamakan user.poin dan pointevent dengan ledger lalu melaporkan selisihnya (khusus pengelola sistem)
+func PostPoinLedgerReconcile(respw http.ResponseWriter, req *http.Request) {
+	rekonsiliasiPoin(respw, req, true)
+}
+
+func rekonsiliasiPoin(respw http.ResponseWriter, req *http.Request, perbaiki bool) {
+	drifts, err := ledger.Reconcile(config.Mongoconn, perbaiki)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Rekonsiliasi poin gagal", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: strconv.Itoa(len(drifts)) + " saldo berbeda dengan ledger",
+		Data:     drifts,
+	})
+}
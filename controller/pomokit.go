@@ -11,12 +11,16 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/model"
 
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/pomokitcheck"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,12 +31,12 @@ import (
 func GetPomokitDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusForbidden, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(req),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	var conf model.Config
@@ -41,42 +45,42 @@ func GetPomokitDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 
 	err = config.Mongoconn.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error: Config Not Found",
 			Location: "Database Config",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := atapi.NewClient(15 * time.Second)
 	resp, err := client.Get(conf.PomokitUrl) // GET request tanpa header tambahan
 	if err != nil {
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: API Connection Failed",
 			Location: "Pomokit API",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   fmt.Sprintf("Error: API Returned Status %d", resp.StatusCode),
 			Location: "Pomokit API",
 			Response: string(body),
-		})
+		}))
 		return
 	}
 	var apiResponse []model.PomodoroReport
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
 		body, _ := io.ReadAll(resp.Body)
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: Invalid API Response",
 			Location: "Response Decoding",
 			Response: fmt.Sprintf("Error: %v, Raw Response: %s", err, string(body)),
-		})
+		}))
 		return
 	}
 	var matchingReports []model.PomodoroReport
@@ -86,10 +90,13 @@ func GetPomokitDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 		}
 	}
 	if len(matchingReports) == 0 {
-		at.WriteJSON(respw, http.StatusNotFound, model.PomodoroReport{
-			PhoneNumber: payload.Id,
-			Name:        payload.Alias,
-		})
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, model.Response{
+			Status: "Error : Laporan Pomokit tidak ditemukan",
+			Data: model.PomodoroReport{
+				PhoneNumber: payload.Id,
+				Name:        payload.Alias,
+			},
+		}))
 		return
 	}
 
@@ -108,11 +115,11 @@ func GetPomokitDataUserAPI(respw http.ResponseWriter, req *http.Request) {
 func GetPomokitDataAllUserAPI(respw http.ResponseWriter, req *http.Request) {
 	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusForbidden, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -122,43 +129,43 @@ func GetPomokitDataAllUserAPI(respw http.ResponseWriter, req *http.Request) {
 
 	err = config.Mongoconn.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, model.Response{
 			Status:   "Error: Config Not Found",
 			Location: "Database Config",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := atapi.NewClient(15 * time.Second)
 	resp, err := client.Get(conf.PomokitUrl)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: API Connection Failed",
 			Location: "Pomokit API",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		at.WriteJSON(respw, http.StatusBadGateway, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   fmt.Sprintf("Error: API Returned Status %d", resp.StatusCode),
 			Location: "Pomokit API",
 			Response: string(body),
-		})
+		}))
 		return
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 			Status:   "Error: Failed to Read Response",
 			Location: "Response Reading",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -169,11 +176,11 @@ func GetPomokitDataAllUserAPI(respw http.ResponseWriter, req *http.Request) {
 		var apiResponse model.PomokitResponse
 		err = json.Unmarshal(body, &apiResponse)
 		if err != nil {
-			at.WriteJSON(respw, http.StatusInternalServerError, model.Response{
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
 				Status:   "Error: Invalid API Response Format",
 				Location: "Response Decoding",
 				Response: fmt.Sprintf("Error: %v, Raw Response: %s", err, string(body)),
-			})
+			}))
 			return
 		}
 		pomodoroReports = apiResponse.Data
@@ -223,12 +230,12 @@ func GetPomokitReportTotalSemuaHari(respw http.ResponseWriter, req *http.Request
 					Messages: msg,
 				}
 
-				_, _, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+				_, _, err = waoutbox.Send(config.Mongoconn, dt)
 				if err != nil {
 					resp.Status = "Error"
 					resp.Location = "Laporan Pomokit"
 					resp.Response = "Berhasil membuat laporan, tetapi gagal mengirim pesan: " + err.Error()
-					at.WriteJSON(respw, http.StatusInternalServerError, resp)
+					at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 					return
 				}
 			}
@@ -245,7 +252,7 @@ func GetPomokitReportTotalSemuaHari(respw http.ResponseWriter, req *http.Request
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit"
 		resp.Response = "Gagal memproses laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -283,7 +290,7 @@ func SendPomokitReportKemarinPerGrup(respw http.ResponseWriter, req *http.Reques
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Kemarin"
 		resp.Response = "Parameter 'groupid' tidak boleh kosong"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -293,7 +300,7 @@ func SendPomokitReportKemarinPerGrup(respw http.ResponseWriter, req *http.Reques
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Kemarin"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -314,12 +321,12 @@ func SendPomokitReportKemarinPerGrup(respw http.ResponseWriter, req *http.Reques
 	}
 
 	// Kirim pesan ke API WhatsApp
-	_, sendResp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, sendResp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Kemarin"
 		resp.Response = "Gagal mengirim pesan: " + err.Error() + ", info: " + sendResp.Info
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 
@@ -340,7 +347,7 @@ func GetPomokitReportKemarinPerGrup(respw http.ResponseWriter, req *http.Request
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Kemarin Per Grup"
 		resp.Response = "Parameter 'groupid' tidak boleh kosong"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -350,7 +357,7 @@ func GetPomokitReportKemarinPerGrup(respw http.ResponseWriter, req *http.Request
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Kemarin Per Grup"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -408,7 +415,7 @@ func RefreshPomokitHarianReport(respw http.ResponseWriter, req *http.Request) {
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Harian"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 	case <-time.After(2 * time.Second):
 		// Timeout, but process continues in background
 		resp.Status = "Success"
@@ -430,7 +437,7 @@ func SendPomokitReportMingguanPerGrup(respw http.ResponseWriter, req *http.Reque
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Mingguan"
 		resp.Response = "Parameter 'groupid' atau 'phonenumber' harus diisi"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -440,7 +447,7 @@ func SendPomokitReportMingguanPerGrup(respw http.ResponseWriter, req *http.Reque
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Mingguan"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -470,12 +477,12 @@ func SendPomokitReportMingguanPerGrup(respw http.ResponseWriter, req *http.Reque
 	}
 
 	// Kirim pesan ke API WhatsApp
-	_, sendResp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, sendResp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Status = "Error"
 		resp.Location = "Kirim Laporan Pomokit Mingguan"
 		resp.Response = "Gagal mengirim pesan: " + err.Error() + ", info: " + sendResp.Info
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 
@@ -499,7 +506,7 @@ func GetPomokitReportMingguanPerGrup(respw http.ResponseWriter, req *http.Reques
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Mingguan Per Grup"
 		resp.Response = "Parameter 'groupid' atau 'phonenumber' harus diisi"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, resp))
 		return
 	}
 
@@ -509,7 +516,7 @@ func GetPomokitReportMingguanPerGrup(respw http.ResponseWriter, req *http.Reques
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Mingguan Per Grup"
 		resp.Response = "Gagal menghasilkan laporan: " + err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 		return
 	}
 
@@ -567,7 +574,7 @@ func RefreshPomokitMingguanReport(respw http.ResponseWriter, req *http.Request)
 		resp.Status = "Error"
 		resp.Location = "Laporan Pomokit Mingguan"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
 	case <-time.After(2 * time.Second):
 		// Timeout, tetapi proses tetap berjalan di background
 		resp.Status = "Success"
@@ -580,11 +587,15 @@ func RefreshPomokitMingguanReport(respw http.ResponseWriter, req *http.Request)
 // untuk activity_score.go
 
 // Fungsi untuk mendapatkan skor Pomokit semua waktu
-func GetPomokitScoreForUser(phoneNumber string) (model.ActivityScore, error) {
+func GetPomokitScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
 	var score model.ActivityScore
 
-	// Ambil semua data Pomokit
-	allPomokitData, err := report.GetAllPomokitDataAPI(config.Mongoconn)
+	// Ambil semua data Pomokit, sesi yang ditahan analisis anti-curang tidak dihitung
+	allPomokitData, err := report.GetAllPomokitDataAPIContext(ctx, config.Mongoconn)
+	if err != nil {
+		return score, err
+	}
+	allPomokitData, _, err = pomokitcheck.Saring(ctx, config.Mongoconn, allPomokitData)
 	if err != nil {
 		return score, err
 	}
@@ -606,23 +617,27 @@ func GetPomokitScoreForUser(phoneNumber string) (model.ActivityScore, error) {
 }
 
 // Fungsi untuk mendapatkan skor Pomokit seminggu terakhir
-func GetLastWeekPomokitScoreForUser(phoneNumber string) (model.ActivityScore, error) {
+func GetLastWeekPomokitScoreForUser(ctx context.Context, phoneNumber string) (model.ActivityScore, error) {
 	var score model.ActivityScore
 
-	// Ambil semua data Pomokit
-	allPomokitData, err := report.GetAllPomokitDataAPI(config.Mongoconn)
+	// Ambil semua data Pomokit, sesi yang ditahan analisis anti-curang tidak dihitung
+	allPomokitData, err := report.GetAllPomokitDataAPIContext(ctx, config.Mongoconn)
+	if err != nil {
+		return score, err
+	}
+	allPomokitData, _, err = pomokitcheck.Saring(ctx, config.Mongoconn, allPomokitData)
 	if err != nil {
 		return score, err
 	}
 
-	// Tentukan waktu seminggu yang lalu
-	weekAgo := time.Now().AddDate(0, 0, -7)
+	// Minggu berjalan sesuai periode akademik
+	weekStart, weekEnd := periode.Get(config.Mongoconn).MingguIni("")
 
-	// Filter dan hitung untuk user spesifik dalam seminggu terakhir
+	// Filter dan hitung untuk user spesifik dalam minggu berjalan
 	var sessionCount int
 
 	for _, report := range allPomokitData {
-		if report.PhoneNumber == phoneNumber && report.CreatedAt.After(weekAgo) {
+		if report.PhoneNumber == phoneNumber && !report.CreatedAt.Before(weekStart) && report.CreatedAt.Before(weekEnd) {
 			sessionCount++
 		}
 	}
@@ -644,21 +659,25 @@ func GetLastWeekPomokitScoreKelas(db *mongo.Database, phoneNumber string, usedID
 		usedMap[id] = true
 	}
 
-	// Ambil semua data Pomokit
+	// Ambil semua data Pomokit, sesi yang ditahan tidak masuk resultid supaya bisa dihitung setelah direview valid
 	allPomokitData, err := report.GetAllPomokitDataAPI(db)
 	if err != nil {
 		return nil, score, err
 	}
+	allPomokitData, _, err = pomokitcheck.Saring(context.Background(), db, allPomokitData)
+	if err != nil {
+		return nil, score, err
+	}
 
-	// Tentukan waktu seminggu yang lalu
-	weekAgo := time.Now().AddDate(0, 0, -7)
+	// Minggu berjalan sesuai cutoff kelasai di periode akademik
+	weekStart, weekEnd := periode.Get(db).MingguIni("kelasai")
 
-	// Filter dan hitung untuk user spesifik dalam seminggu terakhir
+	// Filter dan hitung untuk user spesifik dalam minggu berjalan
 	var sessionCount int
 
 	for _, report := range allPomokitData {
 		if report.PhoneNumber == phoneNumber &&
-			report.CreatedAt.After(weekAgo) &&
+			!report.CreatedAt.Before(weekStart) && report.CreatedAt.Before(weekEnd) &&
 			!usedMap[report.ID] {
 
 			resultid = append(resultid, report.ID)
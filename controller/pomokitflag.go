@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/pomokitcheck"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetPomokitFlag menampilkan sesi Pomokit yang ditandai analisis anti-curang, sesi terlama di atas.
+// Default hanya yang menunggu review, filter opsional ?status=, ?phonenumber= dan ?wagroupid=
+func GetPomokitFlag(respw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	filter := bson.M{"status": model.PomokitFlagDitahan}
+	if status := q.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if phonenumber := q.Get("phonenumber"); phonenumber != "" {
+		filter["phonenumber"] = phonenumber
+	}
+	if groupID := q.Get("wagroupid"); groupID != "" {
+		filter["wagroupid"] = groupID
+	}
+	flags, err := atdb.GetAllDoc[[]model.PomokitFlag](config.Mongoconn, pomokitcheck.Collection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil temuan Pomokit", err.Error()))
+		return
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].SessionAt.Before(flags[j].SessionAt) })
+	at.WriteJSON(respw, http.StatusOK, flags)
+}
+
+// PutPomokitFlag menyimpan keputusan dosen, sesi valid kembali dihitung di skor Pomokit
+func PutPomokitFlag(respw http.ResponseWriter, req *http.Request) {
+	var reviewReq model.PomokitFlagReviewRequest
+	if err := json.NewDecoder(req.Body).Decode(&reviewReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	flag, err := pomokitcheck.Review(config.Mongoconn, router.ParamObjectID(req, "id"), reviewReq, rbac.PhoneNumber(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menyimpan review sesi Pomokit"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, flag)
+}
+
+// RefreshPomokitFlag dipasang di cronjob harian sebelum laporan dikirim, menganalisis semua sesi Pomokit
+// dan menahan sesi mencurigakan sampai direview dosen
+func RefreshPomokitFlag(respw http.ResponseWriter, req *http.Request) {
+	reports, err := report.GetAllPomokitDataAPI(config.Mongoconn)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Wrap(apperr.Upstream, "Error : Gagal mengambil data Pomokit", err))
+		return
+	}
+	flags := pomokitcheck.Analisis(reports, pomokitcheck.MuatAturan(config.Mongoconn))
+	if err = pomokitcheck.Simpan(config.Mongoconn, flags, time.Now()); err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menyimpan temuan sesi Pomokit"))
+		return
+	}
+	bersih, tertahan, err := pomokitcheck.Saring(req.Context(), config.Mongoconn, reports)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal membaca temuan sesi Pomokit"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: strconv.Itoa(len(bersih)) + " sesi dihitung, " + strconv.Itoa(len(tertahan)) + " sesi ditahan",
+		Data:     tertahan,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/watoken"
@@ -37,10 +38,7 @@ func GetOnePreTestQuestion(w http.ResponseWriter, r *http.Request) {
 	err := config.Mongoconn.Collection("pretestquestion").FindOne(context.Background(), filter).Decode(&PreTestQuestion)
 	if err != nil {
 		log.Printf("Error querying Pre Test question with ID %s: %v", id, err)
-		at.WriteJSON(w, http.StatusNotFound, map[string]string{
-			"message": "Soal Pre Test tidak ditemukan.",
-			"error":   err.Error(),
-		})
+		at.WriteError(w, r, apperr.Wrap(apperr.NotFound, "Soal Pre Test tidak ditemukan.", err))
 		return
 	}
 
@@ -55,7 +53,7 @@ func GetPreTestScoring(w http.ResponseWriter, r *http.Request) {
 	cursor, err := collection.Find(context.Background(), bson.M{})
 	if err != nil {
 		log.Println("Gagal mengambil skor referensi:", err)
-		http.Error(w, "Gagal mengambil data", http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil data", ""))
 		return
 	}
 	defer cursor.Close(context.Background())
@@ -63,7 +61,7 @@ func GetPreTestScoring(w http.ResponseWriter, r *http.Request) {
 	var results model.PreTestScoring
 	if err = cursor.All(context.Background(), &results); err != nil {
 		log.Println("Gagal decode data:", err)
-		http.Error(w, "Gagal memproses data", http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal memproses data", ""))
 		return
 	}
 
@@ -74,23 +72,23 @@ func GetPreTestScoring(w http.ResponseWriter, r *http.Request) {
 func GetUserAndPreTestScore(w http.ResponseWriter, r *http.Request) {
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
 	phoneNumber := payload.Id
 	if phoneNumber == "" {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Missing Phonenumber",
 			Info:     "Nomor telepon tidak ditemukan dalam token",
 			Location: "Token Parsing",
 			Response: "Invalid Payload",
-		})
+		}))
 		return
 	}
 
@@ -98,12 +96,12 @@ func GetUserAndPreTestScore(w http.ResponseWriter, r *http.Request) {
 	var user model.Userdomyikado
 	err = userCollection.FindOne(context.TODO(), bson.M{"phonenumber": phoneNumber}).Decode(&user)
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, model.Response{
 			Status:   "Error: User Not Found",
 			Info:     "Tidak ada user dengan nomor telepon ini",
 			Location: "User Lookup",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -148,14 +146,14 @@ func PostPretestAnswer(w http.ResponseWriter, r *http.Request) {
 	// Validasi Token Login
 	token := at.GetLoginFromHeader(r)
 	if token == "" {
-		http.Error(w, `{"error": "Akses ditolak! Token login diperlukan."}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Akses ditolak! Token login diperlukan.", ""))
 		return
 	}
 
 	// Decode Token
 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, token)
 	if err != nil {
-		http.Error(w, `{"error": "Token tidak valid atau tidak dapat didecode"}`, http.StatusUnauthorized)
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Token tidak valid atau tidak dapat didecode", ""))
 		return
 	}
 
@@ -172,7 +170,7 @@ func PostPretestAnswer(w http.ResponseWriter, r *http.Request) {
 	var userAnswer model.PreTestAnswerPayload
 	err = json.NewDecoder(r.Body).Decode(&userAnswer)
 	if err != nil {
-		http.Error(w, `{"error": "Gagal membaca data"}`, http.StatusBadRequest)
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Gagal membaca data", ""))
 		return
 	}
 
@@ -184,14 +182,14 @@ func PostPretestAnswer(w http.ResponseWriter, r *http.Request) {
 	questionCollection := config.Mongoconn.Collection("pretestquestion")
 	cursor, err := questionCollection.Find(context.TODO(), bson.M{})
 	if err != nil {
-		http.Error(w, `{"error": "Gagal mengambil data soal dari database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal mengambil data soal dari database", ""))
 		return
 	}
 	defer cursor.Close(context.TODO())
 
 	var questions []model.PreTestQuestion
 	if err = cursor.All(context.TODO(), &questions); err != nil {
-		http.Error(w, `{"error": "Gagal membaca soal"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal membaca soal", ""))
 		return
 	}
 
@@ -232,7 +230,7 @@ func PostPretestAnswer(w http.ResponseWriter, r *http.Request) {
 		{Key: "created_at", Value: now},
 	})
 	if err != nil {
-		http.Error(w, `{"error": "Gagal menyimpan ke database"}`, http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.New(apperr.Database, "Gagal menyimpan ke database", ""))
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -22,7 +23,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var prj model.Project
@@ -31,7 +32,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
@@ -39,7 +40,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data user tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 	if prj.Enroll != "" {
@@ -48,7 +49,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Error : Data enroll tidak di temukan"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotImplemented, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 			return
 		}
 		prj.MasterEnrool = docenroll
@@ -64,7 +65,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Gagal Insert Database"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		prj.ID = idprj
@@ -73,7 +74,7 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Nama Project sudah ada"
 		respn.Response = existingprj.Name
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, respn))
 		return
 	}
 
@@ -89,14 +90,14 @@ func GetDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	if len(existingprjs) == 0 {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = "Kakak belum input proyek, silahkan input dulu ya"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
@@ -115,7 +116,7 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -125,7 +126,7 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Project tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -143,7 +144,7 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Gagal memperbarui database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -166,7 +167,7 @@ func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -176,7 +177,7 @@ func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = "Proyek dengan nama tersebut tidak ditemukan atau bukan milik Anda"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -186,7 +187,7 @@ func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Gagal menghapus project"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -205,14 +206,14 @@ func GetDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	if len(existingprjs) == 0 {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = "Kakak belum menjadi anggota proyek manapun"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
@@ -229,7 +230,7 @@ func PostDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -237,14 +238,14 @@ func PostDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data project tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	docusermember, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": idprjuser.PhoneNumber})
 	if err != nil {
 		respn.Status = "Error : Data member tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 	docusermember.Poin = 0 //set user poin per project, jika baru dimasukkan maka set0 karena belum ada kontribusi di project ini
@@ -252,13 +253,13 @@ func PostDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menambahkan member ke project"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	if rest.ModifiedCount == 0 {
 		respn.Status = "Error : Gagal menambahkan member ke project"
 		respn.Response = "Tidak ada perubahan pada dokumen proyek"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprj)
@@ -280,7 +281,7 @@ func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -288,7 +289,7 @@ func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data project tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -298,13 +299,13 @@ func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menghapus member dari project"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	if rest.ModifiedCount == 0 {
 		respn.Status = "Error : Gagal menghapus member dari project"
 		respn.Response = "Tidak ada perubahan pada dokumen proyek"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
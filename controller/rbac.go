@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetMyAccess menampilkan role dan permission pemilik token, dipakai frontend untuk menampilkan menu
+func GetMyAccess(respw http.ResponseWriter, req *http.Request) {
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	roles, err := rbac.Roles(config.Mongoconn, payload.Id)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal membaca role user", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, userAccess(payload.Id, roles))
+}
+
+// GetUserRoles menampilkan role yang tersimpan, ?phonenumber= untuk satu user (khusus owner)
+func GetUserRoles(respw http.ResponseWriter, req *http.Request) {
+	if phonenumber := req.URL.Query().Get("phonenumber"); phonenumber != "" {
+		roles, err := rbac.Roles(config.Mongoconn, phonenumber)
+		if err != nil {
+			at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal membaca role user", err.Error()))
+			return
+		}
+		at.WriteJSON(respw, http.StatusOK, userAccess(phonenumber, roles))
+		return
+	}
+	roles, err := atdb.GetAllDoc[[]model.UserRole](config.Mongoconn, rbac.Collection, bson.M{})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal membaca role user", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, roles)
+}
+
+// PostUserRole memberi role ke user, scope diisi kode enroll atau ID proyek untuk role terbatas (khusus owner)
+func PostUserRole(respw http.ResponseWriter, req *http.Request) {
+	var roleReq model.UserRoleRequest
+	if err := json.NewDecoder(req.Body).Decode(&roleReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	role, err := rbac.Grant(config.Mongoconn, model.UserRole{
+		PhoneNumber: ValidasiNoHP(roleReq.PhoneNumber),
+		Role:        roleReq.Role,
+		Scope:       roleReq.Scope,
+		GrantedBy:   rbac.PhoneNumber(req),
+	})
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal memberi role"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, role)
+}
+
+// DeleteUserRole mencabut satu role tersimpan (khusus owner)
+func DeleteUserRole(respw http.ResponseWriter, req *http.Request) {
+	id := router.ParamObjectID(req, "id")
+	role, err := rbac.Revoke(config.Mongoconn, id)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal mencabut role"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: "Role " + role.Role + " milik " + role.PhoneNumber + " dicabut",
+	})
+}
+
+func userAccess(phonenumber string, roles []model.UserRole) model.UserAccess {
+	access := model.UserAccess{PhoneNumber: phonenumber, Roles: roles, Permissions: []string{}}
+	for _, p := range rbac.Permissions(roles, "") {
+		access.Permissions = append(access.Permissions, string(p))
+	}
+	for scope, perms := range rbac.ScopedPermissions(roles) {
+		if access.PerScope == nil {
+			access.PerScope = make(map[string][]string)
+		}
+		for _, p := range perms {
+			access.PerScope[scope] = append(access.PerScope[scope], string(p))
+		}
+	}
+	return access
+}
@@ -4,10 +4,11 @@ import (
 	"net/http"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -20,7 +21,7 @@ func GetYesterdayDistincWAGroup(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		resp.Info = "Gagal Query Distincs project.wagroupid"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	for _, wagroupid := range wagroupidlist {
@@ -29,7 +30,7 @@ func GetYesterdayDistincWAGroup(respw http.ResponseWriter, req *http.Request) {
 		if !ok {
 			resp.Info = "wagroupid is not a string"
 			resp.Response = "wagroupid is not a string"
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 			return
 		}
 		//kirim report ke group
@@ -38,11 +39,11 @@ func GetYesterdayDistincWAGroup(respw http.ResponseWriter, req *http.Request) {
 			IsGroup:  true,
 			Messages: report.GetDataRepoMasukHariIni(config.Mongoconn, groupID) + "\n" + report.GetDataLaporanMasukHariini(config.Mongoconn, groupID),
 		}
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 		if err != nil {
 			resp.Info = "Tidak berhak"
 			resp.Response = err.Error()
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 			return
 		}
 	}
@@ -57,11 +58,11 @@ func GetReportHariIni(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  true,
 		Messages: report.GetDataRepoMasukHarian(config.Mongoconn) + "\n" + report.GetDataLaporanMasukHarian(config.Mongoconn),
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, resp)
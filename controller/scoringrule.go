@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/scoring"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetScoringRuleVersions mengembalikan semua versi aturan skor sebuah enroll, terbaru di atas (khusus owner)
+func GetScoringRuleVersions(respw http.ResponseWriter, req *http.Request) {
+	rulesets, err := atdb.GetAllDoc[[]model.ScoringRuleSet](config.Mongoconn, scoring.Collection, bson.M{"enroll": at.GetParam(req)})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil aturan skor", err.Error()))
+		return
+	}
+	for i, j := 0, len(rulesets)-1; i < j; i, j = i+1, j-1 {
+		rulesets[i], rulesets[j] = rulesets[j], rulesets[i]
+	}
+	at.WriteJSON(respw, http.StatusOK, rulesets)
+}
+
+// GetActiveScoringRule mengembalikan aturan skor yang sedang dipakai sebuah enroll, aturan bawaan jika belum ada
+func GetActiveScoringRule(respw http.ResponseWriter, req *http.Request) {
+	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	enroll := at.GetParam(req)
+	ruleset, err := scoring.GetActiveRuleSet(config.Mongoconn, enroll)
+	if err != nil {
+		ruleset = model.ScoringRuleSet{
+			Enroll:  enroll,
+			Active:  true,
+			Rules:   scoring.DefaultRules(),
+			Catatan: "Aturan bawaan",
+		}
+	}
+	at.WriteJSON(respw, http.StatusOK, ruleset)
+}
+
+// PostScoringRule menyimpan aturan skor sebagai versi baru dan langsung mengaktifkannya (khusus owner)
+func PostScoringRule(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	var ruleset model.ScoringRuleSet
+	if err := json.NewDecoder(req.Body).Decode(&ruleset); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	if _, err := atdb.GetOneDoc[model.MasterEnrool](config.Mongoconn, "enroll", bson.M{"kode": ruleset.Enroll}); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.ProjectNotFound, "Error : Enroll tidak ditemukan", ruleset.Enroll))
+		return
+	}
+	ruleset.CreatedBy = phonenumber
+	ruleset, err := scoring.CreateVersion(config.Mongoconn, ruleset)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.BadRequest, "Error : Aturan skor tidak valid", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, ruleset)
+}
+
+// ActivateScoringRule mengaktifkan kembali versi lama, dipakai untuk rollback (khusus owner)
+func ActivateScoringRule(respw http.ResponseWriter, req *http.Request) {
+	ruleset, ok := getScoringRuleByParam(respw, req)
+	if !ok {
+		return
+	}
+	if err := scoring.Activate(config.Mongoconn, ruleset.Enroll, ruleset.Version); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengaktifkan aturan skor", err.Error()))
+		return
+	}
+	ruleset.Active = true
+	at.WriteJSON(respw, http.StatusOK, ruleset)
+}
+
+// DeleteScoringRule menghapus versi yang tidak aktif, versi aktif harus diganti dulu (khusus owner)
+func DeleteScoringRule(respw http.ResponseWriter, req *http.Request) {
+	ruleset, ok := getScoringRuleByParam(respw, req)
+	if !ok {
+		return
+	}
+	if ruleset.Active {
+		at.WriteError(respw, req, apperr.New(apperr.PreconditionFailed, "Error : Aturan skor sedang aktif", "Aktifkan versi lain sebelum menghapus versi ini"))
+		return
+	}
+	if _, err := atdb.DeleteOneDoc(config.Mongoconn, scoring.Collection, bson.M{"_id": ruleset.ID}); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal menghapus aturan skor", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: "Aturan skor versi terhapus",
+	})
+}
+
+func getScoringRuleByParam(respw http.ResponseWriter, req *http.Request) (ruleset model.ScoringRuleSet, ok bool) {
+	id := router.ParamObjectID(req, "id")
+	ruleset, err := atdb.GetOneDoc[model.ScoringRuleSet](config.Mongoconn, scoring.Collection, bson.M{"_id": id})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Aturan skor tidak ditemukan", err.Error()))
+		return
+	}
+	return ruleset, true
+}
+
+// EnrollScoringRule adalah scope route aturan skor dengan :id, yaitu kode enroll versi tersebut
+func EnrollScoringRule(req *http.Request) string {
+	ruleset, err := atdb.GetOneDoc[model.ScoringRuleSet](config.Mongoconn, scoring.Collection, bson.M{"_id": router.ParamObjectID(req, "id")})
+	if err != nil {
+		return ""
+	}
+	return ruleset.Enroll
+}
+
+// EnrollScoringRuleBody adalah scope PostScoringRule, kode enroll dibaca dari body lalu body dipasang lagi untuk handler
+func EnrollScoringRuleBody(req *http.Request) string {
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var ruleset model.ScoringRuleSet
+	if json.Unmarshal(body, &ruleset) != nil {
+		return ""
+	}
+	return ruleset.Enroll
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/sidang"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetSidangSlot menampilkan slot ketersediaan dosen yang belum lewat, filter opsional ?phonenumber=
+func GetSidangSlot(respw http.ResponseWriter, req *http.Request) {
+	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	filter := bson.M{"selesai": bson.M{"$gt": time.Now()}}
+	if phonenumber := req.URL.Query().Get("phonenumber"); phonenumber != "" {
+		filter["phonenumber"] = phonenumber
+	}
+	slots, err := atdb.GetAllDoc[[]model.SidangSlot](config.Mongoconn, sidang.SlotCollection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil slot sidang", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, slots)
+}
+
+// PostSidangSlot membuka slot ketersediaan menguji untuk dosen pemilik token
+func PostSidangSlot(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	var slotReq model.SidangSlotRequest
+	if err := json.NewDecoder(req.Body).Decode(&slotReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	dosen, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.UserNotFound, "Error : Data user tidak di temukan", err.Error()))
+		return
+	}
+	slot, err := sidang.TambahSlot(config.Mongoconn, dosen, slotReq)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menyimpan slot sidang"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, slot)
+}
+
+// DeleteSidangSlot menghapus slot milik sendiri, admin sidang boleh menghapus slot dosen lain.
+// Slot yang masih dipakai jadwal sidang aktif tidak bisa dihapus.
+func DeleteSidangSlot(respw http.ResponseWriter, req *http.Request) {
+	auth, _ := rbac.FromRequest(req)
+	id := router.ParamObjectID(req, "id")
+	slot, err := atdb.GetOneDoc[model.SidangSlot](config.Mongoconn, sidang.SlotCollection, bson.M{"_id": id})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Slot sidang tidak ditemukan", err.Error()))
+		return
+	}
+	if slot.PhoneNumber != auth.Payload.Id && !rbac.Has(auth.Roles, rbac.JadwalSidang, "") {
+		at.WriteError(respw, req, apperr.New(apperr.Forbidden, "Error : Akses Ditolak", "slot milik "+slot.Name))
+		return
+	}
+	if err = sidang.HapusSlot(config.Mongoconn, slot); err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menghapus slot sidang"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: "Slot " + sidang.Rentang{Mulai: slot.Mulai, Selesai: slot.Selesai}.Format() + " terhapus",
+	})
+}
+
+// GetJadwalSidang menampilkan jadwal sidang. Admin sidang melihat semua jadwal,
+// user lain hanya jadwal di mana ia menjadi mahasiswa, pembimbing atau penguji.
+func GetJadwalSidang(respw http.ResponseWriter, req *http.Request) {
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	filter := bson.M{"peserta": payload.Id}
+	if rbac.Can(config.Mongoconn, payload.Id, rbac.JadwalSidang, "") {
+		filter = bson.M{}
+	}
+	if status := req.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+	jadwal, err := atdb.GetAllDoc[[]model.JadwalSidang](config.Mongoconn, sidang.JadwalCollection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil jadwal sidang", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, jadwal)
+}
+
+// PostJadwalSidang menjadwalkan sidang dengan ruang dan waktu yang dipilih admin
+func PostJadwalSidang(respw http.ResponseWriter, req *http.Request) {
+	var jadwalReq model.JadwalSidangRequest
+	if err := json.NewDecoder(req.Body).Decode(&jadwalReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	j, ok := susunJadwal(respw, req, jadwalReq.PengajuanID, jadwalReq.PengujiPhone)
+	if !ok {
+		return
+	}
+	j.Ruang, j.Mulai, j.Selesai = jadwalReq.Ruang, jadwalReq.Mulai, jadwalReq.Selesai
+	if err := sidang.Periksa(config.Mongoconn, j); err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Jadwal sidang tidak bisa dipakai"))
+		return
+	}
+	simpanJadwal(respw, req, j)
+}
+
+// PostJadwalSidangOtomatis mencari waktu dan ruang pertama yang cocok untuk semua dosen lalu menjadwalkannya
+func PostJadwalSidangOtomatis(respw http.ResponseWriter, req *http.Request) {
+	var otomatisReq model.JadwalOtomatisRequest
+	if err := json.NewDecoder(req.Body).Decode(&otomatisReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	j, ok := susunJadwal(respw, req, otomatisReq.PengajuanID, otomatisReq.PengujiPhone)
+	if !ok {
+		return
+	}
+	batas := sidang.Rentang{Mulai: otomatisReq.Dari, Selesai: otomatisReq.Sampai}
+	j, err := sidang.Cari(config.Mongoconn, j, otomatisReq.Ruang, time.Duration(otomatisReq.Durasi)*time.Minute, batas)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Jadwal sidang tidak ditemukan"))
+		return
+	}
+	simpanJadwal(respw, req, j)
+}
+
+// PutJadwalSidang memindah ruang atau waktu jadwal sidang yang masih aktif
+func PutJadwalSidang(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	lama, ok := getJadwalAktif(respw, req)
+	if !ok {
+		return
+	}
+	var jadwalReq model.JadwalSidangRequest
+	if err := json.NewDecoder(req.Body).Decode(&jadwalReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	if jadwalReq.Ruang == "" {
+		jadwalReq.Ruang = lama.Ruang
+	}
+	j, err := sidang.Pindah(config.Mongoconn, lama, sidang.Rentang{Mulai: jadwalReq.Mulai, Selesai: jadwalReq.Selesai}, jadwalReq.Ruang, jadwalReq.Alasan, phonenumber)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Jadwal sidang tidak bisa dipindah"))
+		return
+	}
+	if err = sidang.Kalender(config.Mongoconn, &j); err != nil {
+		log.Printf("Error update kalender sidang %s: %v", j.ID.Hex(), err)
+	}
+	keterangan := "Jadwal sebelumnya: " + sidang.Rentang{Mulai: lama.Mulai, Selesai: lama.Selesai}.Format() + " di " + lama.Ruang
+	if jadwalReq.Alasan != "" {
+		keterangan += "\nAlasan: " + jadwalReq.Alasan
+	}
+	sidang.Notifikasi(config.Mongoconn, j, "Jadwal Sidang Dipindah", keterangan)
+	at.WriteJSON(respw, http.StatusOK, j)
+}
+
+// BatalJadwalSidang membatalkan jadwal sidang, pengajuan kembali pending dan bisa dijadwalkan ulang
+func BatalJadwalSidang(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := rbac.PhoneNumber(req)
+	j, ok := getJadwalAktif(respw, req)
+	if !ok {
+		return
+	}
+	var batalReq model.BatalSidangRequest
+	if err := json.NewDecoder(req.Body).Decode(&batalReq); err != nil || batalReq.Alasan == "" {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", "alasan pembatalan wajib diisi"))
+		return
+	}
+	j, err := sidang.Batal(config.Mongoconn, j, batalReq.Alasan, phonenumber)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal membatalkan jadwal sidang"))
+		return
+	}
+	if err = sidang.HapusKalender(config.Mongoconn, j); err != nil {
+		log.Printf("Error hapus kalender sidang %s: %v", j.ID.Hex(), err)
+	}
+	sidang.Notifikasi(config.Mongoconn, j, "Jadwal Sidang Dibatalkan", "Alasan: "+batalReq.Alasan)
+	at.WriteJSON(respw, http.StatusOK, j)
+}
+
+func susunJadwal(respw http.ResponseWriter, req *http.Request, pengajuanID primitive.ObjectID, pengujiPhone []string) (j model.JadwalSidang, ok bool) {
+	pengajuan, err := atdb.GetOneDoc[model.BimbinganPengajuan](config.Mongoconn, sidang.PengajuanCollection, bson.M{"_id": pengajuanID})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Pengajuan sidang tidak ditemukan", err.Error()))
+		return
+	}
+	for i := range pengujiPhone {
+		pengujiPhone[i] = ValidasiNoHP(pengujiPhone[i])
+	}
+	j, err = sidang.Susun(config.Mongoconn, pengajuan, pengujiPhone)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.BadRequest, "Error : Data penguji tidak valid"))
+		return
+	}
+	j.CreatedBy = rbac.PhoneNumber(req)
+	return j, true
+}
+
+// simpanJadwal menyimpan jadwal, membuat event kalender lalu mengirim WA ke semua peserta.
+// Jadwal tetap tersimpan jika kalender gagal, keterangannya dikirim di Info respon.
+func simpanJadwal(respw http.ResponseWriter, req *http.Request, j model.JadwalSidang) {
+	j, err := sidang.Simpan(config.Mongoconn, j)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menyimpan jadwal sidang"))
+		return
+	}
+	respn := model.Response{Status: "Success", Response: "Sidang " + j.Name + " dijadwalkan " + sidang.Rentang{Mulai: j.Mulai, Selesai: j.Selesai}.Format() + " di " + j.Ruang}
+	if err = sidang.Kalender(config.Mongoconn, &j); err != nil {
+		log.Printf("Error membuat kalender sidang %s: %v", j.ID.Hex(), err)
+		respn.Info = "Event Google Calendar gagal dibuat: " + err.Error()
+	}
+	sidang.Notifikasi(config.Mongoconn, j, "Jadwal Sidang", "")
+	respn.Data = j
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+func getJadwalAktif(respw http.ResponseWriter, req *http.Request) (j model.JadwalSidang, ok bool) {
+	j, err := sidang.GetJadwal(config.Mongoconn, router.ParamObjectID(req, "id"))
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.NotFound, "Error : Jadwal sidang tidak ditemukan"))
+		return
+	}
+	if j.Status != model.SidangTerjadwal {
+		at.WriteError(respw, req, apperr.New(apperr.Conflict, "Error : Jadwal sidang sudah dibatalkan", j.Alasan))
+		return
+	}
+	return j, true
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/watoken"
@@ -30,14 +31,14 @@ func CountCommits(w http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Data project tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	if len(existingprjs) == 0 {
 		var respn model.Response
 		respn.Status = "Error: Data project tidak ditemukan"
 		respn.Response = "Kakak belum input proyek, silahkan input dulu ya"
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -49,7 +50,7 @@ func CountCommits(w http.ResponseWriter, r *http.Request) {
 			var respn model.Response
 			respn.Status = "Error: Data project tidak ditemukan"
 			respn.Response = err.Error()
-			at.WriteJSON(w, http.StatusNotFound, respn)
+			at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 			return
 		}
 		if commitCount == 0 {
@@ -72,7 +73,7 @@ func CountCommits(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(finalResp); err != nil {
-		http.Error(w, "Gagal mengirim data dalam format JSON: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal mengirim data dalam format JSON", err))
 	}
 }
 
@@ -93,14 +94,14 @@ func CountFeedback(w http.ResponseWriter, r *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Data project tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	if len(existingprjs) == 0 {
 		var respn model.Response
 		respn.Status = "Error: Data project tidak ditemukan"
 		respn.Response = "Kakak belum input proyek, silahkan input dulu ya"
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -113,7 +114,7 @@ func CountFeedback(w http.ResponseWriter, r *http.Request) {
 			var respn model.Response
 			respn.Status = "Error: Data project tidak ditemukan"
 			respn.Response = err.Error()
-			at.WriteJSON(w, http.StatusNotFound, respn)
+			at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 			return
 		}
 		if commitCount == 0 {
@@ -136,6 +137,6 @@ func CountFeedback(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(finalResp); err != nil {
-		http.Error(w, "Gagal mengirim data dalam format JSON: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal mengirim data dalam format JSON", err))
 	}
 }
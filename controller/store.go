@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/store"
+	"github.com/gocroot/helper/waoutbox"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/helper/whatsauth"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetStoreItem menampilkan katalog toko poin. Item bawaan dibuat dulu supaya selalu tampil,
+// pengelola event melihat juga item yang tidak aktif.
+func GetStoreItem(respw http.ResponseWriter, req *http.Request) {
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	if _, err = store.ItemBawaan(config.Mongoconn, model.StoreKodeBimbingan); err != nil {
+		log.Printf("Error menyiapkan item bawaan toko: %v", err)
+	}
+	filter := bson.M{"isactive": true}
+	if rbac.Can(config.Mongoconn, payload.Id, rbac.KelolaEvent, "") {
+		filter = bson.M{}
+	}
+	items, err := atdb.GetAllDoc[[]model.StoreItem](config.Mongoconn, store.ItemCollection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil katalog toko", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, items)
+}
+
+// PostStoreItem menambah item katalog
+func PostStoreItem(respw http.ResponseWriter, req *http.Request) {
+	simpanStoreItem(respw, req, primitive.NilObjectID)
+}
+
+// PutStoreItem mengubah item katalog, termasuk menambah stok atau menonaktifkan item
+func PutStoreItem(respw http.ResponseWriter, req *http.Request) {
+	simpanStoreItem(respw, req, router.ParamObjectID(req, "id"))
+}
+
+func simpanStoreItem(respw http.ResponseWriter, req *http.Request, id primitive.ObjectID) {
+	var itemReq model.StoreItemRequest
+	if err := json.NewDecoder(req.Body).Decode(&itemReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	item, err := store.SimpanItem(config.Mongoconn, id, itemReq, rbac.PhoneNumber(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal menyimpan item toko"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, item)
+}
+
+// PostStoreBeli membeli satu item katalog dengan pointevent
+func PostStoreBeli(respw http.ResponseWriter, req *http.Request) {
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	var beliReq model.StoreBeliRequest
+	if err = json.NewDecoder(req.Body).Decode(&beliReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	user, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": payload.Id})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.UserNotFound, "Error : Data user tidak di temukan", err.Error()))
+		return
+	}
+	item, err := atdb.GetOneDoc[model.StoreItem](config.Mongoconn, store.ItemCollection, bson.M{"_id": beliReq.ItemID})
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Item tidak ditemukan", err.Error()))
+		return
+	}
+	pembelian, sisa, err := store.Beli(config.Mongoconn, user, item, beliReq)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal membeli "+item.Nama))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: fmt.Sprintf("%s berhasil dibeli, poin dikurangi %d, sisa %d poin", item.Nama, item.Harga, sisa),
+		Data: map[string]interface{}{
+			"pembelian":        pembelian,
+			"remaining_points": sisa,
+		},
+	})
+}
+
+// GetStorePembelian menampilkan riwayat pembelian pemilik token.
+// Pengelola event melihat semua pembelian dengan filter opsional ?phonenumber= dan ?status=
+func GetStorePembelian(respw http.ResponseWriter, req *http.Request) {
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+		return
+	}
+	filter := bson.M{"phonenumber": payload.Id}
+	if rbac.Can(config.Mongoconn, payload.Id, rbac.KelolaEvent, "") {
+		filter = bson.M{}
+		if phonenumber := req.URL.Query().Get("phonenumber"); phonenumber != "" {
+			filter["phonenumber"] = phonenumber
+		}
+	}
+	if status := req.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+	pembelian, err := atdb.GetAllDoc[[]model.StorePembelian](config.Mongoconn, store.PembelianCollection, filter)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil riwayat pembelian", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, pembelian)
+}
+
+// PutStoreRefund mengembalikan poin pembelian dan memberi tahu pembeli lewat WA
+func PutStoreRefund(respw http.ResponseWriter, req *http.Request) {
+	var refundReq model.StoreRefundRequest
+	if err := json.NewDecoder(req.Body).Decode(&refundReq); err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+		return
+	}
+	pembelian, sisa, err := store.Refund(config.Mongoconn, router.ParamObjectID(req, "id"), refundReq.Alasan, rbac.PhoneNumber(req))
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal refund pembelian"))
+		return
+	}
+	message := fmt.Sprintf("↩️ *Refund Pembelian*\n\n🛒 Item: %s\n💰 Poin dikembalikan: %d\n📊 Sisa poin: %d\n💬 Alasan: %s",
+		pembelian.ItemNama, pembelian.Harga, sisa, pembelian.RefundAlasan)
+	_, resp, err := waoutbox.Send(config.Mongoconn, &whatsauth.TextMessage{To: pembelian.PhoneNumber, Messages: message})
+	if err != nil {
+		log.Printf("Failed to send WhatsApp refund to %s: %v, info: %s", pembelian.PhoneNumber, err, resp.Info)
+	}
+	at.WriteJSON(respw, http.StatusOK, pembelian)
+}
+
+// PutStoreSelesai menandai pembelian yang dipenuhi manual sudah selesai, misal sertifikat sudah dikirim
+func PutStoreSelesai(respw http.ResponseWriter, req *http.Request) {
+	var selesaiReq model.StoreSelesaiRequest
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(&selesaiReq); err != nil {
+			at.WriteError(respw, req, apperr.New(apperr.InvalidBody, "Error : Body tidak valid", err.Error()))
+			return
+		}
+	}
+	pembelian, err := store.Selesai(config.Mongoconn, router.ParamObjectID(req, "id"), selesaiReq.Hasil)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Ensure(err, apperr.Database, "Error : Gagal memperbarui pembelian"))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, pembelian)
+}
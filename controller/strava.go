@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
@@ -34,13 +35,13 @@ func ProcessStravaPoints(respw http.ResponseWriter, req *http.Request) {
 	db := config.Mongoconn
 	conf, err := atdb.GetOneDoc[model.Config](db, "config", bson.M{"phonenumber": "62895601060000"})
 	if err != nil {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{Response: "Failed to fetch config"})
+		at.WriteError(respw, req, apperr.New(apperr.Database, "", "Failed to fetch config"))
 		return
 	}
 
 	scode, activities, err := atapi.Get[[]model.StravaActivity](conf.StravaUrl2)
 	if err != nil || scode != http.StatusOK {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{Response: "Failed to fetch data"})
+		at.WriteError(respw, req, apperr.New(apperr.Upstream, "", "Failed to fetch data"))
 		return
 	}
 
@@ -123,12 +124,12 @@ func AddStravaPoints(respw http.ResponseWriter, req *http.Request) {
 	prof, err := whatsauth.GetAppProfile(at.GetParam(req), config.Mongoconn)
 	if err != nil {
 		resp.Response = "1. " + err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, resp))
 		return
 	}
 	if at.GetSecretFromHeader(req) != prof.Secret {
 		resp.Response = "Salah secret: " + at.GetSecretFromHeader(req)
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidCredentials, resp))
 		return
 	}
 
@@ -136,7 +137,7 @@ func AddStravaPoints(respw http.ResponseWriter, req *http.Request) {
 	err = json.NewDecoder(req.Body).Decode(&reqBody)
 	if err != nil {
 		resp.Response = "Invalid request"
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
 		return
 	}
 
@@ -151,7 +152,7 @@ func AddStravaPoints(respw http.ResponseWriter, req *http.Request) {
 	}
 	err = colUsers.FindOne(context.TODO(), bson.M{"phonenumber": reqBody.PhoneNumber}).Decode(&user)
 	if err != nil && err != mongo.ErrNoDocuments {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{Response: "Failed to process request"})
+		at.WriteError(respw, req, apperr.New(apperr.Database, "", "Failed to process request"))
 		return
 	}
 
@@ -167,7 +168,7 @@ func AddStravaPoints(respw http.ResponseWriter, req *http.Request) {
 	}
 	err = colPoin.FindOne(context.TODO(), filter).Decode(&existingData)
 	if err != nil && err != mongo.ErrNoDocuments {
-		at.WriteJSON(respw, http.StatusInternalServerError, model.Response{Response: "Failed to retrieve data"})
+		at.WriteError(respw, req, apperr.New(apperr.Database, "", "Failed to retrieve data"))
 		return
 	}
 
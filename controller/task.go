@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
@@ -24,7 +25,7 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	//check eksistensi user
@@ -32,7 +33,11 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(w, http.StatusNotFound, docuser)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	var task report.TaskList
@@ -42,12 +47,12 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Body Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	taskuser, err := atdb.GetOneDoc[report.TaskList](config.Mongoconn, "tasklist", bson.M{"_id": task.ID})
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, taskuser)
+		at.WriteError(w, r, apperr.Wrap(apperr.NotFound, "Error : Data task tidak ditemukan", err))
 		return
 	}
 	insertid, err := atdb.InsertOneDoc(config.Mongoconn, "taskdoing", taskuser)
@@ -56,7 +61,7 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = insertid.Hex()
 		respn.Location = "InsertOneDoc"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	rest, err := atdb.DeleteOneDoc(config.Mongoconn, "tasklist", bson.M{"_id": task.ID})
@@ -65,7 +70,7 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
 		respn.Location = "DeleteOneDoc"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
@@ -87,12 +92,12 @@ func PostTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Body Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	taskuser, err := atdb.GetOneDoc[report.TaskList](config.Mongoconn, "taskdoing", bson.M{"_id": task.ID})
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, taskuser)
+		at.WriteError(w, r, apperr.Wrap(apperr.NotFound, "Error : Data task tidak ditemukan", err))
 		return
 	}
 	insertid, err := atdb.InsertOneDoc(config.Mongoconn, "taskdone", taskuser)
@@ -101,7 +106,7 @@ func PostTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = insertid.Hex()
 		respn.Location = "InsertOneDoc"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	rest, err := atdb.DeleteOneDoc(config.Mongoconn, "taskdoing", bson.M{"_id": task.ID})
@@ -110,7 +115,7 @@ func PostTaskUser(w http.ResponseWriter, r *http.Request) {
 		respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
 		respn.Location = "DeleteOneDoc"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
@@ -126,7 +131,7 @@ func GetTaskUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	//check eksistensi user
@@ -134,7 +139,11 @@ func GetTaskUser(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -146,7 +155,7 @@ func GetTaskUser(respw http.ResponseWriter, req *http.Request) {
 	}
 	taskuser, err := atdb.GetAllDoc[[]report.TaskList](config.Mongoconn, "tasklist", filter)
 	if err != nil || len(taskuser) == 0 {
-		at.WriteJSON(respw, http.StatusNotFound, taskuser)
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Data task tidak ditemukan", ""))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, taskuser)
@@ -160,7 +169,7 @@ func GetTaskDoing(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	//check eksistensi user
@@ -168,13 +177,17 @@ func GetTaskDoing(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	docuser.Name = payload.Alias
 	taskdoing, err := atdb.GetOneLatestDoc[report.TaskList](config.Mongoconn, "taskdoing", bson.M{"phonenumber": docuser.PhoneNumber})
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, taskdoing)
+		at.WriteError(respw, req, apperr.Wrap(apperr.NotFound, "Error : Data task tidak ditemukan", err))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, taskdoing)
@@ -188,7 +201,7 @@ func GetTaskDone(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	//check eksistensi user
@@ -196,13 +209,17 @@ func GetTaskDone(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	docuser.Name = payload.Alias
 	taskdoing, err := atdb.GetOneLatestDoc[report.TaskList](config.Mongoconn, "taskdone", bson.M{"phonenumber": docuser.PhoneNumber})
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, taskdoing)
+		at.WriteError(respw, req, apperr.Wrap(apperr.NotFound, "Error : Data task tidak ditemukan", err))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, taskdoing)
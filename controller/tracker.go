@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
@@ -63,29 +64,21 @@ func FactCheck1(w http.ResponseWriter, r *http.Request, userinfo model.UserInfo)
 		"HeadlessChrome",
 	}
 	if origin == "" && referer == "" {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
-			Response: "Akses tidak diizinkan",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Forbidden, "", "Akses tidak diizinkan"))
 		return false
 	}
 	if userAgent == "" {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
-			Response: "Akses tidak diizinkan",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Forbidden, "", "Akses tidak diizinkan"))
 		return false
 	}
 	for _, botUA := range botUserAgents {
 		if strings.Contains(userAgent, botUA) {
-			at.WriteJSON(w, http.StatusForbidden, model.Response{
-				Response: "Akses tidak diizinkan",
-			})
+			at.WriteError(w, r, apperr.New(apperr.Forbidden, "", "Akses tidak diizinkan"))
 			return false
 		}
 	}
 	if userinfo.Hostname == "" || userinfo.Url == "" || userinfo.Browser == "" || userinfo.Browser_Language == "" || userinfo.Screen_Resolution == "" || userinfo.Timezone == "" || userinfo.ISP.IP == "" {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
-			Response: "Akses tidak diizinkan",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Forbidden, "", "Akses tidak diizinkan"))
 		return false
 	}
 
@@ -114,9 +107,7 @@ func FactCheck1(w http.ResponseWriter, r *http.Request, userinfo model.UserInfo)
 	}
 
 	if _, ok := allowedASNs[userinfo.ISP.Asn]; ok {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
-			Response: "Akses tidak diizinkan",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Forbidden, "", "Akses tidak diizinkan"))
 		return false
 	}
 	return true
@@ -126,27 +117,19 @@ func FactCheck2(w http.ResponseWriter, r *http.Request, userinfo model.UserInfo)
 	headerToken := r.Header.Get("Tracker")
 	payload, err := watoken.DecodeWithStruct[model.UserInfo](config.PublicKeyWhatsAuth, headerToken)
 	if err != nil {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
-			Response: "Token tidak valid: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "", "Token tidak valid: "+err.Error()))
 		return false
 	}
 	if payload.Data.Hostname != userinfo.Hostname {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
-			Response: "Data tidak cocok",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "", "Data tidak cocok"))
 		return false
 	}
 	if payload.Data.Url != userinfo.Url {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
-			Response: "Data tidak cocok",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "", "Data tidak cocok"))
 		return false
 	}
 	if payload.Data.Browser != userinfo.Browser {
-		at.WriteJSON(w, http.StatusUnauthorized, model.Response{
-			Response: "Data tidak cocok",
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidCredentials, "", "Data tidak cocok"))
 		return false
 	}
 	return true
@@ -166,9 +149,7 @@ func GenerateTrackerToken(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
-			Response: "Error parsing application/json: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "", "Error parsing application/json: "+err.Error()))
 		return
 	}
 
@@ -187,17 +168,13 @@ func GenerateTrackerToken(w http.ResponseWriter, r *http.Request) {
 	}
 	exist, err := atdb.GetOneDoc[model.UserInfo](config.Mongoconn, "trackerip", filter)
 	if err == nil && exist.Browser != "" {
-		at.WriteJSON(w, http.StatusConflict, model.Response{
-			Response: "Hari ini sudah absen",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "", "Hari ini sudah absen"))
 		return
 	}
 	userinfo.Tanggal_Ambil = waktusekarang
 	token, err := watoken.EncodeWithStructDuration("", &userinfo, config.PrivateKey, duration)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
-			Response: "Error: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Internal, "", "Error: "+err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, model.Response{
@@ -213,9 +190,7 @@ func SimpanInformasiUser(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
-			Response: "Error parsing application/json: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "", "Error parsing application/json: "+err.Error()))
 		return
 	}
 
@@ -237,17 +212,13 @@ func SimpanInformasiUser(w http.ResponseWriter, r *http.Request) {
 	}
 	exist, err := atdb.GetOneDoc[model.UserInfo](config.Mongoconn, "trackerip", filter)
 	if err == nil && exist.Browser != "" {
-		at.WriteJSON(w, http.StatusConflict, model.Response{
-			Response: "Hari ini sudah absen",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "", "Hari ini sudah absen"))
 		return
 	}
 	userinfo.Tanggal_Ambil = waktusekarang
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "trackerip", userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
-			Response: "Gagal Insert Database: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "", "Gagal Insert Database: "+err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, model.Response{
@@ -301,20 +272,18 @@ func AmbilDataStatistik(w http.ResponseWriter, r *http.Request) {
 	case "all_time":
 		startDate = time.Time{}
 	default:
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
-			Response: "Request tidak valid",
-		})
+		at.WriteError(w, r, apperr.New(apperr.BadRequest, "", "Request tidak valid"))
 		return
 	}
 
 	authorization, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -322,9 +291,7 @@ func AmbilDataStatistik(w http.ResponseWriter, r *http.Request) {
 
 	datatracker, err := report.GetStatistikTracker(config.Mongoconn, hostnames, startDate, endDate)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
-			Response: "Gagal mengambil data",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "", "Gagal mengambil data"))
 		return
 	}
 
@@ -347,9 +314,7 @@ func GenerateTrackerTokenTesting(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
-			Response: "Error parsing application/json: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "", "Error parsing application/json: "+err.Error()))
 		return
 	}
 
@@ -368,17 +333,13 @@ func GenerateTrackerTokenTesting(w http.ResponseWriter, r *http.Request) {
 	}
 	exist, err := atdb.GetOneDoc[model.UserInfo](config.Mongoconn, "trackeriptest", filter)
 	if err == nil && exist.Browser != "" {
-		at.WriteJSON(w, http.StatusConflict, model.Response{
-			Response: "Hari ini sudah absen",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "", "Hari ini sudah absen"))
 		return
 	}
 	userinfo.Tanggal_Ambil = waktusekarang
 	token, err := watoken.EncodeWithStructDuration("", &userinfo, config.PrivateKey, duration)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
-			Response: "Error: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Internal, "", "Error: "+err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, model.Response{
@@ -394,9 +355,7 @@ func SimpanInformasiUserTesting(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusBadRequest, model.Response{
-			Response: "Error parsing application/json: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "", "Error parsing application/json: "+err.Error()))
 		return
 	}
 
@@ -418,17 +377,13 @@ func SimpanInformasiUserTesting(w http.ResponseWriter, r *http.Request) {
 	}
 	exist, err := atdb.GetOneDoc[model.UserInfo](config.Mongoconn, "trackeriptest", filter)
 	if err == nil && exist.Browser != "" {
-		at.WriteJSON(w, http.StatusConflict, model.Response{
-			Response: "Hari ini sudah absen",
-		})
+		at.WriteError(w, r, apperr.New(apperr.Conflict, "", "Hari ini sudah absen"))
 		return
 	}
 	userinfo.Tanggal_Ambil = waktusekarang
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "trackeriptest", userinfo)
 	if err != nil {
-		at.WriteJSON(w, http.StatusInternalServerError, model.Response{
-			Response: "Gagal Insert Database: " + err.Error(),
-		})
+		at.WriteError(w, r, apperr.New(apperr.Database, "", "Gagal Insert Database: "+err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, model.Response{
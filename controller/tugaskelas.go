@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
@@ -27,7 +28,7 @@ func PostTugasKelasAI(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var tugasAI model.ScoreKelas
@@ -35,13 +36,13 @@ func PostTugasKelasAI(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if tugasAI.Kelas == "" {
 		respn.Status = "Error : Kelas tidak boleh kosong"
 		respn.Response = "Isi lebih lengkap terlebih dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -49,7 +50,7 @@ func PostTugasKelasAI(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan data tugas ai"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -65,14 +66,14 @@ func GetDataTugasAIById(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	tugasai, err := GetDataTugasById("tugaskelasai", objectId)
 	if err != nil {
 		respn.Status = "Error : Data tugas ai tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, tugasai)
@@ -86,7 +87,7 @@ func GetDataTugasAI(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -94,7 +95,7 @@ func GetDataTugasAI(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data tugas ai"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -104,12 +105,12 @@ func GetDataTugasAI(respw http.ResponseWriter, req *http.Request) {
 func GetLastWeekScoreKelasAI(w http.ResponseWriter, r *http.Request) {
 	authorization, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -127,7 +128,7 @@ func PostTugasKelasWS(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var tugasWS model.ScoreKelas
@@ -135,13 +136,13 @@ func PostTugasKelasWS(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if tugasWS.Kelas == "" {
 		respn.Status = "Error : Kelas tidak boleh kosong"
 		respn.Response = "Isi lebih lengkap terlebih dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 
@@ -149,7 +150,7 @@ func PostTugasKelasWS(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal menyimpan data tugas ws"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -165,14 +166,14 @@ func GetDataTugasWSById(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	tugasws, err := GetDataTugasById("tugaskelasws", objectId)
 	if err != nil {
 		respn.Status = "Error : Data tugas ws tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, tugasws)
@@ -186,7 +187,7 @@ func GetDataTugasWS(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -194,7 +195,7 @@ func GetDataTugasWS(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Gagal mengambil data tugas ws"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -204,12 +205,12 @@ func GetDataTugasWS(respw http.ResponseWriter, req *http.Request) {
 func GetLastWeekScoreKelasWS(w http.ResponseWriter, r *http.Request) {
 	authorization, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
 	if err != nil {
-		at.WriteJSON(w, http.StatusForbidden, model.Response{
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, model.Response{
 			Status:   "Error: Invalid Token",
 			Info:     at.GetSecretFromHeader(r),
 			Location: "Token Validation",
 			Response: err.Error(),
-		})
+		}))
 		return
 	}
 
@@ -11,14 +11,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/model"
-	"github.com/whatsauth/itmodel"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
 	"github.com/gocroot/helper/report"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
@@ -33,26 +34,38 @@ func PutTokenDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	docuser.Name = payload.Alias
 	hcode, qrstat, err := atapi.Get[model.QRStatus](config.WAAPIGetDevice + at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusMisdirectedRequest, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, model.Response{
+			Status:   "Error : Gagal mengecek status device WA",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	if hcode == http.StatusOK && !qrstat.Status {
 		docuser.LinkedDevice, err = watoken.EncodeforHours(docuser.PhoneNumber, docuser.Name, config.PrivateKey, 43830)
 		if err != nil {
-			at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, model.Response{
+				Status:   "Error : Gagal membuat token linked device",
+				Response: err.Error(),
+				Data:     docuser,
+			}))
 			return
 		}
 	} else {
@@ -60,7 +73,11 @@ func PutTokenDataUser(respw http.ResponseWriter, req *http.Request) {
 	}
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, docuser)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusExpectationFailed, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, model.Response{
+			Status:   "Error : Gagal menyimpan data user",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, docuser)
@@ -74,14 +91,18 @@ func GetDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
 		docuser.PhoneNumber = payload.Id
 		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, model.Response{
+			Status:   "Error : Data user tidak ditemukan",
+			Response: err.Error(),
+			Data:     docuser,
+		}))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -96,7 +117,7 @@ func GetAllDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -108,7 +129,7 @@ func GetAllDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Status = "Error: Database Query Failed"
 		respn.Location = "Database Query"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	defer cur.Close(ctx)
@@ -121,7 +142,7 @@ func GetAllDataUser(respw http.ResponseWriter, req *http.Request) {
 			respn.Status = "Error: Decoding Document"
 			respn.Location = "Cursor Iteration"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		allUsers = append(allUsers, user)
@@ -132,7 +153,7 @@ func GetAllDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Status = "Error: Cursor Error"
 		respn.Location = "Cursor Final Check"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -147,7 +168,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var usr model.Userdomyikado
@@ -156,7 +177,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
@@ -168,7 +189,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Gagal Insert Database"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		usr.ID = idusr
@@ -192,7 +213,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Gagal replaceonedoc"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	//melakukan update di seluruh member project
@@ -214,7 +235,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Error : Data project tidak di temukan"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotFound, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 			return
 		}
 		_, err = atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", docuser)
@@ -222,7 +243,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Error : Gagal menambahkan member ke project"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 
@@ -232,16 +253,16 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 }
 
 func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
-	var resp itmodel.Response
+	var resp model.Response
 	prof, err := whatsauth.GetAppProfile(at.GetParam(req), config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, resp))
 		return
 	}
 	if at.GetSecretFromHeader(req) != prof.Secret {
 		resp.Response = "Salah secret: " + at.GetSecretFromHeader(req)
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidCredentials, resp))
 		return
 	}
 	var usr model.Userdomyikado
@@ -249,7 +270,7 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		resp.Response = "Error : Body tidak valid"
 		resp.Info = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": usr.PhoneNumber})
@@ -258,7 +279,7 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			resp.Response = "Gagal Insert Database"
 			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, resp))
 			return
 		}
 		resp.Info = idusr.Hex()
@@ -285,7 +306,7 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		resp.Response = "Gagal replaceonedoc"
 		resp.Info = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, resp))
 		return
 	}
 	//melakukan update di seluruh member project
@@ -308,14 +329,14 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			resp.Response = "Error : Data project tidak di temukan"
 			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusNotFound, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, resp))
 			return
 		}
 		_, err = atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", docuser)
 		if err != nil {
 			resp.Response = "Error : Gagal menambahkan member ke project"
 			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, resp))
 			return
 		}
 
@@ -326,11 +347,8 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 }
 
 func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
-	noHp := r.Header.Get("nohp")
-	if noHp == "" {
-		http.Error(w, "No valid phone number found", http.StatusForbidden)
-		return
-	}
+	// nomor dosen diambil dari token login yang sudah dicek middleware, bukan dari header nohp
+	noHp := rbac.PhoneNumber(r)
 
 	var requestData struct {
 		NIM   string `json:"nim"`
@@ -338,7 +356,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	}
 	err := json.NewDecoder(r.Body).Decode(&requestData)
 	if err != nil || requestData.NIM == "" || requestData.Topik == "" {
-		http.Error(w, "Invalid request body or NIM/Topik not provided", http.StatusBadRequest)
+		at.WriteError(w, r, apperr.New(apperr.InvalidBody, "Invalid request body or NIM/Topik not provided", ""))
 		return
 	}
 
@@ -346,7 +364,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	var conf model.Config
 	err = config.Mongoconn.Collection("config").FindOne(context.TODO(), bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
 	if err != nil {
-		http.Error(w, "Mohon maaf ada kesalahan dalam pengambilan config di database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Mohon maaf ada kesalahan dalam pengambilan config di database", err))
 		return
 	}
 
@@ -356,15 +374,15 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 		"topik": requestData.Topik,
 	})
 	if err != nil {
-		http.Error(w, "Gagal membuat request body: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal membuat request body", err))
 		return
 	}
 
 	// Create and send the HTTP request
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := atapi.NewClient(10 * time.Second)
 	req, err := http.NewRequest("POST", conf.ApproveBimbinganURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		http.Error(w, "Gagal membuat request: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal membuat request", err))
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -372,7 +390,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		http.Error(w, "Gagal mengirim request: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Upstream, "Gagal mengirim request", err))
 		return
 	}
 	defer resp.Body.Close()
@@ -380,11 +398,11 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	if resp.StatusCode != http.StatusOK {
 		switch resp.StatusCode {
 		case http.StatusNotFound:
-			http.Error(w, "Token tidak ditemukan! Silahkan Login Kembali", http.StatusNotFound)
+			at.WriteError(w, r, apperr.New(apperr.TokenInvalid, "Token tidak ditemukan! Silahkan Login Kembali", ""))
 		case http.StatusForbidden:
-			http.Error(w, "Gagal, Bimbingan telah disetujui!", http.StatusForbidden)
+			at.WriteError(w, r, apperr.New(apperr.Conflict, "Gagal, Bimbingan telah disetujui!", ""))
 		default:
-			http.Error(w, fmt.Sprintf("Gagal approve bimbingan, status code: %d", resp.StatusCode), http.StatusInternalServerError)
+			at.WriteError(w, r, apperr.New(apperr.Upstream, fmt.Sprintf("Gagal approve bimbingan, status code: %d", resp.StatusCode), ""))
 		}
 		return
 	}
@@ -392,22 +410,22 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	var responseMap map[string]string
 	err = json.NewDecoder(resp.Body).Decode(&responseMap)
 	if err != nil {
-		http.Error(w, "Gagal memproses response: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Upstream, "Gagal memproses response", err))
 		return
 	}
 
-	// Kurangi poin berdasarkan nomor telepon yang ada di response
+	// Kurangi poin berdasarkan nomor telepon yang ada di response, satu kali untuk setiap nim dan topik
 	phonenumber := responseMap["no_hp"]
-	_, err = report.KurangPoinUserbyPhoneNumber(config.Mongoconn, phonenumber, 13.0)
+	_, err = report.KurangPoinUserbyPhoneNumber(config.Mongoconn, phonenumber, 13.0, "approve bimbingan", "approvebimbingan:"+requestData.NIM+":"+requestData.Topik)
 	if err != nil {
-		http.Error(w, "Gagal mengurangi poin: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal mengurangi poin", err))
 		return
 	}
 
 	// Get updated user data to return the current points
 	usr, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
 	if err != nil {
-		http.Error(w, "Gagal mengambil data pengguna: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal mengambil data pengguna", err))
 		return
 	}
 
@@ -428,7 +446,7 @@ func GetLogPoin(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -440,7 +458,7 @@ func GetLogPoin(w http.ResponseWriter, r *http.Request) {
 		respn.Info = payload.Id
 		respn.Location = "Get User Data"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -448,7 +466,7 @@ func GetLogPoin(w http.ResponseWriter, r *http.Request) {
 	filter := bson.M{"userid": docuser.ID}
 	cursor, err := config.Mongoconn.Collection("logpoin").Find(context.TODO(), filter)
 	if err != nil {
-		http.Error(w, "Gagal mengambil data dari database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal mengambil data dari database", err))
 		return
 	}
 	defer cursor.Close(context.TODO())
@@ -456,20 +474,20 @@ func GetLogPoin(w http.ResponseWriter, r *http.Request) {
 	// Decode hasilnya ke slice dari struct `LogPoin`
 	var logPoins []model.ReportData
 	if err = cursor.All(context.TODO(), &logPoins); err != nil {
-		http.Error(w, "Gagal memproses data dari database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal memproses data dari database", err))
 		return
 	}
 
 	// Jika tidak ada data ditemukan
 	if len(logPoins) == 0 {
-		http.Error(w, "Tidak ada data yang ditemukan untuk pengguna ini", http.StatusNotFound)
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Tidak ada data yang ditemukan untuk pengguna ini", ""))
 		return
 	}
 
 	// Menulis respons dalam format JSON
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(logPoins); err != nil {
-		http.Error(w, "Gagal mengirim data dalam format JSON: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal mengirim data dalam format JSON", err))
 	}
 }
 
@@ -483,7 +501,7 @@ func PostGroup(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -494,7 +512,7 @@ func PostGroup(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -511,7 +529,7 @@ func PostGroup(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Gagal memperbarui data group"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusConflict, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 	} else {
@@ -521,7 +539,7 @@ func PostGroup(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Gagal menambahkan data group"
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		group.ID = idGroup
@@ -541,7 +559,7 @@ func PostMember(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -552,7 +570,7 @@ func PostMember(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -564,7 +582,7 @@ func PostMember(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Grup tidak ditemukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 
@@ -574,7 +592,7 @@ func PostMember(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error: Gagal menambahkan anggota"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -592,7 +610,7 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -601,7 +619,7 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 	if phoneNumber == "" {
 		var respn model.Response
 		respn.Status = "Error: PhoneNumber tidak ditemukan dalam payload"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -614,13 +632,13 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 		if err == mongo.ErrNoDocuments {
 			var respn model.Response
 			respn.Status = "Tidak ada grup ditemukan untuk nomor telepon ini"
-			at.WriteJSON(respw, http.StatusNotFound, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 			return
 		}
 		var respn model.Response
 		respn.Status = "Error: Gagal mengambil data member"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	defer cursor.Close(req.Context())
@@ -630,7 +648,7 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 		var respn model.Response
 		respn.Status = "Error: Gagal memproses data member"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -648,7 +666,7 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 		var respn model.Response
 		respn.Status = "Error: Gagal mengambil data grup"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	defer cursor.Close(req.Context())
@@ -657,7 +675,7 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 		var respn model.Response
 		respn.Status = "Error: Gagal memproses data grup"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -665,8 +683,8 @@ func GetGroupByPhoneNumberFromMember(respw http.ResponseWriter, req *http.Reques
 	at.WriteJSON(respw, http.StatusOK, groups)
 }
 
-func GetAllDataSponsorPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", primitive.M{"phonenumber": phonenumber})
+func GetAllDataSponsorPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	docuser, err := atdb.GetOneDocContext[model.Userdomyikado](ctx, db, "user", primitive.M{"phonenumber": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
@@ -10,50 +10,51 @@ import (
 	"strings"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/gcallapi"
 	"github.com/gocroot/helper/normalize"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
-	"github.com/whatsauth/itmodel"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func PostTaskList(w http.ResponseWriter, r *http.Request) {
-	var resp itmodel.Response
+	var resp model.Response
 	prof, err := whatsauth.GetAppProfile(at.GetParam(r), config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.NotFound, resp))
 		return
 	}
 	if at.GetSecretFromHeader(r) != prof.Secret {
 		resp.Response = "Salah secret: " + at.GetSecretFromHeader(r)
-		at.WriteJSON(w, http.StatusUnauthorized, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.InvalidCredentials, resp))
 		return
 	}
 	var tasklists []report.TaskList
 	err = json.NewDecoder(r.Body).Decode(&tasklists)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.BadRequest, resp))
 		return
 	}
 	docusr, err := atdb.GetOneLatestDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": tasklists[0].PhoneNumber})
 	if err != nil {
 		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(w, http.StatusForbidden, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, resp))
 		return
 	}
 	lapuser, err := atdb.GetOneLatestDoc[report.Laporan](config.Mongoconn, "uxlaporan", primitive.M{"_id": tasklists[0].LaporanID})
 	if err != nil {
 		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(w, http.StatusForbidden, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, resp))
 		return
 	}
 	for _, task := range tasklists {
@@ -69,15 +70,15 @@ func PostTaskList(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			resp.Info = "Kakak sudah melaporkan tasklist sebelumnya"
 			resp.Response = "Error : tidak bisa insert ke database " + err.Error()
-			at.WriteJSON(w, http.StatusForbidden, resp)
+			at.WriteError(w, r, apperr.FromResponse(apperr.Conflict, resp))
 			return
 		}
 	}
-	res, err := report.TambahPoinTasklistbyPhoneNumber(config.Mongoconn, docusr.PhoneNumber, lapuser.Project, float64(len(tasklists)), "tasklist")
+	res, err := report.TambahPoinTasklistbyPhoneNumber(config.Mongoconn, docusr.PhoneNumber, lapuser.Project, float64(len(tasklists)), "tasklist", "tasklist:"+lapuser.ID.Hex()+":"+docusr.PhoneNumber)
 	if err != nil {
 		resp.Info = "Tambah Poin Tasklist gagal"
 		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusExpectationFailed, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, resp))
 		return
 	}
 	resp.Response = strconv.Itoa(int(res.ModifiedCount))
@@ -86,43 +87,43 @@ func PostTaskList(w http.ResponseWriter, r *http.Request) {
 }
 
 func PostPresensi(respw http.ResponseWriter, req *http.Request) {
-	var resp itmodel.Response
+	var resp model.Response
 	prof, err := whatsauth.GetAppProfile(at.GetParam(req), config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, resp))
 		return
 	}
 	if at.GetSecretFromHeader(req) != prof.Secret {
 		resp.Response = "Salah secret: " + at.GetSecretFromHeader(req)
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidCredentials, resp))
 		return
 	}
 	var presensi report.PresensiDomyikado
 	err = json.NewDecoder(req.Body).Decode(&presensi)
 	if err != nil {
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, resp))
 		return
 	}
 	docusr, err := atdb.GetOneLatestDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": presensi.PhoneNumber})
 	if err != nil {
 		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, resp))
 		return
 	}
-	_, err = atdb.InsertOneDoc(config.Mongoconn, "presensi", presensi)
+	presensi.ID, err = atdb.InsertOneDoc(config.Mongoconn, "presensi", presensi)
 	if err != nil {
 		resp.Info = "Kakak sudah melaporkan presensi sebelumnya"
 		resp.Response = "Error : tidak bisa insert ke database " + err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Conflict, resp))
 		return
 	}
-	res, err := report.TambahPoinPresensibyPhoneNumber(config.Mongoconn, presensi.PhoneNumber, presensi.Lokasi, presensi.Skor, config.WAAPIToken, config.WAAPIMessage, "presensi")
+	res, err := report.TambahPoinPresensibyPhoneNumber(config.Mongoconn, presensi.PhoneNumber, presensi.Lokasi, presensi.Skor, config.WAAPIToken, config.WAAPIMessage, "presensi", "presensi:"+presensi.ID.Hex())
 	if err != nil {
 		resp.Info = "Tambah Poin Presensi gagal"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, resp))
 		return
 	}
 	resp.Response = strconv.Itoa(int(res.ModifiedCount))
@@ -138,7 +139,7 @@ func PostRatingLaporan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	objectId, err := primitive.ObjectIDFromHex(rating.ID)
@@ -147,14 +148,14 @@ func PostRatingLaporan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[report.Laporan](config.Mongoconn, "uxlaporan", primitive.M{"_id": objectId})
 	if err != nil {
 		respn.Status = "Error : Data laporan tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	filter := bson.M{"_id": bson.M{"$eq": hasil.ID}}
@@ -166,7 +167,7 @@ func PostRatingLaporan(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		respn.Status = "Error : Data laporan tidak berhasil di update data rating"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	var isRapat bool
@@ -198,22 +199,22 @@ func PostRatingLaporan(respw http.ResponseWriter, req *http.Request) {
 		statuscode, loginf, err := atapi.PostStructWithToken[model.LogInfo]("secret", conf.LeaflySecret, dt, conf.LeaflyURL)
 		if err != nil {
 			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusBadRequest, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 		}
 		if statuscode != http.StatusOK {
 			respn.Response = loginf.Error
-			at.WriteJSON(respw, http.StatusBadRequest, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 			return
 
 		}
 	}
 	poin := float64(rating.Rating) / 5.0
-	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, hasil.Project, hasil.NoPetugas, poin, "rating")
+	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, hasil.Project, hasil.NoPetugas, poin, "rating", "rating:"+hasil.ID.Hex())
 	if err != nil {
 		respn.Info = "TambahPoinLaporanbyPhoneNumber gagal"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 	var message string
@@ -227,11 +228,11 @@ func PostRatingLaporan(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  true,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	respn.Response = strconv.Itoa(int(res.ModifiedCount))
@@ -248,7 +249,7 @@ func GetLaporan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[report.Laporan](config.Mongoconn, "uxlaporan", primitive.M{"_id": objectId})
@@ -256,7 +257,7 @@ func GetLaporan(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data laporan tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, hasil)
@@ -272,7 +273,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -282,7 +283,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Request body decoding error:", err)
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 
@@ -291,7 +292,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("User not found:", err)
 		respn.Status = "Error : Data user tidak di temukan: " + payload.Id
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
@@ -300,7 +301,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Project not found:", err)
 		respn.Status = "Error : Data project tidak di temukan: " + event.ProjectID.Hex()
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 
@@ -329,7 +330,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Failed to create Google Calendar event:", err)
 		respn.Status = "Gagal Membuat Google Calendar"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, respn))
 		return
 	}
 
@@ -338,7 +339,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Failed to insert meeting log:", err)
 		respn.Status = "Gagal Insert Database meetinglog"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -347,7 +348,7 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Failed to insert meeting event:", err)
 		respn.Status = "Gagal Insert Database meeting"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -359,16 +360,16 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Failed to insert uxlaporan:", err)
 		respn.Status = "Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
-	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "meeting")
+	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "meeting", "meeting:"+lap.ID.Hex())
 	if err != nil {
 		fmt.Println("Failed to add report points:", err)
 		respn.Info = "TambahPoinLaporanbyPhoneNumber gagal"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusExpectationFailed, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
 
@@ -387,12 +388,12 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		dt.IsGroup = false
 	}
 
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		fmt.Println("Failed to send WhatsApp message:", err)
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusUnauthorized, resp)
+		at.WriteError(w, r, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 
@@ -408,7 +409,7 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var lap report.Laporan
@@ -417,14 +418,14 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if lap.Solusi == "" {
 		var respn model.Response
 		respn.Status = "Error : Telepon atau nama atau solusi tidak diisi"
 		respn.Response = "Isi lebih lengkap dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 	//check validasi user
@@ -433,7 +434,7 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data user tidak di temukan: " + payload.Id
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 	//ambil data project
@@ -444,7 +445,7 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = lap.Kode
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	prjuser, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prjobjectId})
@@ -452,7 +453,7 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan: " + lap.Kode
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	//lojik inputan post
@@ -468,15 +469,15 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
-	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "laporan")
+	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "laporan", "laporan:"+idlap.Hex())
 	if err != nil {
 		var resp model.Response
 		resp.Info = "TambahPoinPushRepobyGithubUsername gagal"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	message := "*Permintaan Feedback Pekerjaan*\n" + "Petugas : " + docuser.Name + "\nDeskripsi:" + lap.Solusi + "\n Beri Nilai: " + "https://www.do.my.id/rate/#" + idlap.Hex()
@@ -485,11 +486,11 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, lap)
@@ -505,7 +506,7 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = at.GetSecretFromHeader(req)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 	var lap report.Laporan
@@ -514,14 +515,14 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Body tidak valid"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
 		return
 	}
 	if lap.Phone == "" || lap.Nama == "" || lap.Solusi == "" {
 		var respn model.Response
 		respn.Status = "Error : Telepon atau nama atau solusi tidak diisi"
 		respn.Response = "Isi lebih lengkap dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, respn))
 		return
 	}
 	//validasi eksistensi user di db
@@ -530,7 +531,7 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data user tidak di temukan"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 	//ambil data project
@@ -541,7 +542,7 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		respn.Info = lap.Kode
 		respn.Location = "Encode Object ID Error"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
 		return
 	}
 	prjuser, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prjobjectId})
@@ -549,7 +550,7 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Error : Data project tidak di temukan: " + lap.Kode
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
 		return
 	}
 	//lojik inputan post
@@ -564,7 +565,7 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 			var respn model.Response
 			respn.Status = "Feedback tidak boleh dari sendiri atau rekan satu tim " + member.Name
 			respn.Response = member.PhoneNumber
-			at.WriteJSON(respw, http.StatusForbidden, respn)
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, respn))
 			return
 		}
 	}
@@ -574,15 +575,15 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		var respn model.Response
 		respn.Status = "Gagal Insert Database"
 		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
 		return
 	}
-	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "feedback")
+	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "feedback", "feedback:"+idlap.Hex())
 	if err != nil {
 		var resp model.Response
 		resp.Info = "TambahPoinLaporanbyPhoneNumber gagal"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	message := "*Permintaan Feedback*\n" + "Petugas : " + docuser.Name + "\nDeskripsi:" + lap.Solusi + "\n Beri Nilai: " + "https://www.do.my.id/rate/#" + idlap.Hex()
@@ -591,11 +592,11 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(config.Mongoconn, dt)
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, lap)
@@ -610,7 +611,7 @@ func GetUXReport(w http.ResponseWriter, r *http.Request) {
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -621,32 +622,32 @@ func GetUXReport(w http.ResponseWriter, r *http.Request) {
 		respn.Info = payload.Id
 		respn.Location = "Get User Data"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 
 	filter := bson.M{"nopetugas": docuser.PhoneNumber}
 	cursor, err := config.Mongoconn.Collection("uxlaporan").Find(context.TODO(), filter)
 	if err != nil {
-		http.Error(w, "Gagal mengambil data dari database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal mengambil data dari database", err))
 		return
 	}
 	defer cursor.Close(context.TODO())
 
 	var logUXReport []model.LaporanHistory
 	if err = cursor.All(context.TODO(), &logUXReport); err != nil {
-		http.Error(w, "Gagal memproses data dari database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Database, "Gagal memproses data dari database", err))
 		return
 	}
 
 	if len(logUXReport) == 0 {
-		http.Error(w, "Tidak ada data yang ditemukan untuk pengguna ini", http.StatusNotFound)
+		at.WriteError(w, r, apperr.New(apperr.NotFound, "Tidak ada data yang ditemukan untuk pengguna ini", ""))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(logUXReport); err != nil {
-		http.Error(w, "Gagal mengirim data dalam format JSON: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, r, apperr.Wrap(apperr.Internal, "Gagal mengirim data dalam format JSON", err))
 	}
 }
 
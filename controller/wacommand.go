@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/whatsauth"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// perintah WhatsApp yang butuh data controller didaftarkan di sini, router dan !help ada di helper/whatsauth
+func init() {
+	whatsauth.RegisterCommand(whatsauth.Command{
+		Name:        "poin",
+		Description: "saldo poin kamu",
+		Permission:  whatsauth.Terdaftar,
+		Handler:     waPoin,
+	})
+	whatsauth.RegisterCommand(whatsauth.Command{
+		Name:        "skor",
+		Args:        "[total]",
+		Description: "skor aktivitas minggu lalu, tambahkan total untuk skor keseluruhan",
+		Permission:  whatsauth.Terdaftar,
+		Handler:     waSkor,
+	})
+	whatsauth.RegisterCommand(whatsauth.Command{
+		Name:        "bimbingan",
+		Description: "status bimbingan minggu ini",
+		Permission:  whatsauth.Terdaftar,
+		Handler:     waBimbingan,
+	})
+	whatsauth.RegisterCommand(whatsauth.Command{
+		Name:        "pomokit",
+		Description: "rekap total Pomokit dikirim ke japri",
+		Permission:  whatsauth.Terdaftar,
+		Handler:     waPomokit,
+	})
+	whatsauth.RegisterCommand(whatsauth.Command{
+		Name:        "cekskor",
+		Args:        "<nomor hp>",
+		Description: "skor aktivitas dan status bimbingan mahasiswa minggu lalu",
+		Permission:  whatsauth.Izin(rbac.LihatSkorMahasiswa),
+		Handler:     waCekSkor,
+	})
+}
+
+func waPoin(msg whatsauth.IteungMessage, args []string, db *mongo.Database) (string, error) {
+	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": msg.Phone_number})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Poin %s saat ini: *%.2f*", usr.Name, usr.Poin), nil
+}
+
+func waSkor(msg whatsauth.IteungMessage, args []string, db *mongo.Database) (string, error) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "total" {
+		score, err := GetAllActivityScoreData(msg.Phone_number)
+		if err != nil {
+			return "", err
+		}
+		return "*Skor Aktivitas Keseluruhan*\n" + formatActivityScore(score), nil
+	}
+	score, err := GetLastWeekActivityScoreData(msg.Phone_number)
+	if err != nil {
+		return "", err
+	}
+	return "*Skor Aktivitas Minggu Lalu*\n" + formatActivityScore(score), nil
+}
+
+func waBimbingan(msg whatsauth.IteungMessage, args []string, db *mongo.Database) (string, error) {
+	return statusBimbinganText(msg.Phone_number)
+}
+
+// waPomokit memakai RekapPomokitTotalToPhone yang sudah mengirim laporan ke japri,
+// jadi di japri tidak perlu dibalas lagi dan di grup cukup diberi tahu
+func waPomokit(msg whatsauth.IteungMessage, args []string, db *mongo.Database) (string, error) {
+	rekap, err := report.RekapPomokitTotalToPhone(db, msg.Phone_number)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(rekap, "Tidak ada data Pomokit yang tersedia") {
+		return rekap, nil
+	}
+	if msg.Chat_server == "g.us" {
+		return "Rekap Pomokit sudah dikirim ke japri " + msg.Alias_name, nil
+	}
+	return "", nil
+}
+
+func waCekSkor(msg whatsauth.IteungMessage, args []string, db *mongo.Database) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("nomor hp mahasiswa belum diisi, contoh: " + whatsauth.CommandPrefix + "cekskor 6281234567890")
+	}
+	phonenumber := strings.TrimPrefix(args[0], "+")
+	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
+	if err != nil {
+		return "", errors.New("nomor " + phonenumber + " tidak terdaftar")
+	}
+	score, err := GetLastWeekActivityScoreData(phonenumber)
+	if err != nil {
+		return "", err
+	}
+	bimbingan, err := statusBimbinganText(phonenumber)
+	if err != nil {
+		return "", err
+	}
+	return "*" + usr.Name + "* (" + phonenumber + ")\n" + formatActivityScore(score) + "\n\n" + bimbingan, nil
+}
+
+func statusBimbinganText(phonenumber string) (string, error) {
+	hasApproved, hasUnapproved, err := CheckWeeklyBimbinganStatus(phonenumber)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case hasApproved:
+		return "Bimbingan minggu ini sudah di-approve.", nil
+	case hasUnapproved:
+		return "Bimbingan minggu ini sudah diajukan dan menunggu approval asesor.", nil
+	default:
+		return "Belum ada bimbingan minggu ini, silakan ajukan bimbingan.", nil
+	}
+}
+
+func formatActivityScore(score model.ActivityScore) string {
+	baris := []struct {
+		nama  string
+		nilai string
+	}{
+		{"Sponsor", strconv.Itoa(score.Sponsor)},
+		{"Strava", strconv.Itoa(score.Strava)},
+		{"IQ", strconv.Itoa(score.IQ)},
+		{"Pomokit", strconv.Itoa(score.Pomokit)},
+		{"Blockchain", strconv.Itoa(score.BlockChain)},
+		{"QRIS", strconv.Itoa(score.QRIS)},
+		{"Tracker", strconv.FormatFloat(score.Tracker, 'f', 0, 64)},
+		{"Buku", strconv.Itoa(score.BukPed)},
+		{"Jurnal", strconv.Itoa(score.Jurnal)},
+		{"GTMetrix", strconv.Itoa(score.GTMetrix)},
+		{"Webhook", strconv.Itoa(score.WebHook)},
+		{"Presensi", strconv.Itoa(score.Presensi)},
+	}
+	var sb strings.Builder
+	for _, b := range baris {
+		sb.WriteString(b.nama + ": " + b.nilai + "\n")
+	}
+	sb.WriteString("*Total: " + strconv.Itoa(score.TotalScore) + "*")
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/waoutbox"
+	"github.com/gocroot/model"
+)
+
+// RefreshWAOutbox dipasang di cronjob tiap menit, mengirim ulang pesan WA yang gagal atau tertahan rate limit
+func RefreshWAOutbox(respw http.ResponseWriter, req *http.Request) {
+	sent, retried, failed := waoutbox.ProcessDue(config.Mongoconn, 100)
+	at.WriteJSON(respw, http.StatusOK, model.Response{
+		Status:   "Success",
+		Response: "terkirim " + strconv.Itoa(sent) + ", dijadwalkan ulang " + strconv.Itoa(retried) + ", gagal " + strconv.Itoa(failed),
+	})
+}
+
+// GetFailedWAOutbox menampilkan pesan WA yang gagal setelah semua percobaan (khusus owner)
+func GetFailedWAOutbox(respw http.ResponseWriter, req *http.Request) {
+	msgs, err := waoutbox.GetFailed(config.Mongoconn, 200)
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal mengambil outbox", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, msgs)
+}
+
+// ResendWAOutbox mengantrikan ulang satu pesan yang gagal lalu langsung mencoba mengirimnya (khusus owner)
+func ResendWAOutbox(respw http.ResponseWriter, req *http.Request) {
+	msg, err := waoutbox.Resend(config.Mongoconn, router.ParamObjectID(req, "id"))
+	if err != nil {
+		at.WriteError(respw, req, apperr.New(apperr.NotFound, "Error : Pesan gagal tidak ditemukan", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, msg)
+}
@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,15 +15,18 @@ import (
 	"github.com/go-playground/webhooks/gitlab"
 	"github.com/go-playground/webhooks/v6/github"
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/ghapi"
 	"github.com/gocroot/helper/normalize"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func PostWebHookGithub(respw http.ResponseWriter, req *http.Request) {
@@ -28,123 +35,189 @@ func PostWebHookGithub(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		resp.Info = "Tidak terdaftar"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnavailableForLegalReasons, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, resp))
 		return
 	}
 	hook, err := github.New(github.Options.Secret(prj.Secret))
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, resp))
 		return
 	}
-	payload, err := hook.Parse(req, github.PushEvent, github.PingEvent)
+	//body disimpan dulu agar delivery bisa dikirim ulang oleh admin
+	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		resp.Info = "Tidak ada payload"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
 		return
 	}
-	switch pyl := payload.(type) {
-	case github.PingPayload:
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	payload, err := hook.Parse(req, github.PushEvent, github.PingEvent, github.PullRequestEvent, github.PullRequestReviewEvent, github.IssuesEvent)
+	if err != nil {
+		resp.Info = "Tidak ada payload"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
+		return
+	}
+	if _, ok := payload.(github.PingPayload); ok {
 		resp.Response = prj.Description
 		resp.Info = prj.Name
 		resp.Status = prj.Owner.Name
 		at.WriteJSON(respw, http.StatusOK, resp)
 		return
+	}
+	deliveryID := req.Header.Get("X-GitHub-Delivery")
+	if deliveryID == "" {
+		deliveryID = githubDeliveryKey(prj, payload)
+	}
+	//delivery yang diulang tidak diproses lagi, jawab dengan hasil sebelumnya
+	if dlv, err := getWebhookDelivery(deliveryID); err == nil {
+		at.WriteJSON(respw, dlv.StatusCode, dlv.Response)
+		return
+	}
+	status, resp := prosesGithubEvent(prj, payload, deliveryID, req.RemoteAddr)
+	simpanWebhookDelivery(model.WebhookDelivery{
+		DeliveryID:  deliveryID,
+		Host:        "github",
+		Event:       req.Header.Get("X-GitHub-Event"),
+		ProjectName: prj.Name,
+		Payload:     string(body),
+		StatusCode:  status,
+		Response:    resp,
+		CreatedAt:   time.Now(),
+	})
+	at.WriteJSON(respw, status, resp)
+}
+
+// webhookGagal memberi kode error pada hasil pemrosesan webhook.
+// Hasilnya disimpan di webhookdelivery dan dikirim ulang apa adanya jika delivery diulang, sehingga envelope dibuat di sini.
+func webhookGagal(code apperr.Code, resp model.Response) (status int, envelope model.Response) {
+	return code.HTTPStatus(), apperr.FromResponse(code, resp).Response(apperr.ID)
+}
+
+// prosesGithubEvent meneruskan payload ke pemroses sesuai jenis event
+func prosesGithubEvent(prj model.Project, payload interface{}, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	switch pyl := payload.(type) {
 	case github.PushPayload:
-		var komsg, msg string
-		var dokcommit model.PushReport
-		var usr model.Userdomyikado
-		for i, komit := range pyl.Commits {
-			//membuat list file yang diubah
-			//ambil dari api jumlah baris yang dirubah
-			commitURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", pyl.Repository.Owner.Login, pyl.Repository.Name, komit.ID)
-			statuscode, komitdtl, err := atapi.Get[ghapi.CommitDetails](commitURL)
-			var fileChangesinfo string
-			if err == nil && statuscode == http.StatusOK {
-				for n, file := range komitdtl.Files {
-					fileChangesinfo += "> " + normalize.NumberToAlphabet(n+1) + ". " + file.Filename + ": _++" + strconv.Itoa(file.Additions) + " --" + strconv.Itoa(file.Deletions) + "_\n"
-				}
-			} else { //jika api tidak ada akses maka tanpa jumlah baris
-				fileChangesinfo = strings.Join(komit.Modified[:], "\n")
-			}
-			//membuat list commit message yang masuk
-			kommsg := strings.TrimSpace(komit.Message)
-			appd := strconv.Itoa(i+1) + ". " + kommsg + " :\n" + fileChangesinfo + "\n"
-			dokcommit = model.PushReport{
-				ProjectName: prj.Name,
-				Project:     prj,
-				Username:    komit.Author.Username,
-				Email:       komit.Author.Email,
-				Repo:        pyl.Compare,
-				Ref:         pyl.Ref,
-				Message:     kommsg,
-				RemoteAddr:  req.RemoteAddr,
-			}
-			if (prj.Owner.Email == komit.Author.Email) || (prj.Owner.GithubUsername == komit.Author.Username) {
-				dokcommit.User = prj.Owner
-			} else {
-				var member *model.Userdomyikado
-				member, err := getMemberByAttributeInProject(prj, "githubusername", komit.Author.Username)
-				if err != nil {
-					member, err = getMemberByAttributeInProject(prj, "email", komit.Author.Email)
-					if err != nil {
-						resp.Location = komit.Author.Email + " | " + komit.Author.Username
-						resp.Info = "Username dan Email di GitHub tidak terdaftar"
-						resp.Response = err.Error()
-						at.WriteJSON(respw, http.StatusLocked, resp)
-						return
-					}
-				}
-				dokcommit.User = *member
+		return prosesPushGithub(prj, pyl, deliveryID, remoteAddr)
+	case github.PullRequestPayload:
+		return prosesPullRequestGithub(prj, pyl, deliveryID, remoteAddr)
+	case github.PullRequestReviewPayload:
+		return prosesPullRequestReviewGithub(prj, pyl, deliveryID, remoteAddr)
+	case github.IssuesPayload:
+		return prosesIssueGithub(prj, pyl, deliveryID, remoteAddr)
+	}
+	resp.Info = "Event tidak diproses"
+	return http.StatusOK, resp
+}
+
+// githubDeliveryKey dipakai jika header X-GitHub-Delivery kosong
+func githubDeliveryKey(prj model.Project, payload interface{}) string {
+	switch pyl := payload.(type) {
+	case github.PushPayload:
+		return deliveryCadangan("github", prj, pyl.After)
+	case github.PullRequestPayload:
+		return deliveryCadangan("github", prj, "pull_request:"+strconv.FormatInt(pyl.PullRequest.ID, 10)+":"+pyl.Action)
+	case github.PullRequestReviewPayload:
+		return deliveryCadangan("github", prj, "pull_request_review:"+strconv.FormatInt(pyl.Review.ID, 10)+":"+pyl.Action)
+	case github.IssuesPayload:
+		return deliveryCadangan("github", prj, "issues:"+strconv.FormatInt(pyl.Issue.ID, 10)+":"+pyl.Action)
+	}
+	return ""
+}
+
+// deliveryCadangan menyusun ID delivery jika git host tidak mengirim header delivery.
+// ID proyek ikut di dalamnya karena fork atau mirror di proyek lain membawa SHA yang sama.
+func deliveryCadangan(host string, prj model.Project, kunci string) string {
+	return host + ":" + prj.ID.Hex() + ":" + kunci
+}
+
+// parseGithubPayload mengubah body delivery yang tersimpan menjadi payload sesuai event
+func parseGithubPayload(event string, body []byte) (payload interface{}, err error) {
+	switch github.Event(event) {
+	case "", github.PushEvent:
+		var pyl github.PushPayload
+		err = json.Unmarshal(body, &pyl)
+		payload = pyl
+	case github.PullRequestEvent:
+		var pyl github.PullRequestPayload
+		err = json.Unmarshal(body, &pyl)
+		payload = pyl
+	case github.PullRequestReviewEvent:
+		var pyl github.PullRequestReviewPayload
+		err = json.Unmarshal(body, &pyl)
+		payload = pyl
+	case github.IssuesEvent:
+		var pyl github.IssuesPayload
+		err = json.Unmarshal(body, &pyl)
+		payload = pyl
+	default:
+		err = errors.New("event " + event + " tidak didukung")
+	}
+	return
+}
+
+// prosesPushGithub mencatat setiap commit dari payload push GitHub dan mengirim ringkasannya ke WhatsApp.
+// Commit yang SHA-nya sudah tercatat dilewati sehingga aman dipanggil ulang untuk payload yang sama.
+func prosesPushGithub(prj model.Project, pyl github.PushPayload, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	var dokcommit model.PushReport
+	var commits []pushCommit
+	for _, komit := range pyl.Commits {
+		//membuat list file yang diubah
+		//ambil dari api jumlah baris yang dirubah
+		commitURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", pyl.Repository.Owner.Login, pyl.Repository.Name, komit.ID)
+		statuscode, komitdtl, err := atapi.Get[ghapi.CommitDetails](commitURL)
+		var fileChangesinfo string
+		if err == nil && statuscode == http.StatusOK {
+			for n, file := range komitdtl.Files {
+				fileChangesinfo += "> " + normalize.NumberToAlphabet(n+1) + ". " + file.Filename + ": _++" + strconv.Itoa(file.Additions) + " --" + strconv.Itoa(file.Deletions) + "_\n"
 			}
-			usr, err = report.TambahPoinPushRepobyGithubUsername(config.Mongoconn, prj, dokcommit, 1)
+		} else { //jika api tidak ada akses maka tanpa jumlah baris
+			fileChangesinfo = strings.Join(komit.Modified[:], "\n")
+		}
+		//membuat list commit message yang masuk
+		kommsg := strings.TrimSpace(komit.Message)
+		dokcommit = model.PushReport{
+			ProjectName: prj.Name,
+			Project:     prj,
+			Username:    komit.Author.Username,
+			Email:       komit.Author.Email,
+			Repo:        pyl.Compare,
+			Ref:         pyl.Ref,
+			Message:     kommsg,
+			RemoteAddr:  remoteAddr,
+			CommitSHA:   komit.ID,
+			DeliveryID:  deliveryID,
+			Host:        "github",
+			CreatedAt:   time.Now(),
+		}
+		if (prj.Owner.Email == komit.Author.Email) || (prj.Owner.GithubUsername == komit.Author.Username) {
+			dokcommit.User = prj.Owner
+		} else {
+			var member *model.Userdomyikado
+			member, err := getMemberByAttributeInProject(prj, "githubusername", komit.Author.Username)
 			if err != nil {
-				usr, err = report.TambahPoinPushRepobyGithubEmail(config.Mongoconn, prj, dokcommit, 1)
+				member, err = getMemberByAttributeInProject(prj, "email", komit.Author.Email)
 				if err != nil {
-					resp.Info = "User Github: " + dokcommit.Username + " dan email github: " + dokcommit.Email + " tidak terhubung di user manapun di sistem Domyikado."
+					resp.Location = komit.Author.Email + " | " + komit.Author.Username
+					resp.Info = "Username dan Email di GitHub tidak terdaftar"
 					resp.Response = err.Error()
-					at.WriteJSON(respw, http.StatusExpectationFailed, resp)
-					return
+					return webhookGagal(apperr.UserNotFound, resp)
 				}
 			}
-			_, err = atdb.InsertOneDoc(config.Mongoconn, "pushrepo", dokcommit)
-			if err != nil {
-				resp.Info = "Data Push" + kommsg + " tidak berhasil masuk ke database"
-				resp.Response = err.Error()
-				at.WriteJSON(respw, http.StatusExpectationFailed, resp)
-				return
-			}
-			komsg += appd
-		}
-		msg = "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nNama: " + dokcommit.User.Name + "\nUserGitHub: " + pyl.Sender.Login + "\nRepo: " + pyl.Repository.Name + "\nBranch: " + pyl.Ref + "\n" + pyl.Compare + "\n" + komsg
-		dt := &whatsauth.TextMessage{
-			To:       prj.Owner.PhoneNumber,
-			IsGroup:  false,
-			Messages: msg,
+			dokcommit.User = *member
 		}
-		if prj.WAGroupID != "" && !strings.Contains(prj.WAGroupID, "-") {
-			dt.To = prj.WAGroupID
-			dt.IsGroup = true
-		}
-		//log output
-		var logoutwa whatsauth.LogWhatsauth
-		logoutwa.Data = *dt
-		logoutwa.Token = config.WAAPIToken
-		logoutwa.URL = config.WAAPIMessage
-		logoutwa.CreatedAt = time.Now()
-		go atdb.InsertOneDoc(config.Mongoconn, "logwa", logoutwa)
-		//_, resp, err =
-		go atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
-		/* if err != nil {
-			resp.Info = "Tidak berhak"
-			resp.Response = err.Error()
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
-			return
-		} */
+		commits = append(commits, pushCommit{dok: dokcommit, files: fileChangesinfo})
 	}
-	at.WriteJSON(respw, http.StatusOK, resp)
+	usr, komsg, baru, status, resp := simpanPushCommits(prj, commits)
+	if status != http.StatusOK || baru == 0 {
+		return status, resp
+	}
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nNama: " + dokcommit.User.Name + "\nUserGitHub: " + pyl.Sender.Login + "\nRepo: " + pyl.Repository.Name + "\nBranch: " + pyl.Ref + "\n" + pyl.Compare + "\n" + komsg
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
 }
 
 func PostWebHookGitlab(respw http.ResponseWriter, req *http.Request) {
@@ -153,109 +226,242 @@ func PostWebHookGitlab(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		resp.Info = "Tidak terdaftar"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnavailableForLegalReasons, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, resp))
 		return
 	}
 	hook, err := gitlab.New(gitlab.Options.Secret(prj.Secret))
 	if err != nil {
 		resp.Info = "Tidak berhak"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, resp))
 		return
 	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		resp.Info = "Tidak ada payload"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
 	payload, err := hook.Parse(req, gitlab.PushEvents)
 	if err != nil {
 		resp.Info = "Tidak ada payload"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
 		return
 	}
 	switch pyl := payload.(type) {
-	case github.PushPayload:
-		var komsg, msg string
-		var dokcommit model.PushReport
-		var usr model.Userdomyikado
-		for i, komit := range pyl.Commits {
-			//membuat list file yang diubah
-			//ambil dari api jumlah baris yang dirubah
-			commitURL := fmt.Sprintf("https://gitlab.com/api/v4/projects/%s%%2F%s/repository/commits/%s", pyl.Repository.Owner.Login, pyl.Repository.Name, komit.ID)
-			statuscode, komitdtl, err := atapi.Get[ghapi.CommitDetails](commitURL)
-			var fileChangesinfo string
-			if err == nil && statuscode == http.StatusOK {
-				for n, file := range komitdtl.Files {
-					fileChangesinfo += "> " + normalize.NumberToAlphabet(n+1) + ". " + file.Filename + ": _++" + strconv.Itoa(file.Additions) + " --" + strconv.Itoa(file.Deletions) + "_\n"
-				}
-			} else { //jika api tidak ada akses maka tanpa jumlah baris
-				fileChangesinfo = strings.Join(komit.Modified[:], "\n")
-			}
-			//membuat list commit message yang masuk
-			kommsg := strings.TrimSpace(komit.Message)
-			appd := strconv.Itoa(i+1) + ". " + kommsg + " :\n" + fileChangesinfo + "\n"
-			dokcommit = model.PushReport{
-				ProjectName: prj.Name,
-				Project:     prj,
-				Username:    komit.Author.Username,
-				Email:       komit.Author.Email,
-				Repo:        pyl.Compare,
-				Ref:         pyl.Ref,
-				Message:     kommsg,
-				RemoteAddr:  req.RemoteAddr,
-			}
-			if (prj.Owner.Email == komit.Author.Email) || (prj.Owner.GithubUsername == komit.Author.Username) {
-				dokcommit.User = prj.Owner
-			} else {
-				var member *model.Userdomyikado
-				member, err := getMemberByAttributeInProject(prj, "githubusername", komit.Author.Username)
-				if err != nil {
-					member, err = getMemberByAttributeInProject(prj, "email", komit.Author.Email)
-					if err != nil {
-						resp.Location = komit.Author.Email + " | " + komit.Author.Username
-						resp.Info = "Username dan Email di GitHub tidak terdaftar"
-						resp.Response = err.Error()
-						at.WriteJSON(respw, http.StatusLocked, resp)
-						return
-					}
-				}
-				dokcommit.User = *member
-			}
-			usr, err = report.TambahPoinPushRepobyGithubUsername(config.Mongoconn, prj, dokcommit, 1)
+	case gitlab.PushEventPayload:
+		deliveryID := req.Header.Get("X-Gitlab-Event-UUID")
+		if deliveryID == "" {
+			deliveryID = deliveryCadangan("gitlab", prj, pyl.After)
+		}
+		if dlv, err := getWebhookDelivery(deliveryID); err == nil {
+			at.WriteJSON(respw, dlv.StatusCode, dlv.Response)
+			return
+		}
+		status, resp := prosesPushGitlab(prj, pyl, deliveryID, req.RemoteAddr)
+		simpanWebhookDelivery(model.WebhookDelivery{
+			DeliveryID:  deliveryID,
+			Host:        "gitlab",
+			ProjectName: prj.Name,
+			Payload:     string(body),
+			StatusCode:  status,
+			Response:    resp,
+			CreatedAt:   time.Now(),
+		})
+		at.WriteJSON(respw, status, resp)
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, resp)
+}
+
+// prosesPushGitlab sama dengan prosesPushGithub untuk payload push GitLab
+func prosesPushGitlab(prj model.Project, pyl gitlab.PushEventPayload, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	var dokcommit model.PushReport
+	var commits []pushCommit
+	compare := pyl.Project.WebURL + "/-/compare/" + pyl.Before + "..." + pyl.After
+	for _, komit := range pyl.Commits {
+		//GitLab tidak mengirim jumlah baris, cukup daftar file yang diubah
+		fileChangesinfo := strings.Join(append(komit.Added, komit.Modified...), "\n")
+		kommsg := strings.TrimSpace(komit.Message)
+		//payload GitLab tidak membawa username author, username pusher dipakai jika emailnya sama
+		username := ""
+		if komit.Author.Email == pyl.UserEmail {
+			username = pyl.UserUsername
+		}
+		dokcommit = model.PushReport{
+			ProjectName: prj.Name,
+			Project:     prj,
+			Username:    username,
+			Email:       komit.Author.Email,
+			Repo:        compare,
+			Ref:         pyl.Ref,
+			Message:     kommsg,
+			RemoteAddr:  remoteAddr,
+			CommitSHA:   komit.ID,
+			DeliveryID:  deliveryID,
+			Host:        "gitlab",
+			CreatedAt:   time.Now(),
+		}
+		if (prj.Owner.Email == komit.Author.Email) || (username != "" && prj.Owner.GitlabUsername == username) {
+			dokcommit.User = prj.Owner
+		} else {
+			var member *model.Userdomyikado
+			member, err := getMemberByAttributeInProject(prj, "gitlabusername", username)
 			if err != nil {
-				usr, err = report.TambahPoinPushRepobyGithubEmail(config.Mongoconn, prj, dokcommit, 1)
+				member, err = getMemberByAttributeInProject(prj, "email", komit.Author.Email)
 				if err != nil {
-					resp.Info = "User Github: " + dokcommit.Username + " dan email github: " + dokcommit.Email + " tidak terhubung di user manapun di sistem Domyikado."
+					resp.Location = komit.Author.Email + " | " + username
+					resp.Info = "Username dan Email di GitLab tidak terdaftar"
 					resp.Response = err.Error()
-					at.WriteJSON(respw, http.StatusExpectationFailed, resp)
-					return
+					return webhookGagal(apperr.UserNotFound, resp)
 				}
 			}
-			_, err = atdb.InsertOneDoc(config.Mongoconn, "pushrepo", dokcommit)
-			if err != nil {
-				resp.Info = "Data Push" + kommsg + " tidak berhasil masuk ke database"
-				resp.Response = err.Error()
-				at.WriteJSON(respw, http.StatusExpectationFailed, resp)
-				return
-			}
-			komsg += appd
+			dokcommit.User = *member
+		}
+		commits = append(commits, pushCommit{dok: dokcommit, files: fileChangesinfo})
+	}
+	usr, komsg, baru, status, resp := simpanPushCommits(prj, commits)
+	if status != http.StatusOK || baru == 0 {
+		return status, resp
+	}
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nNama: " + dokcommit.User.Name + "\nUserGitLab: " + pyl.UserUsername + "\nRepo: " + pyl.Repository.Name + "\nBranch: " + pyl.Ref + "\n" + compare + "\n" + komsg
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
+}
+
+// pushCommit adalah commit dari payload push yang author-nya sudah ditemukan di anggota proyek
+type pushCommit struct {
+	dok   model.PushReport
+	files string //daftar file yang diubah untuk ringkasan WA
+}
+
+// simpanPushCommits baru mencatat commit setelah semua author terhubung ke user sistem,
+// sehingga payload yang ditolak tidak meninggalkan sebagian commit yang sudah diberi poin.
+// Setelah author diperbaiki payload yang sama bisa dikirim ulang dan semua commit-nya tercatat.
+func simpanPushCommits(prj model.Project, commits []pushCommit) (usr model.Userdomyikado, komsg string, baru int, status int, resp model.Response) {
+	for _, c := range commits {
+		if err := pastikanUserCommit(c.dok); err != nil {
+			resp.Location = c.dok.Email + " | " + c.dok.Username
+			resp.Info = err.Error()
+			resp.Response = err.Error()
+			status, resp = webhookGagal(apperr.UserNotFound, resp)
+			return
+		}
+	}
+	var duplikat int
+	for _, c := range commits {
+		pemilik, isDuplikat, status, resp := simpanPushCommit(prj, c.dok)
+		if status != http.StatusOK {
+			return usr, komsg, baru, status, resp
 		}
-		msg = "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nNama: " + dokcommit.User.Name + "\nUserGitHub: " + pyl.Sender.Login + "\nRepo: " + pyl.Repository.Name + "\nBranch: " + pyl.Ref + "\n" + pyl.Compare + "\n" + komsg
-		dt := &whatsauth.TextMessage{
-			To:       prj.Owner.PhoneNumber,
-			IsGroup:  false,
-			Messages: msg,
+		if isDuplikat {
+			duplikat++
+			continue
 		}
-		if prj.WAGroupID != "" {
-			dt.To = prj.WAGroupID
-			dt.IsGroup = true
+		usr = pemilik
+		baru++
+		komsg += strconv.Itoa(baru) + ". " + c.dok.Message + " :\n" + c.files + "\n"
+	}
+	resp.Info = infoPushCommit(baru, duplikat)
+	return usr, komsg, baru, http.StatusOK, resp
+}
+
+// pastikanUserCommit mencari user author commit dengan urutan yang sama dengan simpanPushCommit, username dulu lalu email
+func pastikanUserCommit(dokcommit model.PushReport) error {
+	field := "githubusername"
+	if dokcommit.Host == "gitea" || dokcommit.Host == "bitbucket" {
+		field = "githostusername"
+	}
+	for _, filter := range []primitive.M{{field: dokcommit.Username}, {"email": dokcommit.Email}} {
+		if _, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", filter); err == nil {
+			return nil
+		}
+	}
+	return errUserCommit(dokcommit)
+}
+
+func errUserCommit(dokcommit model.PushReport) error {
+	return errors.New("User Git: " + dokcommit.Username + " dan email git: " + dokcommit.Email + " tidak terhubung di user manapun di sistem Domyikado.")
+}
+
+// simpanPushCommit mencatat commit ke pushrepo lalu menambah poin pemiliknya.
+// Index unik proyek dan commitsha membuat commit yang sudah tercatat dikembalikan sebagai duplikat tanpa tambahan poin.
+func simpanPushCommit(prj model.Project, dokcommit model.PushReport) (usr model.Userdomyikado, duplikat bool, status int, resp model.Response) {
+	return simpanGitActivity("pushrepo", dokcommit, func() (model.Userdomyikado, error) {
+		tambahPoinByUsername := report.TambahPoinPushRepobyGithubUsername
+		if dokcommit.Host == "gitea" || dokcommit.Host == "bitbucket" {
+			tambahPoinByUsername = report.TambahPoinPushRepobyGitHostUsername
 		}
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		usr, err := tambahPoinByUsername(config.Mongoconn, prj, dokcommit, 1)
 		if err != nil {
-			resp.Info = "Tidak berhak"
-			resp.Response = err.Error()
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
-			return
+			usr, err = report.TambahPoinPushRepobyGithubEmail(config.Mongoconn, prj, dokcommit, 1)
+			if err != nil {
+				return usr, errUserCommit(dokcommit)
+			}
 		}
+		return usr, nil
+	})
+}
+
+// simpanGitActivity menyimpan dokumen aktivitas git lalu menjalankan tambahPoin.
+// Dokumen yang melanggar index unik dianggap sudah pernah diproses.
+// Jika poin gagal ditambahkan dokumen dihapus lagi supaya bisa diproses ulang setelah user terhubung.
+func simpanGitActivity(collection string, doc interface{}, tambahPoin func() (model.Userdomyikado, error)) (usr model.Userdomyikado, duplikat bool, status int, resp model.Response) {
+	ensureWebhookIndexes()
+	id, err := atdb.InsertOneDoc(config.Mongoconn, collection, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return usr, true, http.StatusOK, resp
+	}
+	if err != nil {
+		resp.Info = "Data " + collection + " tidak berhasil masuk ke database"
+		resp.Response = err.Error()
+		status, resp = webhookGagal(apperr.Database, resp)
+		return usr, false, status, resp
+	}
+	usr, err = tambahPoin()
+	if err != nil {
+		atdb.DeleteOneDoc(config.Mongoconn, collection, primitive.M{"_id": id})
+		resp.Info = err.Error()
+		resp.Response = err.Error()
+		status, resp = webhookGagal(apperr.UserNotFound, resp)
+		return usr, false, status, resp
+	}
+	return usr, false, http.StatusOK, resp
+}
+
+func infoPushCommit(baru, duplikat int) string {
+	info := strconv.Itoa(baru) + " commit baru tercatat"
+	if duplikat > 0 {
+		info += ", " + strconv.Itoa(duplikat) + " commit sudah pernah tercatat"
+	}
+	return info
+}
+
+// kirimGitReportWA mengirim ringkasan aktivitas git ke grup WA proyek, atau ke owner jika grup belum diisi
+func kirimGitReportWA(prj model.Project, msg string) {
+	dt := &whatsauth.TextMessage{
+		To:       prj.Owner.PhoneNumber,
+		IsGroup:  false,
+		Messages: msg,
+	}
+	if prj.WAGroupID != "" && !strings.Contains(prj.WAGroupID, "-") {
+		dt.To = prj.WAGroupID
+		dt.IsGroup = true
+	}
+	//log output
+	var logoutwa whatsauth.LogWhatsauth
+	logoutwa.Data = *dt
+	logoutwa.Token = config.WAAPIToken
+	logoutwa.URL = config.WAAPIMessage
+	logoutwa.CreatedAt = time.Now()
+	go atdb.InsertOneDoc(config.Mongoconn, "logwa", logoutwa)
+	// outbox sudah mengantre ulang jika gagal kirim, Send dipanggil langsung supaya pesan tercatat sebelum respon
+	if _, resp, err := waoutbox.Send(config.Mongoconn, dt); err != nil {
+		log.Printf("Error queueing WA message to %s: %v, info: %s", dt.To, err, resp.Info)
 	}
-	at.WriteJSON(respw, http.StatusOK, resp)
 }
 
 func getMemberByAttributeInProject(project model.Project, attribute string, value string) (*model.Userdomyikado, error) {
@@ -269,6 +475,14 @@ func getMemberByAttributeInProject(project model.Project, attribute string, valu
 			if member.GithubUsername == value {
 				return &member, nil
 			}
+		case "gitlabusername":
+			if value != "" && member.GitlabUsername == value {
+				return &member, nil
+			}
+		case "githostusername":
+			if value != "" && member.GitHostUsername == value {
+				return &member, nil
+			}
 		default:
 			return nil, errors.New("unknown attribute")
 		}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-playground/webhooks/gitlab"
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var webhookIndexOnce sync.Once
+
+// ensureWebhookIndexes membuat index unik commit per proyek, event review dan delivery, data lama tanpa commitsha tidak ikut diindex.
+// Fork atau mirror di proyek lain membawa SHA yang sama sehingga commit hanya unik di dalam satu proyek.
+func ensureWebhookIndexes() {
+	webhookIndexOnce.Do(func() {
+		pushrepo := config.Mongoconn.Collection("pushrepo")
+		_, err := pushrepo.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "project._id", Value: 1}, {Key: "commitsha", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"commitsha": bson.M{"$type": "string"}}),
+		})
+		if err != nil {
+			log.Printf("Error creating pushrepo commitsha index: %v", err)
+		} else {
+			// index lama unik per commitsha saja, diganti index per proyek dan commitsha
+			pushrepo.Indexes().DropOne(context.Background(), "commitsha_1")
+		}
+		for _, collection := range []string{"pullrequest", "pullrequestreview", "issuerepo"} {
+			_, err = config.Mongoconn.Collection(collection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+				Keys:    bson.D{{Key: "eventkey", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			if err != nil {
+				log.Printf("Error creating %s eventkey index: %v", collection, err)
+			}
+		}
+		_, err = config.Mongoconn.Collection("webhookdelivery").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "deliveryid", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating webhookdelivery index: %v", err)
+		}
+	})
+}
+
+func getWebhookDelivery(deliveryID string) (model.WebhookDelivery, error) {
+	ensureWebhookIndexes()
+	return atdb.GetOneDoc[model.WebhookDelivery](config.Mongoconn, "webhookdelivery", bson.M{"deliveryid": deliveryID})
+}
+
+// simpanWebhookDelivery gagal dengan duplicate key jika delivery yang sama diproses bersamaan, cukup dicatat di log
+func simpanWebhookDelivery(dlv model.WebhookDelivery) {
+	_, err := atdb.InsertOneDoc(config.Mongoconn, "webhookdelivery", dlv)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Printf("Error saving webhook delivery %s: %v", dlv.DeliveryID, err)
+	}
+}
+
+// ProyekWebhookDelivery adalah scope RedeliverWebHook, ID proyek pemilik delivery atau "" jika tidak ditemukan
+func ProyekWebhookDelivery(req *http.Request) string {
+	dlv, err := getWebhookDelivery(at.GetParam(req))
+	if err != nil {
+		return ""
+	}
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", bson.M{"name": dlv.ProjectName})
+	if err != nil {
+		return ""
+	}
+	return prj.ID.Hex()
+}
+
+// RedeliverWebHook memproses ulang payload delivery yang tersimpan, butuh permission kelola proyek
+// dengan scope ID proyek delivery (pemilik proyek atau owner sistem).
+// Dipakai setelah penyebab gagal diperbaiki, misalnya anggota belum terdaftar.
+// Commit dan event yang sudah tercatat tetap dilewati sehingga poin tidak bertambah dua kali.
+func RedeliverWebHook(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	dlv, err := getWebhookDelivery(at.GetParam(req))
+	if err != nil {
+		respn.Status = "Error : Delivery tidak ditemukan"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, respn))
+		return
+	}
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", bson.M{"name": dlv.ProjectName})
+	if err != nil {
+		respn.Status = "Error : Project tidak ditemukan"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
+		return
+	}
+
+	var status int
+	switch dlv.Host {
+	case "github":
+		var pyl interface{}
+		if pyl, err = parseGithubPayload(dlv.Event, []byte(dlv.Payload)); err == nil {
+			status, respn = prosesGithubEvent(prj, pyl, dlv.DeliveryID, req.RemoteAddr)
+		}
+	case "gitlab":
+		var pyl gitlab.PushEventPayload
+		if err = json.Unmarshal([]byte(dlv.Payload), &pyl); err == nil {
+			status, respn = prosesPushGitlab(prj, pyl, dlv.DeliveryID, req.RemoteAddr)
+		}
+	case "gitea", "bitbucket":
+		var push gitHostPush
+		if push, err = parseGitHostPush(dlv.Host, []byte(dlv.Payload)); err == nil {
+			status, respn = prosesPushGitHost(prj, push, dlv.DeliveryID, req.RemoteAddr)
+		}
+	default:
+		respn.Status = "Error : Host tidak dikenal"
+		respn.Response = dlv.Host
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, respn))
+		return
+	}
+	if err != nil {
+		respn.Status = "Error : Payload tidak valid"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, respn))
+		return
+	}
+
+	//hasil terbaru yang dikembalikan jika git host mengirim delivery ini lagi
+	_, err = atdb.UpdateOneDoc(config.Mongoconn, "webhookdelivery", bson.M{"deliveryid": dlv.DeliveryID}, bson.M{
+		"statuscode":    status,
+		"response":      respn,
+		"redeliveredAt": time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error updating webhook delivery %s: %v", dlv.DeliveryID, err)
+	}
+	at.WriteJSON(respw, status, respn)
+}
@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/webhooks/v6/gitea"
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// gitHostPush adalah payload push Gitea/Forgejo atau Bitbucket yang sudah diseragamkan
+type gitHostPush struct {
+	Host    string
+	Pusher  string
+	Repo    string
+	Ref     string
+	Compare string
+	Commits []gitHostCommit
+}
+
+type gitHostCommit struct {
+	SHA      string
+	Message  string
+	Username string //dicocokkan dengan githostusername
+	Email    string
+	Files    []string
+}
+
+// PostWebHookGitea menerima push dari Gitea atau Forgejo, Forgejo juga mengirim header X-Gitea-*
+func PostWebHookGitea(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": at.GetParam(req)})
+	if err != nil {
+		resp.Info = "Tidak terdaftar"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, resp))
+		return
+	}
+	hook, err := gitea.New(gitea.Options.Secret(prj.Secret))
+	if err != nil {
+		resp.Info = "Tidak berhak"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, resp))
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		resp.Info = "Tidak ada payload"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	payload, err := hook.Parse(req, gitea.PushEvent)
+	if err != nil {
+		resp.Info = "Tidak ada payload"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
+		return
+	}
+	pyl, ok := payload.(gitea.PushPayload)
+	if !ok {
+		at.WriteJSON(respw, http.StatusOK, resp)
+		return
+	}
+	deliveryID := req.Header.Get("X-Gitea-Delivery")
+	if deliveryID == "" {
+		deliveryID = deliveryCadangan("gitea", prj, pyl.After)
+	}
+	terimaPushGitHost(respw, prj, giteaPush(pyl), deliveryID, body, req.RemoteAddr)
+}
+
+// PostWebHookBitbucket menerima repo:push dari Bitbucket Cloud.
+// Signature X-Hub-Signature (HMAC SHA256 dari body) diverifikasi dengan Project.Secret.
+func PostWebHookBitbucket(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": at.GetParam(req)})
+	if err != nil {
+		resp.Info = "Tidak terdaftar"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.ProjectNotFound, resp))
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil || len(body) == 0 {
+		resp.Info = "Tidak ada payload"
+		resp.Response = "payload kosong"
+		at.WriteError(respw, req, apperr.FromResponse(apperr.BadRequest, resp))
+		return
+	}
+	if err = verifyHubSignature(prj.Secret, req.Header.Get("X-Hub-Signature"), body); err != nil {
+		resp.Info = "Tidak berhak"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, resp))
+		return
+	}
+	if req.Header.Get("X-Event-Key") != "repo:push" {
+		resp.Info = "Event tidak diproses"
+		resp.Response = req.Header.Get("X-Event-Key")
+		at.WriteJSON(respw, http.StatusOK, resp)
+		return
+	}
+	var pyl model.BitbucketPushPayload
+	if err = json.Unmarshal(body, &pyl); err != nil {
+		resp.Info = "Tidak ada payload"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidBody, resp))
+		return
+	}
+	push := bitbucketPush(pyl)
+	deliveryID := req.Header.Get("X-Request-UUID")
+	if deliveryID == "" && len(push.Commits) > 0 {
+		deliveryID = deliveryCadangan("bitbucket", prj, push.Commits[0].SHA)
+	}
+	terimaPushGitHost(respw, prj, push, deliveryID, body, req.RemoteAddr)
+}
+
+// terimaPushGitHost menjawab delivery yang diulang dari hasil tersimpan, selain itu memproses push lalu menyimpan delivery
+func terimaPushGitHost(respw http.ResponseWriter, prj model.Project, push gitHostPush, deliveryID string, body []byte, remoteAddr string) {
+	if dlv, err := getWebhookDelivery(deliveryID); err == nil {
+		at.WriteJSON(respw, dlv.StatusCode, dlv.Response)
+		return
+	}
+	status, resp := prosesPushGitHost(prj, push, deliveryID, remoteAddr)
+	simpanWebhookDelivery(model.WebhookDelivery{
+		DeliveryID:  deliveryID,
+		Host:        push.Host,
+		Event:       "push",
+		ProjectName: prj.Name,
+		Payload:     string(body),
+		StatusCode:  status,
+		Response:    resp,
+		CreatedAt:   time.Now(),
+	})
+	at.WriteJSON(respw, status, resp)
+}
+
+// prosesPushGitHost sama dengan prosesPushGithub, author dipetakan lewat GitHostUsername lalu email
+func prosesPushGitHost(prj model.Project, push gitHostPush, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	var dokcommit model.PushReport
+	var commits []pushCommit
+	for _, komit := range push.Commits {
+		kommsg := strings.TrimSpace(komit.Message)
+		dokcommit = model.PushReport{
+			ProjectName: prj.Name,
+			Project:     prj,
+			Username:    komit.Username,
+			Email:       komit.Email,
+			Repo:        push.Compare,
+			Ref:         push.Ref,
+			Message:     kommsg,
+			RemoteAddr:  remoteAddr,
+			CommitSHA:   komit.SHA,
+			DeliveryID:  deliveryID,
+			Host:        push.Host,
+			CreatedAt:   time.Now(),
+		}
+		if (komit.Email != "" && prj.Owner.Email == komit.Email) || (komit.Username != "" && prj.Owner.GitHostUsername == komit.Username) {
+			dokcommit.User = prj.Owner
+		} else {
+			var member *model.Userdomyikado
+			member, err := getMemberByAttributeInProject(prj, "githostusername", komit.Username)
+			if err != nil {
+				member, err = getMemberByAttributeInProject(prj, "email", komit.Email)
+				if err != nil {
+					resp.Location = komit.Email + " | " + komit.Username
+					resp.Info = "Username dan Email di " + push.Host + " tidak terdaftar"
+					resp.Response = err.Error()
+					return webhookGagal(apperr.UserNotFound, resp)
+				}
+			}
+			dokcommit.User = *member
+		}
+		commits = append(commits, pushCommit{dok: dokcommit, files: strings.Join(komit.Files, "\n")})
+	}
+	usr, komsg, baru, status, resp := simpanPushCommits(prj, commits)
+	if status != http.StatusOK || baru == 0 {
+		return status, resp
+	}
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nNama: " + dokcommit.User.Name + "\nUser " + push.Host + ": " + push.Pusher + "\nRepo: " + push.Repo + "\nBranch: " + push.Ref + "\n" + push.Compare + "\n" + komsg
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
+}
+
+func giteaPush(pyl gitea.PushPayload) (push gitHostPush) {
+	push = gitHostPush{
+		Host:    "gitea",
+		Ref:     pyl.Ref,
+		Compare: pyl.CompareURL,
+	}
+	if pyl.Pusher != nil {
+		push.Pusher = pyl.Pusher.UserName
+	}
+	if pyl.Repo != nil {
+		push.Repo = pyl.Repo.Name
+	}
+	for _, komit := range pyl.Commits {
+		if komit == nil {
+			continue
+		}
+		c := gitHostCommit{
+			SHA:     komit.ID,
+			Message: komit.Message,
+			Files:   append(komit.Added, komit.Modified...),
+		}
+		if komit.Author != nil {
+			c.Username = komit.Author.UserName
+			c.Email = komit.Author.Email
+		}
+		push.Commits = append(push.Commits, c)
+	}
+	return
+}
+
+func bitbucketPush(pyl model.BitbucketPushPayload) (push gitHostPush) {
+	push = gitHostPush{
+		Host:   "bitbucket",
+		Pusher: pyl.Actor.Nickname,
+		Repo:   pyl.Repository.Name,
+	}
+	for _, change := range pyl.Push.Changes {
+		//perubahan tanpa New berarti branch dihapus
+		if change.New == nil {
+			continue
+		}
+		push.Ref = change.New.Name
+		push.Compare = change.Links.HTML.Href
+		for _, komit := range change.Commits {
+			c := gitHostCommit{
+				SHA:     komit.Hash,
+				Message: komit.Message,
+			}
+			if komit.Author.User != nil {
+				c.Username = komit.Author.User.Nickname
+			}
+			if addr, err := mail.ParseAddress(komit.Author.Raw); err == nil {
+				c.Email = addr.Address
+			}
+			push.Commits = append(push.Commits, c)
+		}
+	}
+	return
+}
+
+// parseGitHostPush mengubah body delivery Gitea atau Bitbucket yang tersimpan untuk dikirim ulang
+func parseGitHostPush(host string, body []byte) (push gitHostPush, err error) {
+	switch host {
+	case "gitea":
+		var pyl gitea.PushPayload
+		if err = json.Unmarshal(body, &pyl); err == nil {
+			push = giteaPush(pyl)
+		}
+	case "bitbucket":
+		var pyl model.BitbucketPushPayload
+		if err = json.Unmarshal(body, &pyl); err == nil {
+			push = bitbucketPush(pyl)
+		}
+	default:
+		err = errors.New("host " + host + " tidak didukung")
+	}
+	return
+}
+
+// verifyHubSignature mencocokkan header "sha256=<hex>" dengan HMAC body, dilewati jika secret kosong
+func verifyHubSignature(secret, signature string, body []byte) error {
+	if secret == "" {
+		return nil
+	}
+	if signature == "" {
+		return errors.New("missing X-Hub-Signature Header")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("HMAC verification failed")
+	}
+	return nil
+}
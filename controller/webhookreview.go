@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/webhooks/v6/github"
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/report"
+	"github.com/gocroot/model"
+)
+
+// prosesPullRequestGithub mencatat PR yang dibuka atau di-merge dan memberi poin ke author PR
+func prosesPullRequestGithub(prj model.Project, pyl github.PullRequestPayload, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	action := pyl.Action
+	if action == "closed" && pyl.PullRequest.Merged {
+		action = "merged"
+	}
+	poin := report.PoinPullRequest(action)
+	if poin == 0 {
+		resp.Info = "Aksi pull request " + pyl.Action + " tidak dihitung"
+		return http.StatusOK, resp
+	}
+	member, status, resp := githubMemberInProject(prj, pyl.PullRequest.User.Login)
+	if status != http.StatusOK {
+		return status, resp
+	}
+	dok := model.PullRequestReport{
+		ProjectName: prj.Name,
+		Project:     prj,
+		User:        member,
+		Username:    pyl.PullRequest.User.Login,
+		Repo:        pyl.Repository.Name,
+		URL:         pyl.PullRequest.HTMLURL,
+		Number:      pyl.Number,
+		Title:       pyl.PullRequest.Title,
+		Action:      action,
+		Poin:        poin,
+		EventKey:    strconv.FormatInt(pyl.PullRequest.ID, 10) + ":" + action,
+		DeliveryID:  deliveryID,
+		RemoteAddr:  remoteAddr,
+		CreatedAt:   time.Now(),
+	}
+	usr, duplikat, status, resp := simpanGitActivity("pullrequest", dok, func() (model.Userdomyikado, error) {
+		return report.TambahPoinPullRequestbyGithubUsername(config.Mongoconn, prj, dok)
+	})
+	if status != http.StatusOK {
+		return status, resp
+	}
+	if duplikat {
+		resp.Info = "Pull request sudah pernah tercatat"
+		return http.StatusOK, resp
+	}
+	resp.Info = "Pull request " + action + " tercatat"
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nPull Request #" + strconv.FormatInt(dok.Number, 10) + " " + action + " (+" + strconv.Itoa(int(poin)) + " poin)\nJudul: " + dok.Title + "\nUserGitHub: " + dok.Username + "\nRepo: " + dok.Repo + "\n" + dok.URL
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
+}
+
+// prosesPullRequestReviewGithub mencatat review yang dikirim dan memberi poin ke reviewer.
+// Review pada PR milik sendiri tidak dihitung.
+func prosesPullRequestReviewGithub(prj model.Project, pyl github.PullRequestReviewPayload, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	if pyl.Action != "submitted" {
+		resp.Info = "Aksi review " + pyl.Action + " tidak dihitung"
+		return http.StatusOK, resp
+	}
+	reviewer := pyl.Review.User.Login
+	if reviewer == pyl.PullRequest.User.Login {
+		resp.Info = "Review pada pull request sendiri tidak dihitung"
+		return http.StatusOK, resp
+	}
+	state := strings.ToLower(pyl.Review.State)
+	poin := report.PoinPullRequestReview(state)
+	if poin == 0 {
+		resp.Info = "State review " + pyl.Review.State + " tidak dihitung"
+		return http.StatusOK, resp
+	}
+	member, status, resp := githubMemberInProject(prj, reviewer)
+	if status != http.StatusOK {
+		return status, resp
+	}
+	dok := model.PullRequestReviewReport{
+		ProjectName: prj.Name,
+		Project:     prj,
+		User:        member,
+		Username:    reviewer,
+		Repo:        pyl.Repository.Name,
+		URL:         pyl.Review.HTMLURL,
+		PRNumber:    pyl.PullRequest.Number,
+		PRTitle:     pyl.PullRequest.Title,
+		PRAuthor:    pyl.PullRequest.User.Login,
+		State:       state,
+		Body:        strings.TrimSpace(pyl.Review.Body),
+		Poin:        poin,
+		EventKey:    strconv.FormatInt(pyl.Review.ID, 10),
+		DeliveryID:  deliveryID,
+		RemoteAddr:  remoteAddr,
+		CreatedAt:   time.Now(),
+	}
+	usr, duplikat, status, resp := simpanGitActivity("pullrequestreview", dok, func() (model.Userdomyikado, error) {
+		return report.TambahPoinPullRequestReviewbyGithubUsername(config.Mongoconn, prj, dok)
+	})
+	if status != http.StatusOK {
+		return status, resp
+	}
+	if duplikat {
+		resp.Info = "Review sudah pernah tercatat"
+		return http.StatusOK, resp
+	}
+	resp.Info = "Review " + state + " tercatat"
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nReview " + state + " (+" + strconv.Itoa(int(poin)) + " poin)\nPull Request #" + strconv.FormatInt(dok.PRNumber, 10) + ": " + dok.PRTitle + "\nAuthor PR: " + dok.PRAuthor + "\nReviewer: " + reviewer + "\nRepo: " + dok.Repo + "\n" + dok.URL
+	if dok.Body != "" {
+		msg += "\n> " + dok.Body
+	}
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
+}
+
+// prosesIssueGithub mencatat issue yang dibuka (poin ke pembuat) atau ditutup (poin ke yang menutup)
+func prosesIssueGithub(prj model.Project, pyl github.IssuesPayload, deliveryID, remoteAddr string) (status int, resp model.Response) {
+	poin := report.PoinIssue(pyl.Action)
+	if poin == 0 {
+		resp.Info = "Aksi issue " + pyl.Action + " tidak dihitung"
+		return http.StatusOK, resp
+	}
+	username := pyl.Issue.User.Login
+	if pyl.Action == "closed" {
+		username = pyl.Sender.Login
+	}
+	member, status, resp := githubMemberInProject(prj, username)
+	if status != http.StatusOK {
+		return status, resp
+	}
+	dok := model.IssueReport{
+		ProjectName: prj.Name,
+		Project:     prj,
+		User:        member,
+		Username:    username,
+		Repo:        pyl.Repository.Name,
+		URL:         pyl.Issue.HTMLURL,
+		Number:      pyl.Issue.Number,
+		Title:       pyl.Issue.Title,
+		Action:      pyl.Action,
+		Poin:        poin,
+		EventKey:    strconv.FormatInt(pyl.Issue.ID, 10) + ":" + pyl.Action,
+		DeliveryID:  deliveryID,
+		RemoteAddr:  remoteAddr,
+		CreatedAt:   time.Now(),
+	}
+	usr, duplikat, status, resp := simpanGitActivity("issuerepo", dok, func() (model.Userdomyikado, error) {
+		return report.TambahPoinIssuebyGithubUsername(config.Mongoconn, prj, dok)
+	})
+	if status != http.StatusOK {
+		return status, resp
+	}
+	if duplikat {
+		resp.Info = "Issue sudah pernah tercatat"
+		return http.StatusOK, resp
+	}
+	resp.Info = "Issue " + pyl.Action + " tercatat"
+	msg := "*" + prj.Name + "*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nIssue #" + strconv.FormatInt(dok.Number, 10) + " " + pyl.Action + " (+" + strconv.Itoa(int(poin)) + " poin)\nJudul: " + dok.Title + "\nUserGitHub: " + username + "\nRepo: " + dok.Repo + "\n" + dok.URL
+	kirimGitReportWA(prj, msg)
+	return http.StatusOK, resp
+}
+
+// githubMemberInProject mencari owner atau anggota proyek dengan username GitHub tersebut
+func githubMemberInProject(prj model.Project, login string) (member model.Userdomyikado, status int, resp model.Response) {
+	if login != "" && prj.Owner.GithubUsername == login {
+		return prj.Owner, http.StatusOK, resp
+	}
+	anggota, err := getMemberByAttributeInProject(prj, "githubusername", login)
+	if err != nil {
+		resp.Location = login
+		resp.Info = "Username GitHub tidak terdaftar"
+		resp.Response = err.Error()
+		status, resp = webhookGagal(apperr.UserNotFound, resp)
+		return member, status, resp
+	}
+	return *anggota, http.StatusOK, resp
+}
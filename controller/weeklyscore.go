@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var weeklyScoreIndexOnce sync.Once
+
+// ensureWeeklyScoreIndexes membuat satu snapshot per user per minggu periode akademik
+func ensureWeeklyScoreIndexes() {
+	weeklyScoreIndexOnce.Do(func() {
+		_, err := config.Mongoconn.Collection("weeklyscore").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "phonenumber", Value: 1}, {Key: "year", Value: 1}, {Key: "week", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "weekstart", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating weeklyscore indexes: %v", err)
+		}
+	})
+}
+
+// SnapshotWeeklyScore menyimpan skor mingguan semua mahasiswa untuk minggu periode akademik yang memuat now.
+// Snapshot yang sudah ada tidak ditimpa sehingga job aman dijalankan ulang dalam minggu yang sama.
+// User yang salah satu sumber skornya gagal atau timeout tidak dibekukan dan dihitung sebagai tertunda,
+// snapshotnya dibuat oleh run berikutnya dalam minggu yang sama.
+// Cron dipasang beberapa kali sebelum cutoff periode (default Senin 17:01 WIB) agar yang dibekukan adalah minggu yang sedang ditutup.
+func SnapshotWeeklyScore(now time.Time) (dibuat, dilewati, tertunda int, err error) {
+	ensureWeeklyScoreIndexes()
+	weekStart, _ := periode.Get(config.Mongoconn).Minggu(now, "")
+	year, week := weekStart.ISOWeek()
+
+	users, err := atdb.GetAllDoc[[]model.Userdomyikado](config.Mongoconn, "user", bson.M{
+		"phonenumber": bson.M{"$exists": true, "$ne": ""},
+		"isdosen":     bson.M{"$ne": true},
+	})
+	if err != nil {
+		return
+	}
+	for _, usr := range users {
+		filter := bson.M{"phonenumber": usr.PhoneNumber, "year": year, "week": week}
+		if _, errdoc := atdb.GetOneDoc[model.WeeklyScoreSnapshot](config.Mongoconn, "weeklyscore", filter); errdoc == nil {
+			dilewati++
+			continue
+		}
+		score, errscore := GetLastWeekActivityScoreData(usr.PhoneNumber)
+		if gagal := sumberGagal(score, errscore); gagal != "" {
+			log.Printf("Weekly score %s ditunda: %s", usr.PhoneNumber, gagal)
+			tertunda++
+			continue
+		}
+		score.Username = usr.Name
+		score.PhoneNumber = usr.PhoneNumber
+		snapshot := model.WeeklyScoreSnapshot{
+			PhoneNumber:   usr.PhoneNumber,
+			Name:          usr.Name,
+			Enroll:        GetEnrollFromProject(usr.PhoneNumber),
+			Year:          year,
+			Week:          week,
+			WeekLabel:     fmt.Sprintf("%d-W%02d", year, week),
+			WeekStart:     weekStart,
+			ActivityScore: score,
+			CreatedAt:     time.Now(),
+		}
+		//$setOnInsert menjaga snapshot pertama jika job berjalan bersamaan
+		res, errupd := config.Mongoconn.Collection("weeklyscore").UpdateOne(context.Background(), filter,
+			bson.M{"$setOnInsert": snapshot}, options.Update().SetUpsert(true))
+		if errupd != nil {
+			log.Printf("Error saving weekly score %s: %v", usr.PhoneNumber, errupd)
+			continue
+		}
+		if res.UpsertedCount == 0 {
+			dilewati++
+			continue
+		}
+		dibuat++
+	}
+	return
+}
+
+// sumberGagal menjelaskan kenapa skor belum lengkap, kosong jika semua sumber berhasil
+func sumberGagal(score model.ActivityScore, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	var gagal []string
+	for _, src := range score.Sources {
+		if src.Status != model.SourceOK {
+			gagal = append(gagal, src.Source+" "+src.Status)
+		}
+	}
+	return strings.Join(gagal, ", ")
+}
+
+// RefreshWeeklyScoreSnapshot dipanggil cron mingguan, proses berjalan di background
+func RefreshWeeklyScoreSnapshot(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	errChan := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		dibuat, dilewati, tertunda, err := SnapshotWeeklyScore(time.Now())
+		if err != nil {
+			errChan <- err
+			return
+		}
+		log.Printf("Weekly score snapshot: %d dibuat, %d sudah ada, %d tertunda", dibuat, dilewati, tertunda)
+		close(done)
+	}()
+
+	resp.Location = "Snapshot Skor Mingguan"
+	select {
+	case <-done:
+		resp.Status = "Success"
+		resp.Response = "Snapshot skor mingguan berhasil disimpan"
+		at.WriteJSON(respw, http.StatusOK, resp)
+	case err := <-errChan:
+		resp.Status = "Error"
+		resp.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Internal, resp))
+	case <-time.After(2 * time.Second):
+		resp.Status = "Success"
+		resp.Response = "Snapshot skor mingguan telah dimulai dan sedang berjalan di background"
+		at.WriteJSON(respw, http.StatusOK, resp)
+	}
+}
+
+// GetActivityScoreHistory mengembalikan snapshot mingguan dalam rentang ?from=YYYY-MM-DD&to=YYYY-MM-DD.
+// Mahasiswa melihat miliknya sendiri, dosen dan owner bisa memakai ?phonenumber= untuk mahasiswa lain,
+// asesor dengan role terbatas hanya untuk mahasiswa di proyek atau enroll tempat role-nya berlaku.
+func GetActivityScoreHistory(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	if err != nil {
+		respn.Status = "Error : Token Tidak Valid"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.TokenInvalid, respn))
+		return
+	}
+
+	phonenumber := payload.Id
+	if target := req.URL.Query().Get("phonenumber"); target != "" && target != payload.Id {
+		if !bolehLihatSkor(payload.Id, target) {
+			respn.Status = "Error : Akses Ditolak"
+			respn.Response = "Hanya dosen yang dapat melihat riwayat skor mahasiswa lain"
+			at.WriteError(respw, req, apperr.FromResponse(apperr.Forbidden, respn))
+			return
+		}
+		phonenumber = target
+	}
+
+	p := periode.Get(config.Mongoconn)
+	loc := p.Location()
+	weekstart := bson.M{}
+	if from := req.URL.Query().Get("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			respn.Status = "Error : Parameter from tidak valid"
+			respn.Response = err.Error()
+			at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
+			return
+		}
+		weekstart["$gte"], _ = p.Minggu(t, "")
+	}
+	if to := req.URL.Query().Get("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			respn.Status = "Error : Parameter to tidak valid"
+			respn.Response = err.Error()
+			at.WriteError(respw, req, apperr.FromResponse(apperr.InvalidParam, respn))
+			return
+		}
+		weekstart["$lte"] = t
+	}
+	filter := bson.M{"phonenumber": phonenumber}
+	if len(weekstart) > 0 {
+		filter["weekstart"] = weekstart
+	}
+
+	cursor, err := config.Mongoconn.Collection("weeklyscore").Find(context.Background(), filter, options.Find().SetSort(bson.M{"weekstart": 1}))
+	if err != nil {
+		respn.Status = "Error : Gagal mengambil riwayat skor"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	history := []model.WeeklyScoreSnapshot{}
+	if err = cursor.All(context.Background(), &history); err != nil {
+		respn.Status = "Error : Gagal membaca riwayat skor"
+		respn.Response = err.Error()
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Database, respn))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, history)
+}
+
+// bolehLihatSkor mengecek LihatSkorMahasiswa secara global, lalu pada setiap proyek mahasiswa dan enroll proyek tersebut
+func bolehLihatSkor(phonenumber, mahasiswa string) bool {
+	roles, err := rbac.Roles(config.Mongoconn, phonenumber)
+	if err != nil {
+		log.Printf("Error loading roles %s: %v", phonenumber, err)
+		return false
+	}
+	if rbac.Has(roles, rbac.LihatSkorMahasiswa, "") {
+		return true
+	}
+	prjs, err := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", bson.M{"$or": bson.A{
+		bson.M{"members.phonenumber": mahasiswa},
+		bson.M{"owner.phonenumber": mahasiswa},
+	}})
+	if err != nil {
+		log.Printf("Error getting projects of %s: %v", mahasiswa, err)
+		return false
+	}
+	for _, prj := range prjs {
+		if rbac.Has(roles, rbac.LihatSkorMahasiswa, prj.ID.Hex()) || (prj.Enroll != "" && rbac.Has(roles, rbac.LihatSkorMahasiswa, prj.Enroll)) {
+			return true
+		}
+	}
+	return false
+}
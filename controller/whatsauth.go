@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
@@ -23,37 +24,37 @@ func GetHome(respw http.ResponseWriter, req *http.Request) {
 func PostInboxNomor(respw http.ResponseWriter, req *http.Request) {
 	var resp whatsauth.Response
 	var msg whatsauth.IteungMessage
-	httpstatus := http.StatusUnauthorized
-	resp.Response = "Wrong Secret"
 	waphonenumber := at.GetParam(req)
 	prof, err := whatsauth.GetAppProfile(waphonenumber, config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		httpstatus = http.StatusServiceUnavailable
+		at.WriteError(respw, req, apperr.Wrap(apperr.NotFound, "Error : Profile tidak ditemukan", err))
+		return
 	}
-	if at.GetSecretFromHeader(req) == prof.Secret {
-		err := json.NewDecoder(req.Body).Decode(&msg)
-		if err != nil {
-			resp.Response = err.Error()
-		} else {
-			resp, err = whatsauth.WebHook(prof.QRKeyword, waphonenumber, config.WAAPIQRLogin, config.WAAPIMessage, msg, config.Mongoconn)
-			if err != nil {
-				resp.Response = err.Error()
-			}
-		}
+	if at.GetSecretFromHeader(req) != prof.Secret {
+		at.WriteError(respw, req, apperr.New(apperr.InvalidCredentials, "Wrong Secret", ""))
+		return
 	}
-	at.WriteJSON(respw, httpstatus, resp)
+	err = json.NewDecoder(req.Body).Decode(&msg)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Wrap(apperr.InvalidBody, "Error : Body tidak valid", err))
+		return
+	}
+	resp, err = whatsauth.WebHook(prof.QRKeyword, waphonenumber, config.WAAPIQRLogin, config.WAAPIMessage, msg, config.Mongoconn)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Wrap(apperr.Upstream, "Error : Gagal memproses pesan masuk", err))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, resp)
 }
 
 // jalan setiap jam 3 pagi
 func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 	var resp model.Response
-	httpstatus := http.StatusServiceUnavailable
 
 	var wg sync.WaitGroup
 	wg.Add(3) // Menambahkan jumlah goroutine yang akan dijalankan
 
-	// Mutex untuk mengamankan akses ke variabel resp dan httpstatus
+	// Mutex untuk mengamankan akses ke variabel resp
 	var mu sync.Mutex
 	// Variabel untuk menyimpan kesalahan terakhir
 	var lastErr error
@@ -79,13 +80,11 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 				mu.Lock()
 				lastErr = err
 				resp.Response = err.Error()
-				httpstatus = http.StatusInternalServerError
 				mu.Unlock()
 				continue // Lanjutkan ke iterasi berikutnya
 			}
 			mu.Lock()
 			resp.Response = at.Jsonstr(res.ModifiedCount)
-			httpstatus = http.StatusOK
 			mu.Unlock()
 		}
 	}()
@@ -97,7 +96,6 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 			mu.Lock()
 			lastErr = err
 			resp.Response = err.Error()
-			httpstatus = http.StatusInternalServerError
 			mu.Unlock()
 		}
 	}()
@@ -109,7 +107,6 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 			mu.Lock()
 			lastErr = err
 			resp.Response = err.Error()
-			httpstatus = http.StatusInternalServerError
 			mu.Unlock()
 		}
 	}()
@@ -128,9 +125,9 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 
 	wg.Wait() // Menunggu sampai semua goroutine selesai
 
-	// Menggunakan status yang benar dari kesalahan terakhir jika ada
+	// Kesalahan terakhir dikembalikan sebagai error layanan eksternal
 	if lastErr != nil {
-		at.WriteJSON(respw, httpstatus, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 	} else {
 		at.WriteJSON(respw, http.StatusOK, resp)
 	}
@@ -206,7 +203,7 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 func NotFound(respw http.ResponseWriter, req *http.Request) {
 	var resp model.Response
 	resp.Response = "Not Found"
-	at.WriteJSON(respw, http.StatusNotFound, resp)
+	at.WriteError(respw, req, apperr.FromResponse(apperr.NotFound, resp))
 }
 
 // func ForOrangTua(respw http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,216 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocroot/model"
+)
+
+// Code adalah kode error yang stabil, frontend cukup membandingkan kode ini tanpa membaca teks status
+type Code string
+
+const (
+	BadRequest         Code = "BAD_REQUEST"
+	InvalidBody        Code = "INVALID_BODY"
+	InvalidParam       Code = "INVALID_PARAM"
+	TokenInvalid       Code = "TOKEN_INVALID"
+	InvalidCredentials Code = "INVALID_CREDENTIALS"
+	Forbidden          Code = "FORBIDDEN"
+	NotFound           Code = "NOT_FOUND"
+	UserNotFound       Code = "USER_NOT_FOUND"
+	ProjectNotFound    Code = "PROJECT_NOT_FOUND"
+	Conflict           Code = "CONFLICT"
+	Expired            Code = "EXPIRED"
+	PreconditionFailed Code = "PRECONDITION_FAILED"
+	RateLimited        Code = "RATE_LIMITED"
+	Internal           Code = "INTERNAL"
+	Database           Code = "DATABASE_ERROR"
+	Upstream           Code = "UPSTREAM_ERROR"
+)
+
+// Bahasa pesan yang didukung, id adalah default
+const (
+	ID = "id"
+	EN = "en"
+)
+
+type entry struct {
+	status int
+	id     string
+	en     string
+}
+
+// catalog menentukan satu HTTP status dan pesan per kode, sehingga kode yang sama selalu dibalas dengan status yang sama
+var catalog = map[Code]entry{
+	BadRequest:         {http.StatusBadRequest, "Permintaan tidak valid", "Invalid request"},
+	InvalidBody:        {http.StatusBadRequest, "Body permintaan tidak dapat dibaca", "Request body could not be parsed"},
+	InvalidParam:       {http.StatusBadRequest, "Parameter tidak valid", "Invalid parameter"},
+	TokenInvalid:       {http.StatusUnauthorized, "Token login tidak valid, silakan login kembali", "Login token is invalid, please log in again"},
+	InvalidCredentials: {http.StatusUnauthorized, "Kredensial tidak valid", "Invalid credentials"},
+	Forbidden:          {http.StatusForbidden, "Akses ditolak", "Access denied"},
+	NotFound:           {http.StatusNotFound, "Data tidak ditemukan", "Data not found"},
+	UserNotFound:       {http.StatusNotFound, "User tidak ditemukan", "User not found"},
+	ProjectNotFound:    {http.StatusNotFound, "Proyek tidak ditemukan", "Project not found"},
+	Conflict:           {http.StatusConflict, "Data sudah ada atau bentrok dengan data lain", "Data already exists or conflicts with other data"},
+	Expired:            {http.StatusGone, "Sudah kedaluwarsa", "Expired"},
+	PreconditionFailed: {http.StatusPreconditionFailed, "Syarat belum terpenuhi", "Requirement not met"},
+	RateLimited:        {http.StatusTooManyRequests, "Terlalu banyak permintaan, coba lagi nanti", "Too many requests, try again later"},
+	Internal:           {http.StatusInternalServerError, "Terjadi kesalahan pada server", "Internal server error"},
+	Database:           {http.StatusInternalServerError, "Gagal mengakses database", "Database operation failed"},
+	Upstream:           {http.StatusBadGateway, "Layanan eksternal gagal merespon", "External service failed to respond"},
+}
+
+// Codes adalah semua kode yang terdaftar, dipakai dokumentasi OpenAPI
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// HTTPStatus adalah status HTTP baku untuk kode, kode yang tidak dikenal dianggap Internal
+func (c Code) HTTPStatus() int {
+	if e, ok := catalog[c]; ok {
+		return e.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message adalah pesan kode dalam bahasa lang, selain en memakai bahasa Indonesia
+func (c Code) Message(lang string) string {
+	e, ok := catalog[c]
+	if !ok {
+		e = catalog[Internal]
+	}
+	if lang == EN {
+		return e.en
+	}
+	return e.id
+}
+
+// Error membawa kode beserta teks status dan detail lama supaya client lama yang membaca status dan response tetap jalan
+type Error struct {
+	Code     Code
+	Status   string
+	Detail   string
+	Info     string
+	Location string
+	Data     any
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code)
+	if e.Status != "" {
+		msg += ": " + e.Status
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New membuat error dengan teks status dan detail, contoh apperr.New(apperr.NotFound, "Error : Data event tidak ditemukan", err.Error())
+func New(code Code, status, detail string) *Error {
+	return &Error{Code: code, Status: status, Detail: detail}
+}
+
+// Wrap membungkus err, detail diambil dari pesan err
+func Wrap(code Code, status string, err error) *Error {
+	e := &Error{Code: code, Status: status, Err: err}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	return e
+}
+
+// FromResponse memberi kode pada model.Response yang sudah diisi handler
+func FromResponse(code Code, resp model.Response) *Error {
+	return &Error{
+		Code:     code,
+		Status:   resp.Status,
+		Detail:   resp.Response,
+		Info:     resp.Info,
+		Location: resp.Location,
+		Data:     resp.Data,
+	}
+}
+
+// Ensure mempertahankan kode jika err sudah *Error, misalnya error domain dari helper, selain itu err dibungkus dengan code.
+// Teks status diisi jika err belum memilikinya.
+func Ensure(err error, code Code, status string) *Error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return Wrap(code, status, err)
+	}
+	c := *e
+	if c.Status == "" {
+		c.Status = status
+	}
+	return &c
+}
+
+// As mengambil *Error dari err, error biasa dianggap Internal
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if err == nil {
+		return New(Internal, "", "")
+	}
+	return Wrap(Internal, "", err)
+}
+
+// CodeOf adalah kode dari err, kosong jika err nil
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	return As(err).Code
+}
+
+// Lang memilih bahasa pesan dari header Accept-Language, en jika bahasa yang paling diutamakan adalah Inggris
+func Lang(req *http.Request) string {
+	if req == nil {
+		return ID
+	}
+	for _, part := range strings.Split(req.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if tag == EN || strings.HasPrefix(tag, EN+"-") {
+			return EN
+		}
+		return ID
+	}
+	return ID
+}
+
+// Response adalah envelope error yang dikirim ke client
+func (e *Error) Response(lang string) model.Response {
+	status := e.Status
+	if status == "" {
+		status = "Error : " + e.Code.Message(ID)
+	}
+	return model.Response{
+		Code:     string(e.Code),
+		Message:  e.Code.Message(lang),
+		Status:   status,
+		Response: e.Detail,
+		Info:     e.Info,
+		Location: e.Location,
+		Data:     e.Data,
+	}
+}
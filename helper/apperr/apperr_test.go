@@ -0,0 +1,52 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLang(t *testing.T) {
+	cases := map[string]string{
+		"":               ID,
+		"en":             EN,
+		"en-US,en;q=0.9": EN,
+		"id-ID,en;q=0.8": ID,
+		"*, en-GB;q=0.5": EN,
+		"fr-FR,en;q=0.9": ID,
+	}
+	for header, want := range cases {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", header)
+		if got := Lang(req); got != want {
+			t.Errorf("Lang(%q) = %s, seharusnya %s", header, got, want)
+		}
+	}
+}
+
+func TestEnsureKeepsDomainCode(t *testing.T) {
+	domain := New(Conflict, "", "owner terakhir")
+	e := Ensure(domain, Database, "Error : Gagal mencabut role")
+	if e.Code != Conflict || e.Status != "Error : Gagal mencabut role" {
+		t.Fatalf("Ensure = %+v", e)
+	}
+	if domain.Status != "" {
+		t.Fatal("Ensure tidak boleh mengubah error asal")
+	}
+	if got := Ensure(errors.New("timeout"), Database, "").Code; got != Database {
+		t.Fatalf("error biasa harus dibungkus Database, didapat %s", got)
+	}
+}
+
+func TestResponse(t *testing.T) {
+	resp := New(UserNotFound, "", "08123").Response(EN)
+	if resp.Code != "USER_NOT_FOUND" || resp.Message != "User not found" || resp.Response != "08123" {
+		t.Fatalf("Response = %+v", resp)
+	}
+	if resp.Status != "Error : User tidak ditemukan" {
+		t.Fatalf("status default = %q", resp.Status)
+	}
+	if UserNotFound.HTTPStatus() != http.StatusNotFound || Code("LAINNYA").HTTPStatus() != http.StatusInternalServerError {
+		t.Fatal("HTTPStatus tidak sesuai catalog")
+	}
+}
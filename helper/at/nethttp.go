@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+
+	"github.com/gocroot/helper/apperr"
 )
 
 func GetSecretFromHeader(r *http.Request) (secret string) {
@@ -42,3 +44,9 @@ func WriteString(respw http.ResponseWriter, statusCode int, content string) {
 	respw.WriteHeader(statusCode)
 	respw.Write([]byte(content))
 }
+
+// WriteError menulis envelope error dengan status HTTP sesuai kode, pesan mengikuti header Accept-Language
+func WriteError(respw http.ResponseWriter, req *http.Request, err error) {
+	e := apperr.As(err)
+	WriteJSON(respw, e.Code.HTTPStatus(), e.Response(apperr.Lang(req)))
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/gocroot/helper/atapi"
 )
 
 func URLParam(reqpath string, url string) bool {
@@ -72,7 +74,7 @@ func GetClientIP(r *http.Request) (string, error) {
 
 func GetIPaddress() string {
 
-	resp, err := http.Get("https://icanhazip.com/")
+	resp, err := atapi.NewClient(0).Get("https://icanhazip.com/")
 
 	if err != nil {
 		log.Fatal(err)
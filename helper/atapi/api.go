@@ -6,10 +6,28 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"time"
 )
 
+// Transport adalah jalur keluar semua integrasi HTTP (WA API, Strava, Pomokit, Bukped, Discord, Google, GitHub).
+// Mode dev menggantinya dengan transport ke server tiruan lokal, lihat helper/devmode.
+var Transport http.RoundTripper = http.DefaultTransport
+
+// NewClient membuat http.Client yang lewat Transport, timeout 0 berarti tanpa batas waktu.
+// Pakai ini sebagai pengganti &http.Client{} supaya request keluar bisa dialihkan.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: transport{}}
+}
+
+// transport membaca Transport saat request dikirim, sehingga client yang dibuat sebelum mode dev aktif ikut dialihkan
+type transport struct{}
+
+func (transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return Transport.RoundTrip(req)
+}
+
 func PostStructWithToken[T any](tokenkey string, tokenvalue string, structname interface{}, urltarget string) (statusCode int, result T, err error) {
-	client := http.Client{}
+	client := NewClient(0)
 	mJson, _ := json.Marshal(structname)
 	req, err := http.NewRequest("POST", urltarget, bytes.NewBuffer(mJson))
 	if err != nil {
@@ -36,7 +54,7 @@ func PostStructWithToken[T any](tokenkey string, tokenvalue string, structname i
 }
 
 func Get[T any](urltarget string) (statusCode int, result T, err error) {
-	resp, err := http.Get(urltarget)
+	resp, err := NewClient(0).Get(urltarget)
 	if err != nil {
 		return
 	}
@@ -55,7 +73,7 @@ func Get[T any](urltarget string) (statusCode int, result T, err error) {
 }
 
 func GetWithBearer[T any](tokenbearer string, urltarget string) (statusCode int, result T, err error) {
-	client := http.Client{}
+	client := NewClient(0)
 	req, err := http.NewRequest("GET", urltarget, nil)
 	if err != nil {
 		return
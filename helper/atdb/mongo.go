@@ -16,12 +16,12 @@ import (
 
 func MongoConnect(mconn DBInfo) (db *mongo.Database, err error) {
 	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mconn.DBString))
-	if err != nil {
+	if err != nil && strings.HasPrefix(mconn.DBString, "mongodb+srv://") {
 		mconn.DBString = SRVLookup(mconn.DBString)
 		client, err = mongo.Connect(context.TODO(), options.Client().ApplyURI(mconn.DBString))
-		if err != nil {
-			return
-		}
+	}
+	if err != nil {
+		return
 	}
 	db = client.Database(mconn.DBName)
 	return
@@ -107,7 +107,11 @@ func GetRandomDoc[T any](db *mongo.Database, collection string, size uint) (resu
 }
 
 func GetAllDoc[T any](db *mongo.Database, collection string, filter bson.M) (doc T, err error) {
-	ctx := context.TODO()
+	return GetAllDocContext[T](context.TODO(), db, collection, filter)
+}
+
+// GetAllDocContext sama dengan GetAllDoc, query dibatalkan saat ctx selesai
+func GetAllDocContext[T any](ctx context.Context, db *mongo.Database, collection string, filter bson.M) (doc T, err error) {
 	cur, err := db.Collection(collection).Find(ctx, filter)
 	if err != nil {
 		return
@@ -129,7 +133,12 @@ func GetCountDoc(db *mongo.Database, collection string, filter bson.M) (count in
 }
 
 func GetOneDoc[T any](db *mongo.Database, collection string, filter bson.M) (doc T, err error) {
-	err = db.Collection(collection).FindOne(context.Background(), filter).Decode(&doc)
+	return GetOneDocContext[T](context.Background(), db, collection, filter)
+}
+
+// GetOneDocContext sama dengan GetOneDoc, query dibatalkan saat ctx selesai
+func GetOneDocContext[T any](ctx context.Context, db *mongo.Database, collection string, filter bson.M) (doc T, err error) {
+	err = db.Collection(collection).FindOne(ctx, filter).Decode(&doc)
 	if err != nil {
 		return
 	}
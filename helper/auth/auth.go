@@ -9,10 +9,10 @@ import (
 	"sync"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
-	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
-	"github.com/gocroot/model"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/time/rate"
 	"google.golang.org/api/idtoken"
@@ -67,7 +67,7 @@ func HashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
-func SendWhatsAppPassword(respw http.ResponseWriter, phoneNumber string, password string) {
+func SendWhatsAppPassword(respw http.ResponseWriter, req *http.Request, phoneNumber string, password string) {
     // Prepare WhatsApp message
     dt := &whatsauth.TextMessage{
         To:      phoneNumber,
@@ -78,11 +78,11 @@ func SendWhatsAppPassword(respw http.ResponseWriter, phoneNumber string, passwor
     }
 
     // Send WhatsApp message
-    _, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+    _, resp, err := waoutbox.Send(config.Mongoconn, dt)
     if err != nil {
 		resp.Info = "message: unauthorized"
 		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, req, apperr.FromResponse(apperr.Upstream, resp))
 		return
     }
 
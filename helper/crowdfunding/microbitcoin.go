@@ -0,0 +1,239 @@
+package crowdfunding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	MicroBitcoinWalletAddress = "BXheTnryBeec7Ere3zsuRmWjB1LiyCFpec"
+	// Crypto orders get a 1..999 satoshi tag that must be the last digits of the amount sent
+	CryptoUniqueCodeMax = 999
+)
+
+// MicroBitcoin dicek lewat api.mbc.wiki: mempool, history lalu detail transaksi
+type MicroBitcoin struct{}
+
+func (MicroBitcoin) Info() Info {
+	return Info{
+		Method:         model.MicroBitcoin,
+		Name:           "MicroBitcoin",
+		Symbol:         "MBC",
+		Expiry:         25 * time.Minute,
+		Steps:          3,
+		UniqueCodeMax:  CryptoUniqueCodeMax,
+		QRImageURL:     "wonpay.png",
+		WalletAddress:  MicroBitcoinWalletAddress,
+		TotalAmountKey: "totalBitcoinAmount",
+		TotalCountKey:  "bitcoinCount",
+		PointsKey:      "mbc",
+	}
+}
+
+func (MicroBitcoin) PrepareOrder(order *model.CrowdfundingOrder, req OrderRequest) (string, error) {
+	order.WalletAddress = MicroBitcoinWalletAddress
+	order.Wonpaywallet = req.Wonpaywallet
+	return req.Wonpaywallet, nil
+}
+
+func (MicroBitcoin) ApplyUniqueCode(order *model.CrowdfundingOrder, code int) int64 {
+	order.UniqueCode = code
+	return int64(code)
+}
+
+func (MicroBitcoin) Poll(slot model.CrowdfundingSlot, step int, txid string) (PollResult, error) {
+	switch step {
+	case 1:
+		found, mempoolTxid, err := checkMicroBitcoinMempool(slot)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Checking mempool failed: %v", err)
+		}
+		if !found {
+			return PollResult{Message: "No transaction found yet. Please make the payment or wait if you've already sent it."}, nil
+		}
+		return PollResult{Done: true, TxID: mempoolTxid, Message: "Transaction found in mempool, waiting for confirmation."}, nil
+	case 2:
+		found, err := checkMicroBitcoinTxHistory(txid)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Checking transaction history failed: %v", err)
+		}
+		if !found {
+			return PollResult{Message: "Transaction not found in history yet. Please wait."}, nil
+		}
+		return PollResult{Done: true, TxID: txid, Message: "Transaction found in history, proceed to final verification."}, nil
+	case 3:
+		valid, amount, err := checkMicroBitcoinTxDetails(txid, slot)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Error checking transaction details: %v", err)
+		}
+		if !valid {
+			return PollResult{Message: "Transaction details verification failed."}, nil
+		}
+		return PollResult{Done: true, TxID: txid, Amount: amount, Message: "Payment confirmed successfully!"}, nil
+	}
+	return PollResult{}, fmt.Errorf("unknown step %d", step)
+}
+
+func (MicroBitcoin) Confirm(order model.CrowdfundingOrder, req ConfirmRequest) (string, float64, error) {
+	return confirmCrypto(req)
+}
+
+func (MicroBitcoin) Points(db *mongo.Database, amount float64) (float64, int) {
+	// Default rata-rata MBC
+	return relativePoints(db, model.MicroBitcoin, 0.001, amount)
+}
+
+func (MicroBitcoin) ApplyScore(activityScore *model.ActivityScore, amount, points float64, score int) {
+	activityScore.MBC = float32(amount)
+	activityScore.MBCPoints = points
+	activityScore.BlockChain = score
+}
+
+func (MicroBitcoin) FormatAmount(amount float64) string {
+	return fmt.Sprintf("%f MBC", amount)
+}
+
+// FormatReportAmount mengubah 0.0002 menjadi "2 koin MBC" (1 koin = 0.0001 MBC)
+func (MicroBitcoin) FormatReportAmount(amount float64) string {
+	coinAmount := amount * 10000
+	if coinAmount == float64(int(coinAmount)) {
+		return fmt.Sprintf("%.0f koin MBC", coinAmount)
+	}
+	return fmt.Sprintf("%.2f koin MBC", coinAmount)
+}
+
+func (MicroBitcoin) FormatPayAmount(order model.CrowdfundingOrder) string {
+	return fmt.Sprintf("x.00000%03d MBC", order.UniqueCode)
+}
+
+// confirmCrypto memvalidasi konfirmasi manual yang membawa txid dan jumlah koin
+func confirmCrypto(req ConfirmRequest) (string, float64, error) {
+	if req.TxID == "" {
+		return "", 0, errors.New("Transaction ID is required")
+	}
+	if req.Amount <= 0 {
+		return "", 0, errors.New("Amount must be greater than 0")
+	}
+	return req.TxID, req.Amount, nil
+}
+
+// Step 1: Check mempool for a transaction that belongs to the order's slot and extract its txid
+func checkMicroBitcoinMempool(slot model.CrowdfundingSlot) (bool, string, error) {
+	client := atapi.NewClient(10 * time.Second)
+	resp, err := client.Get("https://api.mbc.wiki/mempool/" + MicroBitcoinWalletAddress)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	var mempoolResp model.MicroBitcoinMempoolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&mempoolResp); err != nil {
+		return false, "", err
+	}
+	if mempoolResp.Error != nil {
+		return false, "", errors.New("API error: " + *mempoolResp.Error)
+	}
+
+	// Look for a transaction carrying this slot's unique tag that no other order has claimed
+	for _, tx := range mempoolResp.Result.Tx {
+		if isTxClaimedByOtherSlot(tx.TxID, slot.OrderID) || IsTxUsedBySuccessfulOrder(tx.TxID, slot.OrderID) {
+			continue
+		}
+		// The actual amount is determined in step 3
+		if satoshiMatchesSlot(slot, tx.Satoshis) {
+			return true, tx.TxID, nil
+		}
+		// Fall back to the sender wallet registered on the user profile
+		if slot.SenderWallet != "" {
+			if fromWallet, _, err := checkMicroBitcoinTxDetails(tx.TxID, slot); err == nil && fromWallet {
+				return true, tx.TxID, nil
+			}
+		}
+	}
+
+	return false, "", nil
+}
+
+// Step 2: Check if the transaction exists in history
+func checkMicroBitcoinTxHistory(txid string) (bool, error) {
+	client := atapi.NewClient(10 * time.Second)
+	resp, err := client.Get("https://api.mbc.wiki/history/" + MicroBitcoinWalletAddress)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var historyResp model.MicroBitcoinHistoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&historyResp); err != nil {
+		return false, err
+	}
+	if historyResp.Error != nil {
+		return false, errors.New("API error: " + *historyResp.Error)
+	}
+
+	for _, historyTxid := range historyResp.Result.Tx {
+		if historyTxid == txid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Step 3: Verify transaction details against the order's slot
+func checkMicroBitcoinTxDetails(txid string, slot model.CrowdfundingSlot) (bool, float64, error) {
+	client := atapi.NewClient(10 * time.Second)
+	resp, err := client.Get("https://api.mbc.wiki/transaction/" + txid)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+
+	var txResp model.MicroBitcoinTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
+		return false, 0, err
+	}
+	if txResp.Error != nil {
+		return false, 0, errors.New("API error: " + *txResp.Error)
+	}
+
+	// Find the output that matches our wallet address
+	var amount int64 = 0
+	for _, vout := range txResp.Result.Vout {
+		for _, addr := range vout.ScriptPubKey.Addresses {
+			if addr == MicroBitcoinWalletAddress {
+				amount = vout.Value
+				break
+			}
+		}
+		if vout.ScriptPubKey.Address == MicroBitcoinWalletAddress {
+			amount = vout.Value
+			break
+		}
+	}
+
+	// Check whether the transaction was sent from the user's registered wallet
+	fromSenderWallet := false
+	if slot.SenderWallet != "" {
+		for _, vin := range txResp.Result.Vin {
+			if vin.ScriptPubKey.Address == slot.SenderWallet {
+				fromSenderWallet = true
+				break
+			}
+			for _, addr := range vin.ScriptPubKey.Addresses {
+				if addr == slot.SenderWallet {
+					fromSenderWallet = true
+					break
+				}
+			}
+		}
+	}
+
+	// Transaction is valid if it pays our address and belongs to this slot
+	return amount > 0 && (satoshiMatchesSlot(slot, amount) || fromSenderWallet), float64(amount) / satoshiPerCoin, nil
+}
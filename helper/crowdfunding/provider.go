@@ -0,0 +1,159 @@
+package crowdfunding
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Info berisi data statis sebuah metode pembayaran crowdfunding
+type Info struct {
+	Method        model.PaymentMethod // disimpan di order dan dipakai sebagai segmen route
+	Name          string              // nama lengkap untuk laporan, contoh "MicroBitcoin"
+	Symbol        string              // singkatan untuk laporan, contoh "MBC"
+	Expiry        time.Duration       // lama slot pembayaran berlaku
+	Steps         int                 // jumlah langkah polling, 0 jika status hanya berubah lewat notifikasi
+	UniqueCodeMax int                 // kode unik slot diambil dari 1..UniqueCodeMax
+	QRImageURL    string              // gambar QR yang ditampilkan di frontend
+	WalletAddress string              // alamat tujuan pembayaran, kosong untuk QRIS
+	// Nama field di koleksi crowdfundingtotals dan prefix field di crowdfundingpoints
+	TotalAmountKey string
+	TotalCountKey  string
+	PointsKey      string
+}
+
+// OrderRequest berisi data pembuatan order dari body request dan profil user
+type OrderRequest struct {
+	Amount       float64 `json:"amount"`
+	Wonpaywallet string  `json:"-"`
+	RVNwallet    string  `json:"-"`
+}
+
+// ConfirmRequest berisi data konfirmasi manual sebuah order
+type ConfirmRequest struct {
+	TxID   string  `json:"txid"`
+	Amount float64 `json:"amount"`
+}
+
+// PollResult adalah hasil satu langkah pengecekan pembayaran
+type PollResult struct {
+	Done    bool    // langkah ini selesai
+	TxID    string  // transaksi yang ditemukan pada langkah 1
+	Amount  float64 // jumlah akhir yang terverifikasi pada langkah terakhir
+	Message string
+}
+
+// Provider adalah kontrak satu metode pembayaran crowdfunding.
+// Handler di controller hanya bekerja lewat interface ini sehingga metode baru cukup didaftarkan dengan Register.
+type Provider interface {
+	Info() Info
+	// PrepareOrder mengisi field order yang khusus untuk metode ini dan mengembalikan wallet pengirim untuk slot
+	PrepareOrder(order *model.CrowdfundingOrder, req OrderRequest) (senderWallet string, err error)
+	// ApplyUniqueCode memasang kode unik ke order dan mengembalikan nilai yang dipakai untuk matchKey slot
+	ApplyUniqueCode(order *model.CrowdfundingOrder, code int) int64
+	// Poll menjalankan langkah pengecekan ke-step (1..Steps) untuk slot order
+	Poll(slot model.CrowdfundingSlot, step int, txid string) (PollResult, error)
+	// Confirm memvalidasi konfirmasi manual dan mengembalikan txid serta jumlah akhir
+	Confirm(order model.CrowdfundingOrder, req ConfirmRequest) (txid string, amount float64, err error)
+	// Points mengubah jumlah pembayaran menjadi poin (maks 100) dan skor relatif terhadap rata-rata
+	Points(db *mongo.Database, amount float64) (points float64, score int)
+	// ApplyScore mengisi field ActivityScore milik metode ini
+	ApplyScore(activityScore *model.ActivityScore, amount, points float64, score int)
+	// FormatAmount dipakai untuk log Discord, FormatReportAmount untuk rekap WhatsApp
+	FormatAmount(amount float64) string
+	FormatReportAmount(amount float64) string
+	// FormatPayAmount adalah jumlah persis yang harus ditransfer user untuk order ini
+	FormatPayAmount(order model.CrowdfundingOrder) string
+}
+
+var providers []Provider
+
+// Register menambahkan provider ke daftar metode pembayaran
+func Register(p Provider) {
+	providers = append(providers, p)
+}
+
+// Providers mengembalikan semua provider sesuai urutan pendaftaran
+func Providers() []Provider {
+	return providers
+}
+
+// Get mencari provider berdasarkan nama metode pembayaran
+func Get(method model.PaymentMethod) (Provider, bool) {
+	for _, p := range providers {
+		if p.Info().Method == method {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ProviderAction mengembalikan provider dan aksi dari path /api/crowdfunding/{provider}/{aksi}[/:orderId]
+func ProviderAction(path string) (Provider, string) {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/crowdfunding/"), "/")
+	if !strings.HasPrefix(path, "/api/crowdfunding/") || len(parts) < 2 || len(parts) > 3 {
+		return nil, ""
+	}
+	p, ok := Get(model.PaymentMethod(parts[0]))
+	if !ok {
+		return nil, ""
+	}
+	return p, parts[1]
+}
+
+func init() {
+	Register(QRIS{})
+	Register(MicroBitcoin{})
+	Register(Ravencoin{})
+}
+
+// averageAmount menghitung rata-rata pembayaran sukses sebuah metode, defaultAvg dipakai jika belum ada data
+func averageAmount(db *mongo.Database, method model.PaymentMethod, defaultAvg float64) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"paymentMethod": method,
+			"status":        "success",
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       nil,
+			"avgAmount": bson.M{"$avg": "$amount"},
+		}}},
+	}
+
+	cursor, err := db.Collection("crowdfundingorders").Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	var result struct {
+		AvgAmount float64 `bson:"avgAmount"`
+	}
+	if cursor.Next(context.Background()) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	if result.AvgAmount <= 0 {
+		return defaultAvg, nil
+	}
+	return result.AvgAmount, nil
+}
+
+// relativePoints menghitung poin (dibatasi 100) dan skor (tanpa batas) dari (jumlah / rata-rata) * 100
+func relativePoints(db *mongo.Database, method model.PaymentMethod, defaultAvg, amount float64) (float64, int) {
+	avgAmount, err := averageAmount(db, method, defaultAvg)
+	if err != nil {
+		return 0, 0
+	}
+	ratio := (amount / avgAmount) * 100
+	points := ratio
+	if points > 100 {
+		points = 100
+	}
+	return points, int(ratio)
+}
@@ -0,0 +1,98 @@
+package crowdfunding
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// QRIS order mendapat tambahan 1..999 rupiah sehingga setiap jumlah yang pending unik
+const QRISUniqueCodeMax = 999
+
+// QRIS dibayar lewat kode QR statis, status order diubah oleh notifikasi payment gateway
+type QRIS struct{}
+
+func (QRIS) Info() Info {
+	return Info{
+		Method:         model.QRIS,
+		Name:           "QRIS",
+		Symbol:         "QRIS",
+		Expiry:         60 * time.Minute,
+		Steps:          0,
+		UniqueCodeMax:  QRISUniqueCodeMax,
+		QRImageURL:     "qris.png",
+		TotalAmountKey: "totalQRISAmount",
+		TotalCountKey:  "qrisCount",
+		PointsKey:      "qris",
+	}
+}
+
+func (QRIS) PrepareOrder(order *model.CrowdfundingOrder, req OrderRequest) (string, error) {
+	if req.Amount <= 0 {
+		return "", errors.New("Valid amount is required")
+	}
+	order.Amount = req.Amount
+	order.BaseAmount = req.Amount
+	return "", nil
+}
+
+// ApplyUniqueCode menambahkan kode unik ke jumlah bayar, slot dikunci dengan jumlah tersebut
+func (QRIS) ApplyUniqueCode(order *model.CrowdfundingOrder, code int) int64 {
+	order.UniqueCode = code
+	order.Amount = order.BaseAmount + float64(code)
+	return int64(order.Amount)
+}
+
+func (QRIS) Poll(slot model.CrowdfundingSlot, step int, txid string) (PollResult, error) {
+	return PollResult{}, errors.New("QRIS payments are confirmed by notification")
+}
+
+func (QRIS) Confirm(order model.CrowdfundingOrder, req ConfirmRequest) (string, float64, error) {
+	return "", order.Amount, nil
+}
+
+func (QRIS) Points(db *mongo.Database, amount float64) (float64, int) {
+	// Default rata-rata QRIS (IDR)
+	return relativePoints(db, model.QRIS, 10000, amount)
+}
+
+func (QRIS) ApplyScore(activityScore *model.ActivityScore, amount, points float64, score int) {
+	activityScore.Rupiah = int(amount)
+	activityScore.QRISPoints = points
+	activityScore.QRIS = score
+}
+
+func (QRIS) FormatAmount(amount float64) string {
+	return fmt.Sprintf("Rp %s", strconv.FormatFloat(amount, 'f', 2, 64))
+}
+
+// FormatReportAmount mengubah 1 menjadi "Rp 1" dan 1000 menjadi "Rp 1.000"
+func (QRIS) FormatReportAmount(amount float64) string {
+	if amount == float64(int(amount)) {
+		return "Rp " + groupThousands(fmt.Sprintf("%d", int(amount)))
+	}
+	// Desimal memakai koma sesuai format Indonesia
+	parts := strings.Split(fmt.Sprintf("%.2f", amount), ".")
+	return "Rp " + groupThousands(parts[0]) + "," + parts[1]
+}
+
+func (QRIS) FormatPayAmount(order model.CrowdfundingOrder) string {
+	return fmt.Sprintf("Rp %s", strconv.FormatFloat(order.Amount, 'f', 0, 64))
+}
+
+// groupThousands menambahkan pemisah ribuan (.) ke bagian bulat
+func groupThousands(s string) string {
+	var result strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			result.WriteRune('.')
+		}
+		result.WriteRune(c)
+	}
+	return result.String()
+}
@@ -0,0 +1,201 @@
+package crowdfunding
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	RavencoinWalletAddress = "RKJpSmjTq5MPDaBx2ubTx1msVB2uZcKA5j"
+	// Number of recent Ravencoin transactions scanned when looking for an order's payment
+	RavencoinSlotScanLimit = 10
+)
+
+// Ravencoin dicek lewat blockbook.ravencoin.org: transaksi alamat, konfirmasi lalu detail transaksi
+type Ravencoin struct{}
+
+func (Ravencoin) Info() Info {
+	return Info{
+		Method:         model.Ravencoin,
+		Name:           "Ravencoin",
+		Symbol:         "RVN",
+		Expiry:         25 * time.Minute,
+		Steps:          3,
+		UniqueCodeMax:  CryptoUniqueCodeMax,
+		QRImageURL:     "ravencoin.png",
+		WalletAddress:  RavencoinWalletAddress,
+		TotalAmountKey: "totalRavencoinAmount",
+		TotalCountKey:  "ravencoinCount",
+		PointsKey:      "ravencoin",
+	}
+}
+
+func (Ravencoin) PrepareOrder(order *model.CrowdfundingOrder, req OrderRequest) (string, error) {
+	order.WalletAddress = RavencoinWalletAddress
+	order.RVNwallet = req.RVNwallet
+	return req.RVNwallet, nil
+}
+
+func (Ravencoin) ApplyUniqueCode(order *model.CrowdfundingOrder, code int) int64 {
+	order.UniqueCode = code
+	return int64(code)
+}
+
+func (Ravencoin) Poll(slot model.CrowdfundingSlot, step int, txid string) (PollResult, error) {
+	switch step {
+	case 1:
+		found, addressTxid, amount, err := checkRavencoinAddressAPI(slot)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Checking Ravencoin address failed: %v", err)
+		}
+		if !found {
+			return PollResult{Message: "No transaction found yet. Please make the payment or wait if you've already sent it."}, nil
+		}
+		return PollResult{Done: true, TxID: addressTxid, Amount: amount, Message: "Transaction found in unconfirmed transactions, waiting for confirmation."}, nil
+	case 2:
+		txResp, err := fetchRavencoinTx(txid)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Checking transaction history failed: %v", err)
+		}
+		if txResp.Confirmations <= 0 {
+			return PollResult{Message: "Waiting for transaction confirmation. Please keep checking."}, nil
+		}
+		return PollResult{Done: true, TxID: txid, Message: "Transaction confirmed in blockchain, proceeding to verification."}, nil
+	case 3:
+		txResp, err := fetchRavencoinTx(txid)
+		if err != nil {
+			return PollResult{}, fmt.Errorf("Error checking transaction details: %v", err)
+		}
+		matched, amount := ravencoinTxMatchesSlot(txResp, slot)
+		if !matched {
+			return PollResult{Message: "Transaction details verification failed."}, nil
+		}
+		return PollResult{Done: true, TxID: txid, Amount: amount, Message: "Payment confirmed successfully!"}, nil
+	}
+	return PollResult{}, fmt.Errorf("unknown step %d", step)
+}
+
+func (Ravencoin) Confirm(order model.CrowdfundingOrder, req ConfirmRequest) (string, float64, error) {
+	return confirmCrypto(req)
+}
+
+func (Ravencoin) Points(db *mongo.Database, amount float64) (float64, int) {
+	// Default rata-rata Ravencoin
+	return relativePoints(db, model.Ravencoin, 1, amount)
+}
+
+func (Ravencoin) ApplyScore(activityScore *model.ActivityScore, amount, points float64, score int) {
+	activityScore.RVN = float32(amount)
+	activityScore.RavencoinPoints = points
+	activityScore.BlockChain = score
+}
+
+func (Ravencoin) FormatAmount(amount float64) string {
+	return fmt.Sprintf("%f RVN", amount)
+}
+
+// FormatReportAmount mengubah 0.5 menjadi "0.50 koin RVN" dan 1 menjadi "1 koin RVN"
+func (Ravencoin) FormatReportAmount(amount float64) string {
+	if amount == float64(int(amount)) {
+		return fmt.Sprintf("%.0f koin RVN", amount)
+	}
+	return fmt.Sprintf("%.2f koin RVN", amount)
+}
+
+func (Ravencoin) FormatPayAmount(order model.CrowdfundingOrder) string {
+	return fmt.Sprintf("x.00000%03d RVN", order.UniqueCode)
+}
+
+// fetchRavencoinTx gets the details of a Ravencoin transaction
+func fetchRavencoinTx(txid string) (model.RavencoinTransactionResponse, error) {
+	var txResp model.RavencoinTransactionResponse
+
+	client := atapi.NewClient(10 * time.Second)
+	resp, err := client.Get("https://blockbook.ravencoin.org/api/tx/" + txid)
+	if err != nil {
+		return txResp, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&txResp)
+	return txResp, err
+}
+
+// ravencoinTxMatchesSlot returns the amount sent to our wallet and whether the transaction belongs to the slot
+func ravencoinTxMatchesSlot(txResp model.RavencoinTransactionResponse, slot model.CrowdfundingSlot) (bool, float64) {
+	// Find the output that matches our wallet address
+	var amount float64 = 0
+	for _, vout := range txResp.Vout {
+		for _, addr := range vout.ScriptPubKey.Addresses {
+			if addr == RavencoinWalletAddress {
+				outputAmount, err := strconv.ParseFloat(vout.Value, 64)
+				if err != nil {
+					continue
+				}
+				amount = outputAmount
+				break
+			}
+		}
+		if amount > 0 {
+			break
+		}
+	}
+
+	// Check whether the transaction was sent from the user's registered wallet
+	fromSenderWallet := false
+	if slot.SenderWallet != "" {
+		for _, vin := range txResp.Vin {
+			for _, addr := range vin.Addresses {
+				if addr == slot.SenderWallet {
+					fromSenderWallet = true
+				}
+			}
+		}
+	}
+
+	return amount > 0 && (satoshiMatchesSlot(slot, coinToSatoshi(amount)) || fromSenderWallet), amount
+}
+
+// Step 1: Check Ravencoin address for a new transaction that belongs to the order's slot
+func checkRavencoinAddressAPI(slot model.CrowdfundingSlot) (bool, string, float64, error) {
+	client := atapi.NewClient(10 * time.Second)
+	resp, err := client.Get("https://blockbook.ravencoin.org/api/v2/address/" + RavencoinWalletAddress)
+	if err != nil {
+		return false, "", 0, err
+	}
+	defer resp.Body.Close()
+
+	var addressResp model.RavencoinAddressResponse
+	if err := json.NewDecoder(resp.Body).Decode(&addressResp); err != nil {
+		return false, "", 0, err
+	}
+
+	// Only transactions made after the slot was reserved can belong to it.
+	// Txids are ordered from the newest, so the scan stops at the first older transaction.
+	for i, txid := range addressResp.Txids {
+		if i >= RavencoinSlotScanLimit {
+			break
+		}
+		if isTxClaimedByOtherSlot(txid, slot.OrderID) || IsTxUsedBySuccessfulOrder(txid, slot.OrderID) {
+			continue
+		}
+		txResp, err := fetchRavencoinTx(txid)
+		if err != nil {
+			continue
+		}
+		if txResp.BlockTime > 0 && time.Unix(txResp.BlockTime, 0).Before(slot.CreatedAt) {
+			break
+		}
+		if matched, amount := ravencoinTxMatchesSlot(txResp, slot); matched {
+			return true, txid, amount, nil
+		}
+	}
+
+	return false, "", 0, nil
+}
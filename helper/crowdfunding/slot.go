@@ -0,0 +1,201 @@
+package crowdfunding
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// SlotCollection berisi satu dokumen untuk setiap pembayaran yang belum selesai
+	SlotCollection = "crowdfundingslots"
+
+	// MaxActiveSlots adalah batas pembayaran yang diproses bersamaan
+	MaxActiveSlots = 200
+
+	// slotAcquireAttempts adalah jumlah kode acak yang dicoba sebelum menyerah
+	slotAcquireAttempts = 25
+
+	satoshiPerCoin = 100000000
+)
+
+var (
+	ErrNoFreeSlot    = errors.New("semua slot pembayaran sedang terpakai, silakan coba beberapa saat lagi")
+	ErrTxAlreadyUsed = errors.New("transaction already claimed by another order")
+
+	slotIndexOnce sync.Once
+)
+
+// ensureSlotIndexes membuat index unik yang menjamin satu kode dan satu transaksi hanya dipegang satu slot
+func ensureSlotIndexes() {
+	slotIndexOnce.Do(func() {
+		_, err := config.Mongoconn.Collection(SlotCollection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "matchKey", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "orderId", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "txid", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"txid": bson.M{"$type": "string"}})},
+			{Keys: bson.D{{Key: "expiryTime", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating crowdfunding slot indexes: %v", err)
+		}
+	})
+}
+
+// SlotMatchKey adalah key unik slot, QRIS memakai nominal bayar dalam rupiah dan crypto memakai tag satoshi
+func SlotMatchKey(paymentMethod model.PaymentMethod, value int64) string {
+	return string(paymentMethod) + ":" + strconv.FormatInt(value, 10)
+}
+
+// AcquireSlot memesan kode unik untuk order lalu menyimpan slotnya, cara kode dipasang ke order ditentukan provider.
+// Batas MaxActiveSlots dicek ulang setelah slot tersimpan dan slot dibatalkan jika melewati batas,
+// sehingga AcquireSlot yang berjalan bersamaan tidak bisa membuat slot aktif lebih dari batas.
+func AcquireSlot(p Provider, order *model.CrowdfundingOrder, senderWallet string) (slot model.CrowdfundingSlot, err error) {
+	ensureSlotIndexes()
+
+	//cek awal supaya slot tidak disimpan saat sudah jelas penuh
+	penuh, err := slotPenuh(MaxActiveSlots)
+	if err != nil {
+		return
+	}
+	if penuh {
+		err = ErrNoFreeSlot
+		return
+	}
+
+	info := p.Info()
+	for i := 0; i < slotAcquireAttempts; i++ {
+		code := rand.Intn(info.UniqueCodeMax) + 1
+		candidate := *order
+		key := SlotMatchKey(info.Method, p.ApplyUniqueCode(&candidate, code))
+		slot = model.CrowdfundingSlot{
+			OrderID:       order.OrderID,
+			PhoneNumber:   order.PhoneNumber,
+			PaymentMethod: info.Method,
+			UniqueCode:    code,
+			MatchKey:      key,
+			SenderWallet:  senderWallet,
+			CreatedAt:     time.Now(),
+			ExpiryTime:    order.ExpiryTime,
+		}
+		_, err = config.Mongoconn.Collection(SlotCollection).InsertOne(context.Background(), slot)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		//slot sendiri ikut terhitung, lebih dari batas berarti ada slot lain yang tersimpan bersamaan
+		penuh, err = slotPenuh(MaxActiveSlots + 1)
+		if err != nil || penuh {
+			if _, errHapus := config.Mongoconn.Collection(SlotCollection).DeleteOne(context.Background(), bson.M{"orderId": order.OrderID}); errHapus != nil {
+				log.Printf("Error membatalkan slot %s: %v", order.OrderID, errHapus)
+			}
+			if err == nil {
+				err = ErrNoFreeSlot
+			}
+			return model.CrowdfundingSlot{}, err
+		}
+		*order = candidate
+		return
+	}
+	err = ErrNoFreeSlot
+	return
+}
+
+// slotPenuh bernilai true jika slot yang belum kedaluwarsa sudah mencapai batas
+func slotPenuh(batas int64) (bool, error) {
+	active, err := config.Mongoconn.Collection(SlotCollection).CountDocuments(context.Background(), bson.M{"expiryTime": bson.M{"$gt": time.Now()}})
+	return active >= batas, err
+}
+
+// GetActiveSlot mengambil slot user yang belum kedaluwarsa untuk satu metode pembayaran
+func GetActiveSlot(phoneNumber string, paymentMethod model.PaymentMethod) (slot model.CrowdfundingSlot, err error) {
+	err = config.Mongoconn.Collection(SlotCollection).FindOne(context.Background(), bson.M{
+		"phoneNumber":   phoneNumber,
+		"paymentMethod": paymentMethod,
+		"expiryTime":    bson.M{"$gt": time.Now()},
+	}).Decode(&slot)
+	return
+}
+
+// GetSlotByOrderID mengambil slot milik order
+func GetSlotByOrderID(orderID string) (slot model.CrowdfundingSlot, err error) {
+	err = config.Mongoconn.Collection(SlotCollection).FindOne(context.Background(), bson.M{"orderId": orderID}).Decode(&slot)
+	return
+}
+
+// GetSlotByMatchKey mencari slot aktif dari nominal unik atau tag pembayaran
+func GetSlotByMatchKey(key string) (slot model.CrowdfundingSlot, err error) {
+	err = config.Mongoconn.Collection(SlotCollection).FindOne(context.Background(), bson.M{
+		"matchKey":   key,
+		"expiryTime": bson.M{"$gt": time.Now()},
+	}).Decode(&slot)
+	return
+}
+
+// ReleaseSlot melepas slot order yang sudah selesai supaya kodenya bisa dipakai lagi
+func ReleaseSlot(orderID string) error {
+	_, err := config.Mongoconn.Collection(SlotCollection).DeleteOne(context.Background(), bson.M{"orderId": orderID})
+	return err
+}
+
+// ClaimSlotTx mengikat transaksi ke slot order, satu transaksi hanya bisa diklaim satu kali
+func ClaimSlotTx(orderID, txid string) error {
+	if IsTxUsedBySuccessfulOrder(txid, orderID) {
+		return ErrTxAlreadyUsed
+	}
+	res, err := config.Mongoconn.Collection(SlotCollection).UpdateOne(
+		context.Background(),
+		bson.M{"orderId": orderID, "$or": bson.A{bson.M{"txid": bson.M{"$exists": false}}, bson.M{"txid": txid}}},
+		bson.M{"$set": bson.M{"txid": txid}},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTxAlreadyUsed
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTxAlreadyUsed
+	}
+	return nil
+}
+
+// IsTxUsedBySuccessfulOrder bernilai true jika transaksi sudah melunasi order lain
+func IsTxUsedBySuccessfulOrder(txid, orderID string) bool {
+	count, err := config.Mongoconn.Collection("crowdfundingorders").CountDocuments(context.Background(), bson.M{
+		"txid":    txid,
+		"status":  "success",
+		"orderId": bson.M{"$ne": orderID},
+	})
+	return err == nil && count > 0
+}
+
+// isTxClaimedByOtherSlot bernilai true jika transaksi sudah dipegang slot order lain yang belum selesai
+func isTxClaimedByOtherSlot(txid, orderID string) bool {
+	count, err := config.Mongoconn.Collection(SlotCollection).CountDocuments(context.Background(), bson.M{
+		"txid":    txid,
+		"orderId": bson.M{"$ne": orderID},
+	})
+	return err == nil && count > 0
+}
+
+// satoshiMatchesSlot bernilai true jika nominal satoshi membawa tag slot
+func satoshiMatchesSlot(slot model.CrowdfundingSlot, satoshis int64) bool {
+	return satoshis > 0 && satoshis%1000 == int64(slot.UniqueCode)
+}
+
+// coinToSatoshi mengubah nominal koin ke satoshi tanpa selisih pembulatan float
+func coinToSatoshi(amount float64) int64 {
+	return int64(amount*satoshiPerCoin + 0.5)
+}
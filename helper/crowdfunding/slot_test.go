@@ -0,0 +1,191 @@
+package crowdfunding
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/mongotest"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestMain memasang database memori ke config.Mongoconn karena slot dibaca dari sana
+func TestMain(m *testing.M) {
+	mongotest.Main(m, "crowdfundingtest", func(db *mongo.Database) { config.Mongoconn = db })
+}
+
+// qrisSempit hanya punya dua kode unik supaya slot bisa dihabiskan dalam test
+type qrisSempit struct{ QRIS }
+
+func (q qrisSempit) Info() Info {
+	info := q.QRIS.Info()
+	info.UniqueCodeMax = 2
+	return info
+}
+
+func kosongkanSlot(t *testing.T) {
+	t.Helper()
+	if _, err := config.Mongoconn.Collection(SlotCollection).DeleteMany(context.Background(), bson.M{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func orderBaru(id string) *model.CrowdfundingOrder {
+	return &model.CrowdfundingOrder{
+		OrderID:       id,
+		PhoneNumber:   "6289900000301",
+		PaymentMethod: model.QRIS,
+		BaseAmount:    10000,
+		Amount:        10000,
+		ExpiryTime:    time.Now().Add(time.Hour),
+	}
+}
+
+func TestAcquireSlot(t *testing.T) {
+	kosongkanSlot(t)
+	p := qrisSempit{}
+	dipakai := map[string]bool{}
+	for i := 1; i <= 2; i++ {
+		order := orderBaru("sempit-" + strconv.Itoa(i))
+		slot, err := AcquireSlot(p, order, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dipakai[slot.MatchKey] {
+			t.Fatalf("matchKey %s dipakai dua order", slot.MatchKey)
+		}
+		dipakai[slot.MatchKey] = true
+		if slot.MatchKey != SlotMatchKey(model.QRIS, int64(order.Amount)) || order.Amount != order.BaseAmount+float64(slot.UniqueCode) {
+			t.Fatalf("kode unik tidak terpasang ke order: %+v %+v", slot, order)
+		}
+		if got, err := GetSlotByMatchKey(slot.MatchKey); err != nil || got.OrderID != order.OrderID {
+			t.Fatalf("slot %s tidak ditemukan lewat matchKey: %v", order.OrderID, err)
+		}
+	}
+	//semua kode unik terpakai, order ketiga tidak boleh mendapat kode yang sama
+	order := orderBaru("sempit-3")
+	if _, err := AcquireSlot(p, order, ""); err != ErrNoFreeSlot {
+		t.Fatalf("kode unik habis seharusnya ErrNoFreeSlot: %v", err)
+	}
+	if order.UniqueCode != 0 || order.Amount != order.BaseAmount {
+		t.Fatalf("order yang gagal tidak boleh berubah: %+v", order)
+	}
+	//slot yang dilepas bisa dipakai lagi
+	if err := ReleaseSlot("sempit-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireSlot(p, order, ""); err != nil {
+		t.Fatalf("slot yang dilepas seharusnya bisa dipakai: %v", err)
+	}
+}
+
+func TestAcquireSlotPenuh(t *testing.T) {
+	kosongkanSlot(t)
+	var slots []interface{}
+	for i := 0; i < MaxActiveSlots; i++ {
+		slots = append(slots, model.CrowdfundingSlot{OrderID: "penuh-" + strconv.Itoa(i), MatchKey: "penuh:" + strconv.Itoa(i), ExpiryTime: time.Now().Add(time.Hour)})
+	}
+	if _, err := config.Mongoconn.Collection(SlotCollection).InsertMany(context.Background(), slots); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireSlot(QRIS{}, orderBaru("penuh-baru"), ""); err != ErrNoFreeSlot {
+		t.Fatalf("slot aktif penuh seharusnya ErrNoFreeSlot: %v", err)
+	}
+	//slot kedaluwarsa tidak dihitung
+	if _, err := config.Mongoconn.Collection(SlotCollection).UpdateOne(context.Background(), bson.M{"orderId": "penuh-0"}, bson.M{"$set": bson.M{"expiryTime": time.Now().Add(-time.Minute)}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireSlot(QRIS{}, orderBaru("penuh-baru"), ""); err != nil {
+		t.Fatalf("slot kedaluwarsa seharusnya tidak dihitung: %v", err)
+	}
+}
+
+// TestAcquireSlotBersamaan: order yang memesan slot bersamaan saat tersisa satu kursi tidak melewati MaxActiveSlots
+func TestAcquireSlotBersamaan(t *testing.T) {
+	kosongkanSlot(t)
+	var slots []interface{}
+	for i := 0; i < MaxActiveSlots-1; i++ {
+		slots = append(slots, model.CrowdfundingSlot{OrderID: "hampir-" + strconv.Itoa(i), MatchKey: "hampir:" + strconv.Itoa(i), ExpiryTime: time.Now().Add(time.Hour)})
+	}
+	if _, err := config.Mongoconn.Collection(SlotCollection).InsertMany(context.Background(), slots); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := AcquireSlot(QRIS{}, orderBaru("rebut-"+strconv.Itoa(i)), ""); err != nil && err != ErrNoFreeSlot {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	aktif, err := config.Mongoconn.Collection(SlotCollection).CountDocuments(context.Background(), bson.M{"expiryTime": bson.M{"$gt": time.Now()}})
+	if err != nil || aktif > MaxActiveSlots {
+		t.Fatalf("slot aktif %d, tidak boleh lebih dari %d: %v", aktif, MaxActiveSlots, err)
+	}
+}
+
+func TestClaimSlotTx(t *testing.T) {
+	kosongkanSlot(t)
+	for _, id := range []string{"tx-a", "tx-b"} {
+		if _, err := AcquireSlot(QRIS{}, orderBaru(id), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ClaimSlotTx("tx-a", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClaimSlotTx("tx-a", "abc"); err != nil {
+		t.Fatalf("klaim ulang transaksi yang sama oleh order yang sama seharusnya boleh: %v", err)
+	}
+	if !isTxClaimedByOtherSlot("abc", "tx-b") || isTxClaimedByOtherSlot("abc", "tx-a") {
+		t.Fatal("pemilik transaksi di slot salah")
+	}
+	if err := ClaimSlotTx("tx-b", "abc"); err != ErrTxAlreadyUsed {
+		t.Fatalf("transaksi milik order lain seharusnya ErrTxAlreadyUsed: %v", err)
+	}
+	if err := ClaimSlotTx("tx-a", "def"); err != ErrTxAlreadyUsed {
+		t.Fatalf("slot yang sudah punya transaksi tidak boleh diganti: %v", err)
+	}
+
+	//transaksi yang sudah melunasi order lain tidak bisa diklaim walaupun slotnya sudah dilepas
+	if _, err := atdb.InsertOneDoc(config.Mongoconn, "crowdfundingorders", model.CrowdfundingOrder{OrderID: "lunas", TxID: "ghi", Status: "success"}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsTxUsedBySuccessfulOrder("ghi", "tx-b") || IsTxUsedBySuccessfulOrder("ghi", "lunas") {
+		t.Fatal("transaksi order sukses tidak terdeteksi")
+	}
+	if err := ClaimSlotTx("tx-b", "ghi"); err != ErrTxAlreadyUsed {
+		t.Fatalf("transaksi order sukses seharusnya ErrTxAlreadyUsed: %v", err)
+	}
+}
+
+func TestSatoshiMatchesSlot(t *testing.T) {
+	slot := model.CrowdfundingSlot{UniqueCode: 42}
+	tests := []struct {
+		name   string
+		amount float64
+		want   bool
+	}{
+		{name: "tag cocok", amount: 1.00000042, want: true},
+		{name: "tag cocok tanpa pembulatan", amount: 0.29000042, want: true},
+		{name: "tag beda", amount: 1.00000043},
+		{name: "tanpa tag", amount: 1},
+		{name: "nol", amount: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := satoshiMatchesSlot(slot, coinToSatoshi(tt.amount)); got != tt.want {
+				t.Fatalf("satoshiMatchesSlot(%v) = %v, seharusnya %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package devmode menjalankan backend tanpa akses ke layanan luar.
+// Request keluar lewat atapi.Transport dialihkan ke server tiruan lokal yang mencatat setiap request
+// lalu menjawab dengan stub JSON, MongoDB lokal diisi fixture, dan pesan WA yang seharusnya terkirim
+// bisa dilihat di /dev/wa.
+package devmode
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/linkcheck"
+)
+
+// Start dipanggil run/main.go jika config.Dev aktif, sebelum server utama menerima request
+func Start() {
+	if config.ErrorMongoconn != nil {
+		log.Fatalf("mode dev: gagal konek MongoDB %s: %v", config.MongoString, config.ErrorMongoconn)
+	}
+	n, err := Seed(config.Mongoconn)
+	if err != nil {
+		log.Fatalf("mode dev: gagal memuat fixture: %v", err)
+	}
+	ln, err := net.Listen("tcp", config.DevFakeAddr)
+	if err != nil {
+		log.Fatalf("mode dev: server tiruan tidak bisa dijalankan di %s: %v", config.DevFakeAddr, err)
+	}
+	go func() {
+		log.Println(http.Serve(ln, Server()))
+	}()
+	atapi.Transport = Transport{Addr: config.DevFakeAddr}
+	linkcheck.Transport = atapi.Transport
+	log.Printf("mode dev: %d dokumen fixture dimuat, request keluar dialihkan ke http://%s, pesan WA di /dev/wa", n, config.DevFakeAddr)
+}
+
+// Transport mengalihkan request ke server tiruan dengan host asal sebagai segmen pertama path,
+// https://api.wa.my.id/api/v2/send/message/text menjadi http://127.0.0.1:8081/api.wa.my.id/api/v2/send/message/text
+type Transport struct {
+	Addr string
+	Base http.RoundTripper // default http.DefaultTransport
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = "http"
+	r.URL.Host = t.Addr
+	r.URL.Path = "/" + req.URL.Host + req.URL.Path
+	if req.URL.RawPath != "" {
+		r.URL.RawPath = "/" + req.URL.Host + req.URL.RawPath
+	}
+	r.Host = ""
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
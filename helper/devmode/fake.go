@@ -0,0 +1,154 @@
+package devmode
+
+import (
+	"embed"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+)
+
+//go:embed stub
+var stubs embed.FS
+
+const (
+	// maxCalls adalah jumlah request keluar yang disimpan, yang paling lama dibuang lebih dulu
+	maxCalls = 500
+	// maxBody membatasi body yang disimpan, dokumen WA berisi PDF base64
+	maxBody = 4096
+)
+
+// Call adalah satu request keluar yang diterima server tiruan
+type Call struct {
+	ID     int        `json:"id"`
+	Time   time.Time  `json:"time"`
+	Host   string     `json:"host"`
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Query  string     `json:"query,omitempty"`
+	Body   string     `json:"body,omitempty"`
+	Stub   string     `json:"stub,omitempty"` // kosong berarti belum ada stub, dijawab {}
+	WA     *WAMessage `json:"wa,omitempty"`
+}
+
+// WAMessage adalah pesan teks atau dokumen yang seharusnya dikirim lewat WA API
+type WAMessage struct {
+	To       string `json:"to"`
+	IsGroup  bool   `json:"isgroup,omitempty"`
+	Messages string `json:"messages,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+}
+
+var (
+	mu     sync.Mutex
+	calls  []Call
+	lastID int
+)
+
+// Server adalah server tiruan layanan luar. Segmen pertama path adalah host asal,
+// setiap request dicatat lalu dijawab dengan stub JSON yang cocok.
+func Server() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, p, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		p = path.Clean("/" + p)
+		body, _ := io.ReadAll(r.Body)
+		stub, name := findStub(host, p)
+		record(host, r.Method, p, r.URL.RawQuery, body, name)
+		w.Header().Set("Content-Type", "application/json")
+		if stub == nil {
+			log.Printf("mode dev: belum ada stub untuk %s %s%s", r.Method, host, p)
+			stub = []byte("{}")
+		}
+		w.Write(stub)
+	})
+}
+
+// findStub mencari stub host/path.json, jika tidak ada naik satu segmen sampai host.json.
+// Contoh /api/device/token-user dijawab oleh stub api.wa.my.id/api/device.json.
+func findStub(host, p string) (stub []byte, name string) {
+	if host == "" || strings.Contains(host, "..") {
+		return nil, ""
+	}
+	for {
+		name = host + strings.TrimSuffix(p, "/") + ".json"
+		if config.DevStubDir != "" {
+			if b, err := os.ReadFile(filepath.Join(config.DevStubDir, filepath.FromSlash(name))); err == nil {
+				return b, name
+			}
+		}
+		if b, err := stubs.ReadFile("stub/" + name); err == nil {
+			return b, name
+		}
+		if p == "/" || p == "" {
+			return nil, ""
+		}
+		p = p[:strings.LastIndex(p, "/")]
+	}
+}
+
+func record(host, method, p, query string, body []byte, stub string) {
+	call := Call{Time: time.Now(), Host: host, Method: method, Path: p, Query: query, Stub: stub}
+	if isWAMessage(host, p) {
+		var msg WAMessage
+		if json.Unmarshal(body, &msg) == nil {
+			call.WA = &msg
+		}
+	}
+	if len(body) > maxBody {
+		body = body[:maxBody]
+	}
+	call.Body = string(body)
+
+	mu.Lock()
+	defer mu.Unlock()
+	lastID++
+	call.ID = lastID
+	calls = append(calls, call)
+	if len(calls) > maxCalls {
+		calls = calls[len(calls)-maxCalls:]
+	}
+}
+
+// isWAMessage mengenali endpoint kirim pesan dari URL WA API di config
+func isWAMessage(host, p string) bool {
+	for _, api := range []string{config.WAAPIMessage, config.WAAPIDocMessage} {
+		u, err := url.Parse(api)
+		if err == nil && u.Host == host && u.Path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Calls mengembalikan request yang tercatat, terbaru di atas.
+// host kosong berarti semua host, onlyWA hanya mengambil pesan WA.
+func Calls(host string, onlyWA bool) []Call {
+	mu.Lock()
+	defer mu.Unlock()
+	res := make([]Call, 0)
+	for i := len(calls) - 1; i >= 0; i-- {
+		c := calls[i]
+		if (host != "" && c.Host != host) || (onlyWA && c.WA == nil) {
+			continue
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// Reset menghapus semua catatan request
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	calls = nil
+}
@@ -0,0 +1,60 @@
+package devmode
+
+import (
+	"context"
+	"embed"
+	"strings"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:embed fixture
+var fixtures embed.FS
+
+// Seed mengisi MongoDB dengan fixture, nama file adalah nama koleksi dan isinya array extended JSON.
+// Dokumen dengan _id yang sama ditimpa sehingga aman dijalankan ulang setiap start.
+// Profile bot WA dibuat dari config.DevPhoneNumber dan config.DevPublicKey supaya token /dev/token valid.
+func Seed(db *mongo.Database) (n int, err error) {
+	entries, err := fixtures.ReadDir("fixture")
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		coll := strings.TrimSuffix(entry.Name(), ".json")
+		b, err := fixtures.ReadFile("fixture/" + entry.Name())
+		if err != nil {
+			return n, err
+		}
+		//UnmarshalExtJSON hanya menerima dokumen, array dibungkus dulu
+		var wrap struct {
+			Docs []bson.M `bson:"docs"`
+		}
+		if err = bson.UnmarshalExtJSON([]byte(`{"docs":`+string(b)+`}`), false, &wrap); err != nil {
+			return n, err
+		}
+		for _, doc := range wrap.Docs {
+			_, err = db.Collection(coll).ReplaceOne(context.Background(), bson.M{"_id": doc["_id"]}, doc, options.Replace().SetUpsert(true))
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	profile := model.Profile{
+		Token:       "dev-wa-token",
+		Phonenumber: config.DevPhoneNumber,
+		Secret:      "dev-secret",
+		URL:         "http://localhost:8080/webhook/nomor/" + config.DevPhoneNumber,
+		QRKeyword:   "wh4t5auth0",
+		PublicKey:   config.DevPublicKey,
+	}
+	_, err = db.Collection("profile").ReplaceOne(context.Background(), bson.M{"phonenumber": profile.Phonenumber}, profile, options.Replace().SetUpsert(true))
+	if err != nil {
+		return
+	}
+	return n + 1, nil
+}
@@ -0,0 +1,53 @@
+package devmode
+
+import (
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetWAMessages menampilkan pesan WA yang seharusnya terkirim, terbaru di atas, filter nomor atau grup dengan ?to=
+func GetWAMessages(respw http.ResponseWriter, req *http.Request) {
+	to := req.URL.Query().Get("to")
+	msgs := make([]Call, 0)
+	for _, c := range Calls("", true) {
+		if to == "" || c.WA.To == to {
+			msgs = append(msgs, c)
+		}
+	}
+	at.WriteJSON(respw, http.StatusOK, msgs)
+}
+
+// GetCalls menampilkan semua request keluar yang diterima server tiruan, filter host dengan ?host=
+func GetCalls(respw http.ResponseWriter, req *http.Request) {
+	at.WriteJSON(respw, http.StatusOK, Calls(req.URL.Query().Get("host"), false))
+}
+
+// DeleteCalls mengosongkan catatan request keluar
+func DeleteCalls(respw http.ResponseWriter, req *http.Request) {
+	Reset()
+	at.WriteJSON(respw, http.StatusOK, model.Response{Status: "OK", Response: "catatan request keluar dikosongkan"})
+}
+
+// GetToken membuat token login 24 jam untuk user fixture, pengganti scan QR WhatsAuth selama mode dev
+func GetToken(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := router.Param(req, "phonenumber")
+	user, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
+	if err != nil {
+		at.WriteError(respw, req, apperr.Wrap(apperr.UserNotFound, "Error : User fixture tidak ditemukan", err))
+		return
+	}
+	token, err := watoken.EncodeforHours(user.PhoneNumber, user.Name, config.PrivateKey, 24)
+	if err != nil {
+		at.WriteError(respw, req, apperr.Wrap(apperr.Internal, "Error : Gagal membuat token", err))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, model.Response{Status: "OK", Info: user.Name, Response: token})
+}
@@ -0,0 +1,221 @@
+package eventjadwal
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection sama dengan koleksi event di controller
+const Collection = "events"
+
+// maksSusulan membatasi berapa periode yang dilewati saat cron lama tidak berjalan
+const maksSusulan = 1000
+
+var indexOnce sync.Once
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(Collection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{
+				// satu seri tidak boleh punya dua event dengan urutan yang sama walau cron berjalan bersamaan
+				Keys: bson.D{{Key: "seriesid", Value: 1}, {Key: "occurrence", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"seriesid": bson.M{"$exists": true}}),
+			},
+			{Keys: bson.D{{Key: "isactive", Value: 1}, {Key: "startat", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating events index: %v", err)
+		}
+	})
+}
+
+// Periksa memvalidasi jadwal dan aturan berulang pada request pembuatan event
+func Periksa(req model.EventCreateRequest, now time.Time) error {
+	mulai := req.StartAt
+	if mulai.IsZero() {
+		mulai = now
+	}
+	if !req.EndAt.IsZero() && !req.EndAt.After(mulai) {
+		return apperr.New(apperr.BadRequest, "", "end_at harus setelah start_at")
+	}
+	if !req.EndAt.IsZero() && !req.EndAt.After(now) {
+		return apperr.New(apperr.BadRequest, "", "end_at harus setelah waktu sekarang")
+	}
+	rec := req.Recurrence
+	if rec == nil {
+		return nil
+	}
+	if req.StartAt.IsZero() {
+		return apperr.New(apperr.BadRequest, "", "event berulang harus punya start_at")
+	}
+	switch rec.Frequency {
+	case model.RecurrenceDaily, model.RecurrenceWeekly, model.RecurrenceMonthly:
+	default:
+		return apperr.New(apperr.BadRequest, "", "frequency tidak dikenal, gunakan daily, weekly atau monthly")
+	}
+	if rec.Interval < 0 || rec.Count < 0 {
+		return apperr.New(apperr.BadRequest, "", "interval dan count tidak boleh negatif")
+	}
+	if !req.EndAt.IsZero() && Berikutnya(*rec, req.StartAt).Before(req.EndAt) {
+		return apperr.New(apperr.BadRequest, "", "rentang start_at sampai end_at lebih panjang dari satu periode perulangan")
+	}
+	if !rec.Until.IsZero() && rec.Until.Before(req.StartAt) {
+		return apperr.New(apperr.BadRequest, "", "until harus setelah start_at")
+	}
+	return nil
+}
+
+// Berikutnya menghitung waktu mulai satu periode setelah mulai
+func Berikutnya(rec model.EventRecurrence, mulai time.Time) time.Time {
+	n := rec.Interval
+	if n <= 0 {
+		n = 1
+	}
+	switch rec.Frequency {
+	case model.RecurrenceDaily:
+		return mulai.AddDate(0, 0, n)
+	case model.RecurrenceWeekly:
+		return mulai.AddDate(0, 0, 7*n)
+	default:
+		return mulai.AddDate(0, n, 0)
+	}
+}
+
+// susulan mencari event berikutnya dari seri e. Periode yang sudah berakhir sebelum now dilewati
+// tapi tetap dihitung untuk Count, jadi cron yang lama mati tidak mengumumkan tantangan basi.
+func susulan(e model.Event, now time.Time) (next model.Event, ok bool) {
+	if e.Recurrence == nil || e.StartAt.IsZero() {
+		return next, false
+	}
+	rec := *e.Recurrence
+	durasi := e.EndAt.Sub(e.StartAt)
+	next = e
+	for i := 0; i < maksSusulan; i++ {
+		next.Occurrence++
+		next.StartAt = Berikutnya(rec, next.StartAt)
+		if rec.Count > 0 && next.Occurrence > rec.Count {
+			return next, false
+		}
+		if !rec.Until.IsZero() && next.StartAt.After(rec.Until) {
+			return next, false
+		}
+		if e.EndAt.IsZero() {
+			return next, true
+		}
+		next.EndAt = next.StartAt.Add(durasi)
+		if next.EndAt.After(now) {
+			return next, true
+		}
+	}
+	return next, false
+}
+
+// BuatBerikutnya menyimpan event berikutnya dari seri e dalam keadaan belum aktif lalu menandai e dengan Lanjutan.
+// Event yang sudah dibuat cron lain diabaikan karena index unik seriesid dan occurrence.
+func BuatBerikutnya(db *mongo.Database, e model.Event, now time.Time) (next model.Event, dibuat bool, err error) {
+	ensureIndexes(db)
+	next, ok := susulan(e, now)
+	if ok {
+		next.ID = primitive.NilObjectID
+		if next.SeriesID.IsZero() {
+			next.SeriesID = e.ID
+		}
+		next.IsActive = false
+		next.AnnouncedAt = time.Time{}
+		next.ClaimCount = 0
+		next.Lanjutan = false
+		next.CreatedAt = now
+		next.ID, err = atdb.InsertOneDoc(db, Collection, next)
+		dibuat = err == nil
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return next, false, apperr.Wrap(apperr.Database, "", err)
+		}
+	}
+	_, err = db.Collection(Collection).UpdateOne(context.Background(), bson.M{"_id": e.ID}, bson.M{"$set": bson.M{"lanjutan": true}})
+	if err != nil {
+		return next, dibuat, apperr.Wrap(apperr.Database, "", err)
+	}
+	return next, dibuat, nil
+}
+
+// Aktifkan menyalakan event terjadwal yang waktu tayangnya sudah tiba. Event berikutnya pada seri berulang dibuat
+// lebih dulu, jika gagal event belum ditandai tayang sehingga dicoba lagi pada run berikutnya.
+// Hanya event yang berhasil diubah oleh pemanggil ini yang dikembalikan, sehingga pengumuman tidak terkirim dua kali.
+// Event yang jendela tayangnya sudah lewat ditandai tanpa diaktifkan dan tidak diumumkan.
+func Aktifkan(db *mongo.Database, now time.Time) (aktif []model.Event, err error) {
+	ensureIndexes(db)
+	events, err := atdb.GetAllDoc[[]model.Event](db, Collection, bson.M{
+		"isactive":    false,
+		"startat":     bson.M{"$lte": now},
+		"announcedat": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Database, "", err)
+	}
+	for _, e := range events {
+		if e.Recurrence != nil {
+			if _, _, err = BuatBerikutnya(db, e, now); err != nil {
+				log.Printf("Error membuat event berikutnya dari %s, event ditunda: %v", e.ID.Hex(), err)
+				continue
+			}
+		}
+		terlewat := !e.EndAt.IsZero() && !e.EndAt.After(now)
+		res, err := db.Collection(Collection).UpdateOne(context.Background(),
+			bson.M{"_id": e.ID, "announcedat": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"isactive": !terlewat, "announcedat": now}})
+		if err != nil {
+			return aktif, apperr.Wrap(apperr.Database, "", err)
+		}
+		if res.ModifiedCount == 0 {
+			continue
+		}
+		if terlewat {
+			continue
+		}
+		e.IsActive, e.AnnouncedAt = true, now
+		aktif = append(aktif, e)
+	}
+	susulkan(db, now)
+	return aktif, nil
+}
+
+// susulkan membuat event berikutnya untuk seri yang sudah tayang tapi belum punya lanjutan,
+// misalnya event berulang yang langsung tayang saat dibuat dan pembuatan lanjutannya gagal
+func susulkan(db *mongo.Database, now time.Time) {
+	events, err := atdb.GetAllDoc[[]model.Event](db, Collection, bson.M{
+		"recurrence":  bson.M{"$exists": true},
+		"announcedat": bson.M{"$exists": true},
+		"lanjutan":    bson.M{"$exists": false},
+	})
+	if err != nil {
+		log.Printf("Error mengambil seri event tanpa lanjutan: %v", err)
+		return
+	}
+	for _, e := range events {
+		if _, _, err = BuatBerikutnya(db, e, now); err != nil {
+			log.Printf("Error membuat event berikutnya dari %s: %v", e.ID.Hex(), err)
+		}
+	}
+}
+
+// Kedaluwarsa menonaktifkan event aktif yang EndAt-nya sudah lewat
+func Kedaluwarsa(db *mongo.Database, now time.Time) (int64, error) {
+	res, err := db.Collection(Collection).UpdateMany(context.Background(),
+		bson.M{"isactive": true, "endat": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"isactive": false}})
+	if err != nil {
+		return 0, apperr.Wrap(apperr.Database, "", err)
+	}
+	return res.ModifiedCount, nil
+}
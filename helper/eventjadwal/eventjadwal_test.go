@@ -0,0 +1,77 @@
+package eventjadwal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocroot/model"
+)
+
+func TestPeriksaEndAt(t *testing.T) {
+	now := time.Date(2099, 1, 5, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		salah      bool
+	}{
+		{name: "tanpa jadwal"},
+		{name: "end setelah sekarang", end: now.Add(time.Hour)},
+		{name: "end sama dengan sekarang", end: now, salah: true},
+		{name: "end sebelum start", start: now.Add(2 * time.Hour), end: now.Add(time.Hour), salah: true},
+		{name: "start lampau end lampau", start: now.Add(-2 * time.Hour), end: now.Add(-time.Hour), salah: true},
+		{name: "start lampau end nanti", start: now.Add(-2 * time.Hour), end: now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Periksa(model.EventCreateRequest{StartAt: tt.start, EndAt: tt.end}, now)
+			if (err != nil) != tt.salah {
+				t.Fatalf("Periksa = %v, seharusnya error %v", err, tt.salah)
+			}
+		})
+	}
+}
+
+func TestSusulan(t *testing.T) {
+	mulai := time.Date(2099, 1, 5, 8, 0, 0, 0, time.UTC)
+	mingguan := model.Event{
+		StartAt:    mulai,
+		EndAt:      mulai.Add(48 * time.Hour),
+		Occurrence: 1,
+		Recurrence: &model.EventRecurrence{Frequency: model.RecurrenceWeekly},
+	}
+	tests := []struct {
+		name      string
+		e         model.Event
+		rec       model.EventRecurrence
+		now       time.Time
+		wantMulai time.Time
+		wantKe    int
+		wantOK    bool
+	}{
+		{name: "minggu berikutnya", e: mingguan, rec: *mingguan.Recurrence, now: mulai, wantMulai: mulai.AddDate(0, 0, 7), wantKe: 2, wantOK: true},
+		{name: "dua mingguan", e: mingguan, rec: model.EventRecurrence{Frequency: model.RecurrenceWeekly, Interval: 2}, now: mulai, wantMulai: mulai.AddDate(0, 0, 14), wantKe: 2, wantOK: true},
+		{name: "periode yang sudah berakhir dilewati", e: mingguan, rec: *mingguan.Recurrence, now: mulai.AddDate(0, 0, 16), wantMulai: mulai.AddDate(0, 0, 21), wantKe: 4, wantOK: true},
+		{name: "count habis", e: mingguan, rec: model.EventRecurrence{Frequency: model.RecurrenceWeekly, Count: 1}, now: mulai},
+		{name: "lewat until", e: mingguan, rec: model.EventRecurrence{Frequency: model.RecurrenceDaily, Until: mulai.Add(time.Hour)}, now: mulai},
+		{name: "bulanan", e: mingguan, rec: model.EventRecurrence{Frequency: model.RecurrenceMonthly}, now: mulai, wantMulai: mulai.AddDate(0, 1, 0), wantKe: 2, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.e
+			e.Recurrence = &tt.rec
+			next, ok := susulan(e, tt.now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, seharusnya %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !next.StartAt.Equal(tt.wantMulai) || next.Occurrence != tt.wantKe {
+				t.Fatalf("event berikutnya mulai %v ke-%d, seharusnya %v ke-%d", next.StartAt, next.Occurrence, tt.wantMulai, tt.wantKe)
+			}
+			if got := next.EndAt.Sub(next.StartAt); got != 48*time.Hour {
+				t.Fatalf("durasi tayang %v, seharusnya tetap 48 jam", got)
+			}
+		})
+	}
+}
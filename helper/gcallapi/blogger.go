@@ -37,7 +37,7 @@ func createBloggerService(ctx context.Context, db *mongo.Database) (*blogger.Ser
 		}
 	}
 
-	client := config.Client(ctx, token)
+	client := config.Client(oauthContext(ctx), token)
 	srv, err := blogger.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
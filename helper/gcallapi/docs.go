@@ -35,7 +35,7 @@ func createDocsService(ctx context.Context, db *mongo.Database) (*docs.Service,
 		}
 	}
 
-	client := config.Client(ctx, token)
+	client := config.Client(oauthContext(ctx), token)
 	srv, err := docs.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
@@ -37,7 +37,7 @@ func createDriveService(ctx context.Context, db *mongo.Database) (*drive.Service
 		}
 	}
 
-	client := config.Client(ctx, token)
+	client := config.Client(oauthContext(ctx), token)
 	srv, err := drive.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
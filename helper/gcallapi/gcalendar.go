@@ -10,9 +10,8 @@ import (
 	"google.golang.org/api/option"
 )
 
-func HandlerCalendar(db *mongo.Database, simpleEvent SimpleEvent) (*calendar.Event, error) {
-	ctx := context.Background()
-
+// Helper function to create a Calendar service
+func createCalendarService(ctx context.Context, db *mongo.Database) (*calendar.Service, error) {
 	// Retrieve OAuth2 config from DB
 	config, err := credentialsFromDB(db)
 	if err != nil {
@@ -38,12 +37,48 @@ func HandlerCalendar(db *mongo.Database, simpleEvent SimpleEvent) (*calendar.Eve
 		}
 	}
 
-	client := config.Client(ctx, token)
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	client := config.Client(oauthContext(ctx), token)
+	return calendar.NewService(ctx, option.WithHTTPClient(client))
+}
+
+func HandlerCalendar(db *mongo.Database, simpleEvent SimpleEvent) (*calendar.Event, error) {
+	ctx := context.Background()
+	srv, err := createCalendarService(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	event := toCalendarEvent(simpleEvent)
+	calendarId := "primary"
+	event, err = srv.Events.Insert(calendarId, event).Do()
 	if err != nil {
 		return nil, err
 	}
 
+	return event, nil
+}
+
+// UpdateCalendarEvent mengganti waktu, lokasi dan peserta event yang sudah dibuat HandlerCalendar
+func UpdateCalendarEvent(db *mongo.Database, eventID string, simpleEvent SimpleEvent) (*calendar.Event, error) {
+	ctx := context.Background()
+	srv, err := createCalendarService(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return srv.Events.Update("primary", eventID, toCalendarEvent(simpleEvent)).Do()
+}
+
+// DeleteCalendarEvent menghapus event dari kalender primary
+func DeleteCalendarEvent(db *mongo.Database, eventID string) error {
+	ctx := context.Background()
+	srv, err := createCalendarService(ctx, db)
+	if err != nil {
+		return err
+	}
+	return srv.Events.Delete("primary", eventID).Do()
+}
+
+func toCalendarEvent(simpleEvent SimpleEvent) *calendar.Event {
 	startDateTime := fmt.Sprintf("%sT%s+07:00", simpleEvent.Date, simpleEvent.TimeStart)
 	endDateTime := fmt.Sprintf("%sT%s+07:00", simpleEvent.Date, simpleEvent.TimeEnd)
 
@@ -77,12 +112,5 @@ func HandlerCalendar(db *mongo.Database, simpleEvent SimpleEvent) (*calendar.Eve
 		}
 
 	}
-
-	calendarId := "primary"
-	event, err = srv.Events.Insert(calendarId, event).Do()
-	if err != nil {
-		return nil, err
-	}
-
-	return event, nil
+	return event
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/gocroot/helper/atapi"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -80,9 +81,14 @@ func saveToken(db *mongo.Database, token *oauth2.Token) error {
 	return nil
 }
 
+// oauthContext membuat oauth2 dan client Google API mengirim request lewat atapi.Transport
+func oauthContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, atapi.NewClient(0))
+}
+
 // Refresh the token using the refresh token
 func refreshToken(config *oauth2.Config, token *oauth2.Token) (*oauth2.Token, error) {
-	ts := config.TokenSource(context.Background(), token)
+	ts := config.TokenSource(oauthContext(context.Background()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
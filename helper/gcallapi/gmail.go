@@ -40,7 +40,7 @@ func createGmailService(ctx context.Context, db *mongo.Database) (*gmail.Service
 		}
 	}
 
-	client := config.Client(ctx, token)
+	client := config.Client(oauthContext(ctx), token)
 	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"io"
 	"mime/multipart"
 
+	"github.com/gocroot/helper/atapi"
 	"github.com/google/go-github/v59/github"
 
 	"golang.org/x/oauth2"
@@ -28,7 +29,7 @@ func GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail string,
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: GitHubAccessToken},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	tc := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, atapi.NewClient(0)), ts)
 	client := github.NewClient(tc)
 
 	// Membuat opsi untuk mengunggah file
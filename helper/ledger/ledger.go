@@ -0,0 +1,307 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan semua transaksi poin, hanya pernah di-insert
+const Collection = "poinledger"
+
+// SourceSaldoAwal dipakai rekonsiliasi pertama untuk mencatat saldo user sebelum ledger ada
+const SourceSaldoAwal = "saldoawal"
+
+// prefixBatal adalah awalan idempotency key transaksi pembalik untuk percobaan yang gagal mengubah saldo
+const prefixBatal = "batal:"
+
+// JedaPerbaikan adalah umur transaksi terbaru user yang masih dianggap sedang diproses Post,
+// saldo user tersebut tidak ditimpa rekonsiliasi karena $inc-nya mungkin belum dijalankan
+var JedaPerbaikan = time.Minute
+
+var (
+	ErrDuplicate       = errors.New("transaksi poin dengan idempotency key ini sudah tercatat")
+	ErrSaldoTidakCukup = errors.New("saldo poin tidak cukup")
+
+	indexOnce sync.Once
+)
+
+// Posting adalah perubahan saldo satu user. Amount positif menambah saldo, negatif mengurangi.
+type Posting struct {
+	Account        string // model.AkunPoin atau model.AkunPointEvent
+	Amount         float64
+	Source         string
+	ReferenceID    string
+	IdempotencyKey string // kosong berarti transaksi selalu dicatat baru
+	Keterangan     string
+	WajibCukup     bool // debit ditolak jika saldo kurang dari Amount
+}
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		coll := db.Collection(Collection)
+		_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "idempotencykey", Value: 1}, {Key: "percobaan", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "entries.phonenumber", Value: 1}, {Key: "entries.account", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating poinledger indexes: %v", err)
+			return
+		}
+		// index lama unik per key saja, diganti index per key dan percobaan supaya key bisa diulang setelah dibatalkan
+		coll.Indexes().DropOne(context.Background(), "idempotencykey_1")
+	})
+}
+
+// Post mencatat transaksi di ledger lalu mengubah saldo user yang cocok dengan filter memakai $inc.
+// Transaksi dengan idempotency key yang sama hanya dicatat sekali, panggilan berikutnya mendapat ErrDuplicate.
+// Ledger tidak pernah dihapus: jika saldo gagal diubah, dicatat transaksi pembalik dengan key batal:<key>
+// dan percobaan berikutnya dengan key yang sama disimpan sebagai percobaan baru.
+func Post(db *mongo.Database, userFilter bson.M, p Posting) (usr model.Userdomyikado, res *mongo.UpdateResult, err error) {
+	if p.Account != model.AkunPoin && p.Account != model.AkunPointEvent {
+		err = errors.New("akun " + p.Account + " tidak dikenal")
+		return
+	}
+	ensureIndexes(db)
+	usr, err = atdb.GetOneDoc[model.Userdomyikado](db, "user", userFilter)
+	if err != nil {
+		return
+	}
+	if p.IdempotencyKey == "" {
+		p.IdempotencyKey = p.Source + ":" + primitive.NewObjectID().Hex()
+	}
+	batal, err := db.Collection(Collection).CountDocuments(context.Background(), bson.M{"idempotencykey": prefixBatal + p.IdempotencyKey})
+	if err != nil {
+		return
+	}
+	trx := model.PoinLedger{
+		IdempotencyKey: p.IdempotencyKey,
+		Percobaan:      int(batal),
+		Source:         p.Source,
+		ReferenceID:    p.ReferenceID,
+		Keterangan:     p.Keterangan,
+		Entries: []model.PoinLedgerEntry{
+			{Account: p.Account, PhoneNumber: usr.PhoneNumber, Amount: p.Amount},
+			{Account: "sistem:" + p.Source, Amount: -p.Amount},
+		},
+		CreatedAt: time.Now(),
+	}
+	ins, err := db.Collection(Collection).InsertOne(context.Background(), trx)
+	if mongo.IsDuplicateKeyError(err) {
+		err = ErrDuplicate
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{"_id": usr.ID}
+	if p.WajibCukup && p.Amount < 0 {
+		filter[p.Account] = bson.M{"$gte": -p.Amount}
+	}
+	res, err = db.Collection("user").UpdateOne(context.Background(), filter, bson.M{"$inc": bson.M{p.Account: nilaiAkun(p.Account, p.Amount)}})
+	if err == nil && res.MatchedCount == 0 {
+		err = ErrSaldoTidakCukup
+	}
+	if err != nil {
+		batalkan(db, trx, ins.InsertedID.(primitive.ObjectID))
+		return
+	}
+	usr, err = atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"_id": usr.ID})
+	return
+}
+
+// batalkan mencatat transaksi pembalik untuk trx yang saldonya gagal diubah sehingga jumlah ledger user kembali
+func batalkan(db *mongo.Database, trx model.PoinLedger, id primitive.ObjectID) {
+	balik := model.PoinLedger{
+		IdempotencyKey: prefixBatal + trx.IdempotencyKey,
+		Percobaan:      trx.Percobaan,
+		Source:         trx.Source,
+		ReferenceID:    id.Hex(),
+		Keterangan:     "Pembatalan transaksi yang gagal mengubah saldo",
+		CreatedAt:      time.Now(),
+	}
+	for _, e := range trx.Entries {
+		balik.Entries = append(balik.Entries, model.PoinLedgerEntry{Account: e.Account, PhoneNumber: e.PhoneNumber, Amount: -e.Amount})
+	}
+	_, err := db.Collection(Collection).InsertOne(context.Background(), balik)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Printf("Error saving pembatalan ledger %s: %v", trx.IdempotencyKey, err)
+	}
+}
+
+// Reconcile menghitung ulang saldo poin dan pointevent setiap user dari ledger.
+// User yang belum punya saldo awal tidak dianggap drift, selisihnya dicatat sebagai saldo awal hanya jika perbaiki true.
+// Jika perbaiki true, saldo dan ledger user yang berbeda dibaca ulang lalu saldo ditimpa dengan jumlah ledger.
+// Dry run (perbaiki false) tidak menulis apa pun.
+func Reconcile(db *mongo.Database, perbaiki bool) (drifts []model.PoinDrift, err error) {
+	ensureIndexes(db)
+	saldoLedger, err := jumlahLedger(db)
+	if err != nil {
+		return
+	}
+	saldoAwal := make(map[string]bool)
+	awal, err := atdb.GetAllDoc[[]model.PoinLedger](db, Collection, bson.M{"source": SourceSaldoAwal})
+	if err != nil {
+		return
+	}
+	for _, trx := range awal {
+		for _, e := range trx.Entries {
+			saldoAwal[e.Account+":"+e.PhoneNumber] = true
+		}
+	}
+	users, err := atdb.GetAllDoc[[]model.Userdomyikado](db, "user", bson.M{"phonenumber": bson.M{"$ne": ""}})
+	if err != nil {
+		return
+	}
+	for _, usr := range users {
+		for _, akun := range []string{model.AkunPoin, model.AkunPointEvent} {
+			saldo := usr.Poin
+			if akun == model.AkunPointEvent {
+				saldo = float64(usr.PointEvent)
+			}
+			key := akun + ":" + usr.PhoneNumber
+			jumlah := saldoLedger[key]
+			if !saldoAwal[key] {
+				if !perbaiki {
+					continue
+				}
+				_, errAwal := db.Collection(Collection).InsertOne(context.Background(), model.PoinLedger{
+					IdempotencyKey: SourceSaldoAwal + ":" + key,
+					Source:         SourceSaldoAwal,
+					Keterangan:     "Saldo sebelum ledger dipakai",
+					Entries: []model.PoinLedgerEntry{
+						{Account: akun, PhoneNumber: usr.PhoneNumber, Amount: saldo - jumlah},
+						{Account: "sistem:" + SourceSaldoAwal, Amount: jumlah - saldo},
+					},
+					CreatedAt: time.Now(),
+				})
+				if errAwal != nil && !mongo.IsDuplicateKeyError(errAwal) {
+					log.Printf("Error saving saldo awal %s: %v", key, errAwal)
+				}
+				continue
+			}
+			if math.Abs(saldo-jumlah) < 1e-9 {
+				continue
+			}
+			drifts = append(drifts, model.PoinDrift{
+				PhoneNumber: usr.PhoneNumber,
+				Account:     akun,
+				Saldo:       saldo,
+				Ledger:      jumlah,
+				Selisih:     saldo - jumlah,
+			})
+			if perbaiki {
+				if err = perbaikiSaldo(db, usr.ID, akun); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// perbaikiSaldo membaca ulang saldo user dan menjumlah ulang ledger miliknya sebelum menimpa saldo,
+// karena jumlah seluruh ledger di Reconcile dibaca lebih dulu dan bisa tertinggal dari Post yang berjalan bersamaan.
+// User dengan transaksi lebih baru dari JedaPerbaikan dilewati dan dicek lagi di rekonsiliasi berikutnya.
+func perbaikiSaldo(db *mongo.Database, id primitive.ObjectID, akun string) error {
+	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	saldo := usr.Poin
+	if akun == model.AkunPointEvent {
+		saldo = float64(usr.PointEvent)
+	}
+	jumlah, terakhir, err := jumlahAkun(db, akun, usr.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	if time.Since(terakhir) < JedaPerbaikan || math.Abs(saldo-jumlah) < 1e-9 {
+		return nil
+	}
+	// saldo hanya ditimpa jika belum berubah sejak dibaca ulang
+	lama := interface{}(nilaiAkun(akun, saldo))
+	if saldo == 0 {
+		lama = bson.M{"$in": bson.A{0, nil}}
+	}
+	_, err = db.Collection("user").UpdateOne(context.Background(),
+		bson.M{"_id": id, akun: lama},
+		bson.M{"$set": bson.M{akun: nilaiAkun(akun, jumlah)}})
+	return err
+}
+
+// jumlahAkun menjumlahkan entri satu akun milik satu user beserta waktu transaksi terbarunya
+func jumlahAkun(db *mongo.Database, akun, phonenumber string) (jumlah float64, terakhir time.Time, err error) {
+	cur, err := db.Collection(Collection).Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"entries.phonenumber": phonenumber}}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: bson.M{"entries.phonenumber": phonenumber, "entries.account": akun}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      nil,
+			"jumlah":   bson.M{"$sum": "$entries.amount"},
+			"terakhir": bson.M{"$max": "$createdAt"},
+		}}},
+	})
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.Background())
+	var hasil []struct {
+		Jumlah   float64   `bson:"jumlah"`
+		Terakhir time.Time `bson:"terakhir"`
+	}
+	if err = cur.All(context.Background(), &hasil); err != nil || len(hasil) == 0 {
+		return
+	}
+	return hasil[0].Jumlah, hasil[0].Terakhir, nil
+}
+
+// jumlahLedger menjumlahkan entri per akun dan nomor hp, key map berbentuk akun:nomor
+func jumlahLedger(db *mongo.Database) (map[string]float64, error) {
+	cur, err := db.Collection(Collection).Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: bson.M{"entries.phonenumber": bson.M{"$exists": true, "$ne": ""}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"account": "$entries.account", "phonenumber": "$entries.phonenumber"},
+			"jumlah": bson.M{"$sum": "$entries.amount"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+	var hasil []struct {
+		ID struct {
+			Account     string `bson:"account"`
+			PhoneNumber string `bson:"phonenumber"`
+		} `bson:"_id"`
+		Jumlah float64 `bson:"jumlah"`
+	}
+	if err = cur.All(context.Background(), &hasil); err != nil {
+		return nil, err
+	}
+	saldo := make(map[string]float64, len(hasil))
+	for _, h := range hasil {
+		saldo[h.ID.Account+":"+h.ID.PhoneNumber] = h.Jumlah
+	}
+	return saldo, nil
+}
+
+// nilaiAkun menyesuaikan tipe dengan field user, pointevent disimpan sebagai bilangan bulat
+func nilaiAkun(akun string, v float64) interface{} {
+	if akun == model.AkunPointEvent {
+		return int64(math.Round(v))
+	}
+	return v
+}
@@ -0,0 +1,159 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/mongotest"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var db *mongo.Database
+
+func TestMain(m *testing.M) {
+	mongotest.Main(m, "ledgertest", func(d *mongo.Database) { db = d })
+}
+
+func userBaru(t *testing.T, phone string, poin float64, pointevent int) model.Userdomyikado {
+	t.Helper()
+	usr := model.Userdomyikado{PhoneNumber: phone, Name: "User " + phone, Poin: poin, PointEvent: pointevent}
+	id, err := atdb.InsertOneDoc(db, "user", usr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usr.ID = id
+	return usr
+}
+
+func jumlahKey(t *testing.T, key string) (n int, total float64) {
+	t.Helper()
+	rows, err := atdb.GetAllDoc[[]model.PoinLedger](db, Collection, bson.M{"idempotencykey": bson.M{"$in": bson.A{key, prefixBatal + key}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trx := range rows {
+		for _, e := range trx.Entries {
+			if e.PhoneNumber != "" {
+				total += e.Amount
+			}
+		}
+	}
+	return len(rows), total
+}
+
+func TestPost(t *testing.T) {
+	usr := userBaru(t, "6289900000001", 0, 10)
+	filter := bson.M{"phonenumber": usr.PhoneNumber}
+
+	got, _, err := Post(db, filter, Posting{Account: model.AkunPointEvent, Amount: 5, Source: "event", IdempotencyKey: "event:1"})
+	if err != nil || got.PointEvent != 15 {
+		t.Fatalf("kredit = %d %v, seharusnya 15", got.PointEvent, err)
+	}
+	if _, _, err = Post(db, filter, Posting{Account: model.AkunPointEvent, Amount: 5, Source: "event", IdempotencyKey: "event:1"}); err != ErrDuplicate {
+		t.Fatalf("key yang sama seharusnya ErrDuplicate: %v", err)
+	}
+	if _, _, err = Post(db, filter, Posting{Account: "saldo", Amount: 5, Source: "event"}); err == nil {
+		t.Fatal("akun tidak dikenal seharusnya ditolak")
+	}
+
+	//debit yang gagal dibatalkan dengan transaksi pembalik, bukan dihapus
+	beli := Posting{Account: model.AkunPointEvent, Amount: -20, Source: "store", IdempotencyKey: "store:1", WajibCukup: true}
+	if _, _, err = Post(db, filter, beli); err != ErrSaldoTidakCukup {
+		t.Fatalf("debit melebihi saldo seharusnya ErrSaldoTidakCukup: %v", err)
+	}
+	if n, total := jumlahKey(t, "store:1"); n != 2 || total != 0 {
+		t.Fatalf("percobaan gagal seharusnya dua baris berjumlah nol: %d baris, jumlah %v", n, total)
+	}
+
+	//key yang sama boleh diulang setelah saldo cukup dan dicatat sebagai percobaan berikutnya
+	if _, _, err = Post(db, filter, Posting{Account: model.AkunPointEvent, Amount: 10, Source: "event"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err = Post(db, filter, beli)
+	if err != nil || got.PointEvent != 5 {
+		t.Fatalf("debit ulang = %d %v, seharusnya 5", got.PointEvent, err)
+	}
+	ulang, err := atdb.GetOneDoc[model.PoinLedger](db, Collection, bson.M{"idempotencykey": "store:1", "percobaan": 1})
+	if err != nil || ulang.Entries[0].Amount != -20 {
+		t.Fatalf("percobaan kedua tidak tercatat: %v %v", ulang, err)
+	}
+	if n, total := jumlahKey(t, "store:1"); n != 3 || total != -20 {
+		t.Fatalf("ledger store:1 %d baris, jumlah %v, seharusnya 3 baris berjumlah -20", n, total)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	usr := userBaru(t, "6289900000002", 2.5, 7)
+	awal := func() int64 {
+		n, err := atdb.GetCountDoc(db, Collection, bson.M{"source": SourceSaldoAwal, "entries.phonenumber": usr.PhoneNumber})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	//dry run tidak menulis saldo awal
+	drifts, err := Reconcile(db, false)
+	if err != nil || awal() != 0 {
+		t.Fatalf("dry run tidak boleh mencatat saldo awal: %d %v", awal(), err)
+	}
+	//rekonsiliasi dengan perbaikan mencatat saldo awal sehingga tidak ada drift
+	if drifts, err = Reconcile(db, true); err != nil || awal() != 2 {
+		t.Fatalf("saldo awal = %d %v, seharusnya 2", awal(), err)
+	}
+	for _, d := range drifts {
+		if d.PhoneNumber == usr.PhoneNumber {
+			t.Fatalf("saldo awal tidak boleh dianggap drift: %+v", d)
+		}
+	}
+	if _, _, err = Post(db, bson.M{"_id": usr.ID}, Posting{Account: model.AkunPoin, Amount: 1.5, Source: "presensi"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Collection("user").UpdateOne(context.Background(), bson.M{"_id": usr.ID}, bson.M{"$set": bson.M{"poin": 10.0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	cari := func(drifts []model.PoinDrift) *model.PoinDrift {
+		for i, d := range drifts {
+			if d.PhoneNumber == usr.PhoneNumber && d.Account == model.AkunPoin {
+				return &drifts[i]
+			}
+		}
+		return nil
+	}
+	saldo := func() float64 {
+		got, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"_id": usr.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Poin
+	}
+
+	//dry run hanya melaporkan
+	drifts, err = Reconcile(db, false)
+	if d := cari(drifts); err != nil || d == nil || d.Ledger != 4 || d.Selisih != 6 {
+		t.Fatalf("drift = %+v %v, seharusnya ledger 4 selisih 6", d, err)
+	}
+	if saldo() != 10 {
+		t.Fatal("dry run tidak boleh mengubah saldo")
+	}
+
+	//transaksi terbaru masih dalam JedaPerbaikan sehingga saldo belum ditimpa
+	if _, err = Reconcile(db, true); err != nil || saldo() != 10 {
+		t.Fatalf("saldo dengan transaksi baru seharusnya dilewati: %v %v", saldo(), err)
+	}
+	jeda := JedaPerbaikan
+	JedaPerbaikan = 0
+	defer func() { JedaPerbaikan = jeda }()
+	time.Sleep(time.Millisecond)
+	if _, err = Reconcile(db, true); err != nil || saldo() != 4 {
+		t.Fatalf("saldo setelah diperbaiki = %v %v, seharusnya 4", saldo(), err)
+	}
+	drifts, err = Reconcile(db, false)
+	if d := cari(drifts); err != nil || d != nil {
+		t.Fatalf("drift seharusnya hilang: %+v %v", d, err)
+	}
+}
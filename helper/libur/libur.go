@@ -0,0 +1,254 @@
+package libur
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan kalender libur nasional dan libur tambahan per kampus
+const Collection = "liburnasional"
+
+const (
+	FormatTanggal = "2006-01-02"
+
+	// cacheTTL membatasi umur cache, perubahan lewat API langsung menghapus cache
+	cacheTTL = 30 * time.Minute
+	// maksRentang mencegah rentang tanggal salah ketik membuat ribuan dokumen
+	maksRentang = 120
+)
+
+var (
+	cacheMu     sync.RWMutex
+	cacheLibur  map[string]map[string]bool // kampus -> tanggal, kampus kosong untuk libur nasional
+	cacheLoaded time.Time
+
+	indexOnce sync.Once
+)
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(Collection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "tanggal", Value: 1}, {Key: "kampus", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating liburnasional index: %v", err)
+		}
+	})
+}
+
+// IsLibur mengecek tanggal di kalender yang tersimpan, tanpa memanggil API luar.
+// Libur nasional berlaku untuk semua kampus, libur kampus hanya jika kampus diisi.
+func IsLibur(db *mongo.Database, thedate time.Time, kampus string) bool {
+	tanggal := thedate.Format(FormatTanggal)
+	kalender := getCache(db)
+	if kalender[""][tanggal] {
+		return true
+	}
+	return kampus != "" && kalender[kampus][tanggal]
+}
+
+// Invalidate menghapus cache supaya perubahan kalender langsung terbaca
+func Invalidate() {
+	cacheMu.Lock()
+	cacheLoaded = time.Time{}
+	cacheMu.Unlock()
+}
+
+func getCache(db *mongo.Database) map[string]map[string]bool {
+	cacheMu.RLock()
+	kalender, loaded := cacheLibur, cacheLoaded
+	cacheMu.RUnlock()
+	if kalender != nil && time.Since(loaded) < cacheTTL {
+		return kalender
+	}
+	cur, err := db.Collection(Collection).Find(context.Background(), bson.M{})
+	if err != nil {
+		// cache lama tetap dipakai jika database tidak bisa dibaca
+		log.Printf("Error loading liburnasional: %v", err)
+		return kalender
+	}
+	defer cur.Close(context.Background())
+	var docs []model.LiburNasional
+	if err = cur.All(context.Background(), &docs); err != nil {
+		log.Printf("Error decoding liburnasional: %v", err)
+		return kalender
+	}
+	kalender = make(map[string]map[string]bool)
+	for _, doc := range docs {
+		if kalender[doc.Kampus] == nil {
+			kalender[doc.Kampus] = make(map[string]bool)
+		}
+		kalender[doc.Kampus][doc.Tanggal] = true
+	}
+	cacheMu.Lock()
+	cacheLibur, cacheLoaded = kalender, time.Now()
+	cacheMu.Unlock()
+	return kalender
+}
+
+// Simpan menyimpan atau memperbarui tanggal libur, kunci unik adalah tanggal dan kampus
+func Simpan(db *mongo.Database, docs []model.LiburNasional) (disimpan int, err error) {
+	ensureIndexes(db)
+	defer Invalidate()
+	for _, doc := range docs {
+		if _, err = time.Parse(FormatTanggal, doc.Tanggal); err != nil {
+			return disimpan, errors.New("tanggal " + doc.Tanggal + " tidak valid, gunakan format YYYY-MM-DD")
+		}
+		if doc.CreatedAt.IsZero() {
+			doc.CreatedAt = time.Now()
+		}
+		_, err = db.Collection(Collection).UpdateOne(context.Background(),
+			bson.M{"tanggal": doc.Tanggal, "kampus": doc.Kampus},
+			bson.M{
+				"$set": bson.M{
+					"keterangan": doc.Keterangan,
+					"iscuti":     doc.IsCuti,
+					"sumber":     doc.Sumber,
+					"createdby":  doc.CreatedBy,
+				},
+				"$setOnInsert": bson.M{"createdAt": doc.CreatedAt},
+			},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return
+		}
+		disimpan++
+	}
+	return
+}
+
+// DariRequest mengubah request owner menjadi satu dokumen per tanggal, termasuk rentang tanggal
+func DariRequest(req model.LiburRequest) (docs []model.LiburNasional, err error) {
+	mulai, err := time.Parse(FormatTanggal, req.Tanggal)
+	if err != nil {
+		return nil, errors.New("tanggal tidak valid, gunakan format YYYY-MM-DD")
+	}
+	sampai := mulai
+	if req.Sampai != "" {
+		if sampai, err = time.Parse(FormatTanggal, req.Sampai); err != nil {
+			return nil, errors.New("sampai tidak valid, gunakan format YYYY-MM-DD")
+		}
+	}
+	if sampai.Before(mulai) || sampai.Sub(mulai) > maksRentang*24*time.Hour {
+		return nil, errors.New("rentang tanggal tidak valid")
+	}
+	for t := mulai; !t.After(sampai); t = t.AddDate(0, 0, 1) {
+		docs = append(docs, model.LiburNasional{
+			Tanggal:    t.Format(FormatTanggal),
+			Keterangan: req.Keterangan,
+			IsCuti:     req.IsCuti,
+			Kampus:     req.Kampus,
+			Sumber:     "manual",
+		})
+	}
+	return
+}
+
+// ParseJSON membaca daftar libur dengan format yang sama seperti dayoffapi:
+// [{"tanggal":"2025-01-01","keterangan":"Tahun Baru","is_cuti":false}]
+func ParseJSON(data []byte) (docs []model.LiburNasional, err error) {
+	var daftar []struct {
+		Tanggal    string `json:"tanggal"`
+		Keterangan string `json:"keterangan"`
+		IsCuti     bool   `json:"is_cuti"`
+	}
+	if err = json.Unmarshal(data, &daftar); err != nil {
+		return
+	}
+	for _, d := range daftar {
+		t, err := time.Parse("2006-1-2", d.Tanggal)
+		if err != nil {
+			return nil, errors.New("tanggal " + d.Tanggal + " tidak valid")
+		}
+		docs = append(docs, model.LiburNasional{
+			Tanggal:    t.Format(FormatTanggal),
+			Keterangan: d.Keterangan,
+			IsCuti:     d.IsCuti,
+			Sumber:     "json",
+		})
+	}
+	return
+}
+
+// ParseICS membaca VEVENT dari file kalender iCalendar, event lebih dari sehari dipecah per tanggal.
+// DTEND pada event seharian bersifat eksklusif sesuai RFC 5545.
+func ParseICS(data []byte) (docs []model.LiburNasional, err error) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		//baris yang diawali spasi atau tab adalah lanjutan baris sebelumnya
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	var dalamEvent bool
+	var mulai, selesai time.Time
+	var ringkasan string
+	for _, line := range lines {
+		nama, nilai, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		nama = strings.ToUpper(nama)
+		switch {
+		case nama == "BEGIN" && nilai == "VEVENT":
+			dalamEvent, mulai, selesai, ringkasan = true, time.Time{}, time.Time{}, ""
+		case nama == "END" && nilai == "VEVENT":
+			dalamEvent = false
+			if mulai.IsZero() {
+				return nil, errors.New("VEVENT tanpa DTSTART")
+			}
+			if selesai.IsZero() || !selesai.After(mulai) {
+				selesai = mulai.AddDate(0, 0, 1)
+			}
+			if selesai.Sub(mulai) > maksRentang*24*time.Hour {
+				return nil, errors.New("event " + ringkasan + " terlalu panjang")
+			}
+			for t := mulai; t.Before(selesai); t = t.AddDate(0, 0, 1) {
+				docs = append(docs, model.LiburNasional{
+					Tanggal:    t.Format(FormatTanggal),
+					Keterangan: ringkasan,
+					Sumber:     "ics",
+				})
+			}
+		case !dalamEvent:
+		case strings.HasPrefix(nama, "DTSTART"):
+			if mulai, err = parseTanggalICS(nilai); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(nama, "DTEND"):
+			if selesai, err = parseTanggalICS(nilai); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(nama, "SUMMARY"):
+			ringkasan = strings.ReplaceAll(nilai, "\\,", ",")
+		}
+	}
+	return
+}
+
+// parseTanggalICS menerima 20250101 atau 20250101T000000Z, jam diabaikan
+func parseTanggalICS(nilai string) (time.Time, error) {
+	if len(nilai) < 8 {
+		return time.Time{}, errors.New("tanggal ICS " + nilai + " tidak valid")
+	}
+	return time.Parse("20060102", nilai[:8])
+}
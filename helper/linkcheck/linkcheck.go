@@ -0,0 +1,206 @@
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Timeout adalah batas waktu membuka link tugas
+var Timeout = 10 * time.Second
+
+// Transport adalah jalur keluar cek link. Bawaannya tidak memakai proxy dan menolak koneksi ke alamat internal
+// setelah nama host di-resolve, di setiap hop redirect. Mode dev menggantinya dengan transport server tiruan.
+var Transport http.RoundTripper = &http.Transport{
+	DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: tolakLokal}).DialContext,
+	TLSHandshakeTimeout: 5 * time.Second,
+	MaxIdleConns:        10,
+	IdleConnTimeout:     30 * time.Second,
+}
+
+// ErrAlamatInternal menandai link yang mengarah ke alamat internal, link seperti ini selalu ditolak
+var ErrAlamatInternal = errors.New("tidak diizinkan")
+
+// izinkanLokal hanya diubah test yang memakai httptest di 127.0.0.1, selain itu tolakLokal selalu aktif
+var izinkanLokal = false
+
+// Normalisasi menyeragamkan link tugas menjadi key pembanding link ganda.
+// Skema, www, fragment, slash di akhir dan parameter utm_ diabaikan.
+func Normalisasi(raw string) (u *url.URL, key string, err error) {
+	u, err = url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, "", apperr.New(apperr.BadRequest, "", "link tugas harus berupa URL http atau https yang lengkap")
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(strings.ToLower(k), "utm_") {
+			q.Del(k)
+		}
+	}
+	key = host + strings.TrimRight(u.EscapedPath(), "/")
+	if len(q) > 0 {
+		key += "?" + q.Encode()
+	}
+	return u, key, nil
+}
+
+// CocokDomain bernilai true jika host sama dengan salah satu domain atau subdomainnya
+func CocokDomain(host string, domains []string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, d := range domains {
+		d = BersihkanDomain(d)
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BersihkanDomain membuang skema, path dan www dari isian domain owner atau Project_Hostname
+func BersihkanDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	if i := strings.Index(d, "://"); i >= 0 {
+		d = d[i+3:]
+	}
+	d, _, _ = strings.Cut(d, "/")
+	return strings.TrimPrefix(d, "www.")
+}
+
+// Periksa menjalankan cek otomatis link tugas: link bisa dibuka, domain ada di allowlist event
+// dan link belum pernah disubmit user lain. Semua cek tetap dijalankan supaya reviewer melihat hasil lengkap.
+// Ditolak hanya untuk alamat internal dan domain di luar allowlist, kegagalan lain ditandai untuk reviewer.
+func Periksa(db *mongo.Database, event model.Event, claim model.EventClaim, link string) (hasil model.EventLinkCheck, key string, err error) {
+	u, key, err := Normalisasi(link)
+	if err != nil {
+		return
+	}
+	hasil = model.EventLinkCheck{URL: u.String(), Domain: strings.ToLower(u.Hostname()), CheckedAt: time.Now()}
+
+	hasil.StatusCode, hasil.FinalURL, err = buka(u)
+	hasil.Resolves = err == nil && hasil.StatusCode < 400
+	hasil.Internal = errors.Is(err, ErrAlamatInternal)
+	switch {
+	case err != nil:
+		hasil.Alasan = append(hasil.Alasan, "link tidak bisa dibuka: "+err.Error())
+	case !hasil.Resolves:
+		hasil.Alasan = append(hasil.Alasan, "link menjawab HTTP "+strconv.Itoa(hasil.StatusCode))
+	}
+
+	domains, err := domainDiizinkan(db, event, claim.UserPhone)
+	if err != nil {
+		return hasil, key, apperr.Wrap(apperr.Database, "", err)
+	}
+	hasil.DomainAllowed = domains == nil || CocokDomain(hasil.Domain, domains)
+	if !hasil.DomainAllowed {
+		hasil.Alasan = append(hasil.Alasan, "domain "+hasil.Domain+" tidak diterima, gunakan "+strings.Join(domains, ", "))
+	}
+
+	lain, err := atdb.GetAllDoc[[]model.EventClaim](db, "eventclaims", bson.M{
+		"tasklinkkey": key,
+		"userphone":   bson.M{"$ne": claim.UserPhone},
+	})
+	if err != nil {
+		return hasil, key, apperr.Wrap(apperr.Database, "", err)
+	}
+	for _, c := range lain {
+		hasil.DuplicateClaims = append(hasil.DuplicateClaims, c.ID)
+	}
+	hasil.Duplicate = len(lain) > 0
+	if hasil.Duplicate {
+		hasil.Alasan = append(hasil.Alasan, "link sudah pernah disubmit user lain")
+	}
+
+	hasil.Passed = hasil.Resolves && hasil.DomainAllowed && !hasil.Duplicate
+	hasil.Ditolak = hasil.Internal || !hasil.DomainAllowed
+	return hasil, key, nil
+}
+
+// domainDiizinkan menggabungkan allowlist event dengan Project_Hostname proyek user.
+// nil berarti event tidak membatasi domain.
+func domainDiizinkan(db *mongo.Database, event model.Event, phonenumber string) ([]string, error) {
+	if len(event.AllowedDomains) == 0 && !event.AllowOwnHostname {
+		return nil, nil
+	}
+	domains := append([]string{}, event.AllowedDomains...)
+	if event.AllowOwnHostname {
+		hosts, err := atdb.GetAllDistinct[string](db, bson.M{
+			"$or": []bson.M{{"members.phonenumber": phonenumber}, {"owner.phonenumber": phonenumber}},
+		}, "project_hostname", "project")
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hosts {
+			if h = BersihkanDomain(h); h != "" {
+				domains = append(domains, h)
+			}
+		}
+	}
+	return domains, nil
+}
+
+// buka mengirim HEAD lalu GET jika server tidak mendukung HEAD, redirect diikuti paling banyak 10 kali
+func buka(u *url.URL) (status int, final string, err error) {
+	// URL akhir dicatat dari redirect, bukan dari resp.Request yang bisa sudah diubah transport
+	client := &http.Client{Timeout: Timeout, Transport: Transport}
+	client.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("terlalu banyak redirect")
+		}
+		final = r.URL.String()
+		return nil
+	}
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		final = u.String()
+		var req *http.Request
+		req, err = http.NewRequest(method, u.String(), nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("User-Agent", "domyikado-linkcheck/1.0")
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			return
+		}
+	}
+	return
+}
+
+// tolakLokal dipanggil dialer setelah DNS di-resolve, sehingga nama host yang mengarah ke IP internal
+// dan redirect ke alamat internal ikut ditolak. Link tugas tidak boleh dipakai memindai jaringan internal.
+func tolakLokal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if izinkanLokal {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || bersama.Contains(ip) {
+		return fmt.Errorf("alamat %s %w", host, ErrAlamatInternal)
+	}
+	return nil
+}
+
+// bersama adalah ruang alamat carrier-grade NAT 100.64.0.0/10 yang tidak termasuk IsPrivate
+var bersama = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
@@ -0,0 +1,118 @@
+package linkcheck
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNormalisasi(t *testing.T) {
+	tests := []struct {
+		raw, want string
+		wantErr   bool
+	}{
+		{raw: "https://github.com/user/repo", want: "github.com/user/repo"},
+		{raw: " http://WWW.GitHub.com/user/repo/#readme", want: "github.com/user/repo"},
+		{raw: "https://github.com/user/repo?utm_source=wa&tab=readme", want: "github.com/user/repo?tab=readme"},
+		{raw: "github.com/user/repo", wantErr: true},
+		{raw: "ftp://github.com/user/repo", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, key, err := Normalisasi(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Normalisasi(%q) error = %v, seharusnya error %v", tt.raw, err, tt.wantErr)
+		}
+		if key != tt.want {
+			t.Fatalf("Normalisasi(%q) = %q, seharusnya %q", tt.raw, key, tt.want)
+		}
+	}
+}
+
+func TestCocokDomain(t *testing.T) {
+	domains := []string{"github.com", "https://www.do.my.id/"}
+	for host, want := range map[string]bool{
+		"github.com":         true,
+		"gist.github.com":    true,
+		"www.do.my.id":       true,
+		"evilgithub.com":     false,
+		"github.com.evil.id": false,
+	} {
+		if got := CocokDomain(host, domains); got != want {
+			t.Errorf("CocokDomain(%q) = %v, seharusnya %v", host, got, want)
+		}
+	}
+}
+
+func TestTolakLokal(t *testing.T) {
+	tests := []struct {
+		address string
+		tolak   bool
+	}{
+		{"127.0.0.1:80", true},
+		{"10.1.2.3:443", true},
+		{"192.168.1.10:80", true},
+		{"169.254.169.254:80", true},
+		{"100.64.0.1:80", true},
+		{"[::1]:80", true},
+		{"[fd00::1]:443", true},
+		{"0.0.0.0:80", true},
+		{"36.80.1.1:443", false},
+		{"[2001:4860:4860::8888]:443", false},
+	}
+	for _, tt := range tests {
+		if err := tolakLokal("tcp", tt.address, nil); (err != nil) != tt.tolak {
+			t.Errorf("tolakLokal(%s) = %v, seharusnya tolak %v", tt.address, err, tt.tolak)
+		}
+	}
+}
+
+// TestBuka memakai server stub lokal untuk link yang hidup, mati, redirect dan server tanpa HEAD
+func TestBuka(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/pindah", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/tanpahead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/ok")
+	if _, _, err := buka(u); !errors.Is(err, ErrAlamatInternal) {
+		t.Fatalf("alamat loopback seharusnya ditolak sebagai alamat internal: %v", err)
+	}
+	//nama host dicek setelah di-resolve, bukan hanya IP yang ditulis langsung di link
+	u, _ = url.Parse(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/ok")
+	if _, _, err := buka(u); err == nil {
+		t.Fatal("nama host yang mengarah ke loopback seharusnya ditolak")
+	}
+
+	izinkanLokal = true
+	defer func() { izinkanLokal = false }()
+	tests := []struct {
+		path, final string
+		status      int
+	}{
+		{path: "/ok", final: "/ok", status: http.StatusOK},
+		{path: "/pindah", final: "/ok", status: http.StatusOK},
+		{path: "/tanpahead", final: "/tanpahead", status: http.StatusOK},
+		{path: "/hilang", final: "/hilang", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(srv.URL + tt.path)
+		status, final, err := buka(u)
+		if err != nil {
+			t.Fatalf("buka %s: %v", tt.path, err)
+		}
+		if status != tt.status || final != srv.URL+tt.final {
+			t.Fatalf("buka %s = %d %s, seharusnya %d %s", tt.path, status, final, tt.status, srv.URL+tt.final)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/cookiejar"
 	"regexp"
 
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,9 +21,8 @@ func GetNewCookie(xsrfToken string, laravelSession string, db *mongo.Database) (
 		return "", "", "", fmt.Errorf("error creating cookie jar: %w", err)
 	}
 
-	client := &http.Client{
-		Jar: jar,
-	}
+	client := atapi.NewClient(0)
+	client.Jar = jar
 	profile, err := atdb.GetOneDoc[LoginProfile](db, "lmscreds", bson.M{})
 	if err != nil {
 		return "", "", "", fmt.Errorf("error get profile db: %w", err)
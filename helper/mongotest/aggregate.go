@@ -0,0 +1,621 @@
+package mongotest
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// aggregate menjalankan pipeline pada salinan dokumen koleksi
+func (s *Server) aggregate(db string, docs []bson.D, pipeline bson.A) ([]bson.D, error) {
+	for _, st := range pipeline {
+		stage, ok := st.(bson.D)
+		if !ok || len(stage) != 1 {
+			return nil, fmt.Errorf("stage pipeline harus dokumen dengan satu operator")
+		}
+		name, arg := stage[0].Key, stage[0].Value
+		var err error
+		switch name {
+		case "$match":
+			filter, _ := arg.(bson.D)
+			docs, err = filterDocs(docs, filter)
+		case "$sort":
+			spec, _ := arg.(bson.D)
+			sortDocs(docs, spec)
+		case "$limit":
+			n, _ := toFloat(arg)
+			if int(n) < len(docs) {
+				docs = docs[:int(n)]
+			}
+		case "$skip":
+			n, _ := toFloat(arg)
+			if int(n) < len(docs) {
+				docs = docs[int(n):]
+			} else {
+				docs = nil
+			}
+		case "$sample":
+			//urutan tetap supaya hasil test bisa diulang
+			spec, _ := arg.(bson.D)
+			size, _ := get(spec, "size")
+			n, _ := toFloat(size)
+			if int(n) < len(docs) {
+				docs = docs[:int(n)]
+			}
+		case "$count":
+			field, _ := arg.(string)
+			docs = []bson.D{{{Key: field, Value: int32(len(docs))}}}
+		case "$group":
+			spec, _ := arg.(bson.D)
+			docs, err = group(docs, spec)
+		case "$project":
+			spec, _ := arg.(bson.D)
+			docs, err = projectDocs(docs, spec)
+		case "$addFields", "$set":
+			spec, _ := arg.(bson.D)
+			docs, err = addFields(docs, spec)
+		case "$unset":
+			docs = unsetFields(docs, arg)
+		case "$unwind":
+			docs, err = unwind(docs, arg)
+		case "$replaceRoot", "$replaceWith":
+			expr := arg
+			if name == "$replaceRoot" {
+				spec, _ := arg.(bson.D)
+				expr, _ = get(spec, "newRoot")
+			}
+			docs, err = replaceRoot(docs, expr)
+		case "$lookup":
+			spec, _ := arg.(bson.D)
+			docs, err = s.lookupStage(db, docs, spec)
+		default:
+			err = fmt.Errorf("stage %s belum didukung", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func filterDocs(docs []bson.D, filter bson.D) ([]bson.D, error) {
+	var res []bson.D
+	for _, d := range docs {
+		ok, err := match(d, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+type groupState struct {
+	id     any
+	fields bson.D
+	counts map[string]int
+}
+
+func group(docs []bson.D, spec bson.D) ([]bson.D, error) {
+	idExpr, _ := get(spec, "_id")
+	var groups []*groupState
+	for _, d := range docs {
+		id, err := eval(d, idExpr)
+		if err != nil {
+			return nil, err
+		}
+		var g *groupState
+		for _, existing := range groups {
+			if equal(existing.id, id) {
+				g = existing
+				break
+			}
+		}
+		if g == nil {
+			g = &groupState{id: id, counts: make(map[string]int)}
+			groups = append(groups, g)
+		}
+		for _, f := range spec {
+			if f.Key == "_id" {
+				continue
+			}
+			acc, ok := f.Value.(bson.D)
+			if !ok || len(acc) != 1 {
+				return nil, fmt.Errorf("akumulator %s tidak valid", f.Key)
+			}
+			v, err := eval(d, acc[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			if err = accumulate(g, f.Key, acc[0].Key, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	res := make([]bson.D, 0, len(groups))
+	for _, g := range groups {
+		doc := bson.D{{Key: "_id", Value: g.id}}
+		for _, f := range g.fields {
+			if sum, isAvg := f.Value.(avgState); isAvg {
+				if n := g.counts[f.Key]; n == 0 {
+					f.Value = nil
+				} else {
+					f.Value = float64(sum) / float64(n)
+				}
+			}
+			doc = append(doc, f)
+		}
+		res = append(res, doc)
+	}
+	return res, nil
+}
+
+// avgState menyimpan jumlah sementara $avg sampai semua dokumen selesai dihitung
+type avgState float64
+
+func accumulate(g *groupState, field, op string, v any) error {
+	cur, exists := get(g.fields, field)
+	set := func(val any) {
+		for i := range g.fields {
+			if g.fields[i].Key == field {
+				g.fields[i].Value = val
+				return
+			}
+		}
+		g.fields = append(g.fields, bson.E{Key: field, Value: val})
+	}
+	switch op {
+	case "$sum":
+		if !exists {
+			cur = int32(0)
+		}
+		if _, isNum := toFloat(v); !isNum {
+			set(cur)
+			return nil
+		}
+		res, err := arith("$inc", cur, v)
+		if err != nil {
+			return err
+		}
+		set(res)
+	case "$avg":
+		if !exists {
+			cur = avgState(0)
+		}
+		if f, ok := toFloat(v); ok {
+			cur = cur.(avgState) + avgState(f)
+			g.counts[field]++
+		}
+		set(cur)
+	case "$min", "$max":
+		if v == nil {
+			if !exists {
+				set(nil)
+			}
+			return nil
+		}
+		if !exists || cur == nil || (op == "$min" && compare(v, cur) < 0) || (op == "$max" && compare(v, cur) > 0) {
+			set(v)
+		}
+	case "$first":
+		if !exists {
+			set(v)
+		}
+	case "$last":
+		set(v)
+	case "$push", "$addToSet":
+		arr, _ := cur.(bson.A)
+		if op == "$addToSet" && containsEqual(arr, v) {
+			return nil
+		}
+		set(append(arr, v))
+	default:
+		return fmt.Errorf("akumulator %s belum didukung", op)
+	}
+	return nil
+}
+
+func projectDocs(docs []bson.D, spec bson.D) ([]bson.D, error) {
+	res := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		p, err := project(d, spec)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// project menerapkan projection find atau stage $project, inklusi dan eksklusi tidak boleh dicampur kecuali _id
+func project(doc bson.D, spec bson.D) (bson.D, error) {
+	if len(spec) == 0 {
+		return doc, nil
+	}
+	include := false
+	for _, e := range spec {
+		if e.Key == "_id" {
+			continue
+		}
+		if f, isNum := toFloat(e.Value); isNum && f == 0 {
+			continue
+		}
+		if b, isBool := e.Value.(bool); isBool && !b {
+			continue
+		}
+		include = true
+	}
+	if !include {
+		res := cloneDoc(doc)
+		for _, e := range spec {
+			unsetPath(&res, strings.Split(e.Key, "."))
+		}
+		return res, nil
+	}
+	res := bson.D{}
+	if idSpec, ok := get(spec, "_id"); !ok || truthy(idSpec) {
+		if id, ok := get(doc, "_id"); ok {
+			res = append(res, bson.E{Key: "_id", Value: id})
+		}
+	}
+	for _, e := range spec {
+		if e.Key == "_id" {
+			continue
+		}
+		var v any
+		var found bool
+		switch x := e.Value.(type) {
+		case bool, int32, int64, float64:
+			if !truthy(x) {
+				continue
+			}
+			vals := lookupPath(doc, e.Key)
+			if len(vals) > 0 {
+				v, found = vals[0], true
+			}
+		default:
+			ev, err := eval(doc, e.Value)
+			if err != nil {
+				return nil, err
+			}
+			v, found = ev, true
+		}
+		if found {
+			if err := setPath(&res, strings.Split(e.Key, "."), cloneValue(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+func addFields(docs []bson.D, spec bson.D) ([]bson.D, error) {
+	res := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		nd := cloneDoc(d)
+		for _, e := range spec {
+			v, err := eval(d, e.Value)
+			if err != nil {
+				return nil, err
+			}
+			if err = setPath(&nd, strings.Split(e.Key, "."), v); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, nd)
+	}
+	return res, nil
+}
+
+func unsetFields(docs []bson.D, arg any) []bson.D {
+	fields := bson.A{arg}
+	if arr, ok := arg.(bson.A); ok {
+		fields = arr
+	}
+	res := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		nd := cloneDoc(d)
+		for _, f := range fields {
+			if name, ok := f.(string); ok {
+				unsetPath(&nd, strings.Split(name, "."))
+			}
+		}
+		res = append(res, nd)
+	}
+	return res
+}
+
+func unwind(docs []bson.D, arg any) ([]bson.D, error) {
+	path, _ := arg.(string)
+	preserve := false
+	if spec, ok := arg.(bson.D); ok {
+		path, _ = lookupString(spec, "path")
+		p, _ := get(spec, "preserveNullAndEmptyArrays")
+		preserve = truthy(p)
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path $unwind harus diawali $")
+	}
+	field := strings.Split(path[1:], ".")
+	var res []bson.D
+	for _, d := range docs {
+		vals := lookup(d, field)
+		if len(vals) == 0 || vals[0] == nil {
+			if preserve {
+				res = append(res, d)
+			}
+			continue
+		}
+		arr, ok := vals[0].(bson.A)
+		if !ok {
+			res = append(res, d)
+			continue
+		}
+		if len(arr) == 0 && preserve {
+			nd := cloneDoc(d)
+			unsetPath(&nd, field)
+			res = append(res, nd)
+		}
+		for _, el := range arr {
+			nd := cloneDoc(d)
+			if err := setPath(&nd, field, cloneValue(el)); err != nil {
+				return nil, err
+			}
+			res = append(res, nd)
+		}
+	}
+	return res, nil
+}
+
+func replaceRoot(docs []bson.D, expr any) ([]bson.D, error) {
+	res := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		v, err := eval(d, expr)
+		if err != nil {
+			return nil, err
+		}
+		nd, ok := v.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("newRoot harus menghasilkan dokumen")
+		}
+		res = append(res, nd)
+	}
+	return res, nil
+}
+
+func (s *Server) lookupStage(db string, docs []bson.D, spec bson.D) ([]bson.D, error) {
+	from, _ := lookupString(spec, "from")
+	local, _ := lookupString(spec, "localField")
+	foreign, _ := lookupString(spec, "foreignField")
+	as, _ := lookupString(spec, "as")
+	if from == "" || local == "" || foreign == "" || as == "" {
+		return nil, fmt.Errorf("$lookup hanya mendukung from, localField, foreignField dan as")
+	}
+	var others []bson.D
+	if c := s.dbs[db][from]; c != nil {
+		others = c.docs
+	}
+	res := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		keys := lookupPath(d, local)
+		joined := bson.A{}
+		for _, o := range others {
+			vals := lookupPath(o, foreign)
+			for _, k := range flatten(keys) {
+				if eqAny(vals, k) {
+					joined = append(joined, cloneDoc(o))
+					break
+				}
+			}
+		}
+		nd := cloneDoc(d)
+		if err := setPath(&nd, strings.Split(as, "."), joined); err != nil {
+			return nil, err
+		}
+		res = append(res, nd)
+	}
+	return res, nil
+}
+
+// eval menghitung ekspresi aggregate: "$field", literal, dokumen ekspresi atau operator sederhana
+func eval(doc bson.D, expr any) (any, error) {
+	switch x := expr.(type) {
+	case string:
+		if strings.HasPrefix(x, "$$") {
+			if x == "$$ROOT" {
+				return doc, nil
+			}
+			return nil, fmt.Errorf("variabel %s belum didukung", x)
+		}
+		if strings.HasPrefix(x, "$") {
+			vals := lookup(doc, strings.Split(x[1:], "."))
+			switch {
+			case len(vals) == 0:
+				return nil, nil
+			case len(vals) == 1 && !strings.Contains(x, ".") || len(vals) == 1:
+				return vals[0], nil
+			}
+			return bson.A(vals), nil
+		}
+		return x, nil
+	case bson.A:
+		res := make(bson.A, len(x))
+		for i, el := range x {
+			v, err := eval(doc, el)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = v
+		}
+		return res, nil
+	case bson.D:
+		if isOperatorDoc(x) {
+			return evalOperator(doc, x[0].Key, x[0].Value)
+		}
+		res := bson.D{}
+		for _, e := range x {
+			v, err := eval(doc, e.Value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, bson.E{Key: e.Key, Value: v})
+		}
+		return res, nil
+	}
+	return expr, nil
+}
+
+func evalArgs(doc bson.D, arg any) ([]any, error) {
+	list, ok := arg.(bson.A)
+	if !ok {
+		list = bson.A{arg}
+	}
+	res := make([]any, len(list))
+	for i, el := range list {
+		v, err := eval(doc, el)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+func evalOperator(doc bson.D, op string, arg any) (any, error) {
+	if op == "$literal" {
+		return arg, nil
+	}
+	args, err := evalArgs(doc, arg)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "$add", "$multiply", "$sum":
+		inc := "$inc"
+		var res any = int32(0)
+		if op == "$multiply" {
+			inc, res = "$mul", int32(1)
+		}
+		for _, a := range args {
+			if arr, ok := a.(bson.A); ok && op == "$sum" {
+				for _, el := range arr {
+					if _, isNum := toFloat(el); isNum {
+						if res, err = arith(inc, res, el); err != nil {
+							return nil, err
+						}
+					}
+				}
+				continue
+			}
+			if _, isNum := toFloat(a); !isNum {
+				if op == "$sum" {
+					continue
+				}
+				return nil, nil
+			}
+			if res, err = arith(inc, res, a); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case "$subtract", "$divide":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s membutuhkan dua argumen", op)
+		}
+		a, okA := toFloat(args[0])
+		b, okB := toFloat(args[1])
+		if !okA || !okB {
+			return nil, nil
+		}
+		if op == "$divide" {
+			if b == 0 {
+				return nil, fmt.Errorf("pembagian dengan nol")
+			}
+			return a / b, nil
+		}
+		return arith("$inc", args[0], -b)
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s membutuhkan dua argumen", op)
+		}
+		c := compare(args[0], args[1])
+		switch op {
+		case "$eq":
+			return c == 0, nil
+		case "$ne":
+			return c != 0, nil
+		}
+		return compareOK(op, c), nil
+	case "$and":
+		for _, a := range args {
+			if !truthy(a) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$or":
+		for _, a := range args {
+			if truthy(a) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$not":
+		return len(args) > 0 && !truthy(args[0]), nil
+	case "$ifNull":
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	case "$cond":
+		if spec, ok := arg.(bson.D); ok {
+			ifv, _ := get(spec, "if")
+			thenv, _ := get(spec, "then")
+			elsev, _ := get(spec, "else")
+			args = bson.A{ifv, thenv, elsev}
+			cond, err := eval(doc, ifv)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(cond) {
+				return eval(doc, thenv)
+			}
+			return eval(doc, elsev)
+		}
+		if len(args) != 3 {
+			return nil, fmt.Errorf("$cond membutuhkan tiga argumen")
+		}
+		if truthy(args[0]) {
+			return args[1], nil
+		}
+		return args[2], nil
+	case "$size":
+		arr, ok := args[0].(bson.A)
+		if !ok {
+			return nil, fmt.Errorf("$size membutuhkan array")
+		}
+		return int32(len(arr)), nil
+	case "$concat":
+		var sb strings.Builder
+		for _, a := range args {
+			s, ok := a.(string)
+			if !ok {
+				return nil, nil
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+	case "$toLower", "$toUpper":
+		s, _ := args[0].(string)
+		if op == "$toLower" {
+			return strings.ToLower(s), nil
+		}
+		return strings.ToUpper(s), nil
+	}
+	return nil, fmt.Errorf("operator ekspresi %s belum didukung", op)
+}
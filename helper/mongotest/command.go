@@ -0,0 +1,700 @@
+package mongotest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type collection struct {
+	docs    []bson.D
+	indexes []index
+}
+
+type index struct {
+	Name    string
+	Key     bson.D
+	Unique  bool
+	Sparse  bool
+	Partial bson.D // partialFilterExpression, dokumen yang tidak cocok tidak masuk index
+}
+
+// cmdError adalah error perintah dengan kode dan codeName MongoDB supaya driver mengenalinya,
+// misalnya mongo.IsDuplicateKeyError untuk kode 11000
+type cmdError struct {
+	Code     int32
+	CodeName string
+	Msg      string
+}
+
+func (e *cmdError) Error() string {
+	return e.Msg
+}
+
+func errorDoc(err error) bson.D {
+	var ce *cmdError
+	if !errors.As(err, &ce) {
+		ce = &cmdError{Code: 2, CodeName: "BadValue", Msg: err.Error()}
+	}
+	return bson.D{{Key: "ok", Value: 0.0}, {Key: "errmsg", Value: ce.Msg}, {Key: "code", Value: ce.Code}, {Key: "codeName", Value: ce.CodeName}}
+}
+
+func ok(fields ...bson.E) bson.D {
+	return append(fields, bson.E{Key: "ok", Value: 1.0})
+}
+
+// run menjalankan satu perintah, semua perintah dijalankan bergantian sehingga hasilnya deterministik
+func (s *Server) run(db string, cmd bson.D, connID int32) bson.D {
+	if len(cmd) == 0 {
+		return errorDoc(errors.New("perintah kosong"))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := cmd[0].Key
+	coll, _ := cmd[0].Value.(string)
+	var res bson.D
+	var err error
+	switch strings.ToLower(name) {
+	case "hello", "ismaster":
+		res = ok(
+			bson.E{Key: "helloOk", Value: true},
+			bson.E{Key: "ismaster", Value: true},
+			bson.E{Key: "isWritablePrimary", Value: true},
+			bson.E{Key: "maxBsonObjectSize", Value: int32(16777216)},
+			bson.E{Key: "maxMessageSizeBytes", Value: int32(48000000)},
+			bson.E{Key: "maxWriteBatchSize", Value: int32(100000)},
+			bson.E{Key: "localTime", Value: primitive.NewDateTimeFromTime(time.Now())},
+			bson.E{Key: "logicalSessionTimeoutMinutes", Value: int32(30)},
+			bson.E{Key: "connectionId", Value: connID},
+			bson.E{Key: "minWireVersion", Value: int32(0)},
+			bson.E{Key: "maxWireVersion", Value: int32(17)},
+			bson.E{Key: "readOnly", Value: false},
+		)
+	case "ping", "endsessions":
+		res = ok()
+	case "buildinfo":
+		res = ok(bson.E{Key: "version", Value: "6.0.0"}, bson.E{Key: "versionArray", Value: bson.A{int32(6), int32(0), int32(0), int32(0)}})
+	case "killcursors":
+		res = ok(bson.E{Key: "cursorsKilled", Value: bson.A{}})
+	case "find":
+		res, err = s.find(db, coll, cmd)
+	case "getmore":
+		//semua hasil sudah dikirim di firstBatch
+		c, _ := get(cmd, "collection")
+		res = ok(bson.E{Key: "cursor", Value: bson.D{{Key: "nextBatch", Value: bson.A{}}, {Key: "id", Value: int64(0)}, {Key: "ns", Value: fmt.Sprintf("%s.%v", db, c)}}})
+	case "count":
+		var docs []bson.D
+		query, _ := get(cmd, "query")
+		filter, _ := query.(bson.D)
+		docs, err = filterDocs(s.docs(db, coll), filter)
+		res = ok(bson.E{Key: "n", Value: int32(len(docs))})
+	case "distinct":
+		res, err = s.distinct(db, coll, cmd)
+	case "aggregate":
+		res, err = s.aggregateCmd(db, coll, cmd)
+	case "insert":
+		res, err = s.insert(db, coll, cmd)
+	case "update":
+		res, err = s.update(db, coll, cmd)
+	case "delete":
+		res, err = s.delete(db, coll, cmd)
+	case "findandmodify":
+		res, err = s.findAndModify(db, coll, cmd)
+	case "createindexes":
+		res, err = s.createIndexes(db, coll, cmd)
+	case "listindexes":
+		res = s.listIndexes(db, coll)
+	case "dropindexes":
+		res = s.dropIndexes(db, coll, cmd)
+	case "create":
+		s.collection(db, coll)
+		res = ok()
+	case "drop":
+		if s.dbs[db] != nil {
+			delete(s.dbs[db], coll)
+		}
+		res = ok()
+	case "dropdatabase":
+		delete(s.dbs, db)
+		res = ok()
+	case "listcollections":
+		res, err = s.listCollections(db, cmd)
+	case "listdatabases":
+		res = s.listDatabases()
+	default:
+		err = &cmdError{Code: 59, CodeName: "CommandNotFound", Msg: fmt.Sprintf("no such command: '%s'", name)}
+	}
+	if err != nil {
+		return errorDoc(err)
+	}
+	return res
+}
+
+// collection mengambil koleksi, koleksi baru dibuat seperti MongoDB saat pertama kali ditulis
+func (s *Server) collection(db, name string) *collection {
+	if s.dbs[db] == nil {
+		s.dbs[db] = make(map[string]*collection)
+	}
+	c := s.dbs[db][name]
+	if c == nil {
+		c = &collection{}
+		s.dbs[db][name] = c
+	}
+	return c
+}
+
+func (s *Server) docs(db, name string) []bson.D {
+	if c := s.dbs[db][name]; c != nil {
+		return c.docs
+	}
+	return nil
+}
+
+func cursorDoc(db, coll string, docs []bson.D) bson.D {
+	batch := make(bson.A, 0, len(docs))
+	for _, d := range docs {
+		batch = append(batch, d)
+	}
+	return ok(bson.E{Key: "cursor", Value: bson.D{{Key: "firstBatch", Value: batch}, {Key: "id", Value: int64(0)}, {Key: "ns", Value: db + "." + coll}}})
+}
+
+func docArg(cmd bson.D, key string) bson.D {
+	v, _ := get(cmd, key)
+	d, _ := v.(bson.D)
+	return d
+}
+
+func intArg(cmd bson.D, key string) int {
+	v, _ := get(cmd, key)
+	f, _ := toFloat(v)
+	if f < 0 {
+		f = -f
+	}
+	return int(f)
+}
+
+func (s *Server) find(db, coll string, cmd bson.D) (bson.D, error) {
+	docs, err := filterDocs(s.docs(db, coll), docArg(cmd, "filter"))
+	if err != nil {
+		return nil, err
+	}
+	sortDocs(docs, docArg(cmd, "sort"))
+	if skip := intArg(cmd, "skip"); skip > 0 {
+		if skip >= len(docs) {
+			docs = nil
+		} else {
+			docs = docs[skip:]
+		}
+	}
+	if limit := intArg(cmd, "limit"); limit > 0 && limit < len(docs) {
+		docs = docs[:limit]
+	}
+	if docs, err = projectDocs(docs, docArg(cmd, "projection")); err != nil {
+		return nil, err
+	}
+	return cursorDoc(db, coll, docs), nil
+}
+
+func (s *Server) distinct(db, coll string, cmd bson.D) (bson.D, error) {
+	key, _ := lookupString(cmd, "key")
+	docs, err := filterDocs(s.docs(db, coll), docArg(cmd, "query"))
+	if err != nil {
+		return nil, err
+	}
+	values := bson.A{}
+	for _, d := range docs {
+		for _, v := range flatten(lookupPath(d, key)) {
+			if _, isArr := v.(bson.A); isArr {
+				continue
+			}
+			if !containsEqual(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	return ok(bson.E{Key: "values", Value: values}), nil
+}
+
+func (s *Server) aggregateCmd(db, coll string, cmd bson.D) (bson.D, error) {
+	v, _ := get(cmd, "pipeline")
+	pipeline, _ := v.(bson.A)
+	src := s.docs(db, coll)
+	docs := make([]bson.D, len(src))
+	copy(docs, src)
+	docs, err := s.aggregate(db, docs, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return cursorDoc(db, coll, docs), nil
+}
+
+// writeErrors mengumpulkan error per dokumen seperti balasan perintah tulis MongoDB
+func writeErrors(res bson.D, errs bson.A) bson.D {
+	if len(errs) > 0 {
+		res = append(bson.D{{Key: "writeErrors", Value: errs}}, res...)
+	}
+	return res
+}
+
+func writeError(i int, err error) bson.D {
+	var ce *cmdError
+	if !errors.As(err, &ce) {
+		ce = &cmdError{Code: 2, Msg: err.Error()}
+	}
+	return bson.D{{Key: "index", Value: int32(i)}, {Key: "code", Value: ce.Code}, {Key: "errmsg", Value: ce.Msg}}
+}
+
+func ordered(cmd bson.D) bool {
+	v, exists := get(cmd, "ordered")
+	return !exists || truthy(v)
+}
+
+func (s *Server) insert(db, coll string, cmd bson.D) (bson.D, error) {
+	v, _ := get(cmd, "documents")
+	list, _ := v.(bson.A)
+	c := s.collection(db, coll)
+	n := 0
+	var errs bson.A
+	for i, it := range list {
+		doc, isDoc := it.(bson.D)
+		if !isDoc {
+			return nil, errors.New("documents harus berisi dokumen")
+		}
+		if _, hasID := get(doc, "_id"); !hasID {
+			doc = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+		}
+		if err := c.checkUnique(db, coll, doc, -1); err != nil {
+			errs = append(errs, writeError(i, err))
+			if ordered(cmd) {
+				break
+			}
+			continue
+		}
+		c.docs = append(c.docs, cloneDoc(doc))
+		n++
+	}
+	return writeErrors(ok(bson.E{Key: "n", Value: int32(n)}), errs), nil
+}
+
+func (s *Server) update(db, coll string, cmd bson.D) (bson.D, error) {
+	v, _ := get(cmd, "updates")
+	list, _ := v.(bson.A)
+	c := s.collection(db, coll)
+	n, modified := 0, 0
+	var upserted, errs bson.A
+	for i, it := range list {
+		spec, _ := it.(bson.D)
+		nm, mod, id, err := c.updateOne(db, coll, spec)
+		n += nm
+		modified += mod
+		if id != nil {
+			upserted = append(upserted, bson.D{{Key: "index", Value: int32(i)}, {Key: "_id", Value: id}})
+		}
+		if err != nil {
+			errs = append(errs, writeError(i, err))
+			if ordered(cmd) {
+				break
+			}
+		}
+	}
+	res := bson.D{{Key: "n", Value: int32(n)}, {Key: "nModified", Value: int32(modified)}}
+	if len(upserted) > 0 {
+		res = append(res, bson.E{Key: "upserted", Value: upserted})
+	}
+	return writeErrors(ok(res...), errs), nil
+}
+
+// updateOne menjalankan satu entri updates, id terisi jika dokumen baru dibuat lewat upsert
+func (c *collection) updateOne(db, coll string, spec bson.D) (n, modified int, id any, err error) {
+	filter := docArg(spec, "q")
+	raw, _ := get(spec, "u")
+	upd, isDoc := raw.(bson.D)
+	if !isDoc {
+		return 0, 0, nil, errors.New("update dengan pipeline belum didukung")
+	}
+	multi, _ := get(spec, "multi")
+	upsert, _ := get(spec, "upsert")
+	for i := range c.docs {
+		m, err := match(c.docs[i], filter)
+		if err != nil {
+			return n, modified, nil, err
+		}
+		if !m {
+			continue
+		}
+		n++
+		changed, err := c.replaceAt(db, coll, i, upd, filter)
+		if err != nil {
+			return n, modified, nil, err
+		}
+		if changed {
+			modified++
+		}
+		if !truthy(multi) {
+			break
+		}
+	}
+	if n > 0 || !truthy(upsert) {
+		return n, modified, nil, nil
+	}
+	doc, err := c.upsert(db, coll, filter, upd)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	id, _ = get(doc, "_id")
+	return 1, 0, id, nil
+}
+
+// replaceAt menerapkan update pada dokumen ke-i, _id tidak boleh berubah dan index unik tetap dijaga
+func (c *collection) replaceAt(db, coll string, i int, upd, filter bson.D) (bool, error) {
+	old := c.docs[i]
+	doc, err := applyUpdate(old, upd, filter, false)
+	if err != nil {
+		return false, err
+	}
+	oldID, _ := get(old, "_id")
+	if newID, _ := get(doc, "_id"); !equal(oldID, newID) {
+		return false, &cmdError{Code: 66, CodeName: "ImmutableField", Msg: "field '_id' tidak boleh diubah"}
+	}
+	if err = c.checkUnique(db, coll, doc, i); err != nil {
+		return false, err
+	}
+	c.docs[i] = doc
+	return compare(old, doc) != 0, nil
+}
+
+func (c *collection) upsert(db, coll string, filter, upd bson.D) (bson.D, error) {
+	base, err := upsertBase(filter)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := applyUpdate(base, upd, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, hasID := get(doc, "_id"); !hasID {
+		if id, fromFilter := get(base, "_id"); fromFilter {
+			doc = append(bson.D{{Key: "_id", Value: id}}, doc...)
+		} else {
+			doc = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+		}
+	}
+	if err = c.checkUnique(db, coll, doc, -1); err != nil {
+		return nil, err
+	}
+	c.docs = append(c.docs, doc)
+	return doc, nil
+}
+
+func (s *Server) delete(db, coll string, cmd bson.D) (bson.D, error) {
+	v, _ := get(cmd, "deletes")
+	list, _ := v.(bson.A)
+	c := s.collection(db, coll)
+	n := 0
+	for _, it := range list {
+		spec, _ := it.(bson.D)
+		filter := docArg(spec, "q")
+		limit := intArg(spec, "limit")
+		kept := c.docs[:0:0]
+		removed := 0
+		for _, d := range c.docs {
+			m, err := match(d, filter)
+			if err != nil {
+				return nil, err
+			}
+			if m && (limit == 0 || removed < limit) {
+				removed++
+				continue
+			}
+			kept = append(kept, d)
+		}
+		c.docs = kept
+		n += removed
+	}
+	return ok(bson.E{Key: "n", Value: int32(n)}), nil
+}
+
+func (s *Server) findAndModify(db, coll string, cmd bson.D) (bson.D, error) {
+	c := s.collection(db, coll)
+	filter := docArg(cmd, "query")
+	idx := -1
+	var matched []int
+	for i, d := range c.docs {
+		m, err := match(d, filter)
+		if err != nil {
+			return nil, err
+		}
+		if m {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) > 0 {
+		docs := make([]bson.D, len(matched))
+		for j, i := range matched {
+			docs[j] = c.docs[i]
+		}
+		order := make([]int, len(matched))
+		for j := range order {
+			order[j] = j
+		}
+		spec := docArg(cmd, "sort")
+		sort.SliceStable(order, func(a, b int) bool { return lessDoc(docs[order[a]], docs[order[b]], spec) })
+		idx = matched[order[0]]
+	}
+	remove, _ := get(cmd, "remove")
+	returnNew, _ := get(cmd, "new")
+	upsert, _ := get(cmd, "upsert")
+	raw, hasUpdate := get(cmd, "update")
+	upd, isDoc := raw.(bson.D)
+	if hasUpdate && !isDoc {
+		return nil, errors.New("update dengan pipeline belum didukung")
+	}
+	lastError := bson.D{{Key: "n", Value: int32(0)}}
+	var value any
+	switch {
+	case idx >= 0 && truthy(remove):
+		value = c.docs[idx]
+		c.docs = append(c.docs[:idx:idx], c.docs[idx+1:]...)
+		lastError = bson.D{{Key: "n", Value: int32(1)}}
+	case idx >= 0 && hasUpdate:
+		old := c.docs[idx]
+		if _, err := c.replaceAt(db, coll, idx, upd, filter); err != nil {
+			return nil, err
+		}
+		value = old
+		if truthy(returnNew) {
+			value = c.docs[idx]
+		}
+		lastError = bson.D{{Key: "n", Value: int32(1)}, {Key: "updatedExisting", Value: true}}
+	case idx < 0 && hasUpdate && truthy(upsert):
+		doc, err := c.upsert(db, coll, filter, upd)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := get(doc, "_id")
+		if truthy(returnNew) {
+			value = doc
+		}
+		lastError = bson.D{{Key: "n", Value: int32(1)}, {Key: "updatedExisting", Value: false}, {Key: "upserted", Value: id}}
+	}
+	if d, isDoc := value.(bson.D); isDoc {
+		p, err := project(d, docArg(cmd, "fields"))
+		if err != nil {
+			return nil, err
+		}
+		value = p
+	}
+	return ok(bson.E{Key: "lastErrorObject", Value: lastError}, bson.E{Key: "value", Value: value}), nil
+}
+
+// checkUnique memastikan _id dan semua index unik tidak kembar, skip adalah posisi dokumen yang sedang diupdate
+func (c *collection) checkUnique(db, coll string, doc bson.D, skip int) error {
+	indexes := append([]index{{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}, Unique: true}}, c.indexes...)
+	for _, ix := range indexes {
+		if !ix.Unique {
+			continue
+		}
+		key, present := indexKey(doc, ix.Key)
+		if !ix.covers(doc, present) {
+			continue
+		}
+		for i, other := range c.docs {
+			if i == skip {
+				continue
+			}
+			otherKey, otherPresent := indexKey(other, ix.Key)
+			if !ix.covers(other, otherPresent) {
+				continue
+			}
+			if equal(key, otherKey) {
+				return duplicateKey(db, coll, ix, key)
+			}
+		}
+	}
+	return nil
+}
+
+// covers mengecek apakah dokumen ikut tercatat di index sparse atau partial
+func (ix index) covers(doc bson.D, present bool) bool {
+	if ix.Sparse && !present {
+		return false
+	}
+	if len(ix.Partial) > 0 {
+		ok, err := match(doc, ix.Partial)
+		return ok && err == nil
+	}
+	return true
+}
+
+// indexKey mengambil nilai field index, field yang tidak ada dianggap null seperti di MongoDB
+func indexKey(doc bson.D, key bson.D) (bson.A, bool) {
+	res := make(bson.A, 0, len(key))
+	present := false
+	for _, k := range key {
+		vals := lookupPath(doc, k.Key)
+		if len(vals) == 0 {
+			res = append(res, nil)
+			continue
+		}
+		present = true
+		res = append(res, vals[0])
+	}
+	return res, present
+}
+
+func duplicateKey(db, coll string, ix index, key bson.A) error {
+	parts := make([]string, len(ix.Key))
+	for i, k := range ix.Key {
+		parts[i] = fmt.Sprintf("%s: %v", k.Key, key[i])
+	}
+	return &cmdError{Code: 11000, CodeName: "DuplicateKey",
+		Msg: fmt.Sprintf("E11000 duplicate key error collection: %s.%s index: %s dup key: { %s }", db, coll, ix.Name, strings.Join(parts, ", "))}
+}
+
+func (s *Server) createIndexes(db, coll string, cmd bson.D) (bson.D, error) {
+	c := s.collection(db, coll)
+	before := len(c.indexes) + 1
+	v, _ := get(cmd, "indexes")
+	list, _ := v.(bson.A)
+	for _, it := range list {
+		spec, _ := it.(bson.D)
+		ix := index{Key: docArg(spec, "key")}
+		ix.Name, _ = lookupString(spec, "name")
+		unique, _ := get(spec, "unique")
+		sparse, _ := get(spec, "sparse")
+		ix.Unique, ix.Sparse = truthy(unique), truthy(sparse)
+		ix.Partial = docArg(spec, "partialFilterExpression")
+		if len(ix.Key) == 0 {
+			return nil, errors.New("index tanpa key")
+		}
+		if ix.Name == "" {
+			parts := make([]string, 0, len(ix.Key))
+			for _, k := range ix.Key {
+				parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+			}
+			ix.Name = strings.Join(parts, "_")
+		}
+		exists := ix.Name == "_id_"
+		for _, old := range c.indexes {
+			if old.Name == ix.Name {
+				exists = true
+			}
+		}
+		if exists {
+			continue
+		}
+		if ix.Unique {
+			for i, d := range c.docs {
+				probe := collection{docs: c.docs[:i], indexes: []index{ix}}
+				if err := probe.checkUnique(db, coll, d, -1); err != nil {
+					return nil, err
+				}
+			}
+		}
+		c.indexes = append(c.indexes, ix)
+	}
+	return ok(bson.E{Key: "numIndexesBefore", Value: int32(before)}, bson.E{Key: "numIndexesAfter", Value: int32(len(c.indexes) + 1)}), nil
+}
+
+func (s *Server) listIndexes(db, coll string) bson.D {
+	docs := []bson.D{{{Key: "v", Value: int32(2)}, {Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}}, {Key: "name", Value: "_id_"}}}
+	if c := s.dbs[db][coll]; c != nil {
+		for _, ix := range c.indexes {
+			d := bson.D{{Key: "v", Value: int32(2)}, {Key: "key", Value: ix.Key}, {Key: "name", Value: ix.Name}}
+			if ix.Unique {
+				d = append(d, bson.E{Key: "unique", Value: true})
+			}
+			if ix.Sparse {
+				d = append(d, bson.E{Key: "sparse", Value: true})
+			}
+			if len(ix.Partial) > 0 {
+				d = append(d, bson.E{Key: "partialFilterExpression", Value: ix.Partial})
+			}
+			docs = append(docs, d)
+		}
+	}
+	return cursorDoc(db, coll, docs)
+}
+
+func (s *Server) dropIndexes(db, coll string, cmd bson.D) bson.D {
+	c := s.dbs[db][coll]
+	if c == nil {
+		return ok()
+	}
+	name, _ := get(cmd, "index")
+	var kept []index
+	for _, ix := range c.indexes {
+		if name != "*" && name != ix.Name {
+			kept = append(kept, ix)
+		}
+	}
+	c.indexes = kept
+	return ok()
+}
+
+func (s *Server) listCollections(db string, cmd bson.D) (bson.D, error) {
+	names := make([]string, 0, len(s.dbs[db]))
+	for name := range s.dbs[db] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	docs := make([]bson.D, 0, len(names))
+	for _, name := range names {
+		docs = append(docs, bson.D{{Key: "name", Value: name}, {Key: "type", Value: "collection"}, {Key: "options", Value: bson.D{}}, {Key: "info", Value: bson.D{{Key: "readOnly", Value: false}}}})
+	}
+	docs, err := filterDocs(docs, docArg(cmd, "filter"))
+	if err != nil {
+		return nil, err
+	}
+	return cursorDoc(db, "$cmd.listCollections", docs), nil
+}
+
+func (s *Server) listDatabases() bson.D {
+	names := make([]string, 0, len(s.dbs))
+	for name := range s.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := bson.A{}
+	for _, name := range names {
+		list = append(list, bson.D{{Key: "name", Value: name}, {Key: "sizeOnDisk", Value: int64(0)}, {Key: "empty", Value: len(s.dbs[name]) == 0}})
+	}
+	return ok(bson.E{Key: "databases", Value: list}, bson.E{Key: "totalSize", Value: int64(0)})
+}
+
+// sortDocs mengurutkan dokumen sesuai spesifikasi sort, urutan asli dipertahankan untuk nilai yang sama
+func sortDocs(docs []bson.D, spec bson.D) {
+	if len(spec) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return lessDoc(docs[i], docs[j], spec) })
+}
+
+func lessDoc(a, b bson.D, spec bson.D) bool {
+	for _, k := range spec {
+		if k.Key == "$natural" {
+			continue
+		}
+		c := compare(sortValue(a, k.Key), sortValue(b, k.Key))
+		if dir, _ := toFloat(k.Value); dir < 0 {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+func sortValue(doc bson.D, key string) any {
+	vals := lookupPath(doc, key)
+	if len(vals) == 0 {
+		return nil
+	}
+	return vals[0]
+}
@@ -0,0 +1,29 @@
+package mongotest
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gocroot/helper/atdb"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Main dipanggil dari TestMain paket helper yang butuh database.
+// Server memori dijalankan sekali untuk paket, pakai memasang database ke variabel paket atau config.Mongoconn.
+func Main(m *testing.M, dbname string, pakai func(db *mongo.Database)) {
+	srv, err := Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := atdb.MongoConnect(atdb.DBInfo{DBString: srv.URI(), DBName: dbname})
+	if err != nil {
+		log.Fatal(err)
+	}
+	pakai(db)
+	code := m.Run()
+	db.Client().Disconnect(context.Background())
+	srv.Close()
+	os.Exit(code)
+}
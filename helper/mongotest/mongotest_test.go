@@ -0,0 +1,130 @@
+package mongotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocroot/helper/atdb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func connect(t *testing.T) *mongo.Database {
+	t.Helper()
+	srv, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	db, err := atdb.MongoConnect(atdb.DBInfo{DBString: srv.URI(), DBName: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Client().Disconnect(context.Background()) })
+	return db
+}
+
+type user struct {
+	Phone  string   `bson:"phonenumber"`
+	Name   string   `bson:"name"`
+	Poin   float64  `bson:"poin"`
+	Tags   []string `bson:"tags,omitempty"`
+	Active bool     `bson:"active"`
+}
+
+func TestCRUD(t *testing.T) {
+	db := connect(t)
+	ctx := context.Background()
+	coll := db.Collection("user")
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "phonenumber", Value: 1}}, Options: options.Index().SetUnique(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = atdb.InsertManyDocs(db, "user", []user{
+		{Phone: "62811", Name: "Ani", Poin: 10, Active: true},
+		{Phone: "62812", Name: "Budi", Poin: 5, Tags: []string{"dosen"}},
+		{Phone: "62813", Name: "Citra", Poin: 7.5, Active: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = atdb.InsertOneDoc(db, "user", user{Phone: "62811"}); !mongo.IsDuplicateKeyError(err) {
+		t.Fatalf("want duplicate key error, got %v", err)
+	}
+
+	got, err := atdb.GetOneDoc[user](db, "user", bson.M{"tags": "dosen"})
+	if err != nil || got.Name != "Budi" {
+		t.Fatalf("GetOneDoc: %+v %v", got, err)
+	}
+	n, err := atdb.GetCountDoc(db, "user", bson.M{"active": true, "poin": bson.M{"$gte": 7}})
+	if err != nil || n != 2 {
+		t.Fatalf("GetCountDoc: %d %v", n, err)
+	}
+	names, err := atdb.GetAllDistinct[string](db, bson.M{"active": true}, "name", "user")
+	if err != nil || len(names) != 2 {
+		t.Fatalf("GetAllDistinct: %v %v", names, err)
+	}
+
+	res, err := coll.UpdateOne(ctx, bson.M{"phonenumber": "62812"}, bson.M{"$inc": bson.M{"poin": 2}, "$push": bson.M{"tags": "asesor"}})
+	if err != nil || res.ModifiedCount != 1 {
+		t.Fatalf("UpdateOne: %+v %v", res, err)
+	}
+	res, err = coll.UpdateOne(ctx, bson.M{"phonenumber": "62814"}, bson.M{"$set": bson.M{"name": "Dewi"}}, options.Update().SetUpsert(true))
+	if err != nil || res.UpsertedID == nil {
+		t.Fatalf("upsert: %+v %v", res, err)
+	}
+
+	var after user
+	err = coll.FindOneAndUpdate(ctx, bson.M{"phonenumber": "62812"}, bson.M{"$set": bson.M{"active": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&after)
+	if err != nil || !after.Active || after.Poin != 7 || len(after.Tags) != 2 {
+		t.Fatalf("FindOneAndUpdate: %+v %v", after, err)
+	}
+
+	cur, err := coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "poin", Value: -1}}).SetLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var top []user
+	if err = cur.All(ctx, &top); err != nil || len(top) != 2 || top[0].Name != "Ani" || top[1].Name != "Citra" {
+		t.Fatalf("Find sort: %+v %v", top, err)
+	}
+
+	del, err := atdb.DeleteManyDocs(db, "user", bson.M{"poin": bson.M{"$exists": false}})
+	if err != nil || del.DeletedCount != 1 {
+		t.Fatalf("DeleteManyDocs: %+v %v", del, err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	db := connect(t)
+	ctx := context.Background()
+	_, err := db.Collection("ledger").InsertMany(ctx, []any{
+		bson.M{"user": "a", "entries": bson.A{bson.M{"amount": 3}, bson.M{"amount": 4}}},
+		bson.M{"user": "b", "entries": bson.A{bson.M{"amount": 10}}},
+		bson.M{"user": "a", "entries": bson.A{bson.M{"amount": -2}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := db.Collection("ledger").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$user"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: "$entries.amount"}}}, {Key: "avg", Value: bson.D{{Key: "$avg", Value: "$entries.amount"}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []struct {
+		ID    string  `bson:"_id"`
+		Total int     `bson:"total"`
+		Avg   float64 `bson:"avg"`
+	}
+	if err = cur.All(ctx, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != "a" || res[0].Total != 5 || res[1].Total != 10 || res[1].Avg != 10 {
+		t.Fatalf("aggregate: %+v", res)
+	}
+}
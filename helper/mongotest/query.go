@@ -0,0 +1,510 @@
+package mongotest
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lookup mengambil semua nilai pada path bertitik. Seperti MongoDB, array dokumen ikut ditelusuri
+// sehingga "members.phonenumber" menghasilkan nomor setiap anggota. Slice kosong berarti field tidak ada.
+func lookup(v any, path []string) []any {
+	if len(path) == 0 {
+		return []any{v}
+	}
+	switch x := v.(type) {
+	case bson.D:
+		for _, e := range x {
+			if e.Key == path[0] {
+				return lookup(e.Value, path[1:])
+			}
+		}
+	case bson.A:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i >= 0 && i < len(x) {
+				return lookup(x[i], path[1:])
+			}
+			return nil
+		}
+		var res []any
+		for _, el := range x {
+			if _, ok := el.(bson.D); ok {
+				res = append(res, lookup(el, path)...)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func lookupPath(doc bson.D, path string) []any {
+	return lookup(doc, strings.Split(path, "."))
+}
+
+func lookupString(doc bson.D, key string) (string, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			s, ok := e.Value.(string)
+			return s, ok
+		}
+	}
+	return "", false
+}
+
+func get(doc bson.D, key string) (any, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// match mengecek dokumen terhadap filter query
+func match(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var ok bool
+		var err error
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			subs, isArr := e.Value.(bson.A)
+			if !isArr {
+				return false, fmt.Errorf("%s harus berupa array", e.Key)
+			}
+			ok, err = matchLogical(doc, e.Key, subs)
+		case "$comment":
+			ok = true
+		default:
+			if strings.HasPrefix(e.Key, "$") {
+				return false, fmt.Errorf("operator query %s belum didukung", e.Key)
+			}
+			ok, err = matchField(lookupPath(doc, e.Key), e.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.D, op string, subs bson.A) (bool, error) {
+	for _, s := range subs {
+		sub, ok := s.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("isi %s harus berupa dokumen", op)
+		}
+		m, err := match(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !m:
+			return false, nil
+		case op == "$or" && m:
+			return true, nil
+		case op == "$nor" && m:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+func isOperatorDoc(v any) bool {
+	d, ok := v.(bson.D)
+	return ok && len(d) > 0 && strings.HasPrefix(d[0].Key, "$")
+}
+
+// matchField mengecek nilai field terhadap kondisi, berupa nilai literal atau dokumen operator
+func matchField(vals []any, cond any) (bool, error) {
+	if !isOperatorDoc(cond) {
+		return eqAny(vals, cond), nil
+	}
+	ops := cond.(bson.D)
+	for _, op := range ops {
+		ok, err := matchOperator(vals, op.Key, op.Value, ops)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(vals []any, op string, arg any, ops bson.D) (bool, error) {
+	switch op {
+	case "$eq":
+		return eqAny(vals, arg), nil
+	case "$ne":
+		return !eqAny(vals, arg), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, v := range flatten(vals) {
+			if sameClass(v, arg) && compareOK(op, compare(v, arg)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$in", "$nin":
+		list, ok := arg.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("%s harus berupa array", op)
+		}
+		found := false
+		for _, want := range list {
+			if eqAny(vals, want) {
+				found = true
+				break
+			}
+		}
+		return found == (op == "$in"), nil
+	case "$exists":
+		return (len(vals) > 0) == truthy(arg), nil
+	case "$regex":
+		options, _ := lookupString(ops, "$options")
+		re, err := compileRegex(arg, options)
+		if err != nil {
+			return false, err
+		}
+		for _, v := range flatten(vals) {
+			if s, ok := v.(string); ok && re.MatchString(s) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$options":
+		return true, nil
+	case "$not":
+		ok, err := matchField(vals, arg)
+		return !ok, err
+	case "$elemMatch":
+		sub, ok := arg.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("$elemMatch harus berupa dokumen")
+		}
+		for _, v := range vals {
+			arr, ok := v.(bson.A)
+			if !ok {
+				continue
+			}
+			for _, el := range arr {
+				var m bool
+				var err error
+				if isOperatorDoc(sub) {
+					m, err = matchField([]any{el}, sub)
+				} else if d, isDoc := el.(bson.D); isDoc {
+					m, err = match(d, sub)
+				}
+				if err != nil {
+					return false, err
+				}
+				if m {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case "$size":
+		n, ok := toFloat(arg)
+		if !ok {
+			return false, fmt.Errorf("$size harus berupa angka")
+		}
+		for _, v := range vals {
+			if arr, ok := v.(bson.A); ok && float64(len(arr)) == n {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$all":
+		list, ok := arg.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("$all harus berupa array")
+		}
+		for _, want := range list {
+			if !eqAny(vals, want) {
+				return false, nil
+			}
+		}
+		return len(list) > 0, nil
+	case "$type":
+		want, isNumber := toFloat(arg)
+		for _, v := range flatten(vals) {
+			if (isNumber && float64(typeNumber(v)) == want) || typeName(v) == arg {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("operator query %s belum didukung", op)
+}
+
+func compareOK(op string, c int) bool {
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	}
+	return c <= 0
+}
+
+// flatten membuka satu tingkat array, perbandingan MongoDB berlaku untuk elemen array
+func flatten(vals []any) []any {
+	var res []any
+	for _, v := range vals {
+		if arr, ok := v.(bson.A); ok {
+			res = append(res, arr...)
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// eqAny adalah kesamaan ala MongoDB: nilai sama, salah satu elemen array sama, atau null cocok dengan field kosong
+func eqAny(vals []any, want any) bool {
+	if want == nil && len(vals) == 0 {
+		return true
+	}
+	if re, ok := want.(primitive.Regex); ok {
+		r, err := compileRegex(re.Pattern, re.Options)
+		if err != nil {
+			return false
+		}
+		for _, v := range flatten(vals) {
+			if s, ok := v.(string); ok && r.MatchString(s) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range flatten(vals) {
+		if equal(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileRegex(pattern any, options string) (*regexp.Regexp, error) {
+	var p string
+	switch x := pattern.(type) {
+	case string:
+		p = x
+	case primitive.Regex:
+		p = x.Pattern
+		if options == "" {
+			options = x.Options
+		}
+	default:
+		return nil, fmt.Errorf("$regex harus berupa string")
+	}
+	flags := ""
+	for _, o := range options {
+		if strings.ContainsRune("ims", o) {
+			flags += string(o)
+		}
+	}
+	if flags != "" {
+		p = "(?" + flags + ")" + p
+	}
+	return regexp.Compile(p)
+}
+
+func truthy(v any) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case nil:
+		return false
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(x.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// class adalah urutan tipe BSON saat dibandingkan
+func class(v any) int {
+	switch v.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, float64, int, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+	return 12
+}
+
+func sameClass(a, b any) bool {
+	return class(a) == class(b)
+}
+
+func equal(a, b any) bool {
+	return sameClass(a, b) && compare(a, b) == 0
+}
+
+// compare membandingkan dua nilai BSON, tipe berbeda diurutkan menurut class
+func compare(a, b any) int {
+	ca, cb := class(a), class(b)
+	if ca != cb {
+		return cmpInt(ca, cb)
+	}
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case bson.D:
+		y := b.(bson.D)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := strings.Compare(x[i].Key, y[i].Key); c != 0 {
+				return c
+			}
+			if c := compare(x[i].Value, y[i].Value); c != 0 {
+				return c
+			}
+		}
+		return cmpInt(len(x), len(y))
+	case bson.A:
+		y := b.(bson.A)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return cmpInt(len(x), len(y))
+	case primitive.ObjectID:
+		y := b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case bool:
+		y := b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case primitive.DateTime:
+		return cmpInt(int64(x), int64(b.(primitive.DateTime)))
+	case primitive.Timestamp:
+		y := b.(primitive.Timestamp)
+		if x.T != y.T {
+			return cmpInt(x.T, y.T)
+		}
+		return cmpInt(x.I, y.I)
+	case primitive.Binary:
+		return bytes.Compare(x.Data, b.(primitive.Binary).Data)
+	case primitive.Regex:
+		return strings.Compare(x.Pattern, b.(primitive.Regex).Pattern)
+	}
+	if ca == 2 {
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		case math.IsNaN(fa) || math.IsNaN(fb):
+			return cmpInt(boolInt(!math.IsNaN(fa)), boolInt(!math.IsNaN(fb)))
+		}
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func cmpInt[T int | int64 | uint32](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil, primitive.Null:
+		return "null"
+	case int32, int:
+		return "int"
+	case int64:
+		return "long"
+	case float64:
+		return "double"
+	case primitive.Decimal128:
+		return "decimal"
+	case string:
+		return "string"
+	case bson.D:
+		return "object"
+	case bson.A:
+		return "array"
+	case primitive.Binary:
+		return "binData"
+	case primitive.ObjectID:
+		return "objectId"
+	case bool:
+		return "bool"
+	case primitive.DateTime:
+		return "date"
+	case primitive.Timestamp:
+		return "timestamp"
+	case primitive.Regex:
+		return "regex"
+	}
+	return ""
+}
+
+// typeNumbers adalah nomor tipe BSON untuk $type yang memakai angka
+var typeNumbers = map[string]int{"double": 1, "string": 2, "object": 3, "array": 4, "binData": 5, "objectId": 7,
+	"bool": 8, "date": 9, "null": 10, "regex": 11, "int": 16, "timestamp": 17, "long": 18, "decimal": 19}
+
+func typeNumber(v any) int {
+	return typeNumbers[typeName(v)]
+}
@@ -0,0 +1,235 @@
+// Package mongotest adalah MongoDB tiruan di memori untuk test integrasi.
+// Server berbicara dengan wire protocol MongoDB sehingga driver resmi dan helper atdb dipakai apa adanya,
+// cukup untuk CRUD, findAndModify, index unik, distinct, count dan pipeline aggregate sederhana.
+// Transaksi, change stream dan operator yang tidak dikenal menghasilkan error supaya test tidak lolos diam-diam.
+package mongotest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+
+	flagChecksumPresent = 1 << 0
+	flagMoreToCome      = 1 << 1
+)
+
+// Server adalah satu instance MongoDB tiruan, semua database disimpan di memori
+type Server struct {
+	ln     net.Listener
+	mu     sync.Mutex
+	dbs    map[string]map[string]*collection
+	conns  sync.WaitGroup
+	connID int32
+}
+
+// Start menjalankan server di port acak 127.0.0.1
+func Start() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{ln: ln, dbs: make(map[string]map[string]*collection)}
+	go s.accept()
+	return s, nil
+}
+
+// URI adalah connection string untuk mongo.Connect atau atdb.MongoConnect
+func (s *Server) URI() string {
+	return "mongodb://" + s.ln.Addr().String() + "/?directConnection=true"
+}
+
+// Close menghentikan server, koneksi yang masih terbuka ditutup oleh client
+func (s *Server) Close() error {
+	return s.ln.Close()
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connID++
+		id := s.connID
+		s.mu.Unlock()
+		go s.serve(conn, id)
+	}
+}
+
+func (s *Server) serve(conn net.Conn, id int32) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		length := int32(binary.LittleEndian.Uint32(header[0:]))
+		requestID := int32(binary.LittleEndian.Uint32(header[4:]))
+		opCode := int32(binary.LittleEndian.Uint32(header[12:]))
+		if length < 16 {
+			return
+		}
+		body := make([]byte, length-16)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+		var reply []byte
+		switch opCode {
+		case opQuery:
+			cmd, err := parseQuery(body)
+			if err != nil {
+				return
+			}
+			reply = replyMessage(requestID, opReply, s.run("admin", cmd, id))
+		case opMsg:
+			flags, cmd, err := parseMsg(body)
+			if err != nil {
+				return
+			}
+			db, _ := lookupString(cmd, "$db")
+			res := s.run(db, cmd, id)
+			if flags&flagMoreToCome != 0 {
+				continue
+			}
+			reply = replyMessage(requestID, opMsg, res)
+		default:
+			return
+		}
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// parseQuery membaca OP_QUERY yang dipakai driver untuk handshake pertama
+func parseQuery(body []byte) (bson.D, error) {
+	if len(body) < 4 {
+		return nil, errors.New("OP_QUERY terlalu pendek")
+	}
+	rest := body[4:]
+	i := 0
+	for i < len(rest) && rest[i] != 0 {
+		i++
+	}
+	rest = rest[i+1:]
+	if len(rest) < 8 {
+		return nil, errors.New("OP_QUERY terlalu pendek")
+	}
+	rest = rest[8:]
+	var cmd bson.D
+	if err := bson.Unmarshal(rest, &cmd); err != nil {
+		return nil, err
+	}
+	if len(cmd) > 0 && cmd[0].Key == "$query" {
+		if q, ok := cmd[0].Value.(bson.D); ok {
+			return q, nil
+		}
+	}
+	return cmd, nil
+}
+
+// parseMsg membaca OP_MSG, document sequence (section kind 1) digabung ke body sebagai array
+func parseMsg(body []byte) (flags uint32, cmd bson.D, err error) {
+	if len(body) < 4 {
+		return 0, nil, errors.New("OP_MSG terlalu pendek")
+	}
+	flags = binary.LittleEndian.Uint32(body)
+	rest := body[4:]
+	if flags&flagChecksumPresent != 0 && len(rest) >= 4 {
+		rest = rest[:len(rest)-4]
+	}
+	var seqs bson.D
+	for len(rest) > 0 {
+		kind := rest[0]
+		rest = rest[1:]
+		switch kind {
+		case 0:
+			size, err := docSize(rest)
+			if err != nil {
+				return 0, nil, err
+			}
+			if err = bson.Unmarshal(rest[:size], &cmd); err != nil {
+				return 0, nil, err
+			}
+			rest = rest[size:]
+		case 1:
+			if len(rest) < 4 {
+				return 0, nil, errors.New("document sequence terlalu pendek")
+			}
+			size := int(binary.LittleEndian.Uint32(rest))
+			if size > len(rest) {
+				return 0, nil, errors.New("document sequence terlalu pendek")
+			}
+			seq := rest[4:size]
+			rest = rest[size:]
+			i := 0
+			for i < len(seq) && seq[i] != 0 {
+				i++
+			}
+			ident := string(seq[:i])
+			seq = seq[i+1:]
+			var docs bson.A
+			for len(seq) > 0 {
+				n, err := docSize(seq)
+				if err != nil {
+					return 0, nil, err
+				}
+				var d bson.D
+				if err = bson.Unmarshal(seq[:n], &d); err != nil {
+					return 0, nil, err
+				}
+				docs = append(docs, d)
+				seq = seq[n:]
+			}
+			seqs = append(seqs, bson.E{Key: ident, Value: docs})
+		default:
+			return 0, nil, errors.New("section OP_MSG tidak dikenal")
+		}
+	}
+	return flags, append(cmd, seqs...), nil
+}
+
+func docSize(b []byte) (int, error) {
+	if len(b) < 5 {
+		return 0, errors.New("dokumen BSON terlalu pendek")
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n < 5 || n > len(b) {
+		return 0, errors.New("ukuran dokumen BSON tidak valid")
+	}
+	return n, nil
+}
+
+// replyMessage membungkus dokumen balasan dengan OP_REPLY untuk handshake atau OP_MSG untuk perintah biasa
+func replyMessage(responseTo int32, opCode int32, doc bson.D) []byte {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		raw, _ = bson.Marshal(errorDoc(err))
+	}
+	var payload []byte
+	if opCode == opReply {
+		payload = make([]byte, 20)
+		binary.LittleEndian.PutUint32(payload[16:], 1) //numberReturned
+	} else {
+		payload = []byte{0, 0, 0, 0, 0} //flagBits lalu section kind 0
+	}
+	payload = append(payload, raw...)
+	msg := make([]byte, 16, 16+len(payload))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(16+len(payload)))
+	binary.LittleEndian.PutUint32(msg[8:], uint32(responseTo))
+	binary.LittleEndian.PutUint32(msg[12:], uint32(opCode))
+	return append(msg, payload...)
+}
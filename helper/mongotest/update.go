@@ -0,0 +1,427 @@
+package mongotest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// applyUpdate menjalankan dokumen update atau mengganti isi dokumen jika update tidak memakai operator.
+// inserting bernilai true saat upsert membuat dokumen baru sehingga $setOnInsert ikut dijalankan.
+func applyUpdate(doc bson.D, update bson.D, filter bson.D, inserting bool) (bson.D, error) {
+	if !isOperatorDoc(update) {
+		for _, e := range update {
+			if strings.HasPrefix(e.Key, "$") {
+				return nil, fmt.Errorf("dokumen pengganti tidak boleh berisi operator %s", e.Key)
+			}
+		}
+		id, hasID := get(doc, "_id")
+		res := bson.D{}
+		if hasID {
+			res = append(res, bson.E{Key: "_id", Value: id})
+		}
+		for _, e := range update {
+			if e.Key != "_id" || !hasID {
+				res = append(res, e)
+			}
+		}
+		return res, nil
+	}
+	doc = cloneDoc(doc)
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("isi %s harus berupa dokumen", op.Key)
+		}
+		for _, f := range fields {
+			path, err := positional(doc, filter, f.Key)
+			if err != nil {
+				return nil, err
+			}
+			if err = applyOperator(&doc, op.Key, path, f.Value, inserting); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+func applyOperator(doc *bson.D, op string, path []string, arg any, inserting bool) error {
+	cur := lookup(*doc, path)
+	switch op {
+	case "$set":
+		return setPath(doc, path, arg)
+	case "$setOnInsert":
+		if inserting {
+			return setPath(doc, path, arg)
+		}
+		return nil
+	case "$unset":
+		unsetPath(doc, path)
+		return nil
+	case "$inc", "$mul":
+		var base any = int32(0)
+		if len(cur) > 0 {
+			base = cur[0]
+		}
+		res, err := arith(op, base, arg)
+		if err != nil {
+			return fmt.Errorf("%s pada %s: %w", op, strings.Join(path, "."), err)
+		}
+		return setPath(doc, path, res)
+	case "$min", "$max":
+		if len(cur) == 0 || (op == "$min" && compare(arg, cur[0]) < 0) || (op == "$max" && compare(arg, cur[0]) > 0) {
+			return setPath(doc, path, arg)
+		}
+		return nil
+	case "$currentDate":
+		return setPath(doc, path, primitive.NewDateTimeFromTime(time.Now()))
+	case "$push", "$addToSet":
+		var arr bson.A
+		if len(cur) > 0 {
+			existing, ok := cur[0].(bson.A)
+			if !ok {
+				return fmt.Errorf("%s pada %s yang bukan array", op, strings.Join(path, "."))
+			}
+			arr = append(bson.A{}, existing...)
+		}
+		items := bson.A{arg}
+		if d, ok := arg.(bson.D); ok && len(d) > 0 && d[0].Key == "$each" {
+			each, ok := d[0].Value.(bson.A)
+			if !ok {
+				return fmt.Errorf("$each harus berupa array")
+			}
+			items = each
+		}
+		for _, it := range items {
+			if op == "$addToSet" && eqAny([]any{arr}, it) {
+				continue
+			}
+			arr = append(arr, it)
+		}
+		return setPath(doc, path, arr)
+	case "$pull", "$pullAll":
+		if len(cur) == 0 {
+			return nil
+		}
+		existing, ok := cur[0].(bson.A)
+		if !ok {
+			return nil
+		}
+		arr := bson.A{}
+		for _, el := range existing {
+			remove, err := pullMatch(op, el, arg)
+			if err != nil {
+				return err
+			}
+			if !remove {
+				arr = append(arr, el)
+			}
+		}
+		return setPath(doc, path, arr)
+	case "$pop":
+		if len(cur) == 0 {
+			return nil
+		}
+		existing, ok := cur[0].(bson.A)
+		if !ok || len(existing) == 0 {
+			return nil
+		}
+		if f, _ := toFloat(arg); f < 0 {
+			return setPath(doc, path, append(bson.A{}, existing[1:]...))
+		}
+		return setPath(doc, path, append(bson.A{}, existing[:len(existing)-1]...))
+	case "$rename":
+		target, ok := arg.(string)
+		if !ok {
+			return fmt.Errorf("$rename harus berupa string")
+		}
+		if len(cur) == 0 {
+			return nil
+		}
+		unsetPath(doc, path)
+		return setPath(doc, strings.Split(target, "."), cur[0])
+	}
+	return fmt.Errorf("operator update %s belum didukung", op)
+}
+
+func pullMatch(op string, el, arg any) (bool, error) {
+	if op == "$pullAll" {
+		list, ok := arg.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("$pullAll harus berupa array")
+		}
+		return containsEqual(list, el), nil
+	}
+	if isOperatorDoc(arg) {
+		return matchField([]any{el}, arg)
+	}
+	if cond, ok := arg.(bson.D); ok {
+		if d, isDoc := el.(bson.D); isDoc {
+			return match(d, cond)
+		}
+		return false, nil
+	}
+	return eqAny([]any{el}, arg), nil
+}
+
+func containsEqual(list bson.A, v any) bool {
+	for _, it := range list {
+		if equal(it, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// arith menjumlahkan atau mengalikan dengan aturan tipe MongoDB: int32 tetap int32, ada int64 jadi int64, ada double jadi double
+func arith(op string, a, b any) (any, error) {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if !okA || !okB {
+		return nil, fmt.Errorf("nilai bukan angka")
+	}
+	_, aFloat := a.(float64)
+	_, bFloat := b.(float64)
+	if aFloat || bFloat {
+		if op == "$mul" {
+			return fa * fb, nil
+		}
+		return fa + fb, nil
+	}
+	ia, ib := int64(fa), int64(fb)
+	res := ia + ib
+	if op == "$mul" {
+		res = ia * ib
+	}
+	_, aLong := a.(int64)
+	_, bLong := b.(int64)
+	if aLong || bLong || res > 1<<31-1 || res < -1<<31 {
+		return res, nil
+	}
+	return int32(res), nil
+}
+
+// positional mengganti segmen "$" dengan indeks elemen array pertama yang cocok dengan filter
+func positional(doc bson.D, filter bson.D, key string) ([]string, error) {
+	path := strings.Split(key, ".")
+	for i, seg := range path {
+		if seg != "$" {
+			continue
+		}
+		prefix := strings.Join(path[:i], ".")
+		vals := lookup(doc, path[:i])
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("operator posisi $ pada %s tanpa array", key)
+		}
+		arr, ok := vals[0].(bson.A)
+		if !ok {
+			return nil, fmt.Errorf("operator posisi $ pada %s bukan array", key)
+		}
+		idx := -1
+		for j, el := range arr {
+			ok, err := elementMatches(el, prefix, filter)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("operator posisi $ tidak menemukan elemen yang cocok di %s", prefix)
+		}
+		path[i] = strconv.Itoa(idx)
+	}
+	return path, nil
+}
+
+// elementMatches mengecek satu elemen array terhadap bagian filter yang menyebut array tersebut
+func elementMatches(el any, prefix string, filter bson.D) (bool, error) {
+	matched := false
+	for _, e := range filter {
+		switch {
+		case e.Key == prefix:
+			if d, ok := e.Value.(bson.D); ok && len(d) == 1 && d[0].Key == "$elemMatch" {
+				sub, _ := d[0].Value.(bson.D)
+				var ok bool
+				var err error
+				if elDoc, isDoc := el.(bson.D); isDoc && !isOperatorDoc(sub) {
+					ok, err = match(elDoc, sub)
+				} else {
+					ok, err = matchField([]any{el}, sub)
+				}
+				if err != nil || !ok {
+					return false, err
+				}
+			} else if ok, err := matchField([]any{el}, e.Value); err != nil || !ok {
+				return false, err
+			}
+			matched = true
+		case strings.HasPrefix(e.Key, prefix+"."):
+			elDoc, ok := el.(bson.D)
+			if !ok {
+				return false, nil
+			}
+			ok, err := matchField(lookupPath(elDoc, strings.TrimPrefix(e.Key, prefix+".")), e.Value)
+			if err != nil || !ok {
+				return false, err
+			}
+			matched = true
+		}
+	}
+	return matched, nil
+}
+
+// setPath menulis nilai pada path bertitik, dokumen perantara dibuat jika belum ada
+func setPath(doc *bson.D, path []string, v any) error {
+	if len(path) == 1 {
+		for i := range *doc {
+			if (*doc)[i].Key == path[0] {
+				(*doc)[i].Value = v
+				return nil
+			}
+		}
+		*doc = append(*doc, bson.E{Key: path[0], Value: v})
+		return nil
+	}
+	for i := range *doc {
+		if (*doc)[i].Key != path[0] {
+			continue
+		}
+		switch child := (*doc)[i].Value.(type) {
+		case bson.D:
+			err := setPath(&child, path[1:], v)
+			(*doc)[i].Value = child
+			return err
+		case bson.A:
+			idx, err := strconv.Atoi(path[1])
+			if err != nil || idx < 0 {
+				return fmt.Errorf("indeks array %s tidak valid", path[1])
+			}
+			for len(child) <= idx {
+				child = append(child, nil)
+			}
+			if len(path) == 2 {
+				child[idx] = v
+			} else {
+				el, _ := child[idx].(bson.D)
+				if el == nil {
+					el = bson.D{}
+				}
+				if err = setPath(&el, path[2:], v); err != nil {
+					return err
+				}
+				child[idx] = el
+			}
+			(*doc)[i].Value = child
+			return nil
+		case nil:
+			sub := bson.D{}
+			err := setPath(&sub, path[1:], v)
+			(*doc)[i].Value = sub
+			return err
+		default:
+			return fmt.Errorf("tidak bisa membuat field %s di dalam nilai %s", strings.Join(path[1:], "."), typeName(child))
+		}
+	}
+	sub := bson.D{}
+	if err := setPath(&sub, path[1:], v); err != nil {
+		return err
+	}
+	*doc = append(*doc, bson.E{Key: path[0], Value: sub})
+	return nil
+}
+
+func unsetPath(doc *bson.D, path []string) {
+	for i := range *doc {
+		if (*doc)[i].Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			*doc = append((*doc)[:i:i], (*doc)[i+1:]...)
+			return
+		}
+		switch child := (*doc)[i].Value.(type) {
+		case bson.D:
+			unsetPath(&child, path[1:])
+			(*doc)[i].Value = child
+		case bson.A:
+			if idx, err := strconv.Atoi(path[1]); err == nil && idx >= 0 && idx < len(child) {
+				if len(path) == 2 {
+					child[idx] = nil
+				} else if el, ok := child[idx].(bson.D); ok {
+					unsetPath(&el, path[2:])
+					child[idx] = el
+				}
+			}
+		}
+		return
+	}
+}
+
+// cloneDoc menyalin dokumen sampai ke dalam supaya perubahan tidak ikut mengubah data yang sudah dikirim ke client
+func cloneDoc(doc bson.D) bson.D {
+	res := make(bson.D, len(doc))
+	for i, e := range doc {
+		res[i] = bson.E{Key: e.Key, Value: cloneValue(e.Value)}
+	}
+	return res
+}
+
+func cloneValue(v any) any {
+	switch x := v.(type) {
+	case bson.D:
+		return cloneDoc(x)
+	case bson.A:
+		res := make(bson.A, len(x))
+		for i, el := range x {
+			res[i] = cloneValue(el)
+		}
+		return res
+	}
+	return v
+}
+
+// upsertBase membuat dokumen awal upsert dari kesamaan di filter
+func upsertBase(filter bson.D) (bson.D, error) {
+	doc := bson.D{}
+	for _, e := range filter {
+		if strings.HasPrefix(e.Key, "$") {
+			if e.Key == "$and" {
+				subs, _ := e.Value.(bson.A)
+				for _, s := range subs {
+					if sub, ok := s.(bson.D); ok {
+						part, err := upsertBase(sub)
+						if err != nil {
+							return nil, err
+						}
+						for _, p := range part {
+							if err = setPath(&doc, strings.Split(p.Key, "."), p.Value); err != nil {
+								return nil, err
+							}
+						}
+					}
+				}
+			}
+			continue
+		}
+		v := e.Value
+		if isOperatorDoc(v) {
+			eq, ok := get(v.(bson.D), "$eq")
+			if !ok {
+				continue
+			}
+			v = eq
+		}
+		if err := setPath(&doc, strings.Split(e.Key, "."), cloneValue(v)); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
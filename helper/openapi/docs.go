@@ -0,0 +1,128 @@
+package openapi
+
+import (
+	"github.com/gocroot/model"
+)
+
+// Operations adalah dokumentasi endpoint yang dipakai frontend do.my.id dan naskah.bukupedia.co.id.
+// Kunci berisi method dan pola route persis seperti di route.New, pola yang tidak terdaftar membuat fungsi gagal start.
+var Operations = map[string]Op{
+	// user dan proyek
+	"GET /data/user":    {Summary: "Data user pemilik token", Tag: "user", Auth: Login, Response: model.Userdomyikado{}},
+	"POST /data/user":   {Summary: "Daftar atau ubah data user", Tag: "user", Auth: Login, Request: model.Userdomyikado{}, Response: model.Userdomyikado{}},
+	"PUT /data/user":    {Summary: "Buat token akses WA jangka panjang", Tag: "user", Auth: Login, Response: model.Userdomyikado{}},
+	"GET /data/proyek":  {Summary: "Proyek milik user", Tag: "proyek", Auth: Login, Response: []model.Project{}},
+	"POST /data/proyek": {Summary: "Buat proyek baru", Tag: "proyek", Auth: Login, Request: model.Project{}, Response: model.Project{}},
+	"PUT /data/proyek":  {Summary: "Ubah proyek", Tag: "proyek", Auth: Login, Request: model.Project{}, Response: model.Project{}},
+
+	// skor aktivitas
+	"GET /api/activityscore":                     {Summary: "Skor aktivitas sejak awal semester", Tag: "activityscore", Auth: Login, Response: model.ActivityScore{}},
+	"GET /api/activityscoreweekly":               {Summary: "Skor aktivitas minggu berjalan", Tag: "activityscore", Auth: Login, Response: model.ActivityScore{}},
+	"GET /api/activityscore/history":             {Summary: "Riwayat skor mingguan, phonenumber untuk mahasiswa lain (dosen, asesor, owner)", Tag: "activityscore", Auth: Login, Query: []string{"phonenumber", "from", "to"}, Response: []model.WeeklyScoreSnapshot{}},
+	"GET /data/tugaskelasws/weekly":              {Summary: "Skor tugas kelas WS minggu berjalan", Tag: "activityscore", Auth: Login, Response: model.ScoreKelas{}},
+	"GET /data/tugaskelasai/weekly":              {Summary: "Skor tugas kelas AI minggu berjalan", Tag: "activityscore", Auth: Login, Response: model.ScoreKelas{}},
+	"GET /api/scoringrule/active/:enroll":        {Summary: "Aturan skor yang aktif untuk enroll", Tag: "scoringrule", Response: model.ScoringRuleSet{}},
+	"GET /api/scoringrule/:enroll":               {Summary: "Semua versi aturan skor sebuah enroll", Tag: "scoringrule", Auth: Login, Response: []model.ScoringRuleSet{}},
+	"POST /api/scoringrule":                      {Summary: "Simpan versi baru aturan skor", Tag: "scoringrule", Auth: Login, Request: model.ScoringRuleSet{}, Response: model.ScoringRuleSet{}},
+	"PUT /api/scoringrule/activate/:id:objectid": {Summary: "Aktifkan kembali versi lama aturan skor", Tag: "scoringrule", Auth: Login, Response: model.ScoringRuleSet{}},
+	"DELETE /api/scoringrule/:id:objectid":       {Summary: "Hapus versi aturan skor yang tidak aktif", Tag: "scoringrule", Auth: Login},
+
+	// kalender dan periode
+	"GET /api/liburnasional":                 {Summary: "Daftar tanggal libur", Tag: "kalender", Query: []string{"tahun", "kampus"}, Response: []model.LiburNasional{}},
+	"POST /api/liburnasional":                {Summary: "Tambah tanggal atau rentang libur", Tag: "kalender", Auth: Login, Request: model.LiburRequest{}, Response: model.Response{}},
+	"PUT /api/liburnasional/:id:objectid":    {Summary: "Ubah tanggal libur", Tag: "kalender", Auth: Login, Request: model.LiburRequest{}, Response: model.LiburNasional{}},
+	"DELETE /api/liburnasional/:id:objectid": {Summary: "Hapus tanggal libur", Tag: "kalender", Auth: Login},
+	"GET /api/periodeakademik":               {Summary: "Periode akademik dan minggu berjalan", Tag: "kalender", Auth: Login, Response: model.PeriodeAkademikStatus{}},
+	"PUT /api/periodeakademik":               {Summary: "Ganti mulai semester, cutoff minggu dan zona waktu", Tag: "kalender", Auth: Login, Request: model.PeriodeAkademik{}, Response: model.PeriodeAkademikStatus{}},
+
+	// role
+	"GET /api/rbac/me":                       {Summary: "Role dan permission pemilik token", Tag: "rbac", Auth: Login, Response: model.UserAccess{}},
+	"GET /api/rbac/role":                     {Summary: "Role tersimpan, phonenumber untuk satu user", Tag: "rbac", Auth: Login, Query: []string{"phonenumber"}, Response: []model.UserRole{}},
+	"POST /api/rbac/role":                    {Summary: "Beri role ke user", Tag: "rbac", Auth: Login, Request: model.UserRoleRequest{}, Response: model.UserRole{}},
+	"DELETE /api/rbac/role/:id:objectid":     {Summary: "Cabut role", Tag: "rbac", Auth: Login},
+	"GET /api/waoutbox/failed":               {Summary: "Pesan WA yang gagal dikirim", Tag: "waoutbox", Auth: Login, Response: []model.WAOutbox{}},
+	"POST /api/waoutbox/resend/:id:objectid": {Summary: "Kirim ulang pesan WA yang gagal", Tag: "waoutbox", Auth: Login, Response: model.WAOutbox{}},
+	"GET /api/poinledger/drift":              {Summary: "Selisih saldo poin user dengan ledger tanpa perbaikan", Tag: "poinledger", Auth: Login, Response: model.Response{}},
+	"POST /api/poinledger/reconcile":         {Summary: "Samakan saldo poin user dengan ledger", Tag: "poinledger", Auth: Login, Response: model.Response{}},
+
+	// bimbingan
+	"POST /data/proyek/bimbingan/perdana":      {Summary: "Ajukan bimbingan pertama ke asesor", Tag: "bimbingan", Auth: Login, Request: model.ActivityScore{}, Response: model.ActivityScore{}},
+	"POST /data/proyek/bimbingan/lanjutan":     {Summary: "Ajukan bimbingan lanjutan ke asesor", Tag: "bimbingan", Auth: Login, Request: model.ActivityScore{}, Response: model.ActivityScore{}},
+	"GET /data/proyek/bimbingan/:id:objectid":  {Summary: "Detail bimbingan", Tag: "bimbingan", Auth: Login, Response: model.ActivityScore{}},
+	"POST /data/proyek/bimbingan/:id:objectid": {Summary: "Penilaian bimbingan oleh asesor", Tag: "bimbingan", Auth: Login, Request: model.ActivityScore{}, Response: model.ActivityScore{}},
+	"POST /api/bimbingan/pengajuan":            {Summary: "Ajukan sidang", Tag: "bimbingan", Auth: Login, Request: model.BimbinganPengajuan{}, Response: model.BimbinganPengajuan{}},
+
+	// toko poin
+	"POST /api/store/buy-bimbingan-code":            {Summary: "Beli code bimbingan dengan pointevent", Tag: "store", Auth: Login, Data: map[string]any{}},
+	"GET /api/store/item":                           {Summary: "Katalog toko poin, pengelola event melihat item tidak aktif", Tag: "store", Auth: Login, Response: []model.StoreItem{}},
+	"POST /api/store/item":                          {Summary: "Tambah item katalog", Tag: "store", Auth: Login, Request: model.StoreItemRequest{}, Response: model.StoreItem{}},
+	"PUT /api/store/item/:id:objectid":              {Summary: "Ubah item katalog", Tag: "store", Auth: Login, Request: model.StoreItemRequest{}, Response: model.StoreItem{}},
+	"POST /api/store/beli":                          {Summary: "Beli item katalog dengan pointevent", Tag: "store", Auth: Login, Request: model.StoreBeliRequest{}, Data: map[string]any{}},
+	"GET /api/store/pembelian":                      {Summary: "Riwayat pembelian, pengelola event melihat semua pembelian", Tag: "store", Auth: Login, Query: []string{"phonenumber", "status"}, Response: []model.StorePembelian{}},
+	"PUT /api/store/pembelian/refund/:id:objectid":  {Summary: "Refund pembelian yang belum selesai", Tag: "store", Auth: Login, Request: model.StoreRefundRequest{}, Response: model.StorePembelian{}},
+	"PUT /api/store/pembelian/selesai/:id:objectid": {Summary: "Tandai pemenuhan manual selesai", Tag: "store", Auth: Login, Request: model.StoreSelesaiRequest{}, Response: model.StorePembelian{}},
+
+	// pomokit
+	"GET /api/pomokit/flag":              {Summary: "Sesi Pomokit yang ditahan analisis anti-curang", Tag: "pomokit", Auth: Login, Query: []string{"status", "phonenumber", "wagroupid"}, Response: []model.PomokitFlag{}},
+	"PUT /api/pomokit/flag/:id:objectid": {Summary: "Review sesi Pomokit yang ditahan, valid atau ditolak", Tag: "pomokit", Auth: Login, Request: model.PomokitFlagReviewRequest{}, Response: model.PomokitFlag{}},
+
+	// sidang
+	"GET /api/sidang/slot":                      {Summary: "Slot ketersediaan dosen yang belum lewat", Tag: "sidang", Auth: Login, Query: []string{"phonenumber"}, Response: []model.SidangSlot{}},
+	"POST /api/sidang/slot":                     {Summary: "Buka slot ketersediaan menguji", Tag: "sidang", Auth: Login, Request: model.SidangSlotRequest{}, Response: model.SidangSlot{}},
+	"DELETE /api/sidang/slot/:id:objectid":      {Summary: "Hapus slot ketersediaan", Tag: "sidang", Auth: Login},
+	"GET /api/sidang/jadwal":                    {Summary: "Jadwal sidang, admin melihat semua jadwal", Tag: "sidang", Auth: Login, Query: []string{"status"}, Response: []model.JadwalSidang{}},
+	"POST /api/sidang/jadwal":                   {Summary: "Jadwalkan sidang dengan ruang dan waktu pilihan admin", Tag: "sidang", Auth: Login, Request: model.JadwalSidangRequest{}, Data: model.JadwalSidang{}},
+	"POST /api/sidang/jadwal/otomatis":          {Summary: "Jadwalkan sidang pada waktu dan ruang pertama yang cocok", Tag: "sidang", Auth: Login, Request: model.JadwalOtomatisRequest{}, Data: model.JadwalSidang{}},
+	"PUT /api/sidang/jadwal/:id:objectid":       {Summary: "Pindah jadwal sidang", Tag: "sidang", Auth: Login, Request: model.JadwalSidangRequest{}, Response: model.JadwalSidang{}},
+	"PUT /api/sidang/jadwal/batal/:id:objectid": {Summary: "Batalkan jadwal sidang", Tag: "sidang", Auth: Login, Request: model.BatalSidangRequest{}, Response: model.JadwalSidang{}},
+
+	// event
+	"GET /api/event/all":                         {Summary: "Event aktif yang belum diklaim", Tag: "event", Auth: Login, Data: []map[string]any{}},
+	"POST /api/event/create":                     {Summary: "Buat event", Tag: "event", Auth: Login, Request: model.EventCreateRequest{}, Data: map[string]any{}},
+	"POST /api/event/claim":                      {Summary: "Klaim event", Tag: "event", Auth: Login, Request: model.EventClaimRequest{}, Data: map[string]any{}},
+	"POST /api/event/submit":                     {Summary: "Kirim link tugas event, link dicek otomatis sebelum masuk antrian review", Tag: "event", Auth: Login, Request: model.EventSubmitRequest{}, Data: map[string]any{}},
+	"POST /api/event/approve":                    {Summary: "Setujui tugas event", Tag: "event", Auth: Login, Request: model.EventApproveRequest{}, Data: map[string]any{}},
+	"POST /api/event/review":                     {Summary: "Tolak atau minta revisi tugas event dengan komentar", Tag: "event", Auth: Login, Request: model.EventReviewRequest{}, Data: map[string]any{}},
+	"GET /api/event/queue":                       {Summary: "Antrian review tugas event, terlama di atas, link yang tidak lolos cek otomatis ditandai", Tag: "event", Auth: Login, Query: []string{"category"}, Data: []model.EventReviewItem{}},
+	"GET /api/event/myclaims":                    {Summary: "Klaim event milik user", Tag: "event", Auth: Login, Data: []map[string]any{}},
+	"GET /api/event/claim/:claimid:objectid":     {Summary: "Detail klaim event", Tag: "event", Auth: Login, Data: map[string]any{}},
+	"POST /api/event/claimcode":                  {Summary: "Klaim kode referral bimbingan", Tag: "event", Auth: Login, Request: model.TimeCodeClaimRequest{}, Data: map[string]any{}},
+	"POST /api/event/generatecodetime":           {Summary: "Buat kode bimbingan dengan masa berlaku", Tag: "event", Auth: Login, Request: model.TimeCodeGenerateRequest{}, Response: map[string]any{}},
+	"POST /api/event/claimcodetime":              {Summary: "Klaim kode bimbingan berwaktu", Tag: "event", Auth: Login, Request: model.TimeCodeClaimRequest{}, Data: map[string]any{}},
+	"DELETE /api/event/delete/:eventid:objectid": {Summary: "Hapus event", Tag: "event", Auth: Login, Data: map[string]any{}},
+	"GET /api/event/allclaims":                   {Summary: "Semua klaim event beserta nama dan poin event", Tag: "event", Auth: Login, Data: []model.EventClaimDetail{}},
+
+	// crowdfunding, endpoint per provider ditambahkan di init
+	"GET /api/crowdfunding/checkPayment/:orderId": {Summary: "Status pembayaran langkah 1", Tag: "crowdfunding", Response: model.CrowdfundingPaymentResponse{}},
+	"GET /api/crowdfunding/totals":                {Summary: "Total crowdfunding semua metode", Tag: "crowdfunding", Response: model.CrowdfundingTotal{}},
+	"GET /api/crowdfunding/history":               {Summary: "Riwayat order crowdfunding user", Tag: "crowdfunding", Auth: Login, Response: []model.CrowdfundingOrder{}},
+
+	// tracker
+	"POST /api/tracker":       {Summary: "Simpan kunjungan web peserta", Tag: "tracker", Auth: Tracker, Request: model.UserInfo{}},
+	"POST /api/tracker/token": {Summary: "Buat token tracker untuk hostname proyek", Tag: "tracker", Request: model.UserInfo{}},
+
+	// integrasi dengan secret app profile
+	"POST /data/strava-poin/wa/:nomorwa": {Summary: "Tambah poin Strava dari bot WA", Tag: "integrasi", Auth: Secret},
+}
+
+func init() {
+	for _, method := range []model.PaymentMethod{model.QRIS, model.MicroBitcoin, model.Ravencoin} {
+		prefix := "/api/crowdfunding/" + string(method)
+		payment := Op{Tag: "crowdfunding", Response: model.CrowdfundingPaymentResponse{}}
+		createOrder := payment
+		createOrder.Summary = "Buat order " + string(method)
+		createOrder.Auth = Login
+		if method == model.QRIS {
+			createOrder.Request = model.CreateQRISOrderRequest{}
+		}
+		Operations["POST "+prefix+"/createOrder"] = createOrder
+		for _, step := range []string{"checkPayment", "checkStep2", "checkStep3"} {
+			check := payment
+			check.Summary = "Status pembayaran " + string(method) + " " + step
+			Operations["GET "+prefix+"/"+step+"/:orderId"] = check
+		}
+		confirm := payment
+		confirm.Summary = "Konfirmasi manual order " + string(method)
+		Operations["POST "+prefix+"/confirm/:orderId"] = confirm
+	}
+}
@@ -0,0 +1,381 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Skema auth header yang dipakai endpoint
+const (
+	Login   = "login"   // token whatsauth milik user
+	Secret  = "secret"  // secret app profile untuk webhook dan integrasi
+	Tracker = "tracker" // token tracker yang dipasang di web peserta
+)
+
+// Op adalah dokumentasi satu endpoint. Request, Response dan Data diisi nilai kosong dari struct model,
+// skemanya dibangkitkan dari tag json sehingga selalu sama dengan model.
+type Op struct {
+	Summary  string
+	Tag      string
+	Auth     string   // Login, Secret, Tracker atau kosong
+	Query    []string // nama query parameter
+	Request  any      // body request, nil jika tanpa body
+	Response any      // body respon 200, default model.Response
+	Data     any      // isi field data jika Response adalah model.Response
+	Error    any      // body respon gagal, default envelope at.WriteError
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem memetakan method huruf kecil ke operasinya
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+	objectIDPattern = "^[0-9a-f]{24}$"
+)
+
+// Build menyusun dokumen OpenAPI dari route yang terdaftar, route tanpa Op tetap muncul tanpa skema
+func Build(routes []router.RouteInfo, ops map[string]Op) Document {
+	g := generator{schemas: make(map[string]*Schema)}
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "domyikado",
+			Description: "API backend do.my.id. Skema dibangkitkan dari tabel route dan struct model.",
+			Version:     "1.0.0",
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				Login:   {Type: "apiKey", In: "header", Name: "login", Description: "Token login whatsauth"},
+				Secret:  {Type: "apiKey", In: "header", Name: "secret", Description: "Secret app profile"},
+				Tracker: {Type: "apiKey", In: "header", Name: "Tracker", Description: "Token tracker web peserta"},
+			},
+		},
+	}
+	for _, r := range routes {
+		if r.Method == http.MethodOptions {
+			continue
+		}
+		path := openAPIPath(r.Pattern)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(r.Method)] = g.operation(r, ops[Key(r.Method, r.Pattern)])
+	}
+	return doc
+}
+
+// Key adalah kunci tabel Op, contoh "GET /api/activityscore"
+func Key(method, pattern string) string {
+	return method + " " + pattern
+}
+
+// Check memastikan setiap Op terdokumentasi memiliki route, dipanggil saat router dibuat
+func Check(routes []router.RouteInfo, ops map[string]Op) error {
+	ada := make(map[string]bool)
+	for _, r := range routes {
+		ada[Key(r.Method, r.Pattern)] = true
+	}
+	var hilang []string
+	for key := range ops {
+		if !ada[key] {
+			hilang = append(hilang, key)
+		}
+	}
+	if len(hilang) > 0 {
+		sort.Strings(hilang)
+		return fmt.Errorf("openapi: dokumentasi tanpa route: %s", strings.Join(hilang, ", "))
+	}
+	return nil
+}
+
+// Handler menyajikan dokumen OpenAPI, dokumen dibangun sekali saat request pertama
+func Handler(routes func() []router.RouteInfo, ops map[string]Op) http.HandlerFunc {
+	var once sync.Once
+	var doc Document
+	return func(respw http.ResponseWriter, req *http.Request) {
+		once.Do(func() {
+			doc = Build(routes(), ops)
+		})
+		at.WriteJSON(respw, http.StatusOK, doc)
+	}
+}
+
+func (g generator) operation(r router.RouteInfo, op Op) *Operation {
+	o := &Operation{
+		Summary:     op.Summary,
+		OperationID: operationID(r.Method, r.Pattern),
+		Responses:   make(map[string]Response),
+	}
+	if op.Tag != "" {
+		o.Tags = []string{op.Tag}
+	}
+	for _, p := range r.Params {
+		o.Parameters = append(o.Parameters, Parameter{Name: p.Name, In: "path", Required: true, Schema: paramSchema(p.Type)})
+	}
+	for _, q := range op.Query {
+		o.Parameters = append(o.Parameters, Parameter{Name: q, In: "query", Schema: &Schema{Type: "string"}})
+	}
+	if op.Auth != "" {
+		o.Security = []map[string][]string{{op.Auth: {}}}
+	}
+	if op.Request != nil {
+		o.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schema(reflect.TypeOf(op.Request)))}
+	}
+	if op.Summary == "" && op.Response == nil {
+		o.Responses["200"] = Response{Description: "OK"}
+		return o
+	}
+	resp := op.Response
+	if resp == nil {
+		resp = model.Response{}
+	}
+	ok := g.schema(reflect.TypeOf(resp))
+	if op.Data != nil {
+		ok = &Schema{AllOf: []*Schema{ok, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": g.schema(reflect.TypeOf(op.Data))},
+		}}}
+	}
+	o.Responses["200"] = Response{Description: "OK", Content: jsonContent(ok)}
+	o.Responses["default"] = Response{Description: "Gagal", Content: jsonContent(g.errorSchema(op.Error))}
+	return o
+}
+
+// errorSchema adalah envelope at.WriteError dengan daftar kode apperr, kecuali Op memakai body gagal sendiri.
+// Envelope disimpan sekali di components/schemas sebagai ErrorResponse.
+func (g generator) errorSchema(errResp any) *Schema {
+	if errResp != nil {
+		return g.schema(reflect.TypeOf(errResp))
+	}
+	if _, ok := g.schemas["ErrorResponse"]; !ok {
+		var codes []string
+		for _, c := range apperr.Codes() {
+			codes = append(codes, string(c))
+		}
+		g.schemas["ErrorResponse"] = &Schema{AllOf: []*Schema{g.schema(reflect.TypeOf(model.Response{})), {
+			Type:       "object",
+			Properties: map[string]*Schema{"code": {Type: "string", Enum: codes}},
+			Required:   []string{"code"},
+		}}}
+	}
+	return &Schema{Ref: "#/components/schemas/ErrorResponse"}
+}
+
+// generator membangkitkan skema dari tipe Go, struct bernama disimpan di components/schemas
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func (g generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if s.Ref != "" {
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			// daftarkan dulu supaya struct yang saling merujuk tidak berulang tanpa henti
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// interface{} dan tipe lain bebas
+	return &Schema{}
+}
+
+// object mengikuti aturan encoding/json: nama dari tag json, field tanpa omitempty wajib ada, struct embedded diratakan
+func (g generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := g.object(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		if strings.Contains(opts, "string") {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+func paramSchema(tipe string) *Schema {
+	switch tipe {
+	case router.TypeInt:
+		return &Schema{Type: "integer"}
+	case router.TypeObjectID:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	}
+	return &Schema{Type: "string"}
+}
+
+// openAPIPath mengubah /api/event/delete/:eventid:objectid menjadi /api/event/delete/{eventid}
+func openAPIPath(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			name, _, _ := strings.Cut(p[1:], ":")
+			parts[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// operationID dibuat dari method dan path supaya unik, contoh get_api_event_delete_eventid
+func operationID(method, pattern string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, p := range strings.Split(openAPIPath(pattern), "/") {
+		p = strings.Trim(p, "{}")
+		if p == "" {
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteString(strings.NewReplacer("-", "_", ".", "_").Replace(p))
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocroot/helper/router"
+)
+
+// jalankan go test ./helper/openapi -update setelah perubahan model yang disengaja, lalu review diff testdata
+var update = flag.Bool("update", false, "tulis ulang testdata/openapi.json")
+
+// documentedRoutes mendaftarkan pola setiap Op ke router kosong, sekaligus memastikan polanya valid
+func documentedRoutes(t *testing.T) []router.RouteInfo {
+	r := router.New(http.NotFound)
+	for key := range Operations {
+		method, pattern, _ := strings.Cut(key, " ")
+		if err := r.Add(method, pattern, http.NotFound); err != nil {
+			t.Fatalf("pola %q tidak valid: %v", key, err)
+		}
+	}
+	return r.Routes()
+}
+
+// TestSchemaDrift gagal jika skema endpoint terdokumentasi berubah karena struct model berubah
+func TestSchemaDrift(t *testing.T) {
+	got, err := json.MarshalIndent(Build(documentedRoutes(t), Operations), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("skema OpenAPI berbeda dengan %s, model berubah tanpa memperbarui dokumentasi.\n"+
+			"Jika perubahan disengaja jalankan go test ./helper/openapi -update lalu commit diff-nya.\n%s",
+			golden, firstDiff(string(want), string(got)))
+	}
+}
+
+func TestCheckMissingRoute(t *testing.T) {
+	routes := documentedRoutes(t)
+	if err := Check(routes, Operations); err != nil {
+		t.Fatal(err)
+	}
+	err := Check(routes[1:], Operations)
+	if err == nil || !strings.Contains(err.Error(), routes[0].Pattern) {
+		t.Fatalf("Check harus menolak Op tanpa route, didapat %v", err)
+	}
+}
+
+func TestSchemaFollowsJSONTags(t *testing.T) {
+	type inner struct {
+		Kode string `json:"kode"`
+	}
+	type sample struct {
+		inner
+		Nama    string  `json:"nama"`
+		Catatan string  `json:"catatan,omitempty"`
+		Rahasia string  `json:"-"`
+		Tanpa   int     // tanpa tag json memakai nama field
+		Opsi    *string `json:"opsi"`
+	}
+	g := generator{schemas: make(map[string]*Schema)}
+	g.schema(reflect.TypeOf(sample{}))
+	s := g.schemas["sample"]
+	for _, name := range []string{"kode", "nama", "catatan", "Tanpa", "opsi"} {
+		if s.Properties[name] == nil {
+			t.Errorf("properti %s tidak ada", name)
+		}
+	}
+	if s.Properties["Rahasia"] != nil || s.Properties["-"] != nil {
+		t.Error("field dengan tag json:\"-\" tidak boleh muncul")
+	}
+	if strings.Join(s.Required, ",") != "kode,nama,Tanpa,opsi" {
+		t.Errorf("required = %v", s.Required)
+	}
+	if !s.Properties["opsi"].Nullable {
+		t.Error("pointer harus nullable")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	type item struct {
+		Nama  string    `json:"nama"`
+		Poin  int       `json:"poin"`
+		Waktu time.Time `json:"waktu"`
+		Tag   []string  `json:"tag,omitempty"`
+	}
+	r := router.New(http.NotFound)
+	r.GET("/api/item/:id:objectid", http.NotFound)
+	ops := map[string]Op{"GET /api/item/:id:objectid": {Summary: "Satu item", Data: item{}}}
+	doc := Build(r.Routes(), ops)
+	path := "/api/item/0123456789abcdef01234567"
+	tests := []struct {
+		status int
+		body   string
+		salah  string
+	}{
+		{200, `{"status":"Success","response":"","data":{"nama":"a","poin":1,"waktu":"2026-01-05T08:00:00+07:00","tag":null}}`, ""},
+		{200, `{"status":"Success","response":"","data":{"nama":"a","poin":1.5,"waktu":"2026-01-05T08:00:00Z"}}`, "bukan integer"},
+		{200, `{"status":"Success","response":"","data":{"nama":"a","waktu":"2026-01-05T08:00:00Z"}}`, "poin wajib ada"},
+		{200, `{"status":"Success","response":"","data":{"nama":"a","poin":1,"waktu":"kemarin"}}`, "bukan date-time"},
+		{200, `{"status":"Success","response":"","data":{"nama":"a","poin":1,"waktu":"2026-01-05T08:00:00Z","lain":true}}`, "lain tidak ada di skema"},
+		{200, `{"status":"Success","response":"","data":{"nama":"a","poin":1,"waktu":"2026-01-05T08:00:00Z"},"ekstra":1}`, "ekstra tidak ada di skema"},
+		{404, `{"status":"Error","response":"","code":"NOT_FOUND"}`, ""},
+		{404, `{"status":"Error","response":""}`, "code wajib ada"},
+	}
+	for _, tt := range tests {
+		err := doc.Validate(http.MethodGet, path, tt.status, []byte(tt.body))
+		if (tt.salah == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.salah)) {
+			t.Errorf("Validate(%d %s) = %v, seharusnya %q", tt.status, tt.body, err, tt.salah)
+		}
+	}
+	if err := doc.Validate(http.MethodGet, "/api/lain", 200, []byte(`[]`)); err != nil {
+		t.Errorf("path tanpa dokumentasi seharusnya dilewati: %v", err)
+	}
+}
+
+func firstDiff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			return "baris " + strconv.Itoa(i+1) + ":\n  golden: " + wl + "\n  model:  " + gl
+		}
+	}
+	return ""
+}
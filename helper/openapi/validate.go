@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate mencocokkan body respon sebuah request dengan skema di dokumen.
+// Respon 200 dicek dengan skema "200", status lain dengan "default". Path tanpa Op atau tanpa skema respon dilewati.
+func (d Document) Validate(method, path string, status int, body []byte) error {
+	item, pola := d.cariPath(path)
+	if item == nil {
+		return nil
+	}
+	op := item[strings.ToLower(method)]
+	if op == nil {
+		return nil
+	}
+	kode := "default"
+	if status == http.StatusOK {
+		kode = "200"
+	}
+	resp, ok := op.Responses[kode]
+	if !ok || resp.Content["application/json"].Schema == nil {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("%s %s: respon bukan JSON: %v", method, pola, err)
+	}
+	if err := d.cek(resp.Content["application/json"].Schema, v, "$"); err != nil {
+		return fmt.Errorf("%s %s %d: %v", method, pola, status, err)
+	}
+	return nil
+}
+
+// cariPath mencari path OpenAPI yang cocok, segmen statis didahulukan dari {parameter} seperti router
+func (d Document) cariPath(path string) (PathItem, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var best []string
+	var bestPola string
+	for pola := range d.Paths {
+		segs := strings.Split(strings.Trim(pola, "/"), "/")
+		if len(segs) != len(parts) {
+			continue
+		}
+		cocok := true
+		for i, s := range segs {
+			if !strings.HasPrefix(s, "{") && s != parts[i] {
+				cocok = false
+				break
+			}
+		}
+		if cocok && (best == nil || lebihSpesifik(segs, best)) {
+			best, bestPola = segs, pola
+		}
+	}
+	if best == nil {
+		return nil, ""
+	}
+	return d.Paths[bestPola], bestPola
+}
+
+func lebihSpesifik(a, b []string) bool {
+	for i := range a {
+		pa, pb := strings.HasPrefix(a[i], "{"), strings.HasPrefix(b[i], "{")
+		if pa != pb {
+			return !pa
+		}
+	}
+	return false
+}
+
+// cek memvalidasi v terhadap skema. Slice dan map nil di Go menjadi null, jadi null diterima untuk array dan object.
+// Properti yang tidak ada di skema dianggap salah supaya handler yang mengirim tipe lain ketahuan.
+func (d Document) cek(s *Schema, v any, at string) error {
+	if err := d.cekTanpaSisa(s, v, at); err != nil {
+		return err
+	}
+	return d.cekSisa(s, v, at)
+}
+
+// cekTanpaSisa memvalidasi v tanpa mengecek properti asing, anggota allOf masing-masing hanya mengenal sebagian properti
+func (d Document) cekTanpaSisa(s *Schema, v any, at string) error {
+	s = d.resolve(s)
+	if len(s.AllOf) > 0 {
+		for _, sub := range s.AllOf {
+			if err := d.cekTanpaSisa(sub, v, at); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if v == nil {
+		if s.Nullable || s.Type == "" || s.Type == "array" || s.Type == "object" {
+			return nil
+		}
+		return fmt.Errorf("%s: null, seharusnya %s", at, s.Type)
+	}
+	switch s.Type {
+	case "":
+		return nil
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: %T, seharusnya string", at, v)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q bukan date-time", at, str)
+			}
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+			return fmt.Errorf("%s: %q tidak cocok dengan pola %s", at, str, s.Pattern)
+		}
+		if len(s.Enum) > 0 && !ada(s.Enum, str) {
+			return fmt.Errorf("%s: %q bukan salah satu dari %v", at, str, s.Enum)
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: %T, seharusnya %s", at, v, s.Type)
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("%s: %v bukan integer", at, n)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: %T, seharusnya boolean", at, v)
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: %T, seharusnya array", at, v)
+		}
+		if s.Items != nil {
+			for i, el := range arr {
+				if err := d.cek(s.Items, el, at+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: %T, seharusnya object", at, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: field %s wajib ada", at, name)
+			}
+		}
+		for name, el := range obj {
+			fs := s.Properties[name]
+			if fs == nil {
+				fs = s.AdditionalProperties
+			}
+			if fs == nil {
+				continue
+			}
+			if err := d.cek(fs, el, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cekSisa menolak properti yang tidak dikenal skema object maupun oleh satu pun anggota allOf
+func (d Document) cekSisa(s *Schema, v any, at string) error {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	kenal, bebas := d.properti(s)
+	if bebas {
+		return nil
+	}
+	var asing []string
+	for name := range obj {
+		if !kenal[name] {
+			asing = append(asing, name)
+		}
+	}
+	if len(asing) > 0 {
+		sort.Strings(asing)
+		return fmt.Errorf("%s: field %s tidak ada di skema", at, strings.Join(asing, ", "))
+	}
+	return nil
+}
+
+// properti mengumpulkan nama properti skema object beserta anggota allOf, bebas jika skema menerima properti apa pun
+func (d Document) properti(s *Schema) (kenal map[string]bool, bebas bool) {
+	s = d.resolve(s)
+	kenal = make(map[string]bool)
+	if len(s.AllOf) > 0 {
+		for _, sub := range s.AllOf {
+			k, b := d.properti(sub)
+			if b {
+				return nil, true
+			}
+			for name := range k {
+				kenal[name] = true
+			}
+		}
+		return kenal, false
+	}
+	if s.Type != "object" || s.AdditionalProperties != nil {
+		return nil, true
+	}
+	for name := range s.Properties {
+		kenal[name] = true
+	}
+	return kenal, false
+}
+
+func (d Document) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+func ada(daftar []string, s string) bool {
+	for _, x := range daftar {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package periode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan satu dokumen konfigurasi periode akademik yang aktif
+const Collection = "periodeakademik"
+
+const (
+	FormatTanggal = "2006-01-02"
+
+	// cacheTTL membatasi umur cache, perubahan lewat API langsung menghapus cache
+	cacheTTL = 10 * time.Minute
+)
+
+var (
+	cacheMu      sync.RWMutex
+	cachePeriode *Periode
+	cacheLoaded  time.Time
+)
+
+// Periode adalah konfigurasi periode akademik yang sudah divalidasi dan siap dipakai menghitung minggu
+type Periode struct {
+	Config        model.PeriodeAkademik
+	loc           *time.Location
+	mulaiSemester time.Time
+}
+
+// Default dipakai jika belum ada konfigurasi di database, sama dengan aturan yang dipakai sebelumnya:
+// minggu proyek dimulai Senin 17:01 WIB, kelasws Jumat 00:00 dan kelasai Sabtu 00:01
+func Default() model.PeriodeAkademik {
+	return model.PeriodeAkademik{
+		Nama:          "Semester Genap 2024/2025",
+		SemesterStart: "2025-03-11",
+		TimeZone:      "Asia/Jakarta",
+		Cutoff:        model.BatasMinggu{Hari: int(time.Monday), Jam: "17:01"},
+		CutoffKelas: []model.BatasMinggu{
+			{Kelas: "kelasws", Hari: int(time.Friday), Jam: "00:00"},
+			{Kelas: "kelasai", Hari: int(time.Saturday), Jam: "00:01"},
+		},
+	}
+}
+
+// New memvalidasi konfigurasi lalu menyiapkan zona waktu dan tanggal mulai semester
+func New(cfg model.PeriodeAkademik) (p Periode, err error) {
+	p.Config = cfg
+	p.loc, err = time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return p, errors.New("timezone " + cfg.TimeZone + " tidak dikenal")
+	}
+	p.mulaiSemester, err = time.ParseInLocation(FormatTanggal, cfg.SemesterStart, p.loc)
+	if err != nil {
+		return p, errors.New("semesterstart tidak valid, gunakan format YYYY-MM-DD")
+	}
+	for _, b := range append([]model.BatasMinggu{cfg.Cutoff}, cfg.CutoffKelas...) {
+		if b.Hari < 0 || b.Hari > 6 {
+			return p, errors.New("hari cutoff " + strconv.Itoa(b.Hari) + " tidak valid, gunakan 0 (Minggu) sampai 6 (Sabtu)")
+		}
+		if _, _, err = parseJam(b.Jam); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// Get mengembalikan periode aktif dari cache, jika belum ada di database dipakai Default
+func Get(db *mongo.Database) Periode {
+	cacheMu.RLock()
+	p, loaded := cachePeriode, cacheLoaded
+	cacheMu.RUnlock()
+	if p != nil && time.Since(loaded) < cacheTTL {
+		return *p
+	}
+	var cfg model.PeriodeAkademik
+	err := db.Collection(Collection).FindOne(context.Background(), bson.M{}).Decode(&cfg)
+	if err != nil && err != mongo.ErrNoDocuments {
+		// cache lama tetap dipakai jika database tidak bisa dibaca
+		log.Printf("Error loading periodeakademik: %v", err)
+		if p != nil {
+			return *p
+		}
+	}
+	if err != nil {
+		cfg = Default()
+	}
+	baru, err := New(cfg)
+	if err != nil {
+		log.Printf("Error periodeakademik tidak valid, memakai default: %v", err)
+		baru = defaultPeriode()
+	}
+	cacheMu.Lock()
+	cachePeriode, cacheLoaded = &baru, time.Now()
+	cacheMu.Unlock()
+	return baru
+}
+
+// Simpan menimpa konfigurasi periode aktif setelah divalidasi
+func Simpan(db *mongo.Database, cfg model.PeriodeAkademik) (Periode, error) {
+	p, err := New(cfg)
+	if err != nil {
+		return p, err
+	}
+	cfg.UpdatedAt = time.Now()
+	var lama model.PeriodeAkademik
+	err = db.Collection(Collection).FindOne(context.Background(), bson.M{}).Decode(&lama)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return p, err
+	}
+	cfg.ID = lama.ID
+	if cfg.ID.IsZero() {
+		cfg.ID = primitive.NewObjectID()
+	}
+	_, err = db.Collection(Collection).ReplaceOne(context.Background(), bson.M{"_id": cfg.ID}, cfg, options.Replace().SetUpsert(true))
+	if err != nil {
+		return p, err
+	}
+	Invalidate()
+	p.Config = cfg
+	return p, nil
+}
+
+// Invalidate menghapus cache supaya perubahan periode langsung terbaca
+func Invalidate() {
+	cacheMu.Lock()
+	cacheLoaded = time.Time{}
+	cacheMu.Unlock()
+}
+
+// Location adalah zona waktu periode, dipakai untuk membandingkan tanggal yang disimpan sebagai string
+func (p Periode) Location() *time.Location {
+	return p.loc
+}
+
+// MulaiSemester adalah tanggal mulai semester pukul 00:00 di zona waktu periode
+func (p Periode) MulaiSemester() time.Time {
+	return p.mulaiSemester
+}
+
+// Minggu mengembalikan rentang minggu [mulai, selesai) yang memuat waktu t.
+// Kelas kosong atau tidak punya cutoff khusus memakai cutoff utama.
+func (p Periode) Minggu(t time.Time, kelas string) (mulai, selesai time.Time) {
+	b := p.batas(kelas)
+	jam, menit, _ := parseJam(b.Jam)
+	t = t.In(p.loc)
+	mundur := (int(t.Weekday()) - b.Hari + 7) % 7
+	hari := t.AddDate(0, 0, -mundur)
+	mulai = time.Date(hari.Year(), hari.Month(), hari.Day(), jam, menit, 0, 0, p.loc)
+	// hari cutoff sebelum jamnya masih termasuk minggu sebelumnya
+	if t.Before(mulai) {
+		mulai = mulai.AddDate(0, 0, -7)
+	}
+	selesai = mulai.AddDate(0, 0, 7)
+	return
+}
+
+// MingguIni adalah minggu yang sedang berjalan, dipakai semua fungsi skor minggu lalu
+func (p Periode) MingguIni(kelas string) (mulai, selesai time.Time) {
+	return p.Minggu(time.Now(), kelas)
+}
+
+// MingguKe menghitung minggu ke berapa waktu t sejak mulai semester, minggu pertama bernilai 1
+func (p Periode) MingguKe(t time.Time) int {
+	pertama, _ := p.Minggu(p.mulaiSemester, "")
+	sekarang, _ := p.Minggu(t, "")
+	// dibulatkan supaya pergeseran jam musim panas di zona waktu lain tidak mengurangi minggu
+	n := int(sekarang.Sub(pertama).Hours()/24/7+0.5) + 1
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// Label adalah tahun dan minggu ISO dari awal minggu periode, contoh 2025_18
+func (p Periode) Label(t time.Time) string {
+	mulai, _ := p.Minggu(t, "")
+	year, week := mulai.ISOWeek()
+	return fmt.Sprintf("%d_%02d", year, week)
+}
+
+// Status mengisi BimbinganWeeklyStatus untuk minggu yang memuat waktu t
+func (p Periode) Status(t time.Time) model.BimbinganWeeklyStatus {
+	mulai, selesai := p.Minggu(t, "")
+	return model.BimbinganWeeklyStatus{
+		CurrentWeek: p.MingguKe(t),
+		WeekLabel:   p.Label(t),
+		StartDate:   mulai,
+		EndDate:     selesai,
+		LastUpdated: p.Config.UpdatedAt,
+		UpdatedBy:   p.Config.UpdatedBy,
+	}
+}
+
+// FilterObjectID membatasi _id pada dokumen yang dibuat dalam rentang [mulai, selesai)
+func FilterObjectID(mulai, selesai time.Time) bson.M {
+	return bson.M{
+		"$gte": primitive.NewObjectIDFromTimestamp(mulai),
+		"$lt":  primitive.NewObjectIDFromTimestamp(selesai),
+	}
+}
+
+func (p Periode) batas(kelas string) model.BatasMinggu {
+	for _, b := range p.Config.CutoffKelas {
+		if kelas != "" && b.Kelas == kelas {
+			return b
+		}
+	}
+	return p.Config.Cutoff
+}
+
+func parseJam(jam string) (int, int, error) {
+	h, m, ok := strings.Cut(jam, ":")
+	hour, errH := strconv.Atoi(h)
+	minute, errM := strconv.Atoi(m)
+	if !ok || errH != nil || errM != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, errors.New("jam cutoff " + jam + " tidak valid, gunakan format HH:MM")
+	}
+	return hour, minute, nil
+}
+
+// defaultPeriode dipakai jika konfigurasi di database rusak, zona waktu WIB tetap dipakai walau tzdata tidak ada
+func defaultPeriode() Periode {
+	p, err := New(Default())
+	if err != nil {
+		p.Config = Default()
+		p.loc = time.FixedZone("WIB", 7*60*60)
+		p.mulaiSemester, _ = time.ParseInLocation(FormatTanggal, p.Config.SemesterStart, p.loc)
+	}
+	return p
+}
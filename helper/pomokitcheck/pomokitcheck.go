@@ -0,0 +1,269 @@
+package pomokitcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan temuan per sesi, satu dokumen per reportid
+const Collection = "pomokitflag"
+
+var (
+	// DurasiCycle adalah lama satu cycle fokus Pomokit
+	DurasiCycle = 25 * time.Minute
+	// MaksCycle adalah batas cycle satu sesi, lebih dari 8 jam fokus tanpa henti tidak wajar
+	MaksCycle = 16
+	// MaksNomorPerIP adalah bawaan jumlah nomor berbeda yang masih wajar memakai satu IP publik dalam sehari.
+	// Kos, rumah kontrakan dan jaringan seluler berbagi IP, jadi batasnya longgar dan bisa diubah di dokumen config.
+	MaksNomorPerIP = 25
+)
+
+// Aturan adalah batas analisis yang bisa diatur per deployment lewat dokumen config
+type Aturan struct {
+	MaksNomorPerIP int
+	IPKampus       []string // IP atau CIDR egress kampus dan lab, dipakai banyak mahasiswa sekaligus sehingga tidak dicek
+}
+
+// MuatAturan membaca aturan dari dokumen config, field kosong memakai nilai bawaan
+func MuatAturan(db *mongo.Database) Aturan {
+	aturan := Aturan{MaksNomorPerIP: MaksNomorPerIP}
+	conf, err := atdb.GetOneDoc[model.Config](db, "config", bson.M{"phonenumber": "62895601060000"})
+	if err != nil {
+		return aturan
+	}
+	if conf.PomokitMaksNomorPerIP > 0 {
+		aturan.MaksNomorPerIP = conf.PomokitMaksNomorPerIP
+	}
+	aturan.IPKampus = conf.PomokitIPKampus
+	return aturan
+}
+
+// kampus mengecek apakah ip termasuk egress kampus, entri boleh berupa IP tunggal atau CIDR
+func (a Aturan) kampus(s string) bool {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return false
+	}
+	for _, entri := range a.IPKampus {
+		entri = strings.TrimSpace(entri)
+		if _, jaringan, err := net.ParseCIDR(entri); err == nil {
+			if jaringan.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if other := net.ParseIP(entri); other != nil && other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	wib       = time.FixedZone("WIB", 7*60*60)
+	indexOnce sync.Once
+)
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(Collection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "reportid", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "phonenumber", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating pomokitflag index: %v", err)
+		}
+	})
+}
+
+// Analisis memeriksa seluruh laporan Pomokit dan mengembalikan temuan per sesi, terurut waktu sesi.
+// Akhir sesi adalah CreatedAt dan awal sesi dihitung mundur Cycle x DurasiCycle.
+// Laporan tanpa _id dilewati karena temuannya tidak bisa direview.
+func Analisis(reports []model.PomodoroReport, aturan Aturan) []model.PomokitFlag {
+	temuan := make(map[primitive.ObjectID]*model.PomokitFlag)
+	tandai := func(r model.PomodoroReport, alasan, keterangan string) {
+		f, ok := temuan[r.ID]
+		if !ok {
+			f = &model.PomokitFlag{
+				ReportID: r.ID, Name: r.Name, PhoneNumber: r.PhoneNumber, WaGroupID: r.WaGroupID,
+				Cycle: r.Cycle, Screenshots: r.Screenshots, Hostname: r.Hostname, IP: r.IP, SessionAt: r.CreatedAt,
+			}
+			temuan[r.ID] = f
+		}
+		for _, a := range f.Alasan {
+			if a == alasan {
+				return
+			}
+		}
+		f.Alasan = append(f.Alasan, alasan)
+		f.Keterangan = append(f.Keterangan, keterangan)
+	}
+
+	perUser := make(map[string][]model.PomodoroReport)
+	perIP := make(map[string][]model.PomodoroReport)
+	for _, r := range reports {
+		if r.ID.IsZero() || r.PhoneNumber == "" {
+			continue
+		}
+		perUser[r.PhoneNumber] = append(perUser[r.PhoneNumber], r)
+		if ipPublik(r.IP) && !aturan.kampus(r.IP) {
+			key := r.IP + "|" + r.CreatedAt.In(wib).Format("2006-01-02")
+			perIP[key] = append(perIP[key], r)
+		}
+	}
+
+	for _, sesi := range perUser {
+		sort.Slice(sesi, func(i, j int) bool { return sesi[i].CreatedAt.Before(sesi[j].CreatedAt) })
+		terakhir := make(map[string]model.PomodoroReport)
+		for j, r := range sesi {
+			if r.Screenshots < r.Cycle {
+				tandai(r, model.PomokitFlagScreenshot, fmt.Sprintf("%d screenshot untuk %d cycle", r.Screenshots, r.Cycle))
+			}
+			dev := perangkat(r)
+			switch prev, ada := terakhir[dev]; {
+			case r.Cycle < 1 || r.Cycle > MaksCycle:
+				tandai(r, model.PomokitFlagCycle, fmt.Sprintf("%d cycle di luar batas 1 sampai %d", r.Cycle, MaksCycle))
+			case ada && durasi(r) > r.CreatedAt.Sub(prev.CreatedAt):
+				tandai(r, model.PomokitFlagCycle, fmt.Sprintf("%d cycle (%.0f menit) selesai %.0f menit setelah sesi sebelumnya di %s",
+					r.Cycle, durasi(r).Minutes(), r.CreatedAt.Sub(prev.CreatedAt).Minutes(), dev))
+			}
+			terakhir[dev] = r
+			//sesi diurutkan menurut waktu selesai, jadi sesi i beririsan dengan j jika j mulai sebelum i selesai
+			for _, s := range sesi[:j] {
+				if perangkat(s) != dev && r.CreatedAt.Add(-durasi(r)).Before(s.CreatedAt) {
+					tandai(s, model.PomokitFlagTumpangTindih, "bersamaan dengan sesi di "+dev)
+					tandai(r, model.PomokitFlagTumpangTindih, "bersamaan dengan sesi di "+perangkat(s))
+				}
+			}
+		}
+	}
+
+	for _, sesi := range perIP {
+		nomor := make(map[string]bool)
+		for _, r := range sesi {
+			nomor[r.PhoneNumber] = true
+		}
+		if len(nomor) <= aturan.MaksNomorPerIP {
+			continue
+		}
+		for _, r := range sesi {
+			tandai(r, model.PomokitFlagIPBersama, fmt.Sprintf("IP %s dipakai %d nomor di hari yang sama", r.IP, len(nomor)))
+		}
+	}
+
+	flags := make([]model.PomokitFlag, 0, len(temuan))
+	for _, f := range temuan {
+		flags = append(flags, *f)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		if !flags[i].SessionAt.Equal(flags[j].SessionAt) {
+			return flags[i].SessionAt.Before(flags[j].SessionAt)
+		}
+		return flags[i].PhoneNumber < flags[j].PhoneNumber
+	})
+	return flags
+}
+
+// Simpan mencatat temuan baru dengan status ditahan. Temuan yang masih ditahan diperbarui alasannya,
+// sedangkan sesi yang sudah direview dosen tidak diubah lagi.
+func Simpan(db *mongo.Database, flags []model.PomokitFlag, now time.Time) error {
+	ensureIndexes(db)
+	coll := db.Collection(Collection)
+	for _, f := range flags {
+		res, err := coll.UpdateOne(context.Background(),
+			bson.M{"reportid": f.ReportID, "status": model.PomokitFlagDitahan},
+			bson.M{"$set": bson.M{"alasan": f.Alasan, "keterangan": f.Keterangan}})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if res.MatchedCount > 0 {
+			continue
+		}
+		f.Status, f.FlaggedAt = model.PomokitFlagDitahan, now
+		_, err = coll.UpdateOne(context.Background(), bson.M{"reportid": f.ReportID},
+			bson.M{"$setOnInsert": f}, options.Update().SetUpsert(true))
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+	}
+	return nil
+}
+
+// Saring memisahkan sesi yang boleh dihitung di skor memakai temuan yang sudah tersimpan, tanpa menulis apa pun.
+// Analisis dan Simpan hanya dijalankan job refresh, jadi sesi baru tetap dihitung sampai job berikutnya menandainya.
+// Sesi yang ditahan atau ditolak dikembalikan sebagai tertahan beserta temuannya.
+func Saring(ctx context.Context, db *mongo.Database, reports []model.PomodoroReport) (bersih []model.PomodoroReport, tertahan []model.PomokitFlag, err error) {
+	flags, err := atdb.GetAllDocContext[[]model.PomokitFlag](ctx, db, Collection, bson.M{
+		"status": bson.M{"$in": []string{model.PomokitFlagDitahan, model.PomokitFlagDitolak}},
+	})
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.Database, "", err)
+	}
+	ditahan := make(map[primitive.ObjectID]model.PomokitFlag, len(flags))
+	for _, f := range flags {
+		ditahan[f.ReportID] = f
+	}
+	for _, r := range reports {
+		if f, ok := ditahan[r.ID]; ok {
+			tertahan = append(tertahan, f)
+			continue
+		}
+		bersih = append(bersih, r)
+	}
+	return bersih, tertahan, nil
+}
+
+// Review menyimpan keputusan dosen. Sesi valid kembali dihitung di skor, sesi ditolak tetap dikeluarkan.
+func Review(db *mongo.Database, id primitive.ObjectID, req model.PomokitFlagReviewRequest, reviewer string) (f model.PomokitFlag, err error) {
+	if req.Status != model.PomokitFlagValid && req.Status != model.PomokitFlagDitolak {
+		return f, apperr.New(apperr.BadRequest, "", "status review harus valid atau ditolak")
+	}
+	set := bson.M{"status": req.Status, "reviewedby": reviewer, "reviewedat": time.Now()}
+	if catatan := strings.TrimSpace(req.Catatan); catatan != "" {
+		set["catatan"] = catatan
+	}
+	res, err := db.Collection(Collection).UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return f, apperr.Wrap(apperr.Database, "", err)
+	}
+	if res.MatchedCount == 0 {
+		return f, apperr.New(apperr.NotFound, "", "temuan sesi Pomokit tidak ditemukan")
+	}
+	f, err = atdb.GetOneDoc[model.PomokitFlag](db, Collection, bson.M{"_id": id})
+	if err != nil {
+		return f, apperr.Wrap(apperr.Database, "", err)
+	}
+	return f, nil
+}
+
+// perangkat mengenali asal sesi dari hostname, IP dipakai jika hostname kosong
+func perangkat(r model.PomodoroReport) string {
+	if h := strings.ToLower(strings.TrimSpace(r.Hostname)); h != "" {
+		return h
+	}
+	return r.IP
+}
+
+func durasi(r model.PomodoroReport) time.Duration {
+	return time.Duration(r.Cycle) * DurasiCycle
+}
+
+// ipPublik mengabaikan IP lokal dan privat karena nilainya sama di banyak jaringan yang berbeda
+func ipPublik(s string) bool {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	return ip != nil && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+}
@@ -0,0 +1,60 @@
+package pomokitcheck
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAnalisis(t *testing.T) {
+	jam := func(h, m int) time.Time { return time.Date(2099, 3, 2, h, m, 0, 0, wib) }
+	sesi := func(phone, host, ip string, cycle, ss int, selesai time.Time) model.PomodoroReport {
+		return model.PomodoroReport{ID: primitive.NewObjectID(), PhoneNumber: phone, Hostname: host, IP: ip, Cycle: cycle, Screenshots: ss, CreatedAt: selesai}
+	}
+	reports := []model.PomodoroReport{
+		//wajar: dua sesi berurutan di laptop yang sama
+		sesi("A", "laptop-a", "10.0.0.2", 2, 2, jam(9, 0)),
+		sesi("A", "laptop-a", "10.0.0.2", 2, 2, jam(10, 0)),
+		//4 cycle butuh 100 menit tapi hanya 30 menit setelah sesi sebelumnya
+		sesi("A", "laptop-a", "10.0.0.2", 4, 4, jam(10, 30)),
+		//berjalan bersamaan di perangkat lain
+		sesi("A", "pc-lab", "10.0.0.3", 1, 1, jam(10, 28)),
+		sesi("B", "laptop-b", "10.0.0.4", 3, 1, jam(9, 0)),
+		sesi("B", "laptop-b", "10.0.0.4", 20, 20, jam(20, 0)),
+	}
+	//empat nomor dari satu IP publik di hari yang sama
+	for _, phone := range []string{"C", "D", "E", "F"} {
+		reports = append(reports, sesi(phone, "host-"+phone, "36.80.1.1", 1, 1, jam(13, 0)))
+	}
+	//IP privat yang sama tidak dianggap satu jaringan
+	for _, phone := range []string{"G", "H", "I", "J"} {
+		reports = append(reports, sesi(phone, "host-"+phone, "192.168.1.1", 1, 1, jam(14, 0)))
+	}
+	//egress kampus dipakai banyak mahasiswa sekaligus
+	for _, phone := range []string{"K", "L", "M", "N"} {
+		reports = append(reports, sesi(phone, "host-"+phone, "103.10.0.5", 1, 1, jam(15, 0)))
+	}
+
+	got := make(map[primitive.ObjectID]string)
+	for _, f := range Analisis(reports, Aturan{MaksNomorPerIP: 3, IPKampus: []string{"103.10.0.0/24"}}) {
+		got[f.ReportID] = strings.Join(f.Alasan, ",")
+	}
+	want := []string{
+		"", "",
+		model.PomokitFlagCycle + "," + model.PomokitFlagTumpangTindih,
+		model.PomokitFlagTumpangTindih,
+		model.PomokitFlagScreenshot,
+		model.PomokitFlagCycle,
+		model.PomokitFlagIPBersama, model.PomokitFlagIPBersama, model.PomokitFlagIPBersama, model.PomokitFlagIPBersama,
+		"", "", "", "",
+		"", "", "", "",
+	}
+	for i, r := range reports {
+		if got[r.ID] != want[i] {
+			t.Errorf("sesi %d (%s %s) ditandai %q, seharusnya %q", i, r.PhoneNumber, r.CreatedAt.Format("15:04"), got[r.ID], want[i])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+)
+
+type ctxKey struct{}
+
+// Auth adalah hasil decode token login dan role user, disimpan di context request oleh Require
+type Auth struct {
+	Payload watoken.Payload[any]
+	Roles   []model.UserRole
+}
+
+// Require membungkus handler sehingga hanya user dengan permission perm yang bisa memanggilnya.
+// Tanpa scope hanya role global yang dihitung, route milik satu enroll atau proyek memakai RequireScope.
+func Require(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return RequireScope(perm, nil, next)
+}
+
+// Permit adalah Require dalam bentuk middleware router, contoh r.POST("/api/event/create", controller.CreateEvent, rbac.Permit(rbac.KelolaEvent))
+func Permit(perm Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return Require(perm, next)
+	}
+}
+
+// PermitScope adalah RequireScope dalam bentuk middleware router
+func PermitScope(perm Permission, scope func(*http.Request) string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return RequireScope(perm, scope, next)
+	}
+}
+
+// RequireScope sama dengan Require, scope diambil dari request, contoh kode enroll di path.
+// Role terbatas hanya lolos jika scope-nya sama dengan hasil resolver, resolver mengembalikan "" jika objek tidak ditemukan.
+func RequireScope(perm Permission, scope func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(respw http.ResponseWriter, req *http.Request) {
+		payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+		if err != nil {
+			at.WriteError(respw, req, apperr.New(apperr.TokenInvalid, "Error : Token Tidak Valid", err.Error()))
+			return
+		}
+		roles, err := Roles(config.Mongoconn, payload.Id)
+		if err != nil {
+			at.WriteError(respw, req, apperr.New(apperr.Database, "Error : Gagal membaca role user", err.Error()))
+			return
+		}
+		var s string
+		if scope != nil {
+			s = scope(req)
+		}
+		if !Has(roles, perm, s) {
+			at.WriteError(respw, req, apperr.New(apperr.Forbidden, "Error : Akses Ditolak", "Anda tidak memiliki izin "+string(perm)))
+			return
+		}
+		next(respw, req.WithContext(context.WithValue(req.Context(), ctxKey{}, Auth{Payload: payload, Roles: roles})))
+	}
+}
+
+// FromRequest mengambil Auth yang disimpan Require, ok false jika handler tidak dibungkus middleware
+func FromRequest(req *http.Request) (auth Auth, ok bool) {
+	auth, ok = req.Context().Value(ctxKey{}).(Auth)
+	return
+}
+
+// PhoneNumber adalah nomor hp pemilik token pada request yang sudah lolos Require
+func PhoneNumber(req *http.Request) string {
+	auth, _ := FromRequest(req)
+	return auth.Payload.Id
+}
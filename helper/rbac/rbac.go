@@ -0,0 +1,239 @@
+package rbac
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan role yang diberikan ke user, role dosen dan mahasiswa juga diturunkan dari data user
+const Collection = "userrole"
+
+const (
+	RoleOwner     = "owner"
+	RoleDosen     = "dosen"
+	RoleAsesor    = "asesor"
+	RoleMahasiswa = "mahasiswa"
+	RoleProyek    = "proyek" // pemilik proyek, scope berisi ID proyek
+)
+
+// Permission adalah izin untuk satu jenis aksi, dicek oleh middleware Require
+type Permission string
+
+const (
+	KelolaSistem       Permission = "sistem:kelola"           // aturan skor, kalender libur, periode akademik, outbox WA
+	KelolaRole         Permission = "role:kelola"             // memberi dan mencabut role
+	KelolaEvent        Permission = "event:kelola"            // membuat, menghapus dan melihat semua event dan claim
+	ApproveEvent       Permission = "event:approve"           // menyetujui tugas event
+	KodeEvent          Permission = "event:kode"              // membuat kode referral dan kode waktu bimbingan
+	ApproveBimbingan   Permission = "bimbingan:approve"       // menilai bimbingan sebagai asesor
+	AjukanBimbingan    Permission = "bimbingan:ajukan"        // mengajukan bimbingan ke asesor
+	LihatSkorMahasiswa Permission = "skor:lihat"              // melihat skor dan riwayat mahasiswa lain
+	SlotSidang         Permission = "sidang:slot"             // membuka slot ketersediaan menguji sidang
+	JadwalSidang       Permission = "sidang:jadwal"           // menjadwalkan, memindah dan membatalkan sidang
+	ReviewPomokit      Permission = "pomokit:review"          // memutuskan sesi Pomokit yang ditahan analisis anti-curang
+	KonfirmasiBayar    Permission = "crowdfunding:konfirmasi" // konfirmasi manual pembayaran crowdfunding
+	KelolaProyek       Permission = "proyek:kelola"           // mengelola proyek sesuai scope, contoh kirim ulang webhook
+)
+
+// rolePermissions memetakan role ke permission, owner memiliki semua permission
+var rolePermissions = map[string][]Permission{
+	RoleOwner:     {KelolaSistem, KelolaRole, KelolaEvent, ApproveEvent, KodeEvent, ApproveBimbingan, LihatSkorMahasiswa, SlotSidang, JadwalSidang, ReviewPomokit, KonfirmasiBayar, KelolaProyek},
+	RoleDosen:     {ApproveBimbingan, LihatSkorMahasiswa, SlotSidang, ReviewPomokit},
+	RoleAsesor:    {ApproveBimbingan, LihatSkorMahasiswa, SlotSidang, ReviewPomokit},
+	RoleMahasiswa: {AjukanBimbingan},
+	RoleProyek:    {KelolaProyek},
+}
+
+// ownerAwal adalah daftar owner yang sebelumnya ditulis langsung di controller,
+// dipakai sekali untuk mengisi koleksi userrole jika belum ada owner sama sekali
+var ownerAwal = []string{"6285312924192", "6282117252716", "6285179935117", "6285759790334"}
+
+var (
+	ErrRoleTidakDikenal = apperr.New(apperr.BadRequest, "", "role tidak dikenal, gunakan owner, dosen, asesor atau mahasiswa")
+	ErrOwnerTerakhir    = apperr.New(apperr.Conflict, "", "owner terakhir tidak dapat dicabut")
+
+	indexOnce sync.Once
+)
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		coll := db.Collection(Collection)
+		_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "phonenumber", Value: 1}, {Key: "role", Value: 1}, {Key: "scope", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating userrole index: %v", err)
+		}
+		n, err := coll.CountDocuments(context.Background(), bson.M{"role": RoleOwner})
+		if err != nil || n > 0 {
+			return
+		}
+		for _, phonenumber := range ownerAwal {
+			_, err = coll.UpdateOne(context.Background(),
+				bson.M{"phonenumber": phonenumber, "role": RoleOwner, "scope": ""},
+				bson.M{"$setOnInsert": bson.M{"grantedby": "migrasi", "createdAt": time.Now()}},
+				options.Update().SetUpsert(true))
+			if err != nil {
+				log.Printf("Error seeding owner %s: %v", phonenumber, err)
+			}
+		}
+	})
+}
+
+// IsRole mengecek apakah nama role dikenal
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Roles mengembalikan role tersimpan milik user ditambah role turunan dari data user:
+// dosen jika isdosen true, selain itu mahasiswa untuk user yang terdaftar,
+// dan role proyek dengan scope ID proyek untuk setiap proyek yang dimiliki user
+func Roles(db *mongo.Database, phonenumber string) (roles []model.UserRole, err error) {
+	ensureIndexes(db)
+	roles, err = atdb.GetAllDoc[[]model.UserRole](db, Collection, bson.M{"phonenumber": phonenumber})
+	if err != nil {
+		return
+	}
+	usr, errusr := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
+	if errusr != nil {
+		return roles, nil
+	}
+	turunan := RoleMahasiswa
+	if usr.IsDosen {
+		turunan = RoleDosen
+	}
+	roles = append(roles, model.UserRole{PhoneNumber: phonenumber, Role: turunan, Turunan: true})
+	prjs, errprj := atdb.GetAllDoc[[]model.Project](db, "project", bson.M{"owner.phonenumber": phonenumber})
+	if errprj != nil {
+		log.Printf("Error getting projects of %s: %v", phonenumber, errprj)
+	}
+	for _, prj := range prjs {
+		roles = append(roles, model.UserRole{PhoneNumber: phonenumber, Role: RoleProyek, Scope: prj.ID.Hex(), Turunan: true})
+	}
+	return roles, nil
+}
+
+// Permissions menggabungkan permission dari role yang berlaku di scope.
+// Role tanpa scope berlaku di mana pun, role terbatas hanya dihitung jika scope-nya sama persis.
+// Scope kosong berarti aksi global sehingga hanya role tanpa scope yang dihitung.
+func Permissions(roles []model.UserRole, scope string) []Permission {
+	ada := make(map[Permission]bool)
+	for _, r := range roles {
+		if r.Scope != "" && r.Scope != scope {
+			continue
+		}
+		for _, p := range rolePermissions[r.Role] {
+			ada[p] = true
+		}
+	}
+	perms := make([]Permission, 0, len(ada))
+	for p := range ada {
+		perms = append(perms, p)
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
+// Has mengecek permission pada daftar role yang sudah diambil
+func Has(roles []model.UserRole, perm Permission, scope string) bool {
+	for _, p := range Permissions(roles, scope) {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopedPermissions mengembalikan permission per scope untuk setiap role terbatas, dipakai untuk menampilkan akses user
+func ScopedPermissions(roles []model.UserRole) map[string][]Permission {
+	perScope := make(map[string][]Permission)
+	for _, r := range roles {
+		if r.Scope == "" {
+			continue
+		}
+		if _, ok := perScope[r.Scope]; !ok {
+			perScope[r.Scope] = Permissions(roles, r.Scope)
+		}
+	}
+	return perScope
+}
+
+// Can mengecek permission user langsung dari database
+func Can(db *mongo.Database, phonenumber string, perm Permission, scope string) bool {
+	roles, err := Roles(db, phonenumber)
+	if err != nil {
+		log.Printf("Error loading roles %s: %v", phonenumber, err)
+		return false
+	}
+	return Has(roles, perm, scope)
+}
+
+// PhoneNumbersWithRole mengembalikan nomor user yang diberi role tersimpan, dipakai untuk notifikasi owner
+func PhoneNumbersWithRole(db *mongo.Database, role string) (phonenumbers []string) {
+	ensureIndexes(db)
+	roles, err := atdb.GetAllDoc[[]model.UserRole](db, Collection, bson.M{"role": role})
+	if err != nil {
+		log.Printf("Error loading userrole %s: %v", role, err)
+		return
+	}
+	ada := make(map[string]bool)
+	for _, r := range roles {
+		if !ada[r.PhoneNumber] {
+			ada[r.PhoneNumber] = true
+			phonenumbers = append(phonenumbers, r.PhoneNumber)
+		}
+	}
+	return
+}
+
+// Grant menyimpan role user, role yang sudah ada tidak digandakan
+func Grant(db *mongo.Database, role model.UserRole) (model.UserRole, error) {
+	ensureIndexes(db)
+	if !IsRole(role.Role) {
+		return role, ErrRoleTidakDikenal
+	}
+	if role.PhoneNumber == "" {
+		return role, apperr.New(apperr.BadRequest, "", "nomor hp wajib diisi")
+	}
+	if _, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": role.PhoneNumber}); err != nil {
+		return role, apperr.New(apperr.UserNotFound, "", "nomor "+role.PhoneNumber+" tidak terdaftar")
+	}
+	role.CreatedAt = time.Now()
+	err := db.Collection(Collection).FindOneAndUpdate(context.Background(),
+		bson.M{"phonenumber": role.PhoneNumber, "role": role.Role, "scope": role.Scope},
+		bson.M{"$setOnInsert": bson.M{"grantedby": role.GrantedBy, "createdAt": role.CreatedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&role)
+	return role, err
+}
+
+// Revoke mencabut satu role, owner terakhir tidak bisa dicabut supaya sistem tidak terkunci
+func Revoke(db *mongo.Database, id primitive.ObjectID) (role model.UserRole, err error) {
+	role, err = atdb.GetOneDoc[model.UserRole](db, Collection, bson.M{"_id": id})
+	if err != nil {
+		return role, apperr.Wrap(apperr.NotFound, "", err)
+	}
+	if role.Role == RoleOwner && role.Scope == "" {
+		n, errcount := db.Collection(Collection).CountDocuments(context.Background(), bson.M{"role": RoleOwner, "scope": ""})
+		if errcount != nil {
+			return role, errcount
+		}
+		if n <= 1 {
+			return role, ErrOwnerTerakhir
+		}
+	}
+	_, err = atdb.DeleteOneDoc(db, Collection, bson.M{"_id": id})
+	return
+}
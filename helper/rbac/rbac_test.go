@@ -0,0 +1,119 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/mongotest"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var db *mongo.Database
+
+func TestMain(m *testing.M) {
+	mongotest.Main(m, "rbactest", func(d *mongo.Database) { db = d })
+}
+
+func TestHas(t *testing.T) {
+	const proyekA, proyekB = "6ad313af3db5effff2ef11d4", "6ad313af3db5effff2ef11d5"
+	owner := []model.UserRole{{Role: RoleOwner}}
+	pemilikA := []model.UserRole{{Role: RoleMahasiswa, Turunan: true}, {Role: RoleProyek, Scope: proyekA, Turunan: true}}
+	asesorKelas := []model.UserRole{{Role: RoleAsesor, Scope: "kelasai"}}
+	ownerKelas := []model.UserRole{{Role: RoleOwner, Scope: "kelasai"}, {Role: RoleMahasiswa, Turunan: true}}
+	tests := []struct {
+		name  string
+		roles []model.UserRole
+		perm  Permission
+		scope string
+		want  bool
+	}{
+		{name: "owner semua permission", roles: owner, perm: KonfirmasiBayar, want: true},
+		{name: "owner semua proyek", roles: owner, perm: KelolaProyek, scope: proyekB, want: true},
+		{name: "pemilik proyek di proyeknya", roles: pemilikA, perm: KelolaProyek, scope: proyekA, want: true},
+		{name: "pemilik proyek di proyek lain", roles: pemilikA, perm: KelolaProyek, scope: proyekB},
+		{name: "pemilik proyek tidak bisa konfirmasi bayar", roles: pemilikA, perm: KonfirmasiBayar},
+		{name: "mahasiswa mengajukan bimbingan", roles: pemilikA, perm: AjukanBimbingan, scope: proyekB, want: true},
+		{name: "asesor di kelasnya", roles: asesorKelas, perm: ApproveBimbingan, scope: "kelasai", want: true},
+		{name: "asesor di kelas lain", roles: asesorKelas, perm: ApproveBimbingan, scope: "kelasws"},
+		{name: "role proyek bukan izin global", roles: pemilikA, perm: KelolaProyek},
+		{name: "asesor kelas bukan asesor global", roles: asesorKelas, perm: ApproveBimbingan},
+		{name: "owner enroll di enroll-nya", roles: ownerKelas, perm: KelolaSistem, scope: "kelasai", want: true},
+		{name: "owner enroll tidak bisa kelola role global", roles: ownerKelas, perm: KelolaRole},
+		{name: "owner enroll tidak bisa kelola sistem global", roles: ownerKelas, perm: KelolaSistem},
+		{name: "role turunan tetap global", roles: ownerKelas, perm: AjukanBimbingan, want: true},
+		{name: "tanpa role", perm: AjukanBimbingan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Has(tt.roles, tt.perm, tt.scope); got != tt.want {
+				t.Fatalf("Has(%s, %q) = %v, seharusnya %v", tt.perm, tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoles(t *testing.T) {
+	const mhs, dosen = "6289900000101", "6289900000102"
+	for _, u := range []model.Userdomyikado{{PhoneNumber: mhs, Name: "Mahasiswa"}, {PhoneNumber: dosen, Name: "Dosen", IsDosen: true}} {
+		if _, err := atdb.InsertOneDoc(db, "user", u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prj, err := atdb.InsertOneDoc(db, "project", model.Project{Name: "proyek-mhs", Owner: model.Userdomyikado{PhoneNumber: mhs}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lain, err := atdb.InsertOneDoc(db, "project", model.Project{Name: "proyek-dosen", Owner: model.Userdomyikado{PhoneNumber: dosen}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := Roles(db, mhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || roles[0].Role != RoleMahasiswa || roles[1].Role != RoleProyek || roles[1].Scope != prj.Hex() || !roles[1].Turunan {
+		t.Fatalf("role turunan mahasiswa pemilik proyek: %+v", roles)
+	}
+	if !Can(db, mhs, KelolaProyek, prj.Hex()) || Can(db, mhs, KelolaProyek, lain.Hex()) || Can(db, mhs, KelolaProyek, "") {
+		t.Fatal("pemilik proyek hanya boleh mengelola proyeknya sendiri")
+	}
+	if !Can(db, dosen, SlotSidang, "") || Can(db, dosen, KelolaEvent, "") {
+		t.Fatal("isdosen seharusnya memberi role dosen saja")
+	}
+	if Can(db, "6289900000199", AjukanBimbingan, "") {
+		t.Fatal("nomor yang tidak terdaftar tidak punya role turunan")
+	}
+
+	//role tersimpan digabung dengan role turunan, owner terakhir tidak bisa dicabut
+	granted, err := Grant(db, model.UserRole{PhoneNumber: dosen, Role: RoleAsesor, Scope: "kelasai", GrantedBy: mhs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Can(db, dosen, ApproveBimbingan, "kelasai") {
+		t.Fatal("role asesor yang diberikan tidak terbaca")
+	}
+	if got := ScopedPermissions([]model.UserRole{granted}); len(got) != 1 || len(got["kelasai"]) != len(rolePermissions[RoleAsesor]) {
+		t.Fatalf("permission per scope: %v", got)
+	}
+	if _, err = Grant(db, model.UserRole{PhoneNumber: dosen, Role: "rektor"}); err != ErrRoleTidakDikenal {
+		t.Fatalf("role tidak dikenal seharusnya ditolak: %v", err)
+	}
+	if _, err = Revoke(db, granted.ID); err != nil {
+		t.Fatal(err)
+	}
+	owners, err := atdb.GetAllDoc[[]model.UserRole](db, Collection, bson.M{"role": RoleOwner})
+	if err != nil || len(owners) == 0 {
+		t.Fatalf("owner awal seharusnya diisi: %v", err)
+	}
+	for _, o := range owners[:len(owners)-1] {
+		if _, err = Revoke(db, o.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = Revoke(db, owners[len(owners)-1].ID); err != ErrOwnerTerakhir {
+		t.Fatalf("owner terakhir seharusnya tidak bisa dicabut: %v", err)
+	}
+}
@@ -6,9 +6,8 @@ import (
 	"sort"
 	"time"
 
-	"github.com/gocroot/config"
-	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -137,7 +136,7 @@ func KirimLaporanBelumBimbingan(db *mongo.Database) (err error) {
 
 		// Kirim WA ke API
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Tidak berhak: " + err.Error() + ", " + resp.Info)
 			continue
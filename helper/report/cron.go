@@ -9,6 +9,7 @@ import (
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"github.com/whatsauth/itmodel"
@@ -159,7 +160,7 @@ func RekapStravaYesterday(db *mongo.Database) error {
 
 			// Kirim pesan via API
 			var resp model.Response
-			_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+			_, resp, err = waoutbox.Send(db, dt)
 			if err != nil {
 				lastErr = errors.New("Tidak berhak: " + err.Error() + ", " + resp.Info)
 				continue
@@ -208,7 +209,7 @@ func RekapMingguIni(db *mongo.Database) (err error) {
 		}
 		//kirim wa ke api
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Tidak berhak: " + err.Error() + ", " + resp.Info)
 			continue
@@ -256,7 +257,7 @@ func RekapPagiHari(db *mongo.Database) (err error) {
 		}
 		//kirim wa ke api
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Tidak berhak: " + err.Error() + ", " + resp.Info)
 			continue
@@ -295,7 +296,7 @@ func RekapPomokitTotal(db *mongo.Database, groupID string) (string, error) {
 	}
 
 	// Kirim pesan ke API WhatsApp
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(db, dt)
 	if err != nil {
 		return "", fmt.Errorf("gagal mengirim pesan: %v, info: %s", err, resp.Info)
 	}
@@ -323,7 +324,7 @@ func RekapPomokitTotalToPhone(db *mongo.Database, phoneNumber string) (string, e
 	}
 
 	// Kirim pesan ke API WhatsApp
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(db, dt)
 	if err != nil {
 		return "", fmt.Errorf("gagal mengirim pesan: %v, info: %s", err, resp.Info)
 	}
@@ -380,7 +381,7 @@ func RekapPomokitKemarin(db *mongo.Database) (err error) {
 		}
 
 		// Kirim pesan ke API WhatsApp
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("gagal mengirim pesan ke %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -444,7 +445,7 @@ func RekapPomokitSemingguTerakhir(db *mongo.Database) (err error) {
 		}
 
 		// Kirim pesan ke API WhatsApp
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("gagal mengirim pesan ke %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -479,7 +480,7 @@ func KirimLaporanPengunjungWebKeGrup(db *mongo.Database) (err error) {
 
 		// Kirim WA ke API
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Tidak berhak: " + err.Error() + ", " + resp.Info)
 			continue
@@ -536,7 +537,7 @@ func RekapIqScoreHarian(db *mongo.Database) error {
 
 		// **Kirim ke WhatsApp**
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Gagal mengirim ke WhatsApp: " + err.Error() + ", " + resp.Info)
 			continue
@@ -593,7 +594,7 @@ func RekapIqScoreMingguan(db *mongo.Database) error {
 
 		// **Kirim ke WhatsApp**
 		var resp model.Response
-		_, resp, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err = waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = errors.New("Gagal mengirim ke WhatsApp: " + err.Error() + ", " + resp.Info)
 			continue
@@ -650,7 +651,7 @@ func RekapGTMetrixTotalToGroup(db *mongo.Database, groupID string) (string, erro
 	}
 
 	// Kirim pesan ke API WhatsApp
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, resp, err := waoutbox.Send(db, dt)
 	if err != nil {
 		return "", fmt.Errorf("gagal mengirim pesan: %v, info: %s", err, resp.Info)
 	}
@@ -702,7 +703,7 @@ func RekapCrowdfundingMingguanJob(db *mongo.Database) error {
 // 	}
 
 // 	// Kirim pesan ke API WhatsApp
-// 	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+// 	_, resp, err := waoutbox.Send(db, dt)
 // 	if err != nil {
 // 		return fmt.Errorf("gagal mengirim pesan: %v, info: %s", err, resp.Info)
 // 	}
@@ -7,8 +7,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gocroot/config"
-	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/crowdfunding"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,76 +27,68 @@ type CrowdfundingInfo struct {
 	WaGroupID     string              // Will be populated from project collection
 }
 
-// Helper function to format MBC amount for display
-// Convert 0.0002 to "2 koin MBC" with two decimal places
-func formatMBCAmount(amount float64) string {
-	// Convert to coin MBC (multiply by 10000)
-	coinAmount := amount * 10000
-
-	// Format with two decimal places for precise amounts
-	if coinAmount == float64(int(coinAmount)) {
-		// If it's a whole number
-		return fmt.Sprintf("%.0f koin MBC", coinAmount)
-	} else {
-		// If it has decimal places, show two decimal places
-		return fmt.Sprintf("%.2f koin MBC", coinAmount)
-	}
+// crowdfundingTotal menyimpan jumlah dan banyak transaksi satu metode pembayaran
+type crowdfundingTotal struct {
+	Amount float64
+	Count  int
 }
 
-// Helper function to format Ravencoin amount for display
-// Convert 0.5 to "0.5 koin RVN" and 1 to "1 koin RVN"
-func formatRavencoinAmount(amount float64) string {
-	// If it's a whole number
-	if amount == float64(int(amount)) {
-		return fmt.Sprintf("%.0f koin RVN", amount)
-	} else {
-		// If it has decimal places
-		return fmt.Sprintf("%.2f koin RVN", amount)
-	}
+// userCrowdfunding adalah rekap pembayaran satu user per metode pembayaran
+type userCrowdfunding struct {
+	Name         string
+	PhoneNumber  string
+	Methods      map[model.PaymentMethod]crowdfundingTotal
+	TotalPayment int
 }
 
-// Helper function to format QRIS amount for display
-// Convert 1 to "Rp 1" and 1000 to "Rp 1.000"
-func formatQRISAmount(amount float64) string {
-	// Format with no decimal places and thousands separator
-	// Use %d if we only need integer values without decimals
-	if amount == float64(int(amount)) {
-		// For whole number amounts (no decimal part)
-		s := fmt.Sprintf("%d", int(amount))
-		// Add thousands separator (.) manually
-		var result strings.Builder
-		for i, c := range s {
-			if i > 0 && (len(s)-i)%3 == 0 {
-				result.WriteRune('.')
-			}
-			result.WriteRune(c)
-		}
-		return "Rp " + result.String()
-	} else {
-		// For amounts with decimal parts
-		s := fmt.Sprintf("%.2f", amount)
-		// Replace comma with dot for decimal separator in Indonesian format
-		s = strings.Replace(s, ".", ",", 1)
-
-		// Add thousands separator (.) manually to the integer part
-		parts := strings.Split(s, ",")
-		intPart := parts[0]
-
-		var result strings.Builder
-		for i, c := range intPart {
-			if i > 0 && (len(intPart)-i)%3 == 0 {
-				result.WriteRune('.')
-			}
-			result.WriteRune(c)
-		}
+// groupCrowdfundingByUser mengelompokkan pembayaran per user, diurutkan dari jumlah transaksi terbanyak,
+// dan menghitung total per metode pembayaran
+func groupCrowdfundingByUser(payments []CrowdfundingInfo) ([]userCrowdfunding, map[model.PaymentMethod]crowdfundingTotal) {
+	userIndex := make(map[string]int)
+	var users []userCrowdfunding
+	totals := make(map[model.PaymentMethod]crowdfundingTotal)
 
-		if len(parts) > 1 {
-			result.WriteRune(',')
-			result.WriteString(parts[1])
+	for _, payment := range payments {
+		i, exists := userIndex[payment.PhoneNumber]
+		if !exists {
+			i = len(users)
+			userIndex[payment.PhoneNumber] = i
+			users = append(users, userCrowdfunding{
+				Name:        payment.Name,
+				PhoneNumber: payment.PhoneNumber,
+				Methods:     make(map[model.PaymentMethod]crowdfundingTotal),
+			})
 		}
 
-		return "Rp " + result.String()
+		userTotal := users[i].Methods[payment.PaymentMethod]
+		userTotal.Amount += payment.Amount
+		userTotal.Count++
+		users[i].Methods[payment.PaymentMethod] = userTotal
+
+		total := totals[payment.PaymentMethod]
+		total.Amount += payment.Amount
+		total.Count++
+		totals[payment.PaymentMethod] = total
+
+		users[i].TotalPayment++
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].TotalPayment > users[j].TotalPayment
+	})
+
+	return users, totals
+}
+
+// rekapCrowdfundingTotals menuliskan total per metode pembayaran dengan jumlah transaksinya
+func rekapCrowdfundingTotals(totals map[model.PaymentMethod]crowdfundingTotal) string {
+	var msg string
+	for _, p := range crowdfunding.Providers() {
+		info := p.Info()
+		total := totals[info.Method]
+		msg += fmt.Sprintf("Total %s: %s (%d transaksi)\n", info.Symbol, p.FormatReportAmount(total.Amount), total.Count)
 	}
+	return msg
 }
 
 // GetJumlahMBCLastWeek returns the total MicroBitcoin amount contributed by a user in the last week
@@ -327,55 +319,28 @@ func GenerateRekapCrowdfundingDaily(db *mongo.Database, groupID string) (string,
 	msg := "*📊 Rekap Crowdfunding Harian 📊*\n\n"
 	msg += "Berikut ini adalah ringkasan aktifitasi-crownfunding kemarin:\n\n"
 
-	// Separate payments by payment method
-	var qrisPayments, mbcPayments, ravencoinPayments []CrowdfundingInfo
-	var totalQRIS, totalMBC, totalRavencoin float64
-
-	for _, payment := range groupPayments {
-		if payment.PaymentMethod == model.QRIS {
-			qrisPayments = append(qrisPayments, payment)
-			totalQRIS += payment.Amount
-		} else if payment.PaymentMethod == model.MicroBitcoin {
-			mbcPayments = append(mbcPayments, payment)
-			totalMBC += payment.Amount
-		} else if payment.PaymentMethod == model.Ravencoin {
-			ravencoinPayments = append(ravencoinPayments, payment)
-			totalRavencoin += payment.Amount
-		}
-	}
-
-	// Add QRIS payments to the message
-	if len(qrisPayments) > 0 {
-		msg += "*QRIS Payments:*\n"
-		for _, payment := range qrisPayments {
-			msg += fmt.Sprintf("• %s: %s\n", payment.Name, formatQRISAmount(payment.Amount))
-		}
-		msg += fmt.Sprintf("Total QRIS: %s\n\n", formatQRISAmount(totalQRIS))
-	}
-
-	// Add MicroBitcoin payments to the message
-	if len(mbcPayments) > 0 {
-		msg += "*MicroBitcoin Payments:*\n"
-		for _, payment := range mbcPayments {
-			msg += fmt.Sprintf("• %s: %s\n", payment.Name, formatMBCAmount(payment.Amount))
+	// List payments per payment method
+	_, totals := groupCrowdfundingByUser(groupPayments)
+	for _, p := range crowdfunding.Providers() {
+		info := p.Info()
+		if totals[info.Method].Count == 0 {
+			continue
 		}
-		msg += fmt.Sprintf("Total MBC: %s\n\n", formatMBCAmount(totalMBC))
-	}
-
-	// Add Ravencoin payments to the message
-	if len(ravencoinPayments) > 0 {
-		msg += "*Ravencoin Payments:*\n"
-		for _, payment := range ravencoinPayments {
-			msg += fmt.Sprintf("• %s: %s\n", payment.Name, formatRavencoinAmount(payment.Amount))
+		msg += fmt.Sprintf("*%s Payments:*\n", info.Name)
+		for _, payment := range groupPayments {
+			if payment.PaymentMethod == info.Method {
+				msg += fmt.Sprintf("• %s: %s\n", payment.Name, p.FormatReportAmount(payment.Amount))
+			}
 		}
-		msg += fmt.Sprintf("Total Ravencoin: %s\n\n", formatRavencoinAmount(totalRavencoin))
+		msg += fmt.Sprintf("Total %s: %s\n\n", info.Name, p.FormatReportAmount(totals[info.Method].Amount))
 	}
 
 	// Add overall total
 	msg += fmt.Sprintf("*Jumlah Transaksi:* %d\n", len(groupPayments))
-	msg += fmt.Sprintf("*Total QRIS:* %s\n", formatQRISAmount(totalQRIS))
-	msg += fmt.Sprintf("*Total MBC:* %s\n", formatMBCAmount(totalMBC))
-	msg += fmt.Sprintf("*Total Ravencoin:* %s\n", formatRavencoinAmount(totalRavencoin))
+	for _, p := range crowdfunding.Providers() {
+		info := p.Info()
+		msg += fmt.Sprintf("*Total %s:* %s\n", info.Symbol, p.FormatReportAmount(totals[info.Method].Amount))
+	}
 	msg += "\n\n_Jika ada aktifitasi crownfunding yang tidak terinput bisa hubungi 6285312924192_"
 
 	// Use first payment's phone number as representative phone
@@ -468,106 +433,17 @@ func GenerateRekapCrowdfundingWeekly(db *mongo.Database, groupID string) (string
 	msg := "*📊 Rekap Crowdfunding Mingguan 📊*\n\n"
 	msg += "Berikut ini adalah ringkasan aktifitasi-crownfunding selama seminggu terakhir:\n\n"
 
-	// Group payments by user
-	userPayments := make(map[string]struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	})
-
-	var totalQRIS, totalMBC, totalRavencoin float64
-	var totalQRISCount, totalMBCCount, totalRavencoinCount int
-
-	for _, payment := range groupPayments {
-		userInfo, exists := userPayments[payment.PhoneNumber]
-		if !exists {
-			userInfo = struct {
-				Name            string
-				PhoneNumber     string
-				QRISAmount      float64
-				QRISCount       int
-				MBCAmount       float64
-				MBCCount        int
-				RavencoinAmount float64
-				RavencoinCount  int
-				TotalPayment    int
-			}{
-				Name:        payment.Name,
-				PhoneNumber: payment.PhoneNumber,
-			}
-		}
-
-		if payment.PaymentMethod == model.QRIS {
-			userInfo.QRISAmount += payment.Amount
-			userInfo.QRISCount++
-			totalQRIS += payment.Amount
-			totalQRISCount++
-		} else if payment.PaymentMethod == model.MicroBitcoin {
-			userInfo.MBCAmount += payment.Amount
-			userInfo.MBCCount++
-			totalMBC += payment.Amount
-			totalMBCCount++
-		} else if payment.PaymentMethod == model.Ravencoin {
-			userInfo.RavencoinAmount += payment.Amount
-			userInfo.RavencoinCount++
-			totalRavencoin += payment.Amount
-			totalRavencoinCount++
-		}
-
-		userInfo.TotalPayment++
-		userPayments[payment.PhoneNumber] = userInfo
-	}
-
-	// Sort users by total payment
-	type UserPaymentInfo struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	}
-
-	var sortedUsers []UserPaymentInfo
-	for _, info := range userPayments {
-		sortedUsers = append(sortedUsers, UserPaymentInfo{
-			Name:            info.Name,
-			PhoneNumber:     info.PhoneNumber,
-			QRISAmount:      info.QRISAmount,
-			QRISCount:       info.QRISCount,
-			MBCAmount:       info.MBCAmount,
-			MBCCount:        info.MBCCount,
-			RavencoinAmount: info.RavencoinAmount,
-			RavencoinCount:  info.RavencoinCount,
-			TotalPayment:    info.TotalPayment,
-		})
-	}
-
-	// Sort by total payment count
-	sort.Slice(sortedUsers, func(i, j int) bool {
-		return sortedUsers[i].TotalPayment > sortedUsers[j].TotalPayment
-	})
+	// Group payments by user, sorted by total payment count
+	sortedUsers, totals := groupCrowdfundingByUser(groupPayments)
 
 	// Add user payments to the message
 	for _, user := range sortedUsers {
 		msg += fmt.Sprintf("*%s*\n", user.Name)
-		if user.QRISCount > 0 {
-			msg += fmt.Sprintf("- QRIS: %s (%d transaksi)\n", formatQRISAmount(user.QRISAmount), user.QRISCount)
-		}
-		if user.MBCCount > 0 {
-			msg += fmt.Sprintf("- MBC: %s (%d transaksi)\n", formatMBCAmount(user.MBCAmount), user.MBCCount)
-		}
-		if user.RavencoinCount > 0 {
-			msg += fmt.Sprintf("- RVN: %s (%d transaksi)\n", formatRavencoinAmount(user.RavencoinAmount), user.RavencoinCount)
+		for _, p := range crowdfunding.Providers() {
+			info := p.Info()
+			if total := user.Methods[info.Method]; total.Count > 0 {
+				msg += fmt.Sprintf("- %s: %s (%d transaksi)\n", info.Symbol, p.FormatReportAmount(total.Amount), total.Count)
+			}
 		}
 		msg += fmt.Sprintf("- Total: %d transaksi\n\n", user.TotalPayment)
 	}
@@ -576,9 +452,7 @@ func GenerateRekapCrowdfundingWeekly(db *mongo.Database, groupID string) (string
 	msg += "*RINGKASAN MINGGUAN*\n"
 	msg += fmt.Sprintf("Jumlah crownfunding: %d\n", len(sortedUsers))
 	msg += fmt.Sprintf("Total Transaksi: %d\n", len(groupPayments))
-	msg += fmt.Sprintf("Total QRIS: %s (%d transaksi)\n", formatQRISAmount(totalQRIS), totalQRISCount)
-	msg += fmt.Sprintf("Total MBC: %s (%d transaksi)\n", formatMBCAmount(totalMBC), totalMBCCount)
-	msg += fmt.Sprintf("Total RVN: %s (%d transaksi)\n", formatRavencoinAmount(totalRavencoin), totalRavencoinCount)
+	msg += rekapCrowdfundingTotals(totals)
 	msg += "\n\n_Jika ada aktifitasi crownfunding yang tidak terinput bisa hubungi 6285312924192_"
 
 	// Use first payment's phone number as representative phone
@@ -615,107 +489,18 @@ func GenerateRekapCrowdfundingAll(db *mongo.Database, groupID string) (string, s
 	msg := "*📊 Rekap Total Crowdfunding 📊*\n\n"
 	msg += "Berikut ini adalah ringkasan seluruh aktifitasi-crownfunding:\n\n"
 
-	// Group payments by user
-	userPayments := make(map[string]struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	})
-
-	var totalQRIS, totalMBC, totalRavencoin float64
-	var totalQRISCount, totalMBCCount, totalRavencoinCount int
-
-	for _, payment := range groupPayments {
-		userInfo, exists := userPayments[payment.PhoneNumber]
-		if !exists {
-			userInfo = struct {
-				Name            string
-				PhoneNumber     string
-				QRISAmount      float64
-				QRISCount       int
-				MBCAmount       float64
-				MBCCount        int
-				RavencoinAmount float64
-				RavencoinCount  int
-				TotalPayment    int
-			}{
-				Name:        payment.Name,
-				PhoneNumber: payment.PhoneNumber,
-			}
-		}
-
-		if payment.PaymentMethod == model.QRIS {
-			userInfo.QRISAmount += payment.Amount
-			userInfo.QRISCount++
-			totalQRIS += payment.Amount
-			totalQRISCount++
-		} else if payment.PaymentMethod == model.MicroBitcoin {
-			userInfo.MBCAmount += payment.Amount
-			userInfo.MBCCount++
-			totalMBC += payment.Amount
-			totalMBCCount++
-		} else if payment.PaymentMethod == model.Ravencoin {
-			userInfo.RavencoinAmount += payment.Amount
-			userInfo.RavencoinCount++
-			totalRavencoin += payment.Amount
-			totalRavencoinCount++
-		}
-
-		userInfo.TotalPayment++
-		userPayments[payment.PhoneNumber] = userInfo
-	}
-
-	// Sort users by total payment
-	type UserPaymentInfo struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	}
-
-	var sortedUsers []UserPaymentInfo
-	for _, info := range userPayments {
-		sortedUsers = append(sortedUsers, UserPaymentInfo{
-			Name:            info.Name,
-			PhoneNumber:     info.PhoneNumber,
-			QRISAmount:      info.QRISAmount,
-			QRISCount:       info.QRISCount,
-			MBCAmount:       info.MBCAmount,
-			MBCCount:        info.MBCCount,
-			RavencoinAmount: info.RavencoinAmount,
-			RavencoinCount:  info.RavencoinCount,
-			TotalPayment:    info.TotalPayment,
-		})
-	}
-
-	// Sort by total payment amount (QRIS + MBC if we had a conversion rate)
-	sort.Slice(sortedUsers, func(i, j int) bool {
-		return sortedUsers[i].TotalPayment > sortedUsers[j].TotalPayment
-	})
+	// Group payments by user, sorted by total payment count
+	sortedUsers, totals := groupCrowdfundingByUser(groupPayments)
 
 	// List all users with their donation details
 	msg += "*DAFTAR SEMUA crownfunding*\n\n"
 	for i, user := range sortedUsers {
 		msg += fmt.Sprintf("%d. *%s* (%s)\n", i+1, user.Name, user.PhoneNumber)
-		if user.QRISCount > 0 {
-			msg += fmt.Sprintf("   - QRIS: %s (%d transaksi)\n", formatQRISAmount(user.QRISAmount), user.QRISCount)
-		}
-		if user.MBCCount > 0 {
-			msg += fmt.Sprintf("   - MBC: %s (%d transaksi)\n", formatMBCAmount(user.MBCAmount), user.MBCCount)
-		}
-		if user.RavencoinCount > 0 {
-			msg += fmt.Sprintf("   - RVN: %s (%d transaksi)\n", formatRavencoinAmount(user.RavencoinAmount), user.RavencoinCount)
+		for _, p := range crowdfunding.Providers() {
+			info := p.Info()
+			if total := user.Methods[info.Method]; total.Count > 0 {
+				msg += fmt.Sprintf("   - %s: %s (%d transaksi)\n", info.Symbol, p.FormatReportAmount(total.Amount), total.Count)
+			}
 		}
 		msg += fmt.Sprintf("   - Total: %d transaksi\n", user.TotalPayment)
 	}
@@ -723,9 +508,7 @@ func GenerateRekapCrowdfundingAll(db *mongo.Database, groupID string) (string, s
 	msg += "\n*STATISTIK KESELURUHAN*\n"
 	msg += fmt.Sprintf("Jumlah crownfunding: %d\n", len(sortedUsers))
 	msg += fmt.Sprintf("Total Transaksi: %d\n", len(groupPayments))
-	msg += fmt.Sprintf("Total QRIS: %s (%d transaksi)\n", formatQRISAmount(totalQRIS), totalQRISCount)
-	msg += fmt.Sprintf("Total MBC: %s (%d transaksi)\n", formatMBCAmount(totalMBC), totalMBCCount)
-	msg += fmt.Sprintf("Total RVN: %s (%d transaksi)\n", formatRavencoinAmount(totalRavencoin), totalRavencoinCount)
+	msg += rekapCrowdfundingTotals(totals)
 	msg += "\n\n_Jika ada aktifitasi crownfunding yang tidak terinput bisa hubungi 6285312924192_"
 
 	// Use first payment's phone number as representative
@@ -779,7 +562,7 @@ func RekapCrowdfundingHarian(db *mongo.Database) error {
 		}
 
 		// Send the message
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to send daily report to group %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -830,7 +613,7 @@ func RekapCrowdfundingMingguan(db *mongo.Database) error {
 		}
 
 		// Send the message
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to send weekly report to group %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -881,7 +664,7 @@ func RekapCrowdfundingTotal(db *mongo.Database) error {
 		}
 
 		// Send the message
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to send total report to group %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -937,159 +720,38 @@ func GenerateRekapCrowdfundingGlobal(db *mongo.Database) (string, string, error)
 	msg += "Berikut ini adalah rekap seluruh transaksi crowdfunding dari semua pengguna:\n\n"
 
 	// Group payments by user and payment method
-	userPayments := make(map[string]struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	})
-
-	var totalQRIS, totalMBC, totalRavencoin float64
-	var totalQRISCount, totalMBCCount, totalRavencoinCount int
-
-	for _, payment := range payments {
-		userInfo, exists := userPayments[payment.PhoneNumber]
-		if !exists {
-			userInfo = struct {
-				Name            string
-				PhoneNumber     string
-				QRISAmount      float64
-				QRISCount       int
-				MBCAmount       float64
-				MBCCount        int
-				RavencoinAmount float64
-				RavencoinCount  int
-				TotalPayment    int
-			}{
-				Name:        payment.Name,
-				PhoneNumber: payment.PhoneNumber,
+	users, totals := groupCrowdfundingByUser(payments)
+
+	// List the users of every payment method, sorted by amount (highest first)
+	for _, p := range crowdfunding.Providers() {
+		info := p.Info()
+		var methodUsers []userCrowdfunding
+		for _, user := range users {
+			if user.Methods[info.Method].Count > 0 {
+				methodUsers = append(methodUsers, user)
 			}
 		}
-
-		if payment.PaymentMethod == model.QRIS {
-			userInfo.QRISAmount += payment.Amount
-			userInfo.QRISCount++
-			totalQRIS += payment.Amount
-			totalQRISCount++
-		} else if payment.PaymentMethod == model.MicroBitcoin {
-			userInfo.MBCAmount += payment.Amount
-			userInfo.MBCCount++
-			totalMBC += payment.Amount
-			totalMBCCount++
-		} else if payment.PaymentMethod == model.Ravencoin {
-			userInfo.RavencoinAmount += payment.Amount
-			userInfo.RavencoinCount++
-			totalRavencoin += payment.Amount
-			totalRavencoinCount++
-		}
-
-		userInfo.TotalPayment++
-		userPayments[payment.PhoneNumber] = userInfo
-	}
-
-	// Sort users by total payment
-	type UserPaymentInfo struct {
-		Name            string
-		PhoneNumber     string
-		QRISAmount      float64
-		QRISCount       int
-		MBCAmount       float64
-		MBCCount        int
-		RavencoinAmount float64
-		RavencoinCount  int
-		TotalPayment    int
-	}
-
-	var sortedMBCUsers []UserPaymentInfo
-	var sortedQRISUsers []UserPaymentInfo
-	var sortedRavencoinUsers []UserPaymentInfo
-
-	for _, info := range userPayments {
-		userInfo := UserPaymentInfo{
-			Name:            info.Name,
-			PhoneNumber:     info.PhoneNumber,
-			QRISAmount:      info.QRISAmount,
-			QRISCount:       info.QRISCount,
-			MBCAmount:       info.MBCAmount,
-			MBCCount:        info.MBCCount,
-			RavencoinAmount: info.RavencoinAmount,
-			RavencoinCount:  info.RavencoinCount,
-			TotalPayment:    info.TotalPayment,
-		}
-
-		// Add to MBC list if they have MBC transactions
-		if info.MBCCount > 0 {
-			sortedMBCUsers = append(sortedMBCUsers, userInfo)
-		}
-
-		// Add to QRIS list if they have QRIS transactions
-		if info.QRISCount > 0 {
-			sortedQRISUsers = append(sortedQRISUsers, userInfo)
-		}
-
-		// Add to Ravencoin list if they have Ravencoin transactions
-		if info.RavencoinCount > 0 {
-			sortedRavencoinUsers = append(sortedRavencoinUsers, userInfo)
-		}
-	}
-
-	// Sort MBC users by MBC amount (highest first)
-	sort.Slice(sortedMBCUsers, func(i, j int) bool {
-		return sortedMBCUsers[i].MBCAmount > sortedMBCUsers[j].MBCAmount
-	})
-
-	// Sort QRIS users by QRIS amount (highest first)
-	sort.Slice(sortedQRISUsers, func(i, j int) bool {
-		return sortedQRISUsers[i].QRISAmount > sortedQRISUsers[j].QRISAmount
-	})
-
-	// Sort Ravencoin users by Ravencoin amount (highest first)
-	sort.Slice(sortedRavencoinUsers, func(i, j int) bool {
-		return sortedRavencoinUsers[i].RavencoinAmount > sortedRavencoinUsers[j].RavencoinAmount
-	})
-
-	// First list all MBC users
-	if len(sortedMBCUsers) > 0 {
-		msg += "*DAFTAR aktifitasi-crownfunding MBC*\n\n"
-		for i, user := range sortedMBCUsers {
-			msg += fmt.Sprintf("%d. *%s* (%s)\n", i+1, user.Name, user.PhoneNumber)
-			msg += fmt.Sprintf("   - MBC: %s (%d transaksi)\n", formatMBCAmount(user.MBCAmount), user.MBCCount)
-		}
-		msg += "\n"
-	}
-
-	// Then list all QRIS users
-	if len(sortedQRISUsers) > 0 {
-		msg += "*DAFTAR aktifitasi-crownfunding QRIS*\n\n"
-		for i, user := range sortedQRISUsers {
-			msg += fmt.Sprintf("%d. *%s* (%s)\n", i+1, user.Name, user.PhoneNumber)
-			msg += fmt.Sprintf("   - QRIS: %s (%d transaksi)\n", formatQRISAmount(user.QRISAmount), user.QRISCount)
+		if len(methodUsers) == 0 {
+			continue
 		}
-		msg += "\n"
-	}
+		sort.SliceStable(methodUsers, func(i, j int) bool {
+			return methodUsers[i].Methods[info.Method].Amount > methodUsers[j].Methods[info.Method].Amount
+		})
 
-	// Finally list all Ravencoin users
-	if len(sortedRavencoinUsers) > 0 {
-		msg += "*DAFTAR aktifitasi-crownfunding Ravencoin*\n\n"
-		for i, user := range sortedRavencoinUsers {
+		msg += fmt.Sprintf("*DAFTAR aktifitasi-crownfunding %s*\n\n", info.Symbol)
+		for i, user := range methodUsers {
+			total := user.Methods[info.Method]
 			msg += fmt.Sprintf("%d. *%s* (%s)\n", i+1, user.Name, user.PhoneNumber)
-			msg += fmt.Sprintf("   - RVN: %s (%d transaksi)\n", formatRavencoinAmount(user.RavencoinAmount), user.RavencoinCount)
+			msg += fmt.Sprintf("   - %s: %s (%d transaksi)\n", info.Symbol, p.FormatReportAmount(total.Amount), total.Count)
 		}
 		msg += "\n"
 	}
 
 	// Add overall total stats
 	msg += "*STATISTIK KESELURUHAN*\n"
-	msg += fmt.Sprintf("Total Pengguna: %d\n", len(userPayments))
+	msg += fmt.Sprintf("Total Pengguna: %d\n", len(users))
 	msg += fmt.Sprintf("Total Transaksi: %d\n", len(payments))
-	msg += fmt.Sprintf("Total QRIS: %s (%d transaksi)\n", formatQRISAmount(totalQRIS), totalQRISCount)
-	msg += fmt.Sprintf("Total MBC: %s (%d transaksi)\n", formatMBCAmount(totalMBC), totalMBCCount)
-	msg += fmt.Sprintf("Total RVN: %s (%d transaksi)\n", formatRavencoinAmount(totalRavencoin), totalRavencoinCount)
+	msg += rekapCrowdfundingTotals(totals)
 	msg += "\n\n_Jika ada aktifitasi crownfunding yang tidak terinput bisa hubungi 6285312924192_"
 
 	// Use first payment's phone number as representative phone
@@ -1142,7 +804,7 @@ func RekapCrowdfundingGlobal(db *mongo.Database) error {
 		}
 
 		// Send the message
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to send global report to group %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -36,12 +38,17 @@ func gradeToPoints(grade string) float64 {
 }
 
 func GetGTMetrixData(db *mongo.Database, onlyYesterday bool, onlyLastWeek bool) ([]model.GTMetrixInfo, error) {
+    return GetGTMetrixDataContext(context.Background(), db, onlyYesterday, onlyLastWeek)
+}
+
+// GetGTMetrixDataContext sama dengan GetGTMetrixData, query config dan request API dibatalkan saat ctx selesai
+func GetGTMetrixDataContext(ctx context.Context, db *mongo.Database, onlyYesterday bool, onlyLastWeek bool) ([]model.GTMetrixInfo, error) {
     // Ambil konfigurasi
     var conf model.Config
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    confCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
     
-    err := db.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
+    err := db.Collection("config").FindOne(confCtx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
     if err != nil {
         return nil, errors.New("Config Not Found: " + err.Error())
     }
@@ -49,8 +56,12 @@ func GetGTMetrixData(db *mongo.Database, onlyYesterday bool, onlyLastWeek bool)
     fmt.Printf("DEBUG: Mengambil data GTMetrix dari %s\n", conf.PomokitUrl)
 
     // HTTP Client request ke API
-    client := &http.Client{Timeout: 15 * time.Second}
-    resp, err := client.Get(conf.PomokitUrl)
+    client := atapi.NewClient(15 * time.Second)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, conf.PomokitUrl, nil)
+    if err != nil {
+        return nil, errors.New("API Request Failed: " + err.Error())
+    }
+    resp, err := client.Do(req)
     if err != nil {
         return nil, errors.New("API Connection Failed: " + err.Error())
     }
@@ -125,7 +136,7 @@ func GetGTMetrixData(db *mongo.Database, onlyYesterday bool, onlyLastWeek bool)
     if onlyYesterday {
         startOfYesterday, endOfYesterday = getStartAndEndOfYesterday(time.Now())
     } else if onlyLastWeek {
-        weekAgo = time.Now().AddDate(0, 0, -7)
+        weekAgo, _ = periode.Get(db).MingguIni("")
     }
 
     for _, report := range filteredReports {
@@ -345,8 +356,8 @@ func SendGTMetrixReportToGroup(db *mongo.Database, groupID string, reportType st
 		Messages: msg,
 	}
 
-	// Kirim pesan ke API WhatsApp
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", token, dt, apiUrl)
+	// Kirim pesan lewat outbox WhatsApp
+	_, resp, err := waoutbox.Send(db, dt)
 	if err != nil {
 		return "", fmt.Errorf("gagal mengirim pesan: %v, info: %s", err, resp.Info)
 	}
@@ -407,7 +418,7 @@ func RekapGTMetrixHarian(db *mongo.Database, token string, apiUrl string) error
 			Messages: msg,
 		}
 
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", token, dt, apiUrl)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("gagal mengirim pesan ke %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -474,7 +485,7 @@ func RekapGTMetrixMingguan(db *mongo.Database, token string, apiUrl string) erro
 			Messages: msg,
 		}
 
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", token, dt, apiUrl)
+		_, resp, err := waoutbox.Send(db, dt)
 		if err != nil {
 			lastErr = fmt.Errorf("gagal mengirim pesan ke %s: %v, info: %s", groupID, err, resp.Info)
 			continue
@@ -8,7 +8,9 @@ import (
 
 	"fmt"
 
+	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -267,85 +269,26 @@ func GetLastWeekDataIQScores(db *mongo.Database, phonenumber string) (model.Acti
 	return activityscore, nil
 }
 
+// GetCreated_At mengembalikan label minggu periode akademik untuk waktu t, contoh 2025_18
 func GetCreated_At(t time.Time) string {
-	// Load zona waktu Asia/Jakarta (WIB)
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		// Jika gagal load lokasi, fallback ke UTC
-		loc = time.UTC
-	}
-
-	// Ambil waktu saat ini dalam zona WIB
-	now := time.Now().In(loc)
-
-	// Hitung created_at (Senin = 1, Minggu = 7)
-	created_at := int(now.Weekday())
-	if created_at == 0 {
-		created_at = 7 // Minggu
-	}
-
-	// Dapatkan tanggal Senin minggu ini pukul 17:01:00 WIB
-	monday := now.AddDate(0, 0, -created_at+1)
-	startOfWeek := time.Date(monday.Year(), monday.Month(), monday.Day(), 17, 1, 0, 0, loc)
-
-	// Jika sekarang sebelum Senin jam 17:01, kita hitung mundur ke minggu sebelumnya
-	if now.Before(startOfWeek) {
-		startOfWeek = startOfWeek.AddDate(0, 0, -7)
-	}
-
-	// Ambil ISO week dan tahun dari startOfWeek
-	year, week := startOfWeek.ISOWeek()
-
-	// Format: "2025_18"
-	return fmt.Sprintf("%d_%02d", year, week)
+	return periode.Get(config.Mongoconn).Label(t)
 }
 
-func GetLastWeekDataIQScoress(db *mongo.Database, phonenumber, mode string) (model.ActivityScore, error) {
+func GetLastWeekDataIQScoress(ctx context.Context, db *mongo.Database, phonenumber, mode string) (model.ActivityScore, error) {
 	var activityscore model.ActivityScore
 
-	// WIB
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	now := time.Now().In(loc)
-
+	// minggu berjalan mengikuti periode akademik, kelasws dan proyek1 punya cutoff masing-masing
+	p := periode.Get(db)
 	var startTime, endTime time.Time
 
 	switch mode {
-	case "kelasws":
-		// minggu berjalan Jumat 00:00 WIB → Jumat depan 00:00
-		wd := int(now.Weekday())
-		if wd == 0 {
-			wd = 7
-		}
-		// cari Jumat terakhir (weekday==5)
-		daysSinceFri := (wd + 2) % 7 // jika today is Fri (5), daysSinceFri=0
-		lastFri := now.AddDate(0, 0, -daysSinceFri)
-		startTime = time.Date(lastFri.Year(), lastFri.Month(), lastFri.Day(), 0, 0, 0, 0, loc)
-		endTime = startTime.AddDate(0, 0, 7)
-
-	case "proyek1":
-		// minggu Senin 17:01 → Senin depan 17:00
-		wd := int(now.Weekday())
-		if wd == 0 {
-			wd = 7
-		}
-		// jika hari ini Senin sebelum jam 17:01, geser ke minggu kemarin
-		if wd == 1 && (now.Hour() < 17 || (now.Hour() == 17 && now.Minute() < 1)) {
-			now = now.AddDate(0, 0, -1)
-			wd = int(now.Weekday())
-			if wd == 0 {
-				wd = 7
-			}
-		}
-		mon := now.AddDate(0, 0, -wd+1)
-		startTime = time.Date(mon.Year(), mon.Month(), mon.Day(), 17, 1, 0, 0, loc)
-		// Senin depan jam 17:00
-		nxtMon := startTime.AddDate(0, 0, 7)
-		endTime = time.Date(nxtMon.Year(), nxtMon.Month(), nxtMon.Day(), 17, 0, 0, 0, loc)
+	case "kelasws", "proyek1":
+		startTime, endTime = p.MingguIni(mode)
 
 	default:
 		// fallback: 7×24 jam terakhir
-		startTime = now.AddDate(0, 0, -7)
-		endTime = now
+		endTime = time.Now().In(p.Location())
+		startTime = endTime.AddDate(0, 0, -7)
 	}
 
 	// MongoDB menyimpan created_at sebagai string "YYYY-MM-DD HH:MM:SS"
@@ -364,13 +307,13 @@ func GetLastWeekDataIQScoress(db *mongo.Database, phonenumber, mode string) (mod
 		SetLimit(1)
 
 	cursor, err := db.Collection("iqscore").
-		Find(context.TODO(), filter, opts)
+		Find(ctx, filter, opts)
 	if err != nil {
 		return activityscore, fmt.Errorf("query iqscore: %w", err)
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
-	if !cursor.Next(context.TODO()) {
+	if !cursor.Next(ctx) {
 		return activityscore, fmt.Errorf("tidak ada data IQ Score untuk mode %q", mode)
 	}
 
@@ -1,262 +1,214 @@
 package report
 
 import (
+	"context"
 	"math"
 	"strconv"
 	"time"
 
-	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ledger"
+	"github.com/gocroot/helper/periode"
+	"github.com/gocroot/helper/waoutbox"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// menambah poin untuk presensi
-func TambahPoinTasklistbyPhoneNumber(db *mongo.Database, phonenumber string, project model.Project, poin float64, activity string) (res *mongo.UpdateResult, err error) {
-	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
-	if err != nil {
-		return
-	}
-	usr.Poin = usr.Poin + poin
-	res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"phonenumber": phonenumber}, usr)
-	if err != nil {
-		return
-	}
-
-	logpoin := LogPoin{
-		UserID:           usr.ID,
-		Name:             usr.Name,
-		PhoneNumber:      usr.PhoneNumber,
-		Email:            usr.Email,
-		Poin:             poin,
+// menambah poin untuk tasklist, idempotencyKey mencegah poin ganda saat request diulang
+func TambahPoinTasklistbyPhoneNumber(db *mongo.Database, phonenumber string, project model.Project, poin float64, activity, idempotencyKey string) (res *mongo.UpdateResult, err error) {
+	_, res, err = catatPoin(db, bson.M{"phonenumber": phonenumber}, ledger.Posting{Amount: poin, Source: activity, IdempotencyKey: idempotencyKey}, LogPoin{
 		ProjectID:        project.ID,
 		ProjectName:      project.Name,
 		ProjectWAGroupID: project.WAGroupID,
 		Activity:         activity,
-	}
-	//memasukkan detil task ke dalam log
-	taskdoing, err := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
-	if err == nil {
-		taskdoing.Poin = taskdoing.Poin + poin
-		res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"_id": taskdoing.ID}, usr)
-		if err == nil {
-			logpoin.TaskID = taskdoing.ID
-			logpoin.Task = taskdoing.Task
-			logpoin.LaporanID = taskdoing.LaporanID
-		}
-	}
-	_, err = atdb.InsertOneDoc(db, "logpoin", logpoin)
-	if err != nil {
-		return
-	}
-
+	})
 	return
-
 }
 
-// menambah poin untuk presensi
-func TambahPoinPresensibyPhoneNumber(db *mongo.Database, phonenumber string, lokasi string, poin float64, token, api, activity string) (res *mongo.UpdateResult, err error) {
-	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
+// menambah poin untuk presensi, idempotencyKey mencegah poin ganda saat request diulang
+func TambahPoinPresensibyPhoneNumber(db *mongo.Database, phonenumber string, lokasi string, poin float64, token, api, activity, idempotencyKey string) (res *mongo.UpdateResult, err error) {
+	usr, res, err := catatPoin(db, bson.M{"phonenumber": phonenumber}, ledger.Posting{Amount: poin, Source: activity, Keterangan: lokasi, IdempotencyKey: idempotencyKey}, LogPoin{
+		Lokasi:   lokasi,
+		Activity: activity,
+		Location: lokasi,
+	})
 	if err != nil {
 		return
 	}
-	usr.Poin = usr.Poin + poin
-	res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"phonenumber": phonenumber}, usr)
-	if err != nil {
-		return
-	}
-	logpoin := LogPoin{
-		UserID:      usr.ID,
-		Name:        usr.Name,
-		PhoneNumber: usr.PhoneNumber,
-		Email:       usr.Email,
-		Poin:        poin,
-		Lokasi:      lokasi,
-		Activity:    activity,
-		Location:    lokasi,
-	}
-	//memasukkan detil task ke dalam log
 	taskdoing, err := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
-	if err == nil {
-		taskdoing.Poin = taskdoing.Poin + poin
-		res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"_id": taskdoing.ID}, usr)
-		if err == nil {
-			logpoin.TaskID = taskdoing.ID
-			logpoin.Task = taskdoing.Task
-			logpoin.LaporanID = taskdoing.LaporanID
-			logpoin.ProjectID = taskdoing.ProjectID
-			logpoin.ProjectName = taskdoing.ProjectName
-			logpoin.ProjectWAGroupID = taskdoing.ProjectWAGroupID
-		}
-		if taskdoing.ProjectWAGroupID != "" {
-			msg := "*Presensi*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nLokasi: " + lokasi + "\nPoin: " + strconv.Itoa(int(poin))
-			dt := &whatsauth.TextMessage{
-				To:       taskdoing.ProjectWAGroupID,
-				IsGroup:  true,
-				Messages: msg,
-			}
-			_, _, err = atapi.PostStructWithToken[model.Response]("Token", token, dt, api)
-			if err != nil {
-				return
-			}
+	if err == nil && taskdoing.ProjectWAGroupID != "" {
+		msg := "*Presensi*\n" + usr.Name + "(" + strconv.Itoa(int(usr.Poin)) + ") - " + usr.PhoneNumber + "\nLokasi: " + lokasi + "\nPoin: " + strconv.Itoa(int(poin))
+		dt := &whatsauth.TextMessage{
+			To:       taskdoing.ProjectWAGroupID,
+			IsGroup:  true,
+			Messages: msg,
 		}
-	}
-	_, err = atdb.InsertOneDoc(db, "logpoin", logpoin)
-	if err != nil {
+		_, _, err = waoutbox.Send(db, dt)
 		return
 	}
+	return res, nil
+}
 
+// menambah poin untuk laporan, idempotencyKey mencegah poin ganda saat request diulang
+func TambahPoinLaporanbyPhoneNumber(db *mongo.Database, prj model.Project, phonenumber string, poin float64, activity, idempotencyKey string) (res *mongo.UpdateResult, err error) {
+	_, res, err = catatPoin(db, bson.M{"phonenumber": phonenumber}, ledger.Posting{Amount: poin, Source: activity, IdempotencyKey: idempotencyKey}, LogPoin{
+		ProjectID:        prj.ID,
+		ProjectName:      prj.Name,
+		ProjectWAGroupID: prj.WAGroupID,
+		Activity:         activity,
+	})
 	return
+}
 
+// KurangPoinUserbyPhoneNumber mengurangi poin, idempotencyKey mencegah pengurangan ganda saat cron diulang
+func KurangPoinUserbyPhoneNumber(db *mongo.Database, phonenumber string, poin float64, activity, idempotencyKey string) (res *mongo.UpdateResult, err error) {
+	_, res, err = catatPoin(db, bson.M{"phonenumber": phonenumber}, ledger.Posting{Amount: -poin, Source: activity, IdempotencyKey: idempotencyKey}, LogPoin{
+		Activity: activity,
+	})
+	return
 }
 
-// menambah poin untuk laporan
-func TambahPoinLaporanbyPhoneNumber(db *mongo.Database, prj model.Project, phonenumber string, poin float64, activity string) (res *mongo.UpdateResult, err error) {
-	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
-	if err != nil {
-		return
-	}
-	usr.Poin = usr.Poin + poin
-	res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"phonenumber": phonenumber}, usr)
-	if err != nil {
-		return
-	}
-	logpoin := LogPoin{
-		UserID:      usr.ID,
-		Name:        usr.Name,
-		PhoneNumber: usr.PhoneNumber,
-		Email:       usr.Email,
-		ProjectID:   prj.ID,
-		ProjectName: prj.Name,
-		Poin:        poin,
-		Activity:    activity,
+func TambahPoinPushRepobyGithubUsername(db *mongo.Database, prj model.Project, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
+	return tambahPoinPushRepo(db, prj, bson.M{"githubusername": report.Username}, report, poin)
+}
+
+func TambahPoinPushRepobyGithubEmail(db *mongo.Database, prj model.Project, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
+	return tambahPoinPushRepo(db, prj, bson.M{"email": report.Email}, report, poin)
+}
+
+// Poin aktivitas code review di GitHub, push tetap 1 poin per commit
+const (
+	PoinPullRequestOpened = 2
+	PoinPullRequestMerged = 3
+	PoinReviewSubmitted   = 2 // approved atau changes_requested
+	PoinReviewCommented   = 1
+	PoinIssueOpened       = 1
+	PoinIssueClosed       = 1
+)
+
+// PoinPullRequest mengembalikan poin untuk action opened atau merged, action lain 0
+func PoinPullRequest(action string) float64 {
+	switch action {
+	case "opened":
+		return PoinPullRequestOpened
+	case "merged":
+		return PoinPullRequestMerged
 	}
-	//memasukkan detil task ke dalam log
-	taskdoing, err := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
-	if err == nil {
-		taskdoing.Poin = taskdoing.Poin + poin
-		res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"_id": taskdoing.ID}, usr)
-		if err == nil {
-			logpoin.TaskID = taskdoing.ID
-			logpoin.Task = taskdoing.Task
-			logpoin.LaporanID = taskdoing.LaporanID
-			logpoin.ProjectID = prj.ID
-			logpoin.ProjectName = prj.Name
-			logpoin.ProjectWAGroupID = prj.WAGroupID
-		}
+	return 0
+}
+
+// PoinPullRequestReview mengembalikan poin sesuai state review yang dikirim
+func PoinPullRequestReview(state string) float64 {
+	switch state {
+	case "approved", "changes_requested":
+		return PoinReviewSubmitted
+	case "commented":
+		return PoinReviewCommented
 	}
-	_, err = atdb.InsertOneDoc(db, "logpoin", logpoin)
-	if err != nil {
-		return
+	return 0
+}
+
+// PoinIssue mengembalikan poin untuk action opened atau closed, action lain 0
+func PoinIssue(action string) float64 {
+	switch action {
+	case "opened":
+		return PoinIssueOpened
+	case "closed":
+		return PoinIssueClosed
 	}
+	return 0
+}
 
-	return
+func TambahPoinPullRequestbyGithubUsername(db *mongo.Database, prj model.Project, report model.PullRequestReport) (usr model.Userdomyikado, err error) {
+	return tambahPoinGitActivity(db, prj, bson.M{"githubusername": report.Username}, ledger.Posting{
+		Amount:         report.Poin,
+		Source:         "pullrequest",
+		ReferenceID:    report.EventKey,
+		IdempotencyKey: "pullrequest:" + report.EventKey,
+	}, "Pull Request", report.URL, report.Action, report.Title)
+}
 
+func TambahPoinPullRequestReviewbyGithubUsername(db *mongo.Database, prj model.Project, report model.PullRequestReviewReport) (usr model.Userdomyikado, err error) {
+	return tambahPoinGitActivity(db, prj, bson.M{"githubusername": report.Username}, ledger.Posting{
+		Amount:         report.Poin,
+		Source:         "pullrequestreview",
+		ReferenceID:    report.EventKey,
+		IdempotencyKey: "pullrequestreview:" + report.EventKey,
+	}, "Review Pull Request", report.URL, report.State, report.PRTitle)
 }
 
-func KurangPoinUserbyPhoneNumber(db *mongo.Database, phonenumber string, poin float64) (res *mongo.UpdateResult, err error) {
-	usr, err := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phonenumber})
-	if err != nil {
-		return
-	}
-	usr.Poin = usr.Poin - poin
-	res, err = atdb.ReplaceOneDoc(db, "user", bson.M{"phonenumber": phonenumber}, usr)
-	if err != nil {
-		return
-	}
-	return
+func TambahPoinIssuebyGithubUsername(db *mongo.Database, prj model.Project, report model.IssueReport) (usr model.Userdomyikado, err error) {
+	return tambahPoinGitActivity(db, prj, bson.M{"githubusername": report.Username}, ledger.Posting{
+		Amount:         report.Poin,
+		Source:         "issue",
+		ReferenceID:    report.EventKey,
+		IdempotencyKey: "issue:" + report.EventKey,
+	}, "Issue", report.URL, report.Action, report.Title)
+}
 
+// TambahPoinPushRepobyGitHostUsername dipakai webhook Gitea dan Bitbucket, user dicari dari githostusername
+func TambahPoinPushRepobyGitHostUsername(db *mongo.Database, prj model.Project, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
+	return tambahPoinPushRepo(db, prj, bson.M{"githostusername": report.Username}, report, poin)
 }
 
-func TambahPoinPushRepobyGithubUsername(db *mongo.Database, prj model.Project, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
-	usr, err = atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"githubusername": report.Username})
-	if err != nil {
-		return
-	}
-	usr.Poin = usr.Poin + poin
-	_, err = atdb.ReplaceOneDoc(db, "user", bson.M{"githubusername": report.Username}, usr)
-	if err != nil {
-		return
-	}
-	logpoin := LogPoin{
-		UserID:      usr.ID,
-		Name:        usr.Name,
-		PhoneNumber: usr.PhoneNumber,
-		Email:       usr.Email,
-		ProjectID:   prj.ID,
-		ProjectName: prj.Name,
-		Poin:        poin,
-		Activity:    "Push Repo",
-		URL:         report.Repo,
-		Info:        report.Ref,
-		Detail:      report.Message,
+// tambahPoinPushRepo memakai ID proyek dan commit sha sebagai idempotency key, commit lama tanpa sha selalu dicatat baru
+func tambahPoinPushRepo(db *mongo.Database, prj model.Project, filter bson.M, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
+	posting := ledger.Posting{Amount: poin, Source: "pushrepo", ReferenceID: report.CommitSHA}
+	if report.CommitSHA != "" {
+		posting.IdempotencyKey = "pushrepo:" + prj.ID.Hex() + ":" + report.CommitSHA
 	}
-	//memasukkan detil task ke dalam log
-	taskdoing, err := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
-	if err == nil {
-		taskdoing.Poin = taskdoing.Poin + poin
-		_, err = atdb.ReplaceOneDoc(db, "user", bson.M{"_id": taskdoing.ID}, usr)
-		if err == nil {
-			logpoin.TaskID = taskdoing.ID
-			logpoin.Task = taskdoing.Task
-			logpoin.LaporanID = taskdoing.LaporanID
-			logpoin.ProjectID = prj.ID
-			logpoin.ProjectName = prj.Name
-			logpoin.ProjectWAGroupID = prj.WAGroupID
-		}
-	}
-	_, err = atdb.InsertOneDoc(db, "logpoin", logpoin)
-	if err != nil {
-		return
-	}
-	return
+	return tambahPoinGitActivity(db, prj, filter, posting, "Push Repo", report.Repo, report.Ref, report.Message)
+}
 
+// tambahPoinGitActivity menambah poin user yang cocok dengan filter lalu mencatatnya di logpoin
+func tambahPoinGitActivity(db *mongo.Database, prj model.Project, filter bson.M, posting ledger.Posting, activity, url, info, detail string) (usr model.Userdomyikado, err error) {
+	usr, _, err = catatPoin(db, filter, posting, LogPoin{
+		ProjectID:        prj.ID,
+		ProjectName:      prj.Name,
+		ProjectWAGroupID: prj.WAGroupID,
+		Activity:         activity,
+		URL:              url,
+		Info:             info,
+		Detail:           detail,
+	})
+	return
 }
 
-func TambahPoinPushRepobyGithubEmail(db *mongo.Database, prj model.Project, report model.PushReport, poin float64) (usr model.Userdomyikado, err error) {
-	usr, err = atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"email": report.Email})
-	if err != nil {
-		return
+// catatPoin mengubah saldo poin user lewat ledger, menambah poin taskdoing terakhir lalu menulis logpoin.
+// Transaksi yang sudah pernah tercatat dianggap berhasil tanpa menulis log lagi.
+func catatPoin(db *mongo.Database, filter bson.M, posting ledger.Posting, logpoin LogPoin) (usr model.Userdomyikado, res *mongo.UpdateResult, err error) {
+	posting.Account = model.AkunPoin
+	usr, res, err = ledger.Post(db, filter, posting)
+	if err == ledger.ErrDuplicate {
+		return usr, &mongo.UpdateResult{MatchedCount: 1}, nil
 	}
-	usr.Poin = usr.Poin + poin
-	_, err = atdb.ReplaceOneDoc(db, "user", bson.M{"email": report.Email}, usr)
 	if err != nil {
 		return
 	}
-	logpoin := LogPoin{
-		UserID:      usr.ID,
-		Name:        usr.Name,
-		PhoneNumber: usr.PhoneNumber,
-		Email:       usr.Email,
-		ProjectID:   prj.ID,
-		ProjectName: prj.Name,
-		Poin:        poin,
-		Activity:    "Push Repo",
-		URL:         report.Repo,
-		Info:        report.Ref,
-		Detail:      report.Message,
-	}
+	logpoin.UserID = usr.ID
+	logpoin.Name = usr.Name
+	logpoin.PhoneNumber = usr.PhoneNumber
+	logpoin.Email = usr.Email
+	logpoin.Poin = posting.Amount
 	//memasukkan detil task ke dalam log
-	taskdoing, err := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
-	if err == nil {
-		taskdoing.Poin = taskdoing.Poin + poin
-		_, err = atdb.ReplaceOneDoc(db, "user", bson.M{"_id": taskdoing.ID}, usr)
-		if err == nil {
+	taskdoing, errTask := atdb.GetOneLatestDoc[TaskList](db, "taskdoing", bson.M{"phonenumber": usr.PhoneNumber})
+	if errTask == nil {
+		_, errTask = db.Collection("taskdoing").UpdateOne(context.Background(), bson.M{"_id": taskdoing.ID}, bson.M{"$inc": bson.M{"poin": posting.Amount}})
+		if errTask == nil {
 			logpoin.TaskID = taskdoing.ID
 			logpoin.Task = taskdoing.Task
 			logpoin.LaporanID = taskdoing.LaporanID
-			logpoin.ProjectID = prj.ID
-			logpoin.ProjectName = prj.Name
-			logpoin.ProjectWAGroupID = prj.WAGroupID
+			if logpoin.ProjectName == "" {
+				logpoin.ProjectID = taskdoing.ProjectID
+				logpoin.ProjectName = taskdoing.ProjectName
+				logpoin.ProjectWAGroupID = taskdoing.ProjectWAGroupID
+			}
 		}
 	}
 	_, err = atdb.InsertOneDoc(db, "logpoin", logpoin)
-	if err != nil {
-		return
-	}
 	return
 }
 
@@ -292,13 +244,13 @@ func TambahPoinPushRepobyGithubEmail(db *mongo.Database, prj model.Project, repo
 // 	return activityscore, nil
 // }
 
-func GetAllWebhookPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	doc, err := atdb.GetAllDoc[[]model.PushReport](db, "pushrepo", bson.M{"_id": filterFrom11Maret(), "user.phonenumber": phonenumber})
+func GetAllWebhookPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	doc, err := atdb.GetAllDocContext[[]model.PushReport](ctx, db, "pushrepo", bson.M{"_id": filterSemester(db), "user.phonenumber": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
 
-	minggu := jumlahMinggu()
+	minggu := JumlahMinggu()
 	totalPush := len(doc)
 	totalPoin := (float64(totalPush) / float64(minggu)) * 3
 	poin := int(math.Min(totalPoin, 100))
@@ -309,8 +261,8 @@ func GetAllWebhookPoin(db *mongo.Database, phonenumber string) (activityscore mo
 	return activityscore, nil
 }
 
-func GetAllPresensiPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	doc, err := atdb.GetAllDoc[[]PresensiDomyikado](db, "presensi", bson.M{"_id": filterFrom11Maret(), "phonenumber": phonenumber})
+func GetAllPresensiPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	doc, err := atdb.GetAllDocContext[[]PresensiDomyikado](ctx, db, "presensi", bson.M{"_id": filterSemester(db), "phonenumber": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
@@ -323,7 +275,7 @@ func GetAllPresensiPoin(db *mongo.Database, phonenumber string) (activityscore m
 		totalPoin += presensi.Skor
 	}
 
-	minggu := jumlahMinggu()
+	minggu := JumlahMinggu()
 	calTotalPoin := totalPoin / float64(minggu) * 20
 	poin := int(math.Min(calTotalPoin, 100))
 
@@ -333,8 +285,8 @@ func GetAllPresensiPoin(db *mongo.Database, phonenumber string) (activityscore m
 	return activityscore, nil
 }
 
-func GetLastWeekPresensiPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	doc, err := atdb.GetAllDoc[[]PresensiDomyikado](db, "presensi", bson.M{"_id": WeeklyFilter(), "phonenumber": phonenumber})
+func GetLastWeekPresensiPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	doc, err := atdb.GetAllDocContext[[]PresensiDomyikado](ctx, db, "presensi", bson.M{"_id": WeeklyFilter(), "phonenumber": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
@@ -355,8 +307,8 @@ func GetLastWeekPresensiPoin(db *mongo.Database, phonenumber string) (activitysc
 	return activityscore, nil
 }
 
-func GetLastWeekWebhookPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	doc, err := atdb.GetAllDoc[[]model.PushReport](db, "pushrepo", bson.M{"_id": WeeklyFilter(), "user.phonenumber": phonenumber})
+func GetLastWeekWebhookPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	doc, err := atdb.GetAllDocContext[[]model.PushReport](ctx, db, "pushrepo", bson.M{"_id": WeeklyFilter(), "user.phonenumber": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
@@ -371,20 +323,12 @@ func GetLastWeekWebhookPoin(db *mongo.Database, phonenumber string) (activitysco
 	return activityscore, nil
 }
 
-func filterFrom11Maret() bson.M {
-	tanggalAwal := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
-
-	return bson.M{
-		"$gte": primitive.NewObjectIDFromTimestamp(tanggalAwal),
-		"$lt":  primitive.NewObjectIDFromTimestamp(time.Now()),
-	}
+// filterSemester membatasi _id sejak awal semester pada periode akademik aktif
+func filterSemester(db *mongo.Database) bson.M {
+	return periode.FilterObjectID(periode.Get(db).MulaiSemester(), time.Now())
 }
 
-func jumlahMinggu() int {
-	tanggalAwal := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
-	sekarang := time.Now().UTC()
-	selisihHari := sekarang.Sub(tanggalAwal).Hours() / 24
-	jumlahMinggu := int(selisihHari/7) + 1
-
-	return jumlahMinggu
+// JumlahMinggu menghitung minggu berjalan sejak awal semester, minggu pertama bernilai 1
+func JumlahMinggu() int {
+	return periode.Get(config.Mongoconn).MingguKe(time.Now())
 }
@@ -9,22 +9,30 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 
+	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/pomokitcheck"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func GetAllPomokitDataAPI(db *mongo.Database) ([]model.PomodoroReport, error) {
+    return GetAllPomokitDataAPIContext(context.Background(), db)
+}
+
+// GetAllPomokitDataAPIContext sama dengan GetAllPomokitDataAPI, query config dan request API dibatalkan saat ctx selesai
+func GetAllPomokitDataAPIContext(ctx context.Context, db *mongo.Database) ([]model.PomodoroReport, error) {
     // Ambil konfigurasi
     var conf model.Config
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    confCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
     
-    err := db.Collection("config").FindOne(ctx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
+    err := db.Collection("config").FindOne(confCtx, bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
     if err != nil {
         return nil, errors.New("Config Not Found: " + err.Error())
     }
@@ -32,8 +40,12 @@ func GetAllPomokitDataAPI(db *mongo.Database) ([]model.PomodoroReport, error) {
     fmt.Printf("DEBUG: Mengambil semua data Pomokit dari %s\n", conf.PomokitUrl)
 
     // HTTP Client request ke API Pomokit
-    client := &http.Client{Timeout: 15 * time.Second}
-    resp, err := client.Get(conf.PomokitUrl)
+    client := atapi.NewClient(15 * time.Second)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, conf.PomokitUrl, nil)
+    if err != nil {
+        return nil, errors.New("API Request Failed: " + err.Error())
+    }
+    resp, err := client.Do(req)
     if err != nil {
         return nil, errors.New("API Connection Failed: " + err.Error())
     }
@@ -379,6 +391,13 @@ func GeneratePomokitReportSemingguTerakhir(db *mongo.Database, groupID string, p
 	if len(allPomokitData) == 0 {
 		return "Tidak ada data Pomokit yang tersedia", nil
 	}
+
+	// Sesi yang ditahan analisis anti-curang tidak dihitung poinnya dan dicantumkan sebagai temuan,
+	// analisisnya dijalankan job /refresh/pomokit/flag sebelum laporan dikirim
+	allPomokitData, tertahan, err := pomokitcheck.Saring(context.Background(), db, allPomokitData)
+	if err != nil {
+		return "", fmt.Errorf("gagal membaca temuan sesi Pomokit: %v", err)
+	}
 	
 	// Timezone Jakarta untuk konsistensi
 	location, _ := time.LoadLocation("Asia/Jakarta")
@@ -431,8 +450,20 @@ func GeneratePomokitReportSemingguTerakhir(db *mongo.Database, groupID string, p
 		}
 	}
 	
+	// Temuan sesi yang ditahan atau ditolak pada grup/nomor dan minggu yang sama
+	temuan := make(map[string][]model.PomokitFlag)
+	for _, flag := range tertahan {
+		if phoneNumber != "" && flag.PhoneNumber != phoneNumber || phoneNumber == "" && flag.WaGroupID != groupID {
+			continue
+		}
+		sessionTime := flag.SessionAt.In(location)
+		if sessionTime.After(weekAgoJkt) && sessionTime.Before(nowJkt) {
+			temuan[flag.PhoneNumber] = append(temuan[flag.PhoneNumber], flag)
+		}
+	}
+
 	// Cek apakah ada data yang difilter
-	if len(userActivityCounts) == 0 {
+	if len(userActivityCounts) == 0 && len(temuan) == 0 {
 		return fmt.Sprintf("Tidak ada aktivitas Pomokit seminggu terakhir untuk GroupID %s", groupID), nil
 	}
 	
@@ -504,6 +535,30 @@ func GeneratePomokitReportSemingguTerakhir(db *mongo.Database, groupID string, p
 			displayName, up.PhoneNumber, up.ActivityCount, up.Points)
 	}
 	
+	// Tambahkan temuan analisis anti-curang
+	if len(temuan) > 0 {
+		msg += "\n⚠️ *Sesi tidak dihitung, menunggu review dosen atau ditolak:*\n"
+		phones := make([]string, 0, len(temuan))
+		for phone := range temuan {
+			phones = append(phones, phone)
+		}
+		sort.Strings(phones)
+		for _, phone := range phones {
+			flags := temuan[phone]
+			var alasan []string
+			ada := make(map[string]bool)
+			for _, flag := range flags {
+				for _, a := range flag.Alasan {
+					if !ada[a] {
+						ada[a] = true
+						alasan = append(alasan, a)
+					}
+				}
+			}
+			msg += fmt.Sprintf("⏸️ %s (%s): %d sesi (%s)\n", flags[0].Name, phone, len(flags), strings.Join(alasan, ", "))
+		}
+	}
+
 	// Tambahkan motivasi berdasarkan total sesi
 	msg += "\n"
 	if totalAktivitasSeminggu > 30 {
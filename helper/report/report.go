@@ -11,8 +11,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/libur"
+	"github.com/gocroot/helper/periode"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -52,7 +54,8 @@ func GenerateRekapMessageKemarinPerWAGroupID(db *mongo.Database, groupId string)
 		}
 	}
 
-	if !HariLibur(GetDateKemarin()) { //kalo bukan kemaren hari libur maka akan ada pengurangan poin
+	kampus := kampusWAGroup(db, groupId)
+	if !HariLiburKampus(GetDateKemarin(), kampus) { //kalo bukan kemaren hari libur maka akan ada pengurangan poin
 		filter := bson.M{"wagroupid": groupId}
 		var projectDocuments []model.Project
 		projectDocuments, err = atdb.GetAllDoc[[]model.Project](db, "project", filter)
@@ -80,7 +83,7 @@ func GenerateRekapMessageKemarinPerWAGroupID(db *mongo.Database, groupId string)
 				if _, exists := phoneMap[phoneNumber]; !exists {
 					if !processedUsers[member.PhoneNumber] {
 						msg += "⛔ " + member.Name + " (" + member.PhoneNumber + ") : -3\n"
-						KurangPoinUserbyPhoneNumber(db, member.PhoneNumber, 3)
+						KurangPoinUserbyPhoneNumber(db, member.PhoneNumber, 3, "kurang poin harian", "kurangpoinharian:"+groupId+":"+member.PhoneNumber+":"+GetDateKemarin().Format("2006-01-02"))
 						processedUsers[member.PhoneNumber] = true
 					}
 				}
@@ -88,7 +91,7 @@ func GenerateRekapMessageKemarinPerWAGroupID(db *mongo.Database, groupId string)
 		}
 		msg += "\n\n*Klo pada hari kerja kurang dari 3 poin, maka dikurangi 3 poin ya ka. Cemunguddhh..*"
 	} else {
-		if HariLibur(GetDateSekarang()) {
+		if HariLiburKampus(GetDateSekarang(), kampus) {
 			msg += "\n\n*Have a nice day :)*"
 		} else {
 			msg += "\n\n*Yuk bisa yuk... Semangat untuk hari ini...*"
@@ -114,12 +117,12 @@ func SelisihHariTracker() int {
 	return int(selisihHari)
 }
 
-func GetAllDataTracker(db *mongo.Database, hostnames []string) (activityscore model.ActivityScore, err error) {
+func GetAllDataTracker(ctx context.Context, db *mongo.Database, hostnames []string) (activityscore model.ActivityScore, err error) {
 	filter := bson.M{
 		"hostname": bson.M{"$in": hostnames},
 	}
 
-	laps, err := atdb.GetAllDoc[[]model.UserInfo](db, "trackerip", filter)
+	laps, err := atdb.GetAllDocContext[[]model.UserInfo](ctx, db, "trackerip", filter)
 	if err != nil {
 		return activityscore, err
 	}
@@ -132,15 +135,14 @@ func GetAllDataTracker(db *mongo.Database, hostnames []string) (activityscore mo
 	return activityscore, err
 }
 
-func GetLastWeekDataTracker(db *mongo.Database, hostnames []string) (activityscore model.ActivityScore, err error) {
-	now := time.Now().UTC()
-	weekAgo := now.Add(-7 * 24 * time.Hour)
+func GetLastWeekDataTracker(ctx context.Context, db *mongo.Database, hostnames []string) (activityscore model.ActivityScore, err error) {
+	mulai, selesai := periode.Get(db).MingguIni("")
 	filter := bson.M{
 		"hostname":      bson.M{"$in": hostnames},
-		"tanggal_ambil": bson.M{"$gte": weekAgo, "$lt": now},
+		"tanggal_ambil": bson.M{"$gte": mulai.UTC(), "$lt": selesai.UTC()},
 	}
 
-	laps, err := atdb.GetAllDoc[[]model.UserInfo](db, "trackerip", filter)
+	laps, err := atdb.GetAllDocContext[[]model.UserInfo](ctx, db, "trackerip", filter)
 	if err != nil {
 		return activityscore, err
 	}
@@ -483,22 +485,28 @@ func GetDateKemarin() (datekemarin time.Time) {
 	return
 }
 
-func HariLibur(thedate time.Time) (libur bool) {
+// HariLibur mengecek Sabtu, Minggu dan libur nasional di collection liburnasional (lewat cache, tanpa API luar)
+func HariLibur(thedate time.Time) bool {
+	return HariLiburKampus(thedate, "")
+}
+
+// HariLiburKampus sama dengan HariLibur ditambah libur khusus kampus seperti minggu ujian
+func HariLiburKampus(thedate time.Time, kampus string) bool {
 	wekkday := thedate.Weekday()
 	inhari := int(wekkday)
 	if inhari == 0 || inhari == 6 {
-		libur = true
-	}
-	tglskr := thedate.Format("2006-01-02")
-	tgl := int(thedate.Month())
-	urltarget := "https://dayoffapi.vercel.app/api?month=" + strconv.Itoa(tgl)
-	_, hasil, _ := atapi.Get[[]NewLiburNasional](urltarget)
-	for _, v := range hasil {
-		if v.Tanggal == tglskr {
-			libur = true
-		}
+		return true
 	}
-	return
+	return libur.IsLibur(config.Mongoconn, thedate, kampus)
+}
+
+// kampusWAGroup mengambil kode kampus dari proyek yang memakai grup WA tersebut, kosong jika belum diisi
+func kampusWAGroup(db *mongo.Database, groupId string) string {
+	prj, err := atdb.GetOneDoc[model.Project](db, "project", bson.M{"wagroupid": groupId, "kampus": bson.M{"$nin": bson.A{"", nil}}})
+	if err != nil {
+		return ""
+	}
+	return prj.Kampus
 }
 
 func Last3DaysFilter() bson.M {
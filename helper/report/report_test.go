@@ -7,6 +7,8 @@ import (
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/mongotest"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var mongoinfo = atdb.DBInfo{
@@ -16,6 +18,17 @@ var mongoinfo = atdb.DBInfo{
 
 var Mongoconn, ErrorMongoconn = atdb.MongoConnect(mongoinfo)
 
+// TestMain memakai MongoDB di memori jika MONGODOMYID tidak diisi supaya test bisa jalan tanpa database live
+func TestMain(m *testing.M) {
+	if mongoinfo.DBString != "" {
+		os.Exit(m.Run())
+	}
+	mongotest.Main(m, mongoinfo.DBName, func(db *mongo.Database) {
+		Mongoconn, ErrorMongoconn = db, nil
+		config.Mongoconn = db
+	})
+}
+
 // func TestGenerateReport(t *testing.T) {
 // 	config.WAAPIToken = "v4.public."
 // 	fmt.Println(mongoinfo.DBString)
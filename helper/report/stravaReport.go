@@ -12,8 +12,10 @@ import (
 
 	"slices"
 
+	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -310,8 +312,8 @@ func getStravaActivitiesPerDay(db *mongo.Database) ([]model.StravaActivity, erro
 // 	return filteredActivities, nil
 // }
 
-func GetAllDataStravaPoin(db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
-	docs, err := atdb.GetAllDoc[[]model.StravaPoin](db, "stravapoin1", bson.M{"phone_number": phonenumber})
+func GetAllDataStravaPoin(ctx context.Context, db *mongo.Database, phonenumber string) (activityscore model.ActivityScore, err error) {
+	docs, err := atdb.GetAllDocContext[[]model.StravaPoin](ctx, db, "stravapoin1", bson.M{"phone_number": phonenumber})
 	if err != nil {
 		return activityscore, err
 	}
@@ -353,52 +355,12 @@ func GetAllDataStravaPoin(db *mongo.Database, phonenumber string) (activityscore
 	return activityscore, nil
 }
 
-func GetLastWeekDataStravaPoin(db *mongo.Database, phonenumber string, mode string) (activityscore model.ActivityScore, err error) {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	now := time.Now().In(loc)
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-
+func GetLastWeekDataStravaPoin(ctx context.Context, db *mongo.Database, phonenumber string, mode string) (activityscore model.ActivityScore, err error) {
 	var startTime, endTime time.Time
 
 	switch mode {
-	case "kelasai":
-		if weekday == 0 {
-			weekday = 7 // Minggu jadi 7
-		}
-
-		// Mundur ke Jumat terakhir
-		// daysSinceFriday := (weekday + 2) % 7
-		// lastFriday := now.AddDate(0, 0, -daysSinceFriday)
-		daysSinceSaturday := (weekday + 1) % 7
-		lastSaturday := now.AddDate(0, 0, -daysSinceSaturday)
-
-		// Mulai dari Sabtu pukul 00:01 WIB
-		startTime = time.Date(lastSaturday.Year(), lastSaturday.Month(), lastSaturday.Day(), 0, 1, 0, 0, loc)
-
-		// Selesai Sabtu pukul 00:00 WIB
-		nextFriday := lastSaturday.AddDate(0, 0, 7)
-		endTime = time.Date(nextFriday.Year(), nextFriday.Month(), nextFriday.Day(), 0, 0, 0, 0, loc)
-
-	case "proyek1": // senin
-		weekday := int(now.Weekday())
-		if weekday == 0 {
-			weekday = 7
-		}
-		// Cek apakah sekarang masih Senin sebelum 17:01 WIB
-		if weekday == 1 && now.Hour() < 17 || (now.Hour() == 17 && now.Minute() < 1) {
-			now = now.AddDate(0, 0, -1)
-			weekday = int(now.Weekday())
-			if weekday == 0 {
-				weekday = 7
-			}
-		}
-		monday := now.AddDate(0, 0, -weekday+1)
-		startTime = time.Date(monday.Year(), monday.Month(), monday.Day(), 17, 1, 0, 0, loc)
-		endTime = startTime.AddDate(0, 0, 7)
-		endTime = time.Date(endTime.Year(), endTime.Month(), endTime.Day(), 17, 0, 0, 0, loc)
+	case "kelasai", "proyek1":
+		startTime, endTime = periode.Get(db).MingguIni(mode)
 	}
 
 	// Query ke MongoDB
@@ -410,7 +372,7 @@ func GetLastWeekDataStravaPoin(db *mongo.Database, phonenumber string, mode stri
 		},
 	}
 
-	docs, err := atdb.GetAllDoc[[]model.StravaPoin](db, "stravapoin1", filter)
+	docs, err := atdb.GetAllDocContext[[]model.StravaPoin](ctx, db, "stravapoin1", filter)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return activityscore, nil
@@ -485,26 +447,9 @@ func GetLastWeekDataStravaPoinKelas(db *mongo.Database, phonenumber string, used
 // 	return fmt.Sprintf("%d_%d", year, week)
 // }
 
-// GetWeekYear menghitung tahun dan minggu dimulai dari senin 17.01 sampai minggu depan senin 17.00
+// GetWeekYear menghitung tahun dan minggu sesuai cutoff periode akademik, defaultnya Senin 17.01 sampai Senin depan 17.00
 func GetWeekYear(t time.Time) string {
-	loc := t.Location()
-
-	weekday := int(t.Weekday())
-	if weekday == 0 {
-		weekday = 7 // Ubah Minggu jadi 7
-	}
-
-	// Dapatkan Senin minggu ini, lalu set ke jam 17:01
-	monday := t.AddDate(0, 0, -weekday+1)
-	startOfWeek := time.Date(monday.Year(), monday.Month(), monday.Day(), 17, 1, 0, 0, loc)
-
-	// Jika sekarang belum lewat Senin jam 17:01, berarti masih minggu sebelumnya
-	if t.Before(startOfWeek) {
-		startOfWeek = startOfWeek.AddDate(0, 0, -7)
-	}
-
-	year, week := startOfWeek.ISOWeek()
-	return fmt.Sprintf("%d_%02d", year, week)
+	return periode.Get(config.Mongoconn).Label(t)
 }
 
 func duplicatePhoneNumbersCount(users []StravaInfo) map[string]StravaInfo {
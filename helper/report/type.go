@@ -15,12 +15,6 @@ type PushRank struct {
 	Repos       map[string]int
 }
 
-type NewLiburNasional struct {
-	Tanggal    string `json:"tanggal"`
-	Keterangan string `json:"keterangan"`
-	IsCuti     bool   `json:"is_cuti"`
-}
-
 type RekapUser struct {
 	Nama        string
 	PhoneNumber string
@@ -4,23 +4,18 @@ import (
 	"errors"
 	"math"
 	"strconv"
-	"time"
 
+	"github.com/gocroot/config"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/periode"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// Disini filter untuk rentang waktu 7 hari
+// WeeklyFilter membatasi _id pada minggu periode akademik yang sedang berjalan
 func WeeklyFilter() bson.M {
-	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
-
-	return bson.M{
-		"$gte": primitive.NewObjectIDFromTimestamp(weekAgo),
-		"$lt":  primitive.NewObjectIDFromTimestamp(time.Now()),
-	}
+	return periode.FilterObjectID(periode.Get(config.Mongoconn).MingguIni(""))
 }
 
 // Get laporan mingguan dari satu grup wa
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+)
+
+// statusWriter mencatat status code yang ditulis handler untuk Logger
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Recover mengubah panic di handler menjadi respon 500 supaya satu request yang gagal tidak menjatuhkan instance
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(respw http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic %s %s: %v\n%s", req.Method, req.URL.Path, rec, debug.Stack())
+				at.WriteError(respw, req, apperr.New(apperr.Internal, "Error : Internal Server Error", "Terjadi kesalahan pada server"))
+			}
+		}()
+		next(respw, req)
+	}
+}
+
+// Logger mencatat method, path, status dan durasi setiap request
+func Logger(next http.HandlerFunc) http.HandlerFunc {
+	return func(respw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: respw}
+		next(sw, req)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		log.Printf("%s %s %d %s", req.Method, req.URL.Path, sw.status, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// CORS memakai aturan origin yang diberikan, preflight yang sudah dijawab tidak diteruskan ke route
+func CORS(setHeaders func(http.ResponseWriter, *http.Request) bool) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(respw http.ResponseWriter, req *http.Request) {
+			if setHeaders(respw, req) {
+				return
+			}
+			next(respw, req)
+		}
+	}
+}
+
+// PublicCORS membuka route untuk semua origin, dipakai script tracker dan soal yang dipasang di web peserta.
+// Request OPTIONS langsung dijawab 204.
+func PublicCORS(methods, headers string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(respw http.ResponseWriter, req *http.Request) {
+			respw.Header().Set("Access-Control-Allow-Origin", "*")
+			if req.Method == http.MethodOptions {
+				respw.Header().Set("Access-Control-Allow-Methods", methods)
+				respw.Header().Set("Access-Control-Allow-Headers", headers)
+				respw.Header().Set("Access-Control-Max-Age", "3600")
+				respw.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next(respw, req)
+		}
+	}
+}
+
+// BlockLocalOrigin menolak request dari localhost supaya data tracker tidak tercampur data uji coba
+func BlockLocalOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(respw http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
+			respw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next(respw, req)
+	}
+}
+
+// NoContent adalah handler kosong untuk route OPTIONS yang dijawab oleh middleware CORS
+func NoContent(respw http.ResponseWriter, req *http.Request) {
+	respw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,299 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/at"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Middleware membungkus handler, dipakai untuk auth, CORS, log dan recover
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Tipe parameter path, ditulis setelah nama parameter, contoh /api/event/delete/:eventid:objectid
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeObjectID = "objectid"
+)
+
+// Router mencocokkan method dan pola path seperti /data/proyek/bimbingan/:id lalu menjalankan rantai middleware.
+// Segmen statis selalu didahulukan dari parameter, sehingga /data/proyek/bimbingan/perdana tidak tertangkap :id.
+type Router struct {
+	routes     map[string][]*route
+	middleware []Middleware
+	notFound   http.HandlerFunc
+	handler    http.HandlerFunc
+}
+
+// RouteInfo adalah satu route yang terdaftar, dipakai untuk dokumentasi API
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	Params  []ParamInfo
+}
+
+// ParamInfo adalah nama dan tipe satu parameter path
+type ParamInfo struct {
+	Name string
+	Type string
+}
+
+type route struct {
+	method   string
+	pattern  string
+	segments []segment
+	handler  http.HandlerFunc
+}
+
+type segment struct {
+	static string
+	param  string
+	tipe   string
+}
+
+type ctxKey struct{}
+
+type params map[string]string
+
+// New membuat router kosong, notFound dipanggil jika tidak ada route yang cocok
+func New(notFound http.HandlerFunc) *Router {
+	rt := &Router{routes: make(map[string][]*route), notFound: notFound}
+	rt.handler = rt.dispatch
+	return rt
+}
+
+// Use menambah middleware global yang dijalankan untuk semua request, termasuk yang tidak ditemukan
+func (rt *Router) Use(mw ...Middleware) {
+	rt.middleware = append(rt.middleware, mw...)
+	rt.handler = Chain(rt.dispatch, rt.middleware...)
+}
+
+// Handle mendaftarkan route dan panic jika pola tidak valid atau sudah terdaftar,
+// sehingga route ganda langsung gagal saat fungsi dijalankan
+func (rt *Router) Handle(method, pattern string, h http.HandlerFunc, mw ...Middleware) {
+	if err := rt.Add(method, pattern, h, mw...); err != nil {
+		panic(err)
+	}
+}
+
+// Add mendaftarkan route, middleware route dijalankan setelah middleware global
+func (rt *Router) Add(method, pattern string, h http.HandlerFunc, mw ...Middleware) error {
+	if h == nil {
+		return fmt.Errorf("router: handler %s %s kosong", method, pattern)
+	}
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+	for _, r := range rt.routes[method] {
+		if sameShape(r.segments, segments) {
+			return fmt.Errorf("router: %s %s bentrok dengan %s %s yang sudah terdaftar", method, pattern, r.method, r.pattern)
+		}
+	}
+	rt.routes[method] = append(rt.routes[method], &route{
+		method:   method,
+		pattern:  pattern,
+		segments: segments,
+		handler:  Chain(h, mw...),
+	})
+	return nil
+}
+
+func (rt *Router) GET(pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodGet, pattern, h, mw...)
+}
+
+func (rt *Router) POST(pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodPost, pattern, h, mw...)
+}
+
+func (rt *Router) PUT(pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodPut, pattern, h, mw...)
+}
+
+func (rt *Router) DELETE(pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodDelete, pattern, h, mw...)
+}
+
+func (rt *Router) OPTIONS(pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodOptions, pattern, h, mw...)
+}
+
+// Routes mengembalikan semua route terdaftar, diurutkan berdasarkan pola lalu method
+func (rt *Router) Routes() []RouteInfo {
+	var infos []RouteInfo
+	for _, routes := range rt.routes {
+		for _, r := range routes {
+			info := RouteInfo{Method: r.method, Pattern: r.pattern}
+			for _, s := range r.segments {
+				if s.param != "" {
+					info.Params = append(info.Params, ParamInfo{Name: s.param, Type: s.tipe})
+				}
+			}
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Pattern != infos[j].Pattern {
+			return infos[i].Pattern < infos[j].Pattern
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// ServeHTTP menjalankan middleware global lalu route yang cocok
+func (rt *Router) ServeHTTP(respw http.ResponseWriter, req *http.Request) {
+	rt.handler(respw, req)
+}
+
+func (rt *Router) dispatch(respw http.ResponseWriter, req *http.Request) {
+	r, p := rt.match(req.Method, req.URL.Path)
+	if r == nil {
+		rt.notFound(respw, req)
+		return
+	}
+	for _, s := range r.segments {
+		if s.param == "" || validParam(p[s.param], s.tipe) {
+			continue
+		}
+		at.WriteError(respw, req, apperr.New(apperr.InvalidParam, "Error : Parameter tidak valid", "Parameter "+s.param+" harus bertipe "+s.tipe))
+		return
+	}
+	if len(p) > 0 {
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, p))
+	}
+	r.handler(respw, req)
+}
+
+// match memilih route paling spesifik: pada segmen pertama yang berbeda, segmen statis menang atas parameter
+func (rt *Router) match(method, path string) (best *route, p params) {
+	parts := splitPath(path)
+	for _, r := range rt.routes[method] {
+		if len(r.segments) != len(parts) {
+			continue
+		}
+		ok := true
+		for i, s := range r.segments {
+			if s.param == "" && s.static != parts[i] {
+				ok = false
+				break
+			}
+		}
+		if ok && (best == nil || moreSpecific(r, best)) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	p = make(params)
+	for i, s := range best.segments {
+		if s.param != "" {
+			p[s.param] = parts[i]
+		}
+	}
+	return best, p
+}
+
+// Chain membungkus h dengan middleware, middleware pertama dijalankan paling awal
+func Chain(h http.HandlerFunc, mw ...Middleware) http.HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// Param mengembalikan nilai parameter path, kosong jika tidak ada
+func Param(req *http.Request, name string) string {
+	p, _ := req.Context().Value(ctxKey{}).(params)
+	return p[name]
+}
+
+// ParamInt mengembalikan parameter bertipe int, sudah divalidasi router
+func ParamInt(req *http.Request, name string) int {
+	n, _ := strconv.Atoi(Param(req, name))
+	return n
+}
+
+// ParamObjectID mengembalikan parameter bertipe objectid, sudah divalidasi router
+func ParamObjectID(req *http.Request, name string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(Param(req, name))
+	return id
+}
+
+func parsePattern(pattern string) ([]segment, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("router: pola %s harus diawali /", pattern)
+	}
+	var segments []segment
+	names := make(map[string]bool)
+	for _, part := range splitPath(pattern) {
+		if !strings.HasPrefix(part, ":") {
+			segments = append(segments, segment{static: part})
+			continue
+		}
+		name, tipe, _ := strings.Cut(part[1:], ":")
+		if tipe == "" {
+			tipe = TypeString
+		}
+		if name == "" || names[name] {
+			return nil, fmt.Errorf("router: parameter pada pola %s kosong atau ganda", pattern)
+		}
+		if tipe != TypeString && tipe != TypeInt && tipe != TypeObjectID {
+			return nil, fmt.Errorf("router: tipe parameter %s pada pola %s tidak dikenal", tipe, pattern)
+		}
+		names[name] = true
+		segments = append(segments, segment{param: name, tipe: tipe})
+	}
+	return segments, nil
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// sameShape bernilai true jika dua pola selalu cocok dengan path yang sama, nama parameter tidak berpengaruh
+func sameShape(a, b []segment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i].param == "") != (b[i].param == "") || a[i].static != b[i].static {
+			return false
+		}
+	}
+	return true
+}
+
+func moreSpecific(a, b *route) bool {
+	for i := range a.segments {
+		aStatic, bStatic := a.segments[i].param == "", b.segments[i].param == ""
+		if aStatic != bStatic {
+			return aStatic
+		}
+	}
+	return false
+}
+
+func validParam(value, tipe string) bool {
+	switch tipe {
+	case TypeInt:
+		_, err := strconv.Atoi(value)
+		return err == nil
+	case TypeObjectID:
+		return primitive.IsValidObjectID(value)
+	}
+	return value != ""
+}
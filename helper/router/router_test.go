@@ -0,0 +1,144 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tulis(nama string) http.HandlerFunc {
+	return func(respw http.ResponseWriter, req *http.Request) {
+		p, _ := req.Context().Value(ctxKey{}).(params)
+		var pasang []string
+		for _, k := range []string{"id", "n", "x", "y"} {
+			if v, ok := p[k]; ok {
+				pasang = append(pasang, k+"="+v)
+			}
+		}
+		respw.Write([]byte(nama + " " + strings.Join(pasang, ",")))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		ada     []string
+		pattern string
+		kosong  bool
+		wantErr bool
+	}{
+		{name: "route baru", ada: []string{"/api/event"}, pattern: "/api/event/:id"},
+		{name: "pola sama", ada: []string{"/api/event/:id"}, pattern: "/api/event/:id", wantErr: true},
+		{name: "nama parameter beda tetap bentrok", ada: []string{"/api/event/:id"}, pattern: "/api/event/:eventid", wantErr: true},
+		{name: "tipe parameter beda tetap bentrok", ada: []string{"/api/event/:id:objectid"}, pattern: "/api/event/:n:int", wantErr: true},
+		{name: "statis dan parameter tidak bentrok", ada: []string{"/api/event/:id"}, pattern: "/api/event/all"},
+		{name: "statis beda", ada: []string{"/api/event/all"}, pattern: "/api/event/queue"},
+		{name: "parameter di posisi lain", ada: []string{"/a/:x/c"}, pattern: "/a/b/:y"},
+		{name: "tanpa garis miring", pattern: "api/event", wantErr: true},
+		{name: "parameter kosong", pattern: "/api/:", wantErr: true},
+		{name: "parameter ganda", pattern: "/api/:id/:id", wantErr: true},
+		{name: "tipe tidak dikenal", pattern: "/api/:id:uuid", wantErr: true},
+		{name: "handler kosong", pattern: "/api/event", kosong: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := New(http.NotFound)
+			for _, p := range tt.ada {
+				if err := rt.Add(http.MethodGet, p, tulis(p)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			h := tulis(tt.pattern)
+			if tt.kosong {
+				h = nil
+			}
+			if err := rt.Add(http.MethodGet, tt.pattern, h); (err != nil) != tt.wantErr {
+				t.Fatalf("Add(%s) error = %v, seharusnya error %v", tt.pattern, err, tt.wantErr)
+			}
+			//method lain tidak pernah bentrok
+			if !tt.wantErr || len(tt.ada) == 0 {
+				return
+			}
+			if err := rt.Add(http.MethodPost, tt.pattern, tulis(tt.pattern)); err != nil {
+				t.Fatalf("Add POST %s: %v", tt.pattern, err)
+			}
+		})
+	}
+}
+
+func TestHandlePanic(t *testing.T) {
+	rt := New(http.NotFound)
+	rt.GET("/api/event/:id", tulis("a"))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("route ganda seharusnya panic")
+		}
+	}()
+	rt.GET("/api/event/:eventid", tulis("b"))
+}
+
+func TestDispatch(t *testing.T) {
+	rt := New(func(respw http.ResponseWriter, req *http.Request) {
+		respw.WriteHeader(http.StatusNotFound)
+	})
+	rt.GET("/data/proyek/bimbingan/:id", tulis("param"))
+	rt.GET("/data/proyek/bimbingan/perdana", tulis("statis"))
+	rt.GET("/a/:x/c", tulis("ac"))
+	rt.GET("/a/b/:y", tulis("ab"))
+	rt.GET("/api/event/:id:objectid", tulis("event"))
+	rt.GET("/api/halaman/:n:int", tulis("halaman"))
+	rt.POST("/api/event/:id:objectid", tulis("event post"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "statis didahulukan", method: http.MethodGet, path: "/data/proyek/bimbingan/perdana", wantStatus: http.StatusOK, wantBody: "statis "},
+		{name: "parameter", method: http.MethodGet, path: "/data/proyek/bimbingan/abc", wantStatus: http.StatusOK, wantBody: "param id=abc"},
+		{name: "statis pada segmen pertama yang berbeda", method: http.MethodGet, path: "/a/b/c", wantStatus: http.StatusOK, wantBody: "ab y=c"},
+		{name: "hanya satu pola cocok", method: http.MethodGet, path: "/a/z/c", wantStatus: http.StatusOK, wantBody: "ac x=z"},
+		{name: "objectid valid", method: http.MethodGet, path: "/api/event/6ad313af3db5effff2ef11d4", wantStatus: http.StatusOK, wantBody: "event id=6ad313af3db5effff2ef11d4"},
+		{name: "objectid tidak valid", method: http.MethodGet, path: "/api/event/123", wantStatus: http.StatusBadRequest},
+		{name: "int valid", method: http.MethodGet, path: "/api/halaman/12", wantStatus: http.StatusOK, wantBody: "halaman n=12"},
+		{name: "int tidak valid", method: http.MethodGet, path: "/api/halaman/dua", wantStatus: http.StatusBadRequest},
+		{name: "garis miring di akhir", method: http.MethodGet, path: "/api/halaman/3/", wantStatus: http.StatusOK, wantBody: "halaman n=3"},
+		{name: "jumlah segmen beda", method: http.MethodGet, path: "/api/halaman/3/4", wantStatus: http.StatusNotFound},
+		{name: "method beda", method: http.MethodPost, path: "/api/event/6ad313af3db5effff2ef11d4", wantStatus: http.StatusOK, wantBody: "event post id=6ad313af3db5effff2ef11d4"},
+		{name: "method tidak terdaftar", method: http.MethodDelete, path: "/api/event/6ad313af3db5effff2ef11d4", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("%s %s status %d, seharusnya %d: %s", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Fatalf("%s %s = %q, seharusnya %q", tt.method, tt.path, rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var urutan []string
+	mw := func(nama string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(respw http.ResponseWriter, req *http.Request) {
+				urutan = append(urutan, nama)
+				next(respw, req)
+			}
+		}
+	}
+	rt := New(http.NotFound)
+	rt.Use(mw("global"))
+	rt.GET("/api", func(http.ResponseWriter, *http.Request) { urutan = append(urutan, "handler") }, mw("route1"), mw("route2"))
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api", nil))
+	if got := strings.Join(urutan, ","); got != "global,route1,route2,handler" {
+		t.Fatalf("urutan middleware %s", got)
+	}
+}
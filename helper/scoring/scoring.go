@@ -0,0 +1,265 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan semua versi aturan skor
+const Collection = "scoringrule"
+
+const (
+	SumberSkor   = "skor"
+	SumberMentah = "mentah"
+)
+
+var (
+	ErrNoActiveRuleSet = errors.New("belum ada aturan skor aktif untuk enroll ini")
+
+	indexOnce sync.Once
+)
+
+// komponen menghubungkan nama komponen dengan field ActivityScore
+type komponen struct {
+	mentah func(a *model.ActivityScore) float64 // nilai mentah, nil jika komponen hanya punya skor
+	grade  func(a *model.ActivityScore) string  // nilai mentah berupa grade
+	skor   func(a *model.ActivityScore) float64
+	simpan func(a *model.ActivityScore, v float64)
+}
+
+var komponenSkor = map[string]komponen{
+	"sponsor": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.Sponsordata) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.Sponsor) },
+		simpan: func(a *model.ActivityScore, v float64) { a.Sponsor = int(v) },
+	},
+	"strava": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.StravaKM) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.Strava) },
+		simpan: func(a *model.ActivityScore, v float64) { a.Strava = int(v) },
+	},
+	"iq": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.IQresult) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.IQ) },
+		simpan: func(a *model.ActivityScore, v float64) { a.IQ = int(v) },
+	},
+	"pomokit": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.Pomokitsesi) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.Pomokit) },
+		simpan: func(a *model.ActivityScore, v float64) { a.Pomokit = int(v) },
+	},
+	"blockchain": {
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.BlockChain) },
+		simpan: func(a *model.ActivityScore, v float64) { a.BlockChain = int(v) },
+	},
+	"qris": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.Rupiah) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.QRIS) },
+		simpan: func(a *model.ActivityScore, v float64) { a.QRIS = int(v) },
+	},
+	"tracker": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.Trackerdata) },
+		skor:   func(a *model.ActivityScore) float64 { return a.Tracker },
+		simpan: func(a *model.ActivityScore, v float64) { a.Tracker = v },
+	},
+	"bukped": {
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.BukPed) },
+		simpan: func(a *model.ActivityScore, v float64) { a.BukPed = int(v) },
+	},
+	"jurnal": {
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.Jurnal) },
+		simpan: func(a *model.ActivityScore, v float64) { a.Jurnal = int(v) },
+	},
+	"gtmetrix": {
+		grade:  func(a *model.ActivityScore) string { return a.GTMetrixResult },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.GTMetrix) },
+		simpan: func(a *model.ActivityScore, v float64) { a.GTMetrix = int(v) },
+	},
+	"webhook": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.WebHookpush) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.WebHook) },
+		simpan: func(a *model.ActivityScore, v float64) { a.WebHook = int(v) },
+	},
+	"presensi": {
+		mentah: func(a *model.ActivityScore) float64 { return float64(a.PresensiHari) },
+		skor:   func(a *model.ActivityScore) float64 { return float64(a.Presensi) },
+		simpan: func(a *model.ActivityScore, v float64) { a.Presensi = int(v) },
+	},
+}
+
+// urutanKomponen dipakai aturan bawaan, sama dengan penjumlahan HitungTotalScore sebelumnya
+var urutanKomponen = []string{"sponsor", "strava", "iq", "pomokit", "blockchain", "qris", "tracker", "bukped", "jurnal", "gtmetrix", "webhook", "presensi"}
+
+// DefaultRules dipakai jika enroll belum punya aturan aktif: semua komponen memakai skor dari sumber data dengan bobot 1
+func DefaultRules() []model.ScoringRule {
+	rules := make([]model.ScoringRule, 0, len(urutanKomponen))
+	for _, nama := range urutanKomponen {
+		rules = append(rules, model.ScoringRule{Komponen: nama, Sumber: SumberSkor, Bobot: 1})
+	}
+	return rules
+}
+
+// Validate memeriksa aturan sebelum disimpan
+func Validate(rules []model.ScoringRule) error {
+	if len(rules) == 0 {
+		return errors.New("aturan skor tidak boleh kosong")
+	}
+	dipakai := make(map[string]bool)
+	for _, rule := range rules {
+		k, ok := komponenSkor[rule.Komponen]
+		if !ok {
+			return errors.New("komponen " + rule.Komponen + " tidak dikenal")
+		}
+		if dipakai[rule.Komponen] {
+			return errors.New("komponen " + rule.Komponen + " ditulis lebih dari sekali")
+		}
+		dipakai[rule.Komponen] = true
+		if rule.Bobot < 0 || rule.Max < 0 {
+			return errors.New("bobot dan max komponen " + rule.Komponen + " tidak boleh negatif")
+		}
+		switch rule.Sumber {
+		case "", SumberSkor:
+		case SumberMentah:
+			if k.grade != nil {
+				if len(rule.Grade) == 0 {
+					return errors.New("komponen " + rule.Komponen + " butuh daftar grade")
+				}
+			} else if k.mentah == nil {
+				return errors.New("komponen " + rule.Komponen + " tidak punya nilai mentah")
+			} else if rule.Per <= 0 {
+				return errors.New("per pada komponen " + rule.Komponen + " harus lebih dari 0")
+			}
+		default:
+			return errors.New("sumber " + rule.Sumber + " tidak dikenal, gunakan skor atau mentah")
+		}
+	}
+	return nil
+}
+
+// Apply menghitung ulang skor komponen yang memakai nilai mentah lalu mengembalikan total berbobot.
+// minggu adalah jumlah minggu berjalan untuk aturan RataMingguan, isi 1 untuk skor mingguan.
+// Komponen yang tidak ada di aturan tidak ikut dijumlahkan.
+func Apply(score *model.ActivityScore, rules []model.ScoringRule, minggu int) int {
+	if minggu < 1 {
+		minggu = 1
+	}
+	var total float64
+	for _, rule := range rules {
+		k, ok := komponenSkor[rule.Komponen]
+		if !ok {
+			continue
+		}
+		nilai := k.skor(score)
+		if rule.Sumber == SumberMentah {
+			switch {
+			case k.grade != nil:
+				nilai = rule.Grade[strings.ToUpper(k.grade(score))]
+			case k.mentah != nil && rule.Per > 0:
+				mentah := k.mentah(score)
+				if rule.RataMingguan {
+					mentah = mentah / float64(minggu)
+				}
+				nilai = mentah / rule.Per * rule.Nilai
+			}
+		}
+		if rule.Max > 0 {
+			nilai = math.Min(nilai, rule.Max)
+		}
+		k.simpan(score, nilai)
+		bobot := rule.Bobot
+		if bobot == 0 {
+			bobot = 1
+		}
+		total += k.skor(score) * bobot
+	}
+	return int(math.Round(total))
+}
+
+// GetActiveRuleSet mengambil versi aturan yang aktif untuk kode enroll
+func GetActiveRuleSet(db *mongo.Database, enroll string) (ruleset model.ScoringRuleSet, err error) {
+	err = db.Collection(Collection).FindOne(context.Background(), bson.M{"enroll": enroll, "active": true}).Decode(&ruleset)
+	if err == mongo.ErrNoDocuments {
+		err = ErrNoActiveRuleSet
+	}
+	return
+}
+
+// RulesForEnroll mengembalikan aturan aktif beserta versinya, atau aturan bawaan dengan versi 0
+func RulesForEnroll(db *mongo.Database, enroll string) ([]model.ScoringRule, int) {
+	if enroll != "" {
+		ruleset, err := GetActiveRuleSet(db, enroll)
+		if err == nil {
+			return ruleset.Rules, ruleset.Version
+		}
+	}
+	return DefaultRules(), 0
+}
+
+// ensureIndexes membuat nomor versi unik per enroll sehingga dua perubahan bersamaan tidak mendapat versi yang sama
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(Collection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "enroll", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating scoringrule index: %v", err)
+		}
+	})
+}
+
+// CreateVersion menyimpan aturan sebagai versi terbaru enroll dan langsung mengaktifkannya
+func CreateVersion(db *mongo.Database, ruleset model.ScoringRuleSet) (model.ScoringRuleSet, error) {
+	if err := Validate(ruleset.Rules); err != nil {
+		return ruleset, err
+	}
+	ensureIndexes(db)
+	var latest model.ScoringRuleSet
+	err := db.Collection(Collection).FindOne(context.Background(), bson.M{"enroll": ruleset.Enroll},
+		options.FindOne().SetSort(bson.M{"version": -1})).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return ruleset, err
+	}
+	ruleset.ID = primitive.NilObjectID
+	ruleset.Version = latest.Version + 1
+	ruleset.Active = false
+	ruleset.CreatedAt = time.Now()
+	res, err := db.Collection(Collection).InsertOne(context.Background(), ruleset)
+	if err != nil {
+		return ruleset, err
+	}
+	ruleset.ID, _ = res.InsertedID.(primitive.ObjectID)
+	if err = Activate(db, ruleset.Enroll, ruleset.Version); err != nil {
+		return ruleset, err
+	}
+	ruleset.Active = true
+	return ruleset, nil
+}
+
+// Activate menjadikan satu versi aktif dan menonaktifkan versi lain, dipakai juga untuk rollback
+func Activate(db *mongo.Database, enroll string, version int) error {
+	res, err := db.Collection(Collection).UpdateOne(context.Background(),
+		bson.M{"enroll": enroll, "version": version},
+		bson.M{"$set": bson.M{"active": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("versi aturan skor tidak ditemukan")
+	}
+	_, err = db.Collection(Collection).UpdateMany(context.Background(),
+		bson.M{"enroll": enroll, "version": bson.M{"$ne": version}},
+		bson.M{"$set": bson.M{"active": false}})
+	return err
+}
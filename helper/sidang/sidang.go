@@ -0,0 +1,613 @@
+package sidang
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/gcallapi"
+	"github.com/gocroot/helper/libur"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/waoutbox"
+	"github.com/gocroot/helper/whatsauth"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// SlotCollection menyimpan slot ketersediaan dosen
+	SlotCollection = "sidangslot"
+	// JadwalCollection menyimpan jadwal sidang beserta riwayat pindah dan batal
+	JadwalCollection = "sidangjadwal"
+	// PengajuanCollection adalah pengajuan sidang dari PostPengajuanSidang
+	PengajuanCollection = "bimbingan_pengajuan"
+	// KunciCollection menyimpan kunci ruang dan peserta per hari selama jadwal diperiksa dan disimpan
+	KunciCollection = "sidangkunci"
+
+	// PengajuanTerjadwal adalah status pengajuan yang sudah punya jadwal aktif
+	PengajuanTerjadwal = "terjadwal"
+
+	DurasiDefault = 60 * time.Minute
+	// langkah adalah jarak antar kandidat waktu mulai pada penjadwal otomatis
+	langkah = 30 * time.Minute
+	// maksSlot dan maksCari mencegah salah ketik tanggal membuat slot atau pencarian yang sangat panjang
+	maksSlot = 12 * time.Hour
+	maksCari = 31 * 24 * time.Hour
+	// lamaKunci membatasi umur kunci supaya proses yang berhenti di tengah tidak mengunci ruang selamanya
+	lamaKunci = 30 * time.Second
+)
+
+// wib sama dengan offset yang dipakai gcallapi.HandlerCalendar
+var wib = time.FixedZone("WIB", 7*60*60)
+
+var indexOnce sync.Once
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(SlotCollection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "phonenumber", Value: 1}, {Key: "mulai", Value: 1}},
+		})
+		if err != nil {
+			log.Printf("Error creating sidangslot index: %v", err)
+		}
+		_, err = db.Collection(JadwalCollection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "mulai", Value: 1}}},
+			//satu jadwal aktif per pengajuan walau dua admin menjadwalkan bersamaan
+			{Keys: bson.D{{Key: "pengajuanid", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": model.SidangTerjadwal})},
+		})
+		if err != nil {
+			log.Printf("Error creating sidangjadwal index: %v", err)
+		}
+	})
+}
+
+// Rentang adalah interval waktu setengah terbuka [Mulai, Selesai)
+type Rentang struct {
+	Mulai   time.Time
+	Selesai time.Time
+}
+
+// Bentrok bernilai true jika dua rentang beririsan, rentang yang hanya bersentuhan tidak bentrok
+func (r Rentang) Bentrok(o Rentang) bool {
+	return r.Mulai.Before(o.Selesai) && o.Mulai.Before(r.Selesai)
+}
+
+// Mencakup bernilai true jika o berada penuh di dalam r
+func (r Rentang) Mencakup(o Rentang) bool {
+	return !r.Mulai.After(o.Mulai) && !r.Selesai.Before(o.Selesai)
+}
+
+// Format menulis rentang dalam WIB, contoh "Senin 02-02-2026 09:00-10:00 WIB"
+func (r Rentang) Format() string {
+	mulai := r.Mulai.In(wib)
+	return namaHari[mulai.Weekday()] + " " + mulai.Format("02-01-2006 15:04") + "-" + r.Selesai.In(wib).Format("15:04") + " WIB"
+}
+
+var namaHari = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+func rentangJadwal(j model.JadwalSidang) Rentang {
+	return Rentang{Mulai: j.Mulai, Selesai: j.Selesai}
+}
+
+// TambahSlot menyimpan slot ketersediaan dosen, slot tidak boleh beririsan dengan slot dosen yang sama
+func TambahSlot(db *mongo.Database, dosen model.Userdomyikado, req model.SidangSlotRequest) (slot model.SidangSlot, err error) {
+	ensureIndexes(db)
+	r := Rentang{Mulai: req.Mulai, Selesai: req.Selesai}
+	if err = cekRentang(r, maksSlot); err != nil {
+		return
+	}
+	n, err := db.Collection(SlotCollection).CountDocuments(context.Background(), bson.M{
+		"phonenumber": dosen.PhoneNumber,
+		"mulai":       bson.M{"$lt": r.Selesai},
+		"selesai":     bson.M{"$gt": r.Mulai},
+	})
+	if err != nil {
+		return slot, apperr.Wrap(apperr.Database, "", err)
+	}
+	if n > 0 {
+		return slot, apperr.New(apperr.Conflict, "", "slot beririsan dengan slot lain milik "+dosen.Name)
+	}
+	slot = model.SidangSlot{
+		PhoneNumber: dosen.PhoneNumber,
+		Name:        dosen.Name,
+		Mulai:       r.Mulai,
+		Selesai:     r.Selesai,
+		CreatedAt:   time.Now(),
+	}
+	slot.ID, err = atdb.InsertOneDoc(db, SlotCollection, slot)
+	if err != nil {
+		return slot, apperr.Wrap(apperr.Database, "", err)
+	}
+	return
+}
+
+func cekRentang(r Rentang, maks time.Duration) error {
+	switch {
+	case r.Mulai.IsZero() || !r.Selesai.After(r.Mulai):
+		return apperr.New(apperr.BadRequest, "", "waktu selesai harus setelah waktu mulai, gunakan format RFC3339")
+	case r.Mulai.Before(time.Now()):
+		return apperr.New(apperr.BadRequest, "", "waktu mulai sudah lewat")
+	case r.Selesai.Sub(r.Mulai) > maks:
+		return apperr.New(apperr.BadRequest, "", "rentang waktu terlalu panjang, maksimal "+maks.String())
+	}
+	return nil
+}
+
+// HapusSlot menghapus slot dosen selama tidak ada jadwal aktif yang tercakup slot tersebut.
+// Kunci peserta dosen pada hari slot diambil supaya slot tidak terhapus saat jadwal baru sedang disimpan.
+func HapusSlot(db *mongo.Database, slot model.SidangSlot) error {
+	r := Rentang{Mulai: slot.Mulai, Selesai: slot.Selesai}
+	var kunci []string
+	for _, hari := range tanggal(r) {
+		kunci = append(kunci, "peserta:"+slot.PhoneNumber+":"+hari)
+	}
+	lepas, err := ambilKunci(db, kunci)
+	if err != nil {
+		return err
+	}
+	defer lepas()
+	dipakai, err := atdb.GetAllDoc[[]model.JadwalSidang](db, JadwalCollection, bson.M{
+		"status":  model.SidangTerjadwal,
+		"peserta": slot.PhoneNumber,
+		"mulai":   bson.M{"$gte": r.Mulai},
+		"selesai": bson.M{"$lte": r.Selesai},
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	if len(dipakai) > 0 {
+		return apperr.New(apperr.Conflict, "", "slot dipakai sidang "+dipakai[0].Name+" pada "+rentangJadwal(dipakai[0]).Format()+", pindah atau batalkan jadwalnya dulu")
+	}
+	if _, err = atdb.DeleteOneDoc(db, SlotCollection, bson.M{"_id": slot.ID}); err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	return nil
+}
+
+// Susun membuat jadwal dari pengajuan. Pembimbing diambil dari pengajuan,
+// penguji dari pengujiPhone atau dosen penguji pengajuan jika kosong.
+func Susun(db *mongo.Database, pengajuan model.BimbinganPengajuan, pengujiPhone []string) (j model.JadwalSidang, err error) {
+	if len(pengujiPhone) == 0 {
+		pengujiPhone = []string{pengajuan.DosenPengujiPhone}
+	}
+	j = model.JadwalSidang{
+		PengajuanID:   pengajuan.ID,
+		PhoneNumber:   pengajuan.PhoneNumber,
+		Name:          pengajuan.Name,
+		NPM:           pengajuan.NPM,
+		NomorKelompok: pengajuan.NomorKelompok,
+		Pembimbing:    model.SidangDosen{PhoneNumber: pengajuan.DosenPembimbingPhone, Name: pengajuan.DosenPembimbing},
+		Status:        model.SidangTerjadwal,
+	}
+	ada := map[string]bool{pengajuan.PhoneNumber: true, pengajuan.DosenPembimbingPhone: true}
+	for _, phone := range pengujiPhone {
+		if ada[phone] {
+			return j, apperr.New(apperr.BadRequest, "", "penguji "+phone+" sudah menjadi pembimbing, mahasiswa atau penguji lain")
+		}
+		ada[phone] = true
+		dosen, errusr := atdb.GetOneDoc[model.Userdomyikado](db, "user", bson.M{"phonenumber": phone})
+		if errusr != nil || !rbac.Can(db, phone, rbac.ApproveBimbingan, "") {
+			return j, apperr.New(apperr.UserNotFound, "", "nomor "+phone+" bukan dosen")
+		}
+		j.Penguji = append(j.Penguji, model.SidangDosen{PhoneNumber: dosen.PhoneNumber, Name: dosen.Name})
+	}
+	j.Peserta = append([]string{j.PhoneNumber, j.Pembimbing.PhoneNumber}, pengujiPhone...)
+	return
+}
+
+// dosen adalah pembimbing dan semua penguji, yang semuanya harus membuka slot
+func dosen(j model.JadwalSidang) []model.SidangDosen {
+	return append([]model.SidangDosen{j.Pembimbing}, j.Penguji...)
+}
+
+// Periksa memastikan jadwal tidak jatuh pada hari libur, tercakup slot setiap dosen,
+// dan tidak bentrok dengan jadwal lain pada ruang yang sama atau dengan peserta yang sama
+func Periksa(db *mongo.Database, j model.JadwalSidang) error {
+	ensureIndexes(db)
+	r := rentangJadwal(j)
+	if err := cekRentang(r, maksSlot); err != nil {
+		return err
+	}
+	if strings.TrimSpace(j.Ruang) == "" {
+		return apperr.New(apperr.BadRequest, "", "ruang sidang belum diisi")
+	}
+	if libur.IsLibur(db, r.Mulai.In(wib), "") {
+		return apperr.New(apperr.BadRequest, "", r.Mulai.In(wib).Format(libur.FormatTanggal)+" adalah hari libur")
+	}
+	for _, d := range dosen(j) {
+		n, err := db.Collection(SlotCollection).CountDocuments(context.Background(), bson.M{
+			"phonenumber": d.PhoneNumber,
+			"mulai":       bson.M{"$lte": r.Mulai},
+			"selesai":     bson.M{"$gte": r.Selesai},
+		})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if n == 0 {
+			return apperr.New(apperr.PreconditionFailed, "", d.Name+" tidak membuka slot pada "+r.Format())
+		}
+	}
+	lain, err := atdb.GetAllDoc[[]model.JadwalSidang](db, JadwalCollection, bson.M{
+		"_id":     bson.M{"$ne": j.ID},
+		"status":  model.SidangTerjadwal,
+		"mulai":   bson.M{"$lt": r.Selesai},
+		"selesai": bson.M{"$gt": r.Mulai},
+		"$or": bson.A{
+			bson.M{"ruang": j.Ruang},
+			bson.M{"peserta": bson.M{"$in": j.Peserta}},
+		},
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	if len(lain) > 0 {
+		return apperr.New(apperr.Conflict, "", alasanBentrok(j, lain[0]))
+	}
+	return nil
+}
+
+func alasanBentrok(j, lain model.JadwalSidang) string {
+	waktu := rentangJadwal(lain).Format()
+	if lain.Ruang == j.Ruang {
+		return "ruang " + j.Ruang + " sudah dipakai sidang " + lain.Name + " pada " + waktu
+	}
+	for _, p := range lain.Peserta {
+		for _, q := range j.Peserta {
+			if p == q {
+				return p + " sudah terjadwal di sidang " + lain.Name + " pada " + waktu
+			}
+		}
+	}
+	return "bentrok dengan sidang " + lain.Name + " pada " + waktu
+}
+
+// Cari mengisi ruang dan waktu jadwal dengan kandidat pertama dalam batas yang tercakup slot
+// semua dosen, bukan hari libur, dan tidak bentrok dengan jadwal lain
+func Cari(db *mongo.Database, j model.JadwalSidang, ruang []string, durasi time.Duration, batas Rentang) (model.JadwalSidang, error) {
+	ensureIndexes(db)
+	if durasi <= 0 {
+		durasi = DurasiDefault
+	}
+	if len(ruang) == 0 {
+		return j, apperr.New(apperr.BadRequest, "", "daftar ruang sidang kosong")
+	}
+	if batas.Mulai.Before(time.Now()) {
+		batas.Mulai = time.Now()
+	}
+	if !batas.Selesai.After(batas.Mulai) || batas.Selesai.Sub(batas.Mulai) > maksCari {
+		return j, apperr.New(apperr.BadRequest, "", "rentang pencarian tidak valid, maksimal 31 hari ke depan")
+	}
+	var ketersediaan [][]Rentang
+	for _, d := range dosen(j) {
+		slots, err := atdb.GetAllDoc[[]model.SidangSlot](db, SlotCollection, bson.M{
+			"phonenumber": d.PhoneNumber,
+			"mulai":       bson.M{"$lt": batas.Selesai},
+			"selesai":     bson.M{"$gt": batas.Mulai},
+		})
+		if err != nil {
+			return j, apperr.Wrap(apperr.Database, "", err)
+		}
+		if len(slots) == 0 {
+			return j, apperr.New(apperr.PreconditionFailed, "", d.Name+" tidak membuka slot pada rentang tersebut")
+		}
+		var rs []Rentang
+		for _, s := range slots {
+			rs = append(rs, Rentang{Mulai: s.Mulai, Selesai: s.Selesai})
+		}
+		ketersediaan = append(ketersediaan, rs)
+	}
+	jadwal, err := atdb.GetAllDoc[[]model.JadwalSidang](db, JadwalCollection, bson.M{
+		"_id":     bson.M{"$ne": j.ID},
+		"status":  model.SidangTerjadwal,
+		"mulai":   bson.M{"$lt": batas.Selesai},
+		"selesai": bson.M{"$gt": batas.Mulai},
+	})
+	if err != nil {
+		return j, apperr.Wrap(apperr.Database, "", err)
+	}
+	peserta := make(map[string]bool)
+	for _, p := range j.Peserta {
+		peserta[p] = true
+	}
+	var sibuk []Rentang
+	ruangSibuk := make(map[string][]Rentang)
+	for _, lain := range jadwal {
+		ruangSibuk[lain.Ruang] = append(ruangSibuk[lain.Ruang], rentangJadwal(lain))
+		for _, p := range lain.Peserta {
+			if peserta[p] {
+				sibuk = append(sibuk, rentangJadwal(lain))
+				break
+			}
+		}
+	}
+	isLibur := func(t time.Time) bool { return libur.IsLibur(db, t.In(wib), "") }
+	r, ruangDipilih, ok := cariWaktu(ketersediaan, sibuk, ruangSibuk, ruang, durasi, batas, isLibur)
+	if !ok {
+		return j, apperr.New(apperr.Conflict, "", "tidak ada waktu dan ruang yang cocok untuk semua dosen pada rentang tersebut")
+	}
+	j.Ruang, j.Mulai, j.Selesai = ruangDipilih, r.Mulai, r.Selesai
+	return j, nil
+}
+
+// cariWaktu mencoba waktu mulai dari setiap slot dosen pertama dengan jarak langkah,
+// lalu memilih ruang pertama yang kosong pada waktu tersebut
+func cariWaktu(ketersediaan [][]Rentang, sibuk []Rentang, ruangSibuk map[string][]Rentang, ruang []string, durasi time.Duration, batas Rentang, isLibur func(time.Time) bool) (Rentang, string, bool) {
+	if len(ketersediaan) == 0 {
+		return Rentang{}, "", false
+	}
+	slots := append([]Rentang(nil), ketersediaan[0]...)
+	sort.Slice(slots, func(i, k int) bool { return slots[i].Mulai.Before(slots[k].Mulai) })
+	for _, slot := range slots {
+		mulai := slot.Mulai
+		if mulai.Before(batas.Mulai) {
+			//dibulatkan ke atas supaya kandidat tetap sejajar dengan awal slot
+			mulai = mulai.Add((batas.Mulai.Sub(mulai) + langkah - 1) / langkah * langkah)
+		}
+		for ; !mulai.Add(durasi).After(slot.Selesai) && !mulai.Add(durasi).After(batas.Selesai); mulai = mulai.Add(langkah) {
+			r := Rentang{Mulai: mulai, Selesai: mulai.Add(durasi)}
+			if isLibur != nil && isLibur(r.Mulai) {
+				continue
+			}
+			if !semuaTersedia(ketersediaan[1:], r) || bentrokDengan(sibuk, r) {
+				continue
+			}
+			for _, nama := range ruang {
+				if !bentrokDengan(ruangSibuk[nama], r) {
+					return r, nama, true
+				}
+			}
+		}
+	}
+	return Rentang{}, "", false
+}
+
+func semuaTersedia(ketersediaan [][]Rentang, r Rentang) bool {
+	for _, slots := range ketersediaan {
+		tercakup := false
+		for _, s := range slots {
+			if s.Mencakup(r) {
+				tercakup = true
+				break
+			}
+		}
+		if !tercakup {
+			return false
+		}
+	}
+	return true
+}
+
+func bentrokDengan(daftar []Rentang, r Rentang) bool {
+	for _, d := range daftar {
+		if d.Bentrok(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// tanggal mengembalikan tanggal WIB yang disentuh rentang, slot dan jadwal bisa melewati tengah malam
+func tanggal(r Rentang) (hari []string) {
+	for t := r.Mulai.In(wib); t.Before(r.Selesai); t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, wib) {
+		hari = append(hari, t.Format("2006-01-02"))
+	}
+	return
+}
+
+// kunciJadwal adalah kunci ruang dan setiap peserta pada hari jadwal
+func kunciJadwal(j model.JadwalSidang) (kunci []string) {
+	for _, hari := range tanggal(rentangJadwal(j)) {
+		kunci = append(kunci, "ruang:"+j.Ruang+":"+hari)
+		for _, p := range j.Peserta {
+			kunci = append(kunci, "peserta:"+p+":"+hari)
+		}
+	}
+	return
+}
+
+// ambilKunci menserialkan pemeriksaan bentrok dan penyimpanan jadwal karena Mongo dipakai tanpa transaksi.
+// Setiap kunci adalah dokumen dengan _id nama kunci, kunci yang masih dipegang proses lain membuat upsert gagal
+// dengan duplicate key. Kunci diambil berurutan supaya dua proses tidak saling menunggu, lepas wajib dipanggil.
+func ambilKunci(db *mongo.Database, kunci []string) (lepas func(), err error) {
+	sort.Strings(kunci)
+	pemilik := primitive.NewObjectID()
+	var dipegang []string
+	lepas = func() {
+		for _, k := range dipegang {
+			if _, err := db.Collection(KunciCollection).DeleteOne(context.Background(), bson.M{"_id": k, "pemilik": pemilik}); err != nil {
+				log.Printf("Error melepas kunci sidang %s: %v", k, err)
+			}
+		}
+	}
+	for i, k := range kunci {
+		if i > 0 && k == kunci[i-1] {
+			continue
+		}
+		now := time.Now()
+		_, err = db.Collection(KunciCollection).UpdateOne(context.Background(),
+			bson.M{"_id": k, "sampai": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"pemilik": pemilik, "sampai": now.Add(lamaKunci)}},
+			options.Update().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			lepas()
+			jenis, hari := strings.Index(k, ":"), strings.LastIndex(k, ":")
+			return nil, apperr.New(apperr.Conflict, "", "jadwal lain untuk "+k[:jenis]+" "+k[jenis+1:hari]+" pada "+k[hari+1:]+" sedang diproses, coba lagi")
+		}
+		if err != nil {
+			lepas()
+			return nil, apperr.Wrap(apperr.Database, "", err)
+		}
+		dipegang = append(dipegang, k)
+	}
+	return lepas, nil
+}
+
+// Simpan memeriksa ulang jadwal di bawah kunci ruang dan peserta lalu menyimpannya dan menandai pengajuan sebagai terjadwal.
+// Satu pengajuan hanya boleh punya satu jadwal aktif, dijaga index unik pengajuanid.
+func Simpan(db *mongo.Database, j model.JadwalSidang) (model.JadwalSidang, error) {
+	ensureIndexes(db)
+	lepas, err := ambilKunci(db, kunciJadwal(j))
+	if err != nil {
+		return j, err
+	}
+	defer lepas()
+	if err = Periksa(db, j); err != nil {
+		return j, err
+	}
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = j.CreatedAt
+	j.ID, err = atdb.InsertOneDoc(db, JadwalCollection, j)
+	if mongo.IsDuplicateKeyError(err) {
+		return j, apperr.New(apperr.Conflict, "", "pengajuan sudah punya jadwal sidang, gunakan pindah jadwal")
+	}
+	if err != nil {
+		return j, apperr.Wrap(apperr.Database, "", err)
+	}
+	if _, err = atdb.UpdateOneDoc(db, PengajuanCollection, bson.M{"_id": j.PengajuanID}, bson.M{"status": PengajuanTerjadwal}); err != nil {
+		return j, apperr.Wrap(apperr.Database, "", err)
+	}
+	return j, nil
+}
+
+// Pindah mengganti ruang dan waktu jadwal aktif, jadwal lama dicatat di riwayat
+func Pindah(db *mongo.Database, lama model.JadwalSidang, baru Rentang, ruang, alasan, oleh string) (model.JadwalSidang, error) {
+	j := lama
+	j.Mulai, j.Selesai, j.Ruang = baru.Mulai, baru.Selesai, ruang
+	lepas, err := ambilKunci(db, kunciJadwal(j))
+	if err != nil {
+		return lama, err
+	}
+	defer lepas()
+	if err := Periksa(db, j); err != nil {
+		return lama, err
+	}
+	j.UpdatedAt = time.Now()
+	j.Riwayat = append(j.Riwayat, model.RiwayatSidang{
+		Aksi: "pindah", Ruang: lama.Ruang, Mulai: lama.Mulai, Selesai: lama.Selesai, Alasan: alasan, Oleh: oleh, Waktu: j.UpdatedAt,
+	})
+	_, err = atdb.UpdateOneDoc(db, JadwalCollection, bson.M{"_id": j.ID}, bson.M{
+		"ruang": j.Ruang, "mulai": j.Mulai, "selesai": j.Selesai, "riwayat": j.Riwayat, "updatedAt": j.UpdatedAt,
+	})
+	if err != nil {
+		return lama, apperr.Wrap(apperr.Database, "", err)
+	}
+	return j, nil
+}
+
+// Batal membatalkan jadwal aktif dan mengembalikan pengajuan ke pending supaya bisa dijadwalkan ulang
+func Batal(db *mongo.Database, j model.JadwalSidang, alasan, oleh string) (model.JadwalSidang, error) {
+	j.Status = model.SidangDibatalkan
+	j.Alasan = alasan
+	j.UpdatedAt = time.Now()
+	j.Riwayat = append(j.Riwayat, model.RiwayatSidang{
+		Aksi: "batal", Ruang: j.Ruang, Mulai: j.Mulai, Selesai: j.Selesai, Alasan: alasan, Oleh: oleh, Waktu: j.UpdatedAt,
+	})
+	_, err := atdb.UpdateOneDoc(db, JadwalCollection, bson.M{"_id": j.ID}, bson.M{
+		"status": j.Status, "alasan": j.Alasan, "riwayat": j.Riwayat, "updatedAt": j.UpdatedAt,
+	})
+	if err != nil {
+		return j, apperr.Wrap(apperr.Database, "", err)
+	}
+	if _, err = atdb.UpdateOneDoc(db, PengajuanCollection, bson.M{"_id": j.PengajuanID}, bson.M{"status": "pending"}); err != nil {
+		return j, apperr.Wrap(apperr.Database, "", err)
+	}
+	return j, nil
+}
+
+// Kalender membuat atau memperbarui event Google Calendar jadwal dan menyimpan id event.
+// Kegagalan kalender tidak membatalkan jadwal, error dikembalikan supaya bisa dilaporkan ke admin.
+func Kalender(db *mongo.Database, j *model.JadwalSidang) error {
+	event := gcallapi.SimpleEvent{
+		Summary:     "Sidang " + j.Name + " (" + j.NPM + ")",
+		Location:    j.Ruang,
+		Description: deskripsi(*j),
+		Date:        j.Mulai.In(wib).Format("2006-01-02"),
+		TimeStart:   j.Mulai.In(wib).Format("15:04:05"),
+		TimeEnd:     j.Selesai.In(wib).Format("15:04:05"),
+		Attendees:   emailPeserta(db, j.Peserta),
+	}
+	if j.CalendarEventID == "" {
+		created, err := gcallapi.HandlerCalendar(db, event)
+		if err != nil {
+			return err
+		}
+		j.CalendarEventID, j.CalendarLink = created.Id, created.HtmlLink
+	} else {
+		updated, err := gcallapi.UpdateCalendarEvent(db, j.CalendarEventID, event)
+		if err != nil {
+			return err
+		}
+		j.CalendarLink = updated.HtmlLink
+	}
+	_, err := atdb.UpdateOneDoc(db, JadwalCollection, bson.M{"_id": j.ID}, bson.M{"calendareventid": j.CalendarEventID, "calendarlink": j.CalendarLink})
+	return err
+}
+
+// HapusKalender menghapus event Google Calendar dari jadwal yang dibatalkan
+func HapusKalender(db *mongo.Database, j model.JadwalSidang) error {
+	if j.CalendarEventID == "" {
+		return nil
+	}
+	return gcallapi.DeleteCalendarEvent(db, j.CalendarEventID)
+}
+
+func emailPeserta(db *mongo.Database, peserta []string) (emails []string) {
+	users, err := atdb.GetAllDoc[[]model.Userdomyikado](db, "user", bson.M{"phonenumber": bson.M{"$in": peserta}})
+	if err != nil {
+		log.Printf("Error loading email peserta sidang: %v", err)
+		return
+	}
+	for _, u := range users {
+		if u.Email != "" {
+			emails = append(emails, u.Email)
+		}
+	}
+	return
+}
+
+func deskripsi(j model.JadwalSidang) string {
+	msg := "Mahasiswa: " + j.Name +
+		"\nNPM: " + j.NPM +
+		"\nPembimbing: " + j.Pembimbing.Name
+	for _, p := range j.Penguji {
+		msg += "\nPenguji: " + p.Name
+	}
+	return msg
+}
+
+// Notifikasi mengirim pesan WA ke mahasiswa, pembimbing dan semua penguji lewat outbox
+func Notifikasi(db *mongo.Database, j model.JadwalSidang, judul, keterangan string) {
+	msg := "*" + judul + "*\n" + deskripsi(j) +
+		"\nWaktu: " + rentangJadwal(j).Format() +
+		"\nRuang: " + j.Ruang
+	if keterangan != "" {
+		msg += "\n" + keterangan
+	}
+	if j.CalendarLink != "" && j.Status == model.SidangTerjadwal {
+		msg += "\nKalender: " + j.CalendarLink
+	}
+	for _, phone := range j.Peserta {
+		_, _, err := waoutbox.Send(db, &whatsauth.TextMessage{To: phone, IsGroup: false, Messages: msg})
+		if err != nil {
+			log.Printf("Error notifikasi sidang ke %s: %v", phone, err)
+		}
+	}
+}
+
+// GetJadwal mengambil satu jadwal sidang
+func GetJadwal(db *mongo.Database, id primitive.ObjectID) (model.JadwalSidang, error) {
+	j, err := atdb.GetOneDoc[model.JadwalSidang](db, JadwalCollection, bson.M{"_id": id})
+	if err != nil {
+		return j, apperr.Wrap(apperr.NotFound, "", err)
+	}
+	return j, nil
+}
@@ -0,0 +1,92 @@
+package sidang
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocroot/model"
+)
+
+func jam(h, m int) time.Time {
+	return time.Date(2099, 3, 2, h, m, 0, 0, wib)
+}
+
+func TestCariWaktu(t *testing.T) {
+	batas := Rentang{Mulai: jam(0, 0), Selesai: jam(23, 0)}
+	pembimbing := []Rentang{{Mulai: jam(8, 0), Selesai: jam(12, 0)}}
+	penguji := []Rentang{{Mulai: jam(9, 0), Selesai: jam(11, 0)}}
+	tests := []struct {
+		name       string
+		sibuk      []Rentang
+		ruangSibuk map[string][]Rentang
+		isLibur    func(time.Time) bool
+		want       Rentang
+		wantRuang  string
+		wantOK     bool
+	}{
+		{
+			name:      "awal irisan slot",
+			want:      Rentang{Mulai: jam(9, 0), Selesai: jam(10, 0)},
+			wantRuang: "R1", wantOK: true,
+		},
+		{
+			name:      "peserta sibuk digeser satu langkah",
+			sibuk:     []Rentang{{Mulai: jam(9, 0), Selesai: jam(9, 30)}},
+			want:      Rentang{Mulai: jam(9, 30), Selesai: jam(10, 30)},
+			wantRuang: "R1", wantOK: true,
+		},
+		{
+			name:       "ruang pertama dipakai",
+			ruangSibuk: map[string][]Rentang{"R1": {{Mulai: jam(8, 0), Selesai: jam(12, 0)}}},
+			want:       Rentang{Mulai: jam(9, 0), Selesai: jam(10, 0)},
+			wantRuang:  "R2", wantOK: true,
+		},
+		{
+			name:       "semua ruang penuh",
+			ruangSibuk: map[string][]Rentang{"R1": {{Mulai: jam(8, 0), Selesai: jam(12, 0)}}, "R2": {{Mulai: jam(9, 0), Selesai: jam(11, 0)}}},
+		},
+		{
+			name:    "hari libur",
+			isLibur: func(time.Time) bool { return true },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ruang, ok := cariWaktu([][]Rentang{pembimbing, penguji}, tt.sibuk, tt.ruangSibuk, []string{"R1", "R2"}, time.Hour, batas, tt.isLibur)
+			if ok != tt.wantOK || ruang != tt.wantRuang || !got.Mulai.Equal(tt.want.Mulai) || !got.Selesai.Equal(tt.want.Selesai) {
+				t.Fatalf("cariWaktu = %v %q %v, seharusnya %v %q %v", got, ruang, ok, tt.want, tt.wantRuang, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRentang(t *testing.T) {
+	a := Rentang{Mulai: jam(9, 0), Selesai: jam(10, 0)}
+	if a.Bentrok(Rentang{Mulai: jam(10, 0), Selesai: jam(11, 0)}) {
+		t.Fatal("rentang yang bersentuhan tidak boleh dianggap bentrok")
+	}
+	if !a.Bentrok(Rentang{Mulai: jam(9, 59), Selesai: jam(11, 0)}) {
+		t.Fatal("rentang beririsan harus bentrok")
+	}
+	if got := a.Format(); got != "Senin 02-03-2099 09:00-10:00 WIB" {
+		t.Fatalf("Format = %q", got)
+	}
+}
+
+func TestKunciJadwal(t *testing.T) {
+	j := model.JadwalSidang{Ruang: "R1", Peserta: []string{"62811", "62812"}}
+	j.Mulai, j.Selesai = jam(23, 0), jam(23, 0).Add(2*time.Hour)
+	want := []string{
+		"ruang:R1:2099-03-02", "peserta:62811:2099-03-02", "peserta:62812:2099-03-02",
+		"ruang:R1:2099-03-03", "peserta:62811:2099-03-03", "peserta:62812:2099-03-03",
+	}
+	if got := kunciJadwal(j); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("kunciJadwal = %v, seharusnya %v", got, want)
+	}
+	//jadwal yang berakhir tepat tengah malam tidak mengunci hari berikutnya
+	j.Mulai, j.Selesai = jam(22, 0), jam(22, 0).Add(2*time.Hour)
+	if got := tanggal(rentangJadwal(j)); len(got) != 1 {
+		t.Fatalf("tanggal = %v, seharusnya satu hari", got)
+	}
+}
@@ -0,0 +1,508 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/apperr"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ledger"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ItemCollection adalah katalog item toko poin
+	ItemCollection = "storeitem"
+	// PembelianCollection mencatat setiap pembelian dan refund
+	PembelianCollection = "storepembelian"
+	// KuotaCollection menghitung pembelian aktif per user per item untuk BatasPerUser
+	KuotaCollection = "storekuota"
+	// Source adalah sumber transaksi toko di poinledger
+	Source = "store"
+
+	// StokTakTerbatas adalah nilai Stok untuk item tanpa batas stok
+	StokTakTerbatas = -1
+)
+
+// itemBawaan adalah item yang dulu ditulis langsung di BuyBimbinganCode,
+// dibuat otomatis di katalog saat pertama kali dibutuhkan
+var itemBawaan = map[string]model.StoreItem{
+	model.StoreKodeBimbingan: {
+		Kode:        model.StoreKodeBimbingan,
+		Nama:        "Code Bimbingan",
+		Deskripsi:   "Code bimbingan sekali pakai",
+		Harga:       15,
+		Stok:        StokTakTerbatas,
+		Fulfillment: model.StoreKodeBimbingan,
+		IsActive:    true,
+		CreatedBy:   "sistem",
+	},
+}
+
+var indexOnce sync.Once
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(ItemCollection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "kode", Value: 1}}, Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating storeitem index: %v", err)
+		}
+		_, err = db.Collection(PembelianCollection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "phonenumber", Value: 1}, {Key: "itemid", Value: 1}},
+		})
+		if err != nil {
+			log.Printf("Error creating storepembelian index: %v", err)
+		}
+		_, err = db.Collection(KuotaCollection).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "phonenumber", Value: 1}, {Key: "itemid", Value: 1}}, Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Error creating storekuota index: %v", err)
+		}
+	})
+}
+
+// ItemBawaan mengambil item bawaan berdasarkan kode, item dibuat dulu jika belum ada di katalog
+func ItemBawaan(db *mongo.Database, kode string) (item model.StoreItem, err error) {
+	ensureIndexes(db)
+	awal, ok := itemBawaan[kode]
+	if !ok {
+		return item, apperr.New(apperr.NotFound, "", "item "+kode+" tidak ada di katalog")
+	}
+	awal.CreatedAt = time.Now()
+	_, err = db.Collection(ItemCollection).UpdateOne(context.Background(),
+		bson.M{"kode": kode}, bson.M{"$setOnInsert": awal}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return item, apperr.Wrap(apperr.Database, "", err)
+	}
+	item, err = atdb.GetOneDoc[model.StoreItem](db, ItemCollection, bson.M{"kode": kode})
+	if err != nil {
+		return item, apperr.Wrap(apperr.Database, "", err)
+	}
+	return item, nil
+}
+
+// SimpanItem menambah item baru jika id kosong, atau mengubah item yang sudah ada
+func SimpanItem(db *mongo.Database, id primitive.ObjectID, req model.StoreItemRequest, oleh string) (item model.StoreItem, err error) {
+	ensureIndexes(db)
+	req.Kode = strings.ToLower(strings.TrimSpace(req.Kode))
+	req.Nama = strings.TrimSpace(req.Nama)
+	if err = cekItem(req); err != nil {
+		return
+	}
+	item = model.StoreItem{
+		Kode:         req.Kode,
+		Nama:         req.Nama,
+		Deskripsi:    req.Deskripsi,
+		Harga:        req.Harga,
+		Stok:         req.Stok,
+		BatasPerUser: req.BatasPerUser,
+		Fulfillment:  req.Fulfillment,
+		DurasiDetik:  req.DurasiDetik,
+		IsActive:     req.IsActive,
+		CreatedBy:    oleh,
+		CreatedAt:    time.Now(),
+	}
+	if id.IsZero() {
+		item.ID, err = atdb.InsertOneDoc(db, ItemCollection, item)
+	} else {
+		var lama model.StoreItem
+		lama, err = atdb.GetOneDoc[model.StoreItem](db, ItemCollection, bson.M{"_id": id})
+		if err != nil {
+			return item, apperr.Wrap(apperr.NotFound, "", err)
+		}
+		item.ID, item.CreatedBy, item.CreatedAt = lama.ID, lama.CreatedBy, lama.CreatedAt
+		_, err = atdb.ReplaceOneDoc(db, ItemCollection, bson.M{"_id": id}, item)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return item, apperr.New(apperr.Conflict, "", "kode item "+req.Kode+" sudah dipakai")
+	}
+	if err != nil {
+		return item, apperr.Wrap(apperr.Database, "", err)
+	}
+	return item, nil
+}
+
+func cekItem(req model.StoreItemRequest) error {
+	switch {
+	case req.Kode == "" || req.Nama == "":
+		return apperr.New(apperr.BadRequest, "", "kode dan nama item harus diisi")
+	case req.Harga <= 0:
+		return apperr.New(apperr.BadRequest, "", "harga item harus lebih dari 0 poin")
+	case req.Stok < StokTakTerbatas:
+		return apperr.New(apperr.BadRequest, "", "stok tidak boleh negatif, gunakan -1 untuk stok tidak terbatas")
+	case req.BatasPerUser < 0:
+		return apperr.New(apperr.BadRequest, "", "batas pembelian per user tidak boleh negatif")
+	}
+	switch req.Fulfillment {
+	case model.StoreKodeBimbingan, model.StoreSertifikat:
+	case model.StoreKodeWaktu, model.StorePerpanjangDeadline:
+		if req.DurasiDetik <= 0 {
+			return apperr.New(apperr.BadRequest, "", "durasi_detik harus lebih dari 0 untuk "+req.Fulfillment)
+		}
+	default:
+		return apperr.New(apperr.BadRequest, "", "fulfillment tidak dikenal, gunakan kodebimbingan, kodewaktu, perpanjangdeadline atau sertifikat")
+	}
+	return nil
+}
+
+// Beli memotong pointevent user lalu memenuhi item.
+// Stok dan saldo dikurangi dengan update bersyarat, jika salah satu langkah gagal langkah sebelumnya dikembalikan.
+func Beli(db *mongo.Database, user model.Userdomyikado, item model.StoreItem, req model.StoreBeliRequest) (p model.StorePembelian, saldo int, err error) {
+	ensureIndexes(db)
+	if !item.IsActive {
+		return p, 0, apperr.New(apperr.PreconditionFailed, "", item.Nama+" sedang tidak dijual")
+	}
+	if item.Fulfillment == model.StoreSertifikat && strings.TrimSpace(req.Catatan) == "" {
+		return p, 0, apperr.New(apperr.BadRequest, "", "catatan berisi nama di sertifikat dan alamat pengiriman harus diisi")
+	}
+	var claim model.EventClaim
+	if item.Fulfillment == model.StorePerpanjangDeadline {
+		if claim, err = claimAktif(db, req.ClaimID, user.PhoneNumber); err != nil {
+			return
+		}
+	}
+
+	if err = ambilKuota(db, item, user.PhoneNumber); err != nil {
+		return
+	}
+	if err = ambilStok(db, item); err != nil {
+		kembalikanKuota(db, item.ID, user.PhoneNumber)
+		return
+	}
+	p = model.StorePembelian{
+		ID:          primitive.NewObjectID(),
+		ItemID:      item.ID,
+		ItemKode:    item.Kode,
+		ItemNama:    item.Nama,
+		Fulfillment: item.Fulfillment,
+		PhoneNumber: user.PhoneNumber,
+		Name:        user.Name,
+		Harga:       item.Harga,
+		DurasiDetik: item.DurasiDetik,
+		Catatan:     strings.TrimSpace(req.Catatan),
+		CreatedAt:   time.Now(),
+	}
+	usr, _, err := ledger.Post(db, bson.M{"_id": user.ID}, ledger.Posting{
+		Account:        model.AkunPointEvent,
+		Amount:         -float64(item.Harga),
+		Source:         Source,
+		ReferenceID:    p.ID.Hex(),
+		IdempotencyKey: Source + ":" + p.ID.Hex(),
+		Keterangan:     "Beli " + item.Nama,
+		WajibCukup:     true,
+	})
+	if err != nil {
+		kembalikanStok(db, item.ID)
+		kembalikanKuota(db, item.ID, user.PhoneNumber)
+		if err == ledger.ErrSaldoTidakCukup {
+			return p, user.PointEvent, apperr.New(apperr.PreconditionFailed, "",
+				"butuh "+strconv.Itoa(item.Harga)+" poin untuk membeli "+item.Nama+", poin Anda "+strconv.Itoa(user.PointEvent))
+		}
+		return p, user.PointEvent, apperr.Wrap(apperr.Database, "", err)
+	}
+	saldo = usr.PointEvent
+
+	if err = penuhi(db, &p, item, claim); err == nil {
+		_, err = atdb.InsertOneDoc(db, PembelianCollection, p)
+		if err != nil {
+			if errBatal := batalPemenuhan(db, p); errBatal != nil {
+				// hasil sudah terlanjur dipegang user, poin, stok dan kuota tidak dikembalikan supaya item tidak didapat gratis
+				log.Printf("Error menarik hasil pembelian %s (%s) milik %s: %v", p.ID.Hex(), p.Hasil, p.PhoneNumber, errBatal)
+				return p, saldo, apperr.Wrap(apperr.Database, "", err)
+			}
+			err = apperr.Wrap(apperr.Database, "", err)
+		}
+	}
+	if err != nil {
+		kembalikanStok(db, item.ID)
+		kembalikanKuota(db, item.ID, user.PhoneNumber)
+		if usr, errRefund := kembalikanPoin(db, p, "Pengembalian poin, "+item.Nama+" gagal dipenuhi"); errRefund == nil {
+			saldo = usr.PointEvent
+		}
+		return p, saldo, apperr.Ensure(err, apperr.Database, "")
+	}
+	return p, saldo, nil
+}
+
+// claimAktif memastikan claim milik user masih bisa dikerjakan sehingga deadline-nya layak diperpanjang
+func claimAktif(db *mongo.Database, id primitive.ObjectID, phonenumber string) (claim model.EventClaim, err error) {
+	if id.IsZero() {
+		return claim, apperr.New(apperr.BadRequest, "", "claim_id event yang diperpanjang harus diisi")
+	}
+	claim, err = atdb.GetOneDoc[model.EventClaim](db, "eventclaims", bson.M{
+		"_id": id, "userphone": phonenumber,
+		"status": bson.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}},
+	})
+	if err != nil {
+		return claim, apperr.New(apperr.NotFound, "", "claim event aktif milik Anda tidak ditemukan")
+	}
+	if time.Now().After(claim.Deadline) {
+		return claim, apperr.New(apperr.Expired, "", "deadline claim sudah lewat")
+	}
+	return claim, nil
+}
+
+// ambilStok mengurangi stok satu buah, ditolak atomik jika stok sudah habis
+func ambilStok(db *mongo.Database, item model.StoreItem) error {
+	if item.Stok == StokTakTerbatas {
+		return nil
+	}
+	res, err := db.Collection(ItemCollection).UpdateOne(context.Background(),
+		bson.M{"_id": item.ID, "stok": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"stok": -1}})
+	if err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	if res.MatchedCount == 0 {
+		return apperr.New(apperr.Conflict, "", "stok "+item.Nama+" habis")
+	}
+	return nil
+}
+
+// ambilKuota menaikkan jumlah pembelian user untuk item secara atomik selama masih di bawah BatasPerUser.
+// Penghitung yang belum ada diisi dulu dari pembelian lama yang belum direfund.
+func ambilKuota(db *mongo.Database, item model.StoreItem, phonenumber string) error {
+	if item.BatasPerUser <= 0 {
+		return nil
+	}
+	filter := bson.M{"phonenumber": phonenumber, "itemid": item.ID}
+	if _, err := atdb.GetOneDoc[bson.M](db, KuotaCollection, filter); err == mongo.ErrNoDocuments {
+		n, err := atdb.GetCountDoc(db, PembelianCollection, bson.M{
+			"itemid": item.ID, "phonenumber": phonenumber, "status": bson.M{"$ne": model.PembelianRefund},
+		})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		_, err = db.Collection(KuotaCollection).UpdateOne(context.Background(), filter,
+			bson.M{"$setOnInsert": bson.M{"jumlah": n}}, options.Update().SetUpsert(true))
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+	} else if err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	res, err := db.Collection(KuotaCollection).UpdateOne(context.Background(),
+		bson.M{"phonenumber": phonenumber, "itemid": item.ID, "jumlah": bson.M{"$lt": item.BatasPerUser}},
+		bson.M{"$inc": bson.M{"jumlah": 1}})
+	if err != nil {
+		return apperr.Wrap(apperr.Database, "", err)
+	}
+	if res.MatchedCount == 0 {
+		return apperr.New(apperr.Conflict, "", "batas pembelian "+item.Nama+" adalah "+strconv.Itoa(item.BatasPerUser)+" kali per user")
+	}
+	return nil
+}
+
+// kembalikanKuota mengurangi jumlah pembelian user untuk item, dipanggil saat pembelian batal atau direfund
+func kembalikanKuota(db *mongo.Database, itemID primitive.ObjectID, phonenumber string) {
+	_, err := db.Collection(KuotaCollection).UpdateOne(context.Background(),
+		bson.M{"phonenumber": phonenumber, "itemid": itemID, "jumlah": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"jumlah": -1}})
+	if err != nil {
+		log.Printf("Error mengembalikan kuota item %s milik %s: %v", itemID.Hex(), phonenumber, err)
+	}
+}
+
+// kembalikanStok menambah stok satu buah, item tanpa batas stok tidak berubah
+func kembalikanStok(db *mongo.Database, itemID primitive.ObjectID) {
+	_, err := db.Collection(ItemCollection).UpdateOne(context.Background(),
+		bson.M{"_id": itemID, "stok": bson.M{"$gte": 0}}, bson.M{"$inc": bson.M{"stok": 1}})
+	if err != nil {
+		log.Printf("Error mengembalikan stok item %s: %v", itemID.Hex(), err)
+	}
+}
+
+// kembalikanPoin mengkredit harga pembelian, idempotency key per pembelian mencegah refund ganda
+func kembalikanPoin(db *mongo.Database, p model.StorePembelian, keterangan string) (model.Userdomyikado, error) {
+	usr, _, err := ledger.Post(db, bson.M{"phonenumber": p.PhoneNumber}, ledger.Posting{
+		Account:        model.AkunPointEvent,
+		Amount:         float64(p.Harga),
+		Source:         Source,
+		ReferenceID:    p.ID.Hex(),
+		IdempotencyKey: Source + ":refund:" + p.ID.Hex(),
+		Keterangan:     keterangan,
+	})
+	if err != nil && err != ledger.ErrDuplicate {
+		log.Printf("Error mengembalikan poin pembelian %s: %v", p.ID.Hex(), err)
+	}
+	return usr, err
+}
+
+// penuhi membuat kode atau memperpanjang deadline sesuai fulfillment item
+func penuhi(db *mongo.Database, p *model.StorePembelian, item model.StoreItem, claim model.EventClaim) error {
+	p.Status = model.PembelianBerhasil
+	switch item.Fulfillment {
+	case model.StoreKodeBimbingan:
+		p.Hasil = KodeBimbingan()
+		_, err := atdb.InsertOneDoc(db, "eventcodes", model.EventCode{
+			Code:      p.Hasil,
+			CreatedBy: p.PhoneNumber,
+			CreatedAt: p.CreatedAt,
+		})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+	case model.StoreKodeWaktu:
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return apperr.Wrap(apperr.Internal, "", err)
+		}
+		p.Hasil = "GLR" + hex.EncodeToString(b)
+		p.ExpiresAt = p.CreatedAt.Add(time.Duration(item.DurasiDetik) * time.Second)
+		_, err := atdb.InsertOneDoc(db, "eventcodetime", model.EventCodeTime{
+			Code:        p.Hasil,
+			CreatedBy:   p.PhoneNumber,
+			CreatedAt:   p.CreatedAt,
+			ExpiresAt:   p.ExpiresAt,
+			DurationSec: item.DurasiDetik,
+			IsActive:    true,
+		})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+	case model.StorePerpanjangDeadline:
+		// deadline lama ikut di filter supaya perpanjangan bersamaan tidak saling menimpa
+		baru := claim.Deadline.Add(time.Duration(item.DurasiDetik) * time.Second)
+		res, err := db.Collection("eventclaims").UpdateOne(context.Background(),
+			bson.M{"_id": claim.ID, "deadline": claim.Deadline, "status": bson.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}}},
+			bson.M{"$set": bson.M{"deadline": baru}})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if res.MatchedCount == 0 {
+			return apperr.New(apperr.Conflict, "", "claim berubah saat diperpanjang, silakan coba lagi")
+		}
+		p.ClaimID = claim.ID
+		p.ExpiresAt = baru
+		p.Hasil = "deadline " + baru.Format("2006-01-02 15:04:05")
+	case model.StoreSertifikat:
+		p.Status = model.PembelianDiproses
+	default:
+		return apperr.New(apperr.Internal, "", "fulfillment "+item.Fulfillment+" tidak dikenal")
+	}
+	return nil
+}
+
+// batalPemenuhan menarik kembali hasil pembelian, ditolak jika kode atau perpanjangan sudah terpakai
+func batalPemenuhan(db *mongo.Database, p model.StorePembelian) error {
+	switch p.Fulfillment {
+	case model.StoreKodeBimbingan:
+		res, err := atdb.DeleteOneDoc(db, "eventcodes", bson.M{"code": p.Hasil, "isused": false})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if res.DeletedCount == 0 {
+			return apperr.New(apperr.Conflict, "", "code "+p.Hasil+" sudah dipakai")
+		}
+	case model.StoreKodeWaktu:
+		n, err := atdb.GetCountDoc(db, "eventusercodetime", bson.M{"code": p.Hasil})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if n > 0 {
+			return apperr.New(apperr.Conflict, "", "code "+p.Hasil+" sudah dipakai")
+		}
+		_, err = db.Collection("eventcodetime").UpdateOne(context.Background(), bson.M{"code": p.Hasil}, bson.M{"$set": bson.M{"isactive": false}})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+	case model.StorePerpanjangDeadline:
+		res, err := db.Collection("eventclaims").UpdateOne(context.Background(),
+			bson.M{"_id": p.ClaimID, "deadline": p.ExpiresAt, "status": bson.M{"$in": []string{model.ClaimClaimed, model.ClaimRevision}}},
+			bson.M{"$set": bson.M{"deadline": p.ExpiresAt.Add(-time.Duration(p.DurasiDetik) * time.Second)}})
+		if err != nil {
+			return apperr.Wrap(apperr.Database, "", err)
+		}
+		if res.MatchedCount == 0 {
+			return apperr.New(apperr.Conflict, "", "perpanjangan deadline sudah terpakai")
+		}
+	}
+	return nil
+}
+
+// Refund mengembalikan poin pembelian yang belum selesai, hasil pembelian ditarik lebih dulu.
+// Status diubah dengan filter status lama sehingga refund bersamaan hanya berhasil sekali.
+func Refund(db *mongo.Database, id primitive.ObjectID, alasan, oleh string) (p model.StorePembelian, saldo int, err error) {
+	if strings.TrimSpace(alasan) == "" {
+		return p, 0, apperr.New(apperr.BadRequest, "", "alasan refund harus diisi")
+	}
+	p, err = atdb.GetOneDoc[model.StorePembelian](db, PembelianCollection, bson.M{"_id": id})
+	if err != nil {
+		return p, 0, apperr.Wrap(apperr.NotFound, "", err)
+	}
+	bisaRefund := []string{model.PembelianBerhasil, model.PembelianDiproses}
+	if p.Status != model.PembelianBerhasil && p.Status != model.PembelianDiproses {
+		return p, 0, apperr.New(apperr.Conflict, "", "pembelian berstatus "+p.Status+" tidak bisa direfund")
+	}
+	if err = batalPemenuhan(db, p); err != nil {
+		return
+	}
+	p.Status = model.PembelianRefund
+	p.RefundAlasan = strings.TrimSpace(alasan)
+	p.RefundBy = oleh
+	p.RefundAt = time.Now()
+	res, err := db.Collection(PembelianCollection).UpdateOne(context.Background(),
+		bson.M{"_id": id, "status": bson.M{"$in": bisaRefund}},
+		bson.M{"$set": bson.M{"status": p.Status, "refundalasan": p.RefundAlasan, "refundby": p.RefundBy, "refundat": p.RefundAt}})
+	if err != nil {
+		return p, 0, apperr.Wrap(apperr.Database, "", err)
+	}
+	if res.MatchedCount == 0 {
+		return p, 0, apperr.New(apperr.Conflict, "", "pembelian sudah direfund")
+	}
+	kembalikanStok(db, p.ItemID)
+	kembalikanKuota(db, p.ItemID, p.PhoneNumber)
+	usr, err := kembalikanPoin(db, p, "Refund "+p.ItemNama+": "+p.RefundAlasan)
+	if err != nil && err != ledger.ErrDuplicate {
+		return p, 0, apperr.Wrap(apperr.Database, "", err)
+	}
+	return p, usr.PointEvent, nil
+}
+
+// Selesai menandai pembelian yang dipenuhi manual, misal sertifikat yang sudah dikirim
+func Selesai(db *mongo.Database, id primitive.ObjectID, catatan string) (p model.StorePembelian, err error) {
+	set := bson.M{"status": model.PembelianSelesai}
+	if catatan = strings.TrimSpace(catatan); catatan != "" {
+		set["hasil"] = catatan
+	}
+	res, err := db.Collection(PembelianCollection).UpdateOne(context.Background(),
+		bson.M{"_id": id, "status": model.PembelianDiproses}, bson.M{"$set": set})
+	if err != nil {
+		return p, apperr.Wrap(apperr.Database, "", err)
+	}
+	if res.MatchedCount == 0 {
+		return p, apperr.New(apperr.NotFound, "", "pembelian yang sedang diproses tidak ditemukan")
+	}
+	p, err = atdb.GetOneDoc[model.StorePembelian](db, PembelianCollection, bson.M{"_id": id})
+	if err != nil {
+		return p, apperr.Wrap(apperr.Database, "", err)
+	}
+	return p, nil
+}
+
+// KodeBimbingan membuat code bimbingan dengan prefix GLR-
+func KodeBimbingan() string {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	code := make([]byte, 6)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			log.Printf("Error generate code bimbingan: %v", err)
+			n = big.NewInt(int64(time.Now().UnixNano() % int64(len(charset))))
+		}
+		code[i] = charset[n.Int64()]
+	}
+	return "GLR-" + string(code)
+}
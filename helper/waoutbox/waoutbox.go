@@ -0,0 +1,225 @@
+package waoutbox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/whatsauth"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan semua pesan teks WA keluar beserta status pengirimannya
+const Collection = "waoutbox"
+
+// RateCollection menyimpan jumlah kiriman per tujuan per jendela RateWindow
+const RateCollection = "waoutboxrate"
+
+const (
+	// MaxAttempts adalah jumlah percobaan sebelum pesan ditandai failed
+	MaxAttempts = 6
+	// BaseBackoff adalah jeda percobaan ulang pertama, berikutnya dikali dua
+	BaseBackoff = 30 * time.Second
+	MaxBackoff  = time.Hour
+
+	// RateWindow dan RateLimit membatasi jumlah pesan ke satu grup atau nomor yang sama per jendela waktu
+	RateWindow = time.Minute
+	RateLimit  = 5
+
+	// claimLease menahan pesan yang sedang dikirim supaya tidak diambil worker lain
+	claimLease = 2 * time.Minute
+)
+
+var (
+	ErrRateLimited = errors.New("batas kirim ke tujuan ini tercapai, pesan menunggu di antrian")
+
+	indexOnce sync.Once
+)
+
+func ensureIndexes(db *mongo.Database) {
+	indexOnce.Do(func() {
+		_, err := db.Collection(Collection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextattemptat", Value: 1}}},
+		})
+		if err != nil {
+			log.Printf("Error creating waoutbox indexes: %v", err)
+		}
+		_, err = db.Collection(RateCollection).Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "to", Value: 1}, {Key: "window", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expireat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		})
+		if err != nil {
+			log.Printf("Error creating waoutboxrate indexes: %v", err)
+		}
+	})
+}
+
+// Send menyimpan pesan ke outbox lalu langsung mencoba mengirimnya.
+// Bentuk kembalian sama dengan atapi.PostStructWithToken supaya pemanggil lama tidak berubah.
+// Error hanya dikembalikan jika pesan gagal disimpan, pengiriman yang gagal atau tertahan rate limit
+// dicoba ulang oleh worker dengan status 202.
+func Send(db *mongo.Database, dt *whatsauth.TextMessage) (int, model.Response, error) {
+	ensureIndexes(db)
+	now := time.Now()
+	msg := model.WAOutbox{
+		To:            dt.To,
+		IsGroup:       dt.IsGroup,
+		Messages:      dt.Messages,
+		Status:        model.OutboxPending,
+		NextAttemptAt: now.Add(claimLease), //dipegang pengiriman langsung di bawah
+		CreatedAt:     now,
+	}
+	res, err := db.Collection(Collection).InsertOne(context.Background(), msg)
+	if err != nil {
+		return 0, model.Response{}, err
+	}
+	msg.ID, _ = res.InsertedID.(primitive.ObjectID)
+	status, resp, err := deliver(db, msg)
+	if err != nil {
+		return http.StatusAccepted, model.Response{Status: "Queued", Info: err.Error(), Response: msg.ID.Hex()}, nil
+	}
+	return status, resp, nil
+}
+
+// ProcessDue dipanggil worker cron, mengirim pesan yang sudah waktunya dicoba sampai limit pesan
+func ProcessDue(db *mongo.Database, limit int) (sent, retried, failed int) {
+	ensureIndexes(db)
+	for i := 0; i < limit; i++ {
+		msg, err := claimNext(db)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log.Printf("Error claiming waoutbox message: %v", err)
+			}
+			return
+		}
+		if _, _, err = deliver(db, msg); err == nil {
+			sent++
+		} else if msg.Attempts+1 >= MaxAttempts && err != ErrRateLimited {
+			failed++
+		} else {
+			retried++
+		}
+	}
+	return
+}
+
+// Resend mengembalikan pesan failed ke antrian dengan hitungan percobaan dari nol lalu langsung mencoba mengirim
+func Resend(db *mongo.Database, id primitive.ObjectID) (model.WAOutbox, error) {
+	var msg model.WAOutbox
+	err := db.Collection(Collection).FindOneAndUpdate(context.Background(),
+		bson.M{"_id": id, "status": model.OutboxFailed},
+		bson.M{"$set": bson.M{"status": model.OutboxPending, "attempts": 0, "nextattemptat": time.Now().Add(claimLease)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&msg)
+	if err != nil {
+		return msg, err
+	}
+	//hasil pengiriman tercatat di dokumen, gagal lagi berarti kembali dijadwalkan ulang oleh worker
+	deliver(db, msg)
+	err = db.Collection(Collection).FindOne(context.Background(), bson.M{"_id": id}).Decode(&msg)
+	return msg, err
+}
+
+// GetFailed mengembalikan pesan yang gagal terkirim, terbaru di atas
+func GetFailed(db *mongo.Database, limit int64) (msgs []model.WAOutbox, err error) {
+	cur, err := db.Collection(Collection).Find(context.Background(), bson.M{"status": model.OutboxFailed},
+		options.Find().SetSort(bson.M{"failedAt": -1}).SetLimit(limit))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.Background())
+	err = cur.All(context.Background(), &msgs)
+	return
+}
+
+// claimNext mengambil satu pesan pending yang sudah jatuh tempo dan menahannya selama claimLease
+func claimNext(db *mongo.Database) (msg model.WAOutbox, err error) {
+	now := time.Now()
+	err = db.Collection(Collection).FindOneAndUpdate(context.Background(),
+		bson.M{"status": model.OutboxPending, "nextattemptat": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"nextattemptat": now.Add(claimLease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"nextattemptat": 1}).SetReturnDocument(options.After)).Decode(&msg)
+	return
+}
+
+// deliver mengirim pesan yang sudah dipegang lalu mencatat hasilnya.
+// Pesan yang tertahan rate limit dijadwalkan ulang ke jendela berikutnya tanpa menambah hitungan percobaan.
+func deliver(db *mongo.Database, msg model.WAOutbox) (int, model.Response, error) {
+	coll := db.Collection(Collection)
+	jendela := time.Now().Truncate(RateWindow)
+	ok, err := pesanSlot(db, msg.To, jendela)
+	if err != nil {
+		return 0, model.Response{}, err
+	}
+	if !ok {
+		coll.UpdateOne(context.Background(), bson.M{"_id": msg.ID}, bson.M{"$set": bson.M{"nextattemptat": jendela.Add(RateWindow)}})
+		return 0, model.Response{}, ErrRateLimited
+	}
+
+	dt := &whatsauth.TextMessage{To: msg.To, IsGroup: msg.IsGroup, Messages: msg.Messages}
+	status, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	if err == nil && status >= http.StatusMultipleChoices {
+		err = errors.New("status " + strconv.Itoa(status) + " dari API WA: " + resp.Response)
+	}
+	if err == nil {
+		coll.UpdateOne(context.Background(), bson.M{"_id": msg.ID}, bson.M{
+			"$set": bson.M{"status": model.OutboxSent, "sentAt": time.Now(), "response": resp},
+			"$inc": bson.M{"attempts": 1},
+		})
+		return status, resp, nil
+	}
+
+	attempts := msg.Attempts + 1
+	set := bson.M{"lasterror": err.Error(), "response": resp}
+	if attempts >= MaxAttempts {
+		set["status"] = model.OutboxFailed
+		set["failedAt"] = time.Now()
+	} else {
+		set["nextattemptat"] = time.Now().Add(Backoff(attempts))
+	}
+	coll.UpdateOne(context.Background(), bson.M{"_id": msg.ID}, bson.M{"$set": set, "$inc": bson.M{"attempts": 1}})
+	return status, resp, err
+}
+
+// pesanSlot menambah hitungan kiriman tujuan di jendela ini hanya jika masih di bawah RateLimit.
+// Filter count < RateLimit dan $inc berjalan atomik, jadi pengirim yang bersamaan tidak bisa melewati batas.
+// Jendela yang penuh tidak cocok dengan filter sehingga upsert bentrok dengan index unik, artinya slot habis.
+// Slot tetap terpakai walau API WA gagal karena request sudah dikirim.
+func pesanSlot(db *mongo.Database, to string, jendela time.Time) (bool, error) {
+	err := db.Collection(RateCollection).FindOneAndUpdate(context.Background(),
+		bson.M{"to": to, "window": jendela, "count": bson.M{"$lt": RateLimit}},
+		bson.M{"$inc": bson.M{"count": 1}, "$setOnInsert": bson.M{"expireat": jendela.Add(2 * RateWindow)}},
+		options.FindOneAndUpdate().SetUpsert(true)).Err()
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err == mongo.ErrNoDocuments {
+		//dokumen baru dibuat upsert, FindOneAndUpdate mengembalikan dokumen sebelum diubah
+		return true, nil
+	}
+	return err == nil, err
+}
+
+// Backoff menghitung jeda sebelum percobaan berikutnya: 30 detik, 1 menit, 2 menit dan seterusnya sampai MaxBackoff
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := BaseBackoff
+	for i := 1; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+	return d
+}
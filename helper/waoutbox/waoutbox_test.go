@@ -0,0 +1,53 @@
+package waoutbox
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocroot/helper/mongotest"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var db *mongo.Database
+
+func TestMain(m *testing.M) {
+	mongotest.Main(m, "waoutboxtest", func(d *mongo.Database) { db = d })
+}
+
+// TestPesanSlot: pengirim yang bersamaan tidak bisa melewati RateLimit per tujuan per jendela
+func TestPesanSlot(t *testing.T) {
+	ensureIndexes(db)
+	jendela := time.Now().Truncate(RateWindow)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	dapat := 0
+	for i := 0; i < RateLimit*3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := pesanSlot(db, "628123", jendela)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				mu.Lock()
+				dapat++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if dapat != RateLimit {
+		t.Fatalf("slot terpakai %d, seharusnya %d", dapat, RateLimit)
+	}
+	//tujuan lain dan jendela berikutnya punya hitungan sendiri
+	for _, tt := range []struct {
+		to      string
+		jendela time.Time
+	}{{"628124", jendela}, {"628123", jendela.Add(RateWindow)}} {
+		if ok, err := pesanSlot(db, tt.to, tt.jendela); err != nil || !ok {
+			t.Errorf("pesanSlot(%s, %v) = %v %v, seharusnya dapat slot", tt.to, tt.jendela, ok, err)
+		}
+	}
+}
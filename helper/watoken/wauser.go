@@ -2,6 +2,7 @@ package watoken
 
 import (
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/apperr"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/model"
@@ -17,7 +18,7 @@ func ParseToken(w http.ResponseWriter, r *http.Request) (docuser model.Userdomyi
 		respn.Info = at.GetSecretFromHeader(r)
 		respn.Location = "Decode Token Error"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.TokenInvalid, respn))
 		return
 	}
 
@@ -29,7 +30,7 @@ func ParseToken(w http.ResponseWriter, r *http.Request) (docuser model.Userdomyi
 		respn.Info = payload.Id
 		respn.Location = "Get User Data"
 		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, r, apperr.FromResponse(apperr.UserNotFound, respn))
 		return
 	}
 	return docuser, err
@@ -0,0 +1,136 @@
+package whatsauth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CommandPrefix menandai pesan masuk sebagai perintah, contoh: !poin
+const CommandPrefix = "!"
+
+// CommandHandler mengembalikan teks balasan, string kosong berarti handler sudah mengirim balasan sendiri
+type CommandHandler func(msg IteungMessage, args []string, db *mongo.Database) (string, error)
+
+// Permission menentukan apakah pengirim boleh menjalankan perintah, nil berarti semua pengirim boleh
+type Permission func(phonenumber string, db *mongo.Database) bool
+
+type Command struct {
+	Name        string // tanpa prefix, huruf kecil
+	Args        string // contoh argumen untuk !help, boleh kosong
+	Description string
+	Permission  Permission
+	Handler     CommandHandler
+}
+
+var (
+	commandsMu sync.RWMutex
+	commands   = make(map[string]Command)
+)
+
+// RegisterCommand mendaftarkan perintah, panic jika nama sudah dipakai supaya bentrok ketahuan saat start
+func RegisterCommand(cmd Command) {
+	name := strings.ToLower(cmd.Name)
+	if name == "" || cmd.Handler == nil {
+		panic("whatsauth: perintah tanpa nama atau handler")
+	}
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+	if _, ok := commands[name]; ok {
+		panic("whatsauth: perintah " + name + " sudah terdaftar")
+	}
+	cmd.Name = name
+	commands[name] = cmd
+}
+
+// Commands mengembalikan perintah yang boleh dipakai pengirim, urut nama
+func Commands(phonenumber string, db *mongo.Database) (cmds []Command) {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+	for _, cmd := range commands {
+		if cmd.Permission == nil || cmd.Permission(phonenumber, db) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return
+}
+
+// ParseCommand memisahkan nama perintah dan argumennya, ok false jika pesan bukan perintah
+func ParseCommand(message string) (name string, args []string, ok bool) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, CommandPrefix) {
+		return
+	}
+	fields := strings.Fields(strings.TrimPrefix(message, CommandPrefix))
+	if len(fields) == 0 {
+		return
+	}
+	return strings.ToLower(fields[0]), fields[1:], true
+}
+
+// DispatchCommand menjalankan perintah di pesan masuk.
+// isCommand false jika pesan bukan perintah sehingga pemanggil bisa memakai balasan biasa.
+func DispatchCommand(msg IteungMessage, db *mongo.Database) (reply string, isCommand bool) {
+	name, args, ok := ParseCommand(msg.Message)
+	if !ok {
+		return
+	}
+	commandsMu.RLock()
+	cmd, found := commands[name]
+	commandsMu.RUnlock()
+	if !found {
+		return "Perintah *" + CommandPrefix + name + "* tidak dikenal.\n\n" + HelpText(msg.Phone_number, db), true
+	}
+	if cmd.Permission != nil && !cmd.Permission(msg.Phone_number, db) {
+		return "Maaf, perintah *" + CommandPrefix + name + "* tidak bisa kamu gunakan.", true
+	}
+	reply, err := cmd.Handler(msg, args, db)
+	if err != nil {
+		return "Gagal menjalankan " + CommandPrefix + name + ": " + err.Error(), true
+	}
+	return reply, true
+}
+
+// HelpText menyusun daftar perintah sesuai izin pengirim
+func HelpText(phonenumber string, db *mongo.Database) string {
+	var sb strings.Builder
+	sb.WriteString("*Daftar Perintah*\n")
+	for _, cmd := range Commands(phonenumber, db) {
+		sb.WriteString(CommandPrefix + cmd.Name)
+		if cmd.Args != "" {
+			sb.WriteString(" " + cmd.Args)
+		}
+		sb.WriteString(" - " + cmd.Description + "\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Izin hanya mengizinkan user yang role global-nya memiliki permission perm.
+// Perintah WA tidak membawa enroll atau proyek sehingga role terbatas tidak dihitung.
+func Izin(perm rbac.Permission) Permission {
+	return func(phonenumber string, db *mongo.Database) bool {
+		return rbac.Can(db, phonenumber, perm, "")
+	}
+}
+
+// Terdaftar hanya mengizinkan nomor yang sudah terdaftar di collection user
+func Terdaftar(phonenumber string, db *mongo.Database) bool {
+	_, err := atdb.GetOneDoc[bson.M](db, "user", bson.M{"phonenumber": phonenumber})
+	return err == nil
+}
+
+func init() {
+	RegisterCommand(Command{
+		Name:        "help",
+		Description: "menampilkan daftar perintah",
+		Handler: func(msg IteungMessage, args []string, db *mongo.Database) (string, error) {
+			return HelpText(msg.Phone_number, db), nil
+		},
+	})
+}
@@ -63,25 +63,29 @@ func HandlerQRLogin(msg IteungMessage, WAKeyword string, WAPhoneNumber string, d
 }
 
 func HandlerIncomingMessage(msg IteungMessage, WAPhoneNumber string, db *mongo.Database, WAAPIMessage string) (resp Response, err error) {
+	if (msg.Phone_number == "628112000279") || (msg.Phone_number == "6283131895000") { //ignore pesan datang dari iteung
+		return
+	}
+	reply, isCommand := DispatchCommand(msg, db) //pesan diawali ! dijalankan sebagai perintah
+	if !isCommand {
+		reply = GetRandomReplyFromMongo(msg, db)
+	}
+	if reply == "" { //handler perintah sudah mengirim balasan sendiri
+		return
+	}
 	dt := &TextMessage{
 		To:       msg.Chat_number,
 		IsGroup:  false,
-		Messages: GetRandomReplyFromMongo(msg, db),
+		Messages: reply,
 	}
 	if msg.Chat_server == "g.us" { //jika pesan datang dari group maka balas ke group
 		dt.IsGroup = true
 	}
-	if (msg.Phone_number != "628112000279") && (msg.Phone_number != "6283131895000") { //ignore pesan datang dari iteung
-		var profile Profile
-		profile, err = GetAppProfile(WAPhoneNumber, db)
-		if err != nil {
-			return
-		}
-		_, resp, err = atapi.PostStructWithToken[Response]("Token", profile.Token, dt, WAAPIMessage)
-		if err != nil {
-			return
-		}
+	profile, err := GetAppProfile(WAPhoneNumber, db)
+	if err != nil {
+		return
 	}
+	_, resp, err = atapi.PostStructWithToken[Response]("Token", profile.Token, dt, WAAPIMessage)
 	return
 }
 
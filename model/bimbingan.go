@@ -32,6 +32,32 @@ type BimbinganWeeklyStatus struct {
 	UpdatedBy   string             `bson:"updatedby,omitempty" json:"updatedby,omitempty"`
 }
 
+// PeriodeAkademik menentukan batas minggu yang dipakai bersama oleh bimbingan, Strava, Pomokit dan skor kelas.
+// Hanya ada satu dokumen aktif di koleksi periodeakademik.
+type PeriodeAkademik struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Nama          string             `bson:"nama" json:"nama"`
+	SemesterStart string             `bson:"semesterstart" json:"semesterstart"` // YYYY-MM-DD, minggu ke-1 adalah minggu yang memuat tanggal ini
+	TimeZone      string             `bson:"timezone" json:"timezone"`
+	Cutoff        BatasMinggu        `bson:"cutoff" json:"cutoff"`
+	CutoffKelas   []BatasMinggu      `bson:"cutoffkelas,omitempty" json:"cutoffkelas,omitempty"` // batas minggu khusus kelas, contoh kelasws dan kelasai
+	UpdatedBy     string             `bson:"updatedby,omitempty" json:"updatedby,omitempty"`
+	UpdatedAt     time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// BatasMinggu adalah hari dan jam dimulainya minggu baru
+type BatasMinggu struct {
+	Kelas string `bson:"kelas,omitempty" json:"kelas,omitempty"`
+	Hari  int    `bson:"hari" json:"hari"` // 0 Minggu sampai 6 Sabtu, sama dengan time.Weekday
+	Jam   string `bson:"jam" json:"jam"`   // HH:MM
+}
+
+// PeriodeAkademikStatus adalah konfigurasi periode beserta minggu yang sedang berjalan
+type PeriodeAkademikStatus struct {
+	Periode PeriodeAkademik       `json:"periode"`
+	Minggu  BimbinganWeeklyStatus `json:"minggu"`
+}
+
 // ChangeWeekRequest is the request structure for changing the current active week
 type ChangeWeekRequest struct {
 	WeekNumber int    `json:"weeknumber"`
@@ -98,39 +124,142 @@ type TimeCodeClaimRequest struct {
 
 // Event struct untuk menyimpan event yang dibuat owner
 type Event struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Name            string             `bson:"name" json:"name"`
-	Description     string             `bson:"description" json:"description"`
-	Points          int                `bson:"points" json:"points"`
-	DeadlineSeconds int                `bson:"deadlineseconds" json:"deadlineseconds"`
-	CreatedBy       string             `bson:"createdby" json:"createdby"`
-	CreatedAt       time.Time          `bson:"createdat" json:"createdat"`
-	IsActive        bool               `bson:"isactive" json:"isactive"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Name             string             `bson:"name" json:"name"`
+	Description      string             `bson:"description" json:"description"`
+	Points           int                `bson:"points" json:"points"`
+	DeadlineSeconds  int                `bson:"deadlineseconds" json:"deadlineseconds"`
+	CreatedBy        string             `bson:"createdby" json:"createdby"`
+	CreatedAt        time.Time          `bson:"createdat" json:"createdat"`
+	IsActive         bool               `bson:"isactive" json:"isactive"`
+	Category         string             `bson:"category,omitempty" json:"category,omitempty"`
+	MaxClaimants     int                `bson:"maxclaimants,omitempty" json:"maxclaimants,omitempty"` // 0 sama dengan 1 claimant aktif seperti event lama
+	Eligibility      EventEligibility   `bson:"eligibility,omitempty" json:"eligibility,omitempty"`
+	StartAt          time.Time          `bson:"startat,omitempty" json:"startat,omitempty"` // jadwal tayang, kosong berarti langsung aktif saat dibuat
+	EndAt            time.Time          `bson:"endat,omitempty" json:"endat,omitempty"`     // event dinonaktifkan otomatis setelah waktu ini
+	Recurrence       *EventRecurrence   `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	SeriesID         primitive.ObjectID `bson:"seriesid,omitempty" json:"seriesid,omitempty"` // id event pertama pada event berulang
+	Occurrence       int                `bson:"occurrence,omitempty" json:"occurrence,omitempty"`
+	AnnouncedAt      time.Time          `bson:"announcedat,omitempty" json:"announcedat,omitempty"`           // waktu event diaktifkan dan diumumkan
+	AllowedDomains   []string           `bson:"alloweddomains,omitempty" json:"alloweddomains,omitempty"`     // domain link tugas yang diterima termasuk subdomainnya, kosong berarti bebas
+	AllowOwnHostname bool               `bson:"allowownhostname,omitempty" json:"allowownhostname,omitempty"` // Project_Hostname proyek milik user ikut diterima
+	ClaimCount       int                `bson:"claimcount,omitempty" json:"claimcount,omitempty"`             // jumlah claim aktif, dinaikkan atomik saat claim supaya kuota tidak terlewati
+	Lanjutan         bool               `bson:"lanjutan,omitempty" json:"lanjutan,omitempty"`                 // event berikutnya pada seri berulang sudah dibuat atau seri sudah selesai
 }
 
+// Frekuensi EventRecurrence
+const (
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// EventRecurrence adalah aturan event berulang, misal tantangan mingguan.
+// Setiap kali satu event tayang, event berikutnya dibuat dengan StartAt dan EndAt digeser satu periode.
+type EventRecurrence struct {
+	Frequency string    `bson:"frequency" json:"frequency"`                   // daily, weekly atau monthly
+	Interval  int       `bson:"interval,omitempty" json:"interval,omitempty"` // setiap berapa periode, default 1
+	Until     time.Time `bson:"until,omitempty" json:"until,omitempty"`       // tidak ada event yang mulai setelah waktu ini
+	Count     int       `bson:"count,omitempty" json:"count,omitempty"`       // jumlah total event termasuk yang pertama, 0 tanpa batas
+}
+
+// EventEligibility adalah syarat user yang boleh claim event, field kosong berarti tanpa syarat
+type EventEligibility struct {
+	Enroll    []string `bson:"enroll,omitempty" json:"enroll,omitempty"`       // kode enroll proyek yang diikuti user
+	Kelas     []string `bson:"kelas,omitempty" json:"kelas,omitempty"`         // kelas yang tercatat di tugas kelas AI atau WS
+	MinPoints int      `bson:"minpoints,omitempty" json:"minpoints,omitempty"` // minimal total poin event user
+}
+
+// Status EventClaim
+const (
+	ClaimClaimed   = "claimed"
+	ClaimSubmitted = "submitted"
+	ClaimRevision  = "revision" // reviewer meminta perbaikan, user submit ulang sebelum deadline baru
+	ClaimApproved  = "approved"
+	ClaimRejected  = "rejected"
+	ClaimExpired   = "expired"
+)
+
 // EventClaim struct untuk tracking user yang claim event
 type EventClaim struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	EventID     primitive.ObjectID `bson:"eventid" json:"eventid"`
-	UserPhone   string             `bson:"userphone" json:"userphone"`
-	UserName    string             `bson:"username,omitempty" json:"username,omitempty"`
-	UserNPM     string             `bson:"usernpm,omitempty" json:"usernpm,omitempty"`
-	ClaimedAt   time.Time          `bson:"claimedat" json:"claimedat"`
-	Deadline    time.Time          `bson:"deadline" json:"deadline"`
-	Status      string             `bson:"status" json:"status"` // "claimed", "submitted", "approved", "expired"
-	TaskLink    string             `bson:"tasklink,omitempty" json:"tasklink,omitempty"`
-	SubmittedAt time.Time          `bson:"submittedat,omitempty" json:"submittedat,omitempty"`
-	ApprovedAt  time.Time          `bson:"approvedat,omitempty" json:"approvedat,omitempty"`
-	ApprovedBy  string             `bson:"approvedby,omitempty" json:"approvedby,omitempty"`
-	IsApproved  bool               `bson:"isapproved" json:"isapproved"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	EventID       primitive.ObjectID `bson:"eventid" json:"eventid"`
+	UserPhone     string             `bson:"userphone" json:"userphone"`
+	UserName      string             `bson:"username,omitempty" json:"username,omitempty"`
+	UserNPM       string             `bson:"usernpm,omitempty" json:"usernpm,omitempty"`
+	ClaimedAt     time.Time          `bson:"claimedat" json:"claimedat"`
+	Deadline      time.Time          `bson:"deadline" json:"deadline"`
+	Status        string             `bson:"status" json:"status"` // "claimed", "submitted", "revision", "approved", "rejected", "expired"
+	TaskLink      string             `bson:"tasklink,omitempty" json:"tasklink,omitempty"`
+	SubmittedAt   time.Time          `bson:"submittedat,omitempty" json:"submittedat,omitempty"`
+	ApprovedAt    time.Time          `bson:"approvedat,omitempty" json:"approvedat,omitempty"`
+	ApprovedBy    string             `bson:"approvedby,omitempty" json:"approvedby,omitempty"`
+	IsApproved    bool               `bson:"isapproved" json:"isapproved"`
+	ReviewComment string             `bson:"reviewcomment,omitempty" json:"reviewcomment,omitempty"` // komentar review terakhir saat ditolak atau diminta revisi
+	ReviewedBy    string             `bson:"reviewedby,omitempty" json:"reviewedby,omitempty"`
+	ReviewedAt    time.Time          `bson:"reviewedat,omitempty" json:"reviewedat,omitempty"`
+	Revisions     int                `bson:"revisions,omitempty" json:"revisions,omitempty"`
+	TaskLinkKey   string             `bson:"tasklinkkey,omitempty" json:"tasklinkkey,omitempty"` // link yang dinormalisasi untuk cek link ganda
+	LinkCheck     *EventLinkCheck    `bson:"linkcheck,omitempty" json:"linkcheck,omitempty"`     // hasil cek otomatis submit terakhir
+	Aktif         bool               `bson:"aktif,omitempty" json:"-"`                           // true selama claim memakai kuota, dasar index unik claim aktif per user
+}
+
+// EventLinkCheck adalah hasil cek otomatis link tugas sebelum claim masuk antrian review
+type EventLinkCheck struct {
+	URL             string               `bson:"url" json:"url"`
+	Resolves        bool                 `bson:"resolves" json:"resolves"`
+	StatusCode      int                  `bson:"statuscode,omitempty" json:"statuscode,omitempty"`
+	FinalURL        string               `bson:"finalurl,omitempty" json:"finalurl,omitempty"` // URL setelah redirect
+	Domain          string               `bson:"domain" json:"domain"`
+	DomainAllowed   bool                 `bson:"domainallowed" json:"domainallowed"`
+	Duplicate       bool                 `bson:"duplicate" json:"duplicate"`
+	DuplicateClaims []primitive.ObjectID `bson:"duplicateclaims,omitempty" json:"duplicateclaims,omitempty"` // claim user lain dengan link yang sama
+	Internal        bool                 `bson:"internal,omitempty" json:"internal,omitempty"`               // link atau redirectnya mengarah ke alamat internal
+	Passed          bool                 `bson:"passed" json:"passed"`
+	Ditolak         bool                 `bson:"ditolak" json:"ditolak"` // alamat internal atau domain di luar allowlist, claim tidak bisa disubmit
+	Alasan          []string             `bson:"alasan,omitempty" json:"alasan,omitempty"`
+	CheckedAt       time.Time            `bson:"checkedat" json:"checkedat"`
+}
+
+// EventClaimDetail adalah claim beserta nama dan poin event pada /api/event/allclaims
+type EventClaimDetail struct {
+	EventClaim
+	EventName   string `json:"eventname"`
+	EventPoints int    `json:"eventpoints"`
+}
+
+// EventReviewItem adalah satu claim di antrian review, Flagged jika link tugas tidak lolos cek otomatis
+type EventReviewItem struct {
+	ClaimID     string          `json:"claim_id"`
+	EventID     string          `json:"event_id"`
+	EventName   string          `json:"event_name"`
+	Category    string          `json:"category"`
+	Points      int             `json:"points"`
+	Username    string          `json:"username"`
+	NPM         string          `json:"npm"`
+	PhoneNumber string          `json:"phonenumber"`
+	TaskLink    string          `json:"task_link"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+	AgeSeconds  int             `json:"age_seconds"`
+	Revisions   int             `json:"revisions"`
+	Flagged     bool            `json:"flagged"`
+	LinkCheck   *EventLinkCheck `json:"link_check"`
 }
 
 // EventCreateRequest struct untuk request create event
 type EventCreateRequest struct {
-	Name            string `json:"name" bson:"name"`
-	Description     string `json:"description" bson:"description"`
-	Points          int    `json:"points" bson:"points"`
-	DeadlineSeconds int    `json:"deadline_seconds" bson:"deadline_seconds"`
+	Name             string           `json:"name" bson:"name"`
+	Description      string           `json:"description" bson:"description"`
+	Points           int              `json:"points" bson:"points"`
+	DeadlineSeconds  int              `json:"deadline_seconds" bson:"deadline_seconds"`
+	Category         string           `json:"category,omitempty" bson:"category,omitempty"`
+	MaxClaimants     int              `json:"max_claimants,omitempty" bson:"max_claimants,omitempty"`
+	Eligibility      EventEligibility `json:"eligibility,omitempty" bson:"eligibility,omitempty"`
+	StartAt          time.Time        `json:"start_at,omitempty" bson:"start_at,omitempty"`
+	EndAt            time.Time        `json:"end_at,omitempty" bson:"end_at,omitempty"`
+	Recurrence       *EventRecurrence `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	AllowedDomains   []string         `json:"allowed_domains,omitempty" bson:"allowed_domains,omitempty"`
+	AllowOwnHostname bool             `json:"allow_own_hostname,omitempty" bson:"allow_own_hostname,omitempty"`
 }
 
 // EventClaimRequest struct untuk request claim event
@@ -149,6 +278,13 @@ type EventApproveRequest struct {
 	ClaimID string `json:"claim_id" bson:"claim_id"`
 }
 
+// EventReviewRequest untuk menolak claim atau meminta revisi, komentar dikirim ke user
+type EventReviewRequest struct {
+	ClaimID string `json:"claim_id" bson:"claim_id"`
+	Action  string `json:"action" bson:"action"` // reject atau revision
+	Comment string `json:"comment" bson:"comment"`
+}
+
 // EventUserPoint struct untuk tracking poin user dari event
 type EventUserPoint struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Saldo user yang dicatat di ledger, sama dengan nama field di dokumen user
+const (
+	AkunPoin       = "poin"
+	AkunPointEvent = "pointevent"
+)
+
+// PoinLedger adalah satu transaksi poin yang tidak pernah diubah atau dihapus setelah tersimpan.
+// Entries selalu berjumlah nol: satu sisi saldo user, sisi lain akun sistem sumber poin.
+type PoinLedger struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	IdempotencyKey string             `bson:"idempotencykey" json:"idempotencykey"`
+	Percobaan      int                `bson:"percobaan,omitempty" json:"percobaan,omitempty"`     // naik setiap percobaan dengan key yang sama dibatalkan
+	Source         string             `bson:"source" json:"source"`                               // presensi, pushrepo, event, bimbingancode, ...
+	ReferenceID    string             `bson:"referenceid,omitempty" json:"referenceid,omitempty"` // id dokumen asal, misal commit sha atau claim id
+	Keterangan     string             `bson:"keterangan,omitempty" json:"keterangan,omitempty"`
+	Entries        []PoinLedgerEntry  `bson:"entries" json:"entries"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// PoinLedgerEntry bernilai positif untuk kredit dan negatif untuk debit
+type PoinLedgerEntry struct {
+	Account     string  `bson:"account" json:"account"` // poin, pointevent atau sistem:<source>
+	PhoneNumber string  `bson:"phonenumber,omitempty" json:"phonenumber,omitempty"`
+	Amount      float64 `bson:"amount" json:"amount"`
+}
+
+// PoinDrift adalah selisih saldo user dengan jumlah entri ledger saat rekonsiliasi
+type PoinDrift struct {
+	PhoneNumber string  `bson:"phonenumber" json:"phonenumber"`
+	Account     string  `bson:"account" json:"account"`
+	Saldo       float64 `bson:"saldo" json:"saldo"`   // nilai di dokumen user sebelum diperbaiki
+	Ledger      float64 `bson:"ledger" json:"ledger"` // jumlah entri ledger
+	Selisih     float64 `bson:"selisih" json:"selisih"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LiburNasional adalah satu tanggal libur di collection liburnasional.
+// Kampus kosong berarti libur nasional, diisi berarti libur tambahan kampus tersebut seperti minggu ujian.
+type LiburNasional struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Tanggal    string             `bson:"tanggal" json:"tanggal"` // format 2006-01-02
+	Keterangan string             `bson:"keterangan,omitempty" json:"keterangan,omitempty"`
+	IsCuti     bool               `bson:"iscuti,omitempty" json:"is_cuti,omitempty"`
+	Kampus     string             `bson:"kampus,omitempty" json:"kampus,omitempty"`
+	Sumber     string             `bson:"sumber,omitempty" json:"sumber,omitempty"` // manual, json atau ics
+	CreatedBy  string             `bson:"createdby,omitempty" json:"createdby,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// LiburRequest dipakai owner untuk menambah satu tanggal atau rentang tanggal libur
+type LiburRequest struct {
+	Tanggal    string `json:"tanggal"`
+	Sampai     string `json:"sampai,omitempty"` // opsional, akhir rentang termasuk tanggal ini
+	Keterangan string `json:"keterangan"`
+	IsCuti     bool   `json:"is_cuti,omitempty"`
+	Kampus     string `json:"kampus,omitempty"`
+}
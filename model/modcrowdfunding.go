@@ -23,6 +23,8 @@ type CrowdfundingOrder struct {
 	PhoneNumber   string             `json:"phoneNumber" bson:"phoneNumber"`
 	NPM           string             `json:"npm,omitempty" bson:"npm,omitempty"`
 	Amount        float64            `json:"amount" bson:"amount"`
+	BaseAmount    float64            `json:"baseAmount,omitempty" bson:"baseAmount,omitempty"` // Amount requested by user before the unique code is added (QRIS)
+	UniqueCode    int                `json:"uniqueCode,omitempty" bson:"uniqueCode,omitempty"` // Code from the payment slot used to tell concurrent orders apart
 	PaymentMethod PaymentMethod      `json:"paymentMethod" bson:"paymentMethod"`
 	WonpayCode    string             `json:"wonpayCode,omitempty" bson:"wonpayCode,omitempty"`       // Used for MicroBitcoin
 	WalletAddress string             `json:"walletAddress,omitempty" bson:"walletAddress,omitempty"` // Used for MicroBitcoin and Ravencoin
@@ -35,13 +37,19 @@ type CrowdfundingOrder struct {
 	RVNwallet     string             `json:"rvnwallet,omitempty" bson:"rvnwallet,omitempty"`
 }
 
-// CrowdfundingQueue struct to manage payment processing
-type CrowdfundingQueue struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	IsProcessing   bool               `json:"isProcessing" bson:"isProcessing"`
-	CurrentOrderID string             `json:"currentOrderId" bson:"currentOrderId"`
-	PaymentMethod  PaymentMethod      `json:"paymentMethod" bson:"paymentMethod"`
-	ExpiryTime     time.Time          `json:"expiryTime" bson:"expiryTime"`
+// CrowdfundingSlot reserves a unique payment identifier for one pending order.
+// Several slots can be active at the same time, each with its own expiry.
+type CrowdfundingSlot struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	OrderID       string             `json:"orderId" bson:"orderId"`
+	PhoneNumber   string             `json:"phoneNumber" bson:"phoneNumber"`
+	PaymentMethod PaymentMethod      `json:"paymentMethod" bson:"paymentMethod"`
+	UniqueCode    int                `json:"uniqueCode" bson:"uniqueCode"`
+	MatchKey      string             `json:"matchKey" bson:"matchKey"`                             // unique per active slot, e.g. "qris:10123" or "microbitcoin:123"
+	SenderWallet  string             `json:"senderWallet,omitempty" bson:"senderWallet,omitempty"` // user's registered wallet, used as an extra match for crypto
+	TxID          string             `json:"txid,omitempty" bson:"txid,omitempty"`                 // transaction claimed by this slot
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiryTime    time.Time          `json:"expiryTime" bson:"expiryTime"`
 }
 
 // CrowdfundingTotal struct to track total payments
@@ -89,7 +97,10 @@ type CrowdfundingPaymentResponse struct {
 	QueueStatus   bool          `json:"queueStatus,omitempty"`
 	Status        string        `json:"status,omitempty"`
 	IsProcessing  bool          `json:"isProcessing,omitempty"`
+	ActiveSlots   int           `json:"activeSlots,omitempty"`
 	PaymentMethod PaymentMethod `json:"paymentMethod,omitempty"`
+	UniqueCode    int           `json:"uniqueCode,omitempty"`
+	PayAmount     string        `json:"payAmount,omitempty"` // exact amount the user has to transfer
 
 	// Bitcoin and Ravencoin specific fields
 	WalletAddress string  `json:"walletAddress,omitempty"`
@@ -246,15 +257,6 @@ type RavencoinScriptPubKey struct {
 	Addresses []string `json:"addresses"`
 }
 
-// Add this new struct to modcrowdfunding.go after the other structs
-
-// RavencoinLastTransactions struct to track the last transaction count
-type RavencoinLastTransactions struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	LastTxCount int                `json:"lastTxCount" bson:"lastTxCount"`
-	LastUpdated time.Time          `json:"lastUpdated" bson:"lastUpdated"`
-}
-
 // PaymentPointsData struct to store calculated points
 type PaymentPointsData struct {
 	PhoneNumber     string    `json:"phoneNumber" bson:"phoneNumber"`
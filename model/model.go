@@ -1,6 +1,8 @@
 package model
 
 type Response struct {
+	Code     string      `json:"code,omitempty"`
+	Message  string      `json:"message,omitempty"`
 	Response string      `json:"response"`
 	Info     string      `json:"info,omitempty"`
 	Status   string      `json:"status,omitempty"`
@@ -43,4 +45,8 @@ type Config struct {
 	StravaUrl              string `json:"stravaurl,omitempty" bson:"stravaurl,omitempty"`
 	StravaUrl2             string `json:"stravaurl2,omitempty" bson:"stravaurl2,omitempty"`
 	DataMemberBukped       string `json:"datamemberbukped,omitempty" bson:"datamemberbukped,omitempty"`
+
+	// analisis anti-curang Pomokit, nilai kosong memakai bawaan helper/pomokitcheck
+	PomokitMaksNomorPerIP int      `json:"pomokitmaksnomorperip,omitempty" bson:"pomokitmaksnomorperip,omitempty"` //batas nomor berbeda per IP publik per hari
+	PomokitIPKampus       []string `json:"pomokitipkampus,omitempty" bson:"pomokitipkampus,omitempty"`             //IP atau CIDR egress kampus dan lab yang tidak dicek nomor per IP
 }
@@ -33,3 +33,45 @@ type TugasPomodoro struct {
 	GTMetrixURLTarget string             `json:"gtmetrix_url_target" bson:"gtmetrix_url_target"`
 	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
 }
+
+// Kode temuan PomokitFlag
+const (
+	PomokitFlagTumpangTindih = "sesi_tumpang_tindih" // dua sesi dari perangkat berbeda berjalan bersamaan
+	PomokitFlagIPBersama     = "ip_bersama"          // satu IP publik dipakai banyak nomor di hari yang sama
+	PomokitFlagCycle         = "cycle_mustahil"      // jumlah cycle tidak muat di waktu sejak sesi sebelumnya
+	PomokitFlagScreenshot    = "screenshot_kurang"   // screenshot lebih sedikit dari jumlah cycle
+)
+
+// Status review PomokitFlag, sesi berstatus ditahan dan ditolak tidak dihitung di skor
+const (
+	PomokitFlagDitahan = "ditahan"
+	PomokitFlagValid   = "valid"
+	PomokitFlagDitolak = "ditolak"
+)
+
+// PomokitFlag adalah temuan analisis anti-curang untuk satu sesi Pomokit, disimpan di koleksi pomokitflag
+type PomokitFlag struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ReportID    primitive.ObjectID `bson:"reportid" json:"reportid"` // _id PomodoroReport dari API Pomokit
+	Name        string             `bson:"name" json:"name"`
+	PhoneNumber string             `bson:"phonenumber" json:"phonenumber"`
+	WaGroupID   string             `bson:"wagroupid,omitempty" json:"wagroupid,omitempty"`
+	Cycle       int                `bson:"cycle" json:"cycle"`
+	Screenshots int                `bson:"screenshots" json:"screenshots"`
+	Hostname    string             `bson:"hostname,omitempty" json:"hostname,omitempty"`
+	IP          string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	SessionAt   time.Time          `bson:"sessionat" json:"sessionat"` // CreatedAt sesi
+	Alasan      []string           `bson:"alasan" json:"alasan"`
+	Keterangan  []string           `bson:"keterangan,omitempty" json:"keterangan,omitempty"`
+	Status      string             `bson:"status" json:"status"`
+	FlaggedAt   time.Time          `bson:"flaggedat" json:"flaggedat"`
+	ReviewedBy  string             `bson:"reviewedby,omitempty" json:"reviewedby,omitempty"`
+	ReviewedAt  time.Time          `bson:"reviewedat,omitempty" json:"reviewedat,omitempty"`
+	Catatan     string             `bson:"catatan,omitempty" json:"catatan,omitempty"`
+}
+
+// PomokitFlagReviewRequest adalah keputusan dosen atas sesi yang ditahan, status valid atau ditolak
+type PomokitFlagReviewRequest struct {
+	Status  string `json:"status"`
+	Catatan string `json:"catatan,omitempty"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRole memberi satu role kepada user. Scope kosong berarti berlaku di semua enroll dan proyek,
+// selain itu berisi kode MasterEnrool atau ID proyek tempat role berlaku.
+type UserRole struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	PhoneNumber string             `bson:"phonenumber" json:"phonenumber"`
+	Role        string             `bson:"role" json:"role"` // owner, dosen, asesor, mahasiswa atau proyek
+	Scope       string             `bson:"scope" json:"scope,omitempty"`
+	Turunan     bool               `bson:"-" json:"turunan,omitempty"` // role dari data user (isdosen), tidak tersimpan di koleksi userrole
+	GrantedBy   string             `bson:"grantedby,omitempty" json:"grantedby,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// UserRoleRequest adalah body untuk memberi role
+type UserRoleRequest struct {
+	PhoneNumber string `json:"phonenumber"`
+	Role        string `json:"role"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// UserAccess adalah role dan permission milik satu user
+type UserAccess struct {
+	PhoneNumber string              `json:"phonenumber"`
+	Roles       []UserRole          `json:"roles"`
+	Permissions []string            `json:"permissions"`        // permission global
+	PerScope    map[string][]string `json:"perscope,omitempty"` // permission role terbatas per kode enroll atau ID proyek
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScoringRuleSet berisi aturan skor aktivitas untuk satu MasterEnrool.
+// Aturan tidak pernah diubah, setiap perubahan disimpan sebagai versi baru dan hanya satu versi yang aktif.
+type ScoringRuleSet struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Enroll    string             `bson:"enroll" json:"enroll"` // kode MasterEnrool: kelas, proyek atau bimbingan
+	Version   int                `bson:"version" json:"version"`
+	Active    bool               `bson:"active" json:"active"`
+	Rules     []ScoringRule      `bson:"rules" json:"rules"`
+	Catatan   string             `bson:"catatan,omitempty" json:"catatan,omitempty"`
+	CreatedBy string             `bson:"createdby,omitempty" json:"createdby,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// ScoringRule mengatur skor satu komponen ActivityScore.
+// Sumber "skor" memakai skor yang sudah dihitung sumber data, sumber "mentah" menghitung ulang dari nilai mentah:
+// skor = nilai mentah / Per * Nilai, contoh Strava Per 6 (km) Nilai 100.
+type ScoringRule struct {
+	Komponen     string             `bson:"komponen" json:"komponen"` // sponsor, strava, iq, pomokit, blockchain, qris, tracker, bukped, jurnal, gtmetrix, webhook, presensi
+	Sumber       string             `bson:"sumber,omitempty" json:"sumber,omitempty"`
+	Per          float64            `bson:"per,omitempty" json:"per,omitempty"`
+	Nilai        float64            `bson:"nilai,omitempty" json:"nilai,omitempty"`
+	Grade        map[string]float64 `bson:"grade,omitempty" json:"grade,omitempty"`               // skor per grade untuk komponen gtmetrix, contoh A 100
+	RataMingguan bool               `bson:"ratamingguan,omitempty" json:"ratamingguan,omitempty"` // skor semua waktu: nilai mentah dibagi jumlah minggu berjalan
+	Max          float64            `bson:"max,omitempty" json:"max,omitempty"`                   // batas skor komponen, 0 tanpa batas
+	Bobot        float64            `bson:"bobot,omitempty" json:"bobot,omitempty"`               // pengali saat dijumlahkan ke total, 0 dianggap 1
+}
+
+// WeeklyScoreSnapshot membekukan ActivityScore mingguan seorang user, satu dokumen per minggu periode akademik.
+// Dosen menilai dari angka ini sehingga nilai tidak berubah saat data sumber bergeser.
+type WeeklyScoreSnapshot struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	PhoneNumber   string             `bson:"phonenumber" json:"phonenumber"`
+	Name          string             `bson:"name,omitempty" json:"name,omitempty"`
+	Enroll        string             `bson:"enroll,omitempty" json:"enroll,omitempty"`
+	Year          int                `bson:"year" json:"year"` // tahun ISO
+	Week          int                `bson:"week" json:"week"` // minggu ISO
+	WeekLabel     string             `bson:"weeklabel" json:"weeklabel"`
+	WeekStart     time.Time          `bson:"weekstart" json:"weekstart"` // Senin 00:00 WIB minggu ISO tersebut
+	ActivityScore ActivityScore      `bson:"activityscore" json:"activityscore"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+}
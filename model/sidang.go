@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status jadwal sidang
+const (
+	SidangTerjadwal  = "terjadwal"
+	SidangDibatalkan = "dibatalkan"
+)
+
+// SidangSlot adalah rentang waktu dosen bersedia menguji atau mendampingi sidang
+type SidangSlot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	PhoneNumber string             `bson:"phonenumber" json:"phonenumber"`
+	Name        string             `bson:"name" json:"name"`
+	Mulai       time.Time          `bson:"mulai" json:"mulai"`
+	Selesai     time.Time          `bson:"selesai" json:"selesai"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// SidangSlotRequest dipakai dosen untuk membuka slot, waktu dalam format RFC3339
+type SidangSlotRequest struct {
+	Mulai   time.Time `json:"mulai"`
+	Selesai time.Time `json:"selesai"`
+}
+
+// SidangDosen adalah dosen penguji atau pembimbing pada jadwal sidang
+type SidangDosen struct {
+	PhoneNumber string `bson:"phonenumber" json:"phonenumber"`
+	Name        string `bson:"name" json:"name"`
+}
+
+// JadwalSidang adalah sidang yang sudah dijadwalkan dari satu BimbinganPengajuan.
+// Peserta berisi nomor mahasiswa, pembimbing dan semua penguji untuk pengecekan bentrok.
+type JadwalSidang struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	PengajuanID     primitive.ObjectID `bson:"pengajuanid" json:"pengajuanid"`
+	PhoneNumber     string             `bson:"phonenumber" json:"phonenumber"`
+	Name            string             `bson:"name" json:"name"`
+	NPM             string             `bson:"npm" json:"npm"`
+	NomorKelompok   string             `bson:"nomorkelompok,omitempty" json:"nomorkelompok,omitempty"`
+	Pembimbing      SidangDosen        `bson:"pembimbing" json:"pembimbing"`
+	Penguji         []SidangDosen      `bson:"penguji" json:"penguji"`
+	Peserta         []string           `bson:"peserta" json:"peserta"`
+	Ruang           string             `bson:"ruang" json:"ruang"`
+	Mulai           time.Time          `bson:"mulai" json:"mulai"`
+	Selesai         time.Time          `bson:"selesai" json:"selesai"`
+	Status          string             `bson:"status" json:"status"` // terjadwal atau dibatalkan
+	Alasan          string             `bson:"alasan,omitempty" json:"alasan,omitempty"`
+	CalendarEventID string             `bson:"calendareventid,omitempty" json:"calendareventid,omitempty"`
+	CalendarLink    string             `bson:"calendarlink,omitempty" json:"calendarlink,omitempty"`
+	Riwayat         []RiwayatSidang    `bson:"riwayat,omitempty" json:"riwayat,omitempty"`
+	CreatedBy       string             `bson:"createdby" json:"createdby"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// RiwayatSidang mencatat jadwal lama setiap kali sidang dipindah atau dibatalkan
+type RiwayatSidang struct {
+	Aksi    string    `bson:"aksi" json:"aksi"` // pindah atau batal
+	Ruang   string    `bson:"ruang" json:"ruang"`
+	Mulai   time.Time `bson:"mulai" json:"mulai"`
+	Selesai time.Time `bson:"selesai" json:"selesai"`
+	Alasan  string    `bson:"alasan,omitempty" json:"alasan,omitempty"`
+	Oleh    string    `bson:"oleh" json:"oleh"`
+	Waktu   time.Time `bson:"waktu" json:"waktu"`
+}
+
+// JadwalSidangRequest dipakai admin untuk menjadwalkan atau memindah sidang.
+// PengujiPhone kosong berarti memakai dosen penguji dari pengajuan.
+type JadwalSidangRequest struct {
+	PengajuanID  primitive.ObjectID `json:"pengajuanid,omitempty"`
+	PengujiPhone []string           `json:"pengujiphone,omitempty"`
+	Ruang        string             `json:"ruang"`
+	Mulai        time.Time          `json:"mulai"`
+	Selesai      time.Time          `json:"selesai"`
+	Alasan       string             `json:"alasan,omitempty"` // hanya untuk pindah jadwal
+}
+
+// JadwalOtomatisRequest meminta penjadwal mencari waktu dan ruang pertama yang cocok
+// di antara Dari dan Sampai, dengan durasi dalam menit (default 60)
+type JadwalOtomatisRequest struct {
+	PengajuanID  primitive.ObjectID `json:"pengajuanid"`
+	PengujiPhone []string           `json:"pengujiphone,omitempty"`
+	Ruang        []string           `json:"ruang"`
+	Durasi       int                `json:"durasi,omitempty"`
+	Dari         time.Time          `json:"dari"`
+	Sampai       time.Time          `json:"sampai"`
+}
+
+// BatalSidangRequest berisi alasan pembatalan yang dikirim ke semua peserta
+type BatalSidangRequest struct {
+	Alasan string `json:"alasan"`
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis pemenuhan item toko poin
+const (
+	StoreKodeBimbingan      = "kodebimbingan"      // kode bimbingan sekali pakai di koleksi eventcodes
+	StoreKodeWaktu          = "kodewaktu"          // EventCodeTime yang berlaku selama DurasiDetik
+	StorePerpanjangDeadline = "perpanjangdeadline" // deadline EventClaim aktif diperpanjang DurasiDetik
+	StoreSertifikat         = "sertifikat"         // sertifikat cetak, diproses manual oleh owner
+)
+
+// Status StorePembelian
+const (
+	PembelianBerhasil = "berhasil"
+	PembelianDiproses = "diproses" // menunggu pemenuhan manual, misal sertifikat cetak
+	PembelianSelesai  = "selesai"
+	PembelianRefund   = "refund"
+)
+
+// StoreItem adalah item katalog toko yang dibeli dengan pointevent
+type StoreItem struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Kode         string             `bson:"kode" json:"kode"` // unik, contoh kodebimbingan
+	Nama         string             `bson:"nama" json:"nama"`
+	Deskripsi    string             `bson:"deskripsi,omitempty" json:"deskripsi,omitempty"`
+	Harga        int                `bson:"harga" json:"harga"`
+	Stok         int                `bson:"stok" json:"stok"`                                     // sisa stok, -1 berarti tidak terbatas
+	BatasPerUser int                `bson:"batasperuser,omitempty" json:"batasperuser,omitempty"` // 0 berarti tanpa batas
+	Fulfillment  string             `bson:"fulfillment" json:"fulfillment"`
+	DurasiDetik  int                `bson:"durasidetik,omitempty" json:"durasidetik,omitempty"` // masa berlaku kode waktu atau lama perpanjangan deadline
+	IsActive     bool               `bson:"isactive" json:"isactive"`
+	CreatedBy    string             `bson:"createdby" json:"createdby"`
+	CreatedAt    time.Time          `bson:"createdat" json:"createdat"`
+}
+
+// StorePembelian adalah catatan satu pembelian, Hasil berisi kode atau keterangan pemenuhan
+type StorePembelian struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ItemID       primitive.ObjectID `bson:"itemid" json:"itemid"`
+	ItemKode     string             `bson:"itemkode" json:"itemkode"`
+	ItemNama     string             `bson:"itemnama" json:"itemnama"`
+	Fulfillment  string             `bson:"fulfillment" json:"fulfillment"`
+	PhoneNumber  string             `bson:"phonenumber" json:"phonenumber"`
+	Name         string             `bson:"name,omitempty" json:"name,omitempty"`
+	Harga        int                `bson:"harga" json:"harga"`
+	DurasiDetik  int                `bson:"durasidetik,omitempty" json:"durasidetik,omitempty"`
+	Status       string             `bson:"status" json:"status"` // berhasil, diproses, selesai atau refund
+	Hasil        string             `bson:"hasil,omitempty" json:"hasil,omitempty"`
+	ClaimID      primitive.ObjectID `bson:"claimid,omitempty" json:"claimid,omitempty"`
+	ExpiresAt    time.Time          `bson:"expiresat,omitempty" json:"expiresat,omitempty"`
+	Catatan      string             `bson:"catatan,omitempty" json:"catatan,omitempty"`
+	RefundAlasan string             `bson:"refundalasan,omitempty" json:"refundalasan,omitempty"`
+	RefundBy     string             `bson:"refundby,omitempty" json:"refundby,omitempty"`
+	RefundAt     time.Time          `bson:"refundat,omitempty" json:"refundat,omitempty"`
+	CreatedAt    time.Time          `bson:"createdat" json:"createdat"`
+}
+
+// StoreItemRequest dipakai owner untuk menambah atau mengubah item katalog
+type StoreItemRequest struct {
+	Kode         string `json:"kode"`
+	Nama         string `json:"nama"`
+	Deskripsi    string `json:"deskripsi,omitempty"`
+	Harga        int    `json:"harga"`
+	Stok         int    `json:"stok"`
+	BatasPerUser int    `json:"batas_per_user,omitempty"`
+	Fulfillment  string `json:"fulfillment"`
+	DurasiDetik  int    `json:"durasi_detik,omitempty"`
+	IsActive     bool   `json:"isactive"`
+}
+
+// StoreBeliRequest berisi item yang dibeli. ClaimID wajib untuk perpanjangan deadline,
+// Catatan dipakai untuk nama di sertifikat dan alamat pengiriman.
+type StoreBeliRequest struct {
+	ItemID  primitive.ObjectID `json:"item_id"`
+	ClaimID primitive.ObjectID `json:"claim_id,omitempty"`
+	Catatan string             `json:"catatan,omitempty"`
+}
+
+// StoreRefundRequest berisi alasan refund yang dikirim ke pembeli
+type StoreRefundRequest struct {
+	Alasan string `json:"alasan"`
+}
+
+// StoreSelesaiRequest berisi keterangan pemenuhan manual, misal nomor resi pengiriman sertifikat
+type StoreSelesaiRequest struct {
+	Hasil string `json:"hasil,omitempty"`
+}
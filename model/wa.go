@@ -1,8 +1,36 @@
 package model
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type QRStatus struct {
 	PhoneNumber string `json:"phonenumber"`
 	Status      bool   `json:"status"`
 	Code        string `json:"code"`
 	Message     string `json:"message"`
 }
+
+const (
+	OutboxPending = "pending" // menunggu dikirim atau dicoba ulang
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed" // percobaan habis, bisa dikirim ulang lewat admin
+)
+
+// WAOutbox adalah satu pesan teks WhatsApp di collection waoutbox
+type WAOutbox struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	To            string             `bson:"to" json:"to"`
+	IsGroup       bool               `bson:"isgroup,omitempty" json:"isgroup,omitempty"`
+	Messages      string             `bson:"messages" json:"messages"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"lasterror,omitempty" json:"lasterror,omitempty"`
+	Response      Response           `bson:"response,omitempty" json:"response,omitempty"` // response terakhir dari API WA
+	NextAttemptAt time.Time          `bson:"nextattemptat" json:"nextattemptat"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	SentAt        time.Time          `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+	FailedAt      time.Time          `bson:"failedAt,omitempty" json:"failedAt,omitempty"`
+}
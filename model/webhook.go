@@ -17,6 +17,121 @@ type PushReport struct {
 	Message     string        `bson:"message" json:"message"`
 	Modified    string        `bson:"modified,omitempty" json:"modified,omitempty"`
 	RemoteAddr  string        `bson:"remoteaddr,omitempty" json:"remoteaddr,omitempty"`
+	CommitSHA   string        `bson:"commitsha,omitempty" json:"commitsha,omitempty"`   //unik per proyek, commit yang sama tidak dapat poin dua kali
+	DeliveryID  string        `bson:"deliveryid,omitempty" json:"deliveryid,omitempty"` //id delivery webhook yang membawa commit ini
+	Host        string        `bson:"host,omitempty" json:"host,omitempty"`             //github, gitlab, gitea atau bitbucket
+	CreatedAt   time.Time     `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+}
+
+// PullRequestReport dicatat dari event pull_request GitHub saat PR dibuka atau di-merge
+type PullRequestReport struct {
+	ProjectName string        `bson:"projectname" json:"projectname"`
+	Project     Project       `bson:"project" json:"project"`
+	User        Userdomyikado `bson:"user,omitempty" json:"user,omitempty"`
+	Username    string        `bson:"username" json:"username"` //author PR
+	Repo        string        `bson:"repo" json:"repo"`
+	URL         string        `bson:"url" json:"url"`
+	Number      int64         `bson:"number" json:"number"`
+	Title       string        `bson:"title" json:"title"`
+	Action      string        `bson:"action" json:"action"` //opened atau merged
+	Poin        float64       `bson:"poin,omitempty" json:"poin,omitempty"`
+	EventKey    string        `bson:"eventkey" json:"eventkey"` //unik, id PR dan action
+	DeliveryID  string        `bson:"deliveryid,omitempty" json:"deliveryid,omitempty"`
+	RemoteAddr  string        `bson:"remoteaddr,omitempty" json:"remoteaddr,omitempty"`
+	CreatedAt   time.Time     `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+}
+
+// PullRequestReviewReport dicatat dari event pull_request_review GitHub saat review dikirim
+type PullRequestReviewReport struct {
+	ProjectName string        `bson:"projectname" json:"projectname"`
+	Project     Project       `bson:"project" json:"project"`
+	User        Userdomyikado `bson:"user,omitempty" json:"user,omitempty"`
+	Username    string        `bson:"username" json:"username"` //reviewer
+	Repo        string        `bson:"repo" json:"repo"`
+	URL         string        `bson:"url" json:"url"`
+	PRNumber    int64         `bson:"prnumber" json:"prnumber"`
+	PRTitle     string        `bson:"prtitle" json:"prtitle"`
+	PRAuthor    string        `bson:"prauthor" json:"prauthor"`
+	State       string        `bson:"state" json:"state"` //approved, changes_requested atau commented
+	Body        string        `bson:"body,omitempty" json:"body,omitempty"`
+	Poin        float64       `bson:"poin,omitempty" json:"poin,omitempty"`
+	EventKey    string        `bson:"eventkey" json:"eventkey"` //unik, id review
+	DeliveryID  string        `bson:"deliveryid,omitempty" json:"deliveryid,omitempty"`
+	RemoteAddr  string        `bson:"remoteaddr,omitempty" json:"remoteaddr,omitempty"`
+	CreatedAt   time.Time     `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+}
+
+// IssueReport dicatat dari event issues GitHub saat issue dibuka atau ditutup
+type IssueReport struct {
+	ProjectName string        `bson:"projectname" json:"projectname"`
+	Project     Project       `bson:"project" json:"project"`
+	User        Userdomyikado `bson:"user,omitempty" json:"user,omitempty"`
+	Username    string        `bson:"username" json:"username"` //pembuka issue atau yang menutup
+	Repo        string        `bson:"repo" json:"repo"`
+	URL         string        `bson:"url" json:"url"`
+	Number      int64         `bson:"number" json:"number"`
+	Title       string        `bson:"title" json:"title"`
+	Action      string        `bson:"action" json:"action"` //opened atau closed
+	Poin        float64       `bson:"poin,omitempty" json:"poin,omitempty"`
+	EventKey    string        `bson:"eventkey" json:"eventkey"` //unik, id issue dan action
+	DeliveryID  string        `bson:"deliveryid,omitempty" json:"deliveryid,omitempty"`
+	RemoteAddr  string        `bson:"remoteaddr,omitempty" json:"remoteaddr,omitempty"`
+	CreatedAt   time.Time     `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+}
+
+// BitbucketPushPayload adalah bagian payload repo:push Bitbucket Cloud yang dipakai webhook
+type BitbucketPushPayload struct {
+	Actor      BitbucketUser `json:"actor"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+			Commits []BitbucketCommit `json:"commits"`
+		} `json:"changes"`
+	} `json:"push"`
+}
+
+type BitbucketCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+	Author  struct {
+		Raw  string         `json:"raw"` //format "Nama <email>"
+		User *BitbucketUser `json:"user"`
+	} `json:"author"`
+}
+
+type BitbucketUser struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+	AccountID   string `json:"account_id"`
+}
+
+// WebhookDelivery menyimpan payload dan hasil satu delivery webhook push.
+// Delivery yang dikirim ulang oleh git host langsung dijawab dengan hasil yang tersimpan.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	DeliveryID    string             `bson:"deliveryid" json:"deliveryid"`
+	Host          string             `bson:"host" json:"host"`
+	Event         string             `bson:"event,omitempty" json:"event,omitempty"` //kosong berarti push
+	ProjectName   string             `bson:"projectname" json:"projectname"`
+	Payload       string             `bson:"payload" json:"payload"`
+	StatusCode    int                `bson:"statuscode" json:"statuscode"`
+	Response      Response           `bson:"response" json:"response"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	RedeliveredAt time.Time          `bson:"redeliveredAt,omitempty" json:"redeliveredAt,omitempty"`
 }
 
 type MasterEnrool struct {
@@ -42,6 +157,7 @@ type Project struct {
 	Closed           bool               `bson:"closed,omitempty" json:"closed,omitempty"`
 	Pembimbing       []Userdomyikado    `bson:"pembimbing,omitempty" json:"pembimbing,omitempty"`
 	Project_Hostname string             `bson:"project_hostname,omitempty" json:"project_hostname,omitempty"`
+	Kampus           string             `bson:"kampus,omitempty" json:"kampus,omitempty"` //kode kampus untuk libur tambahan di collection liburnasional
 }
 
 type Userdomyikado struct {
@@ -73,46 +189,64 @@ type Userdomyikado struct {
 }
 
 // skor asessment proyek1 dan lainnya aktifitas mingguan. ini pengganti kartu bimbingan
+// rumus di komentar field adalah perhitungan bawaan sumber data, rumus dan bobot total per enroll diatur lewat ScoringRuleSet
 type ActivityScore struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	CreatedAt       time.Time          `bson:"createdAt"`                                          //kalo lebih dari seminggu auto hapus
-	BimbinganKe     int                `bson:"bimbinganke,omitempty" json:"bimbinganke,omitempty"` //bimbingan ke berapa
-	Username        string             `bson:"username,omitempty" json:"username,omitempty"`
-	PhoneNumber     string             `bson:"phonenumber,omitempty" json:"phonenumber,omitempty"`
-	Enroll          MasterEnrool       `bson:"enroll,omitempty" json:"enroll,omitempty"` //kelas atau proyek atau bimbingan
-	Sponsordata     int                `bson:"sponsordata,omitempty" json:"sponsordata,omitempty"`
-	Sponsor         int                `bson:"sponsor,omitempty" json:"sponsor,omitempty"` // lengkap 100, nomor 50, nama 50
-	StravaKM        float32            `bson:"stravakm,omitempty" json:"stravakm,omitempty"`
-	Strava          int                `bson:"strava,omitempty" json:"strava,omitempty"` //perminggu dibagi 6KM dikali 100
-	IQresult        int                `bson:"iqresult,omitempty" json:"iqresult,omitempty"`
-	IQ              int                `bson:"iq,omitempty" json:"iq,omitempty"`
-	Pomokitsesi     int                `bson:"pomokitsesi,omitempty" json:"pomokitsesi,omitempty"`
-	Pomokit         int                `bson:"pomokit,omitempty" json:"pomokit,omitempty"`                 //20 per cycle
-	MBC             float32            `bson:"mbc,omitempty" json:"mbc,omitempty"`                         //jumlah total mbc
-	MBCPoints       float64            `bson:"mbcPoints,omitempty" json:"mbcPoints,omitempty"`             //points for MBC contributions
-	RVN             float32            `bson:"rvn,omitempty" json:"rvn,omitempty"`                         //jumlah total rvn
-	RavencoinPoints float64            `bson:"ravencoinPoints,omitempty" json:"ravencoinPoints,omitempty"` //points for Ravencoin contributions
-	BlockChain      int                `bson:"blockchain,omitempty" json:"blockchain,omitempty"`           // dibagi rata2 kelas dikali 100
-	Rupiah          int                `bson:"rupiah,omitempty" json:"rupiah,omitempty"`                   //total nilai rupiah yang disetorkan
-	QRIS            int                `bson:"qris,omitempty" json:"qris,omitempty"`                       // dibagi rata2 kelas dikali 100
-	QRISPoints      float64            `bson:"qrisPoints,omitempty" json:"qrisPoints,omitempty"`           //points for QRIS contributions
-	Trackerdata     int                `bson:"trackerdata,omitempty" json:"trackerdata,omitempty"`         //jumlah total visitor
-	Tracker         float64            `bson:"tracker,omitempty" json:"tracker,omitempty"`                 //rata2 10 unique visitor sehari 100
-	BukuKatalog     string             `bson:"bukukatalog,omitempty" json:"bukukatalog,omitempty"`         //url katalog buku
-	BukPed          int                `bson:"bukped,omitempty" json:"bukped,omitempty"`                   //upload 25;approve 50;resi 75;deposit 100
-	JurnalWeb       string             `bson:"jurnalweb,omitempty" json:"jurnalweb,omitempty"`             //Alamat web jurnal
-	Jurnal          int                `bson:"jurnal,omitempty" json:"jurnal,omitempty"`                   //score jurnal
-	GTMetrixResult  string             `bson:"gtmetrixresult,omitempty" json:"gtmetrixresult,omitempty"`   //detaul score gtmetrix
-	GTMetrix        int                `bson:"gtmetrix,omitempty" json:"gtmetrix,omitempty"`               //A 100;B 75;C 50;D 25; E 0
-	WebHookpush     int                `bson:"webhookpush,omitempty" json:"webhookpush,omitempty"`         // jumlah push ke webhook
-	WebHook         int                `bson:"webhook,omitempty" json:"webhook,omitempty"`                 //maksimal 100 dari push github diambil dari seminggu terakhir
-	PresensiHari    int                `bson:"presensihari,omitempty" json:"presensihari,omitempty"`       //jumlah ari presensi
-	Presensi        int                `bson:"presensi,omitempty" json:"presensi,omitempty"`               //5*lengkap masuk dan pulang = 100
-	TotalScore      int                `bson:"total,omitempty" json:"total,omitempty"`
-	Approved        bool               `bson:"approved" json:"approved"`
-	Asesor          Userdomyikado      `bson:"asesor,omitempty" json:"asesor,omitempty"`
-	Validasi        int                `bson:"validasi,omitempty" json:"validasi,omitempty"` // rate bintang validasi
-	Komentar        string             `bson:"komentar,omitempty" json:"komentar,omitempty"` //komentar dari asesor
+	ID              primitive.ObjectID    `bson:"_id,omitempty" json:"_id,omitempty"`
+	CreatedAt       time.Time             `bson:"createdAt"`                                          //kalo lebih dari seminggu auto hapus
+	BimbinganKe     int                   `bson:"bimbinganke,omitempty" json:"bimbinganke,omitempty"` //bimbingan ke berapa
+	Username        string                `bson:"username,omitempty" json:"username,omitempty"`
+	PhoneNumber     string                `bson:"phonenumber,omitempty" json:"phonenumber,omitempty"`
+	Enroll          MasterEnrool          `bson:"enroll,omitempty" json:"enroll,omitempty"` //kelas atau proyek atau bimbingan
+	Sponsordata     int                   `bson:"sponsordata,omitempty" json:"sponsordata,omitempty"`
+	Sponsor         int                   `bson:"sponsor,omitempty" json:"sponsor,omitempty"` // lengkap 100, nomor 50, nama 50
+	StravaKM        float32               `bson:"stravakm,omitempty" json:"stravakm,omitempty"`
+	Strava          int                   `bson:"strava,omitempty" json:"strava,omitempty"` //perminggu dibagi 6KM dikali 100
+	IQresult        int                   `bson:"iqresult,omitempty" json:"iqresult,omitempty"`
+	IQ              int                   `bson:"iq,omitempty" json:"iq,omitempty"`
+	Pomokitsesi     int                   `bson:"pomokitsesi,omitempty" json:"pomokitsesi,omitempty"`
+	Pomokit         int                   `bson:"pomokit,omitempty" json:"pomokit,omitempty"`                 //20 per cycle
+	MBC             float32               `bson:"mbc,omitempty" json:"mbc,omitempty"`                         //jumlah total mbc
+	MBCPoints       float64               `bson:"mbcPoints,omitempty" json:"mbcPoints,omitempty"`             //points for MBC contributions
+	RVN             float32               `bson:"rvn,omitempty" json:"rvn,omitempty"`                         //jumlah total rvn
+	RavencoinPoints float64               `bson:"ravencoinPoints,omitempty" json:"ravencoinPoints,omitempty"` //points for Ravencoin contributions
+	BlockChain      int                   `bson:"blockchain,omitempty" json:"blockchain,omitempty"`           // dibagi rata2 kelas dikali 100
+	Rupiah          int                   `bson:"rupiah,omitempty" json:"rupiah,omitempty"`                   //total nilai rupiah yang disetorkan
+	QRIS            int                   `bson:"qris,omitempty" json:"qris,omitempty"`                       // dibagi rata2 kelas dikali 100
+	QRISPoints      float64               `bson:"qrisPoints,omitempty" json:"qrisPoints,omitempty"`           //points for QRIS contributions
+	Trackerdata     int                   `bson:"trackerdata,omitempty" json:"trackerdata,omitempty"`         //jumlah total visitor
+	Tracker         float64               `bson:"tracker,omitempty" json:"tracker,omitempty"`                 //rata2 10 unique visitor sehari 100
+	BukuKatalog     string                `bson:"bukukatalog,omitempty" json:"bukukatalog,omitempty"`         //url katalog buku
+	BukPed          int                   `bson:"bukped,omitempty" json:"bukped,omitempty"`                   //upload 25;approve 50;resi 75;deposit 100
+	JurnalWeb       string                `bson:"jurnalweb,omitempty" json:"jurnalweb,omitempty"`             //Alamat web jurnal
+	Jurnal          int                   `bson:"jurnal,omitempty" json:"jurnal,omitempty"`                   //score jurnal
+	GTMetrixResult  string                `bson:"gtmetrixresult,omitempty" json:"gtmetrixresult,omitempty"`   //detaul score gtmetrix
+	GTMetrix        int                   `bson:"gtmetrix,omitempty" json:"gtmetrix,omitempty"`               //A 100;B 75;C 50;D 25; E 0
+	WebHookpush     int                   `bson:"webhookpush,omitempty" json:"webhookpush,omitempty"`         // jumlah push ke webhook
+	WebHook         int                   `bson:"webhook,omitempty" json:"webhook,omitempty"`                 //maksimal 100 dari push github diambil dari seminggu terakhir
+	PresensiHari    int                   `bson:"presensihari,omitempty" json:"presensihari,omitempty"`       //jumlah ari presensi
+	Presensi        int                   `bson:"presensi,omitempty" json:"presensi,omitempty"`               //5*lengkap masuk dan pulang = 100
+	TotalScore      int                   `bson:"total,omitempty" json:"total,omitempty"`
+	RuleVersion     int                   `bson:"ruleversion,omitempty" json:"ruleversion,omitempty"` //versi ScoringRuleSet yang dipakai menghitung total, 0 berarti aturan bawaan
+	Sources         []ActivityScoreSource `bson:"sources,omitempty" json:"sources,omitempty"`         //status tiap sumber data saat skor dihitung
+	Approved        bool                  `bson:"approved" json:"approved"`
+	Asesor          Userdomyikado         `bson:"asesor,omitempty" json:"asesor,omitempty"`
+	Validasi        int                   `bson:"validasi,omitempty" json:"validasi,omitempty"` // rate bintang validasi
+	Komentar        string                `bson:"komentar,omitempty" json:"komentar,omitempty"` //komentar dari asesor
+}
+
+// status sumber data ActivityScore
+const (
+	SourceOK      = "ok"
+	SourceError   = "error"
+	SourceTimeout = "timeout"
+)
+
+// ActivityScoreSource mencatat hasil satu sumber data, dashboard memakai ini untuk menampilkan sumber yang tidak tersedia
+type ActivityScoreSource struct {
+	Source     string `bson:"source" json:"source"`
+	Status     string `bson:"status" json:"status"`
+	Error      string `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64  `bson:"durationms" json:"durationms"`
 }
 
 type Task struct {
@@ -0,0 +1,552 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ledger"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestBimbinganFlow: mahasiswa mengajukan bimbingan perdana ke dosen asesor, dosen memberi nilai dan approve
+func TestBimbinganFlow(t *testing.T) {
+	g := newGolden(t)
+	g.do("", http.MethodPost, "/data/proyek/bimbingan/perdana", bson.M{"asesor": bson.M{"phonenumber": dosenPhone}}, http.StatusUnauthorized)
+	res := g.do(mhs1Phone, http.MethodPost, "/data/proyek/bimbingan/perdana", bson.M{"asesor": bson.M{"phonenumber": "+62 811-0000-0002"}}, http.StatusOK)
+	if field(t, res, "asesor.phonenumber") != dosenPhone {
+		t.Fatalf("nomor asesor tidak dinormalisasi: %v", res)
+	}
+	bim, err := atdb.GetOneLatestDoc[model.ActivityScore](config.Mongoconn, "bimbingan", bson.M{"phonenumber": mhs1Phone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/data/proyek/bimbingan/" + bim.ID.Hex()
+	//hanya asesor yang dipilih yang boleh menilai
+	g.do(mhs2Phone, http.MethodPost, path, bson.M{"approved": true}, http.StatusForbidden)
+	g.do(dosenPhone, http.MethodPost, path, bson.M{"approved": true, "validasi": 5, "komentar": "Lanjutkan"}, http.StatusOK)
+	//bimbingan yang sudah approve tidak bisa diajukan ulang
+	g.do(mhs1Phone, http.MethodPost, "/data/proyek/bimbingan/perdana", bson.M{"asesor": bson.M{"phonenumber": dosenPhone}}, http.StatusConflict)
+
+	g.DB["bimbingan"] = findDocs(t, "bimbingan", bson.M{"phonenumber": mhs1Phone})
+	g.WA = waitWA(t, 2)
+	g.check()
+}
+
+// TestEventFlow: owner membuat event, mahasiswa claim dan submit tugas, owner approve dan poin tercatat
+func TestEventFlow(t *testing.T) {
+	g := newGolden(t)
+	event := bson.M{"name": "Event Test", "description": "Tulis artikel", "points": 10, "deadline_seconds": 3600}
+	g.do(mhs2Phone, http.MethodPost, "/api/event/create", event, http.StatusForbidden)
+	res := g.do(ownerPhone, http.MethodPost, "/api/event/create", event, http.StatusOK)
+	eventID := field(t, res, "data.event_id")
+
+	res = g.do(mhs2Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claimID := field(t, res, "data.claim_id")
+	//event yang sedang dikerjakan tidak bisa di-claim user lain
+	g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusConflict)
+	g.do(mhs2Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claimID, "task_link": "https://example.com/artikel"}, http.StatusOK)
+	g.do(mhs2Phone, http.MethodPost, "/api/event/approve", bson.M{"claim_id": claimID}, http.StatusForbidden)
+	g.do(ownerPhone, http.MethodPost, "/api/event/approve", bson.M{"claim_id": claimID}, http.StatusOK)
+
+	g.DB["events"] = findDocs(t, "events", bson.M{"name": "Event Test"})
+	g.DB["eventclaims"] = findDocs(t, "eventclaims", bson.M{"userphone": mhs2Phone})
+	g.DB["eventuserpoint"] = findDocs(t, "eventuserpoint", bson.M{"phone": mhs2Phone})
+	g.DB["bimbingan"] = findDocs(t, "bimbingan", bson.M{"phonenumber": mhs2Phone})
+	//notifikasi grup dikirim dari goroutine, notifikasi submit ke setiap owner
+	g.WA = waitWA(t, 2)
+	g.check()
+}
+
+// TestEventMarketplaceFlow: event dengan kuota dua claimant dan syarat poin, reviewer meminta revisi lalu menolak
+func TestEventMarketplaceFlow(t *testing.T) {
+	g := newGolden(t, "age_seconds")
+	res := g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Artikel", "description": "Tulis artikel", "points": 10, "deadline_seconds": 3600, "category": "artikel", "max_claimants": 2}, http.StatusOK)
+	eventID := field(t, res, "data.event_id")
+	res = g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Video", "description": "Buat video", "points": 20, "deadline_seconds": 3600, "category": "video", "eligibility": bson.M{"minpoints": 1000}}, http.StatusOK)
+	eventSenior := field(t, res, "data.event_id")
+	g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Minus", "description": "x", "points": 1, "deadline_seconds": 60, "max_claimants": -1}, http.StatusBadRequest)
+
+	res = g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claim1 := field(t, res, "data.claim_id")
+	res = g.do(mhs2Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claim2 := field(t, res, "data.claim_id")
+	//kuota dua claimant sudah penuh
+	g.do(dosenPhone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusConflict)
+	g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventSenior}, http.StatusPreconditionFailed)
+	g.do(dosenPhone, http.MethodGet, "/api/event/all", nil, http.StatusOK)
+
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim1, "task_link": "https://example.com/artikel-1"}, http.StatusOK)
+	g.do(mhs2Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim2, "task_link": "https://example.com/artikel-2"}, http.StatusOK)
+	g.do(mhs1Phone, http.MethodGet, "/api/event/queue", nil, http.StatusForbidden)
+	res = g.do(ownerPhone, http.MethodGet, "/api/event/queue", nil, http.StatusOK)
+	if queue := field(t, res, "data").([]any); len(queue) != 2 || field(t, queue[0], "claim_id") != claim1 {
+		t.Fatalf("antrian harus berisi dua claim dengan claim terlama di depan: %v", queue)
+	}
+
+	g.do(ownerPhone, http.MethodPost, "/api/event/review", bson.M{"claim_id": claim1, "action": "revision"}, http.StatusBadRequest)
+	g.do(ownerPhone, http.MethodPost, "/api/event/review", bson.M{"claim_id": claim1, "action": "revision", "comment": "Tambahkan daftar pustaka"}, http.StatusOK)
+	g.do(mhs1Phone, http.MethodGet, "/api/event/myclaims", nil, http.StatusOK)
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim1, "task_link": "https://example.com/artikel-1-revisi"}, http.StatusOK)
+	g.do(ownerPhone, http.MethodPost, "/api/event/review", bson.M{"claim_id": claim2, "action": "reject", "comment": "Artikel bukan karya sendiri"}, http.StatusOK)
+	//claim yang ditolak membebaskan kuota tapi tidak bisa diulang oleh user yang sama
+	g.do(mhs2Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusConflict)
+	g.do(dosenPhone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	g.do(ownerPhone, http.MethodGet, "/api/event/queue?category=video", nil, http.StatusOK)
+	g.do(ownerPhone, http.MethodGet, "/api/event/queue?category=artikel", nil, http.StatusOK)
+	g.do(ownerPhone, http.MethodPost, "/api/event/approve", bson.M{"claim_id": claim1}, http.StatusOK)
+	g.do(ownerPhone, http.MethodGet, "/api/event/allclaims", nil, http.StatusOK)
+
+	g.DB["eventclaims"] = findDocs(t, "eventclaims", bson.M{})
+	//claimcount tinggal satu kursi milik dosen setelah claim1 diapprove dan claim2 ditolak
+	g.DB["events"] = findDocs(t, "events", bson.M{"name": "Event Artikel"})
+	//notifikasi grup untuk dua event baru, submit ke owner tiga kali, revisi dan penolakan
+	g.WA = waitWA(t, 7)
+	g.check()
+}
+
+// TestEventLinkCheckFlow: link tugas dicek domain dan link ganda sebelum masuk antrian review
+func TestEventLinkCheckFlow(t *testing.T) {
+	g := newGolden(t)
+	res := g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Repo", "description": "Buat repo", "points": 10, "deadline_seconds": 3600, "max_claimants": 3, "allowed_domains": []string{"https://GitHub.com/"}, "allow_own_hostname": true}, http.StatusOK)
+	eventID := field(t, res, "data.event_id")
+	res = g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claim1 := field(t, res, "data.claim_id")
+	res = g.do(mhs2Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claim2 := field(t, res, "data.claim_id")
+	res = g.do(dosenPhone, http.MethodPost, "/api/event/claim", bson.M{"event_id": eventID}, http.StatusOK)
+	claim3 := field(t, res, "data.claim_id")
+
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim1, "task_link": "repo saya"}, http.StatusBadRequest)
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim1, "task_link": "https://gitlab.com/mhs1/repo"}, http.StatusPreconditionFailed)
+	//Project_Hostname proyek sendiri diterima
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim1, "task_link": "https://proyek-dev.do.my.id/artikel"}, http.StatusOK)
+	//link yang sama dengan variasi www, slash dan utm tetap terdeteksi ganda, claim masuk antrian dengan tanda
+	g.do(mhs2Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim2, "task_link": "http://www.proyek-dev.do.my.id/artikel/?utm_source=wa"}, http.StatusOK)
+	//dosen tidak tergabung di proyek-dev jadi hostname proyek itu bukan miliknya
+	g.do(dosenPhone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim3, "task_link": "https://proyek-dev.do.my.id/dosen"}, http.StatusPreconditionFailed)
+	g.do(dosenPhone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": claim3, "task_link": "https://gist.github.com/dosen/artikel"}, http.StatusOK)
+	res = g.do(ownerPhone, http.MethodGet, "/data/event/approval/"+claim1.(string), nil, http.StatusOK)
+	if field(t, res, "linkcheck.passed") != true {
+		t.Fatalf("hasil cek link harus tampil di data approval: %v", res)
+	}
+	res = g.do(ownerPhone, http.MethodGet, "/api/event/queue", nil, http.StatusOK)
+	for _, item := range field(t, res, "data").([]interface{}) {
+		q := item.(map[string]interface{})
+		if flagged := q["claim_id"] == claim2; q["flagged"] != flagged {
+			t.Fatalf("hanya claim dengan link ganda yang ditandai: %v", q)
+		}
+	}
+
+	g.DB["events"] = findDocs(t, "events", bson.M{"name": "Event Repo"})
+	g.DB["eventclaims"] = findDocs(t, "eventclaims", bson.M{})
+	//notifikasi grup event baru dan tiga submit ke owner, satu di antaranya ditandai
+	g.WA = waitWA(t, 4)
+	g.check()
+}
+
+// TestEventJadwalFlow: event mingguan terjadwal diaktifkan cron sekali saja, event berikutnya disiapkan lalu event berakhir otomatis
+func TestEventJadwalFlow(t *testing.T) {
+	g := newGolden(t)
+	event := bson.M{
+		"name": "Tantangan Mingguan", "description": "Tulis artikel mingguan", "points": 5, "deadline_seconds": 3600,
+		"start_at": "2099-01-05T08:00:00+07:00", "end_at": "2099-01-07T08:00:00+07:00",
+		"recurrence": bson.M{"frequency": "weekly", "count": 3},
+	}
+	g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{
+		"name": "Salah", "description": "x", "points": 5, "deadline_seconds": 3600,
+		"start_at": "2099-01-05T08:00:00+07:00", "end_at": "2099-01-20T08:00:00+07:00", "recurrence": bson.M{"frequency": "weekly"},
+	}, http.StatusBadRequest)
+	res := g.do(ownerPhone, http.MethodPost, "/api/event/create", event, http.StatusOK)
+	eventID, err := primitive.ObjectIDFromHex(field(t, res, "data.event_id").(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.do(mhs1Phone, http.MethodGet, "/api/event/all", nil, http.StatusOK)
+	g.do("", http.MethodGet, "/refresh/event/jadwal", nil, http.StatusOK)
+
+	//waktu tayang dimajukan supaya cron menganggapnya sudah tiba
+	now := time.Now()
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "events", bson.M{"_id": eventID}, bson.M{"startat": now.Add(-time.Hour), "endat": now.Add(47 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	g.do("", http.MethodGet, "/refresh/event/jadwal", nil, http.StatusOK)
+	//cron berikutnya tidak mengumumkan ulang
+	g.do("", http.MethodGet, "/refresh/event/jadwal", nil, http.StatusOK)
+	g.do(mhs1Phone, http.MethodGet, "/api/event/all", nil, http.StatusOK)
+
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "events", bson.M{"_id": eventID}, bson.M{"endat": now.Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	g.do("", http.MethodGet, "/refresh/event/jadwal", nil, http.StatusOK)
+
+	g.DB["events"] = findDocs(t, "events", bson.M{})
+	if events := g.DB["events"]; len(events) != 2 || field(t, events[1], "occurrence") != float64(2) || field(t, events[1], "isactive") != false {
+		t.Fatalf("seharusnya ada satu event berikutnya yang belum aktif: %v", events)
+	}
+
+	//seri yang sudah tayang tanpa lanjutan, misalnya pembuatan lanjutannya gagal, disusulkan cron berikutnya
+	yatim, err := atdb.InsertOneDoc(config.Mongoconn, "events", model.Event{
+		Name: "Tantangan Harian", Points: 1, IsActive: true, StartAt: now.Add(-time.Hour), AnnouncedAt: now.Add(-time.Hour),
+		Occurrence: 1, Recurrence: &model.EventRecurrence{Frequency: model.RecurrenceDaily},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.do("", http.MethodGet, "/refresh/event/jadwal", nil, http.StatusOK)
+	if n, err := atdb.GetCountDoc(config.Mongoconn, "events", bson.M{"seriesid": yatim, "occurrence": 2}); err != nil || n != 1 {
+		t.Fatalf("lanjutan seri tanpa event berikutnya seharusnya dibuat: %d %v", n, err)
+	}
+	g.WA = waitWA(t, 1)
+	g.check()
+}
+
+// TestStoreFlow: owner mengisi katalog, mahasiswa membeli dengan pointevent, owner refund dan poin kembali
+func TestStoreFlow(t *testing.T) {
+	g := newGolden(t)
+	_, _, err := ledger.Post(config.Mongoconn, bson.M{"phonenumber": mhs1Phone}, ledger.Posting{Account: model.AkunPointEvent, Amount: 45, Source: "event"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sertifikat := bson.M{"kode": "sertifikat", "nama": "Sertifikat Cetak", "harga": 20, "stok": 1, "batas_per_user": 1, "fulfillment": "sertifikat", "isactive": true}
+	g.do(mhs1Phone, http.MethodPost, "/api/store/item", sertifikat, http.StatusForbidden)
+	g.do(ownerPhone, http.MethodPost, "/api/store/item", bson.M{"kode": "x", "nama": "X", "harga": 5, "stok": -1, "fulfillment": "kodewaktu", "isactive": true}, http.StatusBadRequest)
+	res := g.do(ownerPhone, http.MethodPost, "/api/store/item", sertifikat, http.StatusOK)
+	sertifikatID := field(t, res, "_id")
+	res = g.do(ownerPhone, http.MethodPost, "/api/store/item", bson.M{"kode": "perpanjang", "nama": "Perpanjang Deadline 1 Jam", "harga": 5, "stok": -1, "fulfillment": "perpanjangdeadline", "durasi_detik": 3600, "isactive": true}, http.StatusOK)
+	perpanjangID := field(t, res, "_id")
+	g.do(mhs1Phone, http.MethodGet, "/api/store/item", nil, http.StatusOK)
+
+	g.do(mhs1Phone, http.MethodPost, "/api/store/buy-bimbingan-code", nil, http.StatusOK)
+	g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID}, http.StatusBadRequest)
+	res = g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID, "catatan": "Mahasiswa Dev Satu, Jl. Sariasih 54"}, http.StatusOK)
+	beliSertifikat := field(t, res, "data.pembelian._id").(string)
+	//stok sertifikat hanya satu
+	g.do(mhs2Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID, "catatan": "Mahasiswa Dev Dua"}, http.StatusConflict)
+
+	res = g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Toko", "description": "Tulis artikel", "points": 10, "deadline_seconds": 3600}, http.StatusOK)
+	res = g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": field(t, res, "data.event_id")}, http.StatusOK)
+	claimID := field(t, res, "data.claim_id")
+	g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": perpanjangID}, http.StatusBadRequest)
+	res = g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": perpanjangID, "claim_id": claimID}, http.StatusOK)
+	beliPerpanjang := field(t, res, "data.pembelian._id").(string)
+	//sisa poin 45-15-20-5 = 5, tidak cukup untuk code bimbingan
+	g.do(mhs1Phone, http.MethodPost, "/api/store/buy-bimbingan-code", nil, http.StatusPreconditionFailed)
+
+	g.do(ownerPhone, http.MethodPut, "/api/store/pembelian/refund/"+beliSertifikat, bson.M{}, http.StatusBadRequest)
+	g.do(ownerPhone, http.MethodPut, "/api/store/pembelian/refund/"+beliSertifikat, bson.M{"alasan": "Stok kertas habis"}, http.StatusOK)
+	g.do(ownerPhone, http.MethodPut, "/api/store/pembelian/refund/"+beliSertifikat, bson.M{"alasan": "Stok kertas habis"}, http.StatusConflict)
+	g.do(ownerPhone, http.MethodPut, "/api/store/pembelian/selesai/"+beliSertifikat, nil, http.StatusNotFound)
+	g.do(ownerPhone, http.MethodPut, "/api/store/pembelian/refund/"+beliPerpanjang, bson.M{"alasan": "Salah beli"}, http.StatusOK)
+	//refund mengembalikan stok, mahasiswa lain bisa membeli tapi poinnya kosong
+	g.do(mhs2Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID, "catatan": "Mahasiswa Dev Dua"}, http.StatusPreconditionFailed)
+	g.do(mhs1Phone, http.MethodGet, "/api/store/pembelian", nil, http.StatusOK)
+
+	user, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": mhs1Phone})
+	if err != nil || user.PointEvent != 30 {
+		t.Fatalf("poin setelah dua refund seharusnya 30: %d %v", user.PointEvent, err)
+	}
+	//refund juga mengembalikan kuota batas_per_user, pembelian berikutnya ditolak karena batas satu per user
+	g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID, "catatan": "Mahasiswa Dev Satu, Jl. Sariasih 54"}, http.StatusOK)
+	g.do(mhs1Phone, http.MethodPost, "/api/store/beli", bson.M{"item_id": sertifikatID, "catatan": "Mahasiswa Dev Satu, Jl. Sariasih 54"}, http.StatusConflict)
+	g.DB["storekuota"] = findDocs(t, "storekuota", bson.M{})
+	g.DB["storeitem"] = findDocs(t, "storeitem", bson.M{})
+	g.DB["storepembelian"] = findDocs(t, "storepembelian", bson.M{})
+	g.DB["poinledger"] = findDocs(t, "poinledger", bson.M{"source": "store"})
+	g.DB["eventclaims"] = findDocs(t, "eventclaims", bson.M{})
+	//notifikasi grup event baru dan dua notifikasi refund
+	g.WA = waitWA(t, 3)
+	g.check()
+}
+
+// TestCrowdfundingFlow: order QRIS dibuat, dicek masih pending, dikonfirmasi manual lalu tercatat sukses
+func TestCrowdfundingFlow(t *testing.T) {
+	g := newGolden(t, "amount", "uniqueCode", "payAmount", "matchKey", "totalAmount", "totalQRISAmount")
+	g.do(mhs2Phone, http.MethodPost, "/api/crowdfunding/qris/createOrder", bson.M{"amount": 0}, http.StatusBadRequest)
+	res := g.do(mhs2Phone, http.MethodPost, "/api/crowdfunding/qris/createOrder", bson.M{"amount": 10000}, http.StatusOK)
+	orderID := field(t, res, "orderId").(string)
+	amount := field(t, res, "amount").(float64)
+	if amount <= 10000 || amount > 10000+999 {
+		t.Fatalf("jumlah bayar %v harus 10000 ditambah kode unik", amount)
+	}
+	//satu user hanya boleh punya satu pembayaran QRIS yang pending
+	res = g.do(mhs2Phone, http.MethodPost, "/api/crowdfunding/qris/createOrder", bson.M{"amount": 5000}, http.StatusOK)
+	if field(t, res, "orderId") != orderID {
+		t.Fatalf("order kedua seharusnya diarahkan ke order %s: %v", orderID, res)
+	}
+	g.do("", http.MethodGet, "/api/crowdfunding/qris/checkPayment/"+orderID, nil, http.StatusOK)
+	//konfirmasi manual hanya untuk owner, pembeli sendiri tidak bisa menandai ordernya lunas
+	g.do("", http.MethodPost, "/api/crowdfunding/qris/confirm/"+orderID, nil, http.StatusUnauthorized)
+	g.do(mhs2Phone, http.MethodPost, "/api/crowdfunding/qris/confirm/"+orderID, nil, http.StatusForbidden)
+	g.do(ownerPhone, http.MethodPost, "/api/crowdfunding/qris/confirm/"+orderID, nil, http.StatusOK)
+	//konfirmasi ulang tidak menambah total dua kali
+	g.do(ownerPhone, http.MethodPost, "/api/crowdfunding/qris/confirm/"+orderID, nil, http.StatusConflict)
+	g.do("", http.MethodGet, "/api/crowdfunding/qris/checkPayment/"+orderID, nil, http.StatusOK)
+
+	g.DB["crowdfundingorders"] = findDocs(t, "crowdfundingorders", bson.M{"orderId": orderID})
+	g.DB["crowdfundingslots"] = findDocs(t, "crowdfundingslots", bson.M{"orderId": orderID})
+	g.DB["crowdfundingtotals"] = findDocs(t, "crowdfundingtotals", bson.M{})
+	//jumlah disamarkan di golden, pastikan order dan total memakai jumlah yang sama dengan respon
+	if orders := g.DB["crowdfundingorders"]; len(orders) != 1 || field(t, orders[0], "amount") != amount {
+		t.Fatalf("jumlah order tidak sesuai: %v", orders)
+	}
+	if totals := g.DB["crowdfundingtotals"]; len(totals) != 1 || field(t, totals[0], "totalQRISAmount") != amount {
+		t.Fatalf("total QRIS tidak sesuai: %v", totals)
+	}
+	g.check()
+}
+
+// TestSidangFlow: dosen membuka slot, admin menjadwalkan dua sidang tanpa bentrok, lalu memindah dan membatalkan
+func TestSidangFlow(t *testing.T) {
+	g := newGolden(t)
+	//tanggal jauh di depan supaya pesan WA yang memuat tanggal tetap sama di golden
+	slot := func(mulai, selesai string) bson.M {
+		return bson.M{"mulai": "2099-03-02T" + mulai + ":00+07:00", "selesai": "2099-03-02T" + selesai + ":00+07:00"}
+	}
+	g.do(mhs1Phone, http.MethodPost, "/api/sidang/slot", slot("08:00", "12:00"), http.StatusForbidden)
+	res := g.do(dosenPhone, http.MethodPost, "/api/sidang/slot", slot("08:00", "12:00"), http.StatusOK)
+	slotDosen := field(t, res, "_id").(string)
+	g.do(dosenPhone, http.MethodPost, "/api/sidang/slot", slot("11:00", "13:00"), http.StatusConflict)
+	g.do(ownerPhone, http.MethodPost, "/api/sidang/slot", slot("09:00", "12:00"), http.StatusOK)
+
+	var pengajuanID [2]any
+	for i, mhs := range []string{mhs1Phone, mhs2Phone} {
+		id, err := atdb.InsertOneDoc(config.Mongoconn, "bimbingan_pengajuan", model.BimbinganPengajuan{
+			Name: "Mahasiswa " + mhs, PhoneNumber: mhs, NomorKelompok: "1",
+			DosenPembimbing: "Dosen Dev", DosenPembimbingPhone: dosenPhone,
+			DosenPenguji: "Owner Dev", DosenPengujiPhone: ownerPhone, Status: "pending",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pengajuanID[i] = id
+	}
+	//penguji belum membuka slot jam 8
+	manual := slot("08:00", "09:00")
+	manual["pengajuanid"], manual["ruang"] = pengajuanID[0], "R1"
+	g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal", manual, http.StatusPreconditionFailed)
+	otomatis := bson.M{"pengajuanid": pengajuanID[0], "ruang": []string{"R1", "R2"}, "dari": "2099-03-02T00:00:00+07:00", "sampai": "2099-03-03T00:00:00+07:00"}
+	g.do(mhs1Phone, http.MethodPost, "/api/sidang/jadwal/otomatis", otomatis, http.StatusForbidden)
+	res = g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal/otomatis", otomatis, http.StatusOK)
+	jadwal1 := field(t, res, "data._id").(string)
+	g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal/otomatis", otomatis, http.StatusConflict)
+	//slot yang dipakai jadwal aktif tidak bisa dihapus
+	g.do(dosenPhone, http.MethodDelete, "/api/sidang/slot/"+slotDosen, nil, http.StatusConflict)
+
+	//dosen yang sama tidak bisa dipakai di ruang lain pada jam yang beririsan
+	manual = slot("09:30", "10:30")
+	manual["pengajuanid"], manual["ruang"] = pengajuanID[1], "R2"
+	g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal", manual, http.StatusConflict)
+	otomatis["pengajuanid"] = pengajuanID[1]
+	//mahasiswa sedang dijadwalkan proses lain, kunci yang kedaluwarsa boleh diambil alih
+	kunci := bson.M{"_id": "peserta:" + mhs2Phone + ":2099-03-02"}
+	if _, err := config.Mongoconn.Collection("sidangkunci").InsertOne(context.Background(), bson.M{"_id": kunci["_id"], "sampai": time.Now().Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal/otomatis", otomatis, http.StatusConflict)
+	if _, err := config.Mongoconn.Collection("sidangkunci").UpdateOne(context.Background(), kunci, bson.M{"$set": bson.M{"sampai": time.Now().Add(-time.Minute)}}); err != nil {
+		t.Fatal(err)
+	}
+	res = g.do(ownerPhone, http.MethodPost, "/api/sidang/jadwal/otomatis", otomatis, http.StatusOK)
+	jadwal2 := field(t, res, "data._id").(string)
+
+	pindah := slot("10:00", "11:00")
+	pindah["alasan"] = "Penguji rapat"
+	g.do(ownerPhone, http.MethodPut, "/api/sidang/jadwal/"+jadwal1, pindah, http.StatusConflict)
+	pindah = slot("11:00", "12:00")
+	pindah["alasan"] = "Penguji rapat"
+	g.do(ownerPhone, http.MethodPut, "/api/sidang/jadwal/"+jadwal1, pindah, http.StatusOK)
+	g.do(ownerPhone, http.MethodPut, "/api/sidang/jadwal/batal/"+jadwal2, bson.M{}, http.StatusBadRequest)
+	g.do(ownerPhone, http.MethodPut, "/api/sidang/jadwal/batal/"+jadwal2, bson.M{"alasan": "Berkas belum lengkap"}, http.StatusOK)
+	g.do(ownerPhone, http.MethodPut, "/api/sidang/jadwal/batal/"+jadwal2, bson.M{"alasan": "Berkas belum lengkap"}, http.StatusConflict)
+	g.do(mhs2Phone, http.MethodGet, "/api/sidang/jadwal", nil, http.StatusOK)
+
+	g.DB["sidangjadwal"] = findDocs(t, "sidangjadwal", bson.M{})
+	g.DB["sidangkunci"] = findDocs(t, "sidangkunci", bson.M{})
+	g.DB["bimbingan_pengajuan"] = findDocs(t, "bimbingan_pengajuan", bson.M{})
+	//jadwal 1, jadwal 2, pindah dan batal masing-masing ke mahasiswa, pembimbing dan penguji
+	g.WA = waitWA(t, 12)
+	g.check()
+}
+
+// TestPomokitFlagFlow: sesi bersamaan di dua perangkat dan sesi tanpa screenshot ditahan sampai dosen mereview
+func TestPomokitFlagFlow(t *testing.T) {
+	g := newGolden(t)
+	stub := filepath.Join(t.TempDir(), "pomokit.dev", "api")
+	if err := os.MkdirAll(stub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sesi := func(id, phone, host string, cycle, screenshots int, selesai string) bson.M {
+		return bson.M{"_id": id, "name": "Mahasiswa " + phone, "phonenumber": phone, "cycle": cycle, "hostname": host, "ip": "10.0.0.1",
+			"screenshots": screenshots, "wagroupid": "120363000000000001", "createdAt": "2099-03-02T" + selesai + ":00+07:00"}
+	}
+	b, _ := json.Marshal([]bson.M{
+		sesi("66f000000000000000000201", mhs1Phone, "laptop", 2, 2, "09:00"),
+		sesi("66f000000000000000000202", mhs1Phone, "pc-lab", 2, 2, "09:20"),
+		sesi("66f000000000000000000203", mhs2Phone, "laptop", 2, 0, "09:00"),
+		sesi("66f000000000000000000204", mhs2Phone, "laptop", 1, 1, "11:00"),
+	})
+	if err := os.WriteFile(filepath.Join(stub, "report.json"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := config.DevStubDir
+	config.DevStubDir = filepath.Dir(filepath.Dir(stub))
+	t.Cleanup(func() { config.DevStubDir = dir })
+
+	g.do("", http.MethodGet, "/refresh/pomokit/flag", nil, http.StatusOK)
+	g.do(mhs1Phone, http.MethodGet, "/api/pomokit/flag", nil, http.StatusForbidden)
+	res := g.do(dosenPhone, http.MethodGet, "/api/pomokit/flag?phonenumber="+mhs2Phone, nil, http.StatusOK)
+	flags := res.([]any)
+	if len(flags) != 1 {
+		t.Fatalf("sesi tanpa screenshot harus ditahan: %v", flags)
+	}
+	path := "/api/pomokit/flag/" + field(t, flags[0], "_id").(string)
+	g.do(dosenPhone, http.MethodPut, path, bson.M{"status": "ditahan"}, http.StatusBadRequest)
+	g.do(dosenPhone, http.MethodPut, path, bson.M{"status": "valid", "catatan": "Screenshot dikirim manual"}, http.StatusOK)
+	//sesi yang sudah valid tidak ditahan lagi saat analisis berikutnya
+	res = g.do("", http.MethodGet, "/refresh/pomokit/flag", nil, http.StatusOK)
+	if field(t, res, "response") != "2 sesi dihitung, 2 sesi ditahan" {
+		t.Fatalf("hasil analisis ulang: %v", res)
+	}
+
+	g.DB["pomokitflag"] = findDocs(t, "pomokitflag", bson.M{})
+	g.check()
+}
+
+// TestWebhookPushFlow: push Bitbucket dengan author yang belum terhubung ditolak tanpa ada commit yang tercatat,
+// commit yang sama di proyek fork tetap mendapat poin karena commitsha hanya unik per proyek
+func TestWebhookPushFlow(t *testing.T) {
+	g := newGolden(t)
+	proyek, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", bson.M{"name": "proyek-dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proyek.Members[0].GitHostUsername = "mhs1-dev"
+	proyek.Members[1].GitHostUsername = "mhs2-dev"
+	if _, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", bson.M{"_id": proyek.ID}, proyek); err != nil {
+		t.Fatal(err)
+	}
+	fork := proyek
+	fork.ID = primitive.NewObjectID()
+	fork.Name = "proyek-fork"
+	if _, err = atdb.InsertOneDoc(config.Mongoconn, "project", fork); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "user", bson.M{"phonenumber": mhs1Phone}, bson.M{"githostusername": "mhs1-dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	komit := func(sha, nick string) bson.M {
+		return bson.M{"hash": sha, "message": "commit " + sha, "author": bson.M{"raw": nick + " <" + nick + "@git.local>", "user": bson.M{"nickname": nick}}}
+	}
+	push := func(nama, delivery string, wantStatus int, commits ...bson.M) any {
+		t.Helper()
+		body, err := json.Marshal(bson.M{
+			"actor":      bson.M{"nickname": "mhs1-dev"},
+			"repository": bson.M{"name": nama},
+			"push":       bson.M{"changes": []bson.M{{"new": bson.M{"name": "main"}, "commits": commits}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mac := hmac.New(sha256.New, []byte(proyek.Secret))
+		mac.Write(body)
+		req := httptest.NewRequest(http.MethodPost, "/webhook/bitbucket/"+nama, bytes.NewReader(body))
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		req.Header.Set("X-Event-Key", "repo:push")
+		if delivery != "" {
+			req.Header.Set("X-Request-UUID", delivery)
+		}
+		rec := httptest.NewRecorder()
+		URL(rec, req)
+		var res any
+		if err = json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("respon bukan JSON: %s", rec.Body)
+		}
+		if rec.Code != wantStatus {
+			t.Fatalf("push %s: status %d, seharusnya %d: %v", delivery, rec.Code, wantStatus, res)
+		}
+		g.Steps = append(g.Steps, goldenStep{Request: "POST /webhook/bitbucket/" + nama + " " + delivery, Status: rec.Code, Body: res})
+		return res
+	}
+	pushrepo := func(nama string) int {
+		return len(findDocs(t, "pushrepo", bson.M{"projectname": nama}))
+	}
+
+	//author kedua bukan anggota proyek, commit pertama tidak boleh ikut tercatat
+	push("proyek-dev", "delivery-1", http.StatusNotFound, komit("aaa111", "mhs1-dev"), komit("bbb222", "orang-luar"))
+	//author kedua anggota proyek tapi belum terhubung ke user sistem
+	push("proyek-dev", "delivery-2", http.StatusNotFound, komit("aaa111", "mhs1-dev"), komit("bbb222", "mhs2-dev"))
+	if n := pushrepo("proyek-dev"); n != 0 {
+		t.Fatalf("push yang ditolak meninggalkan %d commit", n)
+	}
+	push("proyek-dev", "delivery-3", http.StatusOK, komit("aaa111", "mhs1-dev"), komit("ccc333", "mhs1-dev"))
+	res := push("proyek-dev", "delivery-4", http.StatusOK, komit("aaa111", "mhs1-dev"))
+	if field(t, res, "info") != "0 commit baru tercatat, 1 commit sudah pernah tercatat" {
+		t.Fatalf("commit ulang di proyek yang sama: %v", res)
+	}
+	res = push("proyek-fork", "delivery-5", http.StatusOK, komit("aaa111", "mhs1-dev"))
+	if field(t, res, "info") != "1 commit baru tercatat" {
+		t.Fatalf("commit yang sama di proyek fork: %v", res)
+	}
+	if pushrepo("proyek-dev") != 2 || pushrepo("proyek-fork") != 1 {
+		t.Fatalf("pushrepo proyek-dev %d, proyek-fork %d", pushrepo("proyek-dev"), pushrepo("proyek-fork"))
+	}
+	//tanpa header delivery, ID cadangan memuat ID proyek sehingga push fork dengan head yang sama tetap diproses
+	push("proyek-dev", "", http.StatusOK, komit("ddd444", "mhs1-dev"))
+	push("proyek-fork", "", http.StatusOK, komit("ddd444", "mhs1-dev"))
+	if pushrepo("proyek-dev") != 3 || pushrepo("proyek-fork") != 2 {
+		t.Fatalf("push tanpa header delivery: proyek-dev %d, proyek-fork %d", pushrepo("proyek-dev"), pushrepo("proyek-fork"))
+	}
+	usr, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": mhs1Phone})
+	if err != nil || usr.Poin != 25 {
+		t.Fatalf("poin mhs1 = %v %v, seharusnya 25", usr.Poin, err)
+	}
+
+	g.DB["poinledger"] = findDocs(t, "poinledger", bson.M{"source": "pushrepo"})
+	g.WA = waitWA(t, 4)
+	g.check()
+}
+
+// TestRBACScopeFlow: role yang dibatasi satu enroll tidak berlaku sebagai izin global
+func TestRBACScopeFlow(t *testing.T) {
+	g := newGolden(t)
+	g.do(ownerPhone, http.MethodPost, "/api/rbac/role", bson.M{"phonenumber": dosenPhone, "role": "owner", "scope": "kelasai"}, http.StatusOK)
+	g.do(ownerPhone, http.MethodPost, "/api/rbac/role", bson.M{"phonenumber": mhs2Phone, "role": "asesor", "scope": "kelasai"}, http.StatusOK)
+	//owner satu enroll tidak bisa menjadikan dirinya owner global
+	g.do(dosenPhone, http.MethodPost, "/api/rbac/role", bson.M{"phonenumber": dosenPhone, "role": "owner"}, http.StatusForbidden)
+	g.do(dosenPhone, http.MethodGet, "/api/scoringrule/kelasai", nil, http.StatusOK)
+	g.do(dosenPhone, http.MethodGet, "/api/scoringrule/kelasws", nil, http.StatusForbidden)
+	res := g.do(dosenPhone, http.MethodGet, "/api/rbac/me", nil, http.StatusOK)
+	if _, ok := field(t, res, "perscope").(map[string]any)["kelasai"]; !ok {
+		t.Fatalf("permission enroll tidak ditampilkan: %v", res)
+	}
+
+	//asesor kelasai hanya bisa menilai bimbingan enroll kelasai
+	for _, kode := range []string{"kelasai", "kelasws"} {
+		bim := model.ActivityScore{PhoneNumber: mhs1Phone, Enroll: model.MasterEnrool{Kode: kode}, Asesor: model.Userdomyikado{PhoneNumber: mhs2Phone}}
+		id, err := atdb.InsertOneDoc(config.Mongoconn, "bimbingan", bim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := http.StatusOK
+		if kode != "kelasai" {
+			want = http.StatusForbidden
+		}
+		g.do(mhs2Phone, http.MethodPost, "/data/proyek/bimbingan/"+id.Hex(), bson.M{"approved": true, "validasi": 4, "komentar": "Oke"}, want)
+	}
+	g.check()
+}
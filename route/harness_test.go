@@ -0,0 +1,361 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/devmode"
+	"github.com/gocroot/helper/linkcheck"
+	"github.com/gocroot/helper/mongotest"
+	"github.com/gocroot/helper/openapi"
+	"github.com/gocroot/helper/watoken"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jalankan go test ./route -update setelah perubahan respon yang disengaja, lalu review diff testdata/golden
+var update = flag.Bool("update", false, "tulis ulang testdata/golden")
+
+// Nomor fixture helper/devmode/fixture/user.json
+const (
+	ownerPhone = "6281100000001"
+	dosenPhone = "6281100000002"
+	mhs1Phone  = "6281100000003"
+	mhs2Phone  = "6281100000004"
+)
+
+// TestMain menyiapkan MongoDB sekali pakai berisi fixture devmode dan server tiruan untuk semua request keluar.
+// MongoDB dipilih berurutan: MONGOTEST_URI, mongod di PATH, lalu helper/mongotest di memori.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	uri, stop, err := startMongo()
+	if err != nil {
+		log.Fatalf("gagal menjalankan MongoDB test: %v", err)
+	}
+	code := run(m, uri)
+	stop()
+	os.Exit(code)
+}
+
+func run(m *testing.M, uri string) int {
+	dbname := "domyid_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	db, err := atdb.MongoConnect(atdb.DBInfo{DBString: uri, DBName: dbname})
+	if err != nil {
+		log.Printf("gagal konek MongoDB test: %v", err)
+		return 1
+	}
+	defer db.Client().Disconnect(context.Background())
+	defer db.Drop(context.Background())
+	config.Mongoconn, config.ErrorMongoconn = db, nil
+	config.PrivateKey = config.DevPrivateKey
+	config.PhoneNumber = config.DevPhoneNumber
+	fake := httptest.NewServer(devmode.Server())
+	defer fake.Close()
+	atapi.Transport = devmode.Transport{Addr: fake.Listener.Addr().String()}
+	linkcheck.Transport = atapi.Transport
+	return m.Run()
+}
+
+func startMongo() (uri string, stop func(), err error) {
+	if uri = os.Getenv("MONGOTEST_URI"); uri != "" {
+		return uri, func() {}, nil
+	}
+	if bin, lookErr := exec.LookPath("mongod"); lookErr == nil {
+		return startMongod(bin)
+	}
+	srv, err := mongotest.Start()
+	if err != nil {
+		return "", nil, err
+	}
+	return srv.URI(), func() { srv.Close() }, nil
+}
+
+// startMongod menjalankan mongod dengan data di folder sementara, dihapus lagi setelah test selesai
+func startMongod(bin string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "mongotest")
+	if err != nil {
+		return "", nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	addr := ln.Addr().String()
+	_, port, _ := net.SplitHostPort(addr)
+	ln.Close()
+	cmd := exec.Command(bin, "--dbpath", dir, "--port", port, "--bind_ip", "127.0.0.1", "--quiet")
+	if err = cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+	}
+	for i := 0; i < 100; i++ {
+		if conn, dialErr := net.Dial("tcp", addr); dialErr == nil {
+			conn.Close()
+			return "mongodb://" + addr + "/?directConnection=true", stop, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	stop()
+	return "", nil, fmt.Errorf("mongod tidak bisa dihubungi di %s", addr)
+}
+
+// apiDoc adalah dokumen OpenAPI yang sama dengan /openapi.json, setiap respon di test dicocokkan dengannya
+var apiDoc = openapi.Build(mux.Routes(), openapi.Operations)
+
+// call mengirim request ke route.URL dengan token login milik phone, phone kosong berarti tanpa token
+func call(t *testing.T, phone, method, path string, body any) (int, any) {
+	t.Helper()
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rd = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, rd)
+	req.Header.Set("Content-Type", "application/json")
+	if phone != "" {
+		token, err := watoken.EncodeforHours(phone, "Test "+phone, config.DevPrivateKey, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("login", token)
+	}
+	rec := httptest.NewRecorder()
+	URL(rec, req)
+	if err := apiDoc.Validate(method, req.URL.Path, rec.Code, rec.Body.Bytes()); err != nil {
+		t.Errorf("respon tidak sesuai dokumentasi OpenAPI: %v", err)
+	}
+	var res any
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s %s: respon bukan JSON: %s", method, path, rec.Body.String())
+	}
+	return rec.Code, res
+}
+
+// field mengambil nilai dari respon JSON lewat path bertitik, contoh "data.claim_id"
+func field(t *testing.T, v any, path string) any {
+	t.Helper()
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("field %s tidak ditemukan", path)
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// findDocs mengambil isi koleksi dalam bentuk JSON relaxed supaya bisa dibandingkan dengan golden
+func findDocs(t *testing.T, coll string, filter bson.M) []any {
+	t.Helper()
+	cur, err := config.Mongoconn.Collection(coll).Find(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []bson.D
+	if err = cur.All(context.Background(), &docs); err != nil {
+		t.Fatal(err)
+	}
+	res := make([]any, 0, len(docs))
+	for _, d := range docs {
+		b, err := bson.MarshalExtJSON(d, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v any
+		if err = json.Unmarshal(b, &v); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// waitWA menunggu pesan WA yang dikirim dari goroutine, lalu mengembalikan semua pesan WA terurut
+func waitWA(t *testing.T, n int) []any {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(devmode.Calls("", true)) < n && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	calls := devmode.Calls("", true)
+	if len(calls) != n {
+		t.Fatalf("jumlah pesan WA %d, seharusnya %d", len(calls), n)
+	}
+	res := make([]any, 0, len(calls))
+	for _, c := range calls {
+		res = append(res, c.WA)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].(*devmode.WAMessage), res[j].(*devmode.WAMessage)
+		return a.To+a.Messages < b.To+b.Messages
+	})
+	return res
+}
+
+// golden adalah satu skenario yang dibandingkan dengan testdata/golden/<nama>.json
+type golden struct {
+	t        *testing.T
+	volatile map[string]bool
+	Steps    []goldenStep     `json:"steps"`
+	DB       map[string][]any `json:"db,omitempty"`
+	WA       []any            `json:"wa,omitempty"`
+}
+
+type goldenStep struct {
+	Request string `json:"request"`
+	Status  int    `json:"status"`
+	Body    any    `json:"body"`
+}
+
+// newGolden membuat skenario di database yang baru diisi fixture.
+// volatile adalah nama field yang nilainya acak (kode unik, jumlah bayar) dan disamarkan.
+func newGolden(t *testing.T, volatile ...string) *golden {
+	t.Helper()
+	if err := config.Mongoconn.Drop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := devmode.Seed(config.Mongoconn); err != nil {
+		t.Fatal(err)
+	}
+	devmode.Reset()
+	g := &golden{t: t, volatile: make(map[string]bool), DB: make(map[string][]any)}
+	for _, v := range volatile {
+		g.volatile[v] = true
+	}
+	return g
+}
+
+// do menjalankan call, mencatat hasilnya dan memastikan status HTTP sesuai
+func (g *golden) do(phone, method, path string, body any, wantStatus int) any {
+	g.t.Helper()
+	status, res := call(g.t, phone, method, path, body)
+	if status != wantStatus {
+		g.t.Fatalf("%s %s: status %d, seharusnya %d: %v", method, path, status, wantStatus, res)
+	}
+	g.Steps = append(g.Steps, goldenStep{Request: method + " " + path, Status: status, Body: res})
+	return res
+}
+
+var (
+	reObjectID = regexp.MustCompile(`[0-9a-f]{24}`)
+	reUUID     = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	reTime     = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`)
+	reKode     = regexp.MustCompile(`\bGLR-?[0-9A-Za-z]{6,8}\b`)
+
+	zeroObjectID = primitive.NilObjectID.Hex()
+)
+
+// normalize menyamarkan ObjectID, UUID, kode GLR acak, waktu dan field volatile supaya golden stabil di setiap run
+func (g *golden) normalize(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, el := range x {
+			if g.volatile[k] {
+				x[k] = "<" + k + ">"
+				continue
+			}
+			x[k] = g.normalize(el)
+		}
+	case []any:
+		for i, el := range x {
+			x[i] = g.normalize(el)
+		}
+	case string:
+		if strings.HasPrefix(x, "0001-01-01T00:00:00") {
+			return x
+		}
+		x = reUUID.ReplaceAllString(x, "<uuid>")
+		x = reKode.ReplaceAllString(x, "<kode>")
+		x = reObjectID.ReplaceAllStringFunc(x, func(id string) string {
+			if id == zeroObjectID {
+				return id
+			}
+			return "<objectid>"
+		})
+		return reTime.ReplaceAllString(x, "<time>")
+	}
+	return v
+}
+
+// check membandingkan skenario dengan golden, atau menulis ulang golden jika -update
+func (g *golden) check() {
+	g.t.Helper()
+	raw, err := json.Marshal(g)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	var v any
+	if err = json.Unmarshal(raw, &v); err != nil {
+		g.t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(g.normalize(v)); err != nil {
+		g.t.Fatal(err)
+	}
+	got := buf.Bytes()
+	path := filepath.Join("testdata", "golden", g.t.Name()+".json")
+	if *update {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			g.t.Fatal(err)
+		}
+		if err = os.WriteFile(path, got, 0644); err != nil {
+			g.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		g.t.Fatalf("%v, jalankan go test ./route -update untuk membuat golden", err)
+	}
+	if !bytes.Equal(got, want) {
+		g.t.Errorf("hasil berbeda dengan %s.\nJika perubahan disengaja jalankan go test ./route -update lalu review diff-nya.\n%s",
+			path, firstDiff(string(want), string(got)))
+	}
+}
+
+func firstDiff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			return "baris " + strconv.Itoa(i+1) + ":\n  golden: " + wl + "\n  hasil:  " + gl
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gocroot/helper/openapi"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestOpenAPIResponses memanggil setiap GET terdokumentasi tanpa parameter path sebagai owner dan mahasiswa,
+// lalu call mencocokkan respon sukses maupun gagal dengan skema di openapi.Operations.
+func TestOpenAPIResponses(t *testing.T) {
+	g := newGolden(t)
+	res := g.do(ownerPhone, http.MethodPost, "/api/event/create", bson.M{"name": "Event Skema", "description": "Tulis artikel", "points": 5, "deadline_seconds": 3600, "max_claimants": 2}, http.StatusOK)
+	res = g.do(mhs1Phone, http.MethodPost, "/api/event/claim", bson.M{"event_id": field(t, res, "data.event_id")}, http.StatusOK)
+	g.do(mhs1Phone, http.MethodPost, "/api/event/submit", bson.M{"claim_id": field(t, res, "data.claim_id"), "task_link": "https://example.com/skema"}, http.StatusOK)
+
+	var paths []string
+	for key, op := range openapi.Operations {
+		method, pattern, _ := strings.Cut(key, " ")
+		if method == http.MethodGet && op.Summary != "" && !strings.Contains(pattern, ":") {
+			paths = append(paths, pattern)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, phone := range []string{ownerPhone, mhs1Phone} {
+			call(t, phone, http.MethodGet, path, nil)
+		}
+	}
+}
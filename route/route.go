@@ -2,398 +2,281 @@ package route
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/controller"
-	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/crowdfunding"
+	"github.com/gocroot/helper/devmode"
+	"github.com/gocroot/helper/openapi"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
 )
 
+// mux dibuat saat fungsi dijalankan, route ganda membuat panic sebelum request pertama diterima
+var mux = New()
+
 func URL(w http.ResponseWriter, r *http.Request) {
-	if config.SetAccessControlHeaders(w, r) {
-		return // If it's a preflight request, return early.
-	}
-	config.SetEnv()
+	mux.ServeHTTP(w, r)
+}
 
-	var method, path string = r.Method, r.URL.Path
-	//tracker website yang dipasang di masing2 web peserta
-	origin := r.Header.Get("Origin")
-	if method == http.MethodOptions && (path == "/api/tracker") {
-		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Tracker")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	if method == http.MethodOptions && (path == "/api/tracker/testing" || path == "/api/tracker/token/testing" || path == "/api/tracker/token") {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Tracker")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
+// New mendaftarkan semua endpoint. Middleware global: recover, log, CORS origin terdaftar lalu SetEnv.
+// Pola path memakai :nama atau :nama:tipe (int, objectid), parameter bertipe divalidasi sebelum handler dipanggil.
+func New() *router.Router {
+	r := router.New(controller.NotFound)
+	r.Use(router.Recover, router.Logger, router.CORS(config.SetAccessControlHeaders), setEnv)
+
+	tracker := router.PublicCORS("GET, POST", "Content-Type, Tracker")
+	public := router.PublicCORS("POST", "Content-Type")
 
-	switch {
-	case method == "GET" && path == "/":
-		controller.GetHome(w, r)
+	r.GET("/", controller.GetHome)
 	//jalan setiap jam 8 pagi dipasang di cronjob
-	case method == "GET" && path == "/refresh/token":
-		controller.GetNewToken(w, r)
-	case method == "GET" && path == "/data/pushrepo/kemarin":
-		controller.GetYesterdayDistincWAGroup(w, r)
-	case method == "GET" && path == "/data/user":
-		controller.GetDataUser(w, r)
-	case method == "GET" && path == "/data/alluser":
-		controller.GetAllDataUser(w, r)
+	r.GET("/refresh/token", controller.GetNewToken)
+	//jalan setiap menit dipasang di cronjob
+	r.GET("/refresh/waoutbox", controller.RefreshWAOutbox)
+	r.GET("/api/waoutbox/failed", controller.GetFailedWAOutbox, rbac.Permit(rbac.KelolaSistem))
+	r.POST("/api/waoutbox/resend/:id:objectid", controller.ResendWAOutbox, rbac.Permit(rbac.KelolaSistem))
+	r.GET("/api/poinledger/drift", controller.GetPoinLedgerDrift, rbac.Permit(rbac.KelolaSistem))
+	r.POST("/api/poinledger/reconcile", controller.PostPoinLedgerReconcile, rbac.Permit(rbac.KelolaSistem))
+	//jalan setiap 5 menit dipasang di cronjob, mengaktifkan dan mengakhiri event terjadwal
+	r.GET("/refresh/event/jadwal", controller.RefreshEventJadwal)
+	r.GET("/data/pushrepo/kemarin", controller.GetYesterdayDistincWAGroup)
+	r.GET("/data/user", controller.GetDataUser)
+	r.GET("/data/alluser", controller.GetAllDataUser)
 	//generate token akses untuk kirim wa jangka panjang token
-	case method == "PUT" && path == "/data/user":
-		controller.PutTokenDataUser(w, r)
-	case method == "GET" && path == "/data/user/task/todo":
-		controller.GetTaskUser(w, r)
-	case method == "GET" && path == "/data/user/task/doing":
-		controller.GetTaskDoing(w, r)
-	case method == "PUT" && path == "/data/user/task/doing":
-		controller.PutTaskUser(w, r)
-	case method == "GET" && path == "/data/user/task/done":
-		controller.GetTaskDone(w, r)
-	case method == "POST" && path == "/data/user/task/done":
-		controller.PostTaskUser(w, r)
-	case method == "POST" && path == "/data/user":
-		controller.PostDataUser(w, r)
-	case method == "GET" && path == "/data/poin":
-		controller.GetLogPoin(w, r)
-	case method == "POST" && at.URLParam(path, "/data/user/wa/:nomorwa"):
-		controller.PostDataUserFromWA(w, r)
-	case method == "POST" && path == "/data/proyek":
-		controller.PostDataProject(w, r)
-	case method == "POST" && path == "/data/group":
-		controller.PostGroup(w, r)
-	case method == "POST" && path == "/data/members":
-		controller.PostMember(w, r)
-	case method == "GET" && path == "/data/group":
-		controller.GetGroupByPhoneNumberFromMember(w, r)
-	case method == "GET" && path == "/data/proyek":
-		controller.GetDataProject(w, r)
-	case method == "PUT" && path == "/data/proyek":
-		controller.PutDataProject(w, r)
-	case method == "DELETE" && path == "/data/proyek":
-		controller.DeleteDataProject(w, r)
-	case method == "GET" && path == "/data/proyek/anggota":
-		controller.GetDataMemberProject(w, r)
-	case method == "POST" && path == "/data/proyek/anggota":
-		controller.PostDataMemberProject(w, r)
-	case method == "POST" && path == "/approvebimbingan":
-		controller.ApproveBimbinganbyPoin(w, r)
-	case method == "DELETE" && path == "/data/proyek/anggota":
-		controller.DeleteDataMemberProject(w, r)
-	case method == "POST" && at.URLParam(path, "/webhook/github/:proyek"):
-		controller.PostWebHookGithub(w, r)
-	case method == "POST" && at.URLParam(path, "/webhook/gitlab/:proyek"):
-		controller.PostWebHookGitlab(w, r)
-	case method == "POST" && path == "/notif/ux/postlaporan":
-		controller.PostLaporan(w, r)
-	case method == "POST" && path == "/notif/ux/postfeedback": //posting feedback
-		controller.PostFeedback(w, r)
-	case method == "POST" && path == "/notif/ux/postrating": //resume atau risalah rapat dan feedback
-		controller.PostRatingLaporan(w, r)
-	case method == "POST" && path == "/notif/ux/postmeeting":
-		controller.PostMeeting(w, r)
-	case method == "POST" && at.URLParam(path, "/notif/ux/postpresensi/:id"):
-		controller.PostPresensi(w, r)
-	case method == "POST" && at.URLParam(path, "/notif/ux/posttasklists/:id"):
-		controller.PostTaskList(w, r)
-	case method == "GET" && at.URLParam(path, "/notif/ux/getlaporan/:id"):
-		controller.GetLaporan(w, r)
-	case method == "GET" && path == "/notif/ux/getreportdata":
-		controller.GetUXReport(w, r)
-	case method == "POST" && at.URLParam(path, "/webhook/nomor/:nomorwa"):
-		controller.PostInboxNomor(w, r)
+	r.PUT("/data/user", controller.PutTokenDataUser)
+	r.GET("/data/user/task/todo", controller.GetTaskUser)
+	r.GET("/data/user/task/doing", controller.GetTaskDoing)
+	r.PUT("/data/user/task/doing", controller.PutTaskUser)
+	r.GET("/data/user/task/done", controller.GetTaskDone)
+	r.POST("/data/user/task/done", controller.PostTaskUser)
+	r.POST("/data/user", controller.PostDataUser)
+	r.GET("/data/poin", controller.GetLogPoin)
+	r.POST("/data/user/wa/:nomorwa", controller.PostDataUserFromWA)
+	r.POST("/data/proyek", controller.PostDataProject)
+	r.POST("/data/group", controller.PostGroup)
+	r.POST("/data/members", controller.PostMember)
+	r.GET("/data/group", controller.GetGroupByPhoneNumberFromMember)
+	r.GET("/data/proyek", controller.GetDataProject)
+	r.PUT("/data/proyek", controller.PutDataProject)
+	r.DELETE("/data/proyek", controller.DeleteDataProject)
+	r.GET("/data/proyek/anggota", controller.GetDataMemberProject)
+	r.POST("/data/proyek/anggota", controller.PostDataMemberProject)
+	r.POST("/approvebimbingan", controller.ApproveBimbinganbyPoin, rbac.Permit(rbac.ApproveBimbingan))
+	r.DELETE("/data/proyek/anggota", controller.DeleteDataMemberProject)
+	r.POST("/webhook/github/:proyek", controller.PostWebHookGithub)
+	r.POST("/webhook/gitlab/:proyek", controller.PostWebHookGitlab)
+	r.POST("/webhook/gitea/:proyek", controller.PostWebHookGitea)
+	r.POST("/webhook/bitbucket/:proyek", controller.PostWebHookBitbucket)
+	r.POST("/api/webhook/redeliver/:deliveryid", controller.RedeliverWebHook, rbac.PermitScope(rbac.KelolaProyek, controller.ProyekWebhookDelivery))
+	r.POST("/notif/ux/postlaporan", controller.PostLaporan)
+	r.POST("/notif/ux/postfeedback", controller.PostFeedback)    //posting feedback
+	r.POST("/notif/ux/postrating", controller.PostRatingLaporan) //resume atau risalah rapat dan feedback
+	r.POST("/notif/ux/postmeeting", controller.PostMeeting)
+	r.POST("/notif/ux/postpresensi/:id", controller.PostPresensi)
+	r.POST("/notif/ux/posttasklists/:id", controller.PostTaskList)
+	r.GET("/notif/ux/getlaporan/:id:objectid", controller.GetLaporan)
+	r.GET("/notif/ux/getreportdata", controller.GetUXReport)
+	r.POST("/webhook/nomor/:nomorwa", controller.PostInboxNomor)
 	// LMS
-	case method == "GET" && path == "/lms/refresh/cookie":
-		controller.RefreshLMSCookie(w, r)
-	case method == "GET" && path == "/lms/count/user":
-		controller.GetCountDocUser(w, r)
+	r.GET("/lms/refresh/cookie", controller.RefreshLMSCookie)
+	r.GET("/lms/count/user", controller.GetCountDocUser)
 	// Google Auth
-	case method == "POST" && path == "/auth/users":
-		controller.Auth(w, r)
-	case method == "POST" && path == "/auth/login":
-		controller.GeneratePasswordHandler(w, r)
-	case method == "POST" && path == "/auth/verify":
-		controller.VerifyPasswordHandler(w, r)
-	case method == "POST" && path == "/auth/resend":
-		controller.ResendPasswordHandler(w, r)
+	r.POST("/auth/users", controller.Auth)
+	r.POST("/auth/login", controller.GeneratePasswordHandler)
+	r.POST("/auth/verify", controller.VerifyPasswordHandler)
+	r.POST("/auth/resend", controller.ResendPasswordHandler)
 	// LMS
-	case method == "GET" && path == "/stats/commit":
-		controller.CountCommits(w, r)
-	case method == "GET" && path == "/stats/feedback":
-		controller.CountFeedback(w, r)
-		//log
-	case method == "GET" && path == "/refresh/report/crowdfundingglobal":
-		controller.GetCrowdfundingGlobalReport(w, r)
-	case method == "GET" && path == "/refresh/report/log/crowdfundingglobal":
-		controller.GetLogCrowdfundingGlobalReport(w, r)
-	case method == "GET" && path == "/refresh/report/log/crowdfundingharian":
-		controller.GetLogCrowdfundingDailyReport(w, r)
-	case method == "GET" && path == "/refresh/report/log/crowdfundingmingguan":
-		controller.GetLogCrowdfundingWeeklyReport(w, r)
-	case method == "GET" && path == "/refresh/report/log/crowdfundingtotal":
-		controller.GetLogCrowdfundingTotalReport(w, r)
-		// QRIS Payment Routes dengan Basic Auth
-	// QRIS Payment Routes
-	case method == "POST" && path == "/api/crowdfunding/qris/createOrder":
-		controller.CreateQRISOrder(w, r) // Tanpa Basic Auth
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/qris/checkPayment/:orderId"):
-		controller.CheckPayment(w, r) // Tanpa Basic Auth
-	case method == "POST" && at.URLParam(path, "/api/crowdfunding/qris/confirm/:orderId"):
-		controller.ConfirmQRISPayment(w, r) // Tanpa Basic Auth
-	// Hanya notification yang menggunakan Basic Auth
-	case method == "POST" && path == "/api/crowdfunding/qris/notification":
-		controller.ProcessQRISNotificationHandler(w, r) // Dengan Basic Auth
-
-	// MicroBitcoin Payment Routes tanpa Basic Auth (hanya menggunakan token)
-	case method == "POST" && path == "/api/crowdfunding/microbitcoin/createOrder":
-		controller.CreateMicroBitcoinOrder(w, r)
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/microbitcoin/checkStep2/:orderId"):
-		controller.CheckStep2Handler(w, r)
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/microbitcoin/checkStep3/:orderId"):
-		controller.CheckStep3Handler(w, r)
-	case method == "POST" && at.URLParam(path, "/api/crowdfunding/microbitcoin/confirm/:orderId"):
-		controller.ConfirmMicroBitcoinPayment(w, r)
+	r.GET("/stats/commit", controller.CountCommits)
+	r.GET("/stats/feedback", controller.CountFeedback)
+	//log
+	r.GET("/refresh/report/crowdfundingglobal", controller.GetCrowdfundingGlobalReport)
+	r.GET("/refresh/report/log/crowdfundingglobal", controller.GetLogCrowdfundingGlobalReport)
+	r.GET("/refresh/report/log/crowdfundingharian", controller.GetLogCrowdfundingDailyReport)
+	r.GET("/refresh/report/log/crowdfundingmingguan", controller.GetLogCrowdfundingWeeklyReport)
+	r.GET("/refresh/report/log/crowdfundingtotal", controller.GetLogCrowdfundingTotalReport)
+	// Hanya notification QRIS yang menggunakan Basic Auth
+	r.POST("/api/crowdfunding/qris/notification", controller.ProcessQRISNotificationHandler) // Dengan Basic Auth
+	// Payment provider routes: /api/crowdfunding/{qris|microbitcoin|ravencoin}/... (hanya menggunakan token)
+	for _, p := range crowdfunding.Providers() {
+		prefix := "/api/crowdfunding/" + string(p.Info().Method)
+		r.POST(prefix+"/createOrder", controller.CreateCrowdfundingOrder)
+		r.GET(prefix+"/checkPayment/:orderId", controller.CheckPayment)
+		r.GET(prefix+"/checkStep2/:orderId", controller.CheckPayment)
+		r.GET(prefix+"/checkStep3/:orderId", controller.CheckPayment)
+		r.POST(prefix+"/confirm/:orderId", controller.ConfirmCrowdfundingPayment, rbac.Permit(rbac.KonfirmasiBayar))
+	}
 
 	// Endpoint umum Crowdfunding
-	case method == "GET" && path == "/api/crowdfunding/userinfo":
-		controller.GetUserInfo(w, r)
-	case method == "GET" && path == "/api/crowdfunding/queueStatus":
-		controller.CheckQueueStatus(w, r)
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/checkPayment/:orderId"):
-		controller.CheckPayment(w, r)
-	case method == "GET" && path == "/api/crowdfunding/totals":
-		controller.GetCrowdfundingTotal(w, r)
-	case method == "GET" && path == "/api/crowdfunding/history":
-		controller.GetUserCrowdfundingHistory(w, r)
+	r.GET("/api/crowdfunding/userinfo", controller.GetUserInfo)
+	r.GET("/api/crowdfunding/queueStatus", controller.CheckQueueStatus)
+	r.GET("/api/crowdfunding/checkPayment/:orderId", controller.CheckPayment)
+	r.GET("/api/crowdfunding/totals", controller.GetCrowdfundingTotal)
+	r.GET("/api/crowdfunding/history", controller.GetUserCrowdfundingHistory)
 	// Endpoints untuk laporan crowdfunding
-	case method == "GET" && path == "/refresh/report/crowdfundingharian":
-		controller.GetCrowdfundingDailyReport(w, r)
-	case method == "GET" && path == "/refresh/report/crowdfundingmingguan":
-		controller.GetCrowdfundingWeeklyReport(w, r)
-	case method == "GET" && path == "/refresh/report/crowdfundingtotal":
-		controller.GetCrowdfundingTotalReport(w, r)
-	case method == "GET" && path == "/api/crowdfunding/user":
-		controller.GetCrowdfundingUserData(w, r)
-	// Ravencoin Payment Routes
-	case method == "POST" && path == "/api/crowdfunding/ravencoin/createOrder":
-		controller.CreateRavencoinOrder(w, r)
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/ravencoin/checkStep2/:orderId"):
-		controller.CheckRavencoinStep2Handler(w, r)
-	case method == "GET" && at.URLParam(path, "/api/crowdfunding/ravencoin/checkStep3/:orderId"):
-		controller.CheckRavencoinStep3Handler(w, r)
-	case method == "POST" && at.URLParam(path, "/api/crowdfunding/ravencoin/confirm/:orderId"):
-		controller.ConfirmRavencoinPayment(w, r)
+	r.GET("/refresh/report/crowdfundingharian", controller.GetCrowdfundingDailyReport)
+	r.GET("/refresh/report/crowdfundingmingguan", controller.GetCrowdfundingWeeklyReport)
+	r.GET("/refresh/report/crowdfundingtotal", controller.GetCrowdfundingTotalReport)
+	r.GET("/api/crowdfunding/user", controller.GetCrowdfundingUserData)
 	// Endpoint untuk pengelolaan poin pembayaran
-	case method == "GET" && path == "/api/crowdfunding/points":
-		controller.GetUserPaymentPointsHandler(w, r)
-	case method == "GET" && path == "/api/crowdfunding/points/all":
-		controller.GetAllPaymentPointsHandler(w, r)
-	case method == "GET" && path == "/api/crowdfunding/points/top":
-		controller.GetTopPaymentPointsHandler(w, r)
-	case method == "POST" && path == "/api/crowdfunding/points/calculate":
-		controller.CalculatePaymentPointsHandler(w, r)
-	case method == "GET" && path == "/refresh/report/crowdfundingpoints":
-		controller.SendPaymentPointsReportHandler(w, r)
-	case method == "GET" && path == "/refresh/report/log/crowdfundingpoints":
-		controller.GetPaymentPointsReportHandler(w, r)
+	r.GET("/api/crowdfunding/points", controller.GetUserPaymentPointsHandler)
+	r.GET("/api/crowdfunding/points/all", controller.GetAllPaymentPointsHandler)
+	r.GET("/api/crowdfunding/points/top", controller.GetTopPaymentPointsHandler)
+	r.POST("/api/crowdfunding/points/calculate", controller.CalculatePaymentPointsHandler)
+	r.GET("/refresh/report/crowdfundingpoints", controller.SendPaymentPointsReportHandler)
+	r.GET("/refresh/report/log/crowdfundingpoints", controller.GetPaymentPointsReportHandler)
 	// IQ
-	case method == "GET" && strings.HasPrefix(path, "/api/iq/question/"):
-		controller.GetOneIqQuestion(w, r)
-	case method == "GET" && path == "/api/iqscoring":
-		controller.GetIqScoring(w, r)
-	case method == "GET" && path == "/api/iq/new":
-		controller.GetUserAndIqScore(w, r)
-	case method == "POST" && path == "/api/iq/answer":
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.PostAnswer(w, r)
-	case method == "GET" && path == "/api/iq/getall":
-		controller.HandleGetAllDataIQScore(w, r)
-	case method == "GET" && path == "/refresh/report/iq/score/harian":
-		controller.GetIqScoreDataDaily(w, r)
-	case method == "GET" && path == "/refresh/report/iq/score/mingguan":
-		controller.GetIqScoreDataWeekly(w, r)
+	r.GET("/api/iq/question/:id", controller.GetOneIqQuestion)
+	r.GET("/api/iqscoring", controller.GetIqScoring)
+	r.GET("/api/iq/new", controller.GetUserAndIqScore)
+	r.POST("/api/iq/answer", controller.PostAnswer, public)
+	r.GET("/api/iq/getall", controller.HandleGetAllDataIQScore)
+	r.GET("/refresh/report/iq/score/harian", controller.GetIqScoreDataDaily)
+	r.GET("/refresh/report/iq/score/mingguan", controller.GetIqScoreDataWeekly)
 	// case method == "GET" && path == "/api/iq/get/week":
 	// 	controller.GetLastWeekDataIQScore(w, r)
 	// Pre Test
-	case method == "GET" && strings.HasPrefix(path, "/api/pretest/question/"):
-		controller.GetOnePreTestQuestion(w, r)
-	case method == "GET" && path == "/api/pretest/user":
-		controller.GetUserAndPreTestScore(w, r)
-	case method == "GET" && path == "/api/pretest/scoring":
-		controller.GetPreTestScoring(w, r)
-	case method == "POST" && path == "/api/pretest/answer":
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.PostPretestAnswer(w, r)
+	r.GET("/api/pretest/question/:id", controller.GetOnePreTestQuestion)
+	r.GET("/api/pretest/user", controller.GetUserAndPreTestScore)
+	r.GET("/api/pretest/scoring", controller.GetPreTestScoring)
+	r.POST("/api/pretest/answer", controller.PostPretestAnswer, public)
 	// Google Auth
 	// Tracker start
-	case method == "POST" && path == "/api/tracker":
-		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.SimpanInformasiUser(w, r)
-	case method == "POST" && path == "/api/tracker/token":
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.GenerateTrackerToken(w, r)
-	case method == "POST" && path == "/api/tracker/token/testing":
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.GenerateTrackerTokenTesting(w, r)
-	case method == "POST" && path == "/api/tracker/testing":
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		controller.SimpanInformasiUserTesting(w, r)
-	case method == "GET" && path == "/refresh/laporantracker":
-		controller.LaporanPengunjungWeb(w, r)
-	case method == "GET" && path == "/api/tracker":
-		controller.AmbilDataStatistik(w, r)
+	//tracker website yang dipasang di masing2 web peserta, data dari localhost ditolak
+	r.OPTIONS("/api/tracker", router.NoContent, router.BlockLocalOrigin, tracker)
+	r.POST("/api/tracker", controller.SimpanInformasiUser, router.BlockLocalOrigin, tracker)
+	r.OPTIONS("/api/tracker/token", router.NoContent, tracker)
+	r.POST("/api/tracker/token", controller.GenerateTrackerToken, tracker)
+	r.OPTIONS("/api/tracker/token/testing", router.NoContent, tracker)
+	r.POST("/api/tracker/token/testing", controller.GenerateTrackerTokenTesting, tracker)
+	r.OPTIONS("/api/tracker/testing", router.NoContent, tracker)
+	r.POST("/api/tracker/testing", controller.SimpanInformasiUserTesting, tracker)
+	r.GET("/refresh/laporantracker", controller.LaporanPengunjungWeb)
+	r.GET("/api/tracker", controller.AmbilDataStatistik)
 	// Tracker end
 	// case method == "GET" && path == "/refresh/reportmingguan":
 	// 	controller.GetNewCode(w, r)
 
 	// Pomodoro
 	// dengan token header 'login'
-	case method == "GET" && path == ("/report/pomokit/user"):
-		controller.GetPomokitDataUserAPI(w, r)
+	r.GET("/report/pomokit/user", controller.GetPomokitDataUserAPI)
 	// parameter groupid=, phonenumber=, send=true/false(default nya true)
-	case method == "GET" && path == "/report/pomokit/total":
-		controller.GetPomokitReportTotalSemuaHari(w, r)
-		// hanya melalui log
-	case method == "GET" && path == "/report/pomokit/grup/kemarin/log":
-		controller.GetPomokitReportKemarinPerGrup(w, r)
-	case method == "GET" && path == "/report/pomokit/grup/kemarin":
-		controller.SendPomokitReportKemarinPerGrup(w, r)
+	r.GET("/report/pomokit/total", controller.GetPomokitReportTotalSemuaHari)
+	// hanya melalui log
+	r.GET("/report/pomokit/grup/kemarin/log", controller.GetPomokitReportKemarinPerGrup)
+	r.GET("/report/pomokit/grup/kemarin", controller.SendPomokitReportKemarinPerGrup)
 	// Menjalankan laporan mingguan secara manual dan mengirimnya ke grup
-	case method == "GET" && path == "/report/pomokit/grup/mingguan":
-		controller.SendPomokitReportMingguanPerGrup(w, r)
-		// hanya melalui log
-	case method == "GET" && path == "/report/pomokit/grup/mingguan/log":
-		controller.GetPomokitReportMingguanPerGrup(w, r)
+	r.GET("/report/pomokit/grup/mingguan", controller.SendPomokitReportMingguanPerGrup)
+	// hanya melalui log
+	r.GET("/report/pomokit/grup/mingguan/log", controller.GetPomokitReportMingguanPerGrup)
 	// Menjalankan laporan dengan cron job
-	case method == "GET" && path == "/refresh/report/pomokitmingguan":
-		controller.RefreshPomokitMingguanReport(w, r)
-	case method == "GET" && path == "/refresh/report/pomokitharian":
-		controller.RefreshPomokitHarianReport(w, r)
+	r.GET("/refresh/report/pomokitmingguan", controller.RefreshPomokitMingguanReport)
+	r.GET("/refresh/report/pomokitharian", controller.RefreshPomokitHarianReport)
+	// analisis anti-curang, sesi yang ditahan direview dosen sebelum dihitung di skor
+	r.GET("/refresh/pomokit/flag", controller.RefreshPomokitFlag)
+	r.GET("/api/pomokit/flag", controller.GetPomokitFlag, rbac.Permit(rbac.ReviewPomokit))
+	r.PUT("/api/pomokit/flag/:id:objectid", controller.PutPomokitFlag, rbac.Permit(rbac.ReviewPomokit))
 
 	// Endpoint GTMetrix Report
 	// dengan token header 'login'
-	case method == "GET" && path == ("/report/gtmetrix/user"):
-		controller.GetGTMetrixDataUserAPI(w, r)
-	case method == "GET" && path == "/report/gtmetrix/yesterday":
-		controller.GetGTMetrixReportYesterday(w, r)
-	case method == "GET" && path == "/report/gtmetrix/lastweek":
-		controller.GetGTMetrixReportLastWeek(w, r)
-	case method == "GET" && path == "/report/gtmetrix/total":
-		controller.GetGTMetrixReportTotal(w, r)
+	r.GET("/report/gtmetrix/user", controller.GetGTMetrixDataUserAPI)
+	r.GET("/report/gtmetrix/yesterday", controller.GetGTMetrixReportYesterday)
+	r.GET("/report/gtmetrix/lastweek", controller.GetGTMetrixReportLastWeek)
+	r.GET("/report/gtmetrix/total", controller.GetGTMetrixReportTotal)
 	// Endpoint untuk cron job
-	case method == "GET" && path == "/refresh/report/gtmetrixharian":
-		controller.RefreshGTMetrixHarianReport(w, r)
-	case method == "GET" && path == "/refresh/report/gtmetrixmingguan":
-		controller.RefreshGTMetrixMingguanReport(w, r)
+	r.GET("/refresh/report/gtmetrixharian", controller.RefreshGTMetrixHarianReport)
+	r.GET("/refresh/report/gtmetrixmingguan", controller.RefreshGTMetrixMingguanReport)
 
-	case method == "GET" && path == "/report/bukped/user":
-		controller.GetBukpedDataUserAPI(w, r)
+	r.GET("/report/bukped/user", controller.GetBukpedDataUserAPI)
 
 	//strava coba
-	case method == "GET" && path == "/data/strava": // hanya untuk mengambil data strava lama
-		controller.ProcessStravaPoints(w, r)
-	case method == "POST" && at.URLParam(path, "/data/strava-poin/wa/:nomorwa"):
-		controller.AddStravaPoints(w, r)
+	r.GET("/data/strava", controller.ProcessStravaPoints) // hanya untuk mengambil data strava lama
+	r.POST("/data/strava-poin/wa/:nomorwa", controller.AddStravaPoints)
 
 	// Endpoint activity score
-	case method == "GET" && path == "/api/activityscore":
-		controller.GetAllActivityScore(w, r)
-	case method == "GET" && path == "/api/activityscoreweekly":
-		controller.GetLastWeekActivityScore(w, r)
+	r.GET("/api/activityscore", controller.GetAllActivityScore)
+	r.GET("/api/activityscoreweekly", controller.GetLastWeekActivityScore)
+	r.GET("/api/activityscore/history", controller.GetActivityScoreHistory)
+	r.GET("/refresh/activityscore/weeklysnapshot", controller.RefreshWeeklyScoreSnapshot)
+	r.GET("/api/scoringrule/active/:enroll", controller.GetActiveScoringRule)
+	r.GET("/api/scoringrule/:enroll", controller.GetScoringRuleVersions, rbac.PermitScope(rbac.KelolaSistem, enrollParam))
+	r.POST("/api/scoringrule", controller.PostScoringRule, rbac.PermitScope(rbac.KelolaSistem, controller.EnrollScoringRuleBody))
+	r.PUT("/api/scoringrule/activate/:id:objectid", controller.ActivateScoringRule, rbac.PermitScope(rbac.KelolaSistem, controller.EnrollScoringRule))
+	r.DELETE("/api/scoringrule/:id:objectid", controller.DeleteScoringRule, rbac.PermitScope(rbac.KelolaSistem, controller.EnrollScoringRule))
+	r.GET("/api/liburnasional", controller.GetLiburNasional)
+	r.POST("/api/liburnasional", controller.PostLiburNasional, rbac.Permit(rbac.KelolaSistem))
+	r.POST("/api/liburnasional/import", controller.ImportLiburNasional, rbac.Permit(rbac.KelolaSistem))
+	r.PUT("/api/liburnasional/:id:objectid", controller.PutLiburNasional, rbac.Permit(rbac.KelolaSistem))
+	r.DELETE("/api/liburnasional/:id:objectid", controller.DeleteLiburNasional, rbac.Permit(rbac.KelolaSistem))
+	r.GET("/api/periodeakademik", controller.GetPeriodeAkademik)
+	r.PUT("/api/periodeakademik", controller.PutPeriodeAkademik, rbac.Permit(rbac.KelolaSistem))
+	r.GET("/api/rbac/me", controller.GetMyAccess)
+	r.GET("/api/rbac/role", controller.GetUserRoles, rbac.Permit(rbac.KelolaRole))
+	r.POST("/api/rbac/role", controller.PostUserRole, rbac.Permit(rbac.KelolaRole))
+	r.DELETE("/api/rbac/role/:id:objectid", controller.DeleteUserRole, rbac.Permit(rbac.KelolaRole))
 	// Endpoint Bimbingan
-	case method == "POST" && path == "/data/proyek/bimbingan/perdana":
-		controller.PostDosenAsesorPerdana(w, r)
-	case method == "POST" && path == "/data/proyek/bimbingan/lanjutan":
-		controller.PostDosenAsesorLanjutan(w, r)
-	case method == "GET" && path == "/data/proyek/bimbingan":
-		controller.GetDataBimbingan(w, r)
-	case method == "GET" && at.URLParam(path, "/data/proyek/bimbingan/:id"):
-		controller.GetDataBimbinganById(w, r)
-	case method == "POST" && at.URLParam(path, "/data/proyek/bimbingan/:id"):
-		controller.ReplaceDataBimbingan(w, r)
-		// Endpoint untuk cek status bimbingan mingguan
-	case method == "GET" && path == "/api/bimbingan/weekly/status":
-		controller.GetWeeklyBimbinganStatus(w, r)
-		// Pengajuan Sidang endpoints
-	case method == "POST" && path == "/api/bimbingan/pengajuan":
-		controller.PostPengajuanSidang(w, r)
-	case method == "GET" && path == "/api/bimbingan/pengajuan":
-		controller.GetPengajuanSidang(w, r)
-	case method == "GET" && path == "/api/bimbingan/dosenpenguji":
-		controller.GetDosenPenguji(w, r)
-		// New Referral Event Endpoints
-	case method == "GET" && path == "/api/event/generatecode":
-		controller.GenerateEventCode(w, r)
-	case method == "POST" && path == "/api/event/claimcode":
-		controller.ClaimEventCode(w, r)
-		// New Time Event Code Routes
-	case method == "POST" && path == "/api/event/generatecodetime":
-		controller.GenerateEventCodeTime(w, r)
-	case method == "POST" && path == "/api/event/claimcodetime":
-		controller.ClaimEventCodeTime(w, r)
-	case method == "GET" && path == "/api/event/claimtimestatus":
-		controller.CheckEventTimeClaimStatus(w, r)
-		// New Event Management Endpoints
-	case method == "POST" && path == "/api/event/create":
-		controller.CreateEvent(w, r)
-	case method == "GET" && path == "/api/event/all":
-		controller.GetAllEvents(w, r)
-	case method == "POST" && path == "/api/event/claim":
-		controller.ClaimEvent(w, r)
-	case method == "POST" && path == "/api/event/submit":
-		controller.SubmitEventTask(w, r)
-	case method == "POST" && path == "/api/event/approve":
-		controller.ApproveEventClaim(w, r)
-	case method == "GET" && path == "/api/event/myclaims":
-		controller.GetUserEventClaims(w, r)
-	case method == "GET" && path == "/api/event/checkexpired":
-		controller.CheckExpiredClaims(w, r)
-	case method == "GET" && at.URLParam(path, "/api/event/claim/:claimid"):
-		controller.GetEventClaimDetails(w, r)
-		// Event approval endpoints (mengikuti pola bimbingan)
-	case method == "GET" && at.URLParam(path, "/data/event/approval/:claimid"):
-		controller.GetEventApprovalData(w, r)
-	case method == "POST" && at.URLParam(path, "/data/event/approval/:claimid"):
-		controller.PostEventApproval(w, r)
-		// Check expired approvals untuk recovery 24 jam timeout
-	case method == "GET" && path == "/api/event/checkexpired":
-		controller.CheckExpiredApprovals(w, r)
-		// Get user event points
-	case method == "GET" && path == "/api/event/mypoints":
-		controller.GetUserEventPoints(w, r)
-		// Store endpoints
-	case method == "POST" && path == "/api/store/buy-bimbingan-code":
-		controller.BuyBimbinganCode(w, r)
-		// Delete endpoints for owner
-	case method == "DELETE" && at.URLParam(path, "/api/event/delete/:eventid"):
-		controller.DeleteEvent(w, r)
-	case method == "DELETE" && at.URLParam(path, "/api/event/claim/delete/:claimid"):
-		controller.DeleteEventClaim(w, r)
+	r.POST("/data/proyek/bimbingan/perdana", controller.PostDosenAsesorPerdana)
+	r.POST("/data/proyek/bimbingan/lanjutan", controller.PostDosenAsesorLanjutan)
+	r.GET("/data/proyek/bimbingan", controller.GetDataBimbingan)
+	r.GET("/data/proyek/bimbingan/:id:objectid", controller.GetDataBimbinganById)
+	r.POST("/data/proyek/bimbingan/:id:objectid", controller.ReplaceDataBimbingan, rbac.PermitScope(rbac.ApproveBimbingan, controller.EnrollBimbingan))
+	// Endpoint untuk cek status bimbingan mingguan
+	r.GET("/api/bimbingan/weekly/status", controller.GetWeeklyBimbinganStatus)
+	// Pengajuan Sidang endpoints
+	r.POST("/api/bimbingan/pengajuan", controller.PostPengajuanSidang)
+	r.GET("/api/bimbingan/pengajuan", controller.GetPengajuanSidang)
+	r.GET("/api/bimbingan/dosenpenguji", controller.GetDosenPenguji)
+	// Penjadwalan sidang: slot dosen, jadwal manual dan otomatis, pindah dan batal
+	r.GET("/api/sidang/slot", controller.GetSidangSlot)
+	r.POST("/api/sidang/slot", controller.PostSidangSlot, rbac.Permit(rbac.SlotSidang))
+	r.DELETE("/api/sidang/slot/:id:objectid", controller.DeleteSidangSlot, rbac.Permit(rbac.SlotSidang))
+	r.GET("/api/sidang/jadwal", controller.GetJadwalSidang)
+	r.POST("/api/sidang/jadwal", controller.PostJadwalSidang, rbac.Permit(rbac.JadwalSidang))
+	r.POST("/api/sidang/jadwal/otomatis", controller.PostJadwalSidangOtomatis, rbac.Permit(rbac.JadwalSidang))
+	r.PUT("/api/sidang/jadwal/:id:objectid", controller.PutJadwalSidang, rbac.Permit(rbac.JadwalSidang))
+	r.PUT("/api/sidang/jadwal/batal/:id:objectid", controller.BatalJadwalSidang, rbac.Permit(rbac.JadwalSidang))
+	// New Referral Event Endpoints
+	r.GET("/api/event/generatecode", controller.GenerateEventCode, rbac.Permit(rbac.KodeEvent))
+	r.POST("/api/event/claimcode", controller.ClaimEventCode)
+	// New Time Event Code Routes
+	r.POST("/api/event/generatecodetime", controller.GenerateEventCodeTime, rbac.Permit(rbac.KodeEvent))
+	r.POST("/api/event/claimcodetime", controller.ClaimEventCodeTime)
+	r.GET("/api/event/claimtimestatus", controller.CheckEventTimeClaimStatus)
+	// New Event Management Endpoints
+	r.POST("/api/event/create", controller.CreateEvent, rbac.Permit(rbac.KelolaEvent))
+	r.GET("/api/event/all", controller.GetAllEvents)
+	r.POST("/api/event/claim", controller.ClaimEvent)
+	r.POST("/api/event/submit", controller.SubmitEventTask)
+	r.POST("/api/event/approve", controller.ApproveEventClaim, rbac.Permit(rbac.ApproveEvent))
+	r.POST("/api/event/review", controller.ReviewEventClaim, rbac.Permit(rbac.ApproveEvent))
+	r.GET("/api/event/queue", controller.GetEventReviewQueue, rbac.Permit(rbac.ApproveEvent))
+	r.GET("/api/event/myclaims", controller.GetUserEventClaims)
+	r.GET("/api/event/checkexpired", controller.CheckExpiredClaims)
+	r.GET("/api/event/claim/:claimid:objectid", controller.GetEventClaimDetails)
+	// Event approval endpoints (mengikuti pola bimbingan)
+	r.GET("/data/event/approval/:claimid:objectid", controller.GetEventApprovalData)
+	r.POST("/data/event/approval/:claimid:objectid", controller.PostEventApproval, rbac.Permit(rbac.ApproveEvent))
+	// Check expired approvals untuk recovery 24 jam timeout
+	r.GET("/api/event/checkexpiredapprovals", controller.CheckExpiredApprovals)
+	// Get user event points
+	r.GET("/api/event/mypoints", controller.GetUserEventPoints)
+	// Store endpoints
+	r.POST("/api/store/buy-bimbingan-code", controller.BuyBimbinganCode)
+	r.GET("/api/store/item", controller.GetStoreItem)
+	r.POST("/api/store/item", controller.PostStoreItem, rbac.Permit(rbac.KelolaEvent))
+	r.PUT("/api/store/item/:id:objectid", controller.PutStoreItem, rbac.Permit(rbac.KelolaEvent))
+	r.POST("/api/store/beli", controller.PostStoreBeli)
+	r.GET("/api/store/pembelian", controller.GetStorePembelian)
+	r.PUT("/api/store/pembelian/refund/:id:objectid", controller.PutStoreRefund, rbac.Permit(rbac.KelolaEvent))
+	r.PUT("/api/store/pembelian/selesai/:id:objectid", controller.PutStoreSelesai, rbac.Permit(rbac.KelolaEvent))
+	// Delete endpoints for owner
+	r.DELETE("/api/event/delete/:eventid:objectid", controller.DeleteEvent, rbac.Permit(rbac.KelolaEvent))
+	r.DELETE("/api/event/claim/delete/:claimid:objectid", controller.DeleteEventClaim, rbac.Permit(rbac.KelolaEvent))
 	// Get all claims for owner
-	case method == "GET" && path == "/api/event/allclaims":
-		controller.GetAllEventClaims(w, r)
+	r.GET("/api/event/allclaims", controller.GetAllEventClaims, rbac.Permit(rbac.KelolaEvent))
 	// Get all events for owner (management)
-	case method == "GET" && path == "/api/event/allevents":
-		controller.GetAllEventsForOwner(w, r)
-		//cb yg baru pengajuan
-	case method == "GET" && path == "/api/bimbingan/eligibility":
-		controller.CheckSidangEligibility(w, r)
+	r.GET("/api/event/allevents", controller.GetAllEventsForOwner, rbac.Permit(rbac.KelolaEvent))
+	//cb yg baru pengajuan
+	r.GET("/api/bimbingan/eligibility", controller.CheckSidangEligibility)
 	// Tugas Mingguan Kelas
 	// case method == "GET" && path == "/dataenroll/proyek":
 	// 	controller.GetProjectData(w, r)
@@ -404,27 +287,43 @@ func URL(w http.ResponseWriter, r *http.Request) {
 	// case method == "GET" && path == "/refresh/laporan/riwayat/bimbingan/per/week":
 	// 	controller.LaporanRiwayatBimbinganPerMinggu(w, r)
 	// 	controller.LaporanBelumBimbingan(w, r)
-	case method == "GET" && path == "/dataenroll/belumbimbingan":
-		controller.LaporanBelumBimbingan(w, r)
+	r.GET("/dataenroll/belumbimbingan", controller.LaporanBelumBimbingan)
 	// kelas ws
-	case method == "POST" && path == "/data/tugaskelasws":
-		controller.PostTugasKelasWS(w, r)
-	case method == "GET" && path == "/data/tugaskelasws/weekly":
-		controller.GetLastWeekScoreKelasWS(w, r)
-	case method == "GET" && path == "/data/tugaskelasws":
-		controller.GetDataTugasWS(w, r)
-	case method == "GET" && at.URLParam(path, "/data/tugaskelasws/:id"):
-		controller.GetDataTugasWSById(w, r)
+	r.POST("/data/tugaskelasws", controller.PostTugasKelasWS)
+	r.GET("/data/tugaskelasws/weekly", controller.GetLastWeekScoreKelasWS)
+	r.GET("/data/tugaskelasws", controller.GetDataTugasWS)
+	r.GET("/data/tugaskelasws/:id:objectid", controller.GetDataTugasWSById)
 	// kelas ai
-	case method == "POST" && path == "/data/tugaskelasai":
-		controller.PostTugasKelasAI(w, r)
-	case method == "GET" && path == "/data/tugaskelasai/weekly":
-		controller.GetLastWeekScoreKelasAI(w, r)
-	case method == "GET" && path == "/data/tugaskelasai":
-		controller.GetDataTugasAI(w, r)
-	case method == "GET" && at.URLParam(path, "/data/tugaskelasai/:id"):
-		controller.GetDataTugasAIById(w, r)
-	default:
-		controller.NotFound(w, r)
+	r.POST("/data/tugaskelasai", controller.PostTugasKelasAI)
+	r.GET("/data/tugaskelasai/weekly", controller.GetLastWeekScoreKelasAI)
+	r.GET("/data/tugaskelasai", controller.GetDataTugasAI)
+	r.GET("/data/tugaskelasai/:id:objectid", controller.GetDataTugasAIById)
+
+	// mode dev: pesan WA dan request keluar yang ditahan server tiruan, token login untuk user fixture
+	if config.Dev {
+		r.GET("/dev/wa", devmode.GetWAMessages)
+		r.GET("/dev/calls", devmode.GetCalls)
+		r.DELETE("/dev/calls", devmode.DeleteCalls)
+		r.GET("/dev/token/:phonenumber", devmode.GetToken)
+	}
+
+	// dokumentasi API dibangkitkan dari tabel route ini dan struct model
+	r.GET("/openapi.json", openapi.Handler(r.Routes, openapi.Operations))
+	if err := openapi.Check(r.Routes(), openapi.Operations); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// setEnv memuat ulang konfigurasi environment di setiap request
+func setEnv(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config.SetEnv()
+		next(w, r)
 	}
 }
+
+// enrollParam adalah scope role untuk route yang memakai kode enroll di path
+func enrollParam(r *http.Request) string {
+	return router.Param(r, "enroll")
+}
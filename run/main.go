@@ -3,10 +3,16 @@ package main
 import (
 	"net/http"
 
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/devmode"
 	"github.com/gocroot/route"
 )
 
 func main() {
+	//DEVMODE=1 go run ./run menjalankan backend tanpa layanan luar, lihat README
+	if config.Dev {
+		devmode.Start()
+	}
 	http.HandleFunc("/", route.URL)
 	http.ListenAndServe(":8080", nil)
 }